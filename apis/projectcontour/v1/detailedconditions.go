@@ -148,6 +148,11 @@ const (
 	// inclusion of another HTTPProxy resource.
 	ConditionTypeIncludeError = "IncludeError"
 
+	// ConditionTypeIngressPolicyError describes an error condition
+	// with an HTTPProxy resource whose namespace has no IngressPolicy
+	// that allows it to be programmed.
+	ConditionTypeIngressPolicyError = "IngressPolicyError"
+
 	// ConditionTypeOrphanedError describes an error condition
 	// with an HTTPProxy resource which is not part of a delegation chain.
 	ConditionTypeOrphanedError = "Orphaned"
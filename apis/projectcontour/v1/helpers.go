@@ -51,6 +51,12 @@ func (v *VirtualHost) AuthorizationContext() map[string]string {
 	return nil
 }
 
+// OIDCConfigured returns whether OpenID Connect login is
+// configured on this virtual host.
+func (v *VirtualHost) OIDCConfigured() bool {
+	return v.TLS != nil && v.OIDCPolicy != nil
+}
+
 // GetPrefixReplacements returns replacement prefixes from the path
 // rewrite policy (if any).
 func (r *Route) GetPrefixReplacements() []ReplacePrefix {
@@ -60,6 +66,12 @@ func (r *Route) GetPrefixReplacements() []ReplacePrefix {
 	return nil
 }
 
+// GetStripPrefix returns whether the routing prefix is stripped
+// from the path of requests matching this route.
+func (r *Route) GetStripPrefix() bool {
+	return r.PathRewritePolicy != nil && r.PathRewritePolicy.StripPrefix
+}
+
 // AuthorizationContext merges the parent context entries with the
 // context from this Route. Common keys from the parent map will be
 // overwritten by keys from the route. The parent map may be nil.
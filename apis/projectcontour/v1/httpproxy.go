@@ -61,6 +61,15 @@ type MatchCondition struct {
 	// +optional
 	Prefix string `json:"prefix,omitempty"`
 
+	// NotPrefix excludes the requests whose path starts with the
+	// prefix of the route followed by NotPrefix from the route. The
+	// excluded requests are answered with 404 Not Found, unless another
+	// route of the virtual host matches exactly the same requests.
+	// NotPrefix is only supported on the conditions of routes, not
+	// on those of includes.
+	// +optional
+	NotPrefix string `json:"notPrefix,omitempty"`
+
 	// Header specifies the header condition to match.
 	// +optional
 	Header *HeaderMatchCondition `json:"header,omitempty"`
@@ -105,6 +114,17 @@ type HeaderMatchCondition struct {
 	// equal to. The condition is true if the header has any other value.
 	// +optional
 	NotExact string `json:"notexact,omitempty"`
+
+	// Regex specifies a regular expression pattern that the header
+	// value must match. The pattern must match the whole value.
+	// +optional
+	Regex string `json:"regex,omitempty"`
+
+	// IgnoreCase specifies that the exact, notexact, contains or
+	// notcontains value is compared to the header value without
+	// regard to case.
+	// +optional
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
 }
 
 // ExtensionServiceReference names an ExtensionService resource.
@@ -152,10 +172,10 @@ type AuthorizationServer struct {
 	// ResponseTimeout configures maximum time to wait for a check response from the authorization server.
 	// Timeout durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
 	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
-	// The string "infinity" is also a valid input and specifies no timeout.
+	// The strings "infinity" and "disabled" are also valid inputs and specify no timeout.
 	//
 	// +optional
-	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite|disabled)$`
 	ResponseTimeout string `json:"responseTimeout,omitempty"`
 
 	// If FailOpen is true, the client request is forwarded to the upstream service
@@ -185,6 +205,69 @@ type AuthorizationPolicy struct {
 	Context map[string]string `json:"context,omitempty"`
 }
 
+// OIDCPolicy configures an OpenID Connect provider that users are
+// redirected to when they access the virtual host without a valid
+// session. Session tokens are stored in browser cookies signed with
+// an HMAC secret.
+type OIDCPolicy struct {
+	// AuthorizationEndpoint is the URL that users are redirected to
+	// in order to log in, as published in the "authorization_endpoint"
+	// field of the provider's discovery document.
+	//
+	// +kubebuilder:validation:Pattern=`^https://`
+	AuthorizationEndpoint string `json:"authorizationEndpoint"`
+
+	// TokenEndpoint is the URL that Envoy exchanges authorization
+	// codes for access tokens with, as published in the
+	// "token_endpoint" field of the provider's discovery document.
+	//
+	// +kubebuilder:validation:Pattern=`^https://`
+	TokenEndpoint string `json:"tokenEndpoint"`
+
+	// Scopes are the OAuth2 scopes that are requested from the
+	// OpenID Connect provider. The "openid" scope is always
+	// requested, and is the only one requested by default.
+	//
+	// +optional
+	Scopes []string `json:"scopes,omitempty"`
+
+	// ClientCredentialsSecret is the name of a Secret in the same
+	// namespace as the HTTPProxy. The Secret must contain the
+	// "client-id" and "client-secret" keys. It may also contain a
+	// "hmac-secret" key of at least 32 bytes that is used to sign
+	// the session cookies.
+	// If the "hmac-secret" key is not present, an HMAC secret is
+	// derived from the client secret.
+	ClientCredentialsSecret string `json:"clientCredentialsSecret"`
+
+	// RedirectPath is the path of the callback that the OpenID
+	// Connect provider redirects users to after logging in.
+	// Defaults to "/oauth2/callback".
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/`
+	RedirectPath string `json:"redirectPath,omitempty"`
+
+	// SignoutPath is the path that clears the session cookies.
+	// Defaults to "/oauth2/signout".
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/`
+	SignoutPath string `json:"signoutPath,omitempty"`
+
+	// ForwardBearerToken specifies whether the access token is
+	// forwarded to upstream services in the Authorization header.
+	//
+	// +optional
+	ForwardBearerToken bool `json:"forwardBearerToken,omitempty"`
+
+	// UpstreamValidation defines how to verify the certificate of
+	// the token endpoint.
+	//
+	// +optional
+	UpstreamValidation *UpstreamValidation `json:"validation,omitempty"`
+}
+
 // VirtualHost appears at most once. If it is present, the object is considered
 // to be a "root".
 type VirtualHost struct {
@@ -209,12 +292,294 @@ type VirtualHost struct {
 	//
 	// +optional
 	Authorization *AuthorizationServer `json:"authorization,omitempty"`
+	// OIDCPolicy configures browser based OpenID Connect login for
+	// this virtual host. OIDC login can only be configured on virtual
+	// hosts that have TLS enabled.
+	//
+	// +optional
+	OIDCPolicy *OIDCPolicy `json:"oidcPolicy,omitempty"`
+	// MaxConnectionDuration is the maximum time a client connection to
+	// this virtual host may remain open, regardless of activity, after
+	// which Envoy drains and closes it. This bounds long-lived websocket,
+	// gRPC and TCP proxied connections. It overrides the globally
+	// configured maximum connection duration, and is only supported
+	// for virtual hosts that have TLS enabled.
+	// Timeout durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	// The strings "infinity" and "disabled" are also valid inputs and specify no maximum.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite|disabled)$`
+	MaxConnectionDuration string `json:"maxConnectionDuration,omitempty"`
+	// RequestHeadersLimits overrides the globally configured limits
+	// on the size and number of request headers accepted by this
+	// virtual host. It is only supported for virtual hosts that have
+	// TLS enabled.
+	//
+	// +optional
+	RequestHeadersLimits *RequestHeadersLimits `json:"requestHeadersLimits,omitempty"`
+	// PathNormalization replaces the globally configured request path
+	// normalization for this virtual host. Path normalization keeps
+	// requests such as "/public/../admin" or "//admin" from bypassing
+	// path based routing and authorization rules, so it should only be
+	// disabled for applications that rely on the raw request path.
+	// It is only supported for virtual hosts that have TLS enabled.
+	//
+	// +optional
+	PathNormalization *PathNormalizationPolicy `json:"pathNormalization,omitempty"`
+	// HTTP10Policy configures how this virtual host handles HTTP/1.0
+	// requests. It is only supported for virtual hosts that have TLS
+	// enabled.
+	//
+	// +optional
+	HTTP10Policy *HTTP10Policy `json:"http10Policy,omitempty"`
+	// SubdomainDelegations delegates subdomains of this virtual host to
+	// other namespaces. HTTPProxies in a delegated namespace may define
+	// their own virtual hosts within the delegated domain, even if the
+	// namespace is not a root namespace. Only HTTPProxies in root
+	// namespaces may delegate subdomains.
+	//
+	// +optional
+	SubdomainDelegations []SubdomainDelegation `json:"subdomainDelegations,omitempty"`
 	// Specifies the cross-origin policy to apply to the VirtualHost.
 	// +optional
 	CORSPolicy *CORSPolicy `json:"corsPolicy,omitempty"`
 	// The policy for rate limiting on the virtual host.
 	// +optional
 	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// Maintenance takes this virtual host down for maintenance.
+	// Envoy answers every request to the virtual host itself with a
+	// 503 Service Unavailable response and a Retry-After header,
+	// instead of forwarding it to the route's services. The routes
+	// are still validated, so the virtual host is restored as it was
+	// when maintenance is turned off.
+	//
+	// +optional
+	Maintenance bool `json:"maintenance,omitempty"`
+	// MaintenancePolicy customizes the response to requests while
+	// maintenance is enabled. It has no effect unless maintenance
+	// is true.
+	//
+	// +optional
+	MaintenancePolicy *MaintenancePolicy `json:"maintenancePolicy,omitempty"`
+	// ConcurrencyPolicy enables admission control for this virtual
+	// host, to protect its services while they are struggling. Envoy
+	// has no per-route admission control configuration, so it is set
+	// on the filter chain of the virtual host, and is only supported
+	// for virtual hosts that have TLS enabled.
+	//
+	// +optional
+	ConcurrencyPolicy *ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	// AccessLogHeaders replaces the globally configured request and
+	// response headers whose values are captured in the access log
+	// of this virtual host. It is only supported for virtual hosts
+	// that have TLS enabled.
+	//
+	// +optional
+	AccessLogHeaders *AccessLogHeaders `json:"accessLogHeaders,omitempty"`
+	// ForwardProxy turns this virtual host into an egress forward
+	// proxy. Rather than routing to services, Envoy resolves the
+	// upstream host from the Host header of each request, and only
+	// accepts requests whose Host is one of the allowed domains.
+	// A forward proxy cannot have routes, includes, a tcpproxy or TLS.
+	//
+	// +optional
+	ForwardProxy *ForwardProxyPolicy `json:"forwardProxy,omitempty"`
+	// ServerHeader overrides the globally configured Server header
+	// of the responses of this virtual host. It is only supported
+	// for virtual hosts that have TLS enabled.
+	//
+	// +optional
+	ServerHeader *ServerHeaderPolicy `json:"serverHeader,omitempty"`
+}
+
+// ServerHeaderPolicy configures the Server header of the responses
+// that Envoy sends. Fields that are not set keep their globally
+// configured values.
+type ServerHeaderPolicy struct {
+	// Name is the value of the Server header that Envoy sets.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[ -~]*$`
+	Name string `json:"name,omitempty"`
+	// Transformation is how the Server header of upstream responses
+	// is handled. "overwrite" replaces it with Name, "append-if-absent"
+	// only sets it to Name if the upstream did not set one, and
+	// "pass-through" never sets it.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=overwrite;append-if-absent;pass-through
+	Transformation string `json:"transformation,omitempty"`
+}
+
+// ForwardProxyPolicy configures a virtual host that forwards requests
+// to the upstream host named by their Host header.
+type ForwardProxyPolicy struct {
+	// AllowedDomains lists the domains that requests may be forwarded
+	// to. Each entry is either a hostname, or a wildcard such as
+	// "*.example.com" that matches any subdomain of example.com.
+	// Requests for other domains are not matched by this virtual host.
+	//
+	// +kubebuilder:validation:MinItems=1
+	AllowedDomains []string `json:"allowedDomains"`
+}
+
+// AccessLogHeaders lists the request and response headers whose
+// values are captured in access logs.
+type AccessLogHeaders struct {
+	// Request lists the names of the request headers to capture.
+	//
+	// +optional
+	Request []string `json:"request,omitempty"`
+	// Response lists the names of the response headers to capture.
+	//
+	// +optional
+	Response []string `json:"response,omitempty"`
+}
+
+// ConcurrencyPolicy configures admission control. When the success
+// rate of the requests to a virtual host drops below a threshold,
+// Envoy rejects a growing fraction of new requests with a 503
+// response, rather than forwarding them to services that are
+// already failing. Responses with a 5xx status, and gRPC responses
+// with an error status that indicates an overloaded service, count
+// as failures.
+type ConcurrencyPolicy struct {
+	// SuccessRateThreshold is the percentage of requests in the
+	// sampling window that must succeed before Envoy starts to reject
+	// requests. If not set, 95 is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	SuccessRateThreshold uint32 `json:"successRateThreshold,omitempty"`
+	// SamplingWindow is the sliding time window over which the
+	// success rate is calculated. It must be at least 1s. If not
+	// set, 30s is used.
+	// Durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	SamplingWindow string `json:"samplingWindow,omitempty"`
+}
+
+// MaintenancePolicy customizes the response to requests for a virtual
+// host that is down for maintenance. At most one of body and redirect
+// may be specified.
+type MaintenancePolicy struct {
+	// RetryAfter is the time clients are asked to wait before retrying,
+	// sent in the Retry-After header, rounded up to whole seconds.
+	// If not set, clients are asked to retry after five minutes.
+	// Timeout durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	RetryAfter string `json:"retryAfter,omitempty"`
+	// Body is the body of the 503 Service Unavailable response,
+	// such as a maintenance page.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	Body string `json:"body,omitempty"`
+	// Redirect redirects requests elsewhere, such as to a status
+	// page, instead of responding with 503 Service Unavailable.
+	//
+	// +optional
+	Redirect *MaintenanceRedirect `json:"redirect,omitempty"`
+}
+
+// MaintenanceRedirect redirects requests for a virtual host that is
+// down for maintenance. Parts of the request URL that are not set are
+// left as they are.
+type MaintenanceRedirect struct {
+	// Hostname is the hostname requests are redirected to.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Hostname string `json:"hostname,omitempty"`
+	// Path is the path requests are redirected to.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^/.*$`
+	Path string `json:"path,omitempty"`
+	// StatusCode is the HTTP status code of the redirect.
+	// Valid values are 301 and 302. If not set, 302 is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=301;302
+	StatusCode int `json:"statusCode,omitempty"`
+}
+
+// RequestHeadersLimits sets limits on the request headers that Envoy
+// accepts. Requests that exceed them are rejected with a 431 status.
+type RequestHeadersLimits struct {
+	// MaxSizeKB is the maximum total size of the request headers
+	// in KiB. If not set, Envoy's default of 60 is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=96
+	MaxSizeKB uint32 `json:"maxSizeKB,omitempty"`
+	// MaxCount is the maximum number of request headers.
+	// If not set, Envoy's default of 100 is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxCount uint32 `json:"maxCount,omitempty"`
+}
+
+// HTTP10Policy configures the handling of HTTP/1.0 requests.
+type HTTP10Policy struct {
+	// Disabled rejects HTTP/1.0 requests, which are otherwise
+	// accepted as long as they carry a Host header.
+	//
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// DefaultHost routes HTTP/1.0 requests that do not carry a Host
+	// header to this virtual host, instead of rejecting them.
+	// It cannot be combined with disabled.
+	//
+	// +optional
+	DefaultHost bool `json:"defaultHost,omitempty"`
+}
+
+// SubdomainDelegation delegates the subdomains of a domain to a namespace.
+type SubdomainDelegation struct {
+	// Domain is a wildcard domain, such as "*.team-a.example.com", whose
+	// subdomains are delegated. It must be within the fqdn of the
+	// delegating virtual host.
+	//
+	// +kubebuilder:validation:Pattern=`^\*\.[^*]+$`
+	Domain string `json:"domain"`
+	// Namespace is the namespace whose HTTPProxies may define virtual
+	// hosts within the delegated domain.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+}
+
+// PathNormalizationPolicy controls how Envoy normalizes request paths
+// before matching them against routes.
+type PathNormalizationPolicy struct {
+	// DisableNormalizePath disables the RFC 3986 normalization of
+	// request paths, which resolves "." and ".." segments.
+	//
+	// +optional
+	DisableNormalizePath bool `json:"disableNormalizePath,omitempty"`
+	// DisableMergeSlashes disables merging adjacent slashes in
+	// request paths.
+	//
+	// +optional
+	DisableMergeSlashes bool `json:"disableMergeSlashes,omitempty"`
+	// EscapedSlashesAction is the action to take when a request path
+	// contains escaped slashes, "%2F" or "%5C". If not set, the globally
+	// configured action is used.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=keep-unchanged;reject;unescape-and-redirect;unescape-and-forward
+	EscapedSlashesAction string `json:"escapedSlashesAction,omitempty"`
 }
 
 // TLS describes tls properties. The SNI names that will be matched on
@@ -255,8 +620,21 @@ type TLS struct {
 	// EnableFallbackCertificate defines if the vhost should allow a default certificate to
 	// be applied which handles all requests which don't match the SNI defined in this vhost.
 	EnableFallbackCertificate bool `json:"enableFallbackCertificate,omitempty"`
+
+	// HTTPVersions overrides the globally configured HTTP versions
+	// that this vhost offers to clients with ALPN. Setting it to
+	// `http/1.1` stops clients that misbehave with HTTP/2 from
+	// negotiating it. Valid options are `http/1.1` and `http/2`.
+	// It can't be combined with Passthrough, and has no effect
+	// when the vhost proxies TCP.
+	// +optional
+	HTTPVersions []HTTPVersion `json:"httpVersions,omitempty"`
 }
 
+// HTTPVersion is an HTTP version that a vhost offers to clients.
+// +kubebuilder:validation:Enum=http/1.1;http/2
+type HTTPVersion string
+
 // CORSHeaderValue specifies the value of the string headers returned by a cross-domain request.
 // +kubebuilder:validation:Pattern="^[a-zA-Z0-9!#$%&'*+.^_`|~-]+$"
 type CORSHeaderValue string
@@ -304,6 +682,22 @@ type Route struct {
 	// Enables websocket support for the route.
 	// +optional
 	EnableWebsockets bool `json:"enableWebsockets,omitempty"`
+	// The idle timeout for a route with websockets enabled. It replaces
+	// the route's timeoutPolicy.idle, so long-lived websocket connections
+	// can be given a longer idle timeout than other routes on the virtual
+	// host. Requires enableWebsockets.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite|disabled)$`
+	WebsocketIdleTimeout string `json:"websocketIdleTimeout,omitempty"`
+	// Enables case-insensitive matching of the request path against
+	// the route's prefix conditions.
+	// +optional
+	IgnorePathCase bool `json:"ignorePathCase,omitempty"`
+	// Enables the propagation of trailers to and from HTTP/1 upstream
+	// services. Trailers are always propagated for services using the
+	// h2 or h2c protocols.
+	// +optional
+	EnableTrailers bool `json:"enableTrailers,omitempty"`
 	// Allow this path to respond to insecure requests over HTTP which are normally
 	// not permitted when a `virtualhost.tls` block is present.
 	// +optional
@@ -340,6 +734,68 @@ type Route struct {
 	// The policy for rate limiting on the route.
 	// +optional
 	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// The policy for accepting requests on the route based on
+	// their method and headers.
+	// +optional
+	RBACPolicy *RBACPolicy `json:"rbacPolicy,omitempty"`
+	// ActiveWindow restricts the times at which this route is
+	// programmed in Envoy. Outside of the window, requests are
+	// handled as though the route did not exist.
+	//
+	// +optional
+	ActiveWindow *ActiveWindow `json:"activeWindow,omitempty"`
+}
+
+// ActiveWindow defines when a route is active, as an interval
+// between a start and an end time, a recurring schedule, or both,
+// in which case the route is active when the schedule is active
+// within the interval.
+type ActiveWindow struct {
+	// Start is the time at which the route becomes active,
+	// in RFC3339 format, such as "2021-11-26T00:00:00Z".
+	// If not set, the route is active until End.
+	//
+	// +optional
+	Start string `json:"start,omitempty"`
+	// End is the time at which the route stops being active,
+	// in RFC3339 format. If not set, the route is active from
+	// Start onwards.
+	//
+	// +optional
+	End string `json:"end,omitempty"`
+	// Schedule is a cron schedule, such as "0 9 * * 1-5", of the
+	// times at which the route becomes active, evaluated in UTC. It
+	// has five fields: minute, hour, day of month, month and day of
+	// week. The route stays active for Duration after each time
+	// the schedule matches.
+	//
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// Duration is how long the route stays active each time
+	// Schedule matches. It is required if Schedule is set.
+	// Durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	Duration string `json:"duration,omitempty"`
+}
+
+// RBACPolicy defines the requests that a route accepts, without
+// the use of an external authorization service. A request is
+// accepted if its method is one of Methods and it has each of
+// Headers with the given value. Other requests are denied with
+// a 403 (Forbidden) response.
+type RBACPolicy struct {
+	// Methods lists the HTTP methods, such as "GET", that are
+	// accepted. If empty, requests of any method are accepted.
+	// +optional
+	Methods []string `json:"methods,omitempty"`
+
+	// Headers lists the headers that requests must have, such as
+	// "x-internal: true". Values are matched exactly.
+	// +optional
+	Headers []HeaderValue `json:"headers,omitempty"`
 }
 
 // RateLimitPolicy defines rate limiting parameters.
@@ -513,6 +969,12 @@ type TCPProxy struct {
 	// The health check policy for this tcp proxy
 	// +optional
 	HealthCheckPolicy *TCPHealthCheckPolicy `json:"healthCheckPolicy,omitempty"`
+	// AllowedSourceRanges restricts the client addresses that may connect
+	// to this tcp proxy. Each entry is a CIDR block such as 10.0.0.0/8 or
+	// 2001:db8::/32. Connections from addresses outside of these ranges
+	// are closed. If empty, connections from any address are accepted.
+	// +optional
+	AllowedSourceRanges []string `json:"allowedSourceRanges,omitempty"`
 }
 
 // TCPProxyInclude describes a target HTTPProxy document which contains the TCPProxy details.
@@ -538,19 +1000,65 @@ type Service struct {
 	// +kubebuilder:validation:ExclusiveMaximum=true
 	Port int `json:"port"`
 	// Protocol may be used to specify (or override) the protocol used to reach this Service.
-	// Values may be tls, h2, h2c. If omitted, protocol-selection falls back on Service annotations.
-	// +kubebuilder:validation:Enum=h2;h2c;tls
+	// Values may be tls, h2, h2c or http/1.1. The http/1.1 value selects plaintext HTTP/1.1,
+	// overriding the Service's annotations and appProtocol.
+	// If omitted, protocol-selection falls back on Service annotations.
+	// +kubebuilder:validation:Enum=h2;h2c;tls;http/1.1
 	// +optional
 	Protocol *string `json:"protocol,omitempty"`
 	// Weight defines percentage of traffic to balance traffic
 	// +optional
 	// +kubebuilder:validation:Minimum=0
 	Weight int64 `json:"weight,omitempty"`
+	// Priority defines the failover order of the service within its
+	// route. Traffic is sent to the route's single service with the
+	// default priority of 0, and fails over to services with higher
+	// priority values when its endpoints are unavailable. Weights
+	// balance traffic between failover services of the same priority.
+	// Failover services are reached using the protocol and TLS settings
+	// of the priority 0 service. Priorities are not supported for
+	// mirror or ExternalName services, or in TCP proxies.
+	// +optional
+	Priority uint32 `json:"priority,omitempty"`
 	// UpstreamValidation defines how to verify the backend service's certificate
 	// +optional
 	UpstreamValidation *UpstreamValidation `json:"validation,omitempty"`
+	// UpstreamTLS configures the TLS protocol versions and cipher suites
+	// used to connect to the backend service. It only applies when the
+	// protocol used to reach the service is tls or h2.
+	// +optional
+	UpstreamTLS *UpstreamTLS `json:"upstreamTLS,omitempty"`
+	// SNI is the server name presented to the backend service when it
+	// is reached over TLS. It takes precedence over the SNI derived from
+	// the Host header rewrite policy or the ExternalName of the service.
+	// If validation is also specified, the SNI should match its
+	// subjectName.
+	// +optional
+	SNI string `json:"sni,omitempty"`
 	// If Mirror is true the Service will receive a read only mirror of the traffic for this route.
 	Mirror bool `json:"mirror,omitempty"`
+	// DNSLookupFamily overrides the configured DNS lookup family used
+	// to resolve the address of an ExternalName Service.
+	// Values may be auto, v4, v6 or all. The Envoy version that
+	// Contour supports has no all family, so auto is used for it.
+	// +kubebuilder:validation:Enum=auto;v4;v6;all
+	// +optional
+	DNSLookupFamily string `json:"dnsLookupFamily,omitempty"`
+	// HealthyPanicThreshold overrides the configured percentage of
+	// healthy endpoints below which Envoy ignores the health of the
+	// endpoints of this Service and balances requests across all of
+	// them. 0 disables panic mode.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	HealthyPanicThreshold *uint32 `json:"healthyPanicThreshold,omitempty"`
+	// MaxRequestsPerConnection is the maximum number of requests
+	// Envoy sends over a single connection to this Service before
+	// closing it. It overrides the projectcontour.io/max-requests-per-connection
+	// annotation of the Service and the configured default.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxRequestsPerConnection *uint32 `json:"maxRequestsPerConnection,omitempty"`
 	// The policy for managing request headers during proxying.
 	// Rewriting the 'Host' header is not supported.
 	// +optional
@@ -605,7 +1113,7 @@ type TCPHealthCheckPolicy struct {
 //
 // TimeoutPolicy durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
 // Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
-// The string "infinity" is also a valid input and specifies no timeout.
+// The strings "infinity" and "disabled" are also valid inputs and specify no timeout.
 // A value of "0s" will be treated as if the field were not set, i.e. by using Envoy's default behavior.
 //
 // Example input values: "300ms", "5s", "1m".
@@ -613,7 +1121,7 @@ type TimeoutPolicy struct {
 	// Timeout for receiving a response from the server after processing a request from client.
 	// If not supplied, Envoy's default value of 15s applies.
 	// +optional
-	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite|disabled)$`
 	Response string `json:"response,omitempty"`
 
 	// Timeout after which, if there are no active requests for this route, the connection between
@@ -621,7 +1129,7 @@ type TimeoutPolicy struct {
 	// If not specified, there is no per-route idle timeout, though a connection manager-wide
 	// stream_idle_timeout default of 5m still applies.
 	// +optional
-	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite|disabled)$`
 	Idle string `json:"idle,omitempty"`
 }
 
@@ -639,7 +1147,7 @@ type RetryPolicy struct {
 	// PerTryTimeout specifies the timeout per retry attempt.
 	// Ignored if NumRetries is not supplied.
 	// +optional
-	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite|disabled)$`
 	PerTryTimeout string `json:"perTryTimeout,omitempty"`
 	// RetryOn specifies the conditions on which to retry a request.
 	//
@@ -706,6 +1214,15 @@ type PathRewritePolicy struct {
 	// ReplacePrefix describes how the path prefix should be replaced.
 	// +optional
 	ReplacePrefix []ReplacePrefix `json:"replacePrefix,omitempty"`
+
+	// StripPrefix removes the routing prefix rendered by the include
+	// chain from the path, along with any slashes that follow it, so
+	// that a request for <prefix>/v1 is sent as /v1, and requests for
+	// <prefix> and <prefix>/ are sent as /. Paths in which the prefix
+	// is not followed by a slash, such as <prefix>sball, are sent
+	// unmodified.
+	// +optional
+	StripPrefix bool `json:"stripPrefix,omitempty"`
 }
 
 // HeaderHashOptions contains options to configure a HTTP request header hash
@@ -783,8 +1300,43 @@ type HeaderValue struct {
 type UpstreamValidation struct {
 	// Name of the Kubernetes secret be used to validate the certificate presented by the backend
 	CACertificate string `json:"caSecret"`
-	// Key which is expected to be present in the 'subjectAltName' of the presented certificate
-	SubjectName string `json:"subjectName"`
+	// Names of additional Kubernetes secrets whose CA certificates are trusted along
+	// with those of caSecret, for example while the backend's certificate authority
+	// is rotated.
+	// +optional
+	AdditionalCACertificates []string `json:"additionalCASecrets,omitempty"`
+	// Key which is expected to be present in the 'subjectAltName' of the presented certificate.
+	// Exactly one of subjectName and spiffeID must be specified.
+	// +optional
+	SubjectName string `json:"subjectName,omitempty"`
+	// SpiffeID is the SPIFFE ID, such as spiffe://example.org/ns/default/sa/backend,
+	// which is expected to be present as a URI 'subjectAltName' of the presented
+	// certificate. Exactly one of subjectName and spiffeID must be specified.
+	// +kubebuilder:validation:Pattern=`^spiffe://`
+	// +optional
+	SpiffeID string `json:"spiffeID,omitempty"`
+}
+
+// UpstreamTLS defines the TLS parameters used to connect to the backend service.
+type UpstreamTLS struct {
+	// MinimumProtocolVersion is the minimum TLS version to negotiate
+	// with the backend service. Valid options are `1.2` and `1.3`.
+	// If omitted, Envoy's default of 1.2 is used.
+	// +kubebuilder:validation:Enum="1.2";"1.3"
+	// +optional
+	MinimumProtocolVersion string `json:"minimumProtocolVersion,omitempty"`
+	// MaximumProtocolVersion is the maximum TLS version to negotiate
+	// with the backend service. Valid options are `1.2` and `1.3`.
+	// If omitted, Envoy's default of 1.2 is used, unless the minimum
+	// version is 1.3.
+	// +kubebuilder:validation:Enum="1.2";"1.3"
+	// +optional
+	MaximumProtocolVersion string `json:"maximumProtocolVersion,omitempty"`
+	// CipherSuites is the list of cipher suites to offer when
+	// negotiating TLS 1.2 with the backend service. If omitted,
+	// Envoy's default cipher suites are used.
+	// +optional
+	CipherSuites []string `json:"cipherSuites,omitempty"`
 }
 
 // DownstreamValidation defines how to verify the client certificate.
@@ -807,6 +1359,26 @@ type DownstreamValidation struct {
 	// presented to the external authorization server.
 	// +optional
 	SkipClientCertValidation bool `json:"skipClientCertValidation"`
+
+	// AllowedSubjectAltNames restricts the client certificates that are
+	// accepted to those with a subject alternative name matching at least
+	// one of the entries. Cannot be combined with SkipClientCertValidation.
+	// +optional
+	AllowedSubjectAltNames []SubjectAltNameMatch `json:"allowedSubjectAltNames,omitempty"`
+}
+
+// SubjectAltNameMatch matches a subject alternative name of a client
+// certificate. Exactly one of Exact or Suffix must be specified.
+type SubjectAltNameMatch struct {
+	// Exact specifies a string that the subject alternative name must be
+	// equal to.
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// Suffix specifies a string that the subject alternative name must
+	// end with, for example ".example.com".
+	// +optional
+	Suffix string `json:"suffix,omitempty"`
 }
 
 // HTTPProxyStatus reports the current state of the HTTPProxy.
@@ -836,6 +1408,25 @@ type HTTPProxyStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []DetailedCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// +optional
+	// EffectiveRoutes lists the match conditions that the routes of
+	// this HTTPProxy are served with, after merging the conditions
+	// of the includes that lead to it from each root HTTPProxy.
+	EffectiveRoutes []EffectiveRoute `json:"effectiveRoutes,omitempty"`
+}
+
+// EffectiveRoute describes the fully resolved match conditions of
+// a route, as served by the virtual host of a root HTTPProxy.
+type EffectiveRoute struct {
+	// Fqdn is the fully qualified domain name of the root
+	// HTTPProxy that serves the route.
+	Fqdn string `json:"fqdn"`
+	// Index is the index of the route in spec.routes.
+	Index int `json:"index"`
+	// Conditions are the conditions of the route, merged with
+	// the conditions of the includes that lead to it.
+	// +optional
+	Conditions []MatchCondition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -24,6 +24,46 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessLogHeaders) DeepCopyInto(out *AccessLogHeaders) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessLogHeaders.
+func (in *AccessLogHeaders) DeepCopy() *AccessLogHeaders {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessLogHeaders)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveWindow) DeepCopyInto(out *ActiveWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveWindow.
+func (in *ActiveWindow) DeepCopy() *ActiveWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthorizationPolicy) DeepCopyInto(out *AuthorizationPolicy) {
 	*out = *in
@@ -122,6 +162,21 @@ func (in *CertificateDelegation) DeepCopy() *CertificateDelegation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConcurrencyPolicy) DeepCopyInto(out *ConcurrencyPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConcurrencyPolicy.
+func (in *ConcurrencyPolicy) DeepCopy() *ConcurrencyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConcurrencyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DetailedCondition) DeepCopyInto(out *DetailedCondition) {
 	*out = *in
@@ -151,6 +206,11 @@ func (in *DetailedCondition) DeepCopy() *DetailedCondition {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DownstreamValidation) DeepCopyInto(out *DownstreamValidation) {
 	*out = *in
+	if in.AllowedSubjectAltNames != nil {
+		in, out := &in.AllowedSubjectAltNames, &out.AllowedSubjectAltNames
+		*out = make([]SubjectAltNameMatch, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownstreamValidation.
@@ -163,6 +223,28 @@ func (in *DownstreamValidation) DeepCopy() *DownstreamValidation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveRoute) DeepCopyInto(out *EffectiveRoute) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]MatchCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveRoute.
+func (in *EffectiveRoute) DeepCopy() *EffectiveRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtensionServiceReference) DeepCopyInto(out *ExtensionServiceReference) {
 	*out = *in
@@ -178,6 +260,26 @@ func (in *ExtensionServiceReference) DeepCopy() *ExtensionServiceReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForwardProxyPolicy) DeepCopyInto(out *ForwardProxyPolicy) {
+	*out = *in
+	if in.AllowedDomains != nil {
+		in, out := &in.AllowedDomains, &out.AllowedDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForwardProxyPolicy.
+func (in *ForwardProxyPolicy) DeepCopy() *ForwardProxyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ForwardProxyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GenericKeyDescriptor) DeepCopyInto(out *GenericKeyDescriptor) {
 	*out = *in
@@ -230,6 +332,21 @@ func (in *HTTPHealthCheckPolicy) DeepCopy() *HTTPHealthCheckPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTP10Policy) DeepCopyInto(out *HTTP10Policy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTP10Policy.
+func (in *HTTP10Policy) DeepCopy() *HTTP10Policy {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTP10Policy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPProxy) DeepCopyInto(out *HTTPProxy) {
 	*out = *in
@@ -339,6 +456,13 @@ func (in *HTTPProxyStatus) DeepCopyInto(out *HTTPProxyStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.EffectiveRoutes != nil {
+		in, out := &in.EffectiveRoutes, &out.EffectiveRoutes
+		*out = make([]EffectiveRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPProxyStatus.
@@ -485,6 +609,41 @@ func (in *LocalRateLimitPolicy) DeepCopy() *LocalRateLimitPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenancePolicy) DeepCopyInto(out *MaintenancePolicy) {
+	*out = *in
+	if in.Redirect != nil {
+		in, out := &in.Redirect, &out.Redirect
+		*out = new(MaintenanceRedirect)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenancePolicy.
+func (in *MaintenancePolicy) DeepCopy() *MaintenancePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenancePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceRedirect) DeepCopyInto(out *MaintenanceRedirect) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceRedirect.
+func (in *MaintenanceRedirect) DeepCopy() *MaintenanceRedirect {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceRedirect)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MatchCondition) DeepCopyInto(out *MatchCondition) {
 	*out = *in
@@ -505,6 +664,46 @@ func (in *MatchCondition) DeepCopy() *MatchCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCPolicy) DeepCopyInto(out *OIDCPolicy) {
+	*out = *in
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpstreamValidation != nil {
+		in, out := &in.UpstreamValidation, &out.UpstreamValidation
+		*out = new(UpstreamValidation)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCPolicy.
+func (in *OIDCPolicy) DeepCopy() *OIDCPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PathNormalizationPolicy) DeepCopyInto(out *PathNormalizationPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PathNormalizationPolicy.
+func (in *PathNormalizationPolicy) DeepCopy() *PathNormalizationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PathNormalizationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PathRewritePolicy) DeepCopyInto(out *PathRewritePolicy) {
 	*out = *in
@@ -525,6 +724,31 @@ func (in *PathRewritePolicy) DeepCopy() *PathRewritePolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBACPolicy) DeepCopyInto(out *RBACPolicy) {
+	*out = *in
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HeaderValue, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBACPolicy.
+func (in *RBACPolicy) DeepCopy() *RBACPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RBACPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RateLimitDescriptor) DeepCopyInto(out *RateLimitDescriptor) {
 	*out = *in
@@ -692,6 +916,21 @@ func (in *RequestHeaderValueMatchDescriptor) DeepCopy() *RequestHeaderValueMatch
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestHeadersLimits) DeepCopyInto(out *RequestHeadersLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestHeadersLimits.
+func (in *RequestHeadersLimits) DeepCopy() *RequestHeadersLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestHeadersLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
 	*out = *in
@@ -779,6 +1018,16 @@ func (in *Route) DeepCopyInto(out *Route) {
 		*out = new(RateLimitPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RBACPolicy != nil {
+		in, out := &in.RBACPolicy, &out.RBACPolicy
+		*out = new(RBACPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActiveWindow != nil {
+		in, out := &in.ActiveWindow, &out.ActiveWindow
+		*out = new(ActiveWindow)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
@@ -791,6 +1040,21 @@ func (in *Route) DeepCopy() *Route {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerHeaderPolicy) DeepCopyInto(out *ServerHeaderPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerHeaderPolicy.
+func (in *ServerHeaderPolicy) DeepCopy() *ServerHeaderPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerHeaderPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Service) DeepCopyInto(out *Service) {
 	*out = *in
@@ -802,6 +1066,21 @@ func (in *Service) DeepCopyInto(out *Service) {
 	if in.UpstreamValidation != nil {
 		in, out := &in.UpstreamValidation, &out.UpstreamValidation
 		*out = new(UpstreamValidation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpstreamTLS != nil {
+		in, out := &in.UpstreamTLS, &out.UpstreamTLS
+		*out = new(UpstreamTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthyPanicThreshold != nil {
+		in, out := &in.HealthyPanicThreshold, &out.HealthyPanicThreshold
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.MaxRequestsPerConnection != nil {
+		in, out := &in.MaxRequestsPerConnection, &out.MaxRequestsPerConnection
+		*out = new(uint32)
 		**out = **in
 	}
 	if in.RequestHeadersPolicy != nil {
@@ -841,6 +1120,36 @@ func (in *SubCondition) DeepCopy() *SubCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubdomainDelegation) DeepCopyInto(out *SubdomainDelegation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubdomainDelegation.
+func (in *SubdomainDelegation) DeepCopy() *SubdomainDelegation {
+	if in == nil {
+		return nil
+	}
+	out := new(SubdomainDelegation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubjectAltNameMatch) DeepCopyInto(out *SubjectAltNameMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubjectAltNameMatch.
+func (in *SubjectAltNameMatch) DeepCopy() *SubjectAltNameMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(SubjectAltNameMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TCPHealthCheckPolicy) DeepCopyInto(out *TCPHealthCheckPolicy) {
 	*out = *in
@@ -886,6 +1195,11 @@ func (in *TCPProxy) DeepCopyInto(out *TCPProxy) {
 		*out = new(TCPHealthCheckPolicy)
 		**out = **in
 	}
+	if in.AllowedSourceRanges != nil {
+		in, out := &in.AllowedSourceRanges, &out.AllowedSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPProxy.
@@ -919,7 +1233,12 @@ func (in *TLS) DeepCopyInto(out *TLS) {
 	if in.ClientValidation != nil {
 		in, out := &in.ClientValidation, &out.ClientValidation
 		*out = new(DownstreamValidation)
-		**out = **in
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPVersions != nil {
+		in, out := &in.HTTPVersions, &out.HTTPVersions
+		*out = make([]HTTPVersion, len(*in))
+		copy(*out, *in)
 	}
 }
 
@@ -1051,9 +1370,34 @@ func (in *TimeoutPolicy) DeepCopy() *TimeoutPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpstreamTLS) DeepCopyInto(out *UpstreamTLS) {
+	*out = *in
+	if in.CipherSuites != nil {
+		in, out := &in.CipherSuites, &out.CipherSuites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpstreamTLS.
+func (in *UpstreamTLS) DeepCopy() *UpstreamTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(UpstreamTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpstreamValidation) DeepCopyInto(out *UpstreamValidation) {
 	*out = *in
+	if in.AdditionalCACertificates != nil {
+		in, out := &in.AdditionalCACertificates, &out.AdditionalCACertificates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpstreamValidation.
@@ -1079,6 +1423,31 @@ func (in *VirtualHost) DeepCopyInto(out *VirtualHost) {
 		*out = new(AuthorizationServer)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OIDCPolicy != nil {
+		in, out := &in.OIDCPolicy, &out.OIDCPolicy
+		*out = new(OIDCPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestHeadersLimits != nil {
+		in, out := &in.RequestHeadersLimits, &out.RequestHeadersLimits
+		*out = new(RequestHeadersLimits)
+		**out = **in
+	}
+	if in.PathNormalization != nil {
+		in, out := &in.PathNormalization, &out.PathNormalization
+		*out = new(PathNormalizationPolicy)
+		**out = **in
+	}
+	if in.HTTP10Policy != nil {
+		in, out := &in.HTTP10Policy, &out.HTTP10Policy
+		*out = new(HTTP10Policy)
+		**out = **in
+	}
+	if in.SubdomainDelegations != nil {
+		in, out := &in.SubdomainDelegations, &out.SubdomainDelegations
+		*out = make([]SubdomainDelegation, len(*in))
+		copy(*out, *in)
+	}
 	if in.CORSPolicy != nil {
 		in, out := &in.CORSPolicy, &out.CORSPolicy
 		*out = new(CORSPolicy)
@@ -1089,6 +1458,31 @@ func (in *VirtualHost) DeepCopyInto(out *VirtualHost) {
 		*out = new(RateLimitPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MaintenancePolicy != nil {
+		in, out := &in.MaintenancePolicy, &out.MaintenancePolicy
+		*out = new(MaintenancePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConcurrencyPolicy != nil {
+		in, out := &in.ConcurrencyPolicy, &out.ConcurrencyPolicy
+		*out = new(ConcurrencyPolicy)
+		**out = **in
+	}
+	if in.AccessLogHeaders != nil {
+		in, out := &in.AccessLogHeaders, &out.AccessLogHeaders
+		*out = new(AccessLogHeaders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForwardProxy != nil {
+		in, out := &in.ForwardProxy, &out.ForwardProxy
+		*out = new(ForwardProxyPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServerHeader != nil {
+		in, out := &in.ServerHeader, &out.ServerHeader
+		*out = new(ServerHeaderPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualHost.
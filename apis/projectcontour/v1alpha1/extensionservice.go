@@ -56,6 +56,69 @@ type ExtensionServiceTarget struct {
 	//
 	// +optional
 	Weight uint32 `json:"weight,omitempty"`
+
+	// Priority defines the failover order of the Kubernetes Service.
+	// Traffic is sent to the Services with the lowest priority value
+	// that have healthy endpoints, and fails over to Services with
+	// higher priority values when they become unavailable. Weights
+	// balance traffic between Services of the same priority.
+	// Defaults to 0, the highest priority.
+	//
+	// +optional
+	Priority uint32 `json:"priority,omitempty"`
+}
+
+// CircuitBreakerPolicy defines the circuit breaker thresholds that
+// Envoy applies to the connections and requests it sends to an
+// ExtensionService. Unset or zero values use the Envoy defaults.
+type CircuitBreakerPolicy struct {
+	// MaxConnections is the maximum number of connections that
+	// Envoy will make to the extension service.
+	//
+	// +optional
+	MaxConnections uint32 `json:"maxConnections,omitempty"`
+
+	// MaxPendingRequests is the maximum number of pending requests
+	// that Envoy will allow to the extension service.
+	//
+	// +optional
+	MaxPendingRequests uint32 `json:"maxPendingRequests,omitempty"`
+
+	// MaxRequests is the maximum parallel requests that Envoy will
+	// make to the extension service.
+	//
+	// +optional
+	MaxRequests uint32 `json:"maxRequests,omitempty"`
+
+	// MaxRetries is the maximum number of parallel retries that
+	// Envoy will allow to the extension service.
+	//
+	// +optional
+	MaxRetries uint32 `json:"maxRetries,omitempty"`
+}
+
+// GRPCHealthCheckPolicy defines active health checks that use the
+// gRPC health checking protocol.
+type GRPCHealthCheckPolicy struct {
+	// ServiceName is the service name sent in the health check
+	// request. If empty, the health of the whole server is checked.
+	//
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+	// The interval (seconds) between health checks
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	IntervalSeconds int64 `json:"intervalSeconds,omitempty"`
+	// The time to wait (seconds) for a health check response
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+	// The number of unhealthy health checks required before a host is marked unhealthy
+	// +optional
+	UnhealthyThresholdCount uint32 `json:"unhealthyThresholdCount,omitempty"`
+	// The number of healthy health checks required before a host is marked healthy
+	// +optional
+	HealthyThresholdCount uint32 `json:"healthyThresholdCount,omitempty"`
 }
 
 // ExtensionServiceSpec defines the desired state of an ExtensionService resource.
@@ -67,6 +130,9 @@ type ExtensionServiceSpec struct {
 	// services.
 	// Otherwise, traffic is balanced proportionally to the
 	// Weight field in each entry.
+	// If priorities are specified, traffic only fails over to
+	// services with a lower priority (higher Priority value) when
+	// services with a higher priority are unavailable.
 	//
 	// +required
 	// +kubebuilder:validation:MinItems=1
@@ -90,11 +156,22 @@ type ExtensionServiceSpec struct {
 	// +optional
 	LoadBalancerPolicy *contour_api_v1.LoadBalancerPolicy `json:"loadBalancerPolicy,omitempty"`
 
-	// The timeout policy for requests to the services.
+	// The timeout policy for requests to the services. The idle
+	// timeout is applied to the upstream connections to the services.
 	//
 	// +optional
 	TimeoutPolicy *contour_api_v1.TimeoutPolicy `json:"timeoutPolicy,omitempty"`
 
+	// The circuit breaker thresholds for the services.
+	//
+	// +optional
+	CircuitBreakerPolicy *CircuitBreakerPolicy `json:"circuitBreakerPolicy,omitempty"`
+
+	// The active gRPC health check policy for the services.
+	//
+	// +optional
+	HealthCheckPolicy *GRPCHealthCheckPolicy `json:"healthCheckPolicy,omitempty"`
+
 	// This field sets the version of the GRPC protocol that Envoy uses to
 	// send requests to the extension service. Since Contour always uses the
 	// v3 Envoy API, this is currently fixed at "v3". However, other
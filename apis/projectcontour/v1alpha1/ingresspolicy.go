@@ -0,0 +1,57 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IngressPolicySpec defines what the namespace of an IngressPolicy
+// is allowed to expose.
+type IngressPolicySpec struct {
+	// AllowedHosts lists the hostnames that the virtual hosts of the
+	// namespace may use. An entry such as "*.example.com" allows any
+	// subdomain of example.com. If empty, any hostname may be used.
+	//
+	// +optional
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+
+// IngressPolicy approves the exposure of the virtual hosts and routes
+// of its namespace. When Contour is configured to require ingress
+// policies, the HTTPProxies, Ingresses and exposed Services of a
+// namespace are only programmed if the namespace has an IngressPolicy.
+// IngressPolicies are intended to be managed by cluster administrators,
+// so users that manage the routing of a namespace should not be
+// granted access to them.
+type IngressPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IngressPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IngressPolicyList contains a list of IngressPolicy resources.
+type IngressPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IngressPolicy `json:"items"`
+}
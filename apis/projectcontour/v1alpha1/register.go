@@ -21,6 +21,10 @@ import (
 
 var ExtensionServiceGVR = GroupVersion.WithResource("extensionservices")
 
+var TrafficSplitGVR = GroupVersion.WithResource("trafficsplits")
+
+var IngressPolicyGVR = GroupVersion.WithResource("ingresspolicies")
+
 var (
 	// GroupVersion is group version used to register these objects
 	GroupVersion = schema.GroupVersion{Group: "projectcontour.io", Version: "v1alpha1"}
@@ -37,6 +41,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		GroupVersion,
 		&ExtensionService{},
 		&ExtensionServiceList{},
+		&TrafficSplit{},
+		&TrafficSplitList{},
+		&IngressPolicy{},
+		&IngressPolicyList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
@@ -0,0 +1,82 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TrafficSplitBackend is a Kubernetes Service that receives a
+// share of the traffic that is split.
+type TrafficSplitBackend struct {
+	// Service is the name of a Kubernetes Service in the namespace
+	// of the TrafficSplit. It must expose the port of the split
+	// Service that routes send traffic to.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Service string `json:"service"`
+
+	// Weight is the share of the traffic that the Service receives,
+	// relative to the weights of the other backends. A backend with
+	// a weight of 0 receives no traffic.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	Weight uint32 `json:"weight"`
+}
+
+// TrafficSplitSpec defines how the traffic to a Kubernetes Service is
+// split between backend Services.
+type TrafficSplitSpec struct {
+	// Service is the name of the Kubernetes Service, in the namespace
+	// of the TrafficSplit, whose traffic is split. HTTPProxy routes
+	// that send traffic to it send it to the backends instead.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Service string `json:"service"`
+
+	// Backends are the Services that the traffic is split between.
+	//
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	Backends []TrafficSplitBackend `json:"backends"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+
+// TrafficSplit splits the traffic that HTTPProxy routes send to a
+// Kubernetes Service between a set of backend Services, without
+// modifying the HTTPProxies. It is intended to be managed by
+// progressive delivery tools, and its fields follow those of the
+// SMI TrafficSplit.
+type TrafficSplit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TrafficSplitSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TrafficSplitList contains a list of TrafficSplit resources.
+type TrafficSplitList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TrafficSplit `json:"items"`
+}
@@ -0,0 +1,117 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// adminProxyEndpoints is the subset of the Envoy admin interface that
+// the admin proxy exposes, keyed by path, with the HTTP method each
+// endpoint accepts. Endpoints that change Envoy's runtime state, such
+// as /runtime_modify or /quitquitquit, are deliberately not exposed.
+var adminProxyEndpoints = map[string]string{
+	"/stats":            http.MethodGet,
+	"/stats/prometheus": http.MethodGet,
+	"/config_dump":      http.MethodGet,
+	"/server_info":      http.MethodGet,
+	"/ready":            http.MethodGet,
+	"/drain_listeners":  http.MethodPost,
+}
+
+// adminProxy is a http.Handler that forwards authenticated requests for
+// a safe subset of the Envoy admin endpoints to the admin interface.
+type adminProxy struct {
+	token string
+	proxy *httputil.ReverseProxy
+
+	logrus.FieldLogger
+}
+
+// newAdminProxy returns an adminProxy for the Envoy admin interface on
+// the supplied localhost port. Requests must present the supplied token
+// as a bearer token.
+func newAdminProxy(adminPort int, token string, log logrus.FieldLogger) (*adminProxy, error) {
+	if token == "" {
+		return nil, errors.New("admin proxy token must not be empty")
+	}
+
+	target := &url.URL{
+		Scheme: "http",
+		Host:   fmt.Sprintf("localhost:%d", adminPort),
+	}
+
+	return &adminProxy{
+		token:       token,
+		proxy:       httputil.NewSingleHostReverseProxy(target),
+		FieldLogger: log,
+	}, nil
+}
+
+// readAdminProxyToken reads the bearer token for the admin proxy from
+// the supplied file, ignoring surrounding whitespace.
+func readAdminProxyToken(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading admin proxy token: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (p *adminProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !p.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="envoy-admin"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	method, ok := adminProxyEndpoints[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.WithField("context", "adminProxy").
+		WithField("method", r.Method).
+		WithField("path", r.URL.Path).
+		Info("proxying request to envoy admin interface")
+
+	// Don't pass the proxy's credentials on to Envoy.
+	r.Header.Del("Authorization")
+	p.proxy.ServeHTTP(w, r)
+}
+
+// authorized returns true if the request carries the proxy's bearer token.
+func (p *adminProxy) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, prefix)), []byte(p.token)) == 1
+}
@@ -0,0 +1,138 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminProxy(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Envoy should never see the proxy's credentials.
+		if r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.RequestURI()))
+	}))
+	defer admin.Close()
+
+	u, err := url.Parse(admin.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	proxy, err := newAdminProxy(port, "s3cr3t", fixture.NewTestLogger(t))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		method   string
+		target   string
+		auth     string
+		wantCode int
+		wantBody string
+	}{
+		"no credentials": {
+			method:   http.MethodGet,
+			target:   "/stats",
+			wantCode: http.StatusUnauthorized,
+		},
+		"wrong token": {
+			method:   http.MethodGet,
+			target:   "/stats",
+			auth:     "Bearer wrong",
+			wantCode: http.StatusUnauthorized,
+		},
+		"basic auth": {
+			method:   http.MethodGet,
+			target:   "/stats",
+			auth:     "Basic czNjcjN0",
+			wantCode: http.StatusUnauthorized,
+		},
+		"stats": {
+			method:   http.MethodGet,
+			target:   "/stats",
+			auth:     "Bearer s3cr3t",
+			wantCode: http.StatusOK,
+			wantBody: "GET /stats",
+		},
+		"config dump with query": {
+			method:   http.MethodGet,
+			target:   "/config_dump?resource=dynamic_active_clusters",
+			auth:     "Bearer s3cr3t",
+			wantCode: http.StatusOK,
+			wantBody: "GET /config_dump?resource=dynamic_active_clusters",
+		},
+		"drain listeners": {
+			method:   http.MethodPost,
+			target:   "/drain_listeners?inboundonly",
+			auth:     "Bearer s3cr3t",
+			wantCode: http.StatusOK,
+			wantBody: "POST /drain_listeners?inboundonly",
+		},
+		"drain listeners with GET": {
+			method:   http.MethodGet,
+			target:   "/drain_listeners",
+			auth:     "Bearer s3cr3t",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		"unexposed endpoint": {
+			method:   http.MethodPost,
+			target:   "/quitquitquit",
+			auth:     "Bearer s3cr3t",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rr := httptest.NewRecorder()
+			proxy.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminProxyToken(t *testing.T) {
+	_, err := newAdminProxy(9001, "", fixture.NewTestLogger(t))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(path, []byte("s3cr3t\n"), 0600))
+
+	token, err := readAdminProxyToken(path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", token)
+
+	_, err = readAdminProxyToken(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"text/tabwriter"
+	"time"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/xdscache"
+	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
+	"github.com/sirupsen/logrus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// registerBench registers the bench subcommand and flags
+// with the Application provided.
+func registerBench(app *kingpin.Application) (*kingpin.CmdClause, *benchContext) {
+	ctx := &benchContext{}
+
+	bench := app.Command("bench", "Measure DAG build and xDS snapshot performance with synthetic objects.")
+	bench.Flag("namespaces", "Number of synthetic namespaces.").Default("10").IntVar(&ctx.namespaces)
+	bench.Flag("proxies", "Number of HTTPProxies in each synthetic namespace.").Default("100").IntVar(&ctx.proxies)
+	bench.Flag("iterations", "Number of times to build the DAG and xDS snapshot.").Default("10").IntVar(&ctx.iterations)
+	bench.Flag("cpuprofile", "Write a CPU profile of the iterations to this file.").StringVar(&ctx.cpuProfile)
+	bench.Flag("memprofile", "Write a heap profile taken after the iterations to this file.").StringVar(&ctx.memProfile)
+
+	return bench, ctx
+}
+
+type benchContext struct {
+	// namespaces is the number of synthetic namespaces to generate.
+	namespaces int
+
+	// proxies is the number of HTTPProxies to generate in each namespace.
+	proxies int
+
+	// iterations is the number of DAG and xDS snapshot builds to measure.
+	iterations int
+
+	// cpuProfile is the path to write a CPU profile to, if set.
+	cpuProfile string
+
+	// memProfile is the path to write a heap profile to, if set.
+	memProfile string
+}
+
+// benchResult holds the measurements of a single iteration.
+type benchResult struct {
+	build    time.Duration
+	snapshot time.Duration
+	allocs   uint64
+	bytes    uint64
+}
+
+func doBench(ctx *benchContext, out io.Writer, log logrus.FieldLogger) error {
+	if ctx.namespaces < 1 || ctx.proxies < 1 || ctx.iterations < 1 {
+		return fmt.Errorf("namespaces, proxies and iterations must be positive")
+	}
+
+	// Keep the per-object logging of the builder out of the measurements.
+	quiet := logrus.New()
+	quiet.SetOutput(ioutil.Discard)
+
+	builder := dag.Builder{
+		Source: dag.KubernetesCache{
+			FieldLogger: quiet,
+		},
+		Processors: []dag.Processor{
+			&dag.HTTPProxyProcessor{},
+			&dag.ListenerProcessor{},
+		},
+	}
+
+	objs := fixture.SyntheticObjects(ctx.namespaces, ctx.proxies)
+	for _, o := range objs {
+		builder.Source.Insert(o)
+	}
+
+	resources := []xdscache.ResourceCache{
+		xdscache_v3.NewListenerCache(xdscache_v3.ListenerConfig{}, "0.0.0.0", 8002),
+		&xdscache_v3.SecretCache{},
+		&xdscache_v3.RouteCache{},
+		&xdscache_v3.ClusterCache{},
+		xdscache_v3.NewEndpointsTranslator(quiet),
+	}
+	observer := dag.ComposeObservers(append(xdscache.ObserversOf(resources), xdscache.NewSnapshotHandler(resources, quiet))...)
+
+	log.WithField("objects", len(objs)).
+		WithField("namespaces", ctx.namespaces).
+		WithField("proxies", ctx.namespaces*ctx.proxies).
+		Info("running benchmark")
+
+	if ctx.cpuProfile != "" {
+		f, err := os.Create(ctx.cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	results := make([]benchResult, 0, ctx.iterations)
+	for i := 0; i < ctx.iterations; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		start := time.Now()
+		d := builder.Build()
+		built := time.Now()
+		observer.OnChange(d)
+		done := time.Now()
+
+		runtime.ReadMemStats(&after)
+
+		results = append(results, benchResult{
+			build:    built.Sub(start),
+			snapshot: done.Sub(built),
+			allocs:   after.Mallocs - before.Mallocs,
+			bytes:    after.TotalAlloc - before.TotalAlloc,
+		})
+	}
+
+	if ctx.memProfile != "" {
+		f, err := os.Create(ctx.memProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return err
+		}
+	}
+
+	return writeBenchResults(out, results)
+}
+
+// writeBenchResults writes a table of the results of each
+// iteration, followed by their mean.
+func writeBenchResults(out io.Writer, results []benchResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ITERATION\tDAG BUILD\tXDS SNAPSHOT\tALLOCS\tBYTES")
+
+	var mean benchResult
+	for i, r := range results {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\n", i+1, r.build, r.snapshot, r.allocs, r.bytes)
+
+		mean.build += r.build
+		mean.snapshot += r.snapshot
+		mean.allocs += r.allocs
+		mean.bytes += r.bytes
+	}
+
+	n := len(results)
+	fmt.Fprintf(w, "mean\t%s\t%s\t%d\t%d\n",
+		mean.build/time.Duration(n), mean.snapshot/time.Duration(n), mean.allocs/uint64(n), mean.bytes/uint64(n))
+
+	return w.Flush()
+}
@@ -0,0 +1,49 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBench(t *testing.T) {
+	dir := t.TempDir()
+	ctx := &benchContext{
+		namespaces: 2,
+		proxies:    3,
+		iterations: 2,
+		cpuProfile: filepath.Join(dir, "cpu.prof"),
+		memProfile: filepath.Join(dir, "mem.prof"),
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, doBench(ctx, &out, fixture.NewTestLogger(t)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "ITERATION"))
+	assert.True(t, strings.HasPrefix(lines[3], "mean"))
+
+	assert.FileExists(t, ctx.cpuProfile)
+	assert.FileExists(t, ctx.memProfile)
+
+	assert.Error(t, doBench(&benchContext{}, &out, fixture.NewTestLogger(t)))
+}
@@ -36,5 +36,12 @@ func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *envoy.Boo
 	bootstrap.Flag("namespace", "The namespace the Envoy container will run in.").Envar("CONTOUR_NAMESPACE").Default("projectcontour").StringVar(&config.Namespace)
 	bootstrap.Flag("xds-resource-version", "The versions of the xDS resources to request from Contour.").Default("v3").StringVar((*string)(&config.XDSResourceVersion))
 	bootstrap.Flag("dns-lookup-family", "Defines what DNS Resolution Policy to use for Envoy -> Contour cluster name lookup. Either v4, v6 or auto.").StringVar(&config.DNSLookupFamily)
+	bootstrap.Flag("node-id", "Envoy node id.").StringVar(&config.NodeID)
+	bootstrap.Flag("node-cluster", "Envoy node cluster id.").StringVar(&config.NodeCluster)
+	bootstrap.Flag("locality-region", "Region of the Envoy node locality.").Envar("ENVOY_LOCALITY_REGION").StringVar(&config.LocalityRegion)
+	bootstrap.Flag("locality-zone", "Zone of the Envoy node locality.").Envar("ENVOY_LOCALITY_ZONE").StringVar(&config.LocalityZone)
+	bootstrap.Flag("locality-subzone", "Sub-zone of the Envoy node locality.").Envar("ENVOY_LOCALITY_SUBZONE").StringVar(&config.LocalitySubZone)
+	bootstrap.Flag("xds-initial-fetch-timeout", "How long Envoy waits for the first xDS response before starting its listeners.").DurationVar(&config.XDSInitialFetchTimeout)
+	bootstrap.Flag("xds-rate-limit-qps", "Limit on the rate of xDS requests Envoy sends to Contour, in requests per second.").Float64Var(&config.XDSRateLimitQPS)
 	return bootstrap, &config
 }
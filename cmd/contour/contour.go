@@ -41,6 +41,8 @@ func main() {
 
 	certgenApp, certgenConfig := registerCertGen(app)
 
+	bench, benchCtx := registerBench(app)
+
 	cli := app.Command("cli", "A CLI client for the Contour Kubernetes ingress controller.")
 	var client Client
 	cli.Flag("contour", "Contour host:port.").Default("127.0.0.1:8001").StringVar(&client.ContourAddr)
@@ -59,8 +61,12 @@ func main() {
 	rds.Arg("resources", "RDS resource filter").StringsVar(&resources)
 	sds := cli.Command("sds", "Watch secrets.")
 	sds.Arg("resources", "SDS resource filter").StringsVar(&resources)
+	delegations, delegationsCtx := registerDelegations(cli)
+	status, statusCtx := registerStatus(cli)
+	fqdn, fqdnCtx := registerFQDN(cli)
 
 	serve, serveCtx := registerServe(app)
+	statusWriter, statusWriterCtx := registerStatusWriter(app)
 	version := app.Command("version", "Build information for Contour.")
 
 	args := os.Args[1:]
@@ -79,6 +85,10 @@ func main() {
 		}
 	case certgenApp.FullCommand():
 		doCertgen(certgenConfig, log)
+	case bench.FullCommand():
+		if err := doBench(benchCtx, os.Stdout, log); err != nil {
+			log.WithError(err).Fatal("benchmark failed")
+		}
 	case cds.FullCommand():
 		stream := client.ClusterStream()
 		watchstream(stream, resource_v3.ClusterType, resources)
@@ -94,6 +104,18 @@ func main() {
 	case sds.FullCommand():
 		stream := client.RouteStream()
 		watchstream(stream, resource_v3.SecretType, resources)
+	case delegations.FullCommand():
+		if err := doDelegations(delegationsCtx, os.Stdout, log); err != nil {
+			log.WithError(err).Fatal("failed to list delegations")
+		}
+	case status.FullCommand():
+		if err := doStatus(statusCtx, os.Stdout, log); err != nil {
+			log.WithError(err).Fatal("failed to get HTTPProxy status")
+		}
+	case fqdn.FullCommand():
+		if err := doFQDN(fqdnCtx, os.Stdout); err != nil {
+			log.WithError(err).Fatal("failed to get FQDN configuration")
+		}
 	case serve.FullCommand():
 		// Parse args a second time so cli flags are applied
 		// on top of any values sourced from -c's config file.
@@ -120,6 +142,13 @@ func main() {
 		if err := doServe(log, serveCtx); err != nil {
 			log.WithError(err).Fatal("Contour server failed")
 		}
+	case statusWriter.FullCommand():
+		if statusWriterCtx.debug {
+			log.SetLevel(logrus.DebugLevel)
+		}
+		if err := doStatusWriter(log, statusWriterCtx); err != nil {
+			log.WithError(err).Fatal("status writer failed")
+		}
 	case version.FullCommand():
 		println(build.PrintBuildInfo())
 	default:
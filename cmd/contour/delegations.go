@@ -0,0 +1,151 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/annotation"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/sirupsen/logrus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	corev1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// registerDelegations registers the delegations subcommand and
+// flags with the cli command provided.
+func registerDelegations(cli *kingpin.CmdClause) (*kingpin.CmdClause, *delegationsContext) {
+	ctx := &delegationsContext{}
+
+	delegations := cli.Command("delegations", "List TLSCertificateDelegations and the objects that consume them.")
+	delegations.Flag("incluster", "Use in cluster configuration.").BoolVar(&ctx.inCluster)
+	delegations.Flag("kubeconfig", "Path to kubeconfig (if not in running inside a cluster).").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).StringVar(&ctx.kubeconfig)
+	delegations.Flag("ingress-class-name", "Contour IngressClass name.").StringVar(&ctx.ingressClassName)
+	delegations.Flag("ingress-class-matching", "Whether Ingresses and HTTPProxies with no ingress class set are considered, as the ingress.classMatching configuration selects.").EnumVar(&ctx.ingressClassMatching, "strict", "permissive")
+
+	return delegations, ctx
+}
+
+type delegationsContext struct {
+	// inCluster uses the in cluster Kubernetes configuration.
+	inCluster bool
+
+	// kubeconfig is the path to the Kubernetes configuration
+	// used when not running in a cluster.
+	kubeconfig string
+
+	// ingressClassName selects the Ingresses and HTTPProxies
+	// that are considered, as it does for contour serve.
+	ingressClassName string
+
+	// ingressClassMatching selects whether Ingresses and
+	// HTTPProxies that have no ingress class set are considered.
+	ingressClassMatching string
+}
+
+// delegationResources are the resources that are loaded into
+// the cache to compute the usage of delegated secrets.
+var delegationResources = []schema.GroupVersionResource{
+	contour_api_v1.TLSCertificateDelegationGVR,
+	contour_api_v1.HTTPProxyGVR,
+	networking_v1.SchemeGroupVersion.WithResource("ingresses"),
+	corev1.SchemeGroupVersion.WithResource("secrets"),
+}
+
+func doDelegations(ctx *delegationsContext, out io.Writer, log logrus.FieldLogger) error {
+	clients, err := k8s.NewClients(ctx.kubeconfig, ctx.inCluster)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes clients: %w", err)
+	}
+
+	converter, err := k8s.NewUnstructuredConverter()
+	if err != nil {
+		return err
+	}
+
+	// The cache logs every object it rejects; only the report is wanted here.
+	quiet := logrus.New()
+	quiet.SetOutput(ioutil.Discard)
+
+	source := dag.KubernetesCache{
+		IngressClassName:     ctx.ingressClassName,
+		IngressClassMatching: annotation.ClassMatching(ctx.ingressClassMatching),
+		FieldLogger:          quiet,
+	}
+
+	for _, gvr := range delegationResources {
+		list, err := clients.DynamicClient().Resource(gvr).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		}
+
+		for i := range list.Items {
+			obj, err := converter.FromUnstructured(&list.Items[i])
+			if err != nil {
+				log.WithError(err).WithField("resource", gvr.Resource).Error("failed to convert object")
+				continue
+			}
+			source.Insert(obj)
+		}
+	}
+
+	return writeDelegationUsages(out, source.DelegationUsages())
+}
+
+// writeDelegationUsages writes a table of each delegated secret,
+// the objects that consume it and whether it needs attention.
+func writeDelegationUsages(out io.Writer, usages []dag.DelegationUsage) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DELEGATION\tSECRET\tTARGET NAMESPACES\tCONSUMERS\tSTATUS")
+
+	for _, u := range usages {
+		consumers := "<none>"
+		if !u.Unused() {
+			names := make([]string, 0, len(u.Consumers))
+			for _, c := range u.Consumers {
+				names = append(names, c.Kind+"/"+c.String())
+			}
+			consumers = strings.Join(names, ",")
+		}
+
+		var problems []string
+		if u.SecretMissing {
+			problems = append(problems, "secret missing")
+		}
+		if u.Unused() {
+			problems = append(problems, "unused")
+		}
+		status := "ok"
+		if len(problems) > 0 {
+			status = strings.Join(problems, ",")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			u.Delegation, u.Secret.Name, strings.Join(u.TargetNamespaces, ","), consumers, status)
+	}
+
+	return w.Flush()
+}
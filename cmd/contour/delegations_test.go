@@ -0,0 +1,56 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWriteDelegationUsages(t *testing.T) {
+	delegation := types.NamespacedName{Namespace: "certs", Name: "delegation"}
+
+	usages := []dag.DelegationUsage{{
+		Delegation:       delegation,
+		Secret:           types.NamespacedName{Namespace: "certs", Name: "missing"},
+		TargetNamespaces: []string{"*"},
+		SecretMissing:    true,
+	}, {
+		Delegation:       delegation,
+		Secret:           types.NamespacedName{Namespace: "certs", Name: "wildcard"},
+		TargetNamespaces: []string{"team-a", "team-b"},
+		Consumers: []dag.DelegationConsumer{{
+			Kind:           "HTTPProxy",
+			NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "proxy"},
+		}, {
+			Kind:           "Ingress",
+			NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "ingress"},
+		}},
+	}}
+
+	var out bytes.Buffer
+	require.NoError(t, writeDelegationUsages(&out, usages))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"DELEGATION", "SECRET", "TARGET", "NAMESPACES", "CONSUMERS", "STATUS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"certs/delegation", "missing", "*", "<none>", "secret", "missing,unused"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"certs/delegation", "wildcard", "team-a,team-b", "HTTPProxy/team-a/proxy,Ingress/team-b/ingress", "ok"}, strings.Fields(lines[2]))
+}
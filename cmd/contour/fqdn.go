@@ -0,0 +1,194 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/projectcontour/contour/internal/debug"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// registerFQDN registers the fqdn subcommand and flags
+// with the cli command provided.
+func registerFQDN(cli *kingpin.CmdClause) (*kingpin.CmdClause, *fqdnContext) {
+	ctx := &fqdnContext{}
+
+	fqdn := cli.Command("fqdn", "Show the effective configuration of a fully qualified domain name.")
+	fqdn.Arg("name", "The fully qualified domain name.").Required().StringVar(&ctx.name)
+	fqdn.Flag("debug-address", "Contour debug http host:port.").Default("127.0.0.1:6060").StringVar(&ctx.debugAddr)
+	fqdn.Flag("json", "Write the configuration as JSON.").BoolVar(&ctx.json)
+
+	return fqdn, ctx
+}
+
+type fqdnContext struct {
+	// name is the fully qualified domain name to show.
+	name string
+
+	// debugAddr is the address of the debug http
+	// endpoint of the Contour to query.
+	debugAddr string
+
+	// json writes the configuration as JSON, rather
+	// than as text.
+	json bool
+}
+
+func doFQDN(ctx *fqdnContext, out io.Writer) error {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     ctx.debugAddr,
+		Path:     "/debug/fqdn",
+		RawQuery: url.Values{"name": {ctx.name}}.Encode(),
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var fqdn debug.FQDN
+	if err := json.Unmarshal(body, &fqdn); err != nil {
+		return fmt.Errorf("failed to decode configuration: %w", err)
+	}
+
+	if ctx.json {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(fqdn)
+	}
+	return writeFQDN(out, fqdn)
+}
+
+// writeFQDN writes the configuration of each virtual host of the
+// FQDN, followed by a table of its routes.
+func writeFQDN(out io.Writer, fqdn debug.FQDN) error {
+	for i, vh := range fqdn.VirtualHosts {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		fmt.Fprintf(out, "FQDN:      %s\n", fqdn.Name)
+		fmt.Fprintf(out, "Listener:  %s\n", vh.Listener)
+		if vh.TLS != nil {
+			fmt.Fprintf(out, "TLS:       %s\n", tlsSummary(vh.TLS))
+		}
+		fmt.Fprintf(out, "Sources:   %s\n", orNone(strings.Join(vh.Sources, ",")))
+		if len(vh.TCPProxy) > 0 {
+			fmt.Fprintf(out, "TCPProxy:  %s\n", backendsSummary(vh.TCPProxy))
+		}
+
+		if len(vh.Routes) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MATCH\tHEADERS\tACTION\tSOURCE")
+		for _, r := range vh.Routes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				r.Match, orNone(strings.Join(r.Headers, ";")), routeAction(r), orNone(r.Source))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tlsSummary summarizes the TLS configuration of a virtual host.
+func tlsSummary(tls *debug.TLS) string {
+	var parts []string
+	if tls.Secret != "" {
+		parts = append(parts, "secret="+tls.Secret)
+	} else {
+		parts = append(parts, "passthrough")
+	}
+	if tls.MinimumProtocolVersion != "" {
+		parts = append(parts, "min-version="+tls.MinimumProtocolVersion)
+	}
+	if tls.FallbackCertificate != "" {
+		parts = append(parts, "fallback-certificate="+tls.FallbackCertificate)
+	}
+	if tls.ClientValidation {
+		parts = append(parts, "client-validation")
+	}
+	if tls.Authorization != "" {
+		parts = append(parts, "authorization="+tls.Authorization)
+	}
+	return strings.Join(parts, ",")
+}
+
+// routeAction describes what the route does with the requests
+// it matches: redirect, respond directly, or forward them to its
+// backends.
+func routeAction(r debug.Route) string {
+	var action string
+	switch {
+	case r.Redirect != "":
+		action = "redirect " + r.Redirect
+	case r.DirectResponse != 0:
+		action = fmt.Sprintf("respond %d", r.DirectResponse)
+	case r.HTTPSUpgrade:
+		action = "upgrade to https"
+	default:
+		action = orNone(backendsSummary(r.Backends))
+	}
+
+	if r.Websocket {
+		action += " (websocket)"
+	}
+	return action
+}
+
+// backendsSummary lists the backends with their weights,
+// and protocols if set.
+func backendsSummary(backends []debug.Backend) string {
+	var parts []string
+	for _, b := range backends {
+		s := fmt.Sprintf("%s(weight=%d", b.Service, b.Weight)
+		if b.Protocol != "" {
+			s += ",protocol=" + b.Protocol
+		}
+		parts = append(parts, s+")")
+	}
+	return strings.Join(parts, ",")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
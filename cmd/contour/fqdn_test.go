@@ -0,0 +1,76 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/projectcontour/contour/internal/debug"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFQDN(t *testing.T) {
+	fqdn := debug.FQDN{
+		Name: "example.com",
+		VirtualHosts: []debug.VirtualHost{{
+			Listener: "ingress_http",
+			Routes: []debug.Route{{
+				Match:        "prefix: /",
+				HTTPSUpgrade: true,
+				Source:       "HTTPProxy/default/root",
+			}},
+			Sources: []string{"HTTPProxy/default/root"},
+		}, {
+			Listener: "ingress_https",
+			TLS: &debug.TLS{
+				Secret:                 "default/secret",
+				MinimumProtocolVersion: "1.2",
+				ClientValidation:       true,
+			},
+			Routes: []debug.Route{{
+				Match:    "prefix: /",
+				Backends: []debug.Backend{{Service: "default/app:8080", Weight: 90}, {Service: "default/canary:8080", Weight: 10, Protocol: "h2c"}},
+				Source:   "HTTPProxy/default/root",
+			}, {
+				Match:          "exact: /gone",
+				Headers:        []string{"x-beta present"},
+				DirectResponse: 410,
+				Source:         "HTTPProxy/marketing/blog",
+			}},
+			Sources: []string{"HTTPProxy/default/root", "HTTPProxy/marketing/blog"},
+		}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeFQDN(&out, fqdn))
+
+	assert.Equal(t, `FQDN:      example.com
+Listener:  ingress_http
+Sources:   HTTPProxy/default/root
+
+MATCH      HEADERS  ACTION            SOURCE
+prefix: /  <none>   upgrade to https  HTTPProxy/default/root
+
+FQDN:      example.com
+Listener:  ingress_https
+TLS:       secret=default/secret,min-version=1.2,client-validation
+Sources:   HTTPProxy/default/root,HTTPProxy/marketing/blog
+
+MATCH         HEADERS         ACTION                                                                   SOURCE
+prefix: /     <none>          default/app:8080(weight=90),default/canary:8080(weight=10,protocol=h2c)  HTTPProxy/default/root
+exact: /gone  x-beta present  respond 410                                                              HTTPProxy/marketing/blog
+`, out.String())
+}
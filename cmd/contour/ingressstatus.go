@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/annotation"
 	"github.com/projectcontour/contour/internal/k8s"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
@@ -45,13 +46,14 @@ import (
 // 5. If the worker is stopped, the informer continues but no further
 //    status updates are made.
 type loadBalancerStatusWriter struct {
-	log              logrus.FieldLogger
-	clients          *k8s.Clients
-	isLeader         chan struct{}
-	lbStatus         chan v1.LoadBalancerStatus
-	statusUpdater    k8s.StatusUpdater
-	ingressClassName string
-	Converter        k8s.Converter
+	log                  logrus.FieldLogger
+	clients              *k8s.Clients
+	isLeader             chan struct{}
+	lbStatus             chan v1.LoadBalancerStatus
+	statusUpdater        k8s.StatusUpdater
+	ingressClassName     string
+	ingressClassMatching annotation.ClassMatching
+	Converter            k8s.Converter
 }
 
 func (isw *loadBalancerStatusWriter) Start(stop <-chan struct{}) error {
@@ -75,9 +77,10 @@ func (isw *loadBalancerStatusWriter) Start(stop <-chan struct{}) error {
 
 			return log
 		}(),
-		IngressClassName: isw.ingressClassName,
-		StatusUpdater:    isw.statusUpdater,
-		Converter:        isw.Converter,
+		IngressClassName:     isw.ingressClassName,
+		IngressClassMatching: isw.ingressClassMatching,
+		StatusUpdater:        isw.statusUpdater,
+		Converter:            isw.Converter,
 	}
 
 	// Create informers for the types that need load balancer
@@ -22,6 +22,8 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -31,9 +33,12 @@ import (
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/annotation"
+	"github.com/projectcontour/contour/internal/audit"
+	"github.com/projectcontour/contour/internal/build"
 	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/debug"
+	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/health"
 	"github.com/projectcontour/contour/internal/httpsvc"
 	"github.com/projectcontour/contour/internal/k8s"
@@ -48,6 +53,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 	"gopkg.in/alecthomas/kingpin.v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -121,15 +127,25 @@ func registerServe(app *kingpin.Application) (*kingpin.CmdClause, *serveContext)
 
 	serve.Flag("xds-address", "xDS gRPC API address.").PlaceHolder("<ipaddr>").StringVar(&ctx.xdsAddr)
 	serve.Flag("xds-port", "xDS gRPC API port.").PlaceHolder("<port>").IntVar(&ctx.xdsPort)
+	serve.Flag("xds-rest-port", "xDS REST API port. The REST API is disabled if not set.").PlaceHolder("<port>").IntVar(&ctx.xdsRESTPort)
 
 	serve.Flag("stats-address", "Envoy /stats interface address.").PlaceHolder("<ipaddr>").StringVar(&ctx.statsAddr)
 	serve.Flag("stats-port", "Envoy /stats interface port.").PlaceHolder("<port>").IntVar(&ctx.statsPort)
 
 	serve.Flag("debug-http-address", "Address the debug http endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.debugAddr)
 	serve.Flag("debug-http-port", "Port the debug http endpoint will bind to.").PlaceHolder("<port>").IntVar(&ctx.debugPort)
+	serve.Flag("debug-cert-file", "Certificate file name for serving the debug endpoint over TLS.").PlaceHolder("/path/to/file").StringVar(&ctx.debugCert)
+	serve.Flag("debug-key-file", "Key file name for serving the debug endpoint over TLS.").PlaceHolder("/path/to/file").StringVar(&ctx.debugKey)
+	serve.Flag("debug-cafile", "CA bundle file name for requiring client certificates on the debug endpoint.").PlaceHolder("/path/to/file").StringVar(&ctx.debugCAFile)
+	serve.Flag("debug-token-file", "Bearer token file name for authenticating requests to the debug endpoint.").PlaceHolder("/path/to/file").StringVar(&ctx.debugTokenFile)
+	serve.Flag("debug-namespace-review", "Allow requesting the DAG of a namespace from the debug endpoint with a Kubernetes bearer token that may get HTTPProxies in the namespace.").BoolVar(&ctx.debugNamespaceReview)
 
 	serve.Flag("http-address", "Address the metrics HTTP endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.metricsAddr)
 	serve.Flag("http-port", "Port the metrics HTTP endpoint will bind to.").PlaceHolder("<port>").IntVar(&ctx.metricsPort)
+	serve.Flag("metrics-cert-file", "Certificate file name for serving the metrics endpoint over TLS.").PlaceHolder("/path/to/file").StringVar(&ctx.metricsCert)
+	serve.Flag("metrics-key-file", "Key file name for serving the metrics endpoint over TLS.").PlaceHolder("/path/to/file").StringVar(&ctx.metricsKey)
+	serve.Flag("metrics-cafile", "CA bundle file name for requiring client certificates on the metrics endpoint.").PlaceHolder("/path/to/file").StringVar(&ctx.metricsCAFile)
+	serve.Flag("metrics-token-file", "Bearer token file name for authenticating requests to the metrics endpoint.").PlaceHolder("/path/to/file").StringVar(&ctx.metricsTokenFile)
 	serve.Flag("health-address", "Address the health HTTP endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.healthAddr)
 	serve.Flag("health-port", "Port the health HTTP endpoint will bind to.").PlaceHolder("<port>").IntVar(&ctx.healthPort)
 
@@ -153,6 +169,10 @@ func registerServe(app *kingpin.Application) (*kingpin.CmdClause, *serveContext)
 
 	serve.Flag("accesslog-format", "Format for Envoy access logs.").PlaceHolder("<envoy|json>").StringVar((*string)(&ctx.Config.AccessLogFormat))
 	serve.Flag("disable-leader-election", "Disable leader election mechanism.").BoolVar(&ctx.DisableLeaderElection)
+	serve.Flag("disable-status-writes", "Publish status updates for a separate status writer instead of writing them.").BoolVar(&ctx.disableStatusWrites)
+	serve.Flag("status-address", "Address the status publishing HTTP endpoint will bind to.").PlaceHolder("<ipaddr>").StringVar(&ctx.statusAddr)
+	serve.Flag("status-port", "Port the status publishing HTTP endpoint will bind to.").PlaceHolder("<port>").IntVar(&ctx.statusPort)
+	serve.Flag("status-token-file", "Bearer token file name for authenticating requests to the status publishing endpoint.").PlaceHolder("/path/to/file").StringVar(&ctx.statusTokenFile)
 
 	serve.Flag("debug", "Enable debug logging.").Short('d').BoolVar(&ctx.Config.Debug)
 	serve.Flag("kubernetes-debug", "Enable Kubernetes client debug logging with log level.").PlaceHolder("<log level>").UintVar(&ctx.KubernetesDebug)
@@ -195,6 +215,14 @@ func validateCRDs(dynamicClient dynamic.Interface, log logrus.FieldLogger) {
 
 // doServe runs the contour serve subcommand.
 func doServe(log logrus.FieldLogger, ctx *serveContext) error {
+	if build.FIPSEnabled() {
+		log.Info("running in FIPS mode, only FIPS approved TLS ciphers are permitted")
+	}
+
+	if ctx.Config.Server.NodeAuthorization.BindSAN && ctx.PermitInsecureGRPC {
+		return errors.New("xDS node authorization cannot bind nodes to client certificates when serving insecure gRPC")
+	}
+
 	// Establish k8s core & dynamic client connections.
 	clients, err := k8s.NewClients(ctx.Config.Kubeconfig, ctx.Config.InCluster)
 	if err != nil {
@@ -277,26 +305,55 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		ctx.Config.Listener.ConnectionBalancer = ""
 	}
 
+	if ctx.Config.Cluster.DNSLookupFamily == config.AllClusterDNSFamily {
+		log.Warnf("Cluster DNS lookup family %q is not supported by Envoy, %q is used.", config.AllClusterDNSFamily, config.AutoClusterDNSFamily)
+	}
+
+	// https redirect
+	if ctx.Config.HTTPSRedirect.TrustForwardedProto && ctx.Config.Network.XffNumTrustedHops == 0 {
+		log.Warn("HTTPS redirect trust-forwarded-proto is set, but network num-trusted-hops is 0. Envoy overwrites the X-Forwarded-Proto header of requests from untrusted clients, so they are always redirected.")
+	}
+
+	if err := ctx.validateListenerAddresses(); err != nil {
+		return err
+	}
+
+	if err := ctx.validateDebugFlags(); err != nil {
+		return err
+	}
+
+	if err := ctx.validateStatusFlags(); err != nil {
+		return err
+	}
+
 	listenerConfig := xdscache_v3.ListenerConfig{
 		UseProxyProto: ctx.useProxyProto,
 		HTTPListeners: map[string]xdscache_v3.Listener{
 			"ingress_http": {
-				Name:    "ingress_http",
-				Address: ctx.httpAddr,
-				Port:    ctx.httpPort,
+				Name:                "ingress_http",
+				Address:             ctx.httpAddr,
+				Port:                ctx.httpPort,
+				AdditionalAddresses: ctx.Config.Listener.AdditionalAddresses,
+				ConnectionBalancer:  string(ctx.Config.Listener.HTTPConnectionBalancer),
+				DrainType:           string(ctx.Config.Listener.HTTPDrainType),
 			},
 		},
 		HTTPSListeners: map[string]xdscache_v3.Listener{
 			"ingress_https": {
-				Name:    "ingress_https",
-				Address: ctx.httpsAddr,
-				Port:    ctx.httpsPort,
+				Name:                "ingress_https",
+				Address:             ctx.httpsAddr,
+				Port:                ctx.httpsPort,
+				AdditionalAddresses: ctx.Config.Listener.AdditionalAddresses,
+				ConnectionBalancer:  string(ctx.Config.Listener.HTTPSConnectionBalancer),
+				DrainType:           string(ctx.Config.Listener.HTTPSDrainType),
 			},
 		},
 		HTTPAccessLog:                 ctx.httpAccessLog,
 		HTTPSAccessLog:                ctx.httpsAccessLog,
 		AccessLogType:                 ctx.Config.AccessLogFormat,
 		AccessLogFields:               ctx.Config.AccessLogFields,
+		AccessLogHeaders:              ctx.Config.AccessLogHeaders,
+		AccessLogSource:               ctx.Config.AccessLogSource,
 		MinimumTLSVersion:             annotation.MinTLSVersion(ctx.Config.TLS.MinimumProtocolVersion, "1.2"),
 		CipherSuites:                  config.SanitizeCipherSuites(ctx.Config.TLS.CipherSuites),
 		RequestTimeout:                requestTimeout,
@@ -307,8 +364,24 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		ConnectionShutdownGracePeriod: connectionShutdownGracePeriod,
 		DefaultHTTPVersions:           parseDefaultHTTPVersions(ctx.Config.DefaultHTTPVersions),
 		AllowChunkedLength:            !ctx.Config.DisableAllowChunkedLength,
+		EnableTrailers:                ctx.Config.EnableTrailers,
+		EnableRequestDecompression:    ctx.Config.EnableRequestDecompression,
 		XffNumTrustedHops:             ctx.Config.Network.XffNumTrustedHops,
 		ConnectionBalancer:            ctx.Config.Listener.ConnectionBalancer,
+		DrainType:                     string(ctx.Config.Listener.DrainType),
+		MaxRequestHeadersKB:           ctx.Config.Listener.MaxRequestHeadersKB,
+		MaxRequestHeadersCount:        ctx.Config.Listener.MaxRequestHeadersCount,
+		DisableNormalizePath:          ctx.Config.Listener.DisableNormalizePath,
+		DisableMergeSlashes:           ctx.Config.Listener.DisableMergeSlashes,
+		EscapedSlashesAction:          ctx.Config.Listener.EscapedSlashesAction,
+		HeadersWithUnderscoresAction:  ctx.Config.Listener.HeadersWithUnderscoresAction,
+		ServerHeader:                  ctx.Config.Listener.ServerHeader,
+		HealthVirtualHost:             ctx.Config.Listener.HealthVirtualHost,
+		ConsolidateFilterChains:       ctx.Config.Listener.ConsolidateFilterChains,
+	}
+
+	if ctx.Config.Tap != nil {
+		listenerConfig.TapPathPrefix = ctx.Config.Tap.PathPrefix
 	}
 
 	if ctx.Config.RateLimitService.ExtensionService != "" {
@@ -342,17 +415,69 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		}
 	}
 
-	contourMetrics := metrics.NewMetrics(registry)
+	if tcpProxyAccessLog := ctx.Config.TCPProxyAccessLog; tcpProxyAccessLog.Type != "" {
+		listenerConfig.TCPProxyAccessLog = &xdscache_v3.TCPProxyAccessLogConfig{
+			Type:    tcpProxyAccessLog.Type,
+			Path:    tcpProxyAccessLog.Path,
+			LogName: tcpProxyAccessLog.LogName,
+		}
+		if tcpProxyAccessLog.ExtensionService != "" {
+			listenerConfig.TCPProxyAccessLog.ExtensionService = k8s.NamespacedNameFrom(tcpProxyAccessLog.ExtensionService)
+		}
+	}
+
+	if health := ctx.Config.Listener.Health; health.Port != 0 {
+		if health.Address == "" {
+			health.Address = "0.0.0.0"
+		}
+		if health.Path == "" {
+			health.Path = "/healthz"
+		}
+		if health.Address == ctx.statsAddr && health.Port == ctx.statsPort {
+			return fmt.Errorf("health listener address %s:%d duplicates the stats listener address", health.Address, health.Port)
+		}
+		listenerConfig.HealthListener = &xdscache_v3.HealthListenerConfig{
+			Address: health.Address,
+			Port:    health.Port,
+			Path:    health.Path,
+		}
+	}
+
+	contourMetrics := metrics.NewMetrics(registry, ctx.Config.HTTPProxyLabels...)
 
 	// Endpoints updates are handled directly by the EndpointsTranslator
 	// due to their high update rate and their orthogonal nature.
 	endpointHandler := xdscache_v3.NewEndpointsTranslator(log.WithField("context", "endpointstranslator"))
+	endpointHandler.IncludeNotReadyEndpoints = ctx.Config.Cluster.IncludeNotReadyEndpoints
+	endpointHandler.Linger = ctx.Config.Cluster.RemovedClusterLinger
+
+	clusterCache := &xdscache_v3.ClusterCache{
+		Linger: ctx.Config.Cluster.RemovedClusterLinger,
+	}
 
 	resources := []xdscache.ResourceCache{
 		xdscache_v3.NewListenerCache(listenerConfig, ctx.statsAddr, ctx.statsPort),
 		&xdscache_v3.SecretCache{},
-		&xdscache_v3.RouteCache{},
-		&xdscache_v3.ClusterCache{},
+		&xdscache_v3.RouteCache{
+			RouteOrdering: ctx.Config.RouteOrdering,
+			RequestHeadersPolicy: dag.GlobalHeadersPolicy(&dag.HeadersPolicy{
+				Set:    ctx.Config.Policy.RequestHeadersPolicy.Set,
+				Remove: ctx.Config.Policy.RequestHeadersPolicy.Remove,
+			}),
+			ResponseHeadersPolicy: dag.GlobalHeadersPolicy(&dag.HeadersPolicy{
+				Set:    ctx.Config.Policy.ResponseHeadersPolicy.Set,
+				Remove: ctx.Config.Policy.ResponseHeadersPolicy.Remove,
+			}),
+			CorrelationHeader:       ctx.Config.RequestID.CorrelationHeader,
+			VirtualHostStats:        ctx.Config.VirtualHostStats,
+			HealthVirtualHost:       ctx.Config.Listener.HealthVirtualHost,
+			ConsolidateFilterChains: ctx.Config.Listener.ConsolidateFilterChains,
+			HTTPSRedirectScheme:     ctx.Config.HTTPSRedirect.Scheme,
+			HTTPSRedirectPort:       ctx.Config.HTTPSRedirect.Port,
+			TrustForwardedProto:     ctx.Config.HTTPSRedirect.TrustForwardedProto,
+			AccessLogSource:         ctx.Config.AccessLogSource,
+		},
+		clusterCache,
 		endpointHandler,
 	}
 
@@ -361,6 +486,7 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 
 	// register observer for endpoints updates.
 	endpointHandler.Observer = contour.ComposeObservers(snapshotHandler)
+	clusterCache.Observer = contour.ComposeObservers(snapshotHandler)
 
 	// Log that we're using the fallback certificate if configured.
 	if fallbackCert != nil {
@@ -379,6 +505,14 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		FieldLogger:     log.WithField("context", "contourEventHandler"),
 	}
 
+	// Record how long each DAG processor, and each object it
+	// processes, takes during a DAG rebuild, and how many Secrets
+	// are quarantined.
+	eventHandler.Builder.ProcessorDuration = contourMetrics.DAGProcessorDuration
+	eventHandler.Builder.Source.ObjectDuration = contourMetrics.DAGObjectDuration
+	eventHandler.Builder.Source.QuarantinedSecrets = contourMetrics.QuarantinedSecrets
+	eventHandler.InformerLag = contourMetrics.InformerLag
+
 	// Wrap eventHandler in a converter for objects from the dynamic client.
 	// and an EventRecorder which tracks API server events.
 	dynamicHandler := k8s.DynamicClientHandler{
@@ -406,6 +540,12 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		}
 	}
 
+	// Inform on Namespaces, for Gateway route selection and
+	// to find the namespaces whose routes are blocked.
+	if err := informOnResource(clients, k8s.NamespacesResource(), &dynamicHandler); err != nil {
+		log.WithError(err).WithField("resource", k8s.NamespacesResource()).Fatal("failed to create informer")
+	}
+
 	// Set up workgroup runner and register informers.
 	var g workgroup.Group
 
@@ -448,11 +588,6 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 				log.WithError(err).Fatal("failed to create tlsroute-controller")
 			}
 
-			// Inform on Namespaces.
-			if err := informOnResource(clients, k8s.NamespacesResource(), &dynamicHandler); err != nil {
-				log.WithError(err).WithField("resource", k8s.NamespacesResource()).Fatal("failed to create informer")
-			}
-
 			// Start Manager
 			g.AddContext(func(taskCtx context.Context) error {
 				return mgr.Start(signals.SetupSignalHandler())
@@ -490,6 +625,23 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		}
 	}
 
+	// Inform on pods, so that the endpoints of terminating pods
+	// can be drained before their endpoints are updated.
+	if ctx.Config.Cluster.DrainTerminatingEndpoints {
+		for _, r := range k8s.PodsResources() {
+			if err := informOnResource(clients, r, &k8s.DynamicClientHandler{
+				Next: &contour.EventRecorder{
+					Next:    endpointHandler,
+					Counter: contourMetrics.EventHandlerOperations,
+				},
+				Converter: converter,
+				Logger:    log.WithField("context", "endpointstranslator"),
+			}); err != nil {
+				log.WithError(err).WithField("resource", r).Fatal("failed to create informer")
+			}
+		}
+	}
+
 	// Register a task to start all the informers.
 	g.AddContext(func(taskCtx context.Context) error {
 		log := log.WithField("context", "informers")
@@ -512,14 +664,29 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 	metricsvc := httpsvc.Service{
 		Addr:        ctx.metricsAddr,
 		Port:        ctx.metricsPort,
+		CertFile:    ctx.metricsCert,
+		KeyFile:     ctx.metricsKey,
+		CAFile:      ctx.metricsCAFile,
+		TokenFile:   ctx.metricsTokenFile,
 		FieldLogger: log.WithField("context", "metricsvc"),
 		ServeMux:    http.ServeMux{},
 	}
 
 	metricsvc.ServeMux.Handle("/metrics", metrics.Handler(registry))
 
+	// Check the dependencies of Contour for the health endpoint,
+	// and periodically to keep their metrics current.
+	healthChecks := []health.Check{
+		health.APIServer(clients.ClientSet(), contourMetrics.SetAPIServerLatency),
+		health.DAG(eventHandler.Status),
+	}
+	if ctx.contourCert != "" {
+		healthChecks = append(healthChecks, health.Certificate("xds-certificate", ctx.contourCert, contourMetrics.SetXDSCertificateExpiry))
+	}
+	g.Add(health.Poll(time.Minute, healthChecks...))
+
 	if ctx.healthAddr == ctx.metricsAddr && ctx.healthPort == ctx.metricsPort {
-		h := health.Handler(clients.ClientSet())
+		h := health.Handler(healthChecks...)
 		metricsvc.ServeMux.Handle("/health", h)
 		metricsvc.ServeMux.Handle("/healthz", h)
 	}
@@ -534,7 +701,7 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 			FieldLogger: log.WithField("context", "healthsvc"),
 		}
 
-		h := health.Handler(clients.ClientSet())
+		h := health.Handler(healthChecks...)
 		healthsvc.ServeMux.Handle("/health", h)
 		healthsvc.ServeMux.Handle("/healthz", h)
 
@@ -546,9 +713,22 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		Service: httpsvc.Service{
 			Addr:        ctx.debugAddr,
 			Port:        ctx.debugPort,
+			CertFile:    ctx.debugCert,
+			KeyFile:     ctx.debugKey,
+			CAFile:      ctx.debugCAFile,
+			TokenFile:   ctx.debugTokenFile,
 			FieldLogger: log.WithField("context", "debugsvc"),
 		},
-		Builder: &eventHandler.Builder,
+	}
+	eventHandler.Observer = dag.ComposeObservers(eventHandler.Observer, &debugsvc)
+	if ctx.debugNamespaceReview {
+		debugsvc.Reviewer = &debug.TokenReviewer{
+			Client: clients.ClientSet(),
+			// Bound the load that requests with invalid
+			// tokens, which are never cached, put on the
+			// API server.
+			Limiter: rate.NewLimiter(rate.Limit(5), 10),
+		}
 	}
 	g.Add(debugsvc.Start)
 
@@ -567,27 +747,66 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		NextObserver: eventHandler.Observer,
 	}
 
-	sh := k8s.StatusUpdateHandler{
-		Log:           log.WithField("context", "StatusUpdateHandler"),
-		Clients:       clients,
-		LeaderElected: eventHandler.IsLeader,
-		Converter:     converter,
+	// Record the routing changes in each DAG to the audit log.
+	auditSinks, err := ctx.auditSinks()
+	if err != nil {
+		return err
+	}
+	if len(auditSinks) > 0 {
+		auditObserver := audit.NewObserver(log.WithField("context", "audit"), eventHandler.IsLeader, auditSinks...)
+		eventHandler.Observer = dag.ComposeObservers(eventHandler.Observer, auditObserver)
+		g.Add(auditObserver.Start)
 	}
-	g.Add(sh.Start)
 
-	// Now we have the statusUpdateHandler, we can create the event handler's StatusUpdater, which will take the
-	// status updates from the DAG, and send them to the status update handler.
-	eventHandler.StatusUpdater = sh.Writer()
+	var statusUpdater k8s.StatusUpdater
+	statusLeader := eventHandler.IsLeader
+
+	if ctx.disableStatusWrites {
+		// Status is written by a separate status writer, which reads
+		// the status updates that the status publisher serves.
+		sp := k8s.NewStatusPublisher(log.WithField("context", "StatusPublisher"), clients, converter)
+		g.Add(sp.Start)
+		statusUpdater = sp.Writer()
+
+		statussvc := httpsvc.Service{
+			Addr:        ctx.statusAddr,
+			Port:        ctx.statusPort,
+			TokenFile:   ctx.statusTokenFile,
+			FieldLogger: log.WithField("context", "statussvc"),
+		}
+		statussvc.ServeMux.Handle("/status", sp)
+		g.Add(statussvc.Start)
+
+		// The status writer may read from any Contour, so
+		// they all publish the status of Ingresses.
+		alwaysLeader := make(chan struct{})
+		close(alwaysLeader)
+		statusLeader = alwaysLeader
+	} else {
+		sh := k8s.StatusUpdateHandler{
+			Log:           log.WithField("context", "StatusUpdateHandler"),
+			Clients:       clients,
+			LeaderElected: eventHandler.IsLeader,
+			Converter:     converter,
+		}
+		g.Add(sh.Start)
+		statusUpdater = sh.Writer()
+	}
+
+	// Now we have the statusUpdater, the event handler can send
+	// the status updates from the DAG to it.
+	eventHandler.StatusUpdater = statusUpdater
 
 	// Set up ingress load balancer status writer.
 	lbsw := loadBalancerStatusWriter{
-		log:              log.WithField("context", "loadBalancerStatusWriter"),
-		clients:          clients,
-		isLeader:         eventHandler.IsLeader,
-		lbStatus:         make(chan corev1.LoadBalancerStatus, 1),
-		ingressClassName: ctx.ingressClassName,
-		statusUpdater:    sh.Writer(),
-		Converter:        converter,
+		log:                  log.WithField("context", "loadBalancerStatusWriter"),
+		clients:              clients,
+		isLeader:             statusLeader,
+		lbStatus:             make(chan corev1.LoadBalancerStatus, 1),
+		ingressClassName:     ctx.ingressClassName,
+		ingressClassMatching: annotation.ClassMatching(ctx.Config.Ingress.ClassMatching),
+		statusUpdater:        statusUpdater,
+		Converter:            converter,
 	}
 	g.Add(lbsw.Start)
 
@@ -623,6 +842,23 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 			Info("Watching Service for Ingress status")
 	}
 
+	// Optionally serve the xDS REST API, for Envoys that can't
+	// reach Contour with gRPC and for debugging with curl. Its
+	// handler is set once the xDS server is created.
+	var restHandler xdsRESTHandler
+	if ctx.xdsRESTPort != 0 {
+		restsvc := httpsvc.Service{
+			Addr:        ctx.xdsAddr,
+			Port:        ctx.xdsRESTPort,
+			FieldLogger: log.WithField("context", "xds-rest"),
+		}
+		if !ctx.PermitInsecureGRPC {
+			restsvc.TLSConfig = ctx.tlsconfig(log)
+		}
+		restsvc.ServeMux.Handle("/v3/", &restHandler)
+		g.Add(restsvc.Start)
+	}
+
 	g.AddContext(func(taskCtx context.Context) error {
 		log := log.WithField("context", "xds")
 
@@ -632,19 +868,33 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		}
 		log.Printf("informer caches synced")
 
-		grpcServer := xds.NewServer(registry, ctx.grpcOptions(log)...)
+		// Only pass a non-nil authorizer to the gRPC server, as a nil
+		// *NodeAuthorizer would be a non-nil xds.Interceptor.
+		var grpcAuthorizer xds.Interceptor
+		authorizer := ctx.nodeAuthorizer(log)
+		if authorizer != nil {
+			grpcAuthorizer = authorizer
+		}
+
+		grpcServer := xds.NewServer(registry, ctx.streamRateLimiter(), grpcAuthorizer, ctx.grpcOptions(log)...)
 
+		var xdsServer contour_xds_v3.Server
 		switch ctx.Config.Server.XDSServerType {
 		case config.EnvoyServerType:
 			v3cache := contour_xds_v3.NewSnapshotCache(false, log)
 			snapshotHandler.AddSnapshotter(v3cache)
-			contour_xds_v3.RegisterServer(envoy_server_v3.NewServer(taskCtx, v3cache, contour_xds_v3.NewRequestLoggingCallbacks(log)), grpcServer)
+			xdsServer = envoy_server_v3.NewServer(taskCtx, v3cache, contour_xds_v3.NewRequestLoggingCallbacks(log, eventHandler.OnNack))
 		case config.ContourServerType:
-			contour_xds_v3.RegisterServer(contour_xds_v3.NewContourServer(log, xdscache.ResourcesOf(resources)...), grpcServer)
+			xdsServer = contour_xds_v3.NewContourServer(log, eventHandler.OnNack, xdscache.ResourcesOf(resources)...)
 		default:
 			// This can't happen due to config validation.
 			log.Fatalf("invalid xDS server type %q", ctx.Config.Server.XDSServerType)
 		}
+		contour_xds_v3.RegisterServer(xdsServer, grpcServer)
+
+		if ctx.xdsRESTPort != 0 {
+			restHandler.set(contour_xds_v3.NewFetchHandler(log.WithField("context", "xds-rest"), xdsServer, authorizer))
+		}
 
 		addr := net.JoinHostPort(ctx.xdsAddr, strconv.Itoa(ctx.xdsPort))
 		l, err := net.Listen("tcp", addr)
@@ -690,6 +940,32 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 	return g.Run(context.Background())
 }
 
+// xdsRESTHandler serves the xDS REST API with the handler that is
+// set once the informer caches sync, and responds with a 503 status
+// until then.
+type xdsRESTHandler struct {
+	mu      sync.RWMutex
+	handler http.Handler
+}
+
+func (h *xdsRESTHandler) set(handler http.Handler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handler = handler
+}
+
+func (h *xdsRESTHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	handler := h.handler
+	h.mu.RUnlock()
+
+	if handler == nil {
+		http.Error(w, "xDS server is not ready", http.StatusServiceUnavailable)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
 func getDAGBuilder(ctx *serveContext, clients *k8s.Clients, clientCert, fallbackCert *types.NamespacedName, log logrus.FieldLogger) dag.Builder {
 	var requestHeadersPolicy dag.HeadersPolicy
 	if ctx.Config.Policy.RequestHeadersPolicy.Set != nil {
@@ -715,35 +991,149 @@ func getDAGBuilder(ctx *serveContext, clients *k8s.Clients, clientCert, fallback
 		responseHeadersPolicy.Remove = append(responseHeadersPolicy.Remove, ctx.Config.Policy.ResponseHeadersPolicy.Remove...)
 	}
 
+	// Round robin is Envoy's default, so leave it unset to
+	// keep the names of clusters that don't set a strategy.
+	var defaultLoadBalancerPolicy string
+	if ctx.Config.Cluster.LoadBalancerStrategy != config.RoundRobinLoadBalancerStrategy {
+		defaultLoadBalancerPolicy = string(ctx.Config.Cluster.LoadBalancerStrategy)
+	}
+
+	var defaultHealthCheckPolicy *dag.HTTPHealthCheckPolicy
+	if hc := ctx.Config.Cluster.HealthCheck; hc != nil {
+		defaultHealthCheckPolicy = &dag.HTTPHealthCheckPolicy{
+			Path:               hc.Path,
+			Host:               hc.Host,
+			Interval:           hc.Interval,
+			Timeout:            hc.Timeout,
+			UnhealthyThreshold: hc.UnhealthyThreshold,
+			HealthyThreshold:   hc.HealthyThreshold,
+		}
+	}
+
+	defaultLBConfig := dag.ClusterLBConfig{
+		HealthyPanicThreshold: ctx.Config.Cluster.HealthyPanicThreshold,
+	}
+	if rh := ctx.Config.Cluster.RingHash; rh != nil {
+		defaultLBConfig.MinimumRingSize = rh.MinimumRingSize
+		defaultLBConfig.MaximumRingSize = rh.MaximumRingSize
+		defaultLBConfig.RingHashFunction = string(rh.HashFunction)
+	}
+
+	var defaultRetryPolicy *dag.RetryPolicy
+	if rp := ctx.Config.Policy.RetryPolicy; rp != nil {
+		retryOn := rp.RetryOn
+		if len(retryOn) == 0 {
+			retryOn = config.DefaultRetryOn
+		}
+		perTryTimeout := timeout.DefaultSetting()
+		if rp.PerTryTimeout > 0 {
+			perTryTimeout = timeout.DurationSetting(rp.PerTryTimeout)
+		}
+		numRetries := rp.NumRetries
+		if numRetries == 0 {
+			numRetries = 1
+		}
+		defaultRetryPolicy = &dag.RetryPolicy{
+			RetryOn:              strings.Join(retryOn, ","),
+			RetriableStatusCodes: rp.RetriableStatusCodes,
+			NumRetries:           numRetries,
+			PerTryTimeout:        perTryTimeout,
+			RetryOtherHosts:      true,
+		}
+	}
+
+	// Taps are disabled unless they are configured, in which
+	// case they may be set at most an hour ahead by default.
+	var tapMaxDuration time.Duration
+	var tapNamespaces []string
+	if tap := ctx.Config.Tap; tap != nil {
+		tapMaxDuration = tap.MaxDuration
+		if tapMaxDuration == 0 {
+			tapMaxDuration = time.Hour
+		}
+		tapNamespaces = tap.Namespaces
+	}
+
 	// Get the appropriate DAG processors.
 	dagProcessors := []dag.Processor{
-		&dag.IngressProcessor{
-			FieldLogger:       log.WithField("context", "IngressProcessor"),
-			ClientCertificate: clientCert,
-		},
 		&dag.ExtensionServiceProcessor{
 			FieldLogger:       log.WithField("context", "ExtensionServiceProcessor"),
 			ClientCertificate: clientCert,
 		},
+		&dag.IngressProcessor{
+			FieldLogger:                     log.WithField("context", "IngressProcessor"),
+			ClientCertificate:               clientCert,
+			DefaultLoadBalancerPolicy:       defaultLoadBalancerPolicy,
+			DefaultHealthCheckPolicy:        defaultHealthCheckPolicy,
+			DefaultLBConfig:                 defaultLBConfig,
+			DefaultMaxRequestsPerConnection: ctx.Config.Cluster.MaxRequestsPerConnection,
+			DefaultRetryPolicy:              defaultRetryPolicy,
+		},
 		&dag.HTTPProxyProcessor{
-			DisablePermitInsecure: ctx.Config.DisablePermitInsecure,
-			FallbackCertificate:   fallbackCert,
-			DNSLookupFamily:       ctx.Config.Cluster.DNSLookupFamily,
-			ClientCertificate:     clientCert,
-			RequestHeadersPolicy:  &requestHeadersPolicy,
-			ResponseHeadersPolicy: &responseHeadersPolicy,
+			DisablePermitInsecure:           ctx.Config.DisablePermitInsecure,
+			FallbackCertificate:             fallbackCert,
+			DNSLookupFamily:                 ctx.Config.Cluster.DNSLookupFamily,
+			ClientCertificate:               clientCert,
+			RequestHeadersPolicy:            &requestHeadersPolicy,
+			ResponseHeadersPolicy:           &responseHeadersPolicy,
+			AllowedProtectedHeaders:         ctx.Config.Policy.AllowedProtectedHeaders,
+			DefaultLoadBalancerPolicy:       defaultLoadBalancerPolicy,
+			DefaultHealthCheckPolicy:        defaultHealthCheckPolicy,
+			DefaultLBConfig:                 defaultLBConfig,
+			DefaultMaxRequestsPerConnection: ctx.Config.Cluster.MaxRequestsPerConnection,
+			DefaultRetryPolicy:              defaultRetryPolicy,
+			MetadataLabels:                  ctx.Config.HTTPProxyLabels,
+			TapMaxDuration:                  tapMaxDuration,
+			TapNamespaces:                   tapNamespaces,
+			MaxIncludeDepth:                 ctx.Config.Includes.MaxDepth,
+			MaxIncludes:                     ctx.Config.Includes.MaxIncludes,
 		},
 	}
 
 	if ctx.Config.GatewayConfig != nil && clients.ResourcesExist(k8s.GatewayAPIResources()...) {
 		dagProcessors = append(dagProcessors, &dag.GatewayAPIProcessor{
-			FieldLogger: log.WithField("context", "GatewayAPIProcessor"),
+			FieldLogger:                     log.WithField("context", "GatewayAPIProcessor"),
+			AllowedProtectedHeaders:         ctx.Config.Policy.AllowedProtectedHeaders,
+			DefaultMaxRequestsPerConnection: ctx.Config.Cluster.MaxRequestsPerConnection,
+		})
+	}
+
+	// The exposed service processor has to go after the processors
+	// of the other route sources, since it does not expose Services
+	// whose virtual host they have already added.
+	if suffix := ctx.Config.ExposedServices.DomainSuffix; suffix != "" {
+		dagProcessors = append(dagProcessors, &dag.ExposedServiceProcessor{
+			FieldLogger:                     log.WithField("context", "ExposedServiceProcessor"),
+			DomainSuffix:                    suffix,
+			DefaultLoadBalancerPolicy:       defaultLoadBalancerPolicy,
+			DefaultHealthCheckPolicy:        defaultHealthCheckPolicy,
+			DefaultLBConfig:                 defaultLBConfig,
+			DefaultMaxRequestsPerConnection: ctx.Config.Cluster.MaxRequestsPerConnection,
 		})
 	}
 
 	// The listener processor has to go last since it looks at
 	// the output of the other processors.
-	dagProcessors = append(dagProcessors, &dag.ListenerProcessor{})
+	listenerProcessor := &dag.ListenerProcessor{
+		FieldLogger:       log.WithField("context", "ListenerProcessor"),
+		HealthVirtualHost: ctx.Config.Listener.HealthVirtualHost,
+	}
+	var configuredServiceRefs []*types.NamespacedName
+	fallbackPassthrough := ctx.Config.TLS.FallbackPassthroughService
+	if svc := namespacedNameOf(config.NamespacedName{Name: fallbackPassthrough.Name, Namespace: fallbackPassthrough.Namespace}); svc != nil {
+		log.WithField("context", "fallback-passthrough-service").Infof("enabled fallback passthrough service: %q port %d", svc, fallbackPassthrough.Port)
+		listenerProcessor.FallbackPassthroughService = svc
+		listenerProcessor.FallbackPassthroughPort = fallbackPassthrough.Port
+		configuredServiceRefs = append(configuredServiceRefs, svc)
+	}
+	dagProcessors = append(dagProcessors, listenerProcessor)
+
+	// The cluster namer has to go after the listener processor,
+	// since it names the clusters added by all the processors.
+	dagProcessors = append(dagProcessors, &envoy.ClusterNamer{
+		FieldLogger: log.WithField("context", "ClusterNamer"),
+		Naming:      ctx.Config.Cluster.Naming,
+	})
 
 	var configuredSecretRefs []*types.NamespacedName
 	if fallbackCert != nil {
@@ -755,10 +1145,14 @@ func getDAGBuilder(ctx *serveContext, clients *k8s.Clients, clientCert, fallback
 
 	builder := dag.Builder{
 		Source: dag.KubernetesCache{
-			RootNamespaces:       ctx.proxyRootNamespaces(),
-			IngressClassName:     ctx.ingressClassName,
-			ConfiguredSecretRefs: configuredSecretRefs,
-			FieldLogger:          log.WithField("context", "KubernetesCache"),
+			RootNamespaces:        ctx.proxyRootNamespaces(),
+			RequireIngressPolicy:  ctx.Config.RequireIngressPolicy,
+			IngressClassName:      ctx.ingressClassName,
+			IngressClassMatching:  annotation.ClassMatching(ctx.Config.Ingress.ClassMatching),
+			ConfiguredSecretRefs:  configuredSecretRefs,
+			ConfiguredServiceRefs: configuredServiceRefs,
+			HTTPProxyLabels:       len(ctx.Config.HTTPProxyLabels) > 0,
+			FieldLogger:           log.WithField("context", "KubernetesCache"),
 		},
 		Processors: dagProcessors,
 	}
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,10 +31,11 @@ func TestGetDAGBuilder(t *testing.T) {
 		// note that these first two assertions will not hold when a gateway
 		// is configured, but we don't currently have test cases that cover
 		// that so it's OK to keep them in the "common" assertions for now.
-		assert.Len(t, builder.Processors, 4)
+		assert.Len(t, builder.Processors, 5)
 		assert.Equal(t, types.NamespacedName{}, builder.Source.ConfiguredGateway)
 
-		assert.IsType(t, &dag.ListenerProcessor{}, builder.Processors[len(builder.Processors)-1])
+		assert.IsType(t, &dag.ListenerProcessor{}, builder.Processors[len(builder.Processors)-2])
+		assert.IsType(t, &envoy.ClusterNamer{}, builder.Processors[len(builder.Processors)-1])
 	}
 
 	t.Run("all default options", func(t *testing.T) {
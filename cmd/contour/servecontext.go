@@ -14,19 +14,25 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/projectcontour/contour/internal/audit"
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	contour_xds_v3 "github.com/projectcontour/contour/internal/xds/v3"
 	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
 	"github.com/projectcontour/contour/pkg/config"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
@@ -45,10 +51,20 @@ type serveContext struct {
 	debugAddr string
 	debugPort int
 
+	// TLS and authentication of the debug handler.
+	debugCert, debugKey, debugCAFile, debugTokenFile string
+
+	// debugNamespaceReview lets the portion of the DAG in a namespace
+	// be requested with a Kubernetes bearer token.
+	debugNamespaceReview bool
+
 	// contour's metrics handler parameters
 	metricsAddr string
 	metricsPort int
 
+	// TLS and authentication of the metrics handler.
+	metricsCert, metricsKey, metricsCAFile, metricsTokenFile string
+
 	// Contour's health handler parameters.
 	healthAddr string
 	healthPort int
@@ -81,6 +97,17 @@ type serveContext struct {
 
 	// DisableLeaderElection can only be set by command line flag.
 	DisableLeaderElection bool
+
+	// disableStatusWrites publishes status updates for a separate
+	// status writer on the status address and port, rather than
+	// writing them.
+	disableStatusWrites bool
+	statusAddr          string
+	statusPort          int
+
+	// statusTokenFile is the bearer token file that authenticates
+	// the status writer's requests for status updates.
+	statusTokenFile string
 }
 
 // newServeContext returns a serveContext initialized to defaults.
@@ -104,6 +131,8 @@ func newServeContext() *serveContext {
 		httpsPort:             8443,
 		PermitInsecureGRPC:    false,
 		DisableLeaderElection: false,
+		statusAddr:            "127.0.0.1",
+		statusPort:            8003,
 		ServerConfig: ServerConfig{
 			xdsAddr: "127.0.0.1",
 			xdsPort: 8001,
@@ -115,6 +144,7 @@ type ServerConfig struct {
 	// contour's xds service parameters
 	xdsAddr                         string
 	xdsPort                         int
+	xdsRESTPort                     int
 	caFile, contourCert, contourKey string
 }
 
@@ -122,6 +152,11 @@ type ServerConfig struct {
 // if ctx.PermitInsecureGRPC is false, the option set will
 // include TLS configuration.
 func (ctx *serveContext) grpcOptions(log logrus.FieldLogger) []grpc.ServerOption {
+	maxConcurrentStreams := uint32(1 << 20)
+	if ctx.Config.Server.MaxConcurrentStreams > 0 {
+		maxConcurrentStreams = ctx.Config.Server.MaxConcurrentStreams
+	}
+
 	opts := []grpc.ServerOption{
 		// By default the Go grpc library defaults to a value of ~100 streams per
 		// connection. This number is likely derived from the HTTP/2 spec:
@@ -131,10 +166,11 @@ func (ctx *serveContext) grpcOptions(log logrus.FieldLogger) []grpc.ServerOption
 		// so set it the limit similar to envoyproxy/go-control-plane#70.
 		//
 		// Somewhat arbitrary limit to handle many, many, EDS streams.
-		grpc.MaxConcurrentStreams(1 << 20),
+		grpc.MaxConcurrentStreams(maxConcurrentStreams),
 		// Set gRPC keepalive params.
 		// See https://github.com/projectcontour/contour/issues/1756 for background.
 		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             ctx.Config.Server.KeepaliveMinTime,
 			PermitWithoutStream: true,
 		}),
 		grpc.KeepaliveParams(keepalive.ServerParameters{
@@ -142,6 +178,9 @@ func (ctx *serveContext) grpcOptions(log logrus.FieldLogger) []grpc.ServerOption
 			Timeout: 20 * time.Second,
 		}),
 	}
+	if ctx.Config.Server.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(ctx.Config.Server.MaxRecvMsgSize))
+	}
 	if !ctx.PermitInsecureGRPC {
 		tlsconfig := ctx.tlsconfig(log)
 		creds := credentials.NewTLS(tlsconfig)
@@ -150,6 +189,61 @@ func (ctx *serveContext) grpcOptions(log logrus.FieldLogger) []grpc.ServerOption
 	return opts
 }
 
+// streamRateLimiter returns a *rate.Limiter for new xDS streams, or
+// nil if ctx.Config.Server.MaxStreamsPerSecond is not set.
+func (ctx *serveContext) streamRateLimiter() *rate.Limiter {
+	if ctx.Config.Server.MaxStreamsPerSecond == 0 {
+		return nil
+	}
+
+	burst := ctx.Config.Server.MaxStreamsBurst
+	if burst == 0 {
+		burst = ctx.Config.Server.MaxStreamsPerSecond
+	}
+	return rate.NewLimiter(rate.Limit(ctx.Config.Server.MaxStreamsPerSecond), burst)
+}
+
+// nodeAuthorizer returns a *contour_xds_v3.NodeAuthorizer for xDS
+// requests, or nil if ctx.Config.Server.NodeAuthorization is not set.
+func (ctx *serveContext) nodeAuthorizer(log logrus.FieldLogger) *contour_xds_v3.NodeAuthorizer {
+	params := ctx.Config.Server.NodeAuthorization
+	if len(params.AllowedNodes) == 0 && len(params.AllowedClusters) == 0 && !params.BindSAN {
+		return nil
+	}
+
+	authorizer := &contour_xds_v3.NodeAuthorizer{
+		FieldLogger: log,
+		BindSAN:     params.BindSAN,
+	}
+	for _, m := range params.AllowedNodes {
+		authorizer.AllowedNodes = append(authorizer.AllowedNodes, contour_xds_v3.NodeMatch{Exact: m.Exact, Prefix: m.Prefix})
+	}
+	for _, m := range params.AllowedClusters {
+		authorizer.AllowedClusters = append(authorizer.AllowedClusters, contour_xds_v3.NodeMatch{Exact: m.Exact, Prefix: m.Prefix})
+	}
+	return authorizer
+}
+
+// auditSinks returns the audit streams that routing changes are
+// written to, or nil if the audit log is not configured.
+func (ctx *serveContext) auditSinks() ([]audit.Sink, error) {
+	var sinks []audit.Sink
+
+	if ctx.Config.Audit.File != "" {
+		f, err := audit.NewFileSink(ctx.Config.Audit.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		sinks = append(sinks, f)
+	}
+
+	if ctx.Config.Audit.Webhook != "" {
+		sinks = append(sinks, audit.NewWebhookSink(ctx.Config.Audit.Webhook))
+	}
+
+	return sinks, nil
+}
+
 // tlsconfig returns a new *tls.Config. If the context is not properly configured
 // for tls communication, tlsconfig returns nil.
 func (ctx *serveContext) tlsconfig(log logrus.FieldLogger) *tls.Config {
@@ -158,44 +252,104 @@ func (ctx *serveContext) tlsconfig(log logrus.FieldLogger) *tls.Config {
 		log.WithError(err).Fatal("failed to verify TLS flags")
 	}
 
-	// Define a closure that lazily loads certificates and key at TLS handshake
-	// to ensure that latest certificates are used in case they have been rotated.
-	loadConfig := func() (*tls.Config, error) {
-		cert, err := tls.LoadX509KeyPair(ctx.contourCert, ctx.contourKey)
-		if err != nil {
-			return nil, err
-		}
+	reloader := &tlsConfigReloader{
+		caFile:   ctx.caFile,
+		certFile: ctx.contourCert,
+		keyFile:  ctx.contourKey,
+		log:      log,
+	}
+
+	// Attempt to load certificates and key to catch configuration errors early.
+	if _, lerr := reloader.load(); lerr != nil {
+		log.WithError(lerr).Fatal("failed to load certificate and key")
+	}
+
+	return &tls.Config{
+		ClientAuth:         tls.RequireAndVerifyClientCert,
+		Rand:               rand.Reader,
+		GetConfigForClient: reloader.GetConfigForClient,
+	}
+}
+
+// tlsConfigReloader lazily loads the serving certificate, key and CA
+// bundle at each TLS handshake so that rotated credentials are picked up
+// without restarting the server. Only new handshakes see the rotated
+// credentials; established connections, and the xDS streams multiplexed
+// over them, are unaffected.
+//
+// The files are reparsed only when their contents change. If they can't
+// be loaded, for example because the certificate has been rotated but
+// the key has not yet, the last valid configuration keeps being served
+// so that Envoys reconnecting during the rotation aren't refused.
+type tlsConfigReloader struct {
+	caFile, certFile, keyFile string
+	log                       logrus.FieldLogger
+
+	mu     sync.Mutex
+	config *tls.Config
+	// contents holds the CA, certificate and key file
+	// contents that config was built from.
+	contents [3][]byte
+}
 
-		ca, err := ioutil.ReadFile(ctx.caFile)
+// GetConfigForClient returns the *tls.Config to use for a TLS handshake.
+func (r *tlsConfigReloader) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	config, err := r.load()
+	if err == nil {
+		return config, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.config == nil {
+		return nil, err
+	}
+	r.log.WithError(err).Warn("failed to reload certificate and key, serving previous version")
+	return r.config, nil
+}
+
+// load returns the *tls.Config built from the current contents of the
+// CA, certificate and key files, reusing the previous one if they have
+// not changed.
+func (r *tlsConfigReloader) load() (*tls.Config, error) {
+	var contents [3][]byte
+	for i, filename := range []string{r.caFile, r.certFile, r.keyFile} {
+		buf, err := ioutil.ReadFile(filename)
 		if err != nil {
 			return nil, err
 		}
+		contents[i] = buf
+	}
 
-		certPool := x509.NewCertPool()
-		if ok := certPool.AppendCertsFromPEM(ca); !ok {
-			return nil, fmt.Errorf("unable to append certificate in %s to CA pool", ctx.caFile)
-		}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		return &tls.Config{
-			Certificates: []tls.Certificate{cert},
-			ClientAuth:   tls.RequireAndVerifyClientCert,
-			ClientCAs:    certPool,
-			MinVersion:   tls.VersionTLS12,
-		}, nil
+	if r.config != nil && bytes.Equal(contents[0], r.contents[0]) &&
+		bytes.Equal(contents[1], r.contents[1]) && bytes.Equal(contents[2], r.contents[2]) {
+		return r.config, nil
 	}
 
-	// Attempt to load certificates and key to catch configuration errors early.
-	if _, lerr := loadConfig(); lerr != nil {
-		log.WithError(lerr).Fatal("failed to load certificate and key")
+	cert, err := tls.X509KeyPair(contents[1], contents[2])
+	if err != nil {
+		return nil, err
 	}
 
-	return &tls.Config{
-		ClientAuth: tls.RequireAndVerifyClientCert,
-		Rand:       rand.Reader,
-		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
-			return loadConfig()
-		},
+	certPool := x509.NewCertPool()
+	if ok := certPool.AppendCertsFromPEM(contents[0]); !ok {
+		return nil, fmt.Errorf("unable to append certificate in %s to CA pool", r.caFile)
+	}
+
+	if r.config != nil {
+		r.log.Info("reloaded rotated certificate and key")
 	}
+	r.config = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    certPool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	r.contents = contents
+	return r.config, nil
 }
 
 // verifyTLSFlags indicates if the TLS flags are set up correctly.
@@ -211,6 +365,60 @@ func (ctx *serveContext) verifyTLSFlags() error {
 	return nil
 }
 
+// validateListenerAddresses ensures that the HTTP and HTTPS listener
+// addresses are IP addresses that can be bound together with the
+// additional listener addresses.
+func (ctx *serveContext) validateListenerAddresses() error {
+	for _, addr := range []string{ctx.httpAddr, ctx.httpsAddr} {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return fmt.Errorf("invalid listener address %q", addr)
+		}
+
+		for _, additional := range ctx.Config.Listener.AdditionalAddresses {
+			other := net.ParseIP(additional)
+			if ip.Equal(other) {
+				return fmt.Errorf("additional listener address %q duplicates listener address %q", additional, addr)
+			}
+
+			// Envoy binds the IPv6 unspecified address so that it
+			// also accepts IPv4 connections, so it can't be bound
+			// together with an additional IPv4 address.
+			if ip.Equal(net.IPv6unspecified) && other.To4() != nil {
+				return fmt.Errorf("listener address %q already accepts IPv4 connections, additional IPv4 address %q is not allowed", addr, additional)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateDebugFlags ensures that the debug flags that depend
+// on each other are set together.
+func (ctx *serveContext) validateDebugFlags() error {
+	// Without a token file, the debug endpoint doesn't authenticate
+	// requests, so the DAG of every namespace is already exposed.
+	if ctx.debugNamespaceReview && ctx.debugTokenFile == "" {
+		return errors.New("--debug-namespace-review requires --debug-token-file")
+	}
+
+	return nil
+}
+
+// validateStatusFlags ensures that the status updates that Contour
+// publishes aren't readable by other hosts without authentication.
+func (ctx *serveContext) validateStatusFlags() error {
+	if !ctx.disableStatusWrites || ctx.statusTokenFile != "" {
+		return nil
+	}
+
+	if ip := net.ParseIP(ctx.statusAddr); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("--status-address %q is not a loopback address and requires --status-token-file", ctx.statusAddr)
+	}
+
+	return nil
+}
+
 // proxyRootNamespaces returns a slice of namespaces restricting where
 // contour should look for httpproxy roots.
 func (ctx *serveContext) proxyRootNamespaces() []string {
@@ -120,6 +120,139 @@ func TestServeContextTLSParams(t *testing.T) {
 	}
 }
 
+func TestServeContextListenerAddresses(t *testing.T) {
+	tests := map[string]struct {
+		httpAddr, httpsAddr string
+		additional          []string
+		expecterror         bool
+	}{
+		"ipv4 defaults": {
+			httpAddr:  "0.0.0.0",
+			httpsAddr: "0.0.0.0",
+		},
+		"ipv6 dual-stack": {
+			httpAddr:  "::",
+			httpsAddr: "::",
+		},
+		"ipv4 with additional ipv6": {
+			httpAddr:   "0.0.0.0",
+			httpsAddr:  "0.0.0.0",
+			additional: []string{"::"},
+		},
+		"ipv6 dual-stack with additional ipv4": {
+			httpAddr:    "::",
+			httpsAddr:   "::",
+			additional:  []string{"10.0.0.1"},
+			expecterror: true,
+		},
+		"duplicate additional address": {
+			httpAddr:    "0.0.0.0",
+			httpsAddr:   "10.0.0.1",
+			additional:  []string{"10.0.0.1"},
+			expecterror: true,
+		},
+		"invalid address": {
+			httpAddr:    "localhost",
+			httpsAddr:   "0.0.0.0",
+			expecterror: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newServeContext()
+			ctx.httpAddr = tc.httpAddr
+			ctx.httpsAddr = tc.httpsAddr
+			ctx.Config.Listener.AdditionalAddresses = tc.additional
+
+			err := ctx.validateListenerAddresses()
+			goterror := err != nil
+			if goterror != tc.expecterror {
+				t.Errorf("listener addresses: %v", err)
+			}
+		})
+	}
+}
+
+func TestServeContextDebugFlags(t *testing.T) {
+	tests := map[string]struct {
+		tokenFile       string
+		namespaceReview bool
+		expecterror     bool
+	}{
+		"defaults": {},
+		"token file": {
+			tokenFile: "/path/to/token",
+		},
+		"namespace review with token file": {
+			tokenFile:       "/path/to/token",
+			namespaceReview: true,
+		},
+		"namespace review without token file": {
+			namespaceReview: true,
+			expecterror:     true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newServeContext()
+			ctx.debugTokenFile = tc.tokenFile
+			ctx.debugNamespaceReview = tc.namespaceReview
+
+			err := ctx.validateDebugFlags()
+			goterror := err != nil
+			if goterror != tc.expecterror {
+				t.Errorf("debug flags: %v", err)
+			}
+		})
+	}
+}
+
+func TestServeContextStatusFlags(t *testing.T) {
+	tests := map[string]struct {
+		disableStatusWrites bool
+		addr                string
+		tokenFile           string
+		expecterror         bool
+	}{
+		"status writes enabled": {
+			addr: "0.0.0.0",
+		},
+		"loopback address": {
+			disableStatusWrites: true,
+			addr:                "127.0.0.1",
+		},
+		"unspecified address with token file": {
+			disableStatusWrites: true,
+			addr:                "0.0.0.0",
+			tokenFile:           "/path/to/token",
+		},
+		"unspecified address without token file": {
+			disableStatusWrites: true,
+			addr:                "0.0.0.0",
+			expecterror:         true,
+		},
+		"hostname without token file": {
+			disableStatusWrites: true,
+			addr:                "localhost",
+			expecterror:         true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := newServeContext()
+			ctx.disableStatusWrites = tc.disableStatusWrites
+			ctx.statusAddr = tc.addr
+			ctx.statusTokenFile = tc.tokenFile
+
+			err := ctx.validateStatusFlags()
+			goterror := err != nil
+			if goterror != tc.expecterror {
+				t.Errorf("status flags: %v", err)
+			}
+		})
+	}
+}
+
 // Testdata for this test case can be re-generated by running:
 // make gencerts
 // cp certs/*.pem cmd/contour/testdata/X/
@@ -211,6 +344,53 @@ func TestServeContextCertificateHandling(t *testing.T) {
 	}
 }
 
+func TestServeContextCertificateReloadFailure(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "contour-testdata-")
+	checkFatalErr(t, err)
+	defer os.RemoveAll(configDir)
+
+	ctx := serveContext{
+		ServerConfig: ServerConfig{
+			caFile:      filepath.Join(configDir, "CAcert.pem"),
+			contourCert: filepath.Join(configDir, "contourcert.pem"),
+			contourKey:  filepath.Join(configDir, "contourkey.pem"),
+		},
+	}
+
+	err = linkFiles("testdata/1", configDir)
+	checkFatalErr(t, err)
+
+	log := fixture.NewTestLogger(t)
+	preliminaryTLSConfig := ctx.tlsconfig(log)
+
+	serverCert := func() []byte {
+		t.Helper()
+		tlsConfig, err := preliminaryTLSConfig.GetConfigForClient(nil)
+		checkFatalErr(t, err)
+		return tlsConfig.Certificates[0].Certificate[0]
+	}
+
+	cert1, err := loadCertificate("testdata/1/contourcert.pem")
+	checkFatalErr(t, err)
+	cert2, err := loadCertificate("testdata/2/contourcert.pem")
+	checkFatalErr(t, err)
+
+	assert.Equal(t, cert1.Raw, serverCert())
+
+	// Rotate only the certificate, so that it no longer matches the key.
+	// The previous certificate keeps being served.
+	absCert, err := filepath.Abs("testdata/2/contourcert.pem")
+	checkFatalErr(t, err)
+	checkFatalErr(t, os.Remove(ctx.contourCert))
+	checkFatalErr(t, os.Symlink(absCert, ctx.contourCert))
+	assert.Equal(t, cert1.Raw, serverCert())
+
+	// Once the rotation completes the new certificate is served.
+	err = linkFiles("testdata/2", configDir)
+	checkFatalErr(t, err)
+	assert.Equal(t, cert2.Raw, serverCert())
+}
+
 func TestTlsVersionDeprecation(t *testing.T) {
 	// To get tls.Config for the gRPC XDS server, we need to arrange valid TLS certificates and keys.
 	// Create temporary directory to store them for the server.
@@ -17,11 +17,15 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/expfmt"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -53,6 +57,20 @@ type shutdownmanagerContext struct {
 	// shutdownReadyCheckInterval is the polling interval for the file used in the /shutdown endpoint
 	shutdownReadyCheckInterval time.Duration
 
+	// adminPort defines the Envoy admin interface port that open connections are read from
+	adminPort int
+
+	// adminProxyAddress and adminProxyPort define where the Envoy admin
+	// proxy listens. The admin proxy is disabled if the port is zero.
+	adminProxyAddress string
+	adminProxyPort    int
+	// adminProxyTokenFile is the path of the file holding the bearer
+	// token that requests to the admin proxy must present.
+	adminProxyTokenFile string
+
+	// drainDuration records the time taken for the /shutdown endpoint to detect the shutdownReadyFile
+	drainDuration prometheus.Gauge
+
 	logrus.FieldLogger
 }
 
@@ -70,6 +88,10 @@ type shutdownContext struct {
 	// that can be open when polling for active connections in Envoy
 	minOpenConnections int
 
+	// maxDrainTime defines the maximum time to poll for open connections
+	// after draining has started, zero means no limit
+	maxDrainTime time.Duration
+
 	// adminPort defines the port for our envoy pod, being configurable through --admin-port flag
 	adminPort int
 
@@ -82,6 +104,12 @@ func newShutdownManagerContext() *shutdownmanagerContext {
 		httpServePort:              8090,
 		shutdownReadyFile:          shutdownReadyFile,
 		shutdownReadyCheckInterval: shutdownReadyCheckInterval,
+		adminPort:                  9001,
+		adminProxyAddress:          "127.0.0.1",
+		drainDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "contour_shutdown_manager_drain_duration_seconds",
+			Help: "Time taken for Envoy to drain connections before it was allowed to terminate.",
+		}),
 	}
 }
 
@@ -91,6 +119,7 @@ func newShutdownContext() *shutdownContext {
 		checkDelay:         60 * time.Second,
 		drainDelay:         0,
 		minOpenConnections: 0,
+		maxDrainTime:       0,
 		adminPort:          9001,
 	}
 }
@@ -111,6 +140,7 @@ func (s *shutdownmanagerContext) healthzHandler(w http.ResponseWriter, r *http.R
 func (s *shutdownmanagerContext) shutdownReadyHandler(w http.ResponseWriter, r *http.Request) {
 	l := s.WithField("context", "shutdownReadyHandler")
 	ctx := r.Context()
+	start := time.Now()
 	for {
 		_, err := os.Stat(s.shutdownReadyFile)
 		if os.IsNotExist(err) {
@@ -118,6 +148,9 @@ func (s *shutdownmanagerContext) shutdownReadyHandler(w http.ResponseWriter, r *
 				s.shutdownReadyCheckInterval)
 		} else if err == nil {
 			l.Infof("detected file %s; sending HTTP response", s.shutdownReadyFile)
+			if s.drainDuration != nil {
+				s.drainDuration.Set(time.Since(start).Seconds())
+			}
 			http.StatusText(http.StatusOK)
 			if _, err := w.Write([]byte("OK")); err != nil {
 				l.Error(err)
@@ -166,6 +199,8 @@ func (s *shutdownContext) shutdownHandler() {
 		s.WithField("context", "shutdownHandler").Errorf("error sending envoy healthcheck fail after 4 attempts: %v", err)
 	}
 
+	drainStart := time.Now()
+
 	s.WithField("context", "shutdownHandler").Infof("waiting %s before polling for draining connections", s.checkDelay)
 	time.Sleep(s.checkDelay)
 
@@ -179,11 +214,7 @@ func (s *shutdownContext) shutdownHandler() {
 					WithField("open_connections", openConnections).
 					WithField("min_connections", s.minOpenConnections).
 					Info("min number of open connections found, shutting down")
-				file, err := os.Create(shutdownReadyFile)
-				if err != nil {
-					s.Error(err)
-				}
-				defer file.Close()
+				s.writeShutdownReadyFile()
 				return
 			}
 			s.WithField("context", "shutdownHandler").
@@ -191,10 +222,29 @@ func (s *shutdownContext) shutdownHandler() {
 				WithField("min_connections", s.minOpenConnections).
 				Info("polled open connections")
 		}
+
+		if s.maxDrainTime > 0 && time.Since(drainStart) >= s.maxDrainTime {
+			s.WithField("context", "shutdownHandler").
+				WithField("max_drain_time", s.maxDrainTime).
+				Info("max drain time exceeded, shutting down")
+			s.writeShutdownReadyFile()
+			return
+		}
 		time.Sleep(s.checkInterval)
 	}
 }
 
+// writeShutdownReadyFile creates the file that signals the /shutdown
+// endpoint that Envoy can terminate.
+func (s *shutdownContext) writeShutdownReadyFile() {
+	file, err := os.Create(shutdownReadyFile)
+	if err != nil {
+		s.Error(err)
+		return
+	}
+	defer file.Close()
+}
+
 // shutdownEnvoy sends a POST request to /healthcheck/fail to tell Envoy to start draining connections
 func shutdownEnvoy(adminPort int) error {
 	healthcheckFailURL := fmt.Sprintf(healthcheckFailURLFormat, adminPort)
@@ -262,13 +312,64 @@ func parseOpenConnections(stats io.Reader) (int, error) {
 	return openConnections, nil
 }
 
+// openConnectionsCollector is a prometheus.Collector that reports
+// the open connections of the Envoy listeners at scrape time.
+type openConnectionsCollector struct {
+	adminPort int
+	desc      *prometheus.Desc
+}
+
+func newOpenConnectionsCollector(adminPort int) *openConnectionsCollector {
+	return &openConnectionsCollector{
+		adminPort: adminPort,
+		desc: prometheus.NewDesc(
+			"contour_shutdown_manager_open_connections",
+			"Number of open connections on the Envoy listeners.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *openConnectionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *openConnectionsCollector) Collect(ch chan<- prometheus.Metric) {
+	openConnections, err := getOpenConnections(c.adminPort)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(openConnections))
+}
+
 func doShutdownManager(config *shutdownmanagerContext) {
 
 	config.Info("started envoy shutdown manager")
 	defer config.Info("stopped")
 
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(config.drainDuration, newOpenConnectionsCollector(config.adminPort))
+
+	if config.adminProxyPort != 0 {
+		token, err := readAdminProxyToken(config.adminProxyTokenFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		proxy, err := newAdminProxy(config.adminPort, token, config.FieldLogger)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			addr := net.JoinHostPort(config.adminProxyAddress, strconv.Itoa(config.adminProxyPort))
+			config.WithField("address", addr).Info("started envoy admin proxy")
+			log.Fatal(http.ListenAndServe(addr, proxy))
+		}()
+	}
+
 	http.HandleFunc("/healthz", config.healthzHandler)
 	http.HandleFunc("/shutdown", config.shutdownReadyHandler)
+	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.httpServePort), nil))
 }
 
@@ -279,6 +380,11 @@ func registerShutdownManager(cmd *kingpin.CmdClause, log logrus.FieldLogger) (*k
 
 	shutdownmgr := cmd.Command("shutdown-manager", "Start envoy shutdown-manager.")
 	shutdownmgr.Flag("serve-port", "Port to serve the http server on.").IntVar(&ctx.httpServePort)
+	shutdownmgr.Flag("admin-port", "Envoy admin interface port.").IntVar(&ctx.adminPort)
+	shutdownmgr.Flag("ready-check-interval", "Time to poll for the shutdown ready file.").DurationVar(&ctx.shutdownReadyCheckInterval)
+	shutdownmgr.Flag("admin-proxy-address", "Address to serve the Envoy admin proxy on.").PlaceHolder("<ipaddr>").StringVar(&ctx.adminProxyAddress)
+	shutdownmgr.Flag("admin-proxy-port", "Port to serve the Envoy admin proxy on. Zero disables the admin proxy.").PlaceHolder("<port>").IntVar(&ctx.adminProxyPort)
+	shutdownmgr.Flag("admin-proxy-token-file", "File holding the bearer token that admin proxy requests must present.").PlaceHolder("/path/to/file").StringVar(&ctx.adminProxyTokenFile)
 
 	return shutdownmgr, ctx
 }
@@ -294,6 +400,7 @@ func registerShutdown(cmd *kingpin.CmdClause, log logrus.FieldLogger) (*kingpin.
 	shutdown.Flag("check-delay", "Time to wait before polling Envoy for open connections.").Default("60s").DurationVar(&ctx.checkDelay)
 	shutdown.Flag("drain-delay", "Time to wait before draining Envoy connections.").Default("0s").DurationVar(&ctx.drainDelay)
 	shutdown.Flag("min-open-connections", "Min number of open connections when polling Envoy.").IntVar(&ctx.minOpenConnections)
+	shutdown.Flag("max-drain-time", "Max time to poll Envoy for open connections once draining has started. Zero means no limit.").Default("0s").DurationVar(&ctx.maxDrainTime)
 
 	return shutdown, ctx
 }
@@ -18,15 +18,20 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/projectcontour/contour/internal/fixture"
 )
@@ -118,6 +123,11 @@ func TestShutdownManager_ShutdownReadyHandler_Success(t *testing.T) {
 		t.Errorf("handler returned unexpected body: got %v want %v",
 			rr.Body.String(), expected)
 	}
+
+	// Check the drain duration was recorded.
+	m := &dto.Metric{}
+	require.NoError(t, mgr.drainDuration.Write(m))
+	assert.Greater(t, m.GetGauge().GetValue(), float64(0))
 }
 
 func TestShutdownManager_ShutdownReadyHandler_ClientCancel(t *testing.T) {
@@ -142,6 +152,36 @@ func TestShutdownManager_ShutdownReadyHandler_ClientCancel(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 }
 
+func TestOpenConnectionsCollector(t *testing.T) {
+	envoy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stats/prometheus" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, VALIDBOTH)
+	}))
+	defer envoy.Close()
+
+	_, port, err := net.SplitHostPort(envoy.Listener.Addr().String())
+	require.NoError(t, err)
+	adminPort, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newOpenConnectionsCollector(adminPort))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	assert.Equal(t, "contour_shutdown_manager_open_connections", families[0].GetName())
+	assert.Equal(t, float64(8), families[0].GetMetric()[0].GetGauge().GetValue())
+
+	// Scraping fails if Envoy can't be reached.
+	envoy.Close()
+	_, err = registry.Gather()
+	assert.Error(t, err)
+}
+
 func TestParseOpenConnections(t *testing.T) {
 	type testcase struct {
 		stats           io.Reader
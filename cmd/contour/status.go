@@ -0,0 +1,249 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/sirupsen/logrus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// registerStatus registers the status subcommand and flags
+// with the cli command provided.
+func registerStatus(cli *kingpin.CmdClause) (*kingpin.CmdClause, *statusContext) {
+	ctx := &statusContext{}
+
+	status := cli.Command("status", "List the status of HTTPProxies.")
+	status.Flag("incluster", "Use in cluster configuration.").BoolVar(&ctx.inCluster)
+	status.Flag("kubeconfig", "Path to kubeconfig (if not in running inside a cluster).").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).StringVar(&ctx.kubeconfig)
+	status.Flag("watch", "Stream changes to the status of HTTPProxies until interrupted.").Short('w').BoolVar(&ctx.watch)
+
+	return status, ctx
+}
+
+type statusContext struct {
+	// inCluster uses the in cluster Kubernetes configuration.
+	inCluster bool
+
+	// kubeconfig is the path to the Kubernetes configuration
+	// used when not running in a cluster.
+	kubeconfig string
+
+	// watch streams the changes to the status of HTTPProxies,
+	// rather than listing their current status.
+	watch bool
+}
+
+func doStatus(ctx *statusContext, out io.Writer, log logrus.FieldLogger) error {
+	clients, err := k8s.NewClients(ctx.kubeconfig, ctx.inCluster)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes clients: %w", err)
+	}
+
+	converter, err := k8s.NewUnstructuredConverter()
+	if err != nil {
+		return err
+	}
+
+	if !ctx.watch {
+		list, err := clients.DynamicClient().Resource(contour_api_v1.HTTPProxyGVR).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", contour_api_v1.HTTPProxyGVR.Resource, err)
+		}
+
+		var proxies []*contour_api_v1.HTTPProxy
+		for i := range list.Items {
+			obj, err := converter.FromUnstructured(&list.Items[i])
+			if err != nil {
+				log.WithError(err).Error("failed to convert object")
+				continue
+			}
+			if proxy, ok := obj.(*contour_api_v1.HTTPProxy); ok {
+				proxies = append(proxies, proxy)
+			}
+		}
+
+		return writeProxyStatuses(out, proxies)
+	}
+
+	inf, err := clients.InformerForResource(contour_api_v1.HTTPProxyGVR)
+	if err != nil {
+		return fmt.Errorf("failed to create informer: %w", err)
+	}
+
+	inf.AddEventHandler(&k8s.DynamicClientHandler{
+		Next:      newStatusWatcher(out, time.Now),
+		Converter: converter,
+		Logger:    log,
+	})
+
+	ctxt, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGTERM, syscall.SIGINT)
+		<-c
+		cancel()
+	}()
+
+	return clients.StartInformers(ctxt)
+}
+
+// proxyStatus is the summary of the status of a HTTPProxy
+// that is written by the status subcommand.
+type proxyStatus struct {
+	fqdn    string
+	status  string
+	reasons string
+}
+
+// proxyStatusOf summarizes the status of proxy. The reasons of
+// the errors and warnings of its Valid condition are listed,
+// with warnings marked as such.
+func proxyStatusOf(proxy *contour_api_v1.HTTPProxy) proxyStatus {
+	ps := proxyStatus{
+		fqdn:    "-",
+		status:  proxy.Status.CurrentStatus,
+		reasons: "-",
+	}
+
+	if proxy.Spec.VirtualHost != nil {
+		ps.fqdn = proxy.Spec.VirtualHost.Fqdn
+	}
+	if ps.status == "" {
+		ps.status = "<none>"
+	}
+
+	var reasons []string
+	for _, cond := range proxy.Status.Conditions {
+		if cond.Type != contour_api_v1.ValidConditionType {
+			continue
+		}
+		for _, e := range cond.Errors {
+			reasons = append(reasons, e.Reason)
+		}
+		for _, w := range cond.Warnings {
+			reasons = append(reasons, "warning:"+w.Reason)
+		}
+	}
+	if len(reasons) > 0 {
+		ps.reasons = strings.Join(reasons, ",")
+	}
+
+	return ps
+}
+
+// writeProxyStatuses writes a table of the status of each
+// of the proxies, ordered by namespace and name.
+func writeProxyStatuses(out io.Writer, proxies []*contour_api_v1.HTTPProxy) error {
+	sort.Slice(proxies, func(i, j int) bool {
+		return k8s.NamespacedNameOf(proxies[i]).String() < k8s.NamespacedNameOf(proxies[j]).String()
+	})
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HTTPPROXY\tFQDN\tSTATUS\tREASONS")
+
+	for _, proxy := range proxies {
+		ps := proxyStatusOf(proxy)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", k8s.NamespacedNameOf(proxy), ps.fqdn, ps.status, ps.reasons)
+	}
+
+	return w.Flush()
+}
+
+// statusWatcher is a cache.ResourceEventHandler that writes a row
+// each time the status of a HTTPProxy changes. It keeps the last
+// status it wrote for each HTTPProxy, so updates that do not change
+// the status, such as resyncs, are not written.
+type statusWatcher struct {
+	w    *tabwriter.Writer
+	now  func() time.Time
+	last map[types.NamespacedName]proxyStatus
+}
+
+func newStatusWatcher(out io.Writer, now func() time.Time) *statusWatcher {
+	// Rows are streamed, so each one is flushed as soon as it
+	// is written. The minimum cell widths keep the columns of
+	// successive rows aligned in most cases.
+	sw := &statusWatcher{
+		w:    tabwriter.NewWriter(out, 24, 0, 2, ' ', 0),
+		now:  now,
+		last: map[types.NamespacedName]proxyStatus{},
+	}
+
+	sw.write("TIME", "HTTPPROXY", "FQDN", "STATUS", "REASONS")
+	return sw
+}
+
+func (sw *statusWatcher) write(cells ...string) {
+	fmt.Fprintln(sw.w, strings.Join(cells, "\t"))
+	sw.w.Flush()
+}
+
+func (sw *statusWatcher) observe(proxy *contour_api_v1.HTTPProxy) {
+	name := k8s.NamespacedNameOf(proxy)
+	ps := proxyStatusOf(proxy)
+
+	if last, ok := sw.last[name]; ok && last == ps {
+		return
+	}
+
+	sw.last[name] = ps
+	sw.write(sw.now().Format(time.RFC3339), name.String(), ps.fqdn, ps.status, ps.reasons)
+}
+
+func (sw *statusWatcher) OnAdd(obj interface{}) {
+	if proxy, ok := obj.(*contour_api_v1.HTTPProxy); ok {
+		sw.observe(proxy)
+	}
+}
+
+func (sw *statusWatcher) OnUpdate(oldObj, newObj interface{}) {
+	if proxy, ok := newObj.(*contour_api_v1.HTTPProxy); ok {
+		sw.observe(proxy)
+	}
+}
+
+func (sw *statusWatcher) OnDelete(obj interface{}) {
+	switch obj := obj.(type) {
+	case *contour_api_v1.HTTPProxy:
+		name := k8s.NamespacedNameOf(obj)
+		ps, ok := sw.last[name]
+		if !ok {
+			return
+		}
+
+		delete(sw.last, name)
+		sw.write(sw.now().Format(time.RFC3339), name.String(), ps.fqdn, "deleted", "-")
+	case cache.DeletedFinalStateUnknown:
+		sw.OnDelete(obj.Obj) // recurse into ourselves with the tombstoned value
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func statusProxy(namespace, name, fqdn, status string, errors, warnings []string) *contour_api_v1.HTTPProxy {
+	cond := contour_api_v1.DetailedCondition{
+		Condition: contour_api_v1.Condition{Type: contour_api_v1.ValidConditionType},
+	}
+	for _, reason := range errors {
+		cond.Errors = append(cond.Errors, contour_api_v1.SubCondition{Reason: reason})
+	}
+	for _, reason := range warnings {
+		cond.Warnings = append(cond.Warnings, contour_api_v1.SubCondition{Reason: reason})
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status: contour_api_v1.HTTPProxyStatus{
+			CurrentStatus: status,
+			Conditions:    []contour_api_v1.DetailedCondition{cond},
+		},
+	}
+	if fqdn != "" {
+		proxy.Spec.VirtualHost = &contour_api_v1.VirtualHost{Fqdn: fqdn}
+	}
+	return proxy
+}
+
+func TestWriteProxyStatuses(t *testing.T) {
+	proxies := []*contour_api_v1.HTTPProxy{
+		statusProxy("team-b", "child", "", "valid", nil, nil),
+		statusProxy("team-a", "root", "example.com", "invalid", []string{"SecretNotValid"}, []string{"IgnoredField"}),
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeProxyStatuses(&out, proxies))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"HTTPPROXY", "FQDN", "STATUS", "REASONS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"team-a/root", "example.com", "invalid", "SecretNotValid,warning:IgnoredField"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"team-b/child", "-", "valid", "-"}, strings.Fields(lines[2]))
+}
+
+func TestStatusWatcher(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Date(2021, time.April, 1, 12, 0, 0, 0, time.UTC)
+	sw := newStatusWatcher(&out, func() time.Time { return now })
+
+	pending := statusProxy("default", "root", "example.com", "", nil, nil)
+	invalid := statusProxy("default", "root", "example.com", "invalid", []string{"SecretNotValid"}, nil)
+	valid := statusProxy("default", "root", "example.com", "valid", nil, nil)
+
+	sw.OnAdd(pending)
+	sw.OnUpdate(pending, invalid)
+	sw.OnUpdate(invalid, invalid.DeepCopy()) // Resyncs are not written.
+	sw.OnUpdate(invalid, valid)
+	sw.OnDelete(cache.DeletedFinalStateUnknown{Obj: valid})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, []string{"TIME", "HTTPPROXY", "FQDN", "STATUS", "REASONS"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"2021-04-01T12:00:00Z", "default/root", "example.com", "<none>", "-"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"2021-04-01T12:00:00Z", "default/root", "example.com", "invalid", "SecretNotValid"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"2021-04-01T12:00:00Z", "default/root", "example.com", "valid", "-"}, strings.Fields(lines[3]))
+	assert.Equal(t, []string{"2021-04-01T12:00:00Z", "default/root", "example.com", "deleted", "-"}, strings.Fields(lines[4]))
+}
@@ -0,0 +1,309 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/projectcontour/contour/internal/workgroup"
+	"github.com/projectcontour/contour/pkg/config"
+	"github.com/sirupsen/logrus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// registerStatusWriter registers the status-writer subcommand and flags
+// with the Application provided.
+func registerStatusWriter(app *kingpin.Application) (*kingpin.CmdClause, *statusWriterContext) {
+	ctx := &statusWriterContext{
+		statusURL:      "http://contour-status:8003/status",
+		pollInterval:   time.Second,
+		leaderElection: config.Defaults().LeaderElection,
+	}
+	ctx.leaderElection.Name = "leader-elect-status-writer"
+
+	sw := app.Command("status-writer", "Write the status updates published by Contours that run with --disable-status-writes.")
+	sw.Flag("incluster", "Use in cluster configuration.").BoolVar(&ctx.inCluster)
+	sw.Flag("kubeconfig", "Path to kubeconfig (if not in running inside a cluster).").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).StringVar(&ctx.kubeconfig)
+	sw.Flag("contour-status-url", "URL of the status publishing endpoint of Contour.").Default(ctx.statusURL).StringVar(&ctx.statusURL)
+	sw.Flag("contour-status-token-file", "Bearer token file name for authenticating to the status publishing endpoint of Contour.").PlaceHolder("/path/to/file").StringVar(&ctx.statusTokenFile)
+	sw.Flag("poll-interval", "Interval between requests for status updates.").Default(ctx.pollInterval.String()).DurationVar(&ctx.pollInterval)
+	sw.Flag("disable-leader-election", "Disable leader election mechanism.").BoolVar(&ctx.disableLeaderElection)
+	sw.Flag("leader-election-name", "Name of the leader election lock.").Default(ctx.leaderElection.Name).StringVar(&ctx.leaderElection.Name)
+	sw.Flag("leader-election-namespace", "Namespace of the leader election lock.").Default(ctx.leaderElection.Namespace).StringVar(&ctx.leaderElection.Namespace)
+	sw.Flag("debug", "Enable debug logging.").Short('d').BoolVar(&ctx.debug)
+
+	return sw, ctx
+}
+
+type statusWriterContext struct {
+	// inCluster uses the in cluster Kubernetes configuration.
+	inCluster bool
+
+	// kubeconfig is the path to the Kubernetes configuration
+	// used when not running in a cluster.
+	kubeconfig string
+
+	// statusURL is the URL that Contour publishes status updates on.
+	statusURL string
+
+	// statusTokenFile is the path of the file holding the bearer
+	// token that Contour requires for requests to statusURL.
+	statusTokenFile string
+
+	// pollInterval is the interval between requests to statusURL.
+	pollInterval time.Duration
+
+	// disableLeaderElection lets every status writer write status.
+	disableLeaderElection bool
+
+	// leaderElection configures the leader election of status
+	// writers. It uses a different lock from Contour's, so that
+	// the status writer doesn't depend on the Contour leader.
+	leaderElection config.LeaderElectionParameters
+
+	debug bool
+}
+
+func doStatusWriter(log logrus.FieldLogger, ctx *statusWriterContext) error {
+	clients, err := k8s.NewClients(ctx.kubeconfig, ctx.inCluster)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes clients: %w", err)
+	}
+
+	var token string
+	if ctx.statusTokenFile != "" {
+		buf, err := ioutil.ReadFile(ctx.statusTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read status token: %w", err)
+		}
+		if token = strings.TrimSpace(string(buf)); token == "" {
+			return fmt.Errorf("status token file %s is empty", ctx.statusTokenFile)
+		}
+	}
+
+	sw := &statusWriter{
+		log:          log.WithField("context", "statusWriter"),
+		statusURL:    ctx.statusURL,
+		token:        token,
+		pollInterval: ctx.pollInterval,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		write: func(st k8s.PublishedStatus) error {
+			opts := metav1.PatchOptions{FieldManager: k8s.StatusFieldManager}
+			if st.PatchType == types.ApplyPatchType {
+				force := true
+				opts.Force = &force
+			}
+
+			_, err := clients.DynamicClient().
+				Resource(st.Resource).
+				Namespace(st.Namespace).
+				Patch(context.Background(), st.Name, st.PatchType, st.Patch, opts, "status")
+			return err
+		},
+	}
+
+	var g workgroup.Group
+
+	if ctx.disableLeaderElection {
+		sw.isLeader = disableLeaderElection(log)
+	} else {
+		sw.isLeader = setupLeadershipElection(&g, log, &ctx.leaderElection, clients, func() {})
+	}
+
+	g.Add(sw.Start)
+
+	return g.Run(context.Background())
+}
+
+// statusWriter polls a Contour for the status updates it publishes,
+// and writes them while it is the leader.
+type statusWriter struct {
+	log          logrus.FieldLogger
+	statusURL    string
+	token        string
+	pollInterval time.Duration
+	client       *http.Client
+	isLeader     chan struct{}
+
+	// write writes a status to the API server.
+	write func(k8s.PublishedStatus) error
+
+	// epoch and sequence are the epoch and sequence number
+	// of the last response from Contour.
+	epoch    string
+	sequence uint64
+
+	// written holds the patch that was last written to each
+	// object, so that Contour's full resyncs, after it restarts
+	// or a different Contour responds, don't rewrite them.
+	written map[writtenStatusKey]string
+
+	// failed holds the statuses that couldn't be written, so that
+	// they are retried on the next sync even though Contour won't
+	// send them again until they change.
+	failed map[writtenStatusKey]k8s.PublishedStatus
+}
+
+type writtenStatusKey struct {
+	types.NamespacedName
+	Resource schema.GroupVersionResource
+}
+
+// Start waits to become the leader, then writes the published
+// status updates until stopped.
+func (sw *statusWriter) Start(stop <-chan struct{}) error {
+	select {
+	case <-stop:
+		return nil
+	case <-sw.isLeader:
+	}
+
+	sw.log.Info("elected leader, writing status updates")
+
+	ticker := time.NewTicker(sw.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := sw.sync(); err != nil {
+			sw.log.WithError(err).Error("failed to get status updates")
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync writes the statuses that changed since the previous sync.
+func (sw *statusWriter) sync() error {
+	resp, err := sw.fetch(sw.sequence)
+	if err != nil {
+		return err
+	}
+
+	if resp.Epoch != sw.epoch && sw.sequence != 0 {
+		// The sequence numbers of a different Contour, or of
+		// a restarted one, aren't comparable to ours, so fetch
+		// all of its statuses.
+		sw.log.WithField("epoch", resp.Epoch).Info("Contour changed, fetching all status updates")
+		if resp, err = sw.fetch(0); err != nil {
+			return err
+		}
+	}
+
+	if sw.written == nil {
+		sw.written = map[writtenStatusKey]string{}
+	}
+	if sw.failed == nil || resp.Epoch != sw.epoch {
+		// A full resync from a different Contour holds every
+		// status, so the earlier failures don't need retrying.
+		sw.failed = map[writtenStatusKey]k8s.PublishedStatus{}
+	}
+
+	// Retry the statuses that failed before, unless the
+	// response holds a newer one for the same object.
+	pending := make(map[writtenStatusKey]k8s.PublishedStatus, len(sw.failed))
+	for key, st := range sw.failed {
+		pending[key] = st
+	}
+	var statuses []k8s.PublishedStatus
+	for _, st := range resp.Statuses {
+		delete(pending, statusKey(st))
+		statuses = append(statuses, st)
+	}
+	for _, st := range pending {
+		statuses = append(statuses, st)
+	}
+
+	for _, st := range statuses {
+		key := statusKey(st)
+		if patch, ok := sw.written[key]; ok && patch == string(st.Patch) {
+			delete(sw.failed, key)
+			continue
+		}
+
+		if err := sw.write(st); err != nil {
+			sw.log.WithError(err).
+				WithField("name", st.Name).
+				WithField("namespace", st.Namespace).
+				WithField("resource", st.Resource).
+				Error("unable to update status")
+			sw.failed[key] = st
+			continue
+		}
+		sw.written[key] = string(st.Patch)
+		delete(sw.failed, key)
+	}
+
+	sw.epoch, sw.sequence = resp.Epoch, resp.Sequence
+	return nil
+}
+
+func statusKey(st k8s.PublishedStatus) writtenStatusKey {
+	return writtenStatusKey{
+		NamespacedName: types.NamespacedName{Namespace: st.Namespace, Name: st.Name},
+		Resource:       st.Resource,
+	}
+}
+
+// fetch requests the statuses that changed after the given sequence number.
+func (sw *statusWriter) fetch(since uint64) (*k8s.PublishedStatuses, error) {
+	u, err := url.Parse(sw.statusURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("since", strconv.FormatUint(since, 10))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if sw.token != "" {
+		req.Header.Set("Authorization", "Bearer "+sw.token)
+	}
+
+	resp, err := sw.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from %s: %s", sw.statusURL, resp.Status)
+	}
+
+	var statuses k8s.PublishedStatuses
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, fmt.Errorf("failed to decode status updates: %w", err)
+	}
+	return &statuses, nil
+}
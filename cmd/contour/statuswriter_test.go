@@ -0,0 +1,171 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStatusWriterSync(t *testing.T) {
+	status := func(name, currentStatus string) k8s.PublishedStatus {
+		return k8s.PublishedStatus{
+			Resource:  contour_api_v1.HTTPProxyGVR,
+			Namespace: "default",
+			Name:      name,
+			PatchType: types.ApplyPatchType,
+			Patch:     json.RawMessage(`{"status":{"currentStatus":"` + currentStatus + `"}}`),
+		}
+	}
+
+	// published holds the responses of the Contour by the
+	// "since" query parameter of the request.
+	var published map[string]k8s.PublishedStatuses
+	var requests []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("since")
+		requests = append(requests, since)
+		require.NoError(t, json.NewEncoder(w).Encode(published[since]))
+	}))
+	defer srv.Close()
+
+	var written []string
+	sw := &statusWriter{
+		log:       logrus.New(),
+		statusURL: srv.URL + "/status",
+		client:    srv.Client(),
+		write: func(st k8s.PublishedStatus) error {
+			written = append(written, st.Name)
+			return nil
+		},
+	}
+
+	published = map[string]k8s.PublishedStatuses{
+		"0": {Epoch: "one", Sequence: 2, Statuses: []k8s.PublishedStatus{status("a", "valid"), status("b", "valid")}},
+	}
+	require.NoError(t, sw.sync())
+	assert.Equal(t, []string{"0"}, requests)
+	assert.Equal(t, []string{"a", "b"}, written)
+
+	// Only the statuses that changed since are requested.
+	requests, written = nil, nil
+	published = map[string]k8s.PublishedStatuses{
+		"2": {Epoch: "one", Sequence: 3, Statuses: []k8s.PublishedStatus{status("a", "invalid")}},
+	}
+	require.NoError(t, sw.sync())
+	assert.Equal(t, []string{"2"}, requests)
+	assert.Equal(t, []string{"a"}, written)
+
+	// A restarted Contour is asked for all its statuses, and
+	// the ones that were already written are skipped.
+	requests, written = nil, nil
+	published = map[string]k8s.PublishedStatuses{
+		"3": {Epoch: "two", Sequence: 5},
+		"0": {Epoch: "two", Sequence: 2, Statuses: []k8s.PublishedStatus{status("a", "invalid"), status("b", "invalid")}},
+	}
+	require.NoError(t, sw.sync())
+	assert.Equal(t, []string{"3", "0"}, requests)
+	assert.Equal(t, []string{"b"}, written)
+	assert.Equal(t, uint64(2), sw.sequence)
+}
+
+func TestStatusWriterRetriesFailedWrites(t *testing.T) {
+	status := func(name, currentStatus string) k8s.PublishedStatus {
+		return k8s.PublishedStatus{
+			Resource:  contour_api_v1.HTTPProxyGVR,
+			Namespace: "default",
+			Name:      name,
+			PatchType: types.ApplyPatchType,
+			Patch:     json.RawMessage(`{"status":{"currentStatus":"` + currentStatus + `"}}`),
+		}
+	}
+
+	var published map[string]k8s.PublishedStatuses
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(published[r.URL.Query().Get("since")]))
+	}))
+	defer srv.Close()
+
+	var written []string
+	fail := map[string]bool{}
+	sw := &statusWriter{
+		log:       logrus.New(),
+		statusURL: srv.URL + "/status",
+		token:     "secret",
+		client:    srv.Client(),
+		write: func(st k8s.PublishedStatus) error {
+			if fail[st.Name] {
+				return errors.New("conflict")
+			}
+			written = append(written, st.Name+"="+string(st.Patch))
+			return nil
+		},
+	}
+
+	// The status of b fails to be written.
+	fail["b"] = true
+	published = map[string]k8s.PublishedStatuses{
+		"0": {Epoch: "one", Sequence: 2, Statuses: []k8s.PublishedStatus{status("a", "valid"), status("b", "valid")}},
+	}
+	require.NoError(t, sw.sync())
+	assert.Equal(t, []string{"a=" + string(status("a", "valid").Patch)}, written)
+
+	// It's retried on the next sync, although Contour
+	// doesn't send it again.
+	written, fail["b"] = nil, false
+	published = map[string]k8s.PublishedStatuses{
+		"2": {Epoch: "one", Sequence: 2},
+	}
+	require.NoError(t, sw.sync())
+	assert.Equal(t, []string{"b=" + string(status("b", "valid").Patch)}, written)
+
+	// Once written, it isn't retried again.
+	written = nil
+	require.NoError(t, sw.sync())
+	assert.Empty(t, written)
+
+	// A failed status is superseded by a newer one from Contour.
+	fail["a"] = true
+	published = map[string]k8s.PublishedStatuses{
+		"2": {Epoch: "one", Sequence: 3, Statuses: []k8s.PublishedStatus{status("a", "invalid")}},
+	}
+	require.NoError(t, sw.sync())
+	assert.Empty(t, written)
+
+	fail["a"] = false
+	published = map[string]k8s.PublishedStatuses{
+		"3": {Epoch: "one", Sequence: 4, Statuses: []k8s.PublishedStatus{status("a", "orphaned")}},
+	}
+	require.NoError(t, sw.sync())
+	assert.Equal(t, []string{"a=" + string(status("a", "orphaned").Patch)}, written)
+
+	// Requests without the token are rejected.
+	sw.token = ""
+	assert.Error(t, sw.sync())
+}
@@ -54,25 +54,39 @@ var annotationsByKind = map[string]map[string]struct{}{
 		"ingress.kubernetes.io/force-ssl-redirect":       {},
 		"kubernetes.io/ingress.allow-http":               {},
 		"kubernetes.io/ingress.class":                    {},
+		"projectcontour.io/auth-context":                 {},
+		"projectcontour.io/auth-disabled":                {},
+		"projectcontour.io/auth-fail-open":               {},
+		"projectcontour.io/auth-response-timeout":        {},
+		"projectcontour.io/auth-service":                 {},
 		"projectcontour.io/ingress.class":                {},
 		"projectcontour.io/num-retries":                  {},
+		"projectcontour.io/path-response-timeouts":       {},
+		"projectcontour.io/request-timeout":              {},
 		"projectcontour.io/response-timeout":             {},
 		"projectcontour.io/retry-on":                     {},
 		"projectcontour.io/tls-minimum-protocol-version": {},
 		"projectcontour.io/websocket-routes":             {},
 	},
 	"Service": {
-		"projectcontour.io/max-connections":       {},
-		"projectcontour.io/max-pending-requests":  {},
-		"projectcontour.io/max-requests":          {},
-		"projectcontour.io/max-retries":           {},
-		"projectcontour.io/upstream-protocol.h2":  {},
-		"projectcontour.io/upstream-protocol.h2c": {},
-		"projectcontour.io/upstream-protocol.tls": {},
+		"projectcontour.io/expose":                      {},
+		"projectcontour.io/max-connections":             {},
+		"projectcontour.io/max-pending-requests":        {},
+		"projectcontour.io/max-requests":                {},
+		"projectcontour.io/max-requests-per-connection": {},
+		"projectcontour.io/max-retries":                 {},
+		"projectcontour.io/upstream-protocol.h2":        {},
+		"projectcontour.io/upstream-protocol.h2c":       {},
+		"projectcontour.io/upstream-protocol.tls":       {},
 	},
 	"HTTPProxy": {
-		"kubernetes.io/ingress.class":     {},
-		"projectcontour.io/ingress.class": {},
+		"kubernetes.io/ingress.class":       {},
+		"projectcontour.io/ingress.class":   {},
+		"projectcontour.io/tap-path-prefix": {},
+		"projectcontour.io/tap-until":       {},
+	},
+	"Namespace": {
+		"projectcontour.io/routes-blocked": {},
 	},
 }
 
@@ -128,6 +142,25 @@ func ParseUpstreamProtocols(m map[string]string) map[string]string {
 	return up
 }
 
+// ParseAppProtocol maps the appProtocol field of a Kubernetes ServicePort
+// to the equivalent upstream protocol. It returns false if the value is
+// not one Contour understands. The "kubernetes.io/ws" protocol maps to
+// the empty string, which selects HTTP/1.1 to the upstream.
+func ParseAppProtocol(appProtocol string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(appProtocol)) {
+	case "kubernetes.io/h2c", "h2c", "grpc":
+		return "h2c", true
+	case "kubernetes.io/ws", "http":
+		return "", true
+	case "kubernetes.io/wss", "https", "tls":
+		return "tls", true
+	case "h2":
+		return "h2", true
+	default:
+		return "", false
+	}
+}
+
 // HTTPAllowed returns true unless the kubernetes.io/ingress.allow-http annotation is
 // present and set to false.
 func HTTPAllowed(i *networking_v1.Ingress) bool {
@@ -153,6 +186,60 @@ func WebsocketRoutes(i *networking_v1.Ingress) map[string]bool {
 	return routes
 }
 
+// PathResponseTimeouts retrieves the per-path response timeouts from the
+// projectcontour.io/path-response-timeouts annotation. The annotation value
+// is a comma separated list of path=timeout pairs, where each path must match
+// one defined in the Ingress. Entries without a path or a timeout are ignored.
+func PathResponseTimeouts(i *networking_v1.Ingress) map[string]string {
+	timeouts := make(map[string]string)
+	for _, v := range strings.Split(ContourAnnotation(i, "path-response-timeouts"), ",") {
+		// Split on the last '=' since the timeout can't contain one.
+		sep := strings.LastIndex(v, "=")
+		if sep < 0 {
+			continue
+		}
+		path := strings.TrimSpace(v[:sep])
+		timeout := strings.TrimSpace(v[sep+1:])
+		if path != "" && timeout != "" {
+			timeouts[path] = timeout
+		}
+	}
+	return timeouts
+}
+
+// AuthContext retrieves the authorization context of the routes of
+// an Ingress from the projectcontour.io/auth-context annotation. The
+// annotation value is a comma separated list of key=value pairs.
+// Entries without a key are ignored.
+func AuthContext(i *networking_v1.Ingress) map[string]string {
+	context := make(map[string]string)
+	for _, v := range strings.Split(ContourAnnotation(i, "auth-context"), ",") {
+		sep := strings.Index(v, "=")
+		if sep < 0 {
+			continue
+		}
+		key := strings.TrimSpace(v[:sep])
+		if key != "" {
+			context[key] = strings.TrimSpace(v[sep+1:])
+		}
+	}
+	return context
+}
+
+// AuthDisabled returns true if the projectcontour.io/auth-disabled
+// annotation is set to true, disabling authorization of the routes
+// of an Ingress.
+func AuthDisabled(i *networking_v1.Ingress) bool {
+	return ContourAnnotation(i, "auth-disabled") == "true"
+}
+
+// RoutesBlocked returns true if the projectcontour.io/routes-blocked
+// annotation of a Namespace is set to true, blocking the routes of
+// the objects in the Namespace.
+func RoutesBlocked(ns metav1.Object) bool {
+	return ContourAnnotation(ns, "routes-blocked") == "true"
+}
+
 // NumRetries returns the number of retries specified by the
 // "projectcontour.io/num-retries" annotation.
 func NumRetries(i *networking_v1.Ingress) uint32 {
@@ -179,11 +266,50 @@ func IngressClass(o metav1.Object) string {
 	return ""
 }
 
+// ClassMatching selects whether objects that have no ingress
+// class set are matched.
+type ClassMatching string
+
+const (
+	// ClassMatchingStrict never matches objects that have no
+	// ingress class set.
+	ClassMatchingStrict ClassMatching = "strict"
+
+	// ClassMatchingPermissive matches objects that have no
+	// ingress class set, whichever ingress class is configured.
+	ClassMatchingPermissive ClassMatching = "permissive"
+)
+
+// MatchesClassless returns true if objects that have no ingress class
+// set match the ingress class ic. By default, they match only when ic
+// is empty.
+func (m ClassMatching) MatchesClassless(ic string) bool {
+	switch m {
+	case ClassMatchingStrict:
+		return false
+	case ClassMatchingPermissive:
+		return true
+	default:
+		return ic == ""
+	}
+}
+
 // MatchesIngressClass checks that the passed object has an ingress class that matches
 // either the passed ingress-class string, or DEFAULT_INGRESS_CLASS if it's empty.
 func MatchesIngressClass(o metav1.Object, ic string) bool {
+	return MatchesIngressClassWith(o, ic, "")
+}
+
+// MatchesIngressClassWith checks that the passed object matches the
+// ingress class ic as MatchesIngressClass does, except that objects
+// with no ingress class set are matched as m selects.
+func MatchesIngressClassWith(o metav1.Object, ic string, m ClassMatching) bool {
+	class := IngressClass(o)
+	if class == "" {
+		return m.MatchesClassless(ic)
+	}
 
-	switch IngressClass(o) {
+	switch class {
 	case ic:
 		// Handles ic == "" and ic == "custom".
 		return true
@@ -244,3 +370,18 @@ func MaxRequests(o metav1.Object) uint32 {
 func MaxRetries(o metav1.Object) uint32 {
 	return parseUInt32(ContourAnnotation(o, "max-retries"))
 }
+
+// MaxRequestsPerConnection returns the value of the first matching
+// max-requests-per-connection annotation for the following annotations:
+// 1. projectcontour.io/max-requests-per-connection
+//
+// '0' is returned if the annotation is absent or unparsable.
+func MaxRequestsPerConnection(o metav1.Object) uint32 {
+	return parseUInt32(ContourAnnotation(o, "max-requests-per-connection"))
+}
+
+// Exposed returns true if the object has the annotation
+// projectcontour.io/expose: "true".
+func Exposed(o metav1.Object) bool {
+	return ContourAnnotation(o, "expose") == "true"
+}
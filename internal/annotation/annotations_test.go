@@ -111,6 +111,31 @@ func TestParseUpstreamProtocols(t *testing.T) {
 	}
 }
 
+func TestParseAppProtocol(t *testing.T) {
+	tests := map[string]struct {
+		appProtocol string
+		want        string
+		wantOK      bool
+	}{
+		"empty":             {appProtocol: "", want: "", wantOK: false},
+		"kubernetes.io/h2c": {appProtocol: "kubernetes.io/h2c", want: "h2c", wantOK: true},
+		"kubernetes.io/ws":  {appProtocol: "kubernetes.io/ws", want: "", wantOK: true},
+		"kubernetes.io/wss": {appProtocol: "kubernetes.io/wss", want: "tls", wantOK: true},
+		"grpc":              {appProtocol: "grpc", want: "h2c", wantOK: true},
+		"h2":                {appProtocol: "h2", want: "h2", wantOK: true},
+		"mixed case":        {appProtocol: "GRPC", want: "h2c", wantOK: true},
+		"unknown":           {appProtocol: "example.com/custom", want: "", wantOK: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := ParseAppProtocol(tc.appProtocol)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantOK, ok)
+		})
+	}
+}
+
 func TestWebsocketRoutes(t *testing.T) {
 	tests := map[string]struct {
 		a    *networking_v1.Ingress
@@ -184,6 +209,88 @@ func TestWebsocketRoutes(t *testing.T) {
 	}
 }
 
+func TestPathResponseTimeouts(t *testing.T) {
+	tests := map[string]struct {
+		a    map[string]string
+		want map[string]string
+	}{
+		"nada": {
+			a:    nil,
+			want: map[string]string{},
+		},
+		"single value": {
+			a: map[string]string{
+				"projectcontour.io/path-response-timeouts": "/api=30s",
+			},
+			want: map[string]string{
+				"/api": "30s",
+			},
+		},
+		"multiple values": {
+			a: map[string]string{
+				"projectcontour.io/path-response-timeouts": "/api=30s,/upload=infinity",
+			},
+			want: map[string]string{
+				"/api":    "30s",
+				"/upload": "infinity",
+			},
+		},
+		"multiple values with spaces and invalid entries": {
+			a: map[string]string{
+				"projectcontour.io/path-response-timeouts": " /api = 30s, , /nope, =10s, /empty=, /upload=5m ",
+			},
+			want: map[string]string{
+				"/api":    "30s",
+				"/upload": "5m",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := PathResponseTimeouts(&networking_v1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tc.a,
+				},
+			})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAuthContext(t *testing.T) {
+	tests := map[string]struct {
+		a    map[string]string
+		want map[string]string
+	}{
+		"nada": {
+			a:    nil,
+			want: map[string]string{},
+		},
+		"multiple values with spaces and invalid entries": {
+			a: map[string]string{
+				"projectcontour.io/auth-context": " team = payments, , nokey, =value, empty=, env=prod ",
+			},
+			want: map[string]string{
+				"team":  "payments",
+				"empty": "",
+				"env":   "prod",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := AuthContext(&networking_v1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tc.a,
+				},
+			})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestHttpAllowed(t *testing.T) {
 	tests := map[string]struct {
 		i     *networking_v1.Ingress
@@ -306,6 +413,18 @@ func TestAnnotationKindValidation(t *testing.T) {
 				},
 			},
 		},
+		"namespace": {
+			obj: &v1.Namespace{},
+			annotations: map[string]status{
+				"projectcontour.io/routes-blocked": {
+					known: true, valid: true,
+				},
+				// Valid only on Ingress.
+				"projectcontour.io/retry-on": {
+					known: true, valid: false,
+				},
+			},
+		},
 		"secrets": {
 			obj: &v1.Secret{},
 			annotations: map[string]status{
@@ -327,6 +446,7 @@ func TestAnnotationKindValidation(t *testing.T) {
 		kindOf(&v1.Service{}),
 		kindOf(&networking_v1.Ingress{}),
 		kindOf(&contour_api_v1.HTTPProxy{}),
+		kindOf(&v1.Namespace{}),
 	} {
 		for key := range annotationsByKind[kind] {
 			t.Run(fmt.Sprintf("%s is known and valid for %s", key, kind),
@@ -433,6 +553,44 @@ func TestMatchIngressClass(t *testing.T) {
 	}
 }
 
+func TestMatchesIngressClassWith(t *testing.T) {
+	classless := &contour_api_v1.HTTPProxy{}
+	classed := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				"projectcontour.io/ingress.class": "something",
+			},
+		},
+	}
+
+	tests := map[ClassMatching]struct {
+		classless []bool
+		classed   []bool
+	}{
+		"": {
+			classless: []bool{true, false},
+			classed:   []bool{false, true},
+		},
+		ClassMatchingStrict: {
+			classless: []bool{false, false},
+			classed:   []bool{false, true},
+		},
+		ClassMatchingPermissive: {
+			classless: []bool{true, true},
+			classed:   []bool{false, true},
+		},
+	}
+
+	for matching, tc := range tests {
+		t.Run(string(matching), func(t *testing.T) {
+			for i, ic := range []string{"", "something"} {
+				assert.Equal(t, tc.classless[i], MatchesIngressClassWith(classless, ic, matching), "classless against %q", ic)
+				assert.Equal(t, tc.classed[i], MatchesIngressClassWith(classed, ic, matching), "classed against %q", ic)
+			}
+		})
+	}
+}
+
 func backend(name string, port intstr.IntOrString) *networking_v1.IngressBackend {
 	var portObj networking_v1.ServiceBackendPort
 	if port.Type == intstr.Int {
@@ -472,6 +630,8 @@ func kindOf(obj interface{}) string {
 		return "HTTPProxy"
 	case *contour_api_v1.TLSCertificateDelegation:
 		return "TLSCertificateDelegation"
+	case *v1.Namespace:
+		return "Namespace"
 	default:
 		return ""
 	}
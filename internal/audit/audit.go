@@ -0,0 +1,146 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the effective routing changes that Contour
+// makes to an append-only audit stream.
+package audit
+
+import (
+	"time"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/sirupsen/logrus"
+)
+
+// Record is an audit record of an effective routing change.
+type Record struct {
+	Time             time.Time      `json:"time"`
+	Type             dag.ChangeType `json:"type"`
+	Listener         string         `json:"listener"`
+	VirtualHost      string         `json:"vhost"`
+	Route            string         `json:"route,omitempty"`
+	Backends         []string       `json:"backends,omitempty"`
+	PreviousBackends []string       `json:"previousBackends,omitempty"`
+	Sources          []Source       `json:"sources,omitempty"`
+}
+
+// Source is the Kubernetes object that caused a routing change.
+type Source struct {
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	ChangeCause string `json:"changeCause,omitempty"`
+}
+
+// Sink writes audit records to an audit stream.
+type Sink interface {
+	Write(records []Record) error
+}
+
+// Observer is a dag.Observer that compares the routing of each DAG
+// with the previous one, and writes the changes to its sinks.
+type Observer struct {
+	logrus.FieldLogger
+
+	// Sinks are the audit streams changes are written to.
+	Sinks []Sink
+
+	// LeaderElected will become ready to read when this Contour
+	// becomes the leader. Until then, changes are not written.
+	LeaderElected chan struct{}
+
+	isLeader bool
+	prev     dag.RoutingTable
+	records  chan []Record
+}
+
+// NewObserver returns an Observer that writes to sinks once
+// leaderElected is ready to read.
+func NewObserver(log logrus.FieldLogger, leaderElected chan struct{}, sinks ...Sink) *Observer {
+	return &Observer{
+		FieldLogger:   log,
+		Sinks:         sinks,
+		LeaderElected: leaderElected,
+		records:       make(chan []Record, 100),
+	}
+}
+
+// OnChange records the routing changes from the previous DAG to d.
+// Every DAG is compared, so that the changes a new leader writes are
+// relative to the DAG before it was elected.
+func (o *Observer) OnChange(d *dag.DAG) {
+	next := dag.NewRoutingTable(d)
+	changes := dag.Diff(o.prev, next)
+	o.prev = next
+
+	if len(changes) == 0 {
+		return
+	}
+
+	now := time.Now()
+	records := make([]Record, 0, len(changes))
+	for _, c := range changes {
+		records = append(records, newRecord(now, c))
+	}
+
+	select {
+	case o.records <- records:
+	default:
+		o.WithField("records", len(records)).Error("audit stream is not keeping up, dropping records")
+	}
+}
+
+// Start writes records to the sinks until stop is closed. Until
+// this Contour is elected leader, records are dropped.
+func (o *Observer) Start(stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-o.LeaderElected:
+			o.isLeader = true
+			// disable this case
+			o.LeaderElected = nil
+		case records := <-o.records:
+			if !o.isLeader {
+				continue
+			}
+			for _, s := range o.Sinks {
+				if err := s.Write(records); err != nil {
+					o.WithError(err).WithField("records", len(records)).Error("failed to write audit records")
+				}
+			}
+		}
+	}
+}
+
+func newRecord(now time.Time, c dag.Change) Record {
+	r := Record{
+		Time:             now,
+		Type:             c.Type,
+		Listener:         c.VirtualHost.ListenerName,
+		VirtualHost:      c.VirtualHost.Name,
+		Route:            c.Route,
+		Backends:         c.Backends,
+		PreviousBackends: c.PreviousBackends,
+	}
+	for _, s := range c.Sources {
+		r.Sources = append(r.Sources, Source{
+			Kind:        s.Kind,
+			Namespace:   s.Namespace,
+			Name:        s.Name,
+			ChangeCause: s.ChangeCause,
+		})
+	}
+	return r
+}
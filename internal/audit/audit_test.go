@@ -0,0 +1,158 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type recordingSink struct {
+	records chan []Record
+}
+
+func (s *recordingSink) Write(records []Record) error {
+	s.records <- records
+	return nil
+}
+
+func buildDAG(t *testing.T, objs ...interface{}) *dag.DAG {
+	t.Helper()
+
+	builder := dag.Builder{
+		Source: dag.KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []dag.Processor{
+			&dag.HTTPProxyProcessor{},
+			&dag.ListenerProcessor{},
+		},
+	}
+	for _, o := range objs {
+		builder.Source.Insert(o)
+	}
+	return builder.Build()
+}
+
+func TestObserver(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+	proxy := fixture.NewProxy("default/kuard").
+		WithFQDN("kuard.example.com").
+		Annotate("kubernetes.io/change-cause", "kubectl apply --filename=kuard.yaml").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+			}},
+		})
+
+	sink := &recordingSink{records: make(chan []Record, 10)}
+	leaderElected := make(chan struct{})
+	o := NewObserver(fixture.NewTestLogger(t), leaderElected, sink)
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- o.Start(stop) }()
+
+	// Not yet leader, so the change is not written.
+	o.OnChange(buildDAG(t, kuard, proxy))
+	require.Eventually(t, func() bool { return len(o.records) == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	close(leaderElected)
+	o.OnChange(buildDAG(t, kuard))
+
+	select {
+	case records := <-sink.records:
+		require.Len(t, records, 2)
+		assert.Equal(t, dag.VirtualHostRemoved, records[0].Type)
+		assert.Equal(t, "kuard.example.com", records[0].VirtualHost)
+		assert.Equal(t, dag.RouteRemoved, records[1].Type)
+		assert.Equal(t, []string{"default/kuard:8080"}, records[1].PreviousBackends)
+		assert.Equal(t, []Source{{
+			Kind:        "HTTPProxy",
+			Namespace:   "default",
+			Name:        "kuard",
+			ChangeCause: "kubectl apply --filename=kuard.yaml",
+		}}, records[1].Sources)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for audit records")
+	}
+
+	// Rebuilding an identical DAG is not a change.
+	o.OnChange(buildDAG(t, kuard))
+
+	close(stop)
+	require.NoError(t, <-done)
+	assert.Empty(t, sink.records)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for _, name := range []string{"first.example.com", "second.example.com"} {
+		// Reopen the file each time to check that records are appended.
+		s, err := NewFileSink(path)
+		require.NoError(t, err)
+		require.NoError(t, s.Write([]Record{{Type: dag.VirtualHostAdded, VirtualHost: name}}))
+		require.NoError(t, s.file.Close())
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var got []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		got = append(got, r.VirtualHost)
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"first.example.com", "second.example.com"}, got)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got []Record
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audit" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	records := []Record{{Type: dag.BackendChanged, VirtualHost: "kuard.example.com", Route: "prefix: /"}}
+
+	require.NoError(t, NewWebhookSink(srv.URL+"/audit").Write(records))
+	assert.Equal(t, records[0].VirtualHost, got[0].VirtualHost)
+	assert.Equal(t, records[0].Route, got[0].Route)
+
+	assert.Error(t, NewWebhookSink(srv.URL+"/missing").Write(records))
+}
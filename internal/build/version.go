@@ -23,6 +23,7 @@ type BuildInfo struct {
 	Branch  string `yaml:"branch,omitempty"`
 	Sha     string `yaml:"sha,omitempty"`
 	Version string `yaml:"version,omitempty"`
+	FIPS    bool   `yaml:"fips,omitempty"`
 }
 
 // Branch allows for a queryable branch name set at build time.
@@ -36,7 +37,7 @@ var Version string
 
 // PrintBuildInfo prints the build information.
 func PrintBuildInfo() string {
-	buildInfo := &BuildInfo{Branch, Sha, Version}
+	buildInfo := &BuildInfo{Branch, Sha, Version, FIPSEnabled()}
 	out, err := yaml.Marshal(buildInfo)
 	if err != nil {
 		panic(err)
@@ -17,15 +17,13 @@
 package contour
 
 import (
+	"sync"
 	"time"
 
-	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
-	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EventHandler implements cache.ResourceEventHandler, filters k8s events towards
@@ -58,6 +56,15 @@ type EventHandler struct {
 	// seq is the sequence counter of the number of times
 	// an event has been received.
 	seq int
+
+	// InformerLag, if set, records how long the oldest event
+	// included in each DAG rebuild waited to be applied.
+	InformerLag prometheus.Gauge
+
+	// mu guards rebuilt and lag, which are read by Status.
+	mu      sync.Mutex
+	rebuilt time.Time
+	lag     time.Duration
 }
 
 type opAdd struct {
@@ -72,6 +79,10 @@ type opDelete struct {
 	obj interface{}
 }
 
+type opNack struct {
+	typeURL, message string
+}
+
 func (e *EventHandler) OnAdd(obj interface{}) {
 	e.update <- opAdd{obj: obj}
 }
@@ -84,6 +95,13 @@ func (e *EventHandler) OnDelete(obj interface{}) {
 	e.update <- opDelete{obj: obj}
 }
 
+// OnNack enqueues the quarantine of the objects responsible for a
+// configuration update that Envoy rejected. The DAG is rebuilt, subject
+// to the holdoff timer, if any object is quarantined.
+func (e *EventHandler) OnNack(typeURL, message string) {
+	e.update <- opNack{typeURL: typeURL, message: message}
+}
+
 // UpdateNow enqueues a DAG update subject to the holdoff timer.
 func (e *EventHandler) UpdateNow() {
 	e.update <- true
@@ -106,12 +124,23 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 		// yet included in a DAG rebuild.
 		outstanding int
 
+		// oldest holds the time at which the oldest outstanding
+		// event was received.
+		oldest time.Time
+
 		// timer holds the timer which will expire after e.HoldoffDelay
 		timer *time.Timer
 
 		// pending is a reference to the current timer's channel.
 		pending <-chan time.Time
 
+		// windowTimer holds the timer which will expire when the
+		// active window of a route next opens or closes.
+		windowTimer *time.Timer
+
+		// window is a reference to the current window timer's channel.
+		window <-chan time.Time
+
 		// lastDAGRebuild holds the last time rebuildDAG was called.
 		// lastDAGRebuild is seeded to the current time on entry to
 		// run to allow the holdoff timer to batch the updates from
@@ -124,20 +153,30 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 		return
 	}
 
+	received := func() {
+		if outstanding == 0 {
+			oldest = time.Now()
+		}
+		outstanding++
+	}
+
 	for {
-		// In the main loop one of four things can happen.
-		// 1. We're waiting for an event on op, stop, or pending, noting that
-		//    pending may be nil if there are no pending events.
+		// In the main loop one of five things can happen.
+		// 1. We're waiting for an event on op, stop, pending or window, noting
+		//    that pending and window may be nil if there are no pending events
+		//    or route active windows.
 		// 2. We're processing an event.
 		// 3. The holdoff timer from a previous event has fired and we're
 		//    building a new DAG and sending to the Observer.
-		// 4. We're stopping.
+		// 4. The active window of a route has opened or closed, and we're
+		//    scheduling a rebuild of the DAG.
+		// 5. We're stopping.
 		//
 		// Only one of these things can happen at a time.
 		select {
 		case op := <-e.update:
 			if e.onUpdate(op) {
-				outstanding++
+				received()
 				// If there is already a timer running, stop it.
 				if timer != nil {
 					timer.Stop()
@@ -158,9 +197,30 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 			}
 		case <-pending:
 			e.WithField("last_update", time.Since(lastDAGRebuild)).WithField("outstanding", reset()).Info("performing delayed update")
-			e.rebuildDAG()
+			rebuildAt := e.rebuildDAG()
 			e.incSequence()
 			lastDAGRebuild = time.Now()
+			e.setStatus(lastDAGRebuild, lastDAGRebuild.Sub(oldest))
+
+			if windowTimer != nil {
+				windowTimer.Stop()
+				windowTimer, window = nil, nil
+			}
+			if !rebuildAt.IsZero() {
+				windowTimer = time.NewTimer(time.Until(rebuildAt))
+				window = windowTimer.C
+			}
+		case <-window:
+			// A route's active window has opened or closed, so
+			// schedule a rebuild as though an object had changed.
+			e.Info("route active window changed")
+			windowTimer, window = nil, nil
+			received()
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(0)
+			pending = timer.C
 		case <-stop:
 			// shutdown
 			return nil
@@ -168,6 +228,27 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 	}
 }
 
+// setStatus records the time of the last DAG rebuild, and
+// how long the oldest event it included waited to be applied.
+func (e *EventHandler) setStatus(rebuilt time.Time, lag time.Duration) {
+	e.mu.Lock()
+	e.rebuilt, e.lag = rebuilt, lag
+	e.mu.Unlock()
+
+	if e.InformerLag != nil {
+		e.InformerLag.Set(lag.Seconds())
+	}
+}
+
+// Status returns the time of the last DAG rebuild, and how
+// long the oldest event it included waited to be applied. The
+// time is zero if the DAG has not been rebuilt yet.
+func (e *EventHandler) Status() (rebuilt time.Time, lag time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rebuilt, e.lag
+}
+
 // onUpdate processes the event received. onUpdate returns
 // true if the event changed the cache in a way that requires
 // notifying the Observer.
@@ -176,19 +257,11 @@ func (e *EventHandler) onUpdate(op interface{}) bool {
 	case opAdd:
 		return e.Builder.Source.Insert(op.obj)
 	case opUpdate:
-		if cmp.Equal(op.oldObj, op.newObj,
-			cmpopts.IgnoreFields(contour_api_v1.HTTPProxy{}, "Status"),
-			cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ResourceVersion"),
-			cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ManagedFields"),
-		) {
-			e.WithField("op", "update").Debugf("%T skipping update, only status has changed", op.newObj)
-			return false
-		}
-		remove := e.Builder.Source.Remove(op.oldObj)
-		insert := e.Builder.Source.Insert(op.newObj)
-		return remove || insert
+		return e.Builder.Source.Update(op.oldObj, op.newObj)
 	case opDelete:
 		return e.Builder.Source.Remove(op.obj)
+	case opNack:
+		return e.Builder.Source.Quarantine(op.typeURL, op.message)
 	case bool:
 		return op
 	default:
@@ -207,9 +280,10 @@ func (e *EventHandler) incSequence() {
 	}
 }
 
-// rebuildDAG builds a new DAG and sends it to the Observer,
-// the updates the status on objects, and updates the metrics.
-func (e *EventHandler) rebuildDAG() {
+// rebuildDAG builds a new DAG and sends it to the Observer, then
+// updates the status on objects. It returns the time at which the
+// DAG must next be rebuilt, or the zero time if it need not be.
+func (e *EventHandler) rebuildDAG() time.Time {
 	latestDAG := e.Builder.Build()
 	e.Observer.OnChange(latestDAG)
 
@@ -217,4 +291,5 @@ func (e *EventHandler) rebuildDAG() {
 		e.StatusUpdater.Send(upd)
 	}
 
+	return latestDAG.RebuildAt
 }
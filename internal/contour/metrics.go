@@ -84,6 +84,9 @@ func (m *RebuildMetricsObserver) OnChange(d *dag.DAG) {
 	// If we are leader, the IsLeader channel is closed.
 	case <-m.IsLeader:
 		m.Metrics.SetHTTPProxyMetric(calculateRouteMetric(d.StatusCache.GetProxyUpdates()))
+		table := dag.NewRoutingTable(d)
+		m.Metrics.SetHTTPProxyRoutesMetric(calculateHTTPProxyRoutesMetric(table))
+		m.Metrics.SetWebsocketRouteMetric(calculateWebsocketRouteMetric(table))
 	default:
 	}
 }
@@ -125,3 +128,57 @@ func calcMetrics(u *status.ProxyUpdate, metricValid map[metrics.Meta]int, metric
 	}
 	metricTotal[metrics.Meta{Namespace: u.Fullname.Namespace}]++
 }
+
+// calculateWebsocketRouteMetric counts the routes with websockets
+// enabled of each virtual host. A route that is served on both the
+// insecure and secure listeners is counted once.
+func calculateWebsocketRouteMetric(table dag.RoutingTable) map[metrics.Meta]int {
+	type route struct {
+		vhost, conditions string
+	}
+
+	seen := map[route]bool{}
+	websocketRoutes := make(map[metrics.Meta]int)
+
+	for key, routes := range table {
+		for conditions, r := range routes {
+			if !r.Websocket || seen[route{key.Name, conditions}] {
+				continue
+			}
+			seen[route{key.Name, conditions}] = true
+			websocketRoutes[metrics.Meta{VHost: key.Name, Namespace: r.Source.Namespace}]++
+		}
+	}
+
+	return websocketRoutes
+}
+
+// calculateHTTPProxyRoutesMetric counts the routes that each HTTPProxy
+// defines in each virtual host, and records the HTTPProxy's labels. A
+// route that is served on both the insecure and secure listeners is
+// counted once.
+func calculateHTTPProxyRoutesMetric(table dag.RoutingTable) map[metrics.ProxyMeta]metrics.ProxyRoutes {
+	type route struct {
+		vhost, conditions string
+	}
+
+	seen := map[route]bool{}
+	proxyRoutes := make(map[metrics.ProxyMeta]metrics.ProxyRoutes)
+
+	for key, routes := range table {
+		for conditions, r := range routes {
+			if r.Source.Kind != "HTTPProxy" || seen[route{key.Name, conditions}] {
+				continue
+			}
+			seen[route{key.Name, conditions}] = true
+
+			meta := metrics.ProxyMeta{Namespace: r.Source.Namespace, Name: r.Source.Name, VHost: key.Name}
+			value := proxyRoutes[meta]
+			value.Routes++
+			value.Labels = r.Labels
+			proxyRoutes[meta] = value
+		}
+	}
+
+	return proxyRoutes
+}
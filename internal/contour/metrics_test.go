@@ -558,3 +558,61 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		},
 	})
 }
+
+func TestWebsocketRouteMetrics(t *testing.T) {
+	source := func(namespace string) dag.ObjectReference {
+		return dag.ObjectReference{Kind: "HTTPProxy", Namespace: namespace, Name: "proxy"}
+	}
+
+	table := dag.RoutingTable{
+		{ListenerName: "ingress_http", Name: "example.com"}: {
+			"prefix: /ws":   {Source: source("roots"), Websocket: true},
+			"prefix: /chat": {Source: source("chat"), Websocket: true},
+			"prefix: /":     {Source: source("roots")},
+		},
+		{ListenerName: "ingress_https", Name: "example.com"}: {
+			"prefix: /ws":   {Source: source("roots"), Websocket: true},
+			"prefix: /chat": {Source: source("chat"), Websocket: true},
+			"prefix: /":     {Source: source("roots")},
+		},
+		{ListenerName: "ingress_https", Name: "secure.example.com"}: {
+			"prefix: /ws": {Source: source("roots"), Websocket: true},
+		},
+		{ListenerName: "ingress_http", Name: "plain.example.com"}: {
+			"prefix: /": {Source: source("roots")},
+		},
+	}
+
+	assert.Equal(t, map[metrics.Meta]int{
+		{Namespace: "roots", VHost: "example.com"}:        1,
+		{Namespace: "chat", VHost: "example.com"}:         1,
+		{Namespace: "roots", VHost: "secure.example.com"}: 1,
+	}, calculateWebsocketRouteMetric(table))
+}
+
+func TestHTTPProxyRoutesMetrics(t *testing.T) {
+	source := func(kind, name string) dag.ObjectReference {
+		return dag.ObjectReference{Kind: kind, Namespace: "default", Name: name}
+	}
+	labels := map[string]string{"team": "checkout"}
+
+	table := dag.RoutingTable{
+		{ListenerName: "ingress_http", Name: "example.com"}: {
+			"prefix: /":     {Source: source("HTTPProxy", "root")},
+			"prefix: /cart": {Source: source("HTTPProxy", "cart"), Labels: labels},
+			"prefix: /pay":  {Source: source("HTTPProxy", "cart"), Labels: labels},
+		},
+		{ListenerName: "ingress_https", Name: "example.com"}: {
+			"prefix: /":     {Source: source("HTTPProxy", "root")},
+			"prefix: /cart": {Source: source("HTTPProxy", "cart"), Labels: labels},
+		},
+		{ListenerName: "ingress_http", Name: "ingress.example.com"}: {
+			"prefix: /": {Source: source("Ingress", "ingress")},
+		},
+	}
+
+	assert.Equal(t, map[metrics.ProxyMeta]metrics.ProxyRoutes{
+		{Namespace: "default", Name: "root", VHost: "example.com"}: {Routes: 1},
+		{Namespace: "default", Name: "cart", VHost: "example.com"}: {Routes: 2, Labels: labels},
+	}, calculateHTTPProxyRoutesMetric(table))
+}
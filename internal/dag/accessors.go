@@ -68,23 +68,35 @@ func (dag *DAG) EnsureService(meta types.NamespacedName, port intstr.IntOrString
 			ServicePort:      svcPort,
 			Weight:           1,
 		},
-		Protocol:           upstreamProtocol(svc, svcPort),
-		MaxConnections:     annotation.MaxConnections(svc),
-		MaxPendingRequests: annotation.MaxPendingRequests(svc),
-		MaxRequests:        annotation.MaxRequests(svc),
-		MaxRetries:         annotation.MaxRetries(svc),
-		ExternalName:       externalName(svc),
+		Protocol:                 upstreamProtocol(svc, svcPort),
+		MaxConnections:           annotation.MaxConnections(svc),
+		MaxPendingRequests:       annotation.MaxPendingRequests(svc),
+		MaxRequests:              annotation.MaxRequests(svc),
+		MaxRetries:               annotation.MaxRetries(svc),
+		MaxRequestsPerConnection: annotation.MaxRequestsPerConnection(svc),
+		ExternalName:             externalName(svc),
 	}
 	return dagSvc, nil
 }
 
+// upstreamProtocol returns the protocol used to reach the supplied service
+// port. The projectcontour.io/upstream-protocol.{protocol} annotations take
+// precedence over the port's appProtocol field so that existing annotated
+// Services keep their current behavior.
 func upstreamProtocol(svc *v1.Service, port v1.ServicePort) string {
 	up := annotation.ParseUpstreamProtocols(svc.Annotations)
-	protocol := up[port.Name]
-	if protocol == "" {
-		protocol = up[strconv.Itoa(int(port.Port))]
+	if protocol, ok := up[port.Name]; ok {
+		return protocol
 	}
-	return protocol
+	if protocol, ok := up[strconv.Itoa(int(port.Port))]; ok {
+		return protocol
+	}
+	if port.AppProtocol != nil {
+		if protocol, ok := annotation.ParseAppProtocol(*port.AppProtocol); ok {
+			return protocol
+		}
+	}
+	return ""
 }
 
 func externalName(svc *v1.Service) string {
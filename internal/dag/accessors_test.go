@@ -99,3 +99,70 @@ func TestBuilderLookupService(t *testing.T) {
 		})
 	}
 }
+
+func TestUpstreamProtocol(t *testing.T) {
+	appProtocol := func(s string) *string { return &s }
+
+	tests := map[string]struct {
+		annotations map[string]string
+		port        v1.ServicePort
+		want        string
+	}{
+		"no annotation or appProtocol": {
+			port: v1.ServicePort{Name: "http", Port: 8080},
+			want: "",
+		},
+		"annotation by port name": {
+			annotations: map[string]string{"projectcontour.io/upstream-protocol.h2c": "http"},
+			port:        v1.ServicePort{Name: "http", Port: 8080},
+			want:        "h2c",
+		},
+		"annotation by port number": {
+			annotations: map[string]string{"projectcontour.io/upstream-protocol.tls": "8443"},
+			port:        v1.ServicePort{Name: "https", Port: 8443},
+			want:        "tls",
+		},
+		"appProtocol h2c": {
+			port: v1.ServicePort{Name: "http", Port: 8080, AppProtocol: appProtocol("kubernetes.io/h2c")},
+			want: "h2c",
+		},
+		"appProtocol grpc": {
+			port: v1.ServicePort{Name: "grpc", Port: 9000, AppProtocol: appProtocol("grpc")},
+			want: "h2c",
+		},
+		"appProtocol ws": {
+			port: v1.ServicePort{Name: "ws", Port: 8080, AppProtocol: appProtocol("kubernetes.io/ws")},
+			want: "",
+		},
+		"unknown appProtocol is ignored": {
+			port: v1.ServicePort{Name: "http", Port: 8080, AppProtocol: appProtocol("example.com/custom")},
+			want: "",
+		},
+		"annotation takes precedence over appProtocol": {
+			annotations: map[string]string{"projectcontour.io/upstream-protocol.tls": "http"},
+			port:        v1.ServicePort{Name: "http", Port: 8080, AppProtocol: appProtocol("kubernetes.io/h2c")},
+			want:        "tls",
+		},
+		"annotation for another port does not mask appProtocol": {
+			annotations: map[string]string{"projectcontour.io/upstream-protocol.tls": "https"},
+			port:        v1.ServicePort{Name: "http", Port: 8080, AppProtocol: appProtocol("kubernetes.io/h2c")},
+			want:        "h2c",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "kuard",
+					Namespace:   "default",
+					Annotations: tc.annotations,
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{tc.port},
+				},
+			}
+			assert.Equal(t, tc.want, upstreamProtocol(svc, tc.port))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/schedule"
+)
+
+// activeWindow is the parsed form of a route's ActiveWindow.
+type activeWindow struct {
+	// start and end bound the interval in which the
+	// window may be active. Either may be zero.
+	start, end time.Time
+
+	// schedule, if set, is the schedule of times at which
+	// the window opens, for duration.
+	schedule *schedule.Schedule
+	duration time.Duration
+}
+
+// parseActiveWindow validates and parses w.
+func parseActiveWindow(w *contour_api_v1.ActiveWindow) (*activeWindow, error) {
+	var window activeWindow
+	var err error
+
+	if w.Start != "" {
+		if window.start, err = time.Parse(time.RFC3339, w.Start); err != nil {
+			return nil, fmt.Errorf("start is not a RFC3339 time: %w", err)
+		}
+	}
+	if w.End != "" {
+		if window.end, err = time.Parse(time.RFC3339, w.End); err != nil {
+			return nil, fmt.Errorf("end is not a RFC3339 time: %w", err)
+		}
+	}
+	if !window.start.IsZero() && !window.end.IsZero() && !window.start.Before(window.end) {
+		return nil, errors.New("start must be before end")
+	}
+
+	switch {
+	case w.Schedule == "" && w.Duration == "":
+		if w.Start == "" && w.End == "" {
+			return nil, errors.New("one of start, end or schedule must be specified")
+		}
+	case w.Schedule == "":
+		return nil, errors.New("duration requires a schedule")
+	case w.Duration == "":
+		return nil, errors.New("schedule requires a duration")
+	default:
+		if window.schedule, err = schedule.Parse(w.Schedule); err != nil {
+			return nil, err
+		}
+		if window.duration, err = time.ParseDuration(w.Duration); err != nil {
+			return nil, fmt.Errorf("duration: %w", err)
+		}
+		if window.duration <= 0 {
+			return nil, errors.New("duration must be positive")
+		}
+	}
+
+	return &window, nil
+}
+
+// active returns whether the window is active at now, and the
+// next time after now at which that may change, or the zero time
+// if it never will.
+func (w *activeWindow) active(now time.Time) (bool, time.Time) {
+	if !w.end.IsZero() && !now.Before(w.end) {
+		return false, time.Time{}
+	}
+
+	active := w.start.IsZero() || !now.Before(w.start)
+	next := w.end
+	if !active {
+		next = w.start
+	}
+
+	if w.schedule != nil {
+		// The most recent time the schedule opened the window
+		// is the first time it matches after now - duration,
+		// if that is not after now.
+		opened := w.schedule.Next(now.Add(-w.duration))
+		active = active && !opened.IsZero() && !opened.After(now)
+
+		next = earliest(next, w.schedule.Next(now))
+		if !opened.IsZero() {
+			next = earliest(next, opened.Add(w.duration))
+		}
+	}
+
+	return active, next
+}
+
+// earliest returns the earlier of a and b,
+// ignoring either if it is zero.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,227 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func mustParseTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse(time.RFC3339, s)
+	require.NoError(t, err)
+	return v
+}
+
+func TestParseActiveWindow(t *testing.T) {
+	tests := map[string]struct {
+		window  contour_api_v1.ActiveWindow
+		wantErr string
+	}{
+		"interval": {
+			window: contour_api_v1.ActiveWindow{Start: "2021-11-26T00:00:00Z", End: "2021-11-29T00:00:00Z"},
+		},
+		"start only": {
+			window: contour_api_v1.ActiveWindow{Start: "2021-11-26T00:00:00+01:00"},
+		},
+		"schedule": {
+			window: contour_api_v1.ActiveWindow{Schedule: "0 9 * * 1-5", Duration: "8h"},
+		},
+		"schedule within an interval": {
+			window: contour_api_v1.ActiveWindow{End: "2021-11-29T00:00:00Z", Schedule: "0 9 * * 1-5", Duration: "8h"},
+		},
+		"empty": {
+			window:  contour_api_v1.ActiveWindow{},
+			wantErr: "one of start, end or schedule must be specified",
+		},
+		"invalid start": {
+			window:  contour_api_v1.ActiveWindow{Start: "2021-11-26"},
+			wantErr: `start is not a RFC3339 time: parsing time "2021-11-26" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "T"`,
+		},
+		"end before start": {
+			window:  contour_api_v1.ActiveWindow{Start: "2021-11-29T00:00:00Z", End: "2021-11-26T00:00:00Z"},
+			wantErr: "start must be before end",
+		},
+		"schedule without duration": {
+			window:  contour_api_v1.ActiveWindow{Schedule: "0 9 * * 1-5"},
+			wantErr: "schedule requires a duration",
+		},
+		"duration without schedule": {
+			window:  contour_api_v1.ActiveWindow{Duration: "1h"},
+			wantErr: "duration requires a schedule",
+		},
+		"invalid schedule": {
+			window:  contour_api_v1.ActiveWindow{Schedule: "0 25 * * *", Duration: "1h"},
+			wantErr: `invalid hour "25", must be in the range 0-23`,
+		},
+		"zero duration": {
+			window:  contour_api_v1.ActiveWindow{Schedule: "0 9 * * *", Duration: "0s"},
+			wantErr: "duration must be positive",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseActiveWindow(&tc.window)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestActiveWindowActive(t *testing.T) {
+	tests := map[string]struct {
+		window     contour_api_v1.ActiveWindow
+		now        string
+		wantActive bool
+		wantNext   string
+	}{
+		"before interval": {
+			window:     contour_api_v1.ActiveWindow{Start: "2021-11-26T00:00:00Z", End: "2021-11-29T00:00:00Z"},
+			now:        "2021-11-25T12:00:00Z",
+			wantActive: false,
+			wantNext:   "2021-11-26T00:00:00Z",
+		},
+		"in interval": {
+			window:     contour_api_v1.ActiveWindow{Start: "2021-11-26T00:00:00Z", End: "2021-11-29T00:00:00Z"},
+			now:        "2021-11-26T00:00:00Z",
+			wantActive: true,
+			wantNext:   "2021-11-29T00:00:00Z",
+		},
+		"after interval": {
+			window:     contour_api_v1.ActiveWindow{Start: "2021-11-26T00:00:00Z", End: "2021-11-29T00:00:00Z"},
+			now:        "2021-11-29T00:00:00Z",
+			wantActive: false,
+		},
+		"after start": {
+			window:     contour_api_v1.ActiveWindow{Start: "2021-11-26T00:00:00Z"},
+			now:        "2021-12-01T00:00:00Z",
+			wantActive: true,
+		},
+		"scheduled window open": {
+			// 2021-03-01 is a Monday.
+			window:     contour_api_v1.ActiveWindow{Schedule: "0 9 * * 1-5", Duration: "8h"},
+			now:        "2021-03-01T16:59:59Z",
+			wantActive: true,
+			wantNext:   "2021-03-01T17:00:00Z",
+		},
+		"scheduled window closed": {
+			window:     contour_api_v1.ActiveWindow{Schedule: "0 9 * * 1-5", Duration: "8h"},
+			now:        "2021-03-01T17:00:00Z",
+			wantActive: false,
+			wantNext:   "2021-03-02T09:00:00Z",
+		},
+		"scheduled window opening": {
+			window:     contour_api_v1.ActiveWindow{Schedule: "0 9 * * 1-5", Duration: "8h"},
+			now:        "2021-03-01T09:00:00Z",
+			wantActive: true,
+			wantNext:   "2021-03-01T17:00:00Z",
+		},
+		"scheduled window before interval": {
+			window:     contour_api_v1.ActiveWindow{Start: "2021-03-01T12:00:00Z", Schedule: "0 9 * * 1-5", Duration: "8h"},
+			now:        "2021-03-01T10:00:00Z",
+			wantActive: false,
+			wantNext:   "2021-03-01T12:00:00Z",
+		},
+		"scheduled window in interval": {
+			window:     contour_api_v1.ActiveWindow{Start: "2021-03-01T12:00:00Z", Schedule: "0 9 * * 1-5", Duration: "8h"},
+			now:        "2021-03-01T13:00:00Z",
+			wantActive: true,
+			wantNext:   "2021-03-01T17:00:00Z",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			window, err := parseActiveWindow(&tc.window)
+			require.NoError(t, err)
+
+			active, next := window.active(mustParseTime(t, tc.now))
+			assert.Equal(t, tc.wantActive, active)
+			if tc.wantNext == "" {
+				assert.True(t, next.IsZero(), "next: %s", next)
+			} else {
+				assert.Equal(t, mustParseTime(t, tc.wantNext), next)
+			}
+		})
+	}
+}
+
+func TestActiveWindowRoutes(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+	promo := fixture.NewService("default/promo").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+
+	proxy := fixture.NewProxy("default/kuard").
+		WithFQDN("kuard.example.com").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{Prefix: "/promo"}},
+				Services:   []contour_api_v1.Service{{Name: "promo", Port: 8080}},
+				ActiveWindow: &contour_api_v1.ActiveWindow{
+					Start: "2021-11-26T00:00:00Z",
+					End:   "2021-11-29T00:00:00Z",
+				},
+			}},
+		})
+
+	build := func(now string) *DAG {
+		builder := Builder{
+			Source: KubernetesCache{
+				FieldLogger: fixture.NewTestLogger(t),
+			},
+			Processors: []Processor{
+				&HTTPProxyProcessor{Clock: clock.NewFakeClock(mustParseTime(t, now))},
+				&ListenerProcessor{},
+			},
+		}
+		builder.Source.Insert(kuard)
+		builder.Source.Insert(promo)
+		builder.Source.Insert(proxy)
+		return builder.Build()
+	}
+
+	routes := func(d *DAG) []string {
+		var names []string
+		for _, r := range NewRoutingTable(d)[VirtualHostKey{ListenerName: "ingress_http", Name: "kuard.example.com"}] {
+			names = append(names, r.Backends...)
+		}
+		return names
+	}
+
+	before := build("2021-11-25T00:00:00Z")
+	assert.ElementsMatch(t, []string{"default/kuard:8080"}, routes(before))
+	assert.Equal(t, mustParseTime(t, "2021-11-26T00:00:00Z"), before.RebuildAt)
+
+	during := build("2021-11-27T00:00:00Z")
+	assert.ElementsMatch(t, []string{"default/kuard:8080", "default/promo:8080"}, routes(during))
+	assert.Equal(t, mustParseTime(t, "2021-11-29T00:00:00Z"), during.RebuildAt)
+
+	after := build("2021-11-30T00:00:00Z")
+	assert.ElementsMatch(t, []string{"default/kuard:8080"}, routes(after))
+	assert.True(t, after.RebuildAt.IsZero())
+}
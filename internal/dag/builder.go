@@ -14,7 +14,11 @@
 package dag
 
 import (
+	"reflect"
+	"time"
+
 	"github.com/projectcontour/contour/internal/status"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Processor constructs part of a DAG.
@@ -41,6 +45,10 @@ type Builder struct {
 	// Processors is the ordered list of Processors to
 	// use to build the DAG.
 	Processors []Processor
+
+	// ProcessorDuration, if not nil, observes the time
+	// each Processor takes to run, by processor name.
+	ProcessorDuration prometheus.ObserverVec
 }
 
 // Build builds and returns a new DAG by running the
@@ -51,7 +59,21 @@ func (b *Builder) Build() *DAG {
 	}
 
 	for _, p := range b.Processors {
+		start := time.Now()
 		p.Run(&dag, &b.Source)
+		if b.ProcessorDuration != nil {
+			b.ProcessorDuration.WithLabelValues(processorName(p)).Observe(time.Since(start).Seconds())
+		}
 	}
 	return &dag
 }
+
+// processorName returns the name of the type of the Processor,
+// without its package or pointer.
+func processorName(p Processor) string {
+	t := reflect.TypeOf(p)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -15,18 +15,24 @@ package dag
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
 	"testing"
 	"time"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 	gatewayapi_v1alpha1 "sigs.k8s.io/gateway-api/apis/v1alpha1"
@@ -695,13 +701,14 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		objs                         []interface{}
-		disablePermitInsecure        bool
-		fallbackCertificateName      string
-		fallbackCertificateNamespace string
-		gatewayclass                 *gatewayapi_v1alpha1.GatewayClass
-		gateway                      *gatewayapi_v1alpha1.Gateway
-		want                         []Vertex
+		objs                            []interface{}
+		disablePermitInsecure           bool
+		fallbackCertificateName         string
+		fallbackCertificateNamespace    string
+		gatewayclass                    *gatewayapi_v1alpha1.GatewayClass
+		gateway                         *gatewayapi_v1alpha1.Gateway
+		defaultMaxRequestsPerConnection uint32
+		want                            []Vertex
 	}{
 		"insert basic single route, single hostname": {
 			gatewayclass: validClass,
@@ -719,6 +726,27 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 				},
 			),
 		},
+		"insert basic single route with default max requests per connection": {
+			gatewayclass:                    validClass,
+			gateway:                         gatewayWithSelector,
+			defaultMaxRequestsPerConnection: 50,
+			objs: []interface{}{
+				kuardService,
+				genericHTTPRoute,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("test.projectcontour.io", routeCluster("/", &Cluster{
+							Upstream:                 service(kuardService),
+							Weight:                   1,
+							MaxRequestsPerConnection: 50,
+						})),
+					),
+				},
+			),
+		},
 		"gateway with unsupported addresses": {
 			gatewayclass: validClass,
 			gateway:      gatewayWithAddresses,
@@ -3446,7 +3474,8 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 						},
 					},
 					&GatewayAPIProcessor{
-						FieldLogger: fixture.NewTestLogger(t),
+						FieldLogger:                     fixture.NewTestLogger(t),
+						DefaultMaxRequestsPerConnection: tc.defaultMaxRequestsPerConnection,
 					},
 					&ListenerProcessor{},
 				},
@@ -4319,6 +4348,28 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// proxyFailover fails over from kuard to kuarder and kuardest.
+	proxyFailover := proxyMultipleBackends.DeepCopy()
+	proxyFailover.Spec.Routes[0].Services[1].Priority = 5
+	proxyFailover.Spec.Routes[0].Services = append(proxyFailover.Spec.Routes[0].Services, contour_api_v1.Service{
+		Name:     "kuardest",
+		Port:     8080,
+		Priority: 10,
+	})
+
+	// proxyLBConfig tunes the panic threshold of the kuard service.
+	proxyLBConfig := proxyMultipleBackends.DeepCopy()
+	proxyLBConfig.Spec.Routes[0].Services = proxyLBConfig.Spec.Routes[0].Services[:1]
+	proxyLBConfig.Spec.Routes[0].Services[0].HealthyPanicThreshold = func(v uint32) *uint32 { return &v }(50)
+
+	// proxyMaxRequestsPerConnection routes to the kuard service,
+	// and proxyMaxRequestsPerConnectionField also overrides the
+	// maximum requests per connection of its Service.
+	proxyMaxRequestsPerConnection := proxyMultipleBackends.DeepCopy()
+	proxyMaxRequestsPerConnection.Spec.Routes[0].Services = proxyMaxRequestsPerConnection.Spec.Routes[0].Services[:1]
+	proxyMaxRequestsPerConnectionField := proxyMaxRequestsPerConnection.DeepCopy()
+	proxyMaxRequestsPerConnectionField.Spec.Routes[0].Services[0].MaxRequestsPerConnection = func(v uint32) *uint32 { return &v }(10)
+
 	proxyMinTLS12 := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "example-com",
@@ -4654,6 +4705,25 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// s1mrpc limits the requests per upstream connection.
+	s1mrpc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"projectcontour.io/max-requests-per-connection": "100",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
 	// s2 is like s1 but with a different name
 	s2 := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -5258,6 +5328,17 @@ func TestDAGInsert(t *testing.T) {
 			}},
 		},
 	}
+	// proxy17sni overrides the SNI presented to the upstream.
+	proxy17sni := proxy17.DeepCopy()
+	proxy17sni.Spec.Routes[0].RequestHeadersPolicy = &contour_api_v1.HeadersPolicy{
+		Set: []contour_api_v1.HeaderValue{{
+			Name:  "Host",
+			Value: "www.example.com",
+		}},
+	}
+	proxy17sni.Spec.Routes[0].Services[0].SNI = "backend.example.com"
+	proxy17sni.Spec.Routes[0].Services[0].UpstreamValidation.SubjectName = "backend.example.com"
+
 	protocolh2 := "h2"
 	proxy17h2 := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -5313,6 +5394,39 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// proxy18a is downstream validation with allowed subject alt names
+	proxy18a := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: sec1.Name,
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						CACertificate: cert1.Name,
+						AllowedSubjectAltNames: []contour_api_v1.SubjectAltNameMatch{{
+							Exact: "client.example.com",
+						}, {
+							Suffix: ".clients.example.com",
+						}},
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
 	// proxy19 is downstream validation, TCP proxying
 	proxy19 := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -5400,6 +5514,44 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// proxy10c has a websocket route with a websocket idle timeout
+	proxy10c := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+					Idle: "30s",
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/websocket",
+				}},
+				TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+					Idle: "30s",
+				},
+				EnableWebsockets:     true,
+				WebsocketIdleTimeout: "1h",
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
 	// proxy12 tests mirroring
 	proxy12 := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -6536,6 +6688,89 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// proxy110a overrides the h2c protocol annotation of s3a.
+	proxy110a := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name:     s3a.Name,
+					Port:     80,
+					Protocol: pointer.StringPtr("http/1.1"),
+				}},
+			}},
+		},
+	}
+
+	// proxy110b requires TLS 1.3 to the upstream.
+	proxy110b := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name:     "kuard",
+					Port:     8080,
+					Protocol: pointer.StringPtr("tls"),
+					UpstreamTLS: &contour_api_v1.UpstreamTLS{
+						MinimumProtocolVersion: "1.3",
+						CipherSuites:           []string{" ECDHE-RSA-AES256-GCM-SHA384 "},
+					},
+				}},
+			}},
+		},
+	}
+
+	// proxy113 is in maintenance with the default response.
+	proxy113 := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:        "example.com",
+				Maintenance: true,
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxy113a redirects to a status page during maintenance.
+	proxy113a := proxy113.DeepCopy()
+	proxy113a.Spec.VirtualHost.MaintenancePolicy = &contour_api_v1.MaintenancePolicy{
+		RetryAfter: "90s",
+		Redirect: &contour_api_v1.MaintenanceRedirect{
+			Hostname: "status.example.com",
+			Path:     "/maintenance",
+		},
+	}
+
 	proxyExternalNameService := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "example-com",
@@ -6557,6 +6792,31 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	proxyExternalNameMirror := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: s1.Name,
+					Port: 8080,
+				}, {
+					Name:   s14.GetName(),
+					Port:   80,
+					Mirror: true,
+				}},
+			}},
+		},
+	}
+
 	tcpProxyExternalNameService := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "example-com",
@@ -7163,6 +7423,106 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert httpproxy with failover priorities": {
+			objs: []interface{}{
+				proxyFailover, s1, s2, s2a,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream: service(s1),
+								Failover: []WeightedService{{
+									Priority:         1,
+									ServiceName:      s2.Name,
+									ServiceNamespace: s2.Namespace,
+									ServicePort:      s2.Spec.Ports[0],
+								}, {
+									Priority:         2,
+									ServiceName:      s2a.Name,
+									ServiceNamespace: s2a.Namespace,
+									ServicePort:      s2a.Spec.Ports[0],
+								}},
+							}),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy with load balancer config": {
+			objs: []interface{}{
+				proxyLBConfig, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream: service(s1),
+								LBConfig: ClusterLBConfig{
+									HealthyPanicThreshold: 50,
+								},
+							}),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy with max requests per connection annotation": {
+			objs: []interface{}{
+				proxyMaxRequestsPerConnection, s1mrpc,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream: &Service{
+									Weighted: WeightedService{
+										Weight:           1,
+										ServiceName:      s1mrpc.Name,
+										ServiceNamespace: s1mrpc.Namespace,
+										ServicePort:      s1mrpc.Spec.Ports[0],
+									},
+									MaxRequestsPerConnection: 100,
+								},
+								MaxRequestsPerConnection: 100,
+							}),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy with max requests per connection field": {
+			objs: []interface{}{
+				proxyMaxRequestsPerConnectionField, s1mrpc,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream: &Service{
+									Weighted: WeightedService{
+										Weight:           1,
+										ServiceName:      s1mrpc.Name,
+										ServiceNamespace: s1mrpc.Namespace,
+										ServicePort:      s1mrpc.Spec.Ports[0],
+									},
+									MaxRequestsPerConnection: 100,
+								},
+								MaxRequestsPerConnection: 10,
+							}),
+						),
+					),
+				},
+			),
+		},
 		"ingressv1: insert ingress w/ tls min proto annotation": {
 			objs: []interface{}{
 				i10aV1,
@@ -7404,22 +7764,7 @@ func TestDAGInsert(t *testing.T) {
 				proxyRetryPolicyInvalidTimeout,
 				s1,
 			},
-			want: listeners(
-				&Listener{
-					Port: 80,
-					VirtualHosts: virtualhosts(
-						virtualhost("bar.com", &Route{
-							PathMatchCondition: prefixString("/"),
-							Clusters:           clustermap(s1),
-							RetryPolicy: &RetryPolicy{
-								RetryOn:       "5xx",
-								NumRetries:    6,
-								PerTryTimeout: timeout.DefaultSetting(),
-							},
-						}),
-					),
-				},
-			),
+			want: listeners(),
 		},
 		"insert httpproxy with zero retry count": {
 			objs: []interface{}{
@@ -7953,6 +8298,35 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert httpproxy with websocket route and websocket idle timeout": {
+			objs: []interface{}{
+				proxy10c, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							&Route{
+								PathMatchCondition: prefixString("/"),
+								Clusters:           clustermap(s1),
+								TimeoutPolicy: TimeoutPolicy{
+									IdleTimeout: timeout.DurationSetting(30 * time.Second),
+								},
+							},
+							&Route{
+								PathMatchCondition: prefixString("/websocket"),
+								Clusters:           clustermap(s1),
+								Websocket:          true,
+								TimeoutPolicy: TimeoutPolicy{
+									IdleTimeout: timeout.DurationSetting(time.Hour),
+								},
+							},
+						),
+					),
+				},
+			),
+		},
 		"insert httpproxy with multiple upstreams prefix rewrite route and websockets along one path": {
 			objs: []interface{}{
 				proxy10b, s1,
@@ -7985,6 +8359,101 @@ func TestDAGInsert(t *testing.T) {
 			),
 		},
 
+		"insert httpproxy with http/1.1 protocol overriding service annotation": {
+			objs: []interface{}{
+				proxy110a, s3a,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeProtocol("/", "", &Service{
+								Protocol: "h2c",
+								Weighted: WeightedService{
+									Weight:           1,
+									ServiceName:      s3a.Name,
+									ServiceNamespace: s3a.Namespace,
+									ServicePort:      s3a.Spec.Ports[0],
+								},
+							})),
+					),
+				},
+			),
+		},
+
+		"insert httpproxy with upstream tls parameters": {
+			objs: []interface{}{
+				proxy110b, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/",
+								&Cluster{
+									Upstream: service(s1),
+									Protocol: "tls",
+									UpstreamTLS: &UpstreamTLS{
+										MinimumProtocolVersion: "1.3",
+										MaximumProtocolVersion: "1.3",
+										CipherSuites:           []string{"ECDHE-RSA-AES256-GCM-SHA384"},
+									},
+								},
+							),
+						),
+					),
+				},
+			),
+		},
+
+		"insert httpproxy in maintenance": {
+			objs: []interface{}{
+				proxy113, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", &Route{
+							PathMatchCondition: prefixString("/"),
+							Clusters:           clustermap(s1),
+							DirectResponse:     &DirectResponse{StatusCode: http.StatusServiceUnavailable},
+							ResponseHeadersPolicy: &HeadersPolicy{
+								Set: map[string]string{"Retry-After": "300"},
+							},
+						}),
+					),
+				},
+			),
+		},
+
+		"insert httpproxy in maintenance with a redirect": {
+			objs: []interface{}{
+				proxy113a, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", &Route{
+							PathMatchCondition: prefixString("/"),
+							Clusters:           clustermap(s1),
+							Redirect: &Redirect{
+								Hostname:   "status.example.com",
+								Path:       "/maintenance",
+								StatusCode: http.StatusFound,
+							},
+							ResponseHeadersPolicy: &HeadersPolicy{
+								Set: map[string]string{"Retry-After": "90"},
+							},
+						}),
+					),
+				},
+			),
+		},
+
 		"insert httpproxy without tls version": {
 			objs: []interface{}{
 				proxy6, s1, sec1,
@@ -8035,6 +8504,43 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert httpproxy with sni overriding host rewrite": {
+			objs: []interface{}{
+				cert1, proxy17sni, s1a,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							&Route{
+								PathMatchCondition: prefixString("/"),
+								Clusters: []*Cluster{{
+									Upstream: &Service{
+										Protocol: "tls",
+										Weighted: WeightedService{
+											Weight:           1,
+											ServiceName:      s1a.Name,
+											ServiceNamespace: s1a.Namespace,
+											ServicePort:      s1a.Spec.Ports[0],
+										},
+									},
+									Protocol: "tls",
+									UpstreamValidation: &PeerValidationContext{
+										CACertificate: secret(cert1),
+										SubjectName:   "backend.example.com",
+									},
+									SNI: "backend.example.com",
+								}},
+								RequestHeadersPolicy: &HeadersPolicy{
+									HostRewrite: "www.example.com",
+								},
+							},
+						),
+					),
+				},
+			),
+		},
 		"insert httpproxy with h2 expecting upstream verification": {
 			objs: []interface{}{
 				cert1, proxy17h2, s1,
@@ -8117,6 +8623,43 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert httpproxy with downstream verification and allowed subject alt names": {
+			objs: []interface{}{
+				cert1, proxy18a, s1, sec1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", routeUpgrade("/", service(s1))),
+					),
+				}, &Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name:         "example.com",
+								ListenerName: "ingress_https",
+								routes: routes(
+									routeUpgrade("/", service(s1))),
+							},
+							MinTLSVersion: "1.2",
+							Secret:        secret(sec1),
+							DownstreamValidation: &PeerValidationContext{
+								CACertificate: &Secret{Object: cert1},
+								SubjectAltNames: []SubjectAltNameMatch{{
+									MatchType: SubjectAltNameMatchTypeExact,
+									Value:     "client.example.com",
+								}, {
+									MatchType: SubjectAltNameMatchTypeSuffix,
+									Value:     ".clients.example.com",
+								}},
+							},
+						},
+					),
+				},
+			),
+		},
 		"insert httpproxy w/ tcpproxy in tls termination mode w/ downstream verification": {
 			objs: []interface{}{
 				cert1, proxy19, s1, sec1,
@@ -9008,6 +9551,38 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert proxy with externalName mirror service": {
+			objs: []interface{}{
+				proxyExternalNameMirror,
+				s1,
+				s14,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", &Route{
+							PathMatchCondition: prefixString("/"),
+							Clusters:           clustermap(s1),
+							MirrorPolicy: &MirrorPolicy{
+								Cluster: &Cluster{
+									Upstream: &Service{
+										ExternalName: "externalservice.io",
+										Weighted: WeightedService{
+											Weight:           1,
+											ServiceName:      s14.Name,
+											ServiceNamespace: s14.Namespace,
+											ServicePort:      s14.Spec.Ports[0],
+										},
+									},
+									SNI: "externalservice.io",
+								},
+							},
+						}),
+					),
+				},
+			),
+		},
 		"insert tcp proxy with externalName service": {
 			objs: []interface{}{
 				tcpProxyExternalNameService,
@@ -9788,12 +10363,36 @@ func TestDAGInsert(t *testing.T) {
 
 type listenerMap map[int]*Listener
 
+// Visit adds each listener to lm, clearing the sources of its
+// routes and TCP proxies, which are tested by TestRoutingTableSources.
 func (lm listenerMap) Visit(v Vertex) {
 	if l, ok := v.(*Listener); ok {
+		clearSources(l)
 		lm[l.Port] = l
 	}
 }
 
+func clearSources(l *Listener) {
+	for _, vh := range l.VirtualHosts {
+		switch vh := vh.(type) {
+		case *VirtualHost:
+			for _, r := range vh.routes {
+				r.Source = ObjectReference{}
+			}
+		case *SecureVirtualHost:
+			for _, r := range vh.routes {
+				r.Source = ObjectReference{}
+			}
+			if vh.TCPProxy != nil {
+				vh.TCPProxy.Source = ObjectReference{}
+			}
+		}
+	}
+	if l.FallbackTCPProxy != nil {
+		l.FallbackTCPProxy.Source = ObjectReference{}
+	}
+}
+
 func backendv1(name string, port intstr.IntOrString) *networking_v1.IngressBackend {
 
 	var v1port networking_v1.ServiceBackendPort
@@ -9975,59 +10574,1257 @@ func TestDAGRootNamespaces(t *testing.T) {
 	}
 }
 
-func TestHttpPaths(t *testing.T) {
-	tests := map[string]struct {
-		rule networking_v1.IngressRule
-		want []networking_v1.HTTPIngressPath
-	}{
-		"zero value": {
-			rule: networking_v1.IngressRule{},
-			want: nil,
-		},
-		"empty paths": {
-			rule: networking_v1.IngressRule{
-				IngressRuleValue: networking_v1.IngressRuleValue{
-					HTTP: &networking_v1.HTTPIngressRuleValue{},
-				},
+func TestDAGDefaultClusterPolicies(t *testing.T) {
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
 			},
-			want: nil,
-		},
-		"several paths": {
-			rule: networking_v1.IngressRule{
-				IngressRuleValue: networking_v1.IngressRuleValue{
-					HTTP: &networking_v1.HTTPIngressRuleValue{
-						Paths: []networking_v1.HTTPIngressPath{{
-							Backend: *backendv1("kuard", intstr.FromString("http")),
-						}, {
-							Path:    "/kuarder",
-							Backend: *backendv1("kuarder", intstr.FromInt(8080)),
-						}},
-					},
-				},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol: "TCP",
+					Port:     8080,
+				}},
 			},
-			want: []networking_v1.HTTPIngressPath{{
-				Backend: *backendv1("kuard", intstr.FromString("http")),
-			}, {
-				Path:    "/kuarder",
-				Backend: *backendv1("kuarder", intstr.FromInt(8080)),
-			}},
-		},
+		}
 	}
 
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
-			got := httppaths(tc.rule)
-			assert.Equal(t, tc.want, got)
-		})
+	ingress := &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "default",
+		},
+		Spec: networking_v1.IngressSpec{
+			DefaultBackend: backendv1("ingress", intstr.FromInt(8080)),
+		},
 	}
-}
 
-func TestDetermineSNI(t *testing.T) {
-	tests := map[string]struct {
-		routeRequestHeaders   *HeadersPolicy
-		clusterRequestHeaders *HeadersPolicy
-		service               *Service
-		want                  string
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxy",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/default",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "default",
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/override",
+				}},
+				LoadBalancerPolicy: &contour_api_v1.LoadBalancerPolicy{
+					Strategy: "RoundRobin",
+				},
+				HealthCheckPolicy: &contour_api_v1.HTTPHealthCheckPolicy{
+					Path: "/ready",
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "override",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	tcpproxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tcpproxy",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "tcp.example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: sec1.Name,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				Services: []contour_api_v1.Service{{
+					Name: "tcp",
+					Port: 8080,
+				}},
+			},
+		},
+	}
+
+	healthCheck := &HTTPHealthCheckPolicy{
+		Path:     "/healthz",
+		Interval: 5 * time.Second,
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&IngressProcessor{
+				FieldLogger:               fixture.NewTestLogger(t),
+				DefaultLoadBalancerPolicy: LoadBalancerPolicyRandom,
+				DefaultHealthCheckPolicy:  healthCheck,
+			},
+			&HTTPProxyProcessor{
+				DefaultLoadBalancerPolicy: LoadBalancerPolicyRandom,
+				DefaultHealthCheckPolicy:  healthCheck,
+			},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{
+		sec1,
+		service("ingress"),
+		service("default"),
+		service("override"),
+		service("tcp"),
+		ingress,
+		proxy,
+		tcpproxy,
+	} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	clusters := map[string]*Cluster{}
+	var visit func(Vertex)
+	visit = func(v Vertex) {
+		if c, ok := v.(*Cluster); ok {
+			clusters[c.Upstream.Weighted.ServiceName] = c
+		}
+		v.Visit(visit)
+	}
+	dag.Visit(visit)
+
+	assert.Len(t, clusters, 4)
+
+	assert.Equal(t, LoadBalancerPolicyRandom, clusters["ingress"].LoadBalancerPolicy)
+	assert.Equal(t, healthCheck, clusters["ingress"].HTTPHealthCheckPolicy)
+
+	assert.Equal(t, LoadBalancerPolicyRandom, clusters["default"].LoadBalancerPolicy)
+	assert.Equal(t, healthCheck, clusters["default"].HTTPHealthCheckPolicy)
+
+	assert.Equal(t, "", clusters["override"].LoadBalancerPolicy)
+	assert.Equal(t, &HTTPHealthCheckPolicy{Path: "/ready"}, clusters["override"].HTTPHealthCheckPolicy)
+
+	assert.Equal(t, LoadBalancerPolicyRandom, clusters["tcp"].LoadBalancerPolicy)
+	assert.Nil(t, clusters["tcp"].HTTPHealthCheckPolicy)
+	assert.Equal(t, &TCPHealthCheckPolicy{Interval: 5 * time.Second}, clusters["tcp"].TCPHealthCheckPolicy)
+}
+
+func TestDAGDefaultRetryPolicy(t *testing.T) {
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		}
+	}
+
+	ingress := func(name, host string, annotations map[string]string) *networking_v1.Ingress {
+		return &networking_v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: networking_v1.IngressSpec{
+				Rules: []networking_v1.IngressRule{{
+					Host:             host,
+					IngressRuleValue: ingressrulev1value(backendv1(name, intstr.FromInt(8080))),
+				}},
+			},
+		}
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxy",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/default",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "default",
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/override",
+				}},
+				RetryPolicy: &contour_api_v1.RetryPolicy{
+					NumRetries: 3,
+				},
+				Services: []contour_api_v1.Service{{
+					Name: "override",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	defaultRetryPolicy := &RetryPolicy{
+		RetryOn:         "reset,connect-failure,refused-stream",
+		NumRetries:      1,
+		RetryOtherHosts: true,
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&IngressProcessor{
+				FieldLogger:        fixture.NewTestLogger(t),
+				DefaultRetryPolicy: defaultRetryPolicy,
+			},
+			&HTTPProxyProcessor{
+				DefaultRetryPolicy: defaultRetryPolicy,
+			},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{
+		service("ingress"),
+		service("annotated"),
+		service("default"),
+		service("override"),
+		ingress("ingress", "ingress.example.com", nil),
+		ingress("annotated", "annotated.example.com", map[string]string{
+			"projectcontour.io/retry-on": "gateway-error",
+		}),
+		proxy,
+	} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	policies := map[string]*RetryPolicy{}
+	for _, vh := range dag.GetVirtualHosts() {
+		for _, r := range vh.routes {
+			policies[r.Clusters[0].Upstream.Weighted.ServiceName] = r.RetryPolicy
+		}
+	}
+
+	assert.Equal(t, map[string]*RetryPolicy{
+		"ingress":   defaultRetryPolicy,
+		"annotated": {RetryOn: "gateway-error"},
+		"default":   defaultRetryPolicy,
+		"override":  {RetryOn: "5xx", NumRetries: 3},
+	}, policies)
+}
+
+func TestDAGNamespaceBlocked(t *testing.T) {
+	service := func(namespace, name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		}
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&IngressProcessor{
+				FieldLogger: fixture.NewTestLogger(t),
+			},
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{
+		&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "blocked",
+				Annotations: map[string]string{
+					"projectcontour.io/routes-blocked": "true",
+				},
+			},
+		},
+		service("default", "app"),
+		service("blocked", "blog"),
+		service("blocked", "shop"),
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "root",
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "example.com",
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "app",
+						Port: 8080,
+					}},
+				}},
+				Includes: []contour_api_v1.Include{{
+					Name:      "blog",
+					Namespace: "blocked",
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: "/blog",
+					}},
+				}},
+			},
+		},
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "blog",
+				Namespace: "blocked",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "blog",
+						Port: 8080,
+					}},
+				}},
+			},
+		},
+		&networking_v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "shop",
+				Namespace: "blocked",
+			},
+			Spec: networking_v1.IngressSpec{
+				Rules: []networking_v1.IngressRule{{
+					Host:             "shop.example.com",
+					IngressRuleValue: ingressrulev1value(backendv1("shop", intstr.FromInt(8080))),
+				}},
+			},
+		},
+	} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	routes := map[string]*Route{}
+	for _, vh := range dag.GetVirtualHosts() {
+		for _, r := range vh.routes {
+			routes[r.Source.Name] = r
+		}
+	}
+
+	blocked := &DirectResponse{StatusCode: http.StatusServiceUnavailable}
+
+	assert.Nil(t, routes["root"].DirectResponse)
+	assert.Len(t, routes["root"].Clusters, 1)
+
+	assert.Equal(t, blocked, routes["blog"].DirectResponse)
+	assert.Empty(t, routes["blog"].Clusters)
+
+	assert.Equal(t, blocked, routes["shop"].DirectResponse)
+	assert.Empty(t, routes["shop"].Clusters)
+}
+
+func TestDAGTrafficSplit(t *testing.T) {
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		}
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxy",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "app",
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/weighted",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name:   "app",
+					Port:   8080,
+					Weight: 3,
+				}, {
+					Name:   "other",
+					Port:   8080,
+					Weight: 1,
+				}},
+			}},
+		},
+	}
+
+	split := &contour_api_v1alpha1.TrafficSplit{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1alpha1.TrafficSplitSpec{
+			Service: "app",
+			Backends: []contour_api_v1alpha1.TrafficSplitBackend{
+				{Service: "app-primary", Weight: 90},
+				{Service: "app-canary", Weight: 10},
+				{Service: "missing", Weight: 50},
+			},
+		},
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{
+		service("app"),
+		service("app-primary"),
+		service("app-canary"),
+		service("other"),
+		proxy,
+		split,
+	} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	weights := map[string]map[string]uint32{}
+	var visit func(Vertex)
+	visit = func(v Vertex) {
+		if r, ok := v.(*Route); ok {
+			prefix := r.PathMatchCondition.(*PrefixMatchCondition).Prefix
+			weights[prefix] = map[string]uint32{}
+			for _, c := range r.Clusters {
+				weights[prefix][c.Upstream.Weighted.ServiceName] = c.Weight
+			}
+			return
+		}
+		v.Visit(visit)
+	}
+	dag.Visit(visit)
+
+	assert.Equal(t, map[string]map[string]uint32{
+		"/": {
+			"app-primary": 90,
+			"app-canary":  10,
+		},
+		"/weighted": {
+			"app-primary": 270,
+			"app-canary":  30,
+			"other":       100,
+		},
+	}, weights)
+}
+
+func TestDAGExposedService(t *testing.T) {
+	service := func(name string, annotations map[string]string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:     "dns",
+					Protocol: "UDP",
+					Port:     53,
+				}, {
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     8080,
+				}},
+			},
+		}
+	}
+	exposed := map[string]string{"projectcontour.io/expose": "true"}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxy",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "claimed.default.dev.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "other",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{},
+			&ExposedServiceProcessor{
+				FieldLogger:  fixture.NewTestLogger(t),
+				DomainSuffix: "dev.example.com",
+			},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{
+		service("app", exposed),
+		service("claimed", exposed),
+		service("other", nil),
+		service("hidden", map[string]string{"projectcontour.io/expose": "false"}),
+		proxy,
+	} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	upstreams := map[string][]string{}
+	for ln, vh := range dag.GetVirtualHosts() {
+		for _, r := range vh.routes {
+			for _, c := range r.Clusters {
+				upstreams[ln.Name] = append(upstreams[ln.Name],
+					fmt.Sprintf("%s/%s:%d", r.Source.Kind, c.Upstream.Weighted.ServiceName, c.Upstream.Weighted.ServicePort.Port))
+			}
+		}
+	}
+
+	assert.Equal(t, map[string][]string{
+		"app.default.dev.example.com":     {"Service/app:8080"},
+		"claimed.default.dev.example.com": {"HTTPProxy/other:8080"},
+	}, upstreams)
+}
+
+func TestDAGTapPolicy(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		processor   HTTPProxyProcessor
+		annotations map[string]string
+		want        *TapPolicy
+		wantReasons []string
+	}{
+		"no annotation": {
+			processor: HTTPProxyProcessor{TapMaxDuration: time.Hour},
+		},
+		"active tap": {
+			processor: HTTPProxyProcessor{TapMaxDuration: time.Hour},
+			annotations: map[string]string{
+				"projectcontour.io/tap-until":       "2021-03-01T12:30:00Z",
+				"projectcontour.io/tap-path-prefix": "/api",
+			},
+			want: &TapPolicy{PathPrefix: "/api", Until: now.Add(30 * time.Minute)},
+		},
+		"expired tap": {
+			processor: HTTPProxyProcessor{TapMaxDuration: time.Hour},
+			annotations: map[string]string{
+				"projectcontour.io/tap-until": "2021-03-01T11:30:00Z",
+			},
+		},
+		"taps disabled": {
+			annotations: map[string]string{
+				"projectcontour.io/tap-until": "2021-03-01T12:30:00Z",
+			},
+			wantReasons: []string{"TapNotPermitted"},
+		},
+		"namespace not permitted": {
+			processor: HTTPProxyProcessor{TapMaxDuration: time.Hour, TapNamespaces: []string{"debug"}},
+			annotations: map[string]string{
+				"projectcontour.io/tap-until": "2021-03-01T12:30:00Z",
+			},
+			wantReasons: []string{"TapNotPermitted"},
+		},
+		"beyond the maximum duration": {
+			processor: HTTPProxyProcessor{TapMaxDuration: time.Hour},
+			annotations: map[string]string{
+				"projectcontour.io/tap-until": "2021-03-01T14:00:00Z",
+			},
+			wantReasons: []string{"TapNotValid"},
+		},
+		"malformed time": {
+			processor: HTTPProxyProcessor{TapMaxDuration: time.Hour},
+			annotations: map[string]string{
+				"projectcontour.io/tap-until": "in 10 minutes",
+			},
+			wantReasons: []string{"TapNotValid"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pb := fixture.NewProxy("default/kuard")
+			for k, v := range tc.annotations {
+				pb.Annotate(k, v)
+			}
+			proxy := pb.WithFQDN("kuard.example.com").
+				WithSpec(contour_api_v1.HTTPProxySpec{
+					Routes: []contour_api_v1.Route{{
+						Services: []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+					}},
+				})
+
+			processor := tc.processor
+			processor.Clock = clock.NewFakeClock(now)
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&processor,
+					&ListenerProcessor{},
+				},
+			}
+			builder.Source.Insert(kuard)
+			builder.Source.Insert(proxy)
+			dag := builder.Build()
+
+			vhost := dag.GetVirtualHost(ListenerName{Name: "kuard.example.com", ListenerName: "ingress_http"})
+			assert.Equal(t, tc.want, vhost.TapPolicy)
+			if tc.want != nil {
+				assert.Equal(t, tc.want.Until, dag.RebuildAt)
+			} else {
+				assert.True(t, dag.RebuildAt.IsZero())
+			}
+
+			var reasons []string
+			for _, pu := range dag.StatusCache.GetProxyUpdates() {
+				for _, cond := range pu.Conditions {
+					for _, w := range cond.Warnings {
+						reasons = append(reasons, w.Reason)
+					}
+				}
+			}
+			assert.Equal(t, tc.wantReasons, reasons)
+		})
+	}
+}
+
+func TestDAGIncludeLimits(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+
+	include := func(name, prefix string) contour_api_v1.Include {
+		return contour_api_v1.Include{
+			Name:       name,
+			Conditions: []contour_api_v1.MatchCondition{{Prefix: prefix}},
+		}
+	}
+	routes := []contour_api_v1.Route{{
+		Services: []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+	}}
+
+	// root includes a and b, and a includes c.
+	root := fixture.NewProxy("default/root").WithFQDN("kuard.example.com").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Includes: []contour_api_v1.Include{include("a", "/a"), include("b", "/b")},
+		})
+	a := fixture.NewProxy("default/a").WithSpec(contour_api_v1.HTTPProxySpec{
+		Includes: []contour_api_v1.Include{include("c", "/c")},
+		Routes:   routes,
+	})
+	b := fixture.NewProxy("default/b").WithSpec(contour_api_v1.HTTPProxySpec{Routes: routes})
+	c := fixture.NewProxy("default/c").WithSpec(contour_api_v1.HTTPProxySpec{Routes: routes})
+
+	tests := map[string]struct {
+		processor   HTTPProxyProcessor
+		wantRoutes  int
+		wantReasons map[string][]string
+	}{
+		"no limits": {
+			wantRoutes:  3,
+			wantReasons: map[string][]string{},
+		},
+		"within limits": {
+			processor:   HTTPProxyProcessor{MaxIncludeDepth: 2, MaxIncludes: 3},
+			wantRoutes:  3,
+			wantReasons: map[string][]string{},
+		},
+		"too deep": {
+			processor:  HTTPProxyProcessor{MaxIncludeDepth: 1},
+			wantRoutes: 1,
+			wantReasons: map[string][]string{
+				"a": {"TooManyIncludes"},
+			},
+		},
+		"too many includes": {
+			processor:  HTTPProxyProcessor{MaxIncludes: 2},
+			wantRoutes: 0,
+			wantReasons: map[string][]string{
+				"root": {"TooManyIncludes"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := tc.processor
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&processor,
+					&ListenerProcessor{},
+				},
+			}
+			for _, o := range []interface{}{kuard, root, a, b, c} {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			var gotRoutes int
+			if vhost := dag.GetVirtualHost(ListenerName{Name: "kuard.example.com", ListenerName: "ingress_http"}); vhost != nil {
+				gotRoutes = len(vhost.routes)
+			}
+			assert.Equal(t, tc.wantRoutes, gotRoutes)
+
+			reasons := map[string][]string{}
+			for _, pu := range dag.StatusCache.GetProxyUpdates() {
+				for _, cond := range pu.Conditions {
+					for _, e := range cond.Errors {
+						if e.Type == contour_api_v1.ConditionTypeIncludeError {
+							reasons[pu.Fullname.Name] = append(reasons[pu.Fullname.Name], e.Reason)
+						}
+					}
+				}
+			}
+			assert.Equal(t, tc.wantReasons, reasons)
+		})
+	}
+}
+
+func TestDAGForwardProxy(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+
+	tests := map[string]struct {
+		spec        contour_api_v1.HTTPProxySpec
+		want        *ForwardProxy
+		wantReasons []string
+	}{
+		"forward proxy": {
+			spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "egress.example.com",
+					ForwardProxy: &contour_api_v1.ForwardProxyPolicy{
+						AllowedDomains: []string{"*.GitHub.com", "api.example.com", "*.github.com"},
+					},
+				},
+			},
+			want: &ForwardProxy{AllowedDomains: []string{"*.github.com", "api.example.com"}},
+		},
+		"forward proxy with routes": {
+			spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "egress.example.com",
+					ForwardProxy: &contour_api_v1.ForwardProxyPolicy{
+						AllowedDomains: []string{"api.example.com"},
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+				}},
+			},
+			wantReasons: []string{"ForwardProxyNotValid"},
+		},
+		"forward proxy with tls": {
+			spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "egress.example.com",
+					TLS:  &contour_api_v1.TLS{SecretName: "secret"},
+					ForwardProxy: &contour_api_v1.ForwardProxyPolicy{
+						AllowedDomains: []string{"api.example.com"},
+					},
+				},
+			},
+			wantReasons: []string{"ForwardProxyNotValid"},
+		},
+		"any domain": {
+			spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "egress.example.com",
+					ForwardProxy: &contour_api_v1.ForwardProxyPolicy{
+						AllowedDomains: []string{"*"},
+					},
+				},
+			},
+			wantReasons: []string{"ForwardProxyNotValid"},
+		},
+		"no domains": {
+			spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn:         "egress.example.com",
+					ForwardProxy: &contour_api_v1.ForwardProxyPolicy{},
+				},
+			},
+			wantReasons: []string{"ForwardProxyNotValid"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			proxy := &contour_api_v1.HTTPProxy{
+				ObjectMeta: metav1.ObjectMeta{Name: "egress", Namespace: "default"},
+				Spec:       tc.spec,
+			}
+
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			builder.Source.Insert(kuard)
+			builder.Source.Insert(proxy)
+			dag := builder.Build()
+
+			vhost := dag.GetVirtualHost(ListenerName{Name: "egress.example.com", ListenerName: "ingress_http"})
+			if tc.want == nil {
+				assert.Nil(t, vhost)
+			} else if assert.NotNil(t, vhost) {
+				assert.Equal(t, tc.want, vhost.ForwardProxy)
+				route := &Route{
+					PathMatchCondition:  &PrefixMatchCondition{Prefix: "/"},
+					DynamicForwardProxy: true,
+				}
+				assert.Equal(t, map[string]*Route{conditionsToString(route): route}, vhost.routes)
+			}
+
+			var reasons []string
+			for _, pu := range dag.StatusCache.GetProxyUpdates() {
+				for _, cond := range pu.Conditions {
+					for _, e := range cond.Errors {
+						reasons = append(reasons, e.Reason)
+					}
+				}
+			}
+			assert.Equal(t, tc.wantReasons, reasons)
+		})
+	}
+}
+
+func TestDAGIngressPolicy(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+	teamKuard := fixture.NewService("team/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+
+	root := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{Name: "root", Namespace: "default"},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "www.example.com"},
+			Includes: []contour_api_v1.Include{{
+				Name:      "child",
+				Namespace: "team",
+			}},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{Prefix: "/"}},
+				Services:   []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+			}},
+		},
+	}
+	child := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{Name: "child", Namespace: "team"},
+		Spec: contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{Prefix: "/team"}},
+				Services:   []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+			}},
+		},
+	}
+	ingress := &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "default"},
+		Spec: networking_v1.IngressSpec{
+			Rules: []networking_v1.IngressRule{{
+				Host:             "ingress.example.com",
+				IngressRuleValue: ingressrulev1value(backendv1("kuard", intstr.FromInt(8080))),
+			}},
+		},
+	}
+
+	policy := func(namespace string, hosts ...string) *contour_api_v1alpha1.IngressPolicy {
+		return &contour_api_v1alpha1.IngressPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: namespace},
+			Spec:       contour_api_v1alpha1.IngressPolicySpec{AllowedHosts: hosts},
+		}
+	}
+
+	tests := map[string]struct {
+		require     bool
+		objs        []interface{}
+		wantRoutes  map[string][]string
+		wantReasons []string
+	}{
+		"not required": {
+			wantRoutes: map[string][]string{
+				"www.example.com":     {"/", "/team"},
+				"ingress.example.com": {"/"},
+			},
+		},
+		"no policy": {
+			require:     true,
+			wantReasons: []string{"IngressPolicyNotPermitted", "Orphaned"},
+		},
+		"policy without allowed hosts": {
+			require: true,
+			objs:    []interface{}{policy("default"), policy("team")},
+			wantRoutes: map[string][]string{
+				"www.example.com":     {"/", "/team"},
+				"ingress.example.com": {"/"},
+			},
+		},
+		"allowed hosts": {
+			require: true,
+			objs:    []interface{}{policy("default", "*.Example.com"), policy("team", "www.example.com")},
+			wantRoutes: map[string][]string{
+				"www.example.com":     {"/", "/team"},
+				"ingress.example.com": {"/"},
+			},
+		},
+		"host not allowed": {
+			require: true,
+			objs:    []interface{}{policy("default", "ingress.example.com"), policy("team")},
+			wantRoutes: map[string][]string{
+				"ingress.example.com": {"/"},
+			},
+			wantReasons: []string{"IngressPolicyNotPermitted", "Orphaned"},
+		},
+		"included namespace has no policy": {
+			require: true,
+			objs:    []interface{}{policy("default")},
+			wantRoutes: map[string][]string{
+				"www.example.com":     {"/"},
+				"ingress.example.com": {"/"},
+			},
+			wantReasons: []string{"IngressPolicyNotPermitted"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					RequireIngressPolicy: tc.require,
+					FieldLogger:          fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&IngressProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+					},
+					&HTTPProxyProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			for _, obj := range append([]interface{}{kuard, teamKuard, root, child, ingress}, tc.objs...) {
+				builder.Source.Insert(obj)
+			}
+			dag := builder.Build()
+
+			got := map[string][]string{}
+			for _, host := range []string{"www.example.com", "ingress.example.com"} {
+				vhost := dag.GetVirtualHost(ListenerName{Name: host, ListenerName: "ingress_http"})
+				if vhost == nil {
+					continue
+				}
+				for _, route := range vhost.routes {
+					got[host] = append(got[host], route.PathMatchCondition.(*PrefixMatchCondition).Prefix)
+				}
+				sort.Strings(got[host])
+			}
+			if tc.wantRoutes == nil {
+				tc.wantRoutes = map[string][]string{}
+			}
+			assert.Equal(t, tc.wantRoutes, got)
+
+			var reasons []string
+			for _, pu := range dag.StatusCache.GetProxyUpdates() {
+				for _, cond := range pu.Conditions {
+					for _, e := range cond.Errors {
+						reasons = append(reasons, e.Reason)
+					}
+				}
+			}
+			sort.Strings(reasons)
+			assert.Equal(t, tc.wantReasons, reasons)
+		})
+	}
+}
+
+func TestDAGGatewayAPIIngressPolicy(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+
+	gatewayclass := &gatewayapi_v1alpha1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "contour"},
+		Spec: gatewayapi_v1alpha1.GatewayClassSpec{
+			Controller: "projectcontour.io/contour",
+		},
+		Status: gatewayapi_v1alpha1.GatewayClassStatus{
+			Conditions: []metav1.Condition{{
+				Type:   string(gatewayapi_v1alpha1.GatewayClassConditionStatusAdmitted),
+				Status: metav1.ConditionTrue,
+			}},
+		},
+	}
+
+	allNamespaces := &gatewayapi_v1alpha1.RouteNamespaces{
+		From: routeSelectTypePtr(gatewayapi_v1alpha1.RouteSelectAll),
+	}
+	gateway := &gatewayapi_v1alpha1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "contour", Namespace: "projectcontour"},
+		Spec: gatewayapi_v1alpha1.GatewaySpec{
+			GatewayClassName: gatewayclass.Name,
+			Listeners: []gatewayapi_v1alpha1.Listener{{
+				Port:     80,
+				Protocol: gatewayapi_v1alpha1.HTTPProtocolType,
+				Routes: gatewayapi_v1alpha1.RouteBindingSelector{
+					Kind:       KindHTTPRoute,
+					Namespaces: allNamespaces,
+				},
+			}, {
+				Port:     443,
+				Protocol: gatewayapi_v1alpha1.TLSProtocolType,
+				TLS: &gatewayapi_v1alpha1.GatewayTLSConfig{
+					Mode: tlsModeTypePtr(gatewayapi_v1alpha1.TLSModePassthrough),
+				},
+				Routes: gatewayapi_v1alpha1.RouteBindingSelector{
+					Kind:       KindTLSRoute,
+					Namespaces: allNamespaces,
+				},
+			}},
+		},
+	}
+
+	httproute := &gatewayapi_v1alpha1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "http", Namespace: "default"},
+		Spec: gatewayapi_v1alpha1.HTTPRouteSpec{
+			Gateways: &gatewayapi_v1alpha1.RouteGateways{
+				Allow: gatewayAllowTypePtr(gatewayapi_v1alpha1.GatewayAllowAll),
+			},
+			Hostnames: []gatewayapi_v1alpha1.Hostname{"www.example.com", "api.example.com"},
+			Rules: []gatewayapi_v1alpha1.HTTPRouteRule{{
+				Matches:   httpRouteMatch(gatewayapi_v1alpha1.PathMatchPrefix, "/"),
+				ForwardTo: httpRouteForwardTo("kuard", 8080, 0),
+			}},
+		},
+	}
+	tlsroute := &gatewayapi_v1alpha1.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"},
+		Spec: gatewayapi_v1alpha1.TLSRouteSpec{
+			Gateways: &gatewayapi_v1alpha1.RouteGateways{
+				Allow: gatewayAllowTypePtr(gatewayapi_v1alpha1.GatewayAllowAll),
+			},
+			Rules: []gatewayapi_v1alpha1.TLSRouteRule{{
+				Matches: []gatewayapi_v1alpha1.TLSRouteMatch{{
+					SNIs: []gatewayapi_v1alpha1.Hostname{"tls.example.com"},
+				}},
+				ForwardTo: tcpRouteForwardTo("kuard", 8080, 0),
+			}},
+		},
+	}
+
+	policy := func(hosts ...string) *contour_api_v1alpha1.IngressPolicy {
+		return &contour_api_v1alpha1.IngressPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default"},
+			Spec:       contour_api_v1alpha1.IngressPolicySpec{AllowedHosts: hosts},
+		}
+	}
+
+	tests := map[string]struct {
+		require       bool
+		objs          []interface{}
+		wantHosts     []string
+		wantAdmitted  map[string]metav1.ConditionStatus
+		wantPermitted map[string]bool
+	}{
+		"not required": {
+			wantHosts:    []string{"api.example.com", "tls.example.com", "www.example.com"},
+			wantAdmitted: map[string]metav1.ConditionStatus{"http": metav1.ConditionTrue, "tls": metav1.ConditionTrue},
+		},
+		"no policy": {
+			require:       true,
+			wantAdmitted:  map[string]metav1.ConditionStatus{"http": metav1.ConditionFalse, "tls": metav1.ConditionFalse},
+			wantPermitted: map[string]bool{"http": false, "tls": false},
+		},
+		"policy without allowed hosts": {
+			require:      true,
+			objs:         []interface{}{policy()},
+			wantHosts:    []string{"api.example.com", "tls.example.com", "www.example.com"},
+			wantAdmitted: map[string]metav1.ConditionStatus{"http": metav1.ConditionTrue, "tls": metav1.ConditionTrue},
+		},
+		"some hosts not allowed": {
+			require:       true,
+			objs:          []interface{}{policy("www.example.com", "tls.example.com")},
+			wantHosts:     []string{"tls.example.com", "www.example.com"},
+			wantAdmitted:  map[string]metav1.ConditionStatus{"http": metav1.ConditionFalse, "tls": metav1.ConditionTrue},
+			wantPermitted: map[string]bool{"http": false},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					RequireIngressPolicy: tc.require,
+					ConfiguredGateway: types.NamespacedName{
+						Name:      "contour",
+						Namespace: "projectcontour",
+					},
+					gatewayclass: gatewayclass,
+					gateway:      gateway,
+					FieldLogger:  fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&GatewayAPIProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+					},
+					&ListenerProcessor{},
+				},
+			}
+			for _, obj := range append([]interface{}{kuard, httproute, tlsroute}, tc.objs...) {
+				builder.Source.Insert(obj)
+			}
+			dag := builder.Build()
+
+			listeners := map[int]*Listener{}
+			dag.Visit(listenerMap(listeners).Visit)
+
+			var hosts []string
+			for _, listener := range listeners {
+				for _, v := range listener.VirtualHosts {
+					switch v := v.(type) {
+					case *VirtualHost:
+						hosts = append(hosts, v.Name)
+					case *SecureVirtualHost:
+						hosts = append(hosts, v.Name)
+					}
+				}
+			}
+			sort.Strings(hosts)
+			assert.Equal(t, tc.wantHosts, hosts)
+
+			admitted := map[string]metav1.ConditionStatus{}
+			permitted := map[string]bool{}
+			for _, ru := range dag.StatusCache.GetRouteUpdates() {
+				admitted[ru.FullName.Name] = ru.Conditions[gatewayapi_v1alpha1.ConditionRouteAdmitted].Status
+				if cond, ok := ru.Conditions[status.ConditionIngressPolicyPermitted]; ok {
+					assert.Equal(t, string(status.ReasonIngressPolicyNotPermitted), cond.Reason)
+					permitted[ru.FullName.Name] = cond.Status == metav1.ConditionTrue
+				}
+			}
+			assert.Equal(t, tc.wantAdmitted, admitted)
+			if tc.wantPermitted == nil {
+				tc.wantPermitted = map[string]bool{}
+			}
+			assert.Equal(t, tc.wantPermitted, permitted)
+		})
+	}
+}
+
+func TestHttpPaths(t *testing.T) {
+	tests := map[string]struct {
+		rule networking_v1.IngressRule
+		want []networking_v1.HTTPIngressPath
+	}{
+		"zero value": {
+			rule: networking_v1.IngressRule{},
+			want: nil,
+		},
+		"empty paths": {
+			rule: networking_v1.IngressRule{
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{},
+				},
+			},
+			want: nil,
+		},
+		"several paths": {
+			rule: networking_v1.IngressRule{
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{
+						Paths: []networking_v1.HTTPIngressPath{{
+							Backend: *backendv1("kuard", intstr.FromString("http")),
+						}, {
+							Path:    "/kuarder",
+							Backend: *backendv1("kuarder", intstr.FromInt(8080)),
+						}},
+					},
+				},
+			},
+			want: []networking_v1.HTTPIngressPath{{
+				Backend: *backendv1("kuard", intstr.FromString("http")),
+			}, {
+				Path:    "/kuarder",
+				Backend: *backendv1("kuarder", intstr.FromInt(8080)),
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := httppaths(tc.rule)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDetermineSNI(t *testing.T) {
+	tests := map[string]struct {
+		routeRequestHeaders   *HeadersPolicy
+		clusterRequestHeaders *HeadersPolicy
+		service               *Service
+		want                  string
 	}{
 		"default SNI": {
 			routeRequestHeaders:   nil,
@@ -10383,7 +12180,7 @@ func TestValidateHeaderAlteration(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, gotErr := headersPolicyService(test.dhp, test.in, test.dyn)
+			got, gotErr := headersPolicyService(test.dhp, nil, test.in, test.dyn)
 			assert.Equal(t, test.want, got)
 			assert.Equal(t, test.wantErr, gotErr)
 		})
@@ -10408,6 +12205,85 @@ func TestBuilderRunsProcessorsInOrder(t *testing.T) {
 	assert.Equal(t, []string{"foo", "bar", "baz", "abc", "def"}, got)
 }
 
+func TestBuilderObservesDurations(t *testing.T) {
+	processorDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "processor"}, []string{"processor"})
+	objectDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "object"}, []string{"kind"})
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger:    fixture.NewTestLogger(t),
+			ObjectDuration: objectDuration,
+		},
+		Processors: []Processor{
+			&IngressProcessor{
+				FieldLogger: fixture.NewTestLogger(t),
+			},
+			&HTTPProxyProcessor{},
+			ProcessorFunc(nil),
+		},
+		ProcessorDuration: processorDuration,
+	}
+
+	for _, o := range []interface{}{
+		&networking_v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "simple",
+				Namespace: "default",
+			},
+			Spec: networking_v1.IngressSpec{
+				TLS: []networking_v1.IngressTLS{{
+					Hosts:      []string{"kuard.example.com"},
+					SecretName: sec1.Name,
+				}},
+				DefaultBackend: backendv1("kuard", intstr.FromInt(8080)),
+			},
+		},
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "simple",
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "example.com",
+				},
+			},
+		},
+		sec1,
+	} {
+		builder.Source.Insert(o)
+	}
+	builder.Build()
+
+	sampleCounts := func(vec *prometheus.HistogramVec) map[string]uint64 {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(vec)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		counts := map[string]uint64{}
+		for _, family := range families {
+			for _, m := range family.Metric {
+				counts[m.Label[0].GetValue()] = m.Histogram.GetSampleCount()
+			}
+		}
+		return counts
+	}
+
+	assert.Equal(t, map[string]uint64{
+		"IngressProcessor":   1,
+		"HTTPProxyProcessor": 1,
+		"ProcessorFunc":      1,
+	}, sampleCounts(processorDuration))
+	assert.Equal(t, map[string]uint64{
+		"Ingress":   1,
+		"HTTPProxy": 1,
+		"Secret":    1,
+	}, sampleCounts(objectDuration))
+}
+
 func routes(routes ...*Route) map[string]*Route {
 	if len(routes) == 0 {
 		return nil
@@ -10664,3 +12540,24 @@ func routeSelectTypePtr(rst gatewayapi_v1alpha1.RouteSelectType) *gatewayapi_v1a
 func tlsModeTypePtr(mode gatewayapi_v1alpha1.TLSModeType) *gatewayapi_v1alpha1.TLSModeType {
 	return &mode
 }
+
+func BenchmarkBuilderBuild(b *testing.B) {
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewDiscardLogger(),
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+	for _, o := range fixture.SyntheticObjects(5, 50) {
+		builder.Source.Insert(o)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		builder.Build()
+	}
+}
@@ -16,13 +16,17 @@ package dag
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"sync"
+	"time"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/annotation"
 	"github.com/projectcontour/contour/internal/k8s"
 	ingress_validation "github.com/projectcontour/contour/internal/validation/ingress"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
@@ -42,16 +46,43 @@ type KubernetesCache struct {
 	// namespace.
 	RootNamespaces []string
 
+	// RequireIngressPolicy restricts the virtual hosts and routes
+	// that are programmed to those of namespaces that have an
+	// IngressPolicy allowing them.
+	RequireIngressPolicy bool
+
 	// Contour's IngressClassName.
 	// If not set, defaults to DEFAULT_INGRESS_CLASS.
 	IngressClassName string
 
+	// IngressClassMatching selects whether Ingresses and
+	// HTTPProxies that have no ingress class set are matched.
+	IngressClassMatching annotation.ClassMatching
+
 	// ConfiguredGateway defines the current Gateway which Contour is configured to watch.
 	ConfiguredGateway types.NamespacedName
 
 	// Secrets that are referred from the configuration file.
 	ConfiguredSecretRefs []*types.NamespacedName
 
+	// Services that are referred from the configuration file.
+	ConfiguredServiceRefs []*types.NamespacedName
+
+	// ObjectDuration, if not nil, observes the time taken
+	// to process each object when building the DAG, by
+	// object kind.
+	ObjectDuration prometheus.ObserverVec
+
+	// QuarantinedSecrets, if not nil, is set to the number of
+	// Secrets served at their last valid version because their
+	// latest version is invalid.
+	QuarantinedSecrets prometheus.Gauge
+
+	// HTTPProxyLabels is true if the labels of HTTPProxies
+	// are consumed when building the DAG, in which case a
+	// label-only update triggers a rebuild.
+	HTTPProxyLabels bool
+
 	ingresses                 map[types.NamespacedName]*networking_v1.Ingress
 	ingressclass              *networking_v1.IngressClass
 	httpproxies               map[types.NamespacedName]*contour_api_v1.HTTPProxy
@@ -67,6 +98,21 @@ type KubernetesCache struct {
 	udproutes                 map[types.NamespacedName]*gatewayapi_v1alpha1.UDPRoute
 	backendpolicies           map[types.NamespacedName]*gatewayapi_v1alpha1.BackendPolicy
 	extensions                map[types.NamespacedName]*contour_api_v1alpha1.ExtensionService
+	trafficsplits             map[types.NamespacedName]*contour_api_v1alpha1.TrafficSplit
+	ingresspolicies           map[types.NamespacedName]*contour_api_v1alpha1.IngressPolicy
+
+	// specHashes holds a hash of the parts of each accepted
+	// object that are consumed when building the DAG.
+	specHashes map[specKey]string
+
+	// quarantined holds the objects which are excluded from
+	// the DAG because Envoy rejected their configuration.
+	quarantined map[quarantineKey]quarantineEntry
+
+	// quarantinedSecrets holds the Secrets whose latest version
+	// is invalid, and which are served at their last valid
+	// version instead.
+	quarantinedSecrets map[types.NamespacedName]secretQuarantineEntry
 
 	initialize sync.Once
 
@@ -87,6 +133,11 @@ func (kc *KubernetesCache) init() {
 	kc.tlsroutes = make(map[types.NamespacedName]*gatewayapi_v1alpha1.TLSRoute)
 	kc.backendpolicies = make(map[types.NamespacedName]*gatewayapi_v1alpha1.BackendPolicy)
 	kc.extensions = make(map[types.NamespacedName]*contour_api_v1alpha1.ExtensionService)
+	kc.trafficsplits = make(map[types.NamespacedName]*contour_api_v1alpha1.TrafficSplit)
+	kc.ingresspolicies = make(map[types.NamespacedName]*contour_api_v1alpha1.IngressPolicy)
+	kc.specHashes = make(map[specKey]string)
+	kc.quarantined = make(map[quarantineKey]quarantineEntry)
+	kc.quarantinedSecrets = make(map[types.NamespacedName]secretQuarantineEntry)
 }
 
 // matchesIngressClass returns true if the given IngressClass
@@ -109,15 +160,10 @@ func (kc *KubernetesCache) matchesIngressClass(obj *networking_v1.IngressClass)
 // the configured ingress class name via annotation or Spec.IngressClassName
 // and emits a log message if there is no match.
 func (kc *KubernetesCache) ingressMatchesIngressClass(obj *networking_v1.Ingress) bool {
-	if !ingress_validation.MatchesIngressClassName(obj, kc.IngressClassName) {
+	if !ingress_validation.MatchesIngressClassNameWith(obj, kc.IngressClassName, kc.IngressClassMatching) {
 		// We didn't get a match so report this object is being ignored.
-		kc.WithField("name", obj.GetName()).
-			WithField("namespace", obj.GetNamespace()).
-			WithField("kind", k8s.KindOf(obj)).
-			WithField("ingress-class-annotation", annotation.IngressClass(obj)).
-			WithField("ingress-class-name", pointer.StringPtrDerefOr(obj.Spec.IngressClassName, "")).
-			WithField("target-ingress-class", kc.IngressClassName).
-			Debug("ignoring object with unmatched ingress class")
+		specClass := pointer.StringPtrDerefOr(obj.Spec.IngressClassName, "")
+		kc.logUnmatchedIngressClass(kc.WithField("ingress-class-name", specClass), obj, specClass == "")
 		return false
 	}
 	return true
@@ -126,19 +172,34 @@ func (kc *KubernetesCache) ingressMatchesIngressClass(obj *networking_v1.Ingress
 // matchesIngressClassAnnotation returns true if the given Kubernetes object
 // belongs to the Ingress class that this cache is using.
 func (kc *KubernetesCache) matchesIngressClassAnnotation(obj metav1.Object) bool {
-	if !annotation.MatchesIngressClass(obj, kc.IngressClassName) {
-		kc.WithField("name", obj.GetName()).
-			WithField("namespace", obj.GetNamespace()).
-			WithField("kind", k8s.KindOf(obj)).
-			WithField("ingress-class", annotation.IngressClass(obj)).
-			WithField("target-ingress-class", kc.IngressClassName).
-			Debug("ignoring object with unmatched ingress class")
+	if !annotation.MatchesIngressClassWith(obj, kc.IngressClassName, kc.IngressClassMatching) {
+		kc.logUnmatchedIngressClass(kc.FieldLogger, obj, true)
 		return false
 	}
 
 	return true
 }
 
+// logUnmatchedIngressClass reports that the given object is being
+// ignored because it does not match the ingress class. Objects that
+// are ignored because they have no ingress class set are reported
+// at info level when strict class matching is selected, as they
+// would otherwise be served by default.
+func (kc *KubernetesCache) logUnmatchedIngressClass(log logrus.FieldLogger, obj metav1.Object, unsetSpecClass bool) {
+	log = log.WithField("name", obj.GetName()).
+		WithField("namespace", obj.GetNamespace()).
+		WithField("kind", k8s.KindOf(obj)).
+		WithField("ingress-class-annotation", annotation.IngressClass(obj)).
+		WithField("target-ingress-class", kc.IngressClassName)
+
+	if unsetSpecClass && annotation.IngressClass(obj) == "" && kc.IngressClassMatching == annotation.ClassMatchingStrict {
+		log.Info("ignoring object with no ingress class set because of strict ingress class matching")
+		return
+	}
+
+	log.Debug("ignoring object with unmatched ingress class")
+}
+
 // matchesGateway returns true if the given Kubernetes object
 // belongs to the Gateway that this cache is using.
 func (kc *KubernetesCache) matchesGateway(obj *gatewayapi_v1alpha1.Gateway) bool {
@@ -160,10 +221,61 @@ func (kc *KubernetesCache) matchesGateway(obj *gatewayapi_v1alpha1.Gateway) bool
 // Insert inserts obj into the KubernetesCache.
 // Insert returns true if the cache accepted the object, or false if the value
 // is not interesting to the cache. If an object with a matching type, name,
-// and namespace exists, it will be overwritten.
+// and namespace exists, it will be overwritten, and Insert returns false
+// if none of the fields consumed by the DAG have changed.
 func (kc *KubernetesCache) Insert(obj interface{}) bool {
+	accepted, changed := kc.insertObject(obj)
+	return accepted && changed
+}
+
+// Update replaces oldObj with newObj in the KubernetesCache.
+// Update returns true if the cache changed in a way that requires
+// the DAG to be rebuilt. Updates that only change fields that
+// the DAG doesn't consume, such as the status, return false.
+func (kc *KubernetesCache) Update(oldObj, newObj interface{}) bool {
 	kc.initialize.Do(kc.init)
 
+	// Keep the spec hash of the old object so that
+	// the new object can be compared against it. An
+	// invalid Secret version is quarantined, so the last
+	// valid version has to stay cached.
+	removed := false
+	if !quarantinesSecret(newObj) {
+		removed = kc.remove(oldObj)
+	}
+	accepted, changed := kc.insertObject(newObj)
+	if accepted {
+		return changed
+	}
+	return removed
+}
+
+// insertObject inserts obj into the KubernetesCache. It returns whether
+// the cache accepted the object, and whether the spec of the object
+// changed since it was last accepted.
+func (kc *KubernetesCache) insertObject(obj interface{}) (bool, bool) {
+	kc.initialize.Do(kc.init)
+
+	key, hash := specHashOf(obj, kc.labelsConsumed(obj))
+	if !kc.insert(obj) {
+		delete(kc.specHashes, key)
+		return false, false
+	}
+
+	prev, ok := kc.specHashes[key]
+	kc.specHashes[key] = hash
+	if ok && hash != "" && prev == hash {
+		kc.WithField("name", key.Name).
+			WithField("namespace", key.Namespace).
+			WithField("kind", key.kind).
+			Debug("skipping rebuild, spec is unchanged")
+		return true, false
+	}
+
+	return true, true
+}
+
+func (kc *KubernetesCache) insert(obj interface{}) bool {
 	if obj, ok := obj.(metav1.Object); ok {
 		kind := k8s.KindOf(obj)
 		for key := range obj.GetAnnotations() {
@@ -195,10 +307,13 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 					WithField("kind", "Secret").
 					WithField("version", k8s.VersionOf(obj)).
 					Error(err)
+
+				return kc.quarantineSecret(obj, err)
 			}
 			return false
 		}
 
+		kc.liftSecretQuarantine(k8s.NamespacedNameOf(obj))
 		kc.secrets[k8s.NamespacedNameOf(obj)] = obj
 		return kc.secretTriggersRebuild(obj)
 	case *v1.Service:
@@ -255,6 +370,12 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 	case *contour_api_v1alpha1.ExtensionService:
 		kc.extensions[k8s.NamespacedNameOf(obj)] = obj
 		return true
+	case *contour_api_v1alpha1.TrafficSplit:
+		kc.trafficsplits[k8s.NamespacedNameOf(obj)] = obj
+		return true
+	case *contour_api_v1alpha1.IngressPolicy:
+		kc.ingresspolicies[k8s.NamespacedNameOf(obj)] = obj
+		return true
 
 	default:
 		// not an interesting object
@@ -272,6 +393,9 @@ func (kc *KubernetesCache) Remove(obj interface{}) bool {
 
 	switch obj := obj.(type) {
 	default:
+		key, _ := specHashOf(obj, false)
+		delete(kc.specHashes, key)
+		kc.liftQuarantine(obj)
 		return kc.remove(obj)
 	case cache.DeletedFinalStateUnknown:
 		return kc.Remove(obj.Obj) // recurse into ourselves with the tombstoned value
@@ -357,6 +481,16 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 		_, ok := kc.extensions[m]
 		delete(kc.extensions, m)
 		return ok
+	case *contour_api_v1alpha1.TrafficSplit:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.trafficsplits[m]
+		delete(kc.trafficsplits, m)
+		return ok
+	case *contour_api_v1alpha1.IngressPolicy:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.ingresspolicies[m]
+		delete(kc.ingresspolicies, m)
+		return ok
 
 	default:
 		// not interesting
@@ -366,8 +500,12 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 }
 
 // serviceTriggersRebuild returns true if this service is referenced
-// by an Ingress or HTTPProxy in this cache.
+// by an Ingress or HTTPProxy in this cache, or is annotated to be exposed.
 func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
+	if annotation.Exposed(service) {
+		return true
+	}
+
 	for _, ingress := range kc.ingresses {
 		if ingress.Namespace != service.Namespace {
 			continue
@@ -411,6 +549,17 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 		}
 	}
 
+	for _, split := range kc.trafficsplits {
+		if split.Namespace != service.Namespace {
+			continue
+		}
+		for _, backend := range split.Spec.Backends {
+			if backend.Service == service.Name {
+				return true
+			}
+		}
+	}
+
 	for _, route := range kc.httproutes {
 		if route.Namespace != service.Namespace {
 			continue
@@ -426,6 +575,13 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 		}
 	}
 
+	// Services referred by the configuration file shall also trigger rebuild.
+	for _, s := range kc.ConfiguredServiceRefs {
+		if s.Namespace == service.Namespace && s.Name == service.Name {
+			return true
+		}
+	}
+
 	return false
 }
 
@@ -484,6 +640,9 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 			// not a root ingress
 			continue
 		}
+		if vh.OIDCPolicy != nil && proxy.Namespace == secret.Namespace && vh.OIDCPolicy.ClientCredentialsSecret == secret.Name {
+			return true
+		}
 		tls := vh.TLS
 		if tls == nil {
 			// no tls spec
@@ -533,9 +692,19 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 	return false
 }
 
+// observeObject records the time taken since start to process
+// an object of the given kind.
+func (kc *KubernetesCache) observeObject(kind string, start time.Time) {
+	if kc.ObjectDuration != nil {
+		kc.ObjectDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	}
+}
+
 // LookupSecret returns a Secret if present or nil if the underlying kubernetes
 // secret fails validation or is missing.
 func (kc *KubernetesCache) LookupSecret(name types.NamespacedName, validate func(*v1.Secret) error) (*Secret, error) {
+	defer kc.observeObject("Secret", time.Now())
+
 	sec, ok := kc.secrets[name]
 	if !ok {
 		return nil, fmt.Errorf("Secret not found")
@@ -565,15 +734,64 @@ func (kc *KubernetesCache) LookupUpstreamValidation(uv *contour_api_v1.UpstreamV
 		return nil, fmt.Errorf("invalid CA Secret %q: %s", secretName, err)
 	}
 
-	if uv.SubjectName == "" {
+	switch {
+	case uv.SubjectName == "" && uv.SpiffeID == "":
 		// UpstreamValidation is requested, but SAN is not provided
 		return nil, errors.New("missing subject alternative name")
+	case uv.SubjectName != "" && uv.SpiffeID != "":
+		return nil, errors.New("subjectName and spiffeID cannot both be specified")
 	}
 
-	return &PeerValidationContext{
+	pvc := &PeerValidationContext{
 		CACertificate: cacert,
 		SubjectName:   uv.SubjectName,
-	}, nil
+	}
+
+	for _, name := range uv.AdditionalCACertificates {
+		secretName := types.NamespacedName{Name: name, Namespace: namespace}
+		cacert, err := kc.LookupSecret(secretName, validCA)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CA Secret %q: %s", secretName, err)
+		}
+		pvc.AdditionalCACertificates = append(pvc.AdditionalCACertificates, cacert)
+	}
+
+	if uv.SpiffeID != "" {
+		if err := validSpiffeID(uv.SpiffeID); err != nil {
+			return nil, fmt.Errorf("invalid spiffeID %q: %s", uv.SpiffeID, err)
+		}
+
+		// Envoy matches the URI subject alternative names of
+		// the certificate, along with the other types.
+		pvc.SubjectAltNames = []SubjectAltNameMatch{{
+			MatchType: SubjectAltNameMatchTypeExact,
+			Value:     uv.SpiffeID,
+		}}
+	}
+
+	return pvc, nil
+}
+
+// validSpiffeID returns an error if id is not a SPIFFE ID, that is,
+// a spiffe URI with a trust domain and an optional path.
+func validSpiffeID(id string) error {
+	u, err := url.Parse(id)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case u.Scheme != "spiffe":
+		return errors.New("scheme must be spiffe")
+	case u.Host == "":
+		return errors.New("missing trust domain")
+	case u.Port() != "" || u.User != nil:
+		return errors.New("trust domain must not have a port or user info")
+	case u.RawQuery != "" || u.Fragment != "":
+		return errors.New("must not have a query or fragment")
+	}
+
+	return nil
 }
 
 // DelegationPermitted returns true if the referenced secret has been delegated
@@ -611,6 +829,27 @@ func (kc *KubernetesCache) DelegationPermitted(secret types.NamespacedName, targ
 	return false
 }
 
+// validOIDCCredentials returns an error unless s holds a client ID
+// and a client secret, and any HMAC secret is long enough to sign
+// session cookies with.
+func validOIDCCredentials(s *v1.Secret) error {
+	for _, key := range []string{OIDCClientIDKey, OIDCClientSecretKey} {
+		data := s.Data[key]
+		if len(data) == 0 {
+			return fmt.Errorf("empty %q key", key)
+		}
+		if strings.TrimSpace(string(data)) != string(data) {
+			return fmt.Errorf("%q key has leading or trailing whitespace", key)
+		}
+	}
+
+	if data, ok := s.Data[OIDCHMACSecretKey]; ok && len(data) < OIDCMinHMACSecretLength {
+		return fmt.Errorf("%q key must be at least %d bytes", OIDCHMACSecretKey, OIDCMinHMACSecretLength)
+	}
+
+	return nil
+}
+
 func validCA(s *v1.Secret) error {
 	if len(s.Data[CACertificateKey]) == 0 {
 		return fmt.Errorf("empty %q key", CACertificateKey)
@@ -619,6 +858,72 @@ func validCA(s *v1.Secret) error {
 	return nil
 }
 
+// ingressPolicyPermits returns an error if RequireIngressPolicy is
+// set and no IngressPolicy in namespace allows a virtual host for
+// host.
+func (kc *KubernetesCache) ingressPolicyPermits(namespace, host string) error {
+	if !kc.RequireIngressPolicy {
+		return nil
+	}
+
+	found := false
+	for _, policy := range kc.ingresspolicies {
+		if policy.Namespace != namespace {
+			continue
+		}
+		found = true
+		if len(policy.Spec.AllowedHosts) == 0 {
+			return nil
+		}
+		for _, allowed := range policy.Spec.AllowedHosts {
+			if hostMatches(strings.ToLower(allowed), strings.ToLower(host)) {
+				return nil
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("namespace %q has no IngressPolicy", namespace)
+	}
+	return fmt.Errorf("host %q is not allowed by an IngressPolicy of namespace %q", host, namespace)
+}
+
+// namespaceBlocked returns true if a cluster administrator has
+// blocked the routes of the objects in namespace, by annotating
+// it with projectcontour.io/routes-blocked: "true".
+func (kc *KubernetesCache) namespaceBlocked(namespace string) bool {
+	ns, ok := kc.namespaces[namespace]
+	return ok && annotation.RoutesBlocked(ns)
+}
+
+// hostMatches returns true if host is pattern, or is a
+// subdomain of the domain of a wildcard pattern such as
+// "*.example.com".
+func hostMatches(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return pattern == host
+}
+
+// LookupTrafficSplit returns the TrafficSplit that splits the traffic
+// of the named service, or nil if there is none. If several split it,
+// the oldest is used, then the first by name.
+func (kc *KubernetesCache) LookupTrafficSplit(service types.NamespacedName) *contour_api_v1alpha1.TrafficSplit {
+	var found *contour_api_v1alpha1.TrafficSplit
+	for _, split := range kc.trafficsplits {
+		if split.Namespace != service.Namespace || split.Spec.Service != service.Name {
+			continue
+		}
+		if found == nil ||
+			split.CreationTimestamp.Before(&found.CreationTimestamp) ||
+			(split.CreationTimestamp.Equal(&found.CreationTimestamp) && split.Name < found.Name) {
+			found = split
+		}
+	}
+	return found
+}
+
 // LookupService returns the Kubernetes service and port matching the provided parameters,
 // or an error if a match can't be found.
 func (kc *KubernetesCache) LookupService(meta types.NamespacedName, port intstr.IntOrString) (*v1.Service, v1.ServicePort, error) {
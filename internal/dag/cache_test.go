@@ -835,6 +835,35 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert traffic split": {
+			obj: &contour_api_v1alpha1.TrafficSplit{
+				ObjectMeta: fixture.ObjectMeta("default/split"),
+			},
+			want: true,
+		},
+		"insert ingress policy": {
+			obj: &contour_api_v1alpha1.IngressPolicy{
+				ObjectMeta: fixture.ObjectMeta("default/policy"),
+			},
+			want: true,
+		},
+		"insert service referenced by traffic split backend": {
+			pre: []interface{}{
+				&contour_api_v1alpha1.TrafficSplit{
+					ObjectMeta: fixture.ObjectMeta("default/split"),
+					Spec: contour_api_v1alpha1.TrafficSplitSpec{
+						Service: "app",
+						Backends: []contour_api_v1alpha1.TrafficSplitBackend{
+							{Service: "app-canary", Weight: 10},
+						},
+					},
+				},
+			},
+			obj: &v1.Service{
+				ObjectMeta: fixture.ObjectMeta("default/app-canary"),
+			},
+			want: true,
+		},
 		"insert secret that is referred by configuration file": {
 			obj: &v1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -868,6 +897,184 @@ func TestKubernetesCacheInsert(t *testing.T) {
 	}
 }
 
+func TestKubernetesCacheInsertIngressClassMatching(t *testing.T) {
+	classlessIngress := &networking_v1.Ingress{
+		ObjectMeta: fixture.ObjectMeta("default/classless"),
+	}
+	classlessProxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: fixture.ObjectMeta("default/classless"),
+	}
+	classedProxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "classed",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"projectcontour.io/ingress.class": "contour",
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		ingressClassName string
+		matching         annotation.ClassMatching
+		obj              interface{}
+		want             bool
+	}{
+		"default matching, no class configured": {
+			obj:  classlessIngress,
+			want: true,
+		},
+		"default matching, class configured": {
+			ingressClassName: "contour",
+			obj:              classlessProxy,
+			want:             false,
+		},
+		"strict matching, classless ingress": {
+			matching: annotation.ClassMatchingStrict,
+			obj:      classlessIngress,
+			want:     false,
+		},
+		"strict matching, classless httpproxy": {
+			matching: annotation.ClassMatchingStrict,
+			obj:      classlessProxy,
+			want:     false,
+		},
+		"strict matching, httpproxy with default class": {
+			matching: annotation.ClassMatchingStrict,
+			obj:      classedProxy,
+			want:     true,
+		},
+		"permissive matching, classless ingress": {
+			ingressClassName: "contour",
+			matching:         annotation.ClassMatchingPermissive,
+			obj:              classlessIngress,
+			want:             true,
+		},
+		"permissive matching, classless httpproxy": {
+			ingressClassName: "contour",
+			matching:         annotation.ClassMatchingPermissive,
+			obj:              classlessProxy,
+			want:             true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cache := KubernetesCache{
+				IngressClassName:     tc.ingressClassName,
+				IngressClassMatching: tc.matching,
+				FieldLogger:          fixture.NewTestLogger(t),
+			}
+			assert.Equal(t, tc.want, cache.Insert(tc.obj))
+		})
+	}
+}
+
+func TestKubernetesCacheUpdate(t *testing.T) {
+	proxy := func(mutate func(*contour_api_v1.HTTPProxy)) *contour_api_v1.HTTPProxy {
+		p := &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "proxy",
+				Namespace:       "default",
+				ResourceVersion: "1",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "example.com"},
+			},
+		}
+		if mutate != nil {
+			mutate(p)
+		}
+		return p
+	}
+
+	tests := map[string]struct {
+		obj  interface{}
+		want bool
+	}{
+		"status only": {
+			obj: proxy(func(p *contour_api_v1.HTTPProxy) {
+				p.ResourceVersion = "2"
+				p.Status.CurrentStatus = "valid"
+			}),
+			want: false,
+		},
+		"labels only": {
+			obj: proxy(func(p *contour_api_v1.HTTPProxy) {
+				p.Labels = map[string]string{"app": "kuard"}
+			}),
+			want: false,
+		},
+		"spec changed": {
+			obj: proxy(func(p *contour_api_v1.HTTPProxy) {
+				p.Spec.VirtualHost.Fqdn = "example.org"
+			}),
+			want: true,
+		},
+		"annotations changed": {
+			obj: proxy(func(p *contour_api_v1.HTTPProxy) {
+				p.Annotations = map[string]string{"kubernetes.io/ingress.class": "contour"}
+			}),
+			want: true,
+		},
+		"no longer matches ingress class": {
+			obj: proxy(func(p *contour_api_v1.HTTPProxy) {
+				p.Annotations = map[string]string{"kubernetes.io/ingress.class": "nginx"}
+			}),
+			want: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cache := KubernetesCache{
+				FieldLogger: fixture.NewTestLogger(t),
+			}
+			old := proxy(nil)
+			require.True(t, cache.Insert(old))
+			assert.Equal(t, tc.want, cache.Update(old, tc.obj))
+		})
+	}
+
+	// Reinserting an unchanged object doesn't trigger a rebuild,
+	// unless it was removed in between.
+	cache := KubernetesCache{
+		FieldLogger: fixture.NewTestLogger(t),
+	}
+	require.True(t, cache.Insert(proxy(nil)))
+	assert.False(t, cache.Insert(proxy(nil)))
+	require.True(t, cache.Remove(proxy(nil)))
+	assert.True(t, cache.Insert(proxy(nil)))
+
+	// Namespace labels are consumed by route selectors.
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	require.True(t, cache.Insert(ns))
+	labelled := ns.DeepCopy()
+	labelled.Labels = map[string]string{"app": "kuard"}
+	assert.True(t, cache.Update(ns, labelled))
+
+	// HTTPProxy labels are only consumed when they are
+	// copied to the Envoy metadata.
+	labelledProxy := func(value string) *contour_api_v1.HTTPProxy {
+		return proxy(func(p *contour_api_v1.HTTPProxy) {
+			p.Labels = map[string]string{"team": value}
+		})
+	}
+	cache = KubernetesCache{
+		FieldLogger: fixture.NewTestLogger(t),
+	}
+	require.True(t, cache.Insert(labelledProxy("blue")))
+	assert.False(t, cache.Insert(labelledProxy("green")))
+
+	cache = KubernetesCache{
+		HTTPProxyLabels: true,
+		FieldLogger:     fixture.NewTestLogger(t),
+	}
+	require.True(t, cache.Insert(labelledProxy("blue")))
+	assert.True(t, cache.Insert(labelledProxy("green")))
+	assert.False(t, cache.Insert(labelledProxy("green")))
+}
+
 func TestKubernetesCacheRemove(t *testing.T) {
 	cache := func(objs ...interface{}) *KubernetesCache {
 		cache := KubernetesCache{
@@ -1164,6 +1371,24 @@ func TestKubernetesCacheRemove(t *testing.T) {
 			},
 			want: true,
 		},
+		"remove traffic split": {
+			cache: cache(&contour_api_v1alpha1.TrafficSplit{
+				ObjectMeta: fixture.ObjectMeta("default/split"),
+			}),
+			obj: &contour_api_v1alpha1.TrafficSplit{
+				ObjectMeta: fixture.ObjectMeta("default/split"),
+			},
+			want: true,
+		},
+		"remove ingress policy": {
+			cache: cache(&contour_api_v1alpha1.IngressPolicy{
+				ObjectMeta: fixture.ObjectMeta("default/policy"),
+			}),
+			obj: &contour_api_v1alpha1.IngressPolicy{
+				ObjectMeta: fixture.ObjectMeta("default/policy"),
+			},
+			want: true,
+		},
 		"remove unknown": {
 			cache: cache("not an object"),
 			obj:   "not an object",
@@ -1276,6 +1501,31 @@ func TestLookupService(t *testing.T) {
 	}
 }
 
+func TestLookupTrafficSplit(t *testing.T) {
+	split := func(name string, created int64) *contour_api_v1alpha1.TrafficSplit {
+		return &contour_api_v1alpha1.TrafficSplit{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.Unix(created, 0),
+			},
+			Spec: contour_api_v1alpha1.TrafficSplitSpec{Service: "app"},
+		}
+	}
+
+	cache := KubernetesCache{FieldLogger: fixture.NewTestLogger(t)}
+	for _, o := range []interface{}{split("c", 2), split("b", 1), split("a", 2)} {
+		cache.Insert(o)
+	}
+
+	assert.Equal(t, "b", cache.LookupTrafficSplit(types.NamespacedName{Namespace: "default", Name: "app"}).Name)
+	assert.Nil(t, cache.LookupTrafficSplit(types.NamespacedName{Namespace: "other", Name: "app"}))
+	assert.Nil(t, cache.LookupTrafficSplit(types.NamespacedName{Namespace: "default", Name: "other"}))
+
+	cache.Remove(split("b", 1))
+	assert.Equal(t, "a", cache.LookupTrafficSplit(types.NamespacedName{Namespace: "default", Name: "app"}).Name)
+}
+
 func TestServiceTriggersRebuild(t *testing.T) {
 
 	cache := func(objs ...interface{}) *KubernetesCache {
@@ -1483,6 +1733,17 @@ func TestServiceTriggersRebuild(t *testing.T) {
 			svc:  service("default", "service-1"),
 			want: false,
 		},
+		"service is exposed": {
+			cache: cache(),
+			svc: &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "service-1",
+					Namespace:   "default",
+					Annotations: map[string]string{"projectcontour.io/expose": "true"},
+				},
+			},
+			want: true,
+		},
 	}
 
 	for name, tc := range tests {
@@ -69,6 +69,35 @@ func pathMatchConditionsValid(conds []contour_api_v1.MatchCondition) error {
 	return nil
 }
 
+// notPrefixMatchConditionsValid validates the notPrefix MatchConditions of a route.
+// Each must start with a / character, and exclude less than all requests.
+func notPrefixMatchConditionsValid(conds []contour_api_v1.MatchCondition) error {
+	for _, cond := range conds {
+		if cond.NotPrefix == "" {
+			continue
+		}
+		if cond.NotPrefix[0] != '/' {
+			return fmt.Errorf("notPrefix conditions must start with /, %s was supplied", cond.NotPrefix)
+		}
+		if strings.Trim(cond.NotPrefix, "/") == "" {
+			return fmt.Errorf("notPrefix condition %s excludes all requests", cond.NotPrefix)
+		}
+	}
+
+	return nil
+}
+
+// notPrefixMatchConditionsPresent returns true if any of the MatchConditions
+// has a notPrefix.
+func notPrefixMatchConditionsPresent(conds []contour_api_v1.MatchCondition) bool {
+	for _, cond := range conds {
+		if cond.NotPrefix != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func mergeHeaderMatchConditions(conds []contour_api_v1.MatchCondition) []HeaderMatchCondition {
 	var headerConditions []contour_api_v1.HeaderMatchCondition
 	for _, cond := range conds {
@@ -98,29 +127,39 @@ func headerMatchConditions(conditions []contour_api_v1.HeaderMatchCondition) []H
 			})
 		case cond.Contains != "":
 			hc = append(hc, HeaderMatchCondition{
-				Name:      cond.Name,
-				Value:     cond.Contains,
-				MatchType: HeaderMatchTypeContains,
+				Name:       cond.Name,
+				Value:      cond.Contains,
+				MatchType:  HeaderMatchTypeContains,
+				IgnoreCase: cond.IgnoreCase,
 			})
 		case cond.NotContains != "":
 			hc = append(hc, HeaderMatchCondition{
-				Name:      cond.Name,
-				Value:     cond.NotContains,
-				MatchType: HeaderMatchTypeContains,
-				Invert:    true,
+				Name:       cond.Name,
+				Value:      cond.NotContains,
+				MatchType:  HeaderMatchTypeContains,
+				Invert:     true,
+				IgnoreCase: cond.IgnoreCase,
 			})
 		case cond.Exact != "":
 			hc = append(hc, HeaderMatchCondition{
-				Name:      cond.Name,
-				Value:     cond.Exact,
-				MatchType: HeaderMatchTypeExact,
+				Name:       cond.Name,
+				Value:      cond.Exact,
+				MatchType:  HeaderMatchTypeExact,
+				IgnoreCase: cond.IgnoreCase,
 			})
 		case cond.NotExact != "":
+			hc = append(hc, HeaderMatchCondition{
+				Name:       cond.Name,
+				Value:      cond.NotExact,
+				MatchType:  HeaderMatchTypeExact,
+				Invert:     true,
+				IgnoreCase: cond.IgnoreCase,
+			})
+		case cond.Regex != "":
 			hc = append(hc, HeaderMatchCondition{
 				Name:      cond.Name,
-				Value:     cond.NotExact,
-				MatchType: HeaderMatchTypeExact,
-				Invert:    true,
+				Value:     cond.Regex,
+				MatchType: HeaderMatchTypeRegex,
 			})
 		}
 	}
@@ -130,6 +169,9 @@ func headerMatchConditions(conditions []contour_api_v1.HeaderMatchCondition) []H
 // headerMatchConditionsValid validates that the header conditions within a
 // slice of MatchConditions are valid. Specifically, it returns an error for
 // any of the following scenarios:
+//	- more than one match type in a single header condition
+//	- a 'regex' condition that isn't a valid regular expression
+//	- 'ignoreCase' on a condition that doesn't compare the header value to a string
 //	- more than 1 'exact' condition for the same header
 //	- a 'present' and a 'notpresent' condition for the same header
 //	- an 'exact' and a 'notexact' condition for the same header, with the same values
@@ -146,6 +188,15 @@ func headerMatchConditionsValid(conditions []contour_api_v1.MatchCondition) erro
 			continue
 		}
 
+		if headerMatchTypeCount(v.Header) > 1 {
+			return fmt.Errorf("header condition for %q must specify only one of 'present', 'notpresent', 'contains', 'notcontains', 'exact', 'notexact' or 'regex'", v.Header.Name)
+		}
+
+		if v.Header.IgnoreCase && v.Header.Contains == "" && v.Header.NotContains == "" &&
+			v.Header.Exact == "" && v.Header.NotExact == "" {
+			return fmt.Errorf("header condition for %q can only specify 'ignoreCase' with 'contains', 'notcontains', 'exact' or 'notexact'", v.Header.Name)
+		}
+
 		headerName := strings.ToLower(v.Header.Name)
 		switch {
 		case v.Header.Present:
@@ -200,6 +251,10 @@ func headerMatchConditionsValid(conditions []contour_api_v1.MatchCondition) erro
 			}] {
 				return errors.New("cannot specify contradictory 'contains' and 'notcontains' conditions for the same route and header")
 			}
+		case v.Header.Regex != "":
+			if err := ValidateRegex(v.Header.Regex); err != nil {
+				return fmt.Errorf("invalid 'regex' condition for header %q: %w", v.Header.Name, err)
+			}
 		}
 
 		key := *v.Header
@@ -211,6 +266,26 @@ func headerMatchConditionsValid(conditions []contour_api_v1.MatchCondition) erro
 	return nil
 }
 
+// headerMatchTypeCount returns the number of match types that are
+// specified in the supplied header condition.
+func headerMatchTypeCount(cond *contour_api_v1.HeaderMatchCondition) int {
+	count := 0
+	for _, set := range []bool{
+		cond.Present,
+		cond.NotPresent,
+		cond.Contains != "",
+		cond.NotContains != "",
+		cond.Exact != "",
+		cond.NotExact != "",
+		cond.Regex != "",
+	} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
 // ValidateRegex returns an error if the supplied
 // RE2 regex syntax is invalid.
 func ValidateRegex(regex string) error {
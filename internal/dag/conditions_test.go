@@ -138,6 +138,34 @@ func TestHeaderMatchConditions(t *testing.T) {
 				Invert:    true,
 			}},
 		},
+		"header regex": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "x-request-id",
+					Regex: "^[a-f0-9]+$",
+				},
+			}},
+			want: []HeaderMatchCondition{{
+				Name:      "x-request-id",
+				MatchType: "regex",
+				Value:     "^[a-f0-9]+$",
+			}},
+		},
+		"header exact ignore case": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-request-id",
+					Exact:      "ABCdef",
+					IgnoreCase: true,
+				},
+			}},
+			want: []HeaderMatchCondition{{
+				Name:       "x-request-id",
+				MatchType:  "exact",
+				Value:      "ABCdef",
+				IgnoreCase: true,
+			}},
+		},
 		"header name but missing condition": {
 			matchconditions: []contour_api_v1.MatchCondition{{
 				Header: &contour_api_v1.HeaderMatchCondition{
@@ -517,6 +545,74 @@ func TestValidateHeaderMatchConditions(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		"'regex' matchcondition is valid": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "x-header",
+					Regex: "^v[0-9]+$",
+				},
+			}},
+			wantErr: false,
+		},
+		"invalid 'regex' matchcondition is invalid": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "x-header",
+					Regex: "^v[0-9+$",
+				},
+			}},
+			wantErr: true,
+		},
+		"'regex' and 'exact' in the same matchcondition are invalid": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:  "x-header",
+					Exact: "v1",
+					Regex: "^v[0-9]+$",
+				},
+			}},
+			wantErr: true,
+		},
+		"'ignoreCase' with 'contains' is valid": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-header",
+					Contains:   "abc",
+					IgnoreCase: true,
+				},
+			}},
+			wantErr: false,
+		},
+		"'ignoreCase' with 'present' is invalid": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-header",
+					Present:    true,
+					IgnoreCase: true,
+				},
+			}},
+			wantErr: true,
+		},
+		"'ignoreCase' with 'regex' is invalid": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-header",
+					Regex:      "^v[0-9]+$",
+					IgnoreCase: true,
+				},
+			}},
+			wantErr: true,
+		},
+		"'present' and 'notpresent' in the same matchcondition are invalid": {
+			matchconditions: []contour_api_v1.MatchCondition{{
+				Header: &contour_api_v1.HeaderMatchCondition{
+					Name:       "x-header",
+					Present:    true,
+					NotPresent: true,
+				},
+			}},
+			wantErr: true,
+		},
 		"'present' and 'notpresent' matchconditions for different headers are valid": {
 			matchconditions: []contour_api_v1.MatchCondition{
 				{
@@ -16,8 +16,11 @@
 package dag
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -25,7 +28,9 @@ import (
 	"github.com/projectcontour/contour/internal/status"
 	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/projectcontour/contour/internal/xds"
+	"github.com/projectcontour/contour/pkg/config"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -67,10 +72,22 @@ type DAG struct {
 	// StatusCache holds a cache of status updates to send.
 	StatusCache status.Cache
 
+	// RebuildAt is the time at which the active window of a
+	// route next opens or closes, or a tap expires, so the DAG
+	// must be rebuilt for the change to take effect. It is zero
+	// if there is no such change.
+	RebuildAt time.Time
+
 	// roots are the root vertices of this DAG.
 	roots []Vertex
 }
 
+// rebuildBy ensures that the DAG is rebuilt no later than t.
+// A zero t is ignored.
+func (d *DAG) rebuildBy(t time.Time) {
+	d.RebuildAt = earliest(d.RebuildAt, t)
+}
+
 // Visit calls fn on each root of this DAG.
 func (d *DAG) Visit(fn func(Vertex)) {
 	for _, r := range d.roots {
@@ -169,10 +186,11 @@ const (
 
 // HeaderMatchCondition matches request headers by MatchType
 type HeaderMatchCondition struct {
-	Name      string
-	Value     string
-	MatchType string
-	Invert    bool
+	Name       string
+	Value      string
+	MatchType  string
+	Invert     bool
+	IgnoreCase bool
 }
 
 func (hc *HeaderMatchCondition) String() string {
@@ -181,6 +199,7 @@ func (hc *HeaderMatchCondition) String() string {
 		"value=" + hc.Value,
 		"matchtype=", hc.MatchType,
 		"invert=", strconv.FormatBool(hc.Invert),
+		"ignorecase=", strconv.FormatBool(hc.IgnoreCase),
 	}, "&")
 
 	return "header: " + details
@@ -191,6 +210,23 @@ func (hc *HeaderMatchCondition) String() string {
 // an envoy cluster.
 type DirectResponse struct {
 	StatusCode uint32
+
+	// Body is the optional body of the response.
+	Body string
+}
+
+// Redirect redirects a route request to a different
+// hostname or path instead of routing to an envoy cluster.
+type Redirect struct {
+	// Hostname replaces the hostname of the request URL, if set.
+	Hostname string
+
+	// Path replaces the path of the request URL, if set.
+	Path string
+
+	// StatusCode is the status code of the redirect,
+	// either 301 or 302.
+	StatusCode int
 }
 
 // Route defines the properties of a route to a Cluster.
@@ -204,6 +240,23 @@ type Route struct {
 	// match on the request headers.
 	HeaderMatchConditions []HeaderMatchCondition
 
+	// IgnorePathCase specifies that the PathMatchCondition is
+	// matched without regard to case.
+	IgnorePathCase bool
+
+	// CreationTimestamp is the creation time of the object
+	// that this route was built from. It orders routes that
+	// otherwise match the same requests, oldest first.
+	CreationTimestamp time.Time
+
+	// Source is the Kubernetes object that this route was
+	// built from.
+	Source ObjectReference
+
+	// Labels are the labels of the source object that are
+	// copied into the route's metadata.
+	Labels map[string]string
+
 	Clusters []*Cluster
 
 	// Should this route generate a 301 upgrade if accessed
@@ -231,6 +284,15 @@ type Route struct {
 	// Indicates that during forwarding, the matched prefix (or path) should be swapped with this value
 	PrefixRewrite string
 
+	// Exclusion indicates that the route answers the requests that
+	// a notPrefix condition excludes from another route.
+	Exclusion bool
+
+	// StripPrefix indicates that during forwarding, the matched
+	// prefix and the slashes that follow it are removed from paths
+	// in which the prefix is followed by a slash or nothing.
+	StripPrefix bool
+
 	// Mirror Policy defines the mirroring policy for this Route.
 	MirrorPolicy *MirrorPolicy
 
@@ -243,6 +305,9 @@ type Route struct {
 	// RateLimitPolicy defines if/how requests for the route are rate limited.
 	RateLimitPolicy *RateLimitPolicy
 
+	// RBACPolicy restricts the requests that the route accepts.
+	RBACPolicy *RBACPolicy
+
 	// RequestHashPolicies is a list of policies for configuring hashes on
 	// request attributes.
 	RequestHashPolicies []RequestHashPolicy
@@ -251,6 +316,15 @@ type Route struct {
 	// to be the response to a route request vs routing to
 	// an envoy cluster.
 	DirectResponse *DirectResponse
+
+	// Redirect redirects the route request elsewhere
+	// instead of routing to an envoy cluster.
+	Redirect *Redirect
+
+	// DynamicForwardProxy routes the request to the host named
+	// by its Host header, which Envoy resolves itself, instead
+	// of routing to the clusters of the route.
+	DynamicForwardProxy bool
 }
 
 // HasPathPrefix returns whether this route has a PrefixPathCondition.
@@ -265,6 +339,28 @@ func (r *Route) HasPathRegex() bool {
 	return ok
 }
 
+// ObjectReference identifies the Kubernetes object that a
+// route or TCP proxy was built from.
+type ObjectReference struct {
+	Kind      string
+	Namespace string
+	Name      string
+
+	// ChangeCause is the value of the object's
+	// kubernetes.io/change-cause annotation, if set.
+	ChangeCause string
+}
+
+// objectReference returns an ObjectReference to obj of the given kind.
+func objectReference(kind string, obj metav1.Object) ObjectReference {
+	return ObjectReference{
+		Kind:        kind,
+		Namespace:   obj.GetNamespace(),
+		Name:        obj.GetName(),
+		ChangeCause: obj.GetAnnotations()["kubernetes.io/change-cause"],
+	}
+}
+
 // TimeoutPolicy defines the timeout policy for a route.
 type TimeoutPolicy struct {
 	// ResponseTimeout is the timeout applied to the response
@@ -291,6 +387,10 @@ type RetryPolicy struct {
 	// PerTryTimeout specifies the timeout per retry attempt.
 	// Ignored if RetryOn is blank.
 	PerTryTimeout timeout.Setting
+
+	// RetryOtherHosts sends retries to different upstream
+	// endpoints than the attempts that failed.
+	RetryOtherHosts bool
 }
 
 // MirrorPolicy defines the mirroring policy for a route.
@@ -308,6 +408,17 @@ type HeadersPolicy struct {
 	Remove []string
 }
 
+// RBACPolicy holds the methods and header values that the
+// requests of a route must have to be accepted.
+type RBACPolicy struct {
+	// Methods lists the accepted methods. If empty,
+	// any method is accepted.
+	Methods []string
+
+	// Headers lists the header values that requests must have.
+	Headers []HeaderMatchCondition
+}
+
 // RateLimitPolicy holds rate limiting parameters.
 type RateLimitPolicy struct {
 	Local  *LocalRateLimitPolicy
@@ -427,21 +538,72 @@ type PeerValidationContext struct {
 	// CACertificate holds a reference to the Secret containing the CA to be used to
 	// verify the upstream connection.
 	CACertificate *Secret
+	// AdditionalCACertificates holds references to Secrets containing
+	// CAs that are trusted along with CACertificate.
+	AdditionalCACertificates []*Secret
 	// SubjectName holds an optional subject name which Envoy will check against the
 	// certificate presented by the upstream.
 	SubjectName string
 	// SkipClientCertValidation when set to true will ensure Envoy requests but
 	// does not verify peer certificates.
 	SkipClientCertValidation bool
+	// SubjectAltNames holds an optional list of matches, at least one of which
+	// must match a subject alternative name of the certificate presented by the peer.
+	SubjectAltNames []SubjectAltNameMatch
+}
+
+// UpstreamTLS defines the TLS parameters used to connect to an upstream.
+type UpstreamTLS struct {
+	// MinimumProtocolVersion and MaximumProtocolVersion bound the
+	// TLS versions negotiated with the upstream. Empty values use
+	// Envoy's defaults.
+	MinimumProtocolVersion string
+	MaximumProtocolVersion string
+
+	// CipherSuites is the list of cipher suites offered when
+	// negotiating TLS 1.2 with the upstream.
+	CipherSuites []string
+}
+
+const (
+	// SubjectAltNameMatchTypeExact matches a subject alternative name exactly.
+	SubjectAltNameMatchTypeExact = "exact"
+
+	// SubjectAltNameMatchTypeSuffix matches a subject alternative name if it
+	// ends with the provided value.
+	SubjectAltNameMatchTypeSuffix = "suffix"
+)
+
+// SubjectAltNameMatch matches a subject alternative name of a peer certificate.
+type SubjectAltNameMatch struct {
+	// MatchType is one of SubjectAltNameMatchTypeExact or SubjectAltNameMatchTypeSuffix.
+	MatchType string
+	// Value is the string the subject alternative name is matched against.
+	Value string
 }
 
-// GetCACertificate returns the CA certificate from PeerValidationContext.
+// GetCACertificate returns the CA certificate from PeerValidationContext,
+// followed by any additional CA certificates.
 func (pvc *PeerValidationContext) GetCACertificate() []byte {
 	if pvc == nil || pvc.CACertificate == nil {
 		// No validation required.
 		return nil
 	}
-	return pvc.CACertificate.Object.Data[CACertificateKey]
+
+	ca := pvc.CACertificate.Object.Data[CACertificateKey]
+	if len(pvc.AdditionalCACertificates) == 0 {
+		return ca
+	}
+
+	bundle := append([]byte{}, ca...)
+	for _, s := range pvc.AdditionalCACertificates {
+		// Separate the PEM blocks of each bundle.
+		if len(bundle) > 0 && bundle[len(bundle)-1] != '\n' {
+			bundle = append(bundle, '\n')
+		}
+		bundle = append(bundle, s.Object.Data[CACertificateKey]...)
+	}
+	return bundle
 }
 
 // GetSubjectName returns the SubjectName from PeerValidationContext.
@@ -479,18 +641,55 @@ type VirtualHost struct {
 	// are rate limited.
 	RateLimitPolicy *RateLimitPolicy
 
+	// TapPolicy enables capturing the requests to the VirtualHost
+	// with the Envoy tap filter. If nil, requests are not captured.
+	TapPolicy *TapPolicy
+
+	// ForwardProxy, if set, makes the VirtualHost a forward
+	// proxy for the domains of the policy, rather than for
+	// its own name.
+	ForwardProxy *ForwardProxy
+
 	routes map[string]*Route
 }
 
+// ForwardProxy configures a virtual host whose routes forward
+// requests to the host named by their Host header.
+type ForwardProxy struct {
+	// AllowedDomains are the hostnames and wildcard
+	// domains the virtual host accepts requests for.
+	AllowedDomains []string
+}
+
+// TapPolicy selects the requests to a virtual host that are captured
+// by the Envoy tap filter.
+type TapPolicy struct {
+	// PathPrefix restricts the captured requests to those whose
+	// path starts with it. If empty, all requests are captured.
+	PathPrefix string
+
+	// Until is the time at which the tap expires.
+	Until time.Time
+}
+
 func (v *VirtualHost) addRoute(route *Route) {
 	if v.routes == nil {
 		v.routes = make(map[string]*Route)
 	}
-	v.routes[conditionsToString(route)] = route
+	key := conditionsToString(route)
+	// A route takes precedence over an exclusion route
+	// that matches the same requests.
+	if existing, ok := v.routes[key]; ok && route.Exclusion && !existing.Exclusion {
+		return
+	}
+	v.routes[key] = route
 }
 
 func conditionsToString(r *Route) string {
 	s := []string{r.PathMatchCondition.String()}
+	if r.IgnorePathCase {
+		s = append(s, "ignorepathcase")
+	}
 	for _, cond := range r.HeaderMatchConditions {
 		s = append(s, cond.String())
 	}
@@ -541,6 +740,87 @@ type SecureVirtualHost struct {
 	// only reason to set this to `true` is when you are migrating
 	// from internal to external authorization.
 	AuthorizationFailOpen bool
+
+	// OIDCPolicy configures browser based OpenID Connect login
+	// for this host. If nil, OIDC login is not enabled.
+	OIDCPolicy *OIDCPolicy
+
+	// MaxConnectionDuration overrides the listener's maximum
+	// duration of downstream connections to this vhost.
+	MaxConnectionDuration timeout.Setting
+
+	// MaxRequestHeadersKB and MaxRequestHeadersCount override the
+	// listener's limits on the request headers of this vhost.
+	// Zero values use the listener's limits.
+	MaxRequestHeadersKB    uint32
+	MaxRequestHeadersCount uint32
+
+	// PathNormalization overrides the listener's request path
+	// normalization for this vhost. If nil, the listener's
+	// settings are used.
+	PathNormalization *PathNormalization
+
+	// HTTP10Policy configures the handling of HTTP/1.0 requests
+	// for this vhost. If nil, HTTP/1.0 requests that carry a
+	// Host header are accepted.
+	HTTP10Policy *HTTP10Policy
+
+	// ConcurrencyPolicy enables admission control for this
+	// vhost. If nil, admission control is not enabled.
+	ConcurrencyPolicy *ConcurrencyPolicy
+
+	// AccessLogHeaders overrides the request and response headers
+	// captured in the access log of this vhost. If nil, the
+	// listener's headers are captured.
+	AccessLogHeaders *config.AccessLogHeaders
+
+	// ServerHeader overrides the Server header configuration of
+	// the listener for this vhost. Fields that are not set keep
+	// the listener's values.
+	ServerHeader *config.ServerHeaderParameters
+
+	// HTTPVersions overrides the listener's HTTP versions that
+	// this vhost offers with ALPN. If empty, the listener's
+	// versions are offered.
+	HTTPVersions []config.HTTPVersionType
+}
+
+// ConcurrencyPolicy configures admission control, which rejects
+// requests when the success rate of recent requests is too low.
+type ConcurrencyPolicy struct {
+	// SuccessRateThreshold is the percentage of requests that
+	// must succeed before requests are rejected.
+	SuccessRateThreshold uint32
+
+	// SamplingWindow is the window over which the success
+	// rate is calculated.
+	SamplingWindow time.Duration
+}
+
+// HTTP10Policy controls how Envoy handles HTTP/1.0 requests.
+type HTTP10Policy struct {
+	// Disabled rejects HTTP/1.0 requests.
+	Disabled bool
+
+	// DefaultHost is the host used for HTTP/1.0 requests
+	// that do not carry a Host header.
+	DefaultHost string
+}
+
+// PathNormalization controls how Envoy normalizes request
+// paths before routing them.
+type PathNormalization struct {
+	// DisableNormalizePath disables RFC 3986 normalization
+	// of the request path.
+	DisableNormalizePath bool
+
+	// DisableMergeSlashes disables merging adjacent slashes
+	// in the request path.
+	DisableMergeSlashes bool
+
+	// EscapedSlashesAction is the action to take when the
+	// request path contains escaped slashes.
+	EscapedSlashesAction config.EscapedSlashesActionType
 }
 
 func (s *SecureVirtualHost) Visit(f func(Vertex)) {
@@ -551,6 +831,9 @@ func (s *SecureVirtualHost) Visit(f func(Vertex)) {
 	if s.Secret != nil {
 		f(s.Secret) // secret is not required if vhost is using tls passthrough
 	}
+	if s.OIDCPolicy != nil {
+		f(s.OIDCPolicy.TokenCluster)
+	}
 }
 
 func (s *SecureVirtualHost) Valid() bool {
@@ -560,6 +843,91 @@ func (s *SecureVirtualHost) Valid() bool {
 	return (s.Secret != nil && len(s.routes) > 0) || s.TCPProxy != nil
 }
 
+// OIDCPolicy holds the configuration of the OpenID Connect
+// provider that users of a SecureVirtualHost log in with.
+type OIDCPolicy struct {
+	// AuthorizationEndpoint is the URL users are redirected to
+	// in order to log in.
+	AuthorizationEndpoint string
+
+	// TokenEndpoint is the URL authorization codes are exchanged
+	// for access tokens with.
+	TokenEndpoint string
+
+	// TokenCluster is the cluster that is used to reach the
+	// token endpoint.
+	TokenCluster *DNSNameCluster
+
+	// Scopes are the OAuth2 scopes that are requested.
+	Scopes []string
+
+	// ClientID is the OAuth2 client identifier.
+	ClientID string
+
+	// Credentials is the Secret holding the client secret and,
+	// optionally, the HMAC secret.
+	Credentials *Secret
+
+	// RedirectPath is the path of the OAuth2 callback.
+	RedirectPath string
+
+	// SignoutPath is the path that clears the session cookies.
+	SignoutPath string
+
+	// ForwardBearerToken specifies whether the access token is
+	// forwarded to upstream services.
+	ForwardBearerToken bool
+}
+
+// ClientSecret returns the OAuth2 client secret.
+func (o *OIDCPolicy) ClientSecret() []byte {
+	return o.Credentials.Object.Data[OIDCClientSecretKey]
+}
+
+// HMACSecret returns the secret used to sign session cookies. If the
+// credentials Secret does not contain an HMAC secret, one is derived
+// from the client secret so that every Contour replica programs the
+// same value.
+func (o *OIDCPolicy) HMACSecret() []byte {
+	if s := o.Credentials.Object.Data[OIDCHMACSecretKey]; len(s) > 0 {
+		return s
+	}
+
+	mac := hmac.New(sha256.New, o.ClientSecret())
+	mac.Write([]byte("contour-oidc-hmac")) // nolint:errcheck
+	return mac.Sum(nil)
+}
+
+// DNSNameCluster is a cluster that routes directly to a DNS
+// name (i.e. not a Kubernetes service).
+type DNSNameCluster struct {
+	// Address is the DNS name of the upstream.
+	Address string
+
+	// Scheme is the URL scheme of the upstream, either "http"
+	// or "https".
+	Scheme string
+
+	// Port is the port of the upstream.
+	Port int
+
+	// DNSLookupFamily defines how external names are looked up.
+	DNSLookupFamily string
+
+	// UpstreamValidation defines how to verify the upstream's
+	// certificate.
+	UpstreamValidation *PeerValidationContext
+}
+
+// Name returns the name of the cluster.
+func (c *DNSNameCluster) Name() string {
+	return strings.Join([]string{"dnsname", c.Scheme, c.Address, strconv.Itoa(c.Port)}, "/")
+}
+
+func (c *DNSNameCluster) Visit(func(Vertex)) {
+	// DNSNameClusters are leaves in the DAG.
+}
+
 type ListenerName struct {
 	Name         string
 	ListenerName string
@@ -577,12 +945,19 @@ type Listener struct {
 	Port int
 
 	VirtualHosts []Vertex
+
+	// FallbackTCPProxy is the optional TCP proxy that receives
+	// TLS connections which do not present an SNI server name.
+	FallbackTCPProxy *TCPProxy
 }
 
 func (l *Listener) Visit(f func(Vertex)) {
 	for _, vh := range l.VirtualHosts {
 		f(vh)
 	}
+	if l.FallbackTCPProxy != nil {
+		f(l.FallbackTCPProxy)
+	}
 }
 
 // TCPProxy represents a cluster of TCP endpoints.
@@ -591,6 +966,18 @@ type TCPProxy struct {
 	// Clusters is the, possibly weighted, set
 	// of upstream services to forward decrypted traffic.
 	Clusters []*Cluster
+
+	// AllowedSourceRanges is the set of client address ranges
+	// permitted to connect. If empty, all addresses are permitted.
+	AllowedSourceRanges []*net.IPNet
+
+	// MaxConnectionDuration is the maximum duration of a
+	// proxied connection, regardless of activity.
+	MaxConnectionDuration timeout.Setting
+
+	// Source is the Kubernetes object that this TCP proxy
+	// was built from.
+	Source ObjectReference
 }
 
 func (t *TCPProxy) Visit(f func(Vertex)) {
@@ -627,6 +1014,10 @@ type Service struct {
 
 	// ExternalName is an optional field referencing a dns entry for Service type "ExternalName"
 	ExternalName string
+
+	// MaxRequestsPerConnection is the maximum number of requests
+	// that Envoy will send over a single upstream connection.
+	MaxRequestsPerConnection uint32
 }
 
 // Visit applies the visitor function to the Service vertex.
@@ -665,6 +1056,10 @@ type Cluster struct {
 	// UpstreamValidation defines how to verify the backend service's certificate
 	UpstreamValidation *PeerValidationContext
 
+	// UpstreamTLS defines the TLS parameters used to connect to the
+	// backend service. If nil, Envoy's defaults are used.
+	UpstreamTLS *UpstreamTLS
+
 	// The load balancer strategy to use when picking a host in the cluster.
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/cluster/v3/cluster.proto#enum-config-cluster-v3-cluster-lbpolicy
 	LoadBalancerPolicy string
@@ -698,13 +1093,96 @@ type Cluster struct {
 	// Note: This only applies to externalName clusters.
 	DNSLookupFamily string
 
+	// DNSLookupFamilyOverridden is true if DNSLookupFamily was set
+	// by the service to a value other than the configured default.
+	// Only overridden families change the name of the cluster, so
+	// that the clusters using the default keep their names.
+	DNSLookupFamilyOverridden bool
+
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *Secret
+
+	// EnableTrailers enables the propagation of trailers to and from
+	// HTTP/1 upstreams. Trailers are always propagated for HTTP/2 upstreams.
+	EnableTrailers bool
+
+	// Name is the name of the Envoy cluster. If empty, the name is
+	// derived from the service and properties of the cluster.
+	Name string
+
+	// Failover lists the services that traffic fails over to, in
+	// order of their priority, when the endpoints of the upstream
+	// service are unavailable.
+	Failover []WeightedService
+
+	// LBConfig tunes how Envoy balances requests across the
+	// endpoints of the cluster during partial outages.
+	LBConfig ClusterLBConfig
+
+	// MaxRequestsPerConnection is the maximum number of requests
+	// Envoy sends over a connection to the upstream before closing
+	// it. 0 means there is no limit.
+	MaxRequestsPerConnection uint32
+}
+
+// ClusterLBConfig holds the settings of Envoy's common load
+// balancer configuration of a cluster.
+type ClusterLBConfig struct {
+	// HealthyPanicThreshold is the percentage of healthy endpoints
+	// below which Envoy balances requests across all the endpoints,
+	// regardless of their health. 0 disables panic mode.
+	HealthyPanicThreshold uint32
+
+	// MinimumRingSize and MaximumRingSize bound the number of
+	// entries of the hash ring of the cluster, if it uses a
+	// hashing load balancer policy. 0 uses Envoy's defaults.
+	MinimumRingSize uint64
+	MaximumRingSize uint64
+
+	// RingHashFunction is the hash function used to build the hash
+	// ring, either "xx_hash" or "murmur_hash_2". Empty uses Envoy's
+	// default, xx_hash.
+	RingHashFunction string
 }
 
 func (c Cluster) Visit(f func(Vertex)) {
 	f(c.Upstream)
+	if sc := c.FailoverCluster(); sc != nil {
+		f(sc)
+	}
+}
+
+// FailoverCluster returns the ServiceCluster that combines the
+// endpoints of the upstream service, at priority 0, with those of
+// the failover services. It returns nil if the cluster has no
+// failover services.
+func (c *Cluster) FailoverCluster() *ServiceCluster {
+	if len(c.Failover) == 0 {
+		return nil
+	}
+
+	primary := c.Upstream.Weighted
+	primary.Weight = 1
+	primary.Priority = 0
+
+	sc := &ServiceCluster{
+		Services: append([]WeightedService{primary}, c.Failover...),
+	}
+	sc.Rebalance()
+
+	// The failover services are part of the cluster load assignment
+	// name so that each combination has its own endpoints.
+	h := sha256.New()
+	for _, w := range sc.Services {
+		fmt.Fprintf(h, "%s/%s/%s/%d/%d,", w.ServiceNamespace, w.ServiceName, w.ServicePort.Name, w.Weight, w.Priority)
+	}
+	sc.ClusterName = xds.ClusterLoadAssignmentName(
+		types.NamespacedName{Name: primary.ServiceName, Namespace: primary.ServiceNamespace},
+		primary.ServicePort.Name,
+	) + fmt.Sprintf("/failover-%x", h.Sum(nil)[:5])
+
+	return sc
 }
 
 // WeightedService represents the load balancing weight of a
@@ -718,6 +1196,9 @@ type WeightedService struct {
 	ServiceNamespace string
 	// ServicePort is the port to which we forward traffic.
 	ServicePort v1.ServicePort
+	// Priority is the failover priority of the service. Lower
+	// values have higher priority, starting from 0.
+	Priority uint32
 }
 
 // ServiceCluster capture the set of Kubernetes Services that will
@@ -794,17 +1275,18 @@ func (s *ServiceCluster) AddWeightedService(weight uint32, name types.Namespaced
 
 // Rebalance rewrites the weights for the service cluster so that
 // if no weights are specifies, the traffic is evenly distributed.
-// This matches the behavior of weighted routes. Note that this is
+// This matches the behavior of weighted routes. Weights are
+// considered separately for each priority. Note that this is
 // a destructive operation.
 func (s *ServiceCluster) Rebalance() {
-	var sum uint32
+	sum := map[uint32]uint32{}
 
 	for _, w := range s.Services {
-		sum += w.Weight
+		sum[w.Priority] += w.Weight
 	}
 
-	if sum == 0 {
-		for i := range s.Services {
+	for i := range s.Services {
+		if sum[s.Services[i].Priority] == 0 {
 			s.Services[i].Weight = 1
 		}
 	}
@@ -853,6 +1335,15 @@ type TCPHealthCheckPolicy struct {
 	HealthyThreshold   uint32
 }
 
+// GRPCHealthCheckPolicy gRPC health check policy
+type GRPCHealthCheckPolicy struct {
+	ServiceName        string
+	Interval           time.Duration
+	Timeout            time.Duration
+	UnhealthyThreshold uint32
+	HealthyThreshold   uint32
+}
+
 // ExtensionCluster generates an Envoy cluster (aka ClusterLoadAssignment)
 // for an ExtensionService resource.
 type ExtensionCluster struct {
@@ -881,6 +1372,27 @@ type ExtensionCluster struct {
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *Secret
+
+	// Circuit breaking limits
+
+	// Max connections is maximum number of connections
+	// that Envoy will make to the upstream cluster.
+	MaxConnections uint32
+
+	// MaxPendingRequests is maximum number of pending
+	// requests that Envoy will allow to the upstream cluster.
+	MaxPendingRequests uint32
+
+	// MaxRequests is the maximum number of parallel requests that
+	// Envoy will make to the upstream cluster.
+	MaxRequests uint32
+
+	// MaxRetries is the maximum number of parallel retries that
+	// Envoy will allow to the upstream cluster.
+	MaxRetries uint32
+
+	// GRPCHealthCheckPolicy is the active gRPC health check policy.
+	GRPCHealthCheckPolicy *GRPCHealthCheckPolicy
 }
 
 // Visit processes extension clusters.
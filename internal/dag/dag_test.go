@@ -18,6 +18,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -95,6 +96,23 @@ func TestPeerValidationContext(t *testing.T) {
 	assert.Equal(t, pvc2.GetCACertificate(), []byte(nil))
 	assert.Equal(t, pvc3.GetSubjectName(), "")
 	assert.Equal(t, pvc3.GetCACertificate(), []byte(nil))
+
+	pvc4 := pvc1
+	pvc4.AdditionalCACertificates = []*Secret{{
+		Object: &v1.Secret{
+			Data: map[string][]byte{
+				CACertificateKey: []byte("oldcacert\n"),
+			},
+		},
+	}, {
+		Object: &v1.Secret{
+			Data: map[string][]byte{
+				CACertificateKey: []byte("newcacert"),
+			},
+		},
+	}}
+	assert.Equal(t, pvc4.GetCACertificate(), []byte("cacert\noldcacert\nnewcacert"))
+	assert.Equal(t, pvc1.GetCACertificate(), []byte("cacert"))
 }
 
 func TestObserverFunc(t *testing.T) {
@@ -232,6 +250,37 @@ func TestServiceClusterRebalance(t *testing.T) {
 				}},
 			},
 		},
+		"per-priority weights": {
+			have: ServiceCluster{
+				ClusterName: "test",
+				Services: []WeightedService{{
+					Weight:           3,
+					ServiceName:      "s1",
+					ServiceNamespace: "ns",
+					ServicePort:      port,
+				}, {
+					Priority:         1,
+					ServiceName:      "s2",
+					ServiceNamespace: "ns",
+					ServicePort:      port,
+				}},
+			},
+			want: ServiceCluster{
+				ClusterName: "test",
+				Services: []WeightedService{{
+					Weight:           3,
+					ServiceName:      "s1",
+					ServiceNamespace: "ns",
+					ServicePort:      port,
+				}, {
+					Weight:           1,
+					Priority:         1,
+					ServiceName:      "s2",
+					ServiceNamespace: "ns",
+					ServicePort:      port,
+				}},
+			},
+		},
 	}
 
 	for n, c := range cases {
@@ -243,3 +292,63 @@ func TestServiceClusterRebalance(t *testing.T) {
 	}
 
 }
+
+func TestClusterFailoverCluster(t *testing.T) {
+	port := v1.ServicePort{
+		Name:     "http",
+		Protocol: v1.ProtocolTCP,
+		Port:     80,
+	}
+
+	c := &Cluster{
+		Upstream: &Service{
+			Weighted: WeightedService{
+				Weight:           10,
+				ServiceName:      "primary",
+				ServiceNamespace: "ns",
+				ServicePort:      port,
+			},
+		},
+	}
+	assert.Nil(t, c.FailoverCluster())
+
+	c.Failover = []WeightedService{{
+		Priority:         1,
+		ServiceName:      "secondary",
+		ServiceNamespace: "ns",
+		ServicePort:      port,
+	}}
+
+	fc := c.FailoverCluster()
+	assert.Equal(t, []WeightedService{{
+		Weight:           1,
+		ServiceName:      "primary",
+		ServiceNamespace: "ns",
+		ServicePort:      port,
+	}, {
+		Weight:           1,
+		Priority:         1,
+		ServiceName:      "secondary",
+		ServiceNamespace: "ns",
+		ServicePort:      port,
+	}}, fc.Services)
+	assert.NoError(t, fc.Validate())
+	assert.Regexp(t, "^ns/primary/http/failover-[0-9a-f]{10}$", fc.ClusterName)
+
+	// The failover services are not modified.
+	assert.Zero(t, c.Failover[0].Weight)
+
+	// Different failover services have different cluster load assignments.
+	c.Failover[0].ServiceName = "tertiary"
+	assert.NotEqual(t, fc.ClusterName, c.FailoverCluster().ClusterName)
+}
+
+func TestSelectLabels(t *testing.T) {
+	obj := &metav1.ObjectMeta{
+		Labels: map[string]string{"team": "checkout", "app": "cart"},
+	}
+
+	assert.Nil(t, selectLabels(obj, nil))
+	assert.Nil(t, selectLabels(obj, []string{"cost-center"}))
+	assert.Equal(t, map[string]string{"team": "checkout"}, selectLabels(obj, []string{"team", "cost-center"}))
+}
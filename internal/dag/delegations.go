@@ -0,0 +1,147 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"sort"
+
+	"github.com/projectcontour/contour/internal/k8s"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DelegationUsage describes a secret delegated by a TLSCertificateDelegation
+// and the objects in other namespaces which consume it.
+type DelegationUsage struct {
+	// Delegation is the name of the TLSCertificateDelegation.
+	Delegation types.NamespacedName
+
+	// Secret is the delegated secret.
+	Secret types.NamespacedName
+
+	// TargetNamespaces are the namespaces the secret is delegated to.
+	TargetNamespaces []string
+
+	// SecretMissing is true if the delegated secret is missing
+	// or is not a valid TLS secret.
+	SecretMissing bool
+
+	// Consumers are the objects which reference the secret
+	// under this delegation.
+	Consumers []DelegationConsumer
+}
+
+// Unused returns true if no object consumes the delegated secret.
+func (d *DelegationUsage) Unused() bool {
+	return len(d.Consumers) == 0
+}
+
+// DelegationConsumer is an object which references a delegated secret.
+type DelegationConsumer struct {
+	// Kind is the kind of the consuming object, e.g. "HTTPProxy".
+	Kind string
+
+	types.NamespacedName
+}
+
+// DelegationUsages returns the usage of every secret delegated by the
+// TLSCertificateDelegations in the cache, ordered by delegation and
+// secret name.
+func (kc *KubernetesCache) DelegationUsages() []DelegationUsage {
+	kc.initialize.Do(kc.init)
+
+	// consumers maps each secret referenced from another namespace
+	// to the objects that reference it.
+	consumers := make(map[types.NamespacedName][]DelegationConsumer)
+
+	for _, proxy := range kc.httpproxies {
+		vh := proxy.Spec.VirtualHost
+		if vh == nil || vh.TLS == nil || vh.TLS.SecretName == "" {
+			continue
+		}
+		secret := k8s.NamespacedNameFrom(vh.TLS.SecretName, k8s.DefaultNamespace(proxy.Namespace))
+		if secret.Namespace == proxy.Namespace {
+			continue
+		}
+		consumers[secret] = append(consumers[secret], DelegationConsumer{
+			Kind:           "HTTPProxy",
+			NamespacedName: k8s.NamespacedNameOf(proxy),
+		})
+	}
+
+	for _, ingress := range kc.ingresses {
+		for _, tls := range ingress.Spec.TLS {
+			secret := k8s.NamespacedNameFrom(tls.SecretName, k8s.DefaultNamespace(ingress.Namespace))
+			if secret.Namespace == ingress.Namespace {
+				continue
+			}
+			consumers[secret] = append(consumers[secret], DelegationConsumer{
+				Kind:           "Ingress",
+				NamespacedName: k8s.NamespacedNameOf(ingress),
+			})
+		}
+	}
+
+	var usages []DelegationUsage
+	for name, d := range kc.tlscertificatedelegations {
+		for _, cd := range d.Spec.Delegations {
+			usage := DelegationUsage{
+				Delegation:       name,
+				Secret:           types.NamespacedName{Namespace: d.Namespace, Name: cd.SecretName},
+				TargetNamespaces: cd.TargetNamespaces,
+			}
+
+			if _, ok := kc.secrets[usage.Secret]; !ok {
+				usage.SecretMissing = true
+			}
+
+			for _, c := range consumers[usage.Secret] {
+				if delegatedTo(cd.TargetNamespaces, c.Namespace) {
+					usage.Consumers = append(usage.Consumers, c)
+				}
+			}
+			sort.Slice(usage.Consumers, func(i, j int) bool {
+				if usage.Consumers[i].Kind != usage.Consumers[j].Kind {
+					return usage.Consumers[i].Kind < usage.Consumers[j].Kind
+				}
+				return usage.Consumers[i].String() < usage.Consumers[j].String()
+			})
+
+			usages = append(usages, usage)
+		}
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Delegation != usages[j].Delegation {
+			return usages[i].Delegation.String() < usages[j].Delegation.String()
+		}
+		return usages[i].Secret.Name < usages[j].Secret.Name
+	})
+
+	return usages
+}
+
+// delegatedTo returns true if the target namespaces of a
+// delegation include the given namespace. This matches the
+// rules of DelegationPermitted.
+func delegatedTo(targetNamespaces []string, namespace string) bool {
+	if len(targetNamespaces) == 1 && targetNamespaces[0] == "*" {
+		return true
+	}
+	for _, t := range targetNamespaces {
+		if t == namespace {
+			return true
+		}
+	}
+	return false
+}
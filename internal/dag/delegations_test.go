@@ -0,0 +1,151 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDelegationUsages(t *testing.T) {
+	sec := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wildcard",
+			Namespace: "certs",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	delegation := &contour_api_v1.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "delegation",
+			Namespace: "certs",
+		},
+		Spec: contour_api_v1.TLSCertificateDelegationSpec{
+			Delegations: []contour_api_v1.CertificateDelegation{{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"team-a", "team-b"},
+			}, {
+				SecretName:       "missing",
+				TargetNamespaces: []string{"*"},
+			}},
+		},
+	}
+
+	proxy := func(namespace, secretName string) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "proxy",
+				Namespace: namespace,
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: namespace + ".example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName: secretName,
+					},
+				},
+			},
+		}
+	}
+
+	ingress := &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "team-b",
+		},
+		Spec: networking_v1.IngressSpec{
+			TLS: []networking_v1.IngressTLS{{
+				Hosts:      []string{"b.example.com"},
+				SecretName: "certs/wildcard",
+			}},
+		},
+	}
+
+	tests := map[string]struct {
+		objs []interface{}
+		want []DelegationUsage
+	}{
+		"no delegations": {
+			objs: []interface{}{sec, proxy("team-a", "certs/wildcard")},
+			want: nil,
+		},
+		"unused delegation": {
+			objs: []interface{}{sec, delegation},
+			want: []DelegationUsage{{
+				Delegation:       types.NamespacedName{Namespace: "certs", Name: "delegation"},
+				Secret:           types.NamespacedName{Namespace: "certs", Name: "missing"},
+				TargetNamespaces: []string{"*"},
+				SecretMissing:    true,
+			}, {
+				Delegation:       types.NamespacedName{Namespace: "certs", Name: "delegation"},
+				Secret:           types.NamespacedName{Namespace: "certs", Name: "wildcard"},
+				TargetNamespaces: []string{"team-a", "team-b"},
+			}},
+		},
+		"consumers in delegated namespaces": {
+			objs: []interface{}{
+				sec,
+				delegation,
+				proxy("team-a", "certs/wildcard"),
+				proxy("team-c", "certs/wildcard"),
+				proxy("team-d", "certs/missing"),
+				proxy("certs", "wildcard"),
+				ingress,
+			},
+			want: []DelegationUsage{{
+				Delegation:       types.NamespacedName{Namespace: "certs", Name: "delegation"},
+				Secret:           types.NamespacedName{Namespace: "certs", Name: "missing"},
+				TargetNamespaces: []string{"*"},
+				SecretMissing:    true,
+				Consumers: []DelegationConsumer{{
+					Kind:           "HTTPProxy",
+					NamespacedName: types.NamespacedName{Namespace: "team-d", Name: "proxy"},
+				}},
+			}, {
+				Delegation:       types.NamespacedName{Namespace: "certs", Name: "delegation"},
+				Secret:           types.NamespacedName{Namespace: "certs", Name: "wildcard"},
+				TargetNamespaces: []string{"team-a", "team-b"},
+				Consumers: []DelegationConsumer{{
+					Kind:           "HTTPProxy",
+					NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "proxy"},
+				}, {
+					Kind:           "Ingress",
+					NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "ingress"},
+				}},
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kc := KubernetesCache{
+				FieldLogger: fixture.NewTestLogger(t),
+			}
+			for _, o := range tc.objs {
+				kc.Insert(o)
+			}
+
+			assert.Equal(t, tc.want, kc.DelegationUsages())
+		})
+	}
+}
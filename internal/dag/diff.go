@@ -0,0 +1,292 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"fmt"
+	"sort"
+)
+
+// VirtualHostKey identifies a virtual host on a listener.
+type VirtualHostKey struct {
+	ListenerName string
+	Name         string
+}
+
+// RouteEntry holds the backends of a route, and the
+// Kubernetes object the route was built from.
+type RouteEntry struct {
+	Backends []string
+	Source   ObjectReference
+
+	// Websocket is true if the route allows websocket
+	// upgrades. Changes to it are not reported by Diff.
+	Websocket bool
+
+	// Labels are the labels of the source object that are
+	// copied into the route's metadata. Changes to them are
+	// not reported by Diff.
+	Labels map[string]string
+}
+
+// tcpProxyRoute is the RoutingTable key of the TCP proxy
+// of a secure virtual host.
+const tcpProxyRoute = "tcpproxy"
+
+// RoutingTable summarizes the effective routing of a DAG. It
+// maps each virtual host to its routes, keyed by their match
+// conditions.
+type RoutingTable map[VirtualHostKey]map[string]RouteEntry
+
+// NewRoutingTable returns the RoutingTable of d.
+func NewRoutingTable(d *DAG) RoutingTable {
+	table := RoutingTable{}
+
+	addRoutes := func(key VirtualHostKey, routes map[string]*Route) {
+		for conditions, r := range routes {
+			if table[key] == nil {
+				table[key] = map[string]RouteEntry{}
+			}
+			table[key][conditions] = RouteEntry{
+				Backends:  routeBackends(r),
+				Source:    r.Source,
+				Websocket: r.Websocket,
+				Labels:    r.Labels,
+			}
+		}
+	}
+
+	d.Visit(func(v Vertex) {
+		l, ok := v.(*Listener)
+		if !ok {
+			return
+		}
+		for _, vh := range l.VirtualHosts {
+			switch vh := vh.(type) {
+			case *VirtualHost:
+				addRoutes(VirtualHostKey{ListenerName: vh.ListenerName, Name: vh.Name}, vh.routes)
+			case *SecureVirtualHost:
+				key := VirtualHostKey{ListenerName: vh.ListenerName, Name: vh.Name}
+				addRoutes(key, vh.routes)
+				if vh.TCPProxy != nil {
+					if table[key] == nil {
+						table[key] = map[string]RouteEntry{}
+					}
+					table[key][tcpProxyRoute] = RouteEntry{
+						Backends: clusterBackends(vh.TCPProxy.Clusters),
+						Source:   vh.TCPProxy.Source,
+					}
+				}
+			}
+		}
+	})
+
+	return table
+}
+
+// routeBackends returns the sorted backends of r.
+func routeBackends(r *Route) []string {
+	if r.DirectResponse != nil {
+		return []string{fmt.Sprintf("direct response %d", r.DirectResponse.StatusCode)}
+	}
+	if r.Redirect != nil {
+		return []string{fmt.Sprintf("redirect %d %s%s", r.Redirect.StatusCode, r.Redirect.Hostname, r.Redirect.Path)}
+	}
+	return clusterBackends(r.Clusters)
+}
+
+// clusterBackends returns the sorted backends of clusters,
+// formatted as "namespace/name:port", with their weight if set.
+func clusterBackends(clusters []*Cluster) []string {
+	var backends []string
+	for _, c := range clusters {
+		if c.Upstream == nil {
+			continue
+		}
+		s := c.Upstream.Weighted
+		backend := fmt.Sprintf("%s/%s:%d", s.ServiceNamespace, s.ServiceName, s.ServicePort.Port)
+		if c.Weight > 0 {
+			backend += fmt.Sprintf(" weight=%d", c.Weight)
+		}
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+	return backends
+}
+
+// ChangeType is the type of an effective routing change.
+type ChangeType string
+
+const (
+	VirtualHostAdded   ChangeType = "VirtualHostAdded"
+	VirtualHostRemoved ChangeType = "VirtualHostRemoved"
+	RouteAdded         ChangeType = "RouteAdded"
+	RouteRemoved       ChangeType = "RouteRemoved"
+	BackendChanged     ChangeType = "BackendChanged"
+)
+
+// Change is an effective routing change between two RoutingTables.
+type Change struct {
+	Type        ChangeType
+	VirtualHost VirtualHostKey
+
+	// Route is the match conditions of the changed route, or
+	// "tcpproxy" for a TCP proxy. It is empty for virtual host
+	// changes.
+	Route string
+
+	// Backends are the backends of the route after the change,
+	// and PreviousBackends the backends before the change.
+	Backends         []string
+	PreviousBackends []string
+
+	// Sources are the Kubernetes objects that caused the change.
+	// For virtual host changes, these are the sources of all of
+	// its routes.
+	Sources []ObjectReference
+}
+
+// Diff returns the changes from prev to next. A virtual host that
+// is added or removed is followed by the changes to each of its
+// routes. Changes are ordered by virtual host, then route.
+func Diff(prev, next RoutingTable) []Change {
+	var changes []Change
+
+	for _, key := range virtualHostKeys(prev, next) {
+		prevRoutes, inPrev := prev[key]
+		nextRoutes, inNext := next[key]
+
+		switch {
+		case !inPrev:
+			changes = append(changes, Change{
+				Type:        VirtualHostAdded,
+				VirtualHost: key,
+				Sources:     routeSources(nextRoutes),
+			})
+		case !inNext:
+			changes = append(changes, Change{
+				Type:        VirtualHostRemoved,
+				VirtualHost: key,
+				Sources:     routeSources(prevRoutes),
+			})
+		}
+
+		for _, route := range routeKeys(prevRoutes, nextRoutes) {
+			p, inPrev := prevRoutes[route]
+			n, inNext := nextRoutes[route]
+
+			switch {
+			case !inPrev:
+				changes = append(changes, Change{
+					Type:        RouteAdded,
+					VirtualHost: key,
+					Route:       route,
+					Backends:    n.Backends,
+					Sources:     []ObjectReference{n.Source},
+				})
+			case !inNext:
+				changes = append(changes, Change{
+					Type:             RouteRemoved,
+					VirtualHost:      key,
+					Route:            route,
+					PreviousBackends: p.Backends,
+					Sources:          []ObjectReference{p.Source},
+				})
+			case !stringsEqual(p.Backends, n.Backends):
+				changes = append(changes, Change{
+					Type:             BackendChanged,
+					VirtualHost:      key,
+					Route:            route,
+					Backends:         n.Backends,
+					PreviousBackends: p.Backends,
+					Sources:          []ObjectReference{n.Source},
+				})
+			}
+		}
+	}
+
+	return changes
+}
+
+// virtualHostKeys returns the sorted union of the keys of a and b.
+func virtualHostKeys(a, b RoutingTable) []VirtualHostKey {
+	seen := map[VirtualHostKey]bool{}
+	var keys []VirtualHostKey
+	for _, t := range []RoutingTable{a, b} {
+		for k := range t {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Name != keys[j].Name {
+			return keys[i].Name < keys[j].Name
+		}
+		return keys[i].ListenerName < keys[j].ListenerName
+	})
+	return keys
+}
+
+// routeKeys returns the sorted union of the keys of a and b.
+func routeKeys(a, b map[string]RouteEntry) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]RouteEntry{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// routeSources returns the unique sources of routes, sorted.
+func routeSources(routes map[string]RouteEntry) []ObjectReference {
+	seen := map[ObjectReference]bool{}
+	var sources []ObjectReference
+	for _, r := range routes {
+		if !seen[r.Source] {
+			seen[r.Source] = true
+			sources = append(sources, r.Source)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		a, b := sources[i], sources[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return sources
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
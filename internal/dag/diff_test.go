@@ -0,0 +1,182 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func buildRoutingTable(t *testing.T, objs ...interface{}) RoutingTable {
+	t.Helper()
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&IngressProcessor{FieldLogger: fixture.NewTestLogger(t)},
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+	for _, o := range objs {
+		builder.Source.Insert(o)
+	}
+	return NewRoutingTable(builder.Build())
+}
+
+func TestRoutingTableSources(t *testing.T) {
+	kuard := fixture.NewService("default/kuard").WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)})
+
+	proxy := fixture.NewProxy("default/kuard").
+		WithFQDN("kuard.example.com").
+		Annotate("kubernetes.io/change-cause", "kubectl apply --filename=kuard.yaml").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "kuard", Port: 8080}},
+			}},
+		})
+
+	ingress := &networking_v1.Ingress{
+		ObjectMeta: fixture.ObjectMeta("default/kuard-ingress"),
+		Spec: networking_v1.IngressSpec{
+			Rules: []networking_v1.IngressRule{{
+				Host: "ingress.example.com",
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{
+						Paths: []networking_v1.HTTPIngressPath{{
+							Backend: *backendv1("kuard", intstr.FromInt(8080)),
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	want := RoutingTable{
+		{ListenerName: "ingress_http", Name: "kuard.example.com"}: {
+			"prefix: / type: string": {
+				Backends: []string{"default/kuard:8080"},
+				Source: ObjectReference{
+					Kind:        "HTTPProxy",
+					Namespace:   "default",
+					Name:        "kuard",
+					ChangeCause: "kubectl apply --filename=kuard.yaml",
+				},
+			},
+		},
+		{ListenerName: "ingress_http", Name: "ingress.example.com"}: {
+			"prefix: / type: string": {
+				Backends: []string{"default/kuard:8080"},
+				Source:   ObjectReference{Kind: "Ingress", Namespace: "default", Name: "kuard-ingress"},
+			},
+		},
+	}
+
+	assert.Equal(t, want, buildRoutingTable(t, kuard, proxy, ingress))
+}
+
+func TestDiff(t *testing.T) {
+	host := VirtualHostKey{ListenerName: "ingress_http", Name: "kuard.example.com"}
+	kuard := ObjectReference{Kind: "HTTPProxy", Namespace: "default", Name: "kuard"}
+	api := ObjectReference{Kind: "HTTPProxy", Namespace: "default", Name: "api", ChangeCause: "shift traffic to v2"}
+
+	v1Table := RoutingTable{
+		host: {
+			"prefix: /":    {Backends: []string{"default/kuard:80"}, Source: kuard},
+			"prefix: /api": {Backends: []string{"default/api:80"}, Source: api},
+		},
+	}
+
+	tests := map[string]struct {
+		prev, next RoutingTable
+		want       []Change
+	}{
+		"no changes": {
+			prev: v1Table,
+			next: v1Table,
+		},
+		"virtual host added": {
+			next: v1Table,
+			want: []Change{
+				{Type: VirtualHostAdded, VirtualHost: host, Sources: []ObjectReference{api, kuard}},
+				{Type: RouteAdded, VirtualHost: host, Route: "prefix: /", Backends: []string{"default/kuard:80"}, Sources: []ObjectReference{kuard}},
+				{Type: RouteAdded, VirtualHost: host, Route: "prefix: /api", Backends: []string{"default/api:80"}, Sources: []ObjectReference{api}},
+			},
+		},
+		"virtual host removed": {
+			prev: v1Table,
+			next: RoutingTable{},
+			want: []Change{
+				{Type: VirtualHostRemoved, VirtualHost: host, Sources: []ObjectReference{api, kuard}},
+				{Type: RouteRemoved, VirtualHost: host, Route: "prefix: /", PreviousBackends: []string{"default/kuard:80"}, Sources: []ObjectReference{kuard}},
+				{Type: RouteRemoved, VirtualHost: host, Route: "prefix: /api", PreviousBackends: []string{"default/api:80"}, Sources: []ObjectReference{api}},
+			},
+		},
+		"backend changed": {
+			prev: v1Table,
+			next: RoutingTable{
+				host: {
+					"prefix: /":    {Backends: []string{"default/kuard:80"}, Source: kuard},
+					"prefix: /api": {Backends: []string{"default/api-v1:80 weight=10", "default/api-v2:80 weight=90"}, Source: api},
+				},
+			},
+			want: []Change{{
+				Type:             BackendChanged,
+				VirtualHost:      host,
+				Route:            "prefix: /api",
+				Backends:         []string{"default/api-v1:80 weight=10", "default/api-v2:80 weight=90"},
+				PreviousBackends: []string{"default/api:80"},
+				Sources:          []ObjectReference{api},
+			}},
+		},
+		"route removed": {
+			prev: v1Table,
+			next: RoutingTable{
+				host: {
+					"prefix: /": {Backends: []string{"default/kuard:80"}, Source: kuard},
+				},
+			},
+			want: []Change{{
+				Type:             RouteRemoved,
+				VirtualHost:      host,
+				Route:            "prefix: /api",
+				PreviousBackends: []string{"default/api:80"},
+				Sources:          []ObjectReference{api},
+			}},
+		},
+		"only the source changed": {
+			prev: v1Table,
+			next: RoutingTable{
+				host: {
+					"prefix: /":    {Backends: []string{"default/kuard:80"}, Source: kuard},
+					"prefix: /api": {Backends: []string{"default/api:80"}, Source: kuard},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Diff(tc.prev, tc.next))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"strings"
+
+	"github.com/projectcontour/contour/internal/annotation"
+	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ExposedServiceProcessor adds an insecure virtual host to the
+// DAG for each Service annotated with projectcontour.io/expose:
+// "true", so that it can be reached without an Ingress or
+// HTTPProxy. The virtual host is <service>.<namespace>.<suffix>
+// and routes all requests to the first TCP port of the Service.
+//
+// It must run after the processors of the other route sources,
+// since Services whose virtual host is already in the DAG are
+// not exposed.
+type ExposedServiceProcessor struct {
+	logrus.FieldLogger
+
+	// DomainSuffix is the domain under which Services are exposed.
+	DomainSuffix string
+
+	// DefaultLoadBalancerPolicy is the load balancer strategy of
+	// the clusters of exposed Services (optional).
+	DefaultLoadBalancerPolicy string
+
+	// DefaultHealthCheckPolicy is the active health check policy
+	// of the clusters of exposed Services (optional).
+	DefaultHealthCheckPolicy *HTTPHealthCheckPolicy
+
+	// DefaultLBConfig is the load balancer configuration of the
+	// clusters of exposed Services (optional).
+	DefaultLBConfig ClusterLBConfig
+
+	// DefaultMaxRequestsPerConnection is the maximum number of
+	// requests per upstream connection of the clusters of exposed
+	// Services that have no max-requests-per-connection annotation.
+	DefaultMaxRequestsPerConnection uint32
+}
+
+var _ Processor = &ExposedServiceProcessor{}
+
+// Run adds the virtual hosts of exposed Services to the DAG.
+func (p *ExposedServiceProcessor) Run(dag *DAG, cache *KubernetesCache) {
+	if p.DomainSuffix == "" {
+		return
+	}
+
+	for _, svc := range cache.services {
+		if !annotation.Exposed(svc) {
+			continue
+		}
+
+		log := p.WithField("name", svc.Name).WithField("namespace", svc.Namespace)
+
+		host := ExposedServiceHost(svc.Name, svc.Namespace, p.DomainSuffix)
+		if dag.GetVirtualHost(ListenerName{Name: host, ListenerName: "ingress_http"}) != nil ||
+			dag.GetSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"}) != nil {
+			log.WithField("fqdn", host).Warn("not exposing service, its virtual host is already in use")
+			continue
+		}
+
+		if err := cache.ingressPolicyPermits(svc.Namespace, host); err != nil {
+			log.WithError(err).WithField("fqdn", host).Error("not exposing service, it is not permitted")
+			continue
+		}
+
+		port, ok := exposedPort(svc)
+		if !ok {
+			log.Error("not exposing service, it has no TCP ports")
+			continue
+		}
+
+		s, err := dag.EnsureService(k8s.NamespacedNameOf(svc), intstr.FromInt(int(port.Port)), cache)
+		if err != nil {
+			log.WithError(err).Error("unresolved service reference")
+			continue
+		}
+
+		c := &Cluster{
+			Upstream:                 s,
+			Protocol:                 s.Protocol,
+			LoadBalancerPolicy:       p.DefaultLoadBalancerPolicy,
+			HTTPHealthCheckPolicy:    p.DefaultHealthCheckPolicy,
+			LBConfig:                 p.DefaultLBConfig,
+			MaxRequestsPerConnection: s.MaxRequestsPerConnection,
+		}
+		if c.MaxRequestsPerConnection == 0 {
+			c.MaxRequestsPerConnection = p.DefaultMaxRequestsPerConnection
+		}
+
+		vhost := dag.EnsureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_http"})
+		vhost.addRoute(&Route{
+			CreationTimestamp:  svc.CreationTimestamp.Time,
+			Source:             objectReference("Service", svc),
+			PathMatchCondition: &PrefixMatchCondition{Prefix: "/", PrefixMatchType: PrefixMatchString},
+			Clusters:           []*Cluster{c},
+		})
+	}
+}
+
+// ExposedServiceHost returns the virtual host on which the
+// Service with the given name and namespace is exposed.
+func ExposedServiceHost(name, namespace, suffix string) string {
+	return strings.Join([]string{name, namespace, suffix}, ".")
+}
+
+// exposedPort returns the first TCP port of svc.
+func exposedPort(svc *v1.Service) (v1.ServicePort, bool) {
+	for _, port := range svc.Spec.Ports {
+		if port.Protocol == "" || port.Protocol == v1.ProtocolTCP {
+			return port, true
+		}
+	}
+	return v1.ServicePort{}, false
+}
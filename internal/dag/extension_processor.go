@@ -15,7 +15,9 @@ package dag
 
 import (
 	"path"
+	"sort"
 	"strings"
+	"time"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
@@ -121,14 +123,30 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 	}
 	extension.LoadBalancerPolicy = lbPolicy
 
-	// Timeouts are specified above the cluster (e.g.
-	// in the ext_authz filter). The ext_authz filter
-	// doesn't have an idle timeout (only a request
-	// timeout), so validate that it is not provided here.
-	if timeouts := ext.Spec.TimeoutPolicy; timeouts != nil && timeouts.Idle != "" {
-		validCondition.AddWarningf("SpecError", "IgnoredField",
-			"ignoring field %q; idle timeouts are not supported for ExtensionClusters",
-			".Spec.TimeoutPolicy.Idle")
+	// Response timeouts are specified above the cluster (e.g.
+	// in the ext_authz filter). The idle timeout is applied
+	// to the upstream connections of the cluster.
+
+	if cb := ext.Spec.CircuitBreakerPolicy; cb != nil {
+		extension.MaxConnections = cb.MaxConnections
+		extension.MaxPendingRequests = cb.MaxPendingRequests
+		extension.MaxRequests = cb.MaxRequests
+		extension.MaxRetries = cb.MaxRetries
+	}
+
+	if hc := ext.Spec.HealthCheckPolicy; hc != nil {
+		if hc.IntervalSeconds < 0 || hc.TimeoutSeconds < 0 {
+			validCondition.AddErrorf(contour_api_v1.ConditionTypeSpecError, "HealthCheckPolicyNotValid",
+				"spec.healthCheckPolicy intervalSeconds and timeoutSeconds must not be negative")
+		} else {
+			extension.GRPCHealthCheckPolicy = &GRPCHealthCheckPolicy{
+				ServiceName:        hc.ServiceName,
+				Interval:           time.Duration(hc.IntervalSeconds) * time.Second,
+				Timeout:            time.Duration(hc.TimeoutSeconds) * time.Second,
+				UnhealthyThreshold: hc.UnhealthyThresholdCount,
+				HealthyThreshold:   hc.HealthyThresholdCount,
+			}
+		}
 	}
 
 	// API server validation ensures that the protocol is "h2" or "h2c".
@@ -184,7 +202,36 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 		}
 
 		extension.Upstream.AddWeightedService(target.Weight, svcName, port)
+		extension.Upstream.Services[len(extension.Upstream.Services)-1].Priority = target.Priority
 	}
 
+	compactPriorities(extension.Upstream.Services)
+
 	return &extension
 }
+
+// compactPriorities rewrites the priorities of the given services so
+// that they start at 0 and have no gaps, since Envoy requires that
+// the priorities of a cluster load assignment are contiguous.
+func compactPriorities(services []WeightedService) {
+	var priorities []uint32
+	seen := map[uint32]bool{}
+
+	for _, s := range services {
+		if !seen[s.Priority] {
+			seen[s.Priority] = true
+			priorities = append(priorities, s.Priority)
+		}
+	}
+
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+
+	rank := make(map[uint32]uint32, len(priorities))
+	for i, p := range priorities {
+		rank[p] = uint32(i)
+	}
+
+	for i := range services {
+		services[i].Priority = rank[services[i].Priority]
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/projectcontour/contour/internal/errors"
 	"github.com/projectcontour/contour/internal/k8s"
@@ -45,6 +46,15 @@ const (
 type GatewayAPIProcessor struct {
 	logrus.FieldLogger
 
+	// AllowedProtectedHeaders lists the DefaultProtectedHeaders
+	// that RequestHeaderModifier filters are allowed to set or remove (optional).
+	AllowedProtectedHeaders []string
+
+	// DefaultMaxRequestsPerConnection is the maximum number of
+	// requests per upstream connection of Gateway API clusters whose
+	// Service has no max-requests-per-connection annotation.
+	DefaultMaxRequestsPerConnection uint32
+
 	dag    *DAG
 	source *KubernetesCache
 }
@@ -480,6 +490,17 @@ func (p *GatewayAPIProcessor) computeGateway(gateway *gatewayapi_v1alpha1.Gatewa
 	}
 }
 
+// ingressPolicyPermits returns true if the IngressPolicies of
+// namespace permit a route for host. Otherwise, it sets a condition
+// on the route and returns false.
+func (p *GatewayAPIProcessor) ingressPolicyPermits(routeAccessor *status.RouteConditionsUpdate, namespace, host string) bool {
+	if err := p.source.ingressPolicyPermits(namespace, host); err != nil {
+		routeAccessor.AddCondition(status.ConditionIngressPolicyPermitted, metav1.ConditionFalse, status.ReasonIngressPolicyNotPermitted, err.Error())
+		return false
+	}
+	return true
+}
+
 func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha1.TLSRoute, validGateway bool, listenerSecret *Secret) {
 
 	routeAccessor, commit := p.dag.StatusCache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, status.ResourceTLSRoute, route.Status.Gateways)
@@ -491,6 +512,12 @@ func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha1.TLSRout
 		return
 	}
 
+	if p.source.namespaceBlocked(route.Namespace) {
+		routeAccessor.AddCondition(gatewayapi_v1alpha1.ConditionRouteAdmitted, metav1.ConditionFalse, status.ReasonNamespaceBlocked,
+			fmt.Sprintf("Routes from namespace %q are blocked by the cluster administrator.", route.Namespace))
+		return
+	}
+
 	for _, rule := range route.Spec.Rules {
 		var hosts []string
 		var matchErrors []error
@@ -527,12 +554,25 @@ func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha1.TLSRout
 			hosts = []string{"*"}
 		}
 
+		var permitted []string
+		for _, host := range hosts {
+			if p.ingressPolicyPermits(routeAccessor, route.Namespace, host) {
+				permitted = append(permitted, host)
+			}
+		}
+		if len(permitted) == 0 {
+			continue
+		}
+		hosts = permitted
+
 		if len(rule.ForwardTo) == 0 {
 			routeAccessor.AddCondition(status.ConditionResolvedRefs, metav1.ConditionFalse, status.ReasonDegraded, "At least one Spec.Rules.ForwardTo must be specified.")
 			continue
 		}
 
-		var proxy TCPProxy
+		proxy := TCPProxy{
+			Source: objectReference("TLSRoute", route),
+		}
 		for _, forward := range rule.ForwardTo {
 
 			service, err := p.validateForwardTo(forward.ServiceName, forward.Port, route.Namespace)
@@ -542,8 +582,9 @@ func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha1.TLSRout
 			}
 
 			proxy.Clusters = append(proxy.Clusters, &Cluster{
-				Upstream: service,
-				SNI:      service.ExternalName,
+				Upstream:                 service,
+				SNI:                      service.ExternalName,
+				MaxRequestsPerConnection: p.maxRequestsPerConnection(service),
 			})
 		}
 
@@ -584,11 +625,23 @@ func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha1.HTTPRo
 		return
 	}
 
+	if p.source.namespaceBlocked(route.Namespace) {
+		routeAccessor.AddCondition(gatewayapi_v1alpha1.ConditionRouteAdmitted, metav1.ConditionFalse, status.ReasonNamespaceBlocked,
+			fmt.Sprintf("Routes from namespace %q are blocked by the cluster administrator.", route.Namespace))
+		return
+	}
+
 	hosts, errs := p.computeHosts(route.Spec.Hostnames, listenerHostname)
 	for _, err := range errs {
 		routeAccessor.AddCondition(status.ConditionResolvedRefs, metav1.ConditionFalse, status.ReasonDegraded, err.Error())
 	}
 
+	for host := range hosts {
+		if !p.ingressPolicyPermits(routeAccessor, route.Namespace, host) {
+			delete(hosts, host)
+		}
+	}
+
 	// Check if all the hostnames are invalid.
 	if len(hosts) == 0 {
 		routeAccessor.AddCondition(gatewayapi_v1alpha1.ConditionRouteAdmitted, metav1.ConditionFalse, status.ReasonErrorsExist, "Errors found, check other Conditions for details.")
@@ -638,7 +691,7 @@ func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha1.HTTPRo
 				switch filter.Type {
 				case gatewayapi_v1alpha1.HTTPRouteFilterRequestHeaderModifier:
 					var err error
-					headerPolicy, err = headersPolicyGatewayAPI(filter.RequestHeaderModifier)
+					headerPolicy, err = headersPolicyGatewayAPI(filter.RequestHeaderModifier, p.AllowedProtectedHeaders)
 					if err != nil {
 						routeAccessor.AddCondition(status.ConditionResolvedRefs, metav1.ConditionFalse, status.ReasonDegraded, fmt.Sprintf("%s on request headers", err))
 					}
@@ -667,7 +720,7 @@ func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha1.HTTPRo
 			switch filter.Type {
 			case gatewayapi_v1alpha1.HTTPRouteFilterRequestHeaderModifier:
 				var err error
-				headerPolicy, err = headersPolicyGatewayAPI(filter.RequestHeaderModifier)
+				headerPolicy, err = headersPolicyGatewayAPI(filter.RequestHeaderModifier, p.AllowedProtectedHeaders)
 				if err != nil {
 					routeAccessor.AddCondition(status.ConditionResolvedRefs, metav1.ConditionFalse, status.ReasonDegraded, fmt.Sprintf("%s on request headers", err))
 				}
@@ -676,7 +729,7 @@ func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha1.HTTPRo
 			}
 		}
 
-		routes := p.routes(matchconditions, headerPolicy, clusters)
+		routes := p.routes(matchconditions, headerPolicy, clusters, route.CreationTimestamp.Time, objectReference("HTTPRoute", route))
 		for host := range hosts {
 			for _, route := range routes {
 				// If there aren't any valid services, or the total weight of all of
@@ -800,14 +853,17 @@ func headerMatchCondition(mc *matchConditions, match *gatewayapi_v1alpha1.HTTPHe
 	return nil
 }
 
-// routes builds a []*dag.Route for the supplied set of matchConditions, headerPolicy and clusters.
-func (p *GatewayAPIProcessor) routes(matchConditions []*matchConditions, headerPolicy *HeadersPolicy, clusters []*Cluster) []*Route {
+// routes builds a []*dag.Route for the supplied set of matchConditions, headerPolicy and clusters,
+// built from the source object.
+func (p *GatewayAPIProcessor) routes(matchConditions []*matchConditions, headerPolicy *HeadersPolicy, clusters []*Cluster, created time.Time, source ObjectReference) []*Route {
 	var routes []*Route
 
 	for _, mc := range matchConditions {
 		for _, pathMatch := range mc.pathMatchConditions {
 			r := &Route{
-				Clusters: clusters,
+				Clusters:          clusters,
+				CreationTimestamp: created,
+				Source:            source,
 			}
 			r.PathMatchCondition = pathMatch
 			r.HeaderMatchConditions = mc.headerMatchCondition
@@ -822,11 +878,22 @@ func (p *GatewayAPIProcessor) routes(matchConditions []*matchConditions, headerP
 // cluster builds a *dag.Cluster for the supplied set of headerPolicy and service.
 func (p *GatewayAPIProcessor) cluster(headerPolicy *HeadersPolicy, service *Service, weight uint32) *Cluster {
 	return &Cluster{
-		Upstream:             service,
-		Weight:               weight,
-		Protocol:             service.Protocol,
-		RequestHeadersPolicy: headerPolicy,
+		Upstream:                 service,
+		Weight:                   weight,
+		Protocol:                 service.Protocol,
+		RequestHeadersPolicy:     headerPolicy,
+		MaxRequestsPerConnection: p.maxRequestsPerConnection(service),
+	}
+}
+
+// maxRequestsPerConnection returns the maximum number of requests
+// per upstream connection of service, falling back to the global
+// default when its annotation is not set.
+func (p *GatewayAPIProcessor) maxRequestsPerConnection(service *Service) uint32 {
+	if service.MaxRequestsPerConnection > 0 {
+		return service.MaxRequestsPerConnection
 	}
+	return p.DefaultMaxRequestsPerConnection
 }
 
 func pathMatchTypePtr(pmt gatewayapi_v1alpha1.PathMatchType) *gatewayapi_v1alpha1.PathMatchType {
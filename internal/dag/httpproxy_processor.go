@@ -14,10 +14,16 @@
 package dag
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
@@ -27,8 +33,11 @@ import (
 	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/projectcontour/contour/pkg/config"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // defaultExtensionRef populates the unset fields in ref with default values.
@@ -76,6 +85,81 @@ type HTTPProxyProcessor struct {
 
 	// Response headers that will be set on all routes (optional).
 	ResponseHeadersPolicy *HeadersPolicy
+
+	// AllowedProtectedHeaders lists the DefaultProtectedHeaders
+	// that request header policies are allowed to set or remove (optional).
+	AllowedProtectedHeaders []string
+
+	// DefaultLoadBalancerPolicy is the load balancer strategy of
+	// routes and TCPProxies that do not set one (optional).
+	DefaultLoadBalancerPolicy string
+
+	// DefaultHealthCheckPolicy is the active health check policy of
+	// routes and TCPProxies that do not set one (optional). TCPProxies
+	// only use its interval, timeout and thresholds.
+	DefaultHealthCheckPolicy *HTTPHealthCheckPolicy
+
+	// DefaultLBConfig is the load balancer configuration of the
+	// clusters of services that do not override it.
+	DefaultLBConfig ClusterLBConfig
+
+	// DefaultRetryPolicy is the retry policy of routes
+	// that do not set one (optional).
+	DefaultRetryPolicy *RetryPolicy
+
+	// DefaultMaxRequestsPerConnection is the maximum number of
+	// requests per upstream connection of the clusters of services
+	// that neither set one nor have a max-requests-per-connection
+	// annotation. 0 means there is no limit.
+	DefaultMaxRequestsPerConnection uint32
+
+	// MetadataLabels lists the keys of the HTTPProxy labels
+	// that are copied into the metadata of their routes (optional).
+	MetadataLabels []string
+
+	// TapMaxDuration enables the projectcontour.io/tap-until
+	// annotation of root HTTPProxies, and limits how far ahead
+	// of now it may be set. If zero, taps are disabled.
+	TapMaxDuration time.Duration
+
+	// TapNamespaces restricts taps to the root HTTPProxies in
+	// the listed namespaces (optional).
+	TapNamespaces []string
+
+	// MaxIncludeDepth limits the length of the chains of includes
+	// from each root HTTPProxy. If zero, the depth is not limited.
+	MaxIncludeDepth int
+
+	// MaxIncludes limits the number of includes followed from each
+	// root HTTPProxy. If zero, the number is not limited.
+	MaxIncludes int
+
+	// Clock is used to evaluate the active windows of routes
+	// and the expiry of taps. If not set, the real clock is used.
+	Clock clock.Clock
+
+	// delegations are the subdomain delegations of the
+	// root HTTPProxies, computed at the start of each run.
+	delegations []subdomainDelegation
+
+	// includes counts the includes followed from the
+	// root HTTPProxy whose routes are being computed.
+	includes int
+}
+
+// now returns the current time according to p.Clock.
+func (p *HTTPProxyProcessor) now() time.Time {
+	if p.Clock == nil {
+		return time.Now()
+	}
+	return p.Clock.Now()
+}
+
+// subdomainDelegation records that HTTPProxies in namespace may
+// define virtual hosts within the subdomains of domain.
+type subdomainDelegation struct {
+	domain    string
+	namespace string
 }
 
 // Run translates HTTPProxies into DAG objects and
@@ -84,12 +168,14 @@ func (p *HTTPProxyProcessor) Run(dag *DAG, source *KubernetesCache) {
 	p.dag = dag
 	p.source = source
 	p.orphaned = make(map[types.NamespacedName]bool, len(p.orphaned))
+	p.delegations = p.subdomainDelegations()
 
 	// reset the processor when we're done
 	defer func() {
 		p.dag = nil
 		p.source = nil
 		p.orphaned = nil
+		p.delegations = nil
 	}()
 
 	for _, proxy := range p.validHTTPProxies() {
@@ -109,6 +195,8 @@ func (p *HTTPProxyProcessor) Run(dag *DAG, source *KubernetesCache) {
 }
 
 func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
+	defer p.source.observeObject("HTTPProxy", time.Now())
+
 	pa, commit := p.dag.StatusCache.ProxyAccessor(proxy)
 	validCond := pa.ConditionFor(status.ValidCondition)
 
@@ -130,7 +218,9 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 
 	// Ensure root httpproxy lives in allowed namespace.
 	// This check must be after we can determine the vhost in order to be able to calculate metrics correctly.
-	if !p.rootAllowed(proxy.Namespace) {
+	// A root HTTPProxy outside of the root namespaces is allowed
+	// if its fqdn lies within a subdomain delegated to its namespace.
+	if !p.rootAllowed(proxy.Namespace) && !p.subdomainDelegated(host, proxy.Namespace) {
 		validCond.AddError(contour_api_v1.ConditionTypeRootNamespaceError, "RootProxyNotAllowedInNamespace",
 			"root HTTPProxy cannot be defined in this namespace")
 		return
@@ -142,6 +232,30 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 		return
 	}
 
+	if err := p.source.ingressPolicyPermits(proxy.Namespace, host); err != nil {
+		validCond.AddErrorf(contour_api_v1.ConditionTypeIngressPolicyError, "IngressPolicyNotPermitted",
+			"root HTTPProxy is not permitted: %s", err)
+		return
+	}
+
+	for _, d := range proxy.Spec.VirtualHost.SubdomainDelegations {
+		if !p.rootAllowed(proxy.Namespace) {
+			validCond.AddError(contour_api_v1.ConditionTypeVirtualHostError, "SubdomainDelegationNotAllowed",
+				"Spec.VirtualHost.SubdomainDelegations: only HTTPProxies in root namespaces may delegate subdomains")
+			return
+		}
+		if _, err := delegatedDomain(host, d.Domain); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "SubdomainDelegationNotValid",
+				"Spec.VirtualHost.SubdomainDelegations: %s", err)
+			return
+		}
+	}
+
+	if proxy.Spec.VirtualHost.ForwardProxy != nil {
+		p.computeForwardProxy(proxy, validCond)
+		return
+	}
+
 	if len(proxy.Spec.Routes) == 0 && len(proxy.Spec.Includes) == 0 && proxy.Spec.TCPProxy == nil {
 		validCond.AddError(contour_api_v1.ConditionTypeSpecError, "NothingDefined",
 			"HTTPProxy.Spec must have at least one Route, Include, or a TCPProxy")
@@ -172,6 +286,12 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 			return
 		}
 
+		if tls.Passthrough && len(tls.HTTPVersions) > 0 {
+			validCond.AddError(contour_api_v1.ConditionTypeTLSError, "TLSIncompatibleFeatures",
+				"Spec.VirtualHost.TLS passthrough cannot be combined with tls.httpVersions")
+			return
+		}
+
 		tlsEnabled = true
 
 		// Attach secrets to TLS enabled vhosts.
@@ -190,11 +310,20 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 				return
 			}
 
+			if reason, ok := p.source.secretQuarantineReason(secretName); ok {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeTLSError, "SecretQuarantined",
+					"Spec.VirtualHost.TLS Secret %q %s", tls.SecretName, reason)
+			}
+
 			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
 			svhost.Secret = sec
 			// default to a minimum TLS version of 1.2 if it's not specified
 			svhost.MinTLSVersion = annotation.MinTLSVersion(tls.MinimumProtocolVersion, "1.2")
 
+			for _, v := range tls.HTTPVersions {
+				svhost.HTTPVersions = append(svhost.HTTPVersions, config.HTTPVersionType(v))
+			}
+
 			// Check if FallbackCertificate && ClientValidation are both enabled in the same vhost
 			if tls.EnableFallbackCertificate && tls.ClientValidation != nil {
 				validCond.AddError(contour_api_v1.ConditionTypeTLSError, "TLSIncompatibleFeatures",
@@ -213,6 +342,14 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 				return
 			}
 
+			// Fallback certificates and OIDC login are incompatible for
+			// the same reason as fallback certificates and authorization.
+			if tls.EnableFallbackCertificate && proxy.Spec.VirtualHost.OIDCConfigured() {
+				validCond.AddError(contour_api_v1.ConditionTypeTLSError, "TLSIncompatibleFeatures",
+					"Spec.Virtualhost.TLS fallback & OIDC login are incompatible")
+				return
+			}
+
 			// If FallbackCertificate is enabled, but no cert passed, set error
 			if tls.EnableFallbackCertificate {
 				if p.FallbackCertificate == nil {
@@ -242,6 +379,20 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 				dv := &PeerValidationContext{
 					SkipClientCertValidation: tls.ClientValidation.SkipClientCertValidation,
 				}
+				if len(tls.ClientValidation.AllowedSubjectAltNames) > 0 {
+					if tls.ClientValidation.SkipClientCertValidation {
+						validCond.AddErrorf(contour_api_v1.ConditionTypeTLSError, "ClientValidationInvalid",
+							"Spec.VirtualHost.TLS client validation is invalid: allowedSubjectAltNames cannot be specified with skipClientCertValidation")
+						return
+					}
+					sans, err := toSubjectAltNameMatches(tls.ClientValidation.AllowedSubjectAltNames)
+					if err != nil {
+						validCond.AddErrorf(contour_api_v1.ConditionTypeTLSError, "ClientValidationInvalid",
+							"Spec.VirtualHost.TLS client validation is invalid: %s", err)
+						return
+					}
+					dv.SubjectAltNames = sans
+				}
 				if tls.ClientValidation.CACertificate != "" {
 					secretName := k8s.NamespacedNameFrom(tls.ClientValidation.CACertificate, k8s.DefaultNamespace(proxy.Namespace))
 					cacert, err := p.source.LookupSecret(secretName, validCA)
@@ -298,6 +449,139 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 					svhost.AuthorizationResponseTimeout = timeout
 				}
 			}
+
+			if proxy.Spec.VirtualHost.OIDCConfigured() {
+				oidc, err := p.computeOIDCPolicy(proxy.Spec.VirtualHost.OIDCPolicy, proxy.Namespace)
+				if err != nil {
+					validCond.AddErrorf(contour_api_v1.ConditionTypeAuthError, "OIDCPolicyNotValid",
+						"Spec.VirtualHost.OIDCPolicy is invalid: %s", err)
+					return
+				}
+
+				svhost.OIDCPolicy = oidc
+			}
+		}
+	}
+
+	var maxConnectionDuration timeout.Setting
+	if proxy.Spec.VirtualHost.MaxConnectionDuration != "" {
+		var err error
+		maxConnectionDuration, err = timeout.Parse(proxy.Spec.VirtualHost.MaxConnectionDuration)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "MaxConnectionDurationInvalid",
+				"Spec.VirtualHost.MaxConnectionDuration is invalid: %s", err)
+			return
+		}
+
+		if tlsEnabled {
+			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+			svhost.MaxConnectionDuration = maxConnectionDuration
+		} else {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				"ignoring field %q; it requires TLS to be enabled", "Spec.VirtualHost.MaxConnectionDuration")
+		}
+	}
+
+	if limits := proxy.Spec.VirtualHost.RequestHeadersLimits; limits != nil {
+		if tlsEnabled {
+			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+			svhost.MaxRequestHeadersKB = limits.MaxSizeKB
+			svhost.MaxRequestHeadersCount = limits.MaxCount
+		} else {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				"ignoring field %q; it requires TLS to be enabled", "Spec.VirtualHost.RequestHeadersLimits")
+		}
+	}
+
+	if pn := proxy.Spec.VirtualHost.PathNormalization; pn != nil {
+		if tlsEnabled {
+			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+			svhost.PathNormalization = &PathNormalization{
+				DisableNormalizePath: pn.DisableNormalizePath,
+				DisableMergeSlashes:  pn.DisableMergeSlashes,
+				EscapedSlashesAction: config.EscapedSlashesActionType(pn.EscapedSlashesAction),
+			}
+		} else {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				"ignoring field %q; it requires TLS to be enabled", "Spec.VirtualHost.PathNormalization")
+		}
+	}
+
+	if hp := proxy.Spec.VirtualHost.HTTP10Policy; hp != nil {
+		if hp.Disabled && hp.DefaultHost {
+			validCond.AddError(contour_api_v1.ConditionTypeVirtualHostError, "HTTP10PolicyNotValid",
+				"Spec.VirtualHost.HTTP10Policy: defaultHost cannot be specified when disabled is true")
+			return
+		}
+
+		if tlsEnabled {
+			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+			svhost.HTTP10Policy = &HTTP10Policy{
+				Disabled: hp.Disabled,
+			}
+			if hp.DefaultHost {
+				svhost.HTTP10Policy.DefaultHost = host
+			}
+		} else {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				"ignoring field %q; it requires TLS to be enabled", "Spec.VirtualHost.HTTP10Policy")
+		}
+	}
+
+	if cp := proxy.Spec.VirtualHost.ConcurrencyPolicy; cp != nil {
+		policy, err := concurrencyPolicy(cp)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "ConcurrencyPolicyNotValid",
+				"Spec.VirtualHost.ConcurrencyPolicy is invalid: %s", err)
+			return
+		}
+
+		if tlsEnabled {
+			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+			svhost.ConcurrencyPolicy = policy
+		} else {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				"ignoring field %q; it requires TLS to be enabled, as admission control can't be set per route or per insecure virtual host", "Spec.VirtualHost.ConcurrencyPolicy")
+		}
+	}
+
+	if alh := proxy.Spec.VirtualHost.AccessLogHeaders; alh != nil {
+		headers := config.AccessLogHeaders{
+			Request:  alh.Request,
+			Response: alh.Response,
+		}
+		if err := headers.Validate(); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "AccessLogHeadersNotValid",
+				"Spec.VirtualHost.AccessLogHeaders is invalid: %s", err)
+			return
+		}
+
+		if tlsEnabled {
+			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+			svhost.AccessLogHeaders = &headers
+		} else {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				"ignoring field %q; it requires TLS to be enabled", "Spec.VirtualHost.AccessLogHeaders")
+		}
+	}
+
+	if sh := proxy.Spec.VirtualHost.ServerHeader; sh != nil {
+		serverHeader := config.ServerHeaderParameters{
+			Name:           sh.Name,
+			Transformation: config.ServerHeaderTransformationType(sh.Transformation),
+		}
+		if err := serverHeader.Validate(); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "ServerHeaderNotValid",
+				"Spec.VirtualHost.ServerHeader is invalid: %s", err)
+			return
+		}
+
+		if tlsEnabled {
+			svhost := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+			svhost.ServerHeader = &serverHeader
+		} else {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				"ignoring field %q; it requires TLS to be enabled", "Spec.VirtualHost.ServerHeader")
 		}
 	}
 
@@ -310,9 +594,21 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 		if !p.processHTTPProxyTCPProxy(validCond, proxy, nil, host) {
 			return
 		}
+
+		secure := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
+		secure.TCPProxy.MaxConnectionDuration = maxConnectionDuration
+	}
+
+	p.includes = 0
+	routes := p.computeRoutes(pa, proxy, proxy, nil, nil, tlsEnabled)
+	if proxy.Spec.VirtualHost.Maintenance {
+		if err := maintenanceRoutes(routes, proxy.Spec.VirtualHost.MaintenancePolicy); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "MaintenancePolicyNotValid",
+				"Spec.VirtualHost.MaintenancePolicy is invalid: %s", err)
+			return
+		}
 	}
 
-	routes := p.computeRoutes(validCond, proxy, proxy, nil, nil, tlsEnabled)
 	insecure := p.dag.EnsureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_http"})
 	cp, err := toCORSPolicy(proxy.Spec.VirtualHost.CORSPolicy)
 	if err != nil {
@@ -330,6 +626,9 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 	}
 	insecure.RateLimitPolicy = rlp
 
+	tap := p.tapPolicy(proxy, validCond)
+	insecure.TapPolicy = tap
+
 	addRoutes(insecure, routes)
 
 	// if TLS is enabled for this virtual host and there is no tcp proxy defined,
@@ -345,11 +644,297 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *contour_api_v1.HTTPProxy) {
 			return
 		}
 		secure.RateLimitPolicy = rlp
+		secure.TapPolicy = tap
 
 		addRoutes(secure, routes)
 	}
 }
 
+// computeForwardProxy adds the virtual host of a forward proxy,
+// whose single route forwards all requests for its allowed domains
+// to the host named by their Host header.
+func (p *HTTPProxyProcessor) computeForwardProxy(proxy *contour_api_v1.HTTPProxy, validCond *contour_api_v1.DetailedCondition) {
+	vhost := proxy.Spec.VirtualHost
+	if vhost.TLS != nil || len(proxy.Spec.Routes) > 0 || len(proxy.Spec.Includes) > 0 || proxy.Spec.TCPProxy != nil {
+		validCond.AddError(contour_api_v1.ConditionTypeVirtualHostError, "ForwardProxyNotValid",
+			"Spec.VirtualHost.ForwardProxy cannot be combined with tls, routes, includes or a tcpproxy")
+		return
+	}
+
+	domains, err := forwardProxyDomains(vhost.ForwardProxy.AllowedDomains)
+	if err != nil {
+		validCond.AddErrorf(contour_api_v1.ConditionTypeVirtualHostError, "ForwardProxyNotValid",
+			"Spec.VirtualHost.ForwardProxy is invalid: %s", err)
+		return
+	}
+
+	insecure := p.dag.EnsureVirtualHost(ListenerName{Name: vhost.Fqdn, ListenerName: "ingress_http"})
+	insecure.ForwardProxy = &ForwardProxy{AllowedDomains: domains}
+	insecure.addRoute(&Route{
+		PathMatchCondition:  &PrefixMatchCondition{Prefix: "/"},
+		DynamicForwardProxy: true,
+	})
+}
+
+// forwardProxyDomains validates the allowed domains of a forward
+// proxy, and returns them lower cased, sorted and deduplicated.
+func forwardProxyDomains(allowed []string) ([]string, error) {
+	if len(allowed) == 0 {
+		return nil, errors.New("allowedDomains must have at least one entry")
+	}
+
+	seen := map[string]bool{}
+	var domains []string
+	for _, d := range allowed {
+		d = strings.ToLower(d)
+		if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(d, "*.")); len(errs) > 0 {
+			return nil, fmt.Errorf("allowed domain %q must be a hostname or a wildcard domain such as \"*.example.com\"", d)
+		}
+		if !seen[d] {
+			seen[d] = true
+			domains = append(domains, d)
+		}
+	}
+
+	sort.Strings(domains)
+	return domains, nil
+}
+
+// tapPolicy returns the tap policy set by the tap-until and
+// tap-path-prefix annotations of the root proxy. It returns nil,
+// with a warning if the annotations can't be honored, if taps are
+// not permitted, the tap-until time is not valid or it has passed.
+// The DAG is rebuilt when an active tap expires.
+func (p *HTTPProxyProcessor) tapPolicy(proxy *contour_api_v1.HTTPProxy, validCond *contour_api_v1.DetailedCondition) *TapPolicy {
+	value := annotation.ContourAnnotation(proxy, "tap-until")
+	if value == "" {
+		return nil
+	}
+
+	if p.TapMaxDuration == 0 {
+		validCond.AddWarning(contour_api_v1.ConditionTypeVirtualHostError, "TapNotPermitted",
+			"taps are not enabled in the Contour configuration")
+		return nil
+	}
+	if !p.tapAllowed(proxy.Namespace) {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "TapNotPermitted",
+			"taps are not enabled for namespace %q", proxy.Namespace)
+		return nil
+	}
+
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "TapNotValid",
+			"projectcontour.io/tap-until %q is not an RFC 3339 time", value)
+		return nil
+	}
+
+	now := p.now()
+	if until.After(now.Add(p.TapMaxDuration)) {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "TapNotValid",
+			"projectcontour.io/tap-until %q is more than %s from now", value, p.TapMaxDuration)
+		return nil
+	}
+	if !until.After(now) {
+		return nil
+	}
+
+	prefix := annotation.ContourAnnotation(proxy, "tap-path-prefix")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeVirtualHostError, "TapNotValid",
+			"projectcontour.io/tap-path-prefix %q must start with \"/\"", prefix)
+		return nil
+	}
+
+	p.dag.rebuildBy(until)
+	return &TapPolicy{
+		PathPrefix: prefix,
+		Until:      until,
+	}
+}
+
+// concurrencyPolicy returns the admission control policy
+// configured by cp, with defaults for the unset fields.
+func concurrencyPolicy(cp *contour_api_v1.ConcurrencyPolicy) (*ConcurrencyPolicy, error) {
+	policy := &ConcurrencyPolicy{
+		SuccessRateThreshold: 95,
+		SamplingWindow:       30 * time.Second,
+	}
+
+	if cp.SuccessRateThreshold > 100 {
+		return nil, fmt.Errorf("successRateThreshold %d must be in the range 1-100", cp.SuccessRateThreshold)
+	}
+	if cp.SuccessRateThreshold > 0 {
+		policy.SuccessRateThreshold = cp.SuccessRateThreshold
+	}
+
+	if cp.SamplingWindow != "" {
+		d, err := time.ParseDuration(cp.SamplingWindow)
+		if err != nil {
+			return nil, fmt.Errorf("samplingWindow: %w", err)
+		}
+		if d < time.Second {
+			return nil, errors.New("samplingWindow must be at least 1s")
+		}
+		policy.SamplingWindow = d
+	}
+
+	return policy, nil
+}
+
+// defaultMaintenanceRetryAfter is the Retry-After header sent
+// for virtual hosts in maintenance, if not otherwise specified.
+const defaultMaintenanceRetryAfter = 5 * time.Minute
+
+// maintenanceRoutes changes routes to respond with the maintenance
+// response of policy, instead of routing to their clusters. The
+// clusters are left in place, so that they are warm when maintenance
+// is turned off.
+func maintenanceRoutes(routes []*Route, policy *contour_api_v1.MaintenancePolicy) error {
+	if policy == nil {
+		policy = &contour_api_v1.MaintenancePolicy{}
+	}
+
+	retryAfter := defaultMaintenanceRetryAfter
+	if policy.RetryAfter != "" {
+		d, err := time.ParseDuration(policy.RetryAfter)
+		if err != nil {
+			return fmt.Errorf("retryAfter: %w", err)
+		}
+		if d <= 0 {
+			return errors.New("retryAfter must be positive")
+		}
+		retryAfter = d
+	}
+
+	if policy.Redirect != nil && policy.Body != "" {
+		return errors.New("body cannot be specified with redirect")
+	}
+
+	headers := &HeadersPolicy{
+		Set: map[string]string{
+			"Retry-After": strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10),
+		},
+	}
+
+	for _, r := range routes {
+		r.ResponseHeadersPolicy = headers
+		if rd := policy.Redirect; rd != nil {
+			r.Redirect = &Redirect{
+				Hostname:   rd.Hostname,
+				Path:       rd.Path,
+				StatusCode: rd.StatusCode,
+			}
+			if r.Redirect.StatusCode == 0 {
+				r.Redirect.StatusCode = http.StatusFound
+			}
+			continue
+		}
+		r.DirectResponse = &DirectResponse{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       policy.Body,
+		}
+	}
+	return nil
+}
+
+// blockRoute changes r to respond with a 503 status, because the
+// routes of its namespace are blocked. Unlike maintenance, its
+// clusters are removed, so that no traffic reaches them.
+func blockRoute(r *Route) {
+	r.Clusters = nil
+	r.MirrorPolicy = nil
+	r.Redirect = nil
+	r.DirectResponse = &DirectResponse{
+		StatusCode: http.StatusServiceUnavailable,
+	}
+}
+
+// computeOIDCPolicy validates the OIDCPolicy and resolves the
+// client credentials Secret it references.
+func (p *HTTPProxyProcessor) computeOIDCPolicy(policy *contour_api_v1.OIDCPolicy, namespace string) (*OIDCPolicy, error) {
+	authorizationEndpoint := policy.AuthorizationEndpoint
+	if _, err := parseHTTPSURL(authorizationEndpoint); err != nil {
+		return nil, fmt.Errorf("authorization endpoint %q: %w", authorizationEndpoint, err)
+	}
+
+	tokenEndpoint := policy.TokenEndpoint
+	tokenURL, err := parseHTTPSURL(tokenEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("token endpoint %q: %w", tokenEndpoint, err)
+	}
+
+	scopes := []string{"openid"}
+	seen := map[string]bool{"openid": true}
+	for _, scope := range policy.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t") {
+			return nil, fmt.Errorf("invalid scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	port := 443
+	if tokenURL.Port() != "" {
+		port, err = strconv.Atoi(tokenURL.Port())
+		if err != nil {
+			return nil, fmt.Errorf("token endpoint %q: invalid port: %w", tokenEndpoint, err)
+		}
+	}
+
+	uv, err := p.source.LookupUpstreamValidation(policy.UpstreamValidation, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	redirectPath := stringOrDefault(policy.RedirectPath, "/oauth2/callback")
+	signoutPath := stringOrDefault(policy.SignoutPath, "/oauth2/signout")
+	if redirectPath == signoutPath {
+		return nil, fmt.Errorf("redirect path and signout path must be different, both are %q", redirectPath)
+	}
+
+	secretName := types.NamespacedName{Name: policy.ClientCredentialsSecret, Namespace: namespace}
+	sec, err := p.source.LookupSecret(secretName, validOIDCCredentials)
+	if err != nil {
+		return nil, fmt.Errorf("client credentials Secret %q is invalid: %s", secretName, err)
+	}
+
+	return &OIDCPolicy{
+		AuthorizationEndpoint: authorizationEndpoint,
+		TokenEndpoint:         tokenEndpoint,
+		TokenCluster: &DNSNameCluster{
+			Address:            tokenURL.Hostname(),
+			Scheme:             tokenURL.Scheme,
+			Port:               port,
+			DNSLookupFamily:    string(p.DNSLookupFamily),
+			UpstreamValidation: uv,
+		},
+		Scopes:             scopes,
+		ClientID:           string(sec.Object.Data[OIDCClientIDKey]),
+		Credentials:        sec,
+		RedirectPath:       redirectPath,
+		SignoutPath:        signoutPath,
+		ForwardBearerToken: policy.ForwardBearerToken,
+	}, nil
+}
+
+// parseHTTPSURL parses rawurl and ensures that it is an absolute
+// HTTPS URL.
+func parseHTTPSURL(rawurl string) (*url.URL, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return nil, errors.New("must be an absolute https URL")
+	}
+
+	return u, nil
+}
+
 type vhost interface {
 	addRoute(*Route)
 }
@@ -362,13 +947,15 @@ func addRoutes(vhost vhost, routes []*Route) {
 }
 
 func (p *HTTPProxyProcessor) computeRoutes(
-	validCond *contour_api_v1.DetailedCondition,
+	pu *status.ProxyUpdate,
 	rootProxy *contour_api_v1.HTTPProxy,
 	proxy *contour_api_v1.HTTPProxy,
 	conditions []contour_api_v1.MatchCondition,
 	visited []*contour_api_v1.HTTPProxy,
 	enforceTLS bool,
 ) []*Route {
+	validCond := pu.ConditionFor(status.ValidCondition)
+
 	for _, v := range visited {
 		// ensure we are not following an edge that produces a cycle
 		var path []string
@@ -383,6 +970,28 @@ func (p *HTTPProxyProcessor) computeRoutes(
 		}
 	}
 
+	if reason, ok := p.source.quarantineReason(proxy); ok {
+		validCond.AddError(contour_api_v1.ConditionTypeSpecError, "EnvoyRejected", reason)
+		return nil
+	}
+
+	// The root's namespace has already been checked, but the routes
+	// of included HTTPProxies also need the approval of their own
+	// namespace.
+	if proxy.Namespace != rootProxy.Namespace {
+		if err := p.source.ingressPolicyPermits(proxy.Namespace, rootProxy.Spec.VirtualHost.Fqdn); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeIngressPolicyError, "IngressPolicyNotPermitted",
+				"included HTTPProxy is not permitted: %s", err)
+			return nil
+		}
+	}
+
+	blocked := p.source.namespaceBlocked(proxy.Namespace)
+	if blocked {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeRouteError, "NamespaceBlocked",
+			"routes from namespace %q are blocked by the cluster administrator and respond with a 503 status", proxy.Namespace)
+	}
+
 	visited = append(visited, proxy)
 	var routes []*Route
 
@@ -418,26 +1027,62 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
+		if notPrefixMatchConditionsPresent(include.Conditions) {
+			validCond.AddError(contour_api_v1.ConditionTypeIncludeError, "PathMatchConditionsNotValid",
+				"include: notPrefix conditions are only supported on routes")
+			return nil
+		}
+
+		if p.MaxIncludeDepth > 0 && len(visited) > p.MaxIncludeDepth {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeIncludeError, "TooManyIncludes",
+				"include %s/%s is more than %d includes deep", namespace, include.Name, p.MaxIncludeDepth)
+			return nil
+		}
+
+		p.includes++
+		if p.MaxIncludes > 0 && p.includes > p.MaxIncludes {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeIncludeError, "TooManyIncludes",
+				"include %s/%s exceeds the limit of %d includes from root httpproxy %s/%s",
+				namespace, include.Name, p.MaxIncludes, rootProxy.Namespace, rootProxy.Name)
+			return nil
+		}
+
 		inc, incCommit := p.dag.StatusCache.ProxyAccessor(includedProxy)
-		incValidCond := inc.ConditionFor(status.ValidCondition)
-		routes = append(routes, p.computeRoutes(incValidCond, rootProxy, includedProxy, append(conditions, include.Conditions...), visited, enforceTLS)...)
+		routes = append(routes, p.computeRoutes(inc, rootProxy, includedProxy, append(conditions, include.Conditions...), visited, enforceTLS)...)
 		incCommit()
 
 		// dest is not an orphaned httpproxy, as there is an httpproxy that points to it
 		delete(p.orphaned, types.NamespacedName{Name: includedProxy.Name, Namespace: includedProxy.Namespace})
 	}
 
+	// Check for routes that match the same requests, since
+	// only one of them would ever be used.
+	if i, j, ok := duplicateRouteMatchConditions(proxy.Spec.Routes); ok {
+		validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "DuplicateMatchConditions",
+			"routes[%d] and routes[%d] have duplicate match conditions", i, j)
+		return nil
+	}
+
 	dynamicHeaders := map[string]string{
 		"CONTOUR_NAMESPACE": proxy.Namespace,
 	}
 
-	for _, route := range proxy.Spec.Routes {
+	var effectiveRoutes []contour_api_v1.EffectiveRoute
+	var exclusions []*Route
+
+	for i, route := range proxy.Spec.Routes {
 		if err := pathMatchConditionsValid(route.Conditions); err != nil {
 			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "PathMatchConditionsNotValid",
 				"route: %s", err)
 			return nil
 		}
 
+		if err := notPrefixMatchConditionsValid(route.Conditions); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "PathMatchConditionsNotValid",
+				"route: %s", err)
+			return nil
+		}
+
 		conds := append(conditions, route.Conditions...)
 
 		// Look for invalid header conditions on this route
@@ -448,6 +1093,9 @@ func (p *HTTPProxyProcessor) computeRoutes(
 		}
 
 		reqHP, err := headersPolicyRoute(route.RequestHeadersPolicy, true /* allow Host */, dynamicHeaders)
+		if err == nil {
+			err = protectedHeadersValid(p.AllowedProtectedHeaders, headerPolicyNames(route.RequestHeadersPolicy)...)
+		}
 		if err != nil {
 			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "RequestHeadersPolicyInvalid",
 				"%s on request headers", err)
@@ -474,6 +1122,33 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
+		if route.WebsocketIdleTimeout != "" {
+			if !route.EnableWebsockets {
+				validCond.AddError(contour_api_v1.ConditionTypeRouteError, "WebsocketIdleTimeoutNotValid",
+					"route.websocketIdleTimeout requires route.enableWebsockets")
+				return nil
+			}
+
+			// The websocket idle timeout replaces the route idle timeout so
+			// that long-lived sockets aren't closed by a shorter HTTP idle timeout.
+			tp.IdleTimeout, err = timeout.Parse(route.WebsocketIdleTimeout)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "WebsocketIdleTimeoutNotValid",
+					"route.websocketIdleTimeout failed to parse: %s", err)
+				return nil
+			}
+		}
+
+		rp, err := retryPolicy(route.RetryPolicy)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "RetryPolicyNotValid",
+				"route.retryPolicy failed to parse: %s", err)
+			return nil
+		}
+		if route.RetryPolicy == nil {
+			rp = p.DefaultRetryPolicy
+		}
+
 		rlp, err := rateLimitPolicy(route.RateLimitPolicy)
 		if err != nil {
 			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
@@ -481,18 +1156,43 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
+		rbp, err := rbacPolicy(route.RBACPolicy)
+		if err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "RBACPolicyNotValid",
+				"route.rbacPolicy is invalid: %s", err)
+			return nil
+		}
+
+		var window *activeWindow
+		if route.ActiveWindow != nil {
+			window, err = parseActiveWindow(route.ActiveWindow)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeRouteError, "ActiveWindowNotValid",
+					"route.activeWindow is invalid: %s", err)
+				return nil
+			}
+		}
+
 		requestHashPolicies, lbPolicy := loadBalancerRequestHashPolicies(route.LoadBalancerPolicy, validCond)
+		if route.LoadBalancerPolicy == nil {
+			lbPolicy = p.DefaultLoadBalancerPolicy
+		}
 
 		r := &Route{
 			PathMatchCondition:    mergePathMatchConditions(conds),
 			HeaderMatchConditions: mergeHeaderMatchConditions(conds),
+			IgnorePathCase:        route.IgnorePathCase,
+			CreationTimestamp:     proxy.CreationTimestamp.Time,
+			Source:                objectReference("HTTPProxy", proxy),
+			Labels:                selectLabels(proxy, p.MetadataLabels),
 			Websocket:             route.EnableWebsockets,
 			HTTPSUpgrade:          routeEnforceTLS(enforceTLS, route.PermitInsecure && !p.DisablePermitInsecure),
 			TimeoutPolicy:         tp,
-			RetryPolicy:           retryPolicy(route.RetryPolicy),
+			RetryPolicy:           rp,
 			RequestHeadersPolicy:  reqHP,
 			ResponseHeadersPolicy: respHP,
 			RateLimitPolicy:       rlp,
+			RBACPolicy:            rbp,
 			RequestHashPolicies:   requestHashPolicies,
 		}
 
@@ -556,6 +1256,28 @@ func (p *HTTPProxyProcessor) computeRoutes(
 
 		}
 
+		if route.GetStripPrefix() {
+			if len(route.GetPrefixReplacements()) > 0 {
+				validCond.AddError(contour_api_v1.ConditionTypePrefixReplaceError, "AmbiguousReplacement",
+					"cannot specify both prefix replacements and strip prefix")
+				return nil
+			}
+
+			if !r.HasPathPrefix() {
+				validCond.AddError(contour_api_v1.ConditionTypePrefixReplaceError, "MustHavePrefix",
+					"cannot strip the prefix without a prefix condition")
+				return nil
+			}
+
+			r.StripPrefix = true
+		}
+
+		for _, name := range drainedServices(route.Services) {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "ServiceDrained",
+				"service %q has a weight of 0 and receives no traffic", name)
+		}
+
+		var failover []WeightedService
 		for _, service := range route.Services {
 			if service.Port < 1 || service.Port > 65535 {
 				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "ServicePortInvalid",
@@ -570,6 +1292,23 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				return nil
 			}
 
+			// Services with a failover priority join the cluster
+			// of the route's primary service once all the services
+			// have been processed.
+			if service.Priority > 0 {
+				if service.Mirror || s.ExternalName != "" {
+					validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "FailoverInvalid",
+						"Service [%s:%d] failover priority cannot be set on mirror or ExternalName services", service.Name, service.Port)
+					return nil
+				}
+
+				w := s.Weighted
+				w.Weight = uint32(service.Weight)
+				w.Priority = service.Priority
+				failover = append(failover, w)
+				continue
+			}
+
 			// Determine the protocol to use to speak to this Cluster.
 			protocol, err := getProtocol(service, s)
 			if err != nil {
@@ -578,6 +1317,7 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			}
 
 			var uv *PeerValidationContext
+			var ut *UpstreamTLS
 			if protocol == "tls" || protocol == "h2" {
 				// we can only validate TLS connections to services that talk TLS
 				uv, err = p.source.LookupUpstreamValidation(service.UpstreamValidation, proxy.Namespace)
@@ -586,24 +1326,46 @@ func (p *HTTPProxyProcessor) computeRoutes(
 						"Service [%s:%d] TLS upstream validation policy error: %s", service.Name, service.Port, err)
 					return nil
 				}
+
+				ut, err = upstreamTLS(service.UpstreamTLS)
+				if err != nil {
+					validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "UpstreamTLSInvalid",
+						"Service [%s:%d] upstream TLS policy error: %s", service.Name, service.Port, err)
+					return nil
+				}
 			}
 
 			dynamicHeaders["CONTOUR_SERVICE_NAME"] = service.Name
 			dynamicHeaders["CONTOUR_SERVICE_PORT"] = strconv.Itoa(service.Port)
 
-			reqHP, err := headersPolicyService(p.RequestHeadersPolicy, service.RequestHeadersPolicy, dynamicHeaders)
+			reqHP, err := headersPolicyService(p.RequestHeadersPolicy, r.RequestHeadersPolicy, service.RequestHeadersPolicy, dynamicHeaders)
+			if err == nil {
+				err = protectedHeadersValid(p.AllowedProtectedHeaders, headerPolicyNames(service.RequestHeadersPolicy)...)
+			}
 			if err != nil {
 				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "RequestHeadersPolicyInvalid",
 					"%s on request headers", err)
 				return nil
 			}
-			respHP, err := headersPolicyService(p.ResponseHeadersPolicy, service.ResponseHeadersPolicy, dynamicHeaders)
+			respHP, err := headersPolicyService(p.ResponseHeadersPolicy, r.ResponseHeadersPolicy, service.ResponseHeadersPolicy, dynamicHeaders)
 			if err != nil {
 				validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "ResponseHeadersPolicyInvalid",
 					"%s on response headers", err)
 				return nil
 			}
 
+			// Service level header policies take precedence over
+			// route level ones, so let the user know which route
+			// level headers won't apply to this service.
+			if overrides := headersPolicyOverrides(r.RequestHeadersPolicy, reqHP); len(overrides) > 0 {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "HeadersPolicyOverridden",
+					"Service [%s:%d] request headers policy overrides route level headers %s", service.Name, service.Port, strings.Join(overrides, ", "))
+			}
+			if overrides := headersPolicyOverrides(r.ResponseHeadersPolicy, respHP); len(overrides) > 0 {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "HeadersPolicyOverridden",
+					"Service [%s:%d] response headers policy overrides route level headers %s", service.Name, service.Port, strings.Join(overrides, ", "))
+			}
+
 			var clientCertSecret *Secret
 			if p.ClientCertificate != nil {
 				clientCertSecret, err = p.source.LookupSecret(*p.ClientCertificate, validSecret)
@@ -614,18 +1376,48 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				}
 			}
 
+			sni := determineSNI(r.RequestHeadersPolicy, reqHP, s)
+			if service.SNI != "" && (protocol == "tls" || protocol == "h2") {
+				if msgs := validation.IsDNS1123Subdomain(service.SNI); len(msgs) > 0 {
+					validCond.AddErrorf(contour_api_v1.ConditionTypeServiceError, "SNIInvalid",
+						"Service [%s:%d] SNI %q is invalid: %s", service.Name, service.Port, service.SNI, strings.Join(msgs, ", "))
+					return nil
+				}
+
+				// The backend certificate is verified against the
+				// subject name, not the SNI, so let the user know if
+				// they are likely to select a certificate that fails
+				// validation.
+				if uv != nil && uv.SubjectName != "" && uv.SubjectName != service.SNI {
+					validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "SNISubjectNameMismatch",
+						"Service [%s:%d] SNI %q does not match the validation subjectName %q", service.Name, service.Port, service.SNI, uv.SubjectName)
+				}
+
+				sni = service.SNI
+			}
+
+			if service.DNSLookupFamily == string(config.AllClusterDNSFamily) {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "DNSLookupFamilyNotSupported",
+					"Service [%s:%d] DNS lookup family %q is not supported by Envoy, %q is used", service.Name, service.Port, config.AllClusterDNSFamily, config.AutoClusterDNSFamily)
+			}
+
 			c := &Cluster{
-				Upstream:              s,
-				LoadBalancerPolicy:    lbPolicy,
-				Weight:                uint32(service.Weight),
-				HTTPHealthCheckPolicy: httpHealthCheckPolicy(route.HealthCheckPolicy),
-				UpstreamValidation:    uv,
-				RequestHeadersPolicy:  reqHP,
-				ResponseHeadersPolicy: respHP,
-				Protocol:              protocol,
-				SNI:                   determineSNI(r.RequestHeadersPolicy, reqHP, s),
-				DNSLookupFamily:       string(p.DNSLookupFamily),
-				ClientCertificate:     clientCertSecret,
+				Upstream:                  s,
+				LoadBalancerPolicy:        lbPolicy,
+				Weight:                    uint32(service.Weight),
+				HTTPHealthCheckPolicy:     p.httpHealthCheckPolicy(route.HealthCheckPolicy),
+				UpstreamValidation:        uv,
+				UpstreamTLS:               ut,
+				RequestHeadersPolicy:      reqHP,
+				ResponseHeadersPolicy:     respHP,
+				Protocol:                  protocol,
+				SNI:                       sni,
+				DNSLookupFamily:           p.dnsLookupFamily(service),
+				DNSLookupFamilyOverridden: p.dnsLookupFamilyOverridden(service),
+				ClientCertificate:         clientCertSecret,
+				EnableTrailers:            route.EnableTrailers,
+				LBConfig:                  p.lbConfig(service),
+				MaxRequestsPerConnection:  p.maxRequestsPerConnection(service, s),
 			}
 			if service.Mirror && r.MirrorPolicy != nil {
 				validCond.AddError(contour_api_v1.ConditionTypeServiceError, "OnlyOneMirror",
@@ -640,10 +1432,51 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				r.Clusters = append(r.Clusters, c)
 			}
 		}
+
+		if len(failover) > 0 {
+			if len(r.Clusters) != 1 || r.Clusters[0].Upstream.ExternalName != "" {
+				validCond.AddError(contour_api_v1.ConditionTypeServiceError, "FailoverInvalid",
+					"failover priorities require exactly one service with priority 0 in the route, which cannot be an ExternalName service")
+				return nil
+			}
+
+			services := append([]WeightedService{r.Clusters[0].Upstream.Weighted}, failover...)
+			compactPriorities(services)
+			r.Clusters[0].Failover = services[1:]
+		}
+
+		p.splitClusters(r, validCond)
+
+		// Routes outside of their active window are validated,
+		// but not programmed.
+		if window != nil {
+			active, next := window.active(p.now())
+			p.dag.rebuildBy(next)
+			if !active {
+				continue
+			}
+		}
+
 		routes = append(routes, r)
+		exclusions = append(exclusions, exclusionRoutes(r, route.Conditions)...)
+		effectiveRoutes = append(effectiveRoutes, effectiveRoute(rootProxy, i, conds))
 	}
 
-	routes = expandPrefixMatches(routes)
+	pu.EffectiveRoutes = append(pu.EffectiveRoutes, effectiveRoutes...)
+
+	// Exclusion routes are added after the prefix matches are
+	// expanded, since they don't take part in prefix replacement.
+	routes = append(expandPrefixMatches(routes), exclusions...)
+
+	// The routes of included HTTPProxies in other namespaces
+	// have already been blocked if their namespace is.
+	if blocked {
+		for _, r := range routes {
+			if r.Source.Namespace == proxy.Namespace {
+				blockRoute(r)
+			}
+		}
+	}
 
 	return routes
 }
@@ -659,6 +1492,12 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 		return true
 	}
 
+	if p.source.namespaceBlocked(httpproxy.Namespace) {
+		validCond.AddErrorf(contour_api_v1.ConditionTypeTCPProxyError, "NamespaceBlocked",
+			"TCP proxies from namespace %q are blocked by the cluster administrator", httpproxy.Namespace)
+		return false
+	}
+
 	visited = append(visited, httpproxy)
 
 	// #2218 Allow support for both plural and singular "Include" for TCPProxy for the v1 API Spec
@@ -675,6 +1514,9 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 	}
 
 	lbPolicy := loadBalancerPolicy(tcpproxy.LoadBalancerPolicy)
+	if tcpproxy.LoadBalancerPolicy == nil {
+		lbPolicy = p.DefaultLoadBalancerPolicy
+	}
 	switch lbPolicy {
 	case LoadBalancerPolicyCookie, LoadBalancerPolicyRequestHash:
 		validCond.AddWarningf(contour_api_v1.ConditionTypeTCPProxyError, "IgnoredField",
@@ -685,7 +1527,24 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 	}
 
 	if len(tcpproxy.Services) > 0 {
-		var proxy TCPProxy
+		proxy := TCPProxy{
+			Source: objectReference("HTTPProxy", httpproxy),
+		}
+		for _, r := range tcpproxy.AllowedSourceRanges {
+			_, cidr, err := net.ParseCIDR(r)
+			if err != nil {
+				validCond.AddErrorf(contour_api_v1.ConditionTypeTCPProxyError, "InvalidSourceRange",
+					"Spec.TCPProxy.AllowedSourceRanges: invalid CIDR %q", r)
+				return false
+			}
+			proxy.AllowedSourceRanges = append(proxy.AllowedSourceRanges, cidr)
+		}
+
+		for _, name := range drainedServices(tcpproxy.Services) {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "ServiceDrained",
+				"service %q has a weight of 0 and receives no connections", name)
+		}
+
 		for _, service := range httpproxy.Spec.TCPProxy.Services {
 			m := types.NamespacedName{Name: service.Name, Namespace: httpproxy.Namespace}
 			s, err := p.dag.EnsureService(m, intstr.FromInt(service.Port), p.source)
@@ -702,12 +1561,21 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 				return false
 			}
 
+			if service.DNSLookupFamily == string(config.AllClusterDNSFamily) {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "DNSLookupFamilyNotSupported",
+					"Service [%s:%d] DNS lookup family %q is not supported by Envoy, %q is used", service.Name, service.Port, config.AllClusterDNSFamily, config.AutoClusterDNSFamily)
+			}
+
 			proxy.Clusters = append(proxy.Clusters, &Cluster{
-				Upstream:             s,
-				Protocol:             protocol,
-				LoadBalancerPolicy:   lbPolicy,
-				TCPHealthCheckPolicy: tcpHealthCheckPolicy(tcpproxy.HealthCheckPolicy),
-				SNI:                  s.ExternalName,
+				Upstream:                  s,
+				Protocol:                  protocol,
+				LoadBalancerPolicy:        lbPolicy,
+				TCPHealthCheckPolicy:      p.tcpHealthCheckPolicy(tcpproxy.HealthCheckPolicy),
+				SNI:                       s.ExternalName,
+				DNSLookupFamily:           p.dnsLookupFamily(service),
+				DNSLookupFamilyOverridden: p.dnsLookupFamilyOverridden(service),
+				LBConfig:                  p.lbConfig(service),
+				MaxRequestsPerConnection:  p.maxRequestsPerConnection(service, s),
 			})
 		}
 		secure := p.dag.EnsureSecureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_https"})
@@ -747,6 +1615,14 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *contour_api_v1.
 	// dest is no longer an orphan
 	delete(p.orphaned, k8s.NamespacedNameOf(dest))
 
+	if dest.Namespace != httpproxy.Namespace {
+		if err := p.source.ingressPolicyPermits(dest.Namespace, host); err != nil {
+			validCond.AddErrorf(contour_api_v1.ConditionTypeIngressPolicyError, "IngressPolicyNotPermitted",
+				"include %s/%s is not permitted: %s", m.Namespace, m.Name, err)
+			return false
+		}
+	}
+
 	// ensure we are not following an edge that produces a cycle
 	var path []string
 	for _, hp := range visited {
@@ -823,6 +1699,71 @@ func (p *HTTPProxyProcessor) rootAllowed(namespace string) bool {
 	return false
 }
 
+// tapAllowed returns true if root HTTPProxies in namespace may be
+// tapped.
+func (p *HTTPProxyProcessor) tapAllowed(namespace string) bool {
+	if len(p.TapNamespaces) == 0 {
+		return true
+	}
+	for _, ns := range p.TapNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// subdomainDelegations returns the valid subdomain delegations of the
+// HTTPProxies in the permitted root namespaces.
+func (p *HTTPProxyProcessor) subdomainDelegations() []subdomainDelegation {
+	var delegations []subdomainDelegation
+	for _, proxy := range p.source.httpproxies {
+		if proxy.Spec.VirtualHost == nil || !p.rootAllowed(proxy.Namespace) {
+			continue
+		}
+		for _, d := range proxy.Spec.VirtualHost.SubdomainDelegations {
+			domain, err := delegatedDomain(proxy.Spec.VirtualHost.Fqdn, d.Domain)
+			if err != nil {
+				continue
+			}
+			delegations = append(delegations, subdomainDelegation{
+				domain:    domain,
+				namespace: d.Namespace,
+			})
+		}
+	}
+	return delegations
+}
+
+// subdomainDelegated returns true if a root HTTPProxy delegated
+// a domain that host is a subdomain of to the namespace.
+func (p *HTTPProxyProcessor) subdomainDelegated(host, namespace string) bool {
+	host = strings.ToLower(host)
+	for _, d := range p.delegations {
+		if d.namespace == namespace && strings.HasSuffix(host, "."+d.domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// delegatedDomain returns the domain whose subdomains the wildcard
+// delegation covers, such as "team-a.example.com" for
+// "*.team-a.example.com". The domain must be within fqdn.
+func delegatedDomain(fqdn, wildcard string) (string, error) {
+	wildcard = strings.ToLower(wildcard)
+	fqdn = strings.ToLower(fqdn)
+
+	domain := strings.TrimPrefix(wildcard, "*.")
+	if domain == wildcard || len(domain) == 0 || strings.Contains(domain, "*") {
+		return "", fmt.Errorf("domain %q must be a wildcard domain such as \"*.example.com\"", wildcard)
+	}
+	if domain != fqdn && !strings.HasSuffix(domain, "."+fqdn) {
+		return "", fmt.Errorf("domain %q is not within %q", wildcard, fqdn)
+	}
+	return domain, nil
+}
+
 // expandPrefixMatches adds new Routes to account for the difference
 // between prefix replacement when matching on '/foo' and '/foo/'.
 //
@@ -921,6 +1862,10 @@ func getProtocol(service contour_api_v1.Service, s *Service) (string, error) {
 		protocol = *service.Protocol
 		switch protocol {
 		case "h2c", "h2", "tls":
+		case "http/1.1":
+			// Plaintext HTTP/1.1 is the default protocol, an explicit
+			// setting only overrides the Service's annotations.
+			protocol = ""
 		default:
 			return "", fmt.Errorf("unsupported protocol: %v", protocol)
 		}
@@ -931,6 +1876,39 @@ func getProtocol(service contour_api_v1.Service, s *Service) (string, error) {
 	return protocol, nil
 }
 
+// upstreamTLS validates the upstream TLS policy of a service and
+// returns its TLS parameters, or nil if the policy is not set.
+func upstreamTLS(policy *contour_api_v1.UpstreamTLS) (*UpstreamTLS, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	minVersion := policy.MinimumProtocolVersion
+	maxVersion := policy.MaximumProtocolVersion
+	if maxVersion == "" && minVersion == "1.3" {
+		// Envoy's default maximum version for upstream
+		// connections is 1.2, so raise it to match.
+		maxVersion = minVersion
+	}
+	if minVersion != "" && maxVersion != "" && minVersion > maxVersion {
+		return nil, fmt.Errorf("minimumProtocolVersion %q is greater than maximumProtocolVersion %q", minVersion, maxVersion)
+	}
+
+	if err := config.TLSCiphers(policy.CipherSuites).Validate(); err != nil {
+		return nil, err
+	}
+
+	ut := &UpstreamTLS{
+		MinimumProtocolVersion: minVersion,
+		MaximumProtocolVersion: maxVersion,
+	}
+	for _, cipher := range policy.CipherSuites {
+		ut.CipherSuites = append(ut.CipherSuites, strings.TrimSpace(cipher))
+	}
+
+	return ut, nil
+}
+
 // determineSNI decides what the SNI should be on the request. It is configured via RequestHeadersPolicy.Host key.
 // Policies set on service are used before policies set on a route. Otherwise the value of the externalService
 // is used if the route is configured to proxy to an externalService type.
@@ -974,6 +1952,23 @@ func toCORSPolicy(policy *contour_api_v1.CORSPolicy) (*CORSPolicy, error) {
 	}, nil
 }
 
+func toSubjectAltNameMatches(matches []contour_api_v1.SubjectAltNameMatch) ([]SubjectAltNameMatch, error) {
+	sans := make([]SubjectAltNameMatch, 0, len(matches))
+	for i, m := range matches {
+		switch {
+		case m.Exact != "" && m.Suffix != "":
+			return nil, fmt.Errorf("allowedSubjectAltNames[%d] must specify only one of exact or suffix", i)
+		case m.Exact != "":
+			sans = append(sans, SubjectAltNameMatch{MatchType: SubjectAltNameMatchTypeExact, Value: m.Exact})
+		case m.Suffix != "":
+			sans = append(sans, SubjectAltNameMatch{MatchType: SubjectAltNameMatchTypeSuffix, Value: m.Suffix})
+		default:
+			return nil, fmt.Errorf("allowedSubjectAltNames[%d] must specify one of exact or suffix", i)
+		}
+	}
+	return sans, nil
+}
+
 func toStringSlice(hvs []contour_api_v1.CORSHeaderValue) []string {
 	s := make([]string, len(hvs))
 	for i, v := range hvs {
@@ -998,6 +1993,29 @@ func includeMatchConditionsIdentical(includes []contour_api_v1.Include) bool {
 	return false
 }
 
+// duplicateRouteMatchConditions returns the indices of the first two routes
+// that have the same match conditions, regardless of their order.
+func duplicateRouteMatchConditions(routes []contour_api_v1.Route) (int, int, bool) {
+	seen := map[string]int{}
+
+	for i, route := range routes {
+		headers := []string{}
+		for _, hc := range mergeHeaderMatchConditions(route.Conditions) {
+			headers = append(headers, hc.String())
+		}
+		sort.Strings(headers)
+
+		key := mergePathMatchConditions(route.Conditions).String() + "," +
+			strconv.FormatBool(route.IgnorePathCase) + "," + strings.Join(headers, ",")
+		if j, ok := seen[key]; ok {
+			return j, i, true
+		}
+		seen[key] = i
+	}
+
+	return 0, 0, false
+}
+
 // isBlank indicates if a string contains nothing but blank characters.
 func isBlank(s string) bool {
 	return len(strings.TrimSpace(s)) == 0
@@ -1007,3 +2025,268 @@ func isBlank(s string) bool {
 func routeEnforceTLS(enforceTLS, permitInsecure bool) bool {
 	return enforceTLS && !permitInsecure
 }
+
+// exclusionRoutes returns the routes that answer the requests that the
+// notPrefix conditions of the route r exclude from it with 404 Not Found.
+// Each matches the prefix of r followed by the excluded prefix, and the
+// header conditions of r, so it is ordered before r. A route that matches
+// the same requests takes precedence over it. See VirtualHost.addRoute.
+func exclusionRoutes(r *Route, conds []contour_api_v1.MatchCondition) []*Route {
+	prefix := r.PathMatchCondition.(*PrefixMatchCondition).Prefix
+
+	var routes []*Route
+	for _, cond := range conds {
+		if cond.NotPrefix == "" {
+			continue
+		}
+		routes = append(routes, &Route{
+			PathMatchCondition: mergePathMatchConditions([]contour_api_v1.MatchCondition{
+				{Prefix: prefix},
+				{Prefix: cond.NotPrefix},
+			}),
+			HeaderMatchConditions: r.HeaderMatchConditions,
+			IgnorePathCase:        r.IgnorePathCase,
+			CreationTimestamp:     r.CreationTimestamp,
+			Source:                r.Source,
+			Labels:                r.Labels,
+			HTTPSUpgrade:          r.HTTPSUpgrade,
+			DirectResponse:        &DirectResponse{StatusCode: http.StatusNotFound},
+			Exclusion:             true,
+		})
+	}
+	return routes
+}
+
+// effectiveRoute returns the status of the route at index in
+// spec.routes, served by the virtual host of rootProxy with the
+// merged conditions conds.
+func effectiveRoute(rootProxy *contour_api_v1.HTTPProxy, index int, conds []contour_api_v1.MatchCondition) contour_api_v1.EffectiveRoute {
+	er := contour_api_v1.EffectiveRoute{
+		Fqdn:  rootProxy.Spec.VirtualHost.Fqdn,
+		Index: index,
+	}
+	for _, cond := range conds {
+		er.Conditions = append(er.Conditions, *cond.DeepCopy())
+	}
+	return er
+}
+
+// dnsLookupFamily returns the DNS lookup family of the service,
+// or the configured default if the service does not override it.
+func (p *HTTPProxyProcessor) dnsLookupFamily(service contour_api_v1.Service) string {
+	if service.DNSLookupFamily != "" {
+		return service.DNSLookupFamily
+	}
+	return string(p.DNSLookupFamily)
+}
+
+// dnsLookupFamilyOverridden returns true if the service sets a DNS
+// lookup family other than the configured default.
+func (p *HTTPProxyProcessor) dnsLookupFamilyOverridden(service contour_api_v1.Service) bool {
+	family := p.DNSLookupFamily
+	if family == "" {
+		family = config.AutoClusterDNSFamily
+	}
+	return service.DNSLookupFamily != "" && config.ClusterDNSFamilyType(service.DNSLookupFamily) != family
+}
+
+// lbConfig returns the load balancer configuration of the service,
+// starting from the configured default and applying the fields the
+// service overrides.
+func (p *HTTPProxyProcessor) lbConfig(service contour_api_v1.Service) ClusterLBConfig {
+	lb := p.DefaultLBConfig
+	if service.HealthyPanicThreshold != nil {
+		lb.HealthyPanicThreshold = *service.HealthyPanicThreshold
+	}
+	return lb
+}
+
+// maxRequestsPerConnection returns the maximum number of requests per
+// upstream connection of the service. The HTTPProxy field takes
+// precedence over the Service annotation, which takes precedence
+// over the configured default.
+func (p *HTTPProxyProcessor) maxRequestsPerConnection(service contour_api_v1.Service, s *Service) uint32 {
+	if service.MaxRequestsPerConnection != nil {
+		return *service.MaxRequestsPerConnection
+	}
+	if s.MaxRequestsPerConnection > 0 {
+		return s.MaxRequestsPerConnection
+	}
+	return p.DefaultMaxRequestsPerConnection
+}
+
+// httpHealthCheckPolicy returns the health check policy of a route,
+// or the configured default if the route does not set one.
+func (p *HTTPProxyProcessor) httpHealthCheckPolicy(hc *contour_api_v1.HTTPHealthCheckPolicy) *HTTPHealthCheckPolicy {
+	if hc == nil {
+		return p.DefaultHealthCheckPolicy
+	}
+	return httpHealthCheckPolicy(hc)
+}
+
+// tcpHealthCheckPolicy returns the health check policy of a TCPProxy,
+// or one derived from the configured default if the TCPProxy does not
+// set one.
+func (p *HTTPProxyProcessor) tcpHealthCheckPolicy(hc *contour_api_v1.TCPHealthCheckPolicy) *TCPHealthCheckPolicy {
+	if hc == nil && p.DefaultHealthCheckPolicy != nil {
+		return &TCPHealthCheckPolicy{
+			Interval:           p.DefaultHealthCheckPolicy.Interval,
+			Timeout:            p.DefaultHealthCheckPolicy.Timeout,
+			UnhealthyThreshold: p.DefaultHealthCheckPolicy.UnhealthyThreshold,
+			HealthyThreshold:   p.DefaultHealthCheckPolicy.HealthyThreshold,
+		}
+	}
+	return tcpHealthCheckPolicy(hc)
+}
+
+// splitClusters replaces each cluster of the route whose service is
+// split by a TrafficSplit with a cluster for each of the split's
+// backends. The weights of the route's clusters are scaled so that
+// each backend receives its share of the traffic of the split service.
+// ExternalName services and services with failover are not split.
+func (p *HTTPProxyProcessor) splitClusters(r *Route, validCond *contour_api_v1.DetailedCondition) {
+	type split struct {
+		clusters []*Cluster
+		total    uint64
+	}
+
+	splits := make([]*split, len(r.Clusters))
+	scale := uint64(1)
+	applied := false
+	for i, c := range r.Clusters {
+		if c.Upstream.ExternalName != "" || len(c.Failover) > 0 {
+			continue
+		}
+		name := types.NamespacedName{Namespace: c.Upstream.Weighted.ServiceNamespace, Name: c.Upstream.Weighted.ServiceName}
+		ts := p.source.LookupTrafficSplit(name)
+		if ts == nil {
+			continue
+		}
+
+		sp := &split{}
+		for _, backend := range ts.Spec.Backends {
+			m := types.NamespacedName{Namespace: ts.Namespace, Name: backend.Service}
+			s, err := p.dag.EnsureService(m, intstr.FromInt(int(c.Upstream.Weighted.ServicePort.Port)), p.source)
+			if err != nil {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "TrafficSplitUnresolvedReference",
+					"TrafficSplit %q: ignoring backend: %s", k8s.NamespacedNameOf(ts), err)
+				continue
+			}
+			if s.ExternalName != "" {
+				validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "TrafficSplitUnresolvedReference",
+					"TrafficSplit %q: ignoring backend: service %q is an ExternalName service", k8s.NamespacedNameOf(ts), m)
+				continue
+			}
+
+			bc := *c
+			bc.Upstream = s
+			bc.Weight = backend.Weight
+			sp.clusters = append(sp.clusters, &bc)
+			sp.total += uint64(backend.Weight)
+		}
+		if sp.total == 0 {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "TrafficSplitNotApplied",
+				"TrafficSplit %q has no backends with a weight, so the traffic of service %q is not split", k8s.NamespacedNameOf(ts), name.Name)
+			continue
+		}
+
+		splits[i] = sp
+		applied = true
+		scale *= sp.total
+		if scale > math.MaxUint32 {
+			validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "TrafficSplitNotApplied",
+				"the weights of the traffic splits of the route's services are too large to combine, so they are not applied")
+			return
+		}
+	}
+	if !applied {
+		return
+	}
+
+	// The clusters of a route that sets no weights receive an equal
+	// share of its traffic.
+	weighted := false
+	for _, c := range r.Clusters {
+		weighted = weighted || c.Weight > 0
+	}
+
+	var clusters []*Cluster
+	var weights []uint64
+	var total uint64
+	for i, c := range r.Clusters {
+		if sp := splits[i]; sp != nil {
+			for _, bc := range sp.clusters {
+				clusters = append(clusters, bc)
+				weights = append(weights, weightOf(c, weighted)*uint64(bc.Weight)*(scale/sp.total))
+			}
+			continue
+		}
+		clusters = append(clusters, c)
+		weights = append(weights, weightOf(c, weighted)*scale)
+	}
+	for _, w := range weights {
+		total += w
+	}
+	if total > math.MaxUint32 {
+		validCond.AddWarningf(contour_api_v1.ConditionTypeServiceError, "TrafficSplitNotApplied",
+			"the weights of the traffic splits of the route's services are too large to combine, so they are not applied")
+		return
+	}
+
+	for i, c := range clusters {
+		c.Weight = uint32(weights[i])
+	}
+	r.Clusters = clusters
+}
+
+// weightOf returns the weight of a cluster of a route, which is
+// 1 if the route sets no weights.
+// drainedServices returns the names of the services that have a
+// weight of zero while other services that share their traffic have
+// positive weights. They receive no traffic, but their clusters stay
+// configured, so that setting their weight again restores them
+// without waiting for new clusters to warm up. Mirror and failover
+// services don't share the traffic, so their weights are ignored.
+func drainedServices(services []contour_api_v1.Service) []string {
+	weighted := false
+	for _, s := range services {
+		if !s.Mirror && s.Priority == 0 && s.Weight > 0 {
+			weighted = true
+		}
+	}
+	if !weighted {
+		return nil
+	}
+
+	var drained []string
+	for _, s := range services {
+		if !s.Mirror && s.Priority == 0 && s.Weight == 0 {
+			drained = append(drained, s.Name)
+		}
+	}
+	return drained
+}
+
+func weightOf(c *Cluster, weighted bool) uint64 {
+	if !weighted {
+		return 1
+	}
+	return uint64(c.Weight)
+}
+
+// selectLabels returns the labels of obj whose keys are listed,
+// or nil if it has none of them.
+func selectLabels(obj metav1.Object, keys []string) map[string]string {
+	var labels map[string]string
+	for _, key := range keys {
+		value, ok := obj.GetLabels()[key]
+		if !ok {
+			continue
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[key] = value
+	}
+	return labels
+}
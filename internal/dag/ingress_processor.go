@@ -16,11 +16,13 @@ package dag
 import (
 	"regexp"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/projectcontour/contour/internal/annotation"
 	"github.com/projectcontour/contour/internal/k8s"
+	"github.com/projectcontour/contour/internal/timeout"
 	"github.com/sirupsen/logrus"
 	networking_v1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -37,6 +39,27 @@ type IngressProcessor struct {
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *types.NamespacedName
+
+	// DefaultLoadBalancerPolicy is the load balancer strategy of
+	// Ingress clusters (optional).
+	DefaultLoadBalancerPolicy string
+
+	// DefaultHealthCheckPolicy is the active health check policy of
+	// Ingress clusters (optional).
+	DefaultHealthCheckPolicy *HTTPHealthCheckPolicy
+
+	// DefaultLBConfig is the load balancer configuration of
+	// Ingress clusters (optional).
+	DefaultLBConfig ClusterLBConfig
+
+	// DefaultRetryPolicy is the retry policy of Ingress routes
+	// that have no retry-on annotation (optional).
+	DefaultRetryPolicy *RetryPolicy
+
+	// DefaultMaxRequestsPerConnection is the maximum number of
+	// requests per upstream connection of Ingress clusters whose
+	// Service has no max-requests-per-connection annotation.
+	DefaultMaxRequestsPerConnection uint32
 }
 
 // Run translates Ingresses into DAG objects and
@@ -91,20 +114,67 @@ func (p *IngressProcessor) computeSecureVirtualhosts() {
 				svhost.Secret = sec
 				// default to a minimum TLS version of 1.2 if it's not specified
 				svhost.MinTLSVersion = annotation.MinTLSVersion(annotation.ContourAnnotation(ing, "tls-minimum-protocol-version"), "1.2")
+				p.computeAuthorization(ing, svhost)
 			}
 		}
 	}
 }
 
+// computeAuthorization attaches the authorization ExtensionService
+// named by the projectcontour.io/auth-service annotation of the
+// Ingress, if any, to the secure virtual host.
+func (p *IngressProcessor) computeAuthorization(ing *networking_v1.Ingress, svhost *SecureVirtualHost) {
+	service := annotation.ContourAnnotation(ing, "auth-service")
+	if service == "" {
+		return
+	}
+
+	log := p.WithField("name", ing.GetName()).
+		WithField("namespace", ing.GetNamespace()).
+		WithField("host", svhost.Name)
+
+	extensionName := k8s.NamespacedNameFrom(service, k8s.DefaultNamespace(ing.GetNamespace()))
+	ext := p.dag.GetExtensionCluster(ExtensionClusterName(extensionName))
+	if ext == nil {
+		log.WithField("extension", extensionName).
+			Error("authorization extension service not found")
+		return
+	}
+
+	responseTimeout, err := timeout.Parse(annotation.ContourAnnotation(ing, "auth-response-timeout"))
+	if err != nil {
+		log.WithError(err).Error("auth-response-timeout annotation is invalid")
+		return
+	}
+
+	svhost.AuthorizationService = ext
+	svhost.AuthorizationFailOpen = annotation.ContourAnnotation(ing, "auth-fail-open") == "true"
+	if responseTimeout.UseDefault() {
+		svhost.AuthorizationResponseTimeout = ext.TimeoutPolicy.ResponseTimeout
+	} else {
+		svhost.AuthorizationResponseTimeout = responseTimeout
+	}
+}
+
 func (p *IngressProcessor) computeIngresses() {
 	// deconstruct each ingress into routes and virtualhost entries
 	for _, ing := range p.source.ingresses {
+		if reason, ok := p.source.quarantineReason(ing); ok {
+			p.WithField("name", ing.GetName()).
+				WithField("namespace", ing.GetNamespace()).
+				Errorf("skipping quarantined ingress: %s", reason)
+			continue
+		}
+
+		start := time.Now()
 
 		// rewrite the default ingress to a stock ingress rule.
 		rules := rulesFromSpec(ing.Spec)
 		for _, rule := range rules {
 			p.computeIngressRule(ing, rule)
 		}
+
+		p.source.observeObject("Ingress", start)
 	}
 }
 
@@ -116,6 +186,15 @@ func (p *IngressProcessor) computeIngressRule(ing *networking_v1.Ingress, rule n
 		host = "*"
 	}
 
+	if err := p.source.ingressPolicyPermits(ing.Namespace, host); err != nil {
+		p.WithError(err).
+			WithField("name", ing.GetName()).
+			WithField("namespace", ing.GetNamespace()).
+			WithField("host", host).
+			Error("ingress rule is not permitted")
+		return
+	}
+
 	var clientCertSecret *Secret
 	var err error
 	if p.ClientCertificate != nil {
@@ -164,6 +243,24 @@ func (p *IngressProcessor) computeIngressRule(ing *networking_v1.Ingress, rule n
 			return
 		}
 
+		if r.RetryPolicy == nil {
+			r.RetryPolicy = p.DefaultRetryPolicy
+		}
+
+		if p.source.namespaceBlocked(ing.Namespace) {
+			blockRoute(r)
+		}
+
+		for _, c := range r.Clusters {
+			c.LoadBalancerPolicy = p.DefaultLoadBalancerPolicy
+			c.HTTPHealthCheckPolicy = p.DefaultHealthCheckPolicy
+			c.LBConfig = p.DefaultLBConfig
+			c.MaxRequestsPerConnection = c.Upstream.MaxRequestsPerConnection
+			if c.MaxRequestsPerConnection == 0 {
+				c.MaxRequestsPerConnection = p.DefaultMaxRequestsPerConnection
+			}
+		}
+
 		// should we create port 80 routes for this ingress
 		if annotation.TLSRequired(ing) || annotation.HTTPAllowed(ing) {
 			vhost := p.dag.EnsureVirtualHost(ListenerName{Name: host, ListenerName: "ingress_http"})
@@ -191,10 +288,13 @@ func route(ingress *networking_v1.Ingress, host string, path string, pathType ne
 	})
 
 	r := &Route{
-		HTTPSUpgrade:  annotation.TLSRequired(ingress),
-		Websocket:     annotation.WebsocketRoutes(ingress)[path],
-		TimeoutPolicy: ingressTimeoutPolicy(ingress, log),
-		RetryPolicy:   ingressRetryPolicy(ingress, log),
+		CreationTimestamp: ingress.CreationTimestamp.Time,
+		Source:            objectReference("Ingress", ingress),
+		HTTPSUpgrade:      annotation.TLSRequired(ingress),
+		Websocket:         annotation.WebsocketRoutes(ingress)[path],
+		TimeoutPolicy:     ingressTimeoutPolicy(ingress, path, log),
+		RetryPolicy:       ingressRetryPolicy(ingress, log),
+		AuthDisabled:      annotation.AuthDisabled(ingress),
 		Clusters: []*Cluster{{
 			Upstream:          service,
 			Protocol:          service.Protocol,
@@ -202,6 +302,12 @@ func route(ingress *networking_v1.Ingress, host string, path string, pathType ne
 		}},
 	}
 
+	// The authorization context only applies if a virtual host of
+	// the route has an authorization ExtensionService.
+	if context := annotation.AuthContext(ingress); len(context) > 0 {
+		r.AuthContext = context
+	}
+
 	switch pathType {
 	case networking_v1.PathTypePrefix:
 		prefixMatchType := PrefixMatchSegment
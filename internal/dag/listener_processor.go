@@ -13,19 +13,44 @@
 
 package dag
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
 
 // ListenerProcessor adds an HTTP and an HTTPS listener to
 // the DAG if there are virtual hosts and secure virtual
 // hosts already defined as roots in the DAG.
-type ListenerProcessor struct{}
+type ListenerProcessor struct {
+	logrus.FieldLogger
+
+	// FallbackPassthroughService is the optional identifier of the
+	// Kubernetes service to which TLS connections without an SNI
+	// server name are passed through.
+	FallbackPassthroughService *types.NamespacedName
+
+	// FallbackPassthroughPort is the port of FallbackPassthroughService.
+	FallbackPassthroughPort int
+
+	// HealthVirtualHost is the name of the virtual host that Envoy
+	// answers health checks on. Virtual hosts with the same name
+	// are left out of the HTTP listener, and an error condition is
+	// set on the objects that they were built from.
+	HealthVirtualHost string
+}
 
 // Run adds HTTP and HTTPS listeners to the DAG if there are
 // virtual hosts and secure virtual hosts already defined as
 // roots in the DAG.
-func (p *ListenerProcessor) Run(dag *DAG, _ *KubernetesCache) {
+func (p *ListenerProcessor) Run(dag *DAG, cache *KubernetesCache) {
 	p.buildHTTPListener(dag)
-	p.buildHTTPSListener(dag)
+	p.buildHTTPSListener(dag, cache)
 }
 
 // buildHTTPListener builds a *dag.Listener for the vhosts bound to port 80.
@@ -40,6 +65,11 @@ func (p *ListenerProcessor) buildHTTPListener(dag *DAG) {
 		case *VirtualHost:
 			remove = append(remove, obj)
 
+			if p.HealthVirtualHost != "" && obj.Name == p.HealthVirtualHost {
+				p.reportHealthVirtualHostConflict(dag, obj)
+				continue
+			}
+
 			if obj.Valid() {
 				virtualhosts = append(virtualhosts, obj)
 			}
@@ -67,10 +97,46 @@ func (p *ListenerProcessor) buildHTTPListener(dag *DAG) {
 	dag.AddRoot(http)
 }
 
+// reportHealthVirtualHostConflict logs that the virtual host vh is
+// replaced by the health virtual host, and sets an error condition
+// on the HTTPProxies and HTTPRoutes that its routes were built from.
+func (p *ListenerProcessor) reportHealthVirtualHostConflict(dag *DAG, vh *VirtualHost) {
+	sources := map[ObjectReference]bool{}
+	vh.Visit(func(v Vertex) {
+		if r, ok := v.(*Route); ok {
+			sources[ObjectReference{Kind: r.Source.Kind, Namespace: r.Source.Namespace, Name: r.Source.Name}] = true
+		}
+	})
+
+	msg := fmt.Sprintf("virtual host %q is reserved for the health virtual host and is not served over HTTP", vh.Name)
+
+	for source := range sources {
+		if p.FieldLogger != nil {
+			p.WithField("vhost", vh.Name).
+				WithField("kind", source.Kind).
+				WithField("namespace", source.Namespace).
+				WithField("name", source.Name).
+				Error("virtual host is replaced by the health virtual host")
+		}
+
+		fullname := types.NamespacedName{Namespace: source.Namespace, Name: source.Name}
+		switch source.Kind {
+		case "HTTPProxy":
+			if pu := dag.StatusCache.CommittedProxyUpdate(fullname); pu != nil {
+				pu.ConditionFor(status.ValidCondition).AddError(contour_api_v1.ConditionTypeVirtualHostError, "HealthVirtualHostConflict", msg)
+			}
+		case "HTTPRoute":
+			if ru := dag.StatusCache.CommittedRouteUpdate(fullname, status.ResourceHTTPRoute); ru != nil {
+				ru.AddError(status.ConditionAcceptedHostnames, status.ReasonHealthVirtualHostConflict, msg)
+			}
+		}
+	}
+}
+
 // buildHTTPSListener builds a *dag.Listener for the vhosts bound to port 443.
 // The list of virtual hosts will attached to the listener will be sorted
 // by hostname.
-func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
+func (p *ListenerProcessor) buildHTTPSListener(dag *DAG, cache *KubernetesCache) {
 	var virtualhosts []Vertex
 	var remove []Vertex
 
@@ -103,5 +169,37 @@ func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
 		VirtualHosts: virtualhosts,
 	}
 
+	https.FallbackTCPProxy = p.buildFallbackTCPProxy(dag, cache, virtualhosts)
+
 	dag.AddRoot(https)
 }
+
+// buildFallbackTCPProxy returns a *dag.TCPProxy forwarding to the
+// configured fallback passthrough service, or nil if none is configured
+// or a wildcard secure virtual host already accepts non-SNI connections.
+func (p *ListenerProcessor) buildFallbackTCPProxy(dag *DAG, cache *KubernetesCache, virtualhosts []Vertex) *TCPProxy {
+	if p.FallbackPassthroughService == nil {
+		return nil
+	}
+
+	for _, vh := range virtualhosts {
+		if vh.(*SecureVirtualHost).Name == "*" {
+			return nil
+		}
+	}
+
+	s, err := dag.EnsureService(*p.FallbackPassthroughService, intstr.FromInt(p.FallbackPassthroughPort), cache)
+	if err != nil {
+		p.WithError(err).WithField("service", p.FallbackPassthroughService).
+			Error("unable to resolve fallback passthrough service")
+		return nil
+	}
+
+	return &TCPProxy{
+		Clusters: []*Cluster{{
+			Upstream: s,
+			Protocol: s.Protocol,
+			SNI:      s.ExternalName,
+		}},
+	}
+}
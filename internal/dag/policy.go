@@ -27,6 +27,7 @@ import (
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/internal/annotation"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/projectcontour/contour/pkg/config"
 	"github.com/sirupsen/logrus"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -69,19 +70,14 @@ func retryOn(ro []contour_api_v1.RetryOn) string {
 	return strings.Join(ss, ",")
 }
 
-func retryPolicy(rp *contour_api_v1.RetryPolicy) *RetryPolicy {
+func retryPolicy(rp *contour_api_v1.RetryPolicy) (*RetryPolicy, error) {
 	if rp == nil {
-		return nil
+		return nil, nil
 	}
 
-	// If PerTryTimeout is not a valid duration string, use the Envoy default
-	// value, otherwise use the provided value.
-	// TODO(sk) it might make sense to change the behavior here to be consistent
-	// with other timeout parsing, meaning use timeout.Parse which would result
-	// in a disabled per-try timeout if the input was not a valid duration.
-	perTryTimeout := timeout.DefaultSetting()
-	if perTryDuration, err := time.ParseDuration(rp.PerTryTimeout); err == nil {
-		perTryTimeout = timeout.DurationSetting(perTryDuration)
+	perTryTimeout, err := timeout.Parse(rp.PerTryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing per try timeout: %w", err)
 	}
 
 	return &RetryPolicy{
@@ -89,10 +85,15 @@ func retryPolicy(rp *contour_api_v1.RetryPolicy) *RetryPolicy {
 		RetriableStatusCodes: rp.RetriableStatusCodes,
 		NumRetries:           max(1, uint32(rp.NumRetries)),
 		PerTryTimeout:        perTryTimeout,
-	}
+	}, nil
 }
 
-func headersPolicyService(defaultPolicy *HeadersPolicy, policy *contour_api_v1.HeadersPolicy, dynamicHeaders map[string]string) (*HeadersPolicy, error) {
+// headersPolicyService builds the *HeadersPolicy of a service. Entries
+// of defaultPolicy are applied unless the service policy or routePolicy
+// already sets or removes the same header, since Envoy applies service
+// level headers after route level headers. A policy that both sets and
+// removes the same header is an error.
+func headersPolicyService(defaultPolicy, routePolicy *HeadersPolicy, policy *contour_api_v1.HeadersPolicy, dynamicHeaders map[string]string) (*HeadersPolicy, error) {
 	if defaultPolicy == nil {
 		return headersPolicyRoute(policy, false, dynamicHeaders)
 	}
@@ -107,6 +108,10 @@ func headersPolicyService(defaultPolicy *HeadersPolicy, policy *contour_api_v1.H
 	if userPolicy.Set == nil {
 		userPolicy.Set = make(map[string]string, len(defaultPolicy.Set))
 	}
+	// The object's own policy takes precedence over the default
+	// policy, whether it sets or removes a header.
+	userHeaders := headerPolicyKeys(userPolicy)
+	routeHeaders := headerPolicyKeys(routePolicy)
 	for k, v := range defaultPolicy.Set {
 		key := http.CanonicalHeaderKey(k)
 		if key == "Host" {
@@ -115,22 +120,21 @@ func headersPolicyService(defaultPolicy *HeadersPolicy, policy *contour_api_v1.H
 		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
 			return nil, fmt.Errorf("invalid set header %q: %v", key, msgs)
 		}
+		if err := config.ValidateHeaderValue(v); err != nil {
+			return nil, fmt.Errorf("invalid set header %q value: %v", key, err)
+		}
 		// if the user policy set on the object does not contain this header then use the default
-		if _, exists := userPolicy.Set[key]; !exists {
+		if !userHeaders.Has(key) && !routeHeaders.Has(key) {
 			userPolicy.Set[key] = escapeHeaderValue(v, dynamicHeaders)
 		}
 	}
 	// add any default remove header policy if not already set
-	remove := sets.NewString()
-	for _, entry := range userPolicy.Remove {
-		remove.Insert(entry)
-	}
 	for _, entry := range defaultPolicy.Remove {
 		key := http.CanonicalHeaderKey(entry)
 		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
 			return nil, fmt.Errorf("invalid set header %q: %v", key, msgs)
 		}
-		if !remove.Has(key) {
+		if !userHeaders.Has(key) && !routeHeaders.Has(key) {
 			userPolicy.Remove = append(userPolicy.Remove, key)
 		}
 	}
@@ -138,6 +142,31 @@ func headersPolicyService(defaultPolicy *HeadersPolicy, policy *contour_api_v1.H
 	return userPolicy, nil
 }
 
+// headerPolicyKeys returns the names of the headers that policy
+// sets, adds or removes.
+func headerPolicyKeys(policy *HeadersPolicy) sets.String {
+	keys := sets.NewString()
+	if policy == nil {
+		return keys
+	}
+	for k := range policy.Set {
+		keys.Insert(k)
+	}
+	for k := range policy.Add {
+		keys.Insert(k)
+	}
+	keys.Insert(policy.Remove...)
+	return keys
+}
+
+// headersPolicyOverrides returns the sorted names of the headers that
+// both the route and service policies set, add or remove. Since Envoy
+// applies service level headers last, the service policy takes
+// precedence for these headers.
+func headersPolicyOverrides(route, service *HeadersPolicy) []string {
+	return headerPolicyKeys(route).Intersection(headerPolicyKeys(service)).List()
+}
+
 func headersPolicyRoute(policy *contour_api_v1.HeadersPolicy, allowHostRewrite bool, dynamicHeaders map[string]string) (*HeadersPolicy, error) {
 	if policy == nil {
 		return nil, nil
@@ -150,6 +179,9 @@ func headersPolicyRoute(policy *contour_api_v1.HeadersPolicy, allowHostRewrite b
 		if _, ok := set[key]; ok {
 			return nil, fmt.Errorf("duplicate header addition: %q", key)
 		}
+		if err := config.ValidateHeaderValue(entry.Value); err != nil {
+			return nil, fmt.Errorf("invalid set header %q value: %v", key, err)
+		}
 		if key == "Host" {
 			if !allowHostRewrite {
 				return nil, fmt.Errorf("rewriting %q header is not supported", key)
@@ -176,6 +208,10 @@ func headersPolicyRoute(policy *contour_api_v1.HeadersPolicy, allowHostRewrite b
 	}
 	rl := remove.List()
 
+	if err := headersSetAndRemoved(rl, hostRewrite, set); err != nil {
+		return nil, err
+	}
+
 	if len(set) == 0 {
 		set = nil
 	}
@@ -190,9 +226,83 @@ func headersPolicyRoute(policy *contour_api_v1.HeadersPolicy, allowHostRewrite b
 	}, nil
 }
 
+// headersSetAndRemoved returns an error for the first of the removed
+// headers that is also set or added, or rewritten if it is the Host
+// header. Envoy would apply both, so which one takes effect would
+// depend on the order Envoy applies them in, rather than the policy.
+func headersSetAndRemoved(remove []string, hostRewrite string, set ...map[string]string) error {
+	for _, key := range remove {
+		if key == "Host" && hostRewrite != "" {
+			return fmt.Errorf("header %q is both set and removed", key)
+		}
+		for _, s := range set {
+			if _, ok := s[key]; ok {
+				return fmt.Errorf("header %q is both set and removed", key)
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultProtectedHeaders are the request headers that HTTPProxy and
+// HTTPRoute header policies can't set or remove, since Envoy and backends rely
+// on them to describe the client and the request. A trailing "*"
+// matches any header with that prefix.
+var DefaultProtectedHeaders = []string{
+	"X-Envoy-*",
+	"X-Forwarded-Client-Cert",
+	"X-Forwarded-For",
+	"X-Forwarded-Proto",
+}
+
+// headerMatchesAny returns true if key matches one of the header
+// name patterns. A pattern with a trailing "*" matches any header
+// with that prefix. Header names are compared case-insensitively.
+func headerMatchesAny(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+			continue
+		}
+		if key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// protectedHeadersValid returns an error if any of the header names is
+// one of the DefaultProtectedHeaders and is not permitted by allowed.
+func protectedHeadersValid(allowed []string, names ...string) error {
+	for _, name := range names {
+		key := http.CanonicalHeaderKey(name)
+		if headerMatchesAny(key, DefaultProtectedHeaders) && !headerMatchesAny(key, allowed) {
+			return fmt.Errorf("rewriting %q header is not allowed", key)
+		}
+	}
+	return nil
+}
+
+// headerPolicyNames returns the names of the headers set or removed by policy.
+func headerPolicyNames(policy *contour_api_v1.HeadersPolicy) []string {
+	if policy == nil {
+		return nil
+	}
+	names := make([]string, 0, len(policy.Set)+len(policy.Remove))
+	for _, h := range policy.Set {
+		names = append(names, h.Name)
+	}
+	return append(names, policy.Remove...)
+}
+
 // headersPolicyGatewayAPI builds a *HeaderPolicy for the supplied HTTPRequestHeaderFilter.
+// Protected headers that are not in allowed are reported as errors.
 // TODO: Take care about the order of operators once https://github.com/kubernetes-sigs/gateway-api/issues/480 was solved.
-func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter) (*HeadersPolicy, error) {
+func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter, allowed []string) (*HeadersPolicy, error) {
 	set, add := make(map[string]string, len(hf.Set)), make(map[string]string, len(hf.Add))
 	hostRewrite := ""
 	errlist := []error{}
@@ -202,6 +312,10 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter) (*
 			errlist = append(errlist, fmt.Errorf("duplicate header addition: %q", key))
 			continue
 		}
+		if err := config.ValidateHeaderValue(v); err != nil {
+			errlist = append(errlist, fmt.Errorf("invalid set header %q value: %v", key, err))
+			continue
+		}
 		if key == "Host" {
 			hostRewrite = v
 			continue
@@ -210,6 +324,10 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter) (*
 			errlist = append(errlist, fmt.Errorf("invalid set header %q: %v", key, msgs))
 			continue
 		}
+		if err := protectedHeadersValid(allowed, key); err != nil {
+			errlist = append(errlist, err)
+			continue
+		}
 		set[key] = escapeHeaderValue(v, nil)
 	}
 	for k, v := range hf.Add {
@@ -218,6 +336,10 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter) (*
 			errlist = append(errlist, fmt.Errorf("duplicate header addition: %q", key))
 			continue
 		}
+		if err := config.ValidateHeaderValue(v); err != nil {
+			errlist = append(errlist, fmt.Errorf("invalid add header %q value: %v", key, err))
+			continue
+		}
 		if key == "Host" {
 			hostRewrite = v
 			continue
@@ -226,6 +348,10 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter) (*
 			errlist = append(errlist, fmt.Errorf("invalid add header %q: %v", key, msgs))
 			continue
 		}
+		if err := protectedHeadersValid(allowed, key); err != nil {
+			errlist = append(errlist, err)
+			continue
+		}
 		add[key] = escapeHeaderValue(v, nil)
 	}
 
@@ -240,10 +366,18 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter) (*
 			errlist = append(errlist, fmt.Errorf("invalid remove header %q: %v", key, msgs))
 			continue
 		}
+		if err := protectedHeadersValid(allowed, key); err != nil {
+			errlist = append(errlist, err)
+			continue
+		}
 		remove.Insert(key)
 	}
 	rl := remove.List()
 
+	if err := headersSetAndRemoved(rl, hostRewrite, set, add); err != nil {
+		errlist = append(errlist, err)
+	}
+
 	if len(set) == 0 {
 		set = nil
 	}
@@ -259,6 +393,33 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha1.HTTPRequestHeaderFilter) (*
 	}, utilerrors.NewAggregate(errlist)
 }
 
+// GlobalHeadersPolicy returns a copy of policy, with canonical header
+// names and escaped values, that can be applied to all routes. Since
+// the CONTOUR_NAMESPACE, CONTOUR_SERVICE_NAME and CONTOUR_SERVICE_PORT
+// variables can only be expanded for HTTPProxy services, headers whose
+// values refer to them are left out, as is the Host header.
+func GlobalHeadersPolicy(policy *HeadersPolicy) *HeadersPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	global := &HeadersPolicy{}
+	for k, v := range policy.Set {
+		key := http.CanonicalHeaderKey(k)
+		if key == "Host" || strings.Contains(v, "%CONTOUR_") {
+			continue
+		}
+		if global.Set == nil {
+			global.Set = make(map[string]string, len(policy.Set))
+		}
+		global.Set[key] = escapeHeaderValue(v, nil)
+	}
+	for _, k := range policy.Remove {
+		global.Remove = append(global.Remove, http.CanonicalHeaderKey(k))
+	}
+	return global
+}
+
 func escapeHeaderValue(value string, dynamicHeaders map[string]string) string {
 	// Envoy supports %-encoded variables, so literal %'s in the header's value must be escaped.  See:
 	// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_conn_man/headers#custom-request-response-headers
@@ -330,7 +491,22 @@ func ingressRetryPolicy(ingress *networking_v1.Ingress, log logrus.FieldLogger)
 	return rp
 }
 
-func ingressTimeoutPolicy(ingress *networking_v1.Ingress, log logrus.FieldLogger) TimeoutPolicy {
+// ingressTimeoutPolicy returns the timeout policy for the supplied
+// Ingress path. A timeout for the path in the path-response-timeouts
+// annotation takes precedence over the Ingress-wide response-timeout
+// annotation.
+func ingressTimeoutPolicy(ingress *networking_v1.Ingress, path string, log logrus.FieldLogger) TimeoutPolicy {
+	if response, ok := annotation.PathResponseTimeouts(ingress)[path]; ok {
+		tp, err := timeoutPolicy(&contour_api_v1.TimeoutPolicy{
+			Response: response,
+		})
+		if err == nil {
+			return tp
+		}
+		log.WithError(err).WithField("path", path).
+			Error("Error parsing path-response-timeouts annotation, using the Ingress response timeout")
+	}
+
 	response := annotation.ContourAnnotation(ingress, "response-timeout")
 	if len(response) == 0 {
 		// Note: due to a misunderstanding the name of the annotation is
@@ -447,6 +623,59 @@ func prefixReplacementsAreValid(replacements []contour_api_v1.ReplacePrefix) (st
 	return "", nil
 }
 
+// rbacPolicy validates the given RBACPolicy, and returns the
+// methods and header values that the requests of a route must
+// have. The methods are uppercased and deduplicated.
+func rbacPolicy(in *contour_api_v1.RBACPolicy) (*RBACPolicy, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	if len(in.Methods) == 0 && len(in.Headers) == 0 {
+		return nil, errors.New("at least one method or header must be specified")
+	}
+
+	rp := &RBACPolicy{}
+
+	methods := sets.NewString()
+	for _, m := range in.Methods {
+		method := strings.ToUpper(m)
+		if !validMethod.MatchString(method) {
+			return nil, fmt.Errorf("invalid method %q", m)
+		}
+		if methods.Has(method) {
+			continue
+		}
+		methods.Insert(method)
+		rp.Methods = append(rp.Methods, method)
+	}
+
+	names := sets.NewString()
+	for _, h := range in.Headers {
+		key := http.CanonicalHeaderKey(h.Name)
+		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
+			return nil, fmt.Errorf("invalid header name %q: %v", key, msgs)
+		}
+		if names.Has(key) {
+			return nil, fmt.Errorf("duplicate header %q", key)
+		}
+		if err := config.ValidateHeaderValue(h.Value); err != nil {
+			return nil, fmt.Errorf("invalid header %q value: %v", key, err)
+		}
+		names.Insert(key)
+		rp.Headers = append(rp.Headers, HeaderMatchCondition{
+			Name:      key,
+			Value:     h.Value,
+			MatchType: HeaderMatchTypeExact,
+		})
+	}
+
+	return rp, nil
+}
+
+// validMethod matches the names of HTTP methods.
+var validMethod = regexp.MustCompile(`^[A-Z]+$`)
+
 func rateLimitPolicy(in *contour_api_v1.RateLimitPolicy) (*RateLimitPolicy, error) {
 	if in == nil || (in.Local == nil && in.Global == nil) {
 		return nil, nil
@@ -555,6 +784,15 @@ func globalRateLimitPolicy(in *contour_api_v1.GlobalRateLimitPolicy) (*GlobalRat
 			if entry.RequestHeaderValueMatch != nil {
 				set++
 
+				for _, h := range entry.RequestHeaderValueMatch.Headers {
+					if h.Regex == "" {
+						continue
+					}
+					if err := ValidateRegex(h.Regex); err != nil {
+						return nil, fmt.Errorf("invalid regex for header %q: %w", h.Name, err)
+					}
+				}
+
 				rld.Entries = append(rld.Entries, RateLimitDescriptorEntry{
 					HeaderValueMatch: &HeaderValueMatchDescriptorEntry{
 						Headers:     headerMatchConditions(entry.RequestHeaderValueMatch.Headers),
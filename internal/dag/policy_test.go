@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapi_v1alpha1 "sigs.k8s.io/gateway-api/apis/v1alpha1"
 )
 
 func TestRetryPolicyIngress(t *testing.T) {
@@ -117,8 +118,9 @@ func TestRetryPolicyIngress(t *testing.T) {
 
 func TestRetryPolicy(t *testing.T) {
 	tests := map[string]struct {
-		rp   *contour_api_v1.RetryPolicy
-		want *RetryPolicy
+		rp      *contour_api_v1.RetryPolicy
+		want    *RetryPolicy
+		wantErr bool
 	}{
 		"nil retry policy": {
 			rp:   nil,
@@ -160,6 +162,22 @@ func TestRetryPolicy(t *testing.T) {
 				PerTryTimeout: timeout.DefaultSetting(),
 			},
 		},
+		"infinite per try timeout": {
+			rp: &contour_api_v1.RetryPolicy{
+				PerTryTimeout: "infinity",
+			},
+			want: &RetryPolicy{
+				RetryOn:       "5xx",
+				NumRetries:    1,
+				PerTryTimeout: timeout.DisabledSetting(),
+			},
+		},
+		"invalid per try timeout": {
+			rp: &contour_api_v1.RetryPolicy{
+				PerTryTimeout: "10",
+			},
+			wantErr: true,
+		},
 		"retry on": {
 			rp: &contour_api_v1.RetryPolicy{
 				RetryOn: []contour_api_v1.RetryOn{"gateway-error", "connect-failure"},
@@ -183,7 +201,12 @@ func TestRetryPolicy(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := retryPolicy(tc.rp)
+			got, gotErr := retryPolicy(tc.rp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.NoError(t, gotErr)
+			}
 			assert.Equal(t, tc.want, got)
 		})
 	}
@@ -249,6 +272,77 @@ func TestTimeoutPolicy(t *testing.T) {
 	}
 }
 
+func TestIngressTimeoutPolicy(t *testing.T) {
+	ingress := func(annotations map[string]string) *networking_v1.Ingress {
+		return &networking_v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: annotations,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		i    *networking_v1.Ingress
+		path string
+		want TimeoutPolicy
+	}{
+		"no annotations": {
+			i:    ingress(nil),
+			path: "/",
+			want: TimeoutPolicy{
+				ResponseTimeout: timeout.DefaultSetting(),
+				IdleTimeout:     timeout.DefaultSetting(),
+			},
+		},
+		"ingress response timeout": {
+			i: ingress(map[string]string{
+				"projectcontour.io/response-timeout": "10s",
+			}),
+			path: "/",
+			want: TimeoutPolicy{
+				ResponseTimeout: timeout.DurationSetting(10 * time.Second),
+			},
+		},
+		"path response timeout overrides ingress response timeout": {
+			i: ingress(map[string]string{
+				"projectcontour.io/response-timeout":       "10s",
+				"projectcontour.io/path-response-timeouts": "/api=1m,/upload=infinity",
+			}),
+			path: "/upload",
+			want: TimeoutPolicy{
+				ResponseTimeout: timeout.DisabledSetting(),
+			},
+		},
+		"path without a response timeout uses ingress response timeout": {
+			i: ingress(map[string]string{
+				"projectcontour.io/response-timeout":       "10s",
+				"projectcontour.io/path-response-timeouts": "/api=1m",
+			}),
+			path: "/",
+			want: TimeoutPolicy{
+				ResponseTimeout: timeout.DurationSetting(10 * time.Second),
+			},
+		},
+		"invalid path response timeout uses ingress response timeout": {
+			i: ingress(map[string]string{
+				"projectcontour.io/request-timeout":        "10s",
+				"projectcontour.io/path-response-timeouts": "/api=peanut",
+			}),
+			path: "/api",
+			want: TimeoutPolicy{
+				ResponseTimeout: timeout.DurationSetting(10 * time.Second),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ingressTimeoutPolicy(tc.i, tc.path, &logrus.Logger{Out: ioutil.Discard})
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestLoadBalancerPolicy(t *testing.T) {
 	tests := map[string]struct {
 		lbp  *contour_api_v1.LoadBalancerPolicy
@@ -306,6 +400,7 @@ func TestHeadersPolicy(t *testing.T) {
 	tests := map[string]struct {
 		hp      *contour_api_v1.HeadersPolicy
 		dhp     HeadersPolicy
+		rhp     *HeadersPolicy
 		want    HeadersPolicy
 		wantErr bool
 	}{
@@ -469,6 +564,141 @@ func TestHeadersPolicy(t *testing.T) {
 				Remove: []string{"X-Sensitive-Header"},
 			},
 		},
+		"default header value not applied over route header": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "X-Service",
+					Value: "s1",
+				}},
+			},
+			dhp: HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "10",
+					"X-Tenant":     "default",
+				},
+				Remove: []string{"X-Sensitive-Header"},
+			},
+			rhp: &HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "100",
+				},
+				Remove: []string{"X-Tenant", "X-Sensitive-Header"},
+			},
+			want: HeadersPolicy{
+				Set: map[string]string{
+					"X-Service": "s1",
+				},
+			},
+		},
+		"object header value applied over route header": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "X-App-Weight",
+					Value: "50",
+				}},
+			},
+			dhp: HeadersPolicy{},
+			rhp: &HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "100",
+				},
+			},
+			want: HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "50",
+				},
+			},
+		},
+		"control character in header value": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "X-App-Weight",
+					Value: "100\r\nX-Injected: true",
+				}},
+			},
+			wantErr: true,
+		},
+		"control character in host rewrite": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "Host",
+					Value: "example.com\x00",
+				}},
+			},
+			wantErr: true,
+		},
+		"control character in default header value": {
+			dhp: HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "\x7f",
+				},
+			},
+			wantErr: true,
+		},
+		"horizontal tab in header value": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "X-App-Weight",
+					Value: "100\t200",
+				}},
+			},
+			dhp: HeadersPolicy{},
+			want: HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "100\t200",
+				},
+			},
+		},
+		"header both set and removed": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "X-App-Weight",
+					Value: "100",
+				}},
+				Remove: []string{"x-app-weight"},
+			},
+			wantErr: true,
+		},
+		"host both rewritten and removed": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "Host",
+					Value: "example.com",
+				}},
+				Remove: []string{"Host"},
+			},
+			wantErr: true,
+		},
+		"object removal takes precedence over default set": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Remove: []string{"X-App-Weight"},
+			},
+			dhp: HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "100",
+				},
+			},
+			want: HeadersPolicy{
+				Set:    map[string]string{},
+				Remove: []string{"X-App-Weight"},
+			},
+		},
+		"object set takes precedence over default removal": {
+			hp: &contour_api_v1.HeadersPolicy{
+				Set: []contour_api_v1.HeaderValue{{
+					Name:  "X-App-Weight",
+					Value: "100",
+				}},
+			},
+			dhp: HeadersPolicy{
+				Remove: []string{"X-App-Weight"},
+			},
+			want: HeadersPolicy{
+				Set: map[string]string{
+					"X-App-Weight": "100",
+				},
+			},
+		},
 		"default headers with nil object headers": {
 			hp: nil,
 			dhp: HeadersPolicy{
@@ -488,7 +718,7 @@ func TestHeadersPolicy(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got, gotErr := headersPolicyService(&tc.dhp, tc.hp, dynamicHeaders)
+			got, gotErr := headersPolicyService(&tc.dhp, tc.rhp, tc.hp, dynamicHeaders)
 			if tc.wantErr {
 				assert.Error(t, gotErr)
 			} else {
@@ -499,6 +729,64 @@ func TestHeadersPolicy(t *testing.T) {
 	}
 }
 
+func TestHeadersPolicyOverrides(t *testing.T) {
+	route := &HeadersPolicy{
+		Set:    map[string]string{"X-App-Weight": "100", "X-Route": "r1"},
+		Add:    map[string]string{"X-Trace": "on"},
+		Remove: []string{"X-Internal"},
+	}
+	service := &HeadersPolicy{
+		Set:    map[string]string{"X-App-Weight": "50", "X-Internal": "yes"},
+		Remove: []string{"X-Trace"},
+	}
+
+	assert.Equal(t, []string{"X-App-Weight", "X-Internal", "X-Trace"}, headersPolicyOverrides(route, service))
+	assert.Empty(t, headersPolicyOverrides(route, nil))
+	assert.Empty(t, headersPolicyOverrides(nil, service))
+}
+
+func TestGlobalHeadersPolicy(t *testing.T) {
+	assert.Nil(t, GlobalHeadersPolicy(nil))
+	assert.Equal(t, &HeadersPolicy{
+		Set: map[string]string{
+			"X-Envoy-Hostname": "%HOSTNAME%",
+			"X-Weight":         "100%%",
+		},
+		Remove: []string{"Server"},
+	}, GlobalHeadersPolicy(&HeadersPolicy{
+		Set: map[string]string{
+			"x-envoy-hostname": "%HOSTNAME%",
+			"X-Weight":         "100%",
+			"Host":             "example.com",
+			"l5d-dst-override": "%CONTOUR_SERVICE_NAME%.%CONTOUR_NAMESPACE%.svc.cluster.local:%CONTOUR_SERVICE_PORT%",
+		},
+		Remove: []string{"server"},
+	}))
+}
+
+func TestProtectedHeadersValid(t *testing.T) {
+	assert.NoError(t, protectedHeadersValid(nil, "X-App-Weight", "Host"))
+	assert.EqualError(t, protectedHeadersValid(nil, "x-forwarded-for"), `rewriting "X-Forwarded-For" header is not allowed`)
+	assert.EqualError(t, protectedHeadersValid(nil, "X-Envoy-Upstream-Rq-Timeout-Ms"), `rewriting "X-Envoy-Upstream-Rq-Timeout-Ms" header is not allowed`)
+	assert.NoError(t, protectedHeadersValid([]string{"x-forwarded-for"}, "X-Forwarded-For"))
+	assert.NoError(t, protectedHeadersValid([]string{"X-Envoy-*"}, "X-Envoy-Upstream-Rq-Timeout-Ms"))
+	assert.Error(t, protectedHeadersValid([]string{"X-Envoy-Retry-*"}, "X-Envoy-Upstream-Rq-Timeout-Ms"))
+
+	// Protected headers can't be removed either.
+	assert.Equal(t, []string{"X-App-Weight", "x-forwarded-for"}, headerPolicyNames(&contour_api_v1.HeadersPolicy{
+		Set:    []contour_api_v1.HeaderValue{{Name: "X-App-Weight", Value: "1"}},
+		Remove: []string{"x-forwarded-for"},
+	}))
+	_, err := headersPolicyGatewayAPI(&gatewayapi_v1alpha1.HTTPRequestHeaderFilter{
+		Remove: []string{"x-forwarded-for"},
+	}, nil)
+	assert.EqualError(t, err, `rewriting "X-Forwarded-For" header is not allowed`)
+	_, err = headersPolicyGatewayAPI(&gatewayapi_v1alpha1.HTTPRequestHeaderFilter{
+		Remove: []string{"x-forwarded-for"},
+	}, []string{"X-Forwarded-For"})
+	assert.NoError(t, err)
+}
+
 func TestRateLimitPolicy(t *testing.T) {
 	tests := map[string]struct {
 		in      *contour_api_v1.RateLimitPolicy
@@ -849,3 +1137,68 @@ func TestRateLimitPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestRBACPolicy(t *testing.T) {
+	tests := map[string]struct {
+		in      *contour_api_v1.RBACPolicy
+		want    *RBACPolicy
+		wantErr string
+	}{
+		"nil input": {
+			in:   nil,
+			want: nil,
+		},
+		"empty policy": {
+			in:      &contour_api_v1.RBACPolicy{},
+			wantErr: "at least one method or header must be specified",
+		},
+		"methods and headers": {
+			in: &contour_api_v1.RBACPolicy{
+				Methods: []string{"get", "HEAD", "GET"},
+				Headers: []contour_api_v1.HeaderValue{{Name: "x-internal", Value: "true"}},
+			},
+			want: &RBACPolicy{
+				Methods: []string{"GET", "HEAD"},
+				Headers: []HeaderMatchCondition{{
+					Name:      "X-Internal",
+					Value:     "true",
+					MatchType: HeaderMatchTypeExact,
+				}},
+			},
+		},
+		"invalid method": {
+			in: &contour_api_v1.RBACPolicy{
+				Methods: []string{"GET /"},
+			},
+			wantErr: `invalid method "GET /"`,
+		},
+		"duplicate header": {
+			in: &contour_api_v1.RBACPolicy{
+				Headers: []contour_api_v1.HeaderValue{
+					{Name: "x-internal", Value: "true"},
+					{Name: "X-Internal", Value: "yes"},
+				},
+			},
+			wantErr: `duplicate header "X-Internal"`,
+		},
+		"invalid header name": {
+			in: &contour_api_v1.RBACPolicy{
+				Headers: []contour_api_v1.HeaderValue{{Name: ":authority", Value: "example.com"}},
+			},
+			wantErr: `invalid header name ":authority": [a valid HTTP header must consist of alphanumeric characters or '-' (e.g. 'X-Header-Name', regex used for validation is '[-A-Za-z0-9]+')]`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := rbacPolicy(tc.in)
+
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"fmt"
+	"strings"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/k8s"
+	v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// quarantineKey identifies a quarantined object.
+type quarantineKey struct {
+	kind string
+	name types.NamespacedName
+}
+
+// quarantineEntry records why an object was quarantined, and the
+// generation of the object at that time.
+type quarantineEntry struct {
+	generation int64
+	reason     string
+}
+
+// Quarantine correlates the error message of a configuration update
+// that Envoy rejected (NACKed) with the objects in the cache whose
+// regular expressions the message quotes in full. Those objects are
+// excluded from subsequent DAG builds until their generation changes,
+// so that the rest of the configuration can be accepted.
+// Quarantine returns true if any object was newly quarantined.
+func (kc *KubernetesCache) Quarantine(typeURL, message string) bool {
+	kc.initialize.Do(kc.init)
+
+	reason := fmt.Sprintf("Envoy rejected the %s configuration generated from this object: %s", typeURL, message)
+
+	changed := false
+	quarantine := func(obj metav1.Object, regexes []string) {
+		if !mentionsAny(message, regexes) {
+			return
+		}
+		key := quarantineKey{kind: k8s.KindOf(obj), name: k8s.NamespacedNameOf(obj)}
+		if entry, ok := kc.quarantined[key]; ok && entry.generation == obj.GetGeneration() {
+			return
+		}
+
+		kc.WithField("name", obj.GetName()).
+			WithField("namespace", obj.GetNamespace()).
+			WithField("kind", key.kind).
+			WithField("type_url", typeURL).
+			Error("quarantining object rejected by Envoy")
+
+		kc.quarantined[key] = quarantineEntry{generation: obj.GetGeneration(), reason: reason}
+		changed = true
+	}
+
+	for _, proxy := range kc.httpproxies {
+		quarantine(proxy, httpProxyRegexes(proxy))
+	}
+	for _, ingress := range kc.ingresses {
+		quarantine(ingress, ingressRegexes(ingress))
+	}
+
+	return changed
+}
+
+// quarantineReason returns the reason obj is quarantined and true,
+// or false if obj is not quarantined. Changing an object lifts its
+// quarantine.
+func (kc *KubernetesCache) quarantineReason(obj metav1.Object) (string, bool) {
+	key := quarantineKey{kind: k8s.KindOf(obj), name: k8s.NamespacedNameOf(obj)}
+
+	entry, ok := kc.quarantined[key]
+	if !ok {
+		return "", false
+	}
+	if entry.generation != obj.GetGeneration() {
+		delete(kc.quarantined, key)
+		return "", false
+	}
+
+	return entry.reason, true
+}
+
+// secretQuarantineEntry records the version of a Secret that was
+// rejected as invalid, and why.
+type secretQuarantineEntry struct {
+	version string
+	reason  string
+}
+
+// quarantineSecret records that the given version of a Secret is
+// invalid, if a previous valid version of it is cached. That version
+// keeps being served until a valid version arrives, rather than the
+// virtual hosts referring to the Secret breaking. quarantineSecret
+// returns true if the DAG should be rebuilt to report the quarantine.
+func (kc *KubernetesCache) quarantineSecret(secret *v1.Secret, err error) bool {
+	name := k8s.NamespacedNameOf(secret)
+
+	last, ok := kc.secrets[name]
+	if !ok {
+		return false
+	}
+
+	kc.WithField("name", secret.GetName()).
+		WithField("namespace", secret.GetNamespace()).
+		WithField("kind", "Secret").
+		WithField("version", k8s.VersionOf(secret)).
+		WithField("served_version", last.GetResourceVersion()).
+		Warn("quarantining invalid Secret version, serving the last valid version")
+
+	kc.quarantinedSecrets[name] = secretQuarantineEntry{
+		version: secret.GetResourceVersion(),
+		reason: fmt.Sprintf("version %q is invalid: %s; serving the last valid version %q",
+			secret.GetResourceVersion(), err, last.GetResourceVersion()),
+	}
+	kc.setQuarantinedSecrets()
+
+	return kc.secretTriggersRebuild(last)
+}
+
+// quarantinesSecret returns true if obj is a version of a Secret
+// that is quarantined when it replaces a valid version.
+func quarantinesSecret(obj interface{}) bool {
+	secret, ok := obj.(*v1.Secret)
+	if !ok {
+		return false
+	}
+	valid, err := isValidSecret(secret)
+	return !valid && err != nil
+}
+
+// liftQuarantine removes a deleted object from the quarantine
+// lists, if present. Updated objects stay quarantined until their
+// generation changes, so that writing the status that reports the
+// quarantine doesn't lift it.
+func (kc *KubernetesCache) liftQuarantine(obj interface{}) {
+	switch obj := obj.(type) {
+	case *v1.Secret:
+		kc.liftSecretQuarantine(k8s.NamespacedNameOf(obj))
+	case *networking_v1.Ingress, *contour_api_v1.HTTPProxy:
+		o := obj.(metav1.Object)
+		delete(kc.quarantined, quarantineKey{kind: k8s.KindOf(o), name: k8s.NamespacedNameOf(o)})
+	}
+}
+
+// liftSecretQuarantine removes the Secret from the quarantine
+// list, if present.
+func (kc *KubernetesCache) liftSecretQuarantine(name types.NamespacedName) {
+	if _, ok := kc.quarantinedSecrets[name]; !ok {
+		return
+	}
+	delete(kc.quarantinedSecrets, name)
+	kc.setQuarantinedSecrets()
+}
+
+func (kc *KubernetesCache) setQuarantinedSecrets() {
+	if kc.QuarantinedSecrets != nil {
+		kc.QuarantinedSecrets.Set(float64(len(kc.quarantinedSecrets)))
+	}
+}
+
+// secretQuarantineReason returns why the named Secret is served at
+// its last valid version and true, or false if it is not quarantined.
+func (kc *KubernetesCache) secretQuarantineReason(name types.NamespacedName) (string, bool) {
+	entry, ok := kc.quarantinedSecrets[name]
+	return entry.reason, ok
+}
+
+// mentionsAny returns true if message quotes any of the non-empty
+// candidates in full. Envoy quotes the offending regular expression
+// in single quotes, so a candidate that is merely a substring of the
+// rejected expression does not match.
+func mentionsAny(message string, candidates []string) bool {
+	for _, c := range candidates {
+		if c != "" && strings.Contains(message, "'"+c+"'") {
+			return true
+		}
+	}
+	return false
+}
+
+// httpProxyRegexes returns the regular expressions of the header
+// match conditions of an HTTPProxy's routes and includes.
+func httpProxyRegexes(proxy *contour_api_v1.HTTPProxy) []string {
+	var regexes []string
+
+	add := func(conds []contour_api_v1.MatchCondition) {
+		for _, cond := range conds {
+			if cond.Header != nil {
+				regexes = append(regexes, cond.Header.Regex)
+			}
+		}
+	}
+
+	for _, route := range proxy.Spec.Routes {
+		add(route.Conditions)
+	}
+	for _, include := range proxy.Spec.Includes {
+		add(include.Conditions)
+	}
+
+	return regexes
+}
+
+// ingressRegexes returns the paths of an Ingress that are
+// treated as regular expressions.
+func ingressRegexes(ingress *networking_v1.Ingress) []string {
+	var regexes []string
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			pathType := derefPathTypeOr(path.PathType, networking_v1.PathTypeImplementationSpecific)
+			if pathType == networking_v1.PathTypeImplementationSpecific && strings.ContainsAny(path.Path, "^+*[]%") {
+				regexes = append(regexes, path.Path)
+			}
+		}
+	}
+
+	return regexes
+}
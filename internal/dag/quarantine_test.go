@@ -0,0 +1,296 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestQuarantine(t *testing.T) {
+	const rejection = "Invalid regex '^v[0-9]{1000}$': pattern too large - compile failed"
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := func(name, regex string) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: name + ".example.com",
+				},
+				Routes: []contour_api_v1.Route{{
+					Conditions: []contour_api_v1.MatchCondition{{
+						Header: &contour_api_v1.HeaderMatchCondition{
+							Name:  "x-version",
+							Regex: regex,
+						},
+					}},
+					Services: []contour_api_v1.Service{{
+						Name: svc.Name,
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	rejected := proxy("rejected", "^v[0-9]{1000}$")
+	accepted := proxy("accepted", "^v[0-9]+$")
+	// A regex that is a substring of the rejected one is not correlated.
+	substring := proxy("substring", "v[0-9]")
+
+	ingress := &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "default",
+		},
+		Spec: networking_v1.IngressSpec{
+			Rules: []networking_v1.IngressRule{{
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{
+						Paths: []networking_v1.HTTPIngressPath{{
+							Path:    "/v[0-9]+/api",
+							Backend: *backendv1(svc.Name, intstr.FromInt(8080)),
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&IngressProcessor{
+				FieldLogger: fixture.NewTestLogger(t),
+			},
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+	for _, o := range []interface{}{svc, rejected, accepted, substring, ingress} {
+		builder.Source.Insert(o)
+	}
+
+	// A rejection that does not mention any object quarantines nothing.
+	assert.False(t, builder.Source.Quarantine("type.googleapis.com/envoy.config.route.v3.RouteConfiguration", "unrelated failure"))
+
+	// The first rejection quarantines the object, repeated rejections do not.
+	assert.True(t, builder.Source.Quarantine("type.googleapis.com/envoy.config.route.v3.RouteConfiguration", rejection))
+	assert.False(t, builder.Source.Quarantine("type.googleapis.com/envoy.config.route.v3.RouteConfiguration", rejection))
+
+	dag := builder.Build()
+
+	got := make(map[types.NamespacedName]contour_api_v1.DetailedCondition)
+	for _, pu := range dag.StatusCache.GetProxyUpdates() {
+		got[pu.Fullname] = *pu.Conditions[status.ValidCondition]
+	}
+	assert.Equal(t, map[types.NamespacedName]contour_api_v1.DetailedCondition{
+		{Name: "accepted", Namespace: "default"}:  fixture.NewValidCondition().Valid(),
+		{Name: "substring", Namespace: "default"}: fixture.NewValidCondition().Valid(),
+		{Name: "rejected", Namespace: "default"}: fixture.NewValidCondition().
+			WithError(contour_api_v1.ConditionTypeSpecError, "EnvoyRejected",
+				"Envoy rejected the type.googleapis.com/envoy.config.route.v3.RouteConfiguration configuration generated from this object: "+rejection),
+	}, got)
+
+	// Writing the status of the rejected object keeps it quarantined.
+	reported := rejected.DeepCopy()
+	reported.Status.CurrentStatus = "invalid"
+	assert.False(t, builder.Source.Update(rejected, reported))
+	_, ok := builder.Source.quarantineReason(reported)
+	assert.True(t, ok)
+
+	// Changing the rejected object lifts its quarantine.
+	updated := rejected.DeepCopy()
+	updated.Generation++
+	updated.Spec.Routes[0].Conditions[0].Header.Regex = "^v[0-9]{2}$"
+	require.True(t, builder.Source.Update(reported, updated))
+
+	reason, ok := builder.Source.quarantineReason(updated)
+	assert.False(t, ok)
+	assert.Empty(t, reason)
+
+	// Ingress paths that are treated as regular expressions are correlated.
+	assert.True(t, builder.Source.Quarantine("type.googleapis.com/envoy.config.route.v3.RouteConfiguration", "Invalid regex '/v[0-9]+/api'"))
+	_, ok = builder.Source.quarantineReason(ingress)
+	assert.True(t, ok)
+
+	// Deleting a quarantined object lifts its quarantine.
+	require.True(t, builder.Source.Remove(ingress))
+	builder.Source.Insert(ingress)
+	_, ok = builder.Source.quarantineReason(ingress)
+	assert.False(t, ok)
+}
+
+func TestQuarantineSecret(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxy",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: "secret",
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: svc.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	secret := func(version, cert, key string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "secret",
+				Namespace:       "default",
+				ResourceVersion: version,
+			},
+			Type: v1.SecretTypeTLS,
+			Data: secretdata(cert, key),
+		}
+	}
+
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "quarantined_secrets"})
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger:        fixture.NewTestLogger(t),
+			QuarantinedSecrets: gauge,
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+
+	// An invalid Secret with no valid version is not quarantined.
+	assert.False(t, builder.Source.Insert(secret("1", fixture.CERTIFICATE, fixture.EC_PRIVATE_KEY)))
+	_, ok := builder.Source.secretQuarantineReason(types.NamespacedName{Name: "secret", Namespace: "default"})
+	assert.False(t, ok)
+
+	valid := secret("2", fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY)
+	for _, o := range []interface{}{svc, proxy, valid} {
+		builder.Source.Insert(o)
+	}
+
+	// An invalid update of a referenced Secret is quarantined, and
+	// the last valid version is served with a warning.
+	assert.True(t, builder.Source.Insert(secret("3", fixture.CERTIFICATE, fixture.EC_PRIVATE_KEY)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(gauge))
+
+	dag := builder.Build()
+
+	svh := dag.GetSecureVirtualHost(ListenerName{Name: "example.com", ListenerName: "ingress_https"})
+	require.NotNil(t, svh)
+	assert.Equal(t, valid, svh.Secret.Object)
+
+	got := make(map[types.NamespacedName]contour_api_v1.DetailedCondition)
+	for _, pu := range dag.StatusCache.GetProxyUpdates() {
+		got[pu.Fullname] = *pu.Conditions[status.ValidCondition]
+	}
+	assert.Equal(t, map[types.NamespacedName]contour_api_v1.DetailedCondition{
+		{Name: "proxy", Namespace: "default"}: fixture.NewValidCondition().
+			ValidWithWarning(contour_api_v1.ConditionTypeTLSError, "SecretQuarantined",
+				`Spec.VirtualHost.TLS Secret "secret" version "3" is invalid: invalid TLS key pair: tls: private key type does not match public key type; serving the last valid version "2"`),
+	}, got)
+
+	// A valid update lifts the quarantine.
+	assert.True(t, builder.Source.Insert(secret("4", fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY)))
+	_, ok = builder.Source.secretQuarantineReason(types.NamespacedName{Name: "secret", Namespace: "default"})
+	assert.False(t, ok)
+	assert.Equal(t, float64(0), testutil.ToFloat64(gauge))
+
+	// Deleting the Secret lifts the quarantine.
+	builder.Source.Insert(secret("5", fixture.CERTIFICATE, fixture.EC_PRIVATE_KEY))
+	assert.Equal(t, float64(1), testutil.ToFloat64(gauge))
+	assert.True(t, builder.Source.Remove(valid))
+	assert.Equal(t, float64(0), testutil.ToFloat64(gauge))
+
+	// An invalid update keeps the last valid version cached.
+	valid = secret("6", fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY)
+	builder.Source.Insert(valid)
+	invalid := secret("7", fixture.CERTIFICATE, fixture.EC_PRIVATE_KEY)
+	assert.True(t, builder.Source.Update(valid, invalid))
+	assert.Equal(t, float64(1), testutil.ToFloat64(gauge))
+
+	dag = builder.Build()
+	svh = dag.GetSecureVirtualHost(ListenerName{Name: "example.com", ListenerName: "ingress_https"})
+	require.NotNil(t, svh)
+	assert.Equal(t, valid, svh.Secret.Object)
+
+	// So does a further invalid update.
+	builder.Source.Update(invalid, secret("8", fixture.CERTIFICATE, fixture.EC_PRIVATE_KEY))
+	reason, ok := builder.Source.secretQuarantineReason(types.NamespacedName{Name: "secret", Namespace: "default"})
+	assert.True(t, ok)
+	assert.Contains(t, reason, `serving the last valid version "6"`)
+
+	// A valid update replaces the cached version and lifts the quarantine.
+	fixed := secret("9", fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY)
+	assert.True(t, builder.Source.Update(invalid, fixed))
+	assert.Equal(t, float64(0), testutil.ToFloat64(gauge))
+
+	dag = builder.Build()
+	svh = dag.GetSecureVirtualHost(ListenerName{Name: "example.com", ListenerName: "ingress_https"})
+	require.NotNil(t, svh)
+	assert.Equal(t, fixed, svh.Secret.Object)
+}
@@ -15,6 +15,7 @@ package dag
 
 import (
 	"bytes"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -27,6 +28,18 @@ import (
 // CACertificateKey is the key name for accessing TLS CA certificate bundles in Kubernetes Secrets.
 const CACertificateKey = "ca.crt"
 
+// OIDCClientIDKey, OIDCClientSecretKey and OIDCHMACSecretKey are the key
+// names for accessing OpenID Connect client credentials in Kubernetes Secrets.
+const (
+	OIDCClientIDKey     = "client-id"
+	OIDCClientSecretKey = "client-secret"
+	OIDCHMACSecretKey   = "hmac-secret"
+)
+
+// OIDCMinHMACSecretLength is the minimum length of the HMAC secret
+// that signs OpenID Connect session cookies.
+const OIDCMinHMACSecretLength = 32
+
 // isValidSecret returns true if the secret is interesting and well
 // formed. TLS certificate/key pairs must be secrets of type
 // "kubernetes.io/tls". Certificate bundles may be "kubernetes.io/tls"
@@ -53,7 +66,12 @@ func isValidSecret(secret *v1.Secret) (bool, error) {
 			return false, fmt.Errorf("invalid TLS private key: %v", err)
 		}
 
-	// Generic secrets may have a 'ca.crt' only.
+		if _, err := tls.X509KeyPair(secret.Data[v1.TLSCertKey], data); err != nil {
+			return false, fmt.Errorf("invalid TLS key pair: %v", err)
+		}
+
+	// Generic secrets may have a 'ca.crt' only, or OpenID Connect
+	// client credentials.
 	case v1.SecretTypeOpaque, "":
 		if _, ok := secret.Data[v1.TLSCertKey]; ok {
 			return false, nil
@@ -63,6 +81,15 @@ func isValidSecret(secret *v1.Secret) (bool, error) {
 			return false, nil
 		}
 
+		// A Secret with a client secret is only interesting if it
+		// holds complete OpenID Connect client credentials.
+		if _, ok := secret.Data[OIDCClientSecretKey]; ok {
+			if err := validOIDCCredentials(secret); err != nil {
+				return false, fmt.Errorf("invalid OIDC client credentials: %v", err)
+			}
+			return true, nil
+		}
+
 		if data := secret.Data[CACertificateKey]; len(data) == 0 {
 			return false, nil
 		}
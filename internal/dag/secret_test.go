@@ -47,6 +47,12 @@ func TestIsValidSecret(t *testing.T) {
 			valid: true,
 			err:   nil,
 		},
+		"mismatched key pair": {
+			cert:  fixture.CERTIFICATE,
+			key:   fixture.EC_PRIVATE_KEY,
+			valid: false,
+			err:   errors.New("invalid TLS key pair: tls: private key type does not match public key type"),
+		},
 	}
 
 	for name, tc := range tests {
@@ -70,6 +76,76 @@ func TestIsValidSecret(t *testing.T) {
 	}
 }
 
+func TestIsValidOIDCSecret(t *testing.T) {
+	hmac := []byte("0123456789abcdef0123456789abcdef")
+
+	tests := map[string]struct {
+		data  map[string][]byte
+		valid bool
+		err   error
+	}{
+		"client credentials": {
+			data: map[string][]byte{
+				OIDCClientIDKey:     []byte("contour"),
+				OIDCClientSecretKey: []byte("s3cr3t"),
+			},
+			valid: true,
+		},
+		"client credentials with HMAC secret": {
+			data: map[string][]byte{
+				OIDCClientIDKey:     []byte("contour"),
+				OIDCClientSecretKey: []byte("s3cr3t"),
+				OIDCHMACSecretKey:   hmac,
+			},
+			valid: true,
+		},
+		"missing client ID": {
+			data: map[string][]byte{
+				OIDCClientSecretKey: []byte("s3cr3t"),
+			},
+			err: errors.New(`invalid OIDC client credentials: empty "client-id" key`),
+		},
+		"empty client secret": {
+			data: map[string][]byte{
+				OIDCClientIDKey:     []byte("contour"),
+				OIDCClientSecretKey: []byte(""),
+			},
+			err: errors.New(`invalid OIDC client credentials: empty "client-secret" key`),
+		},
+		"client secret with trailing newline": {
+			data: map[string][]byte{
+				OIDCClientIDKey:     []byte("contour"),
+				OIDCClientSecretKey: []byte("s3cr3t\n"),
+			},
+			err: errors.New(`invalid OIDC client credentials: "client-secret" key has leading or trailing whitespace`),
+		},
+		"short HMAC secret": {
+			data: map[string][]byte{
+				OIDCClientIDKey:     []byte("contour"),
+				OIDCClientSecretKey: []byte("s3cr3t"),
+				OIDCHMACSecretKey:   []byte("short"),
+			},
+			err: errors.New(`invalid OIDC client credentials: "hmac-secret" key must be at least 32 bytes`),
+		},
+		"client ID only": {
+			data: map[string][]byte{
+				OIDCClientIDKey: []byte("contour"),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			valid, err := isValidSecret(&v1.Secret{
+				Type: v1.SecretTypeOpaque,
+				Data: tc.data,
+			})
+			assert.Equal(t, tc.valid, valid)
+			assert.Equal(t, tc.err, err)
+		})
+	}
+}
+
 func secretdata(cert, key string) map[string][]byte {
 	return map[string][]byte{
 		v1.TLSCertKey:       []byte(cert),
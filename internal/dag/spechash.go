@@ -0,0 +1,90 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayapi_v1alpha1 "sigs.k8s.io/gateway-api/apis/v1alpha1"
+)
+
+// specKey identifies an object in the KubernetesCache.
+type specKey struct {
+	kind string
+	types.NamespacedName
+}
+
+// specHashOf returns the key of obj and a hash of the fields of obj
+// that are consumed when building the DAG. The status and the
+// bookkeeping metadata maintained by the API server are excluded,
+// as are the labels unless labels is true.
+// If obj can't be hashed, the returned hash is empty.
+func specHashOf(obj interface{}, labels bool) (specKey, string) {
+	o, ok := obj.(metav1.Object)
+	if !ok {
+		return specKey{}, ""
+	}
+
+	key := specKey{
+		kind:           fmt.Sprintf("%T", obj),
+		NamespacedName: types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()},
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return key, ""
+	}
+
+	unstructured.RemoveNestedField(u, "status")
+	unstructured.RemoveNestedField(u, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(u, "metadata", "managedFields")
+	if !labels {
+		unstructured.RemoveNestedField(u, "metadata", "labels")
+	}
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		return key, ""
+	}
+
+	sum := sha256.Sum256(data)
+	return key, hex.EncodeToString(sum[:])
+}
+
+// labelsConsumed returns true if the labels of obj are consumed
+// when building the DAG, either because obj can be selected by
+// label or because its labels are copied to the Envoy metadata.
+func (kc *KubernetesCache) labelsConsumed(obj interface{}) bool {
+	switch obj.(type) {
+	case *contour_api_v1.HTTPProxy:
+		return kc.HTTPProxyLabels
+	case *v1.Namespace,
+		*gatewayapi_v1alpha1.HTTPRoute,
+		*gatewayapi_v1alpha1.TLSRoute,
+		*gatewayapi_v1alpha1.TCPRoute,
+		*gatewayapi_v1alpha1.UDPRoute:
+		return true
+	default:
+		return false
+	}
+}
@@ -36,6 +36,7 @@ func TestDAGStatus(t *testing.T) {
 	type testcase struct {
 		objs                []interface{}
 		fallbackCertificate *types.NamespacedName
+		healthVirtualHost   string
 		want                map[types.NamespacedName]contour_api_v1.DetailedCondition
 	}
 
@@ -58,7 +59,10 @@ func TestDAGStatus(t *testing.T) {
 					&GatewayAPIProcessor{
 						FieldLogger: fixture.NewTestLogger(t),
 					},
-					&ListenerProcessor{},
+					&ListenerProcessor{
+						FieldLogger:       fixture.NewTestLogger(t),
+						HealthVirtualHost: tc.healthVirtualHost,
+					},
 				},
 			}
 			for _, o := range tc.objs {
@@ -549,6 +553,118 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	// proxySubdomainDelegation delegates the subdomains of team-a.example.com to the teama namespace.
+	proxySubdomainDelegation := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				SubdomainDelegations: []contour_api_v1.SubdomainDelegation{{
+					Domain:    "*.team-a.example.com",
+					Namespace: "teama",
+				}},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	// proxyDelegatedSubdomain is a root proxy outside the roots namespaces
+	// that is valid because its fqdn is within a delegated subdomain.
+	proxyDelegatedSubdomain := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "teama",
+			Name:      "api",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "api.team-a.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "root proxy within a delegated subdomain", testcase{
+		objs: []interface{}{proxySubdomainDelegation, proxyDelegatedSubdomain, fixture.ServiceRootsKuard, fixture.ServiceTeamAKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxySubdomainDelegation.Name, Namespace: proxySubdomainDelegation.Namespace}: fixture.NewValidCondition().Valid(),
+			{Name: proxyDelegatedSubdomain.Name, Namespace: proxyDelegatedSubdomain.Namespace}:   fixture.NewValidCondition().Valid(),
+		},
+	})
+
+	// proxyOutsideDelegatedSubdomain lives in the delegated namespace,
+	// but its fqdn is outside the delegated subdomain.
+	proxyOutsideDelegatedSubdomain := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "teama",
+			Name:      "www",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "www.team-b.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "root proxy outside of a delegated subdomain", testcase{
+		objs: []interface{}{proxySubdomainDelegation, proxyOutsideDelegatedSubdomain, fixture.ServiceRootsKuard, fixture.ServiceTeamAKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxySubdomainDelegation.Name, Namespace: proxySubdomainDelegation.Namespace}: fixture.NewValidCondition().Valid(),
+			{Name: proxyOutsideDelegatedSubdomain.Name, Namespace: proxyOutsideDelegatedSubdomain.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeRootNamespaceError, "RootProxyNotAllowedInNamespace", "root HTTPProxy cannot be defined in this namespace"),
+		},
+	})
+
+	// proxyInvalidSubdomainDelegation delegates a domain that is not within its fqdn.
+	proxyInvalidSubdomainDelegation := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				SubdomainDelegations: []contour_api_v1.SubdomainDelegation{{
+					Domain:    "*.example.org",
+					Namespace: "teama",
+				}},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "subdomain delegation outside of the fqdn", testcase{
+		objs: []interface{}{proxyInvalidSubdomainDelegation, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidSubdomainDelegation.Name, Namespace: proxyInvalidSubdomainDelegation.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeVirtualHostError, "SubdomainDelegationNotValid",
+					`Spec.VirtualHost.SubdomainDelegations: domain "*.example.org" is not within "example.com"`),
+		},
+	})
+
 	// proxyInvalidIncludeCycle is invalid because it delegates to itself, producing a cycle
 	proxyInvalidIncludeCycle := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1653,6 +1769,73 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	proxyInvalidDuplicateRouteConditions := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/blog",
+				}, {
+					Header: &contour_api_v1.HeaderMatchCondition{
+						Name:    "x-header",
+						Present: true,
+					},
+				}, {
+					Header: &contour_api_v1.HeaderMatchCondition{
+						Name:     "x-other",
+						Contains: "abc",
+					},
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}, {
+				// Same conditions as the previous route, in a different order.
+				Conditions: []contour_api_v1.MatchCondition{{
+					Header: &contour_api_v1.HeaderMatchCondition{
+						Name:     "x-other",
+						Contains: "abc",
+					},
+				}, {
+					Header: &contour_api_v1.HeaderMatchCondition{
+						Name:    "x-header",
+						Present: true,
+					},
+				}, {
+					Prefix: "/blog",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "duplicate conditions on routes", testcase{
+		objs: []interface{}{proxyInvalidDuplicateRouteConditions, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyInvalidDuplicateRouteConditions.Name,
+				Namespace: proxyInvalidDuplicateRouteConditions.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeRouteError, "DuplicateMatchConditions", "routes[1] and routes[2] have duplicate match conditions"),
+		},
+	})
+
 	proxyInvalidDuplicateHeaderAndPathConditions := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: "roots",
@@ -2134,6 +2317,102 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	tlsPassthroughAndHTTPVersions := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "tcpproxy.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough:  true,
+					HTTPVersions: []contour_api_v1.HTTPVersion{"http/1.1"},
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{},
+		},
+	}
+
+	run(t, "passthrough and http versions are incompatible", testcase{
+		objs: []interface{}{tlsPassthroughAndHTTPVersions},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: tlsPassthroughAndHTTPVersions.Name, Namespace: tlsPassthroughAndHTTPVersions.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeTLSError, "TLSIncompatibleFeatures", "Spec.VirtualHost.TLS passthrough cannot be combined with tls.httpVersions"),
+		},
+	})
+
+	clientValidationSkipVerifyWithSubjectAltNames := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: fixture.SecretRootsCert.Name,
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						SkipClientCertValidation: true,
+						AllowedSubjectAltNames: []contour_api_v1.SubjectAltNameMatch{{
+							Suffix: ".example.com",
+						}},
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "allowed subject alt names cannot be combined with skip client cert validation", testcase{
+		objs: []interface{}{fixture.SecretRootsCert, fixture.ServiceRootsKuard, clientValidationSkipVerifyWithSubjectAltNames},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: "invalid", Namespace: fixture.ServiceRootsKuard.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeTLSError, "ClientValidationInvalid", "Spec.VirtualHost.TLS client validation is invalid: allowedSubjectAltNames cannot be specified with skipClientCertValidation"),
+		},
+	})
+
+	clientValidationSubjectAltNameBothMatches := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					SecretName: fixture.SecretRootsCert.Name,
+					ClientValidation: &contour_api_v1.DownstreamValidation{
+						CACertificate: fixture.SecretRootsCert.Name,
+						AllowedSubjectAltNames: []contour_api_v1.SubjectAltNameMatch{{
+							Exact:  "client.example.com",
+							Suffix: ".example.com",
+						}},
+					},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "allowed subject alt name with both exact and suffix", testcase{
+		objs: []interface{}{fixture.SecretRootsCert, fixture.ServiceRootsKuard, clientValidationSubjectAltNameBothMatches},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: "invalid", Namespace: fixture.ServiceRootsKuard.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeTLSError, "ClientValidationInvalid", "Spec.VirtualHost.TLS client validation is invalid: allowedSubjectAltNames[0] must specify only one of exact or suffix"),
+		},
+	})
+
 	tlsPassthroughAndSecretName := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "invalid",
@@ -2244,9 +2523,9 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
-	invalidResponseHeadersPolicyService := &contour_api_v1.HTTPProxy{
+	conflictingRequestHeadersPolicyRoute := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "invalidRHPService",
+			Name:      "conflictingRHPRoute",
 			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
@@ -2254,33 +2533,32 @@ func TestDAGStatus(t *testing.T) {
 				Fqdn: "example.com",
 			},
 			Routes: []contour_api_v1.Route{{
-				Services: []contour_api_v1.Service{
-					{
-						Name: fixture.ServiceRootsKuard.Name,
-						Port: 8080,
-						ResponseHeadersPolicy: &contour_api_v1.HeadersPolicy{
-							Set: []contour_api_v1.HeaderValue{{
-								Name:  "Host",
-								Value: "external.com",
-							}},
-						},
-					},
+				RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+					Set: []contour_api_v1.HeaderValue{{
+						Name:  "x-foo",
+						Value: "bar",
+					}},
+					Remove: []string{"X-Foo"},
 				},
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
 			}},
 		},
 	}
 
-	run(t, "responseHeadersPolicy, Host header invalid on Service", testcase{
-		objs: []interface{}{invalidResponseHeadersPolicyService, fixture.ServiceRootsKuard},
+	run(t, "requestHeadersPolicy, header set and removed on Route", testcase{
+		objs: []interface{}{conflictingRequestHeadersPolicyRoute, fixture.ServiceRootsKuard},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: invalidResponseHeadersPolicyService.Name, Namespace: invalidResponseHeadersPolicyService.Namespace}: fixture.NewValidCondition().
-				WithError(contour_api_v1.ConditionTypeServiceError, "ResponseHeadersPolicyInvalid", `rewriting "Host" header is not supported on response headers`),
+			{Name: conflictingRequestHeadersPolicyRoute.Name, Namespace: conflictingRequestHeadersPolicyRoute.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeRouteError, "RequestHeadersPolicyInvalid", `header "X-Foo" is both set and removed on request headers`),
 		},
 	})
 
-	invalidResponseHeadersPolicyRoute := &contour_api_v1.HTTPProxy{
+	conflictingRequestHeadersPolicyService := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "invalidRHPRoute",
+			Name:      "conflictingRHPService",
 			Namespace: fixture.ServiceRootsKuard.Namespace,
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
@@ -2288,31 +2566,299 @@ func TestDAGStatus(t *testing.T) {
 				Fqdn: "example.com",
 			},
 			Routes: []contour_api_v1.Route{{
-				Services: []contour_api_v1.Service{
-					{
-						Name: fixture.ServiceRootsKuard.Name,
-						Port: 8080,
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+					RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+						Set: []contour_api_v1.HeaderValue{{
+							Name:  "X-Foo",
+							Value: "bar",
+						}},
+						Remove: []string{"x-foo"},
 					},
-				},
-				ResponseHeadersPolicy: &contour_api_v1.HeadersPolicy{
-					Set: []contour_api_v1.HeaderValue{{
-						Name:  "Host",
-						Value: "external.com",
-					}},
-				},
+				}},
 			}},
 		},
 	}
 
-	run(t, "responseHeadersPolicy, Host header invalid on Route", testcase{
-		objs: []interface{}{invalidResponseHeadersPolicyRoute, fixture.ServiceRootsKuard},
+	run(t, "requestHeadersPolicy, header set and removed on Service", testcase{
+		objs: []interface{}{conflictingRequestHeadersPolicyService, fixture.ServiceRootsKuard},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: invalidResponseHeadersPolicyRoute.Name, Namespace: invalidResponseHeadersPolicyRoute.Namespace}: fixture.NewValidCondition().
-				WithError(contour_api_v1.ConditionTypeRouteError, "ResponseHeaderPolicyInvalid", `rewriting "Host" header is not supported on response headers`),
+			{Name: conflictingRequestHeadersPolicyService.Name, Namespace: conflictingRequestHeadersPolicyService.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeServiceError, "RequestHeadersPolicyInvalid", `header "X-Foo" is both set and removed on request headers`),
 		},
 	})
 
-	proxyAuthFallback := fixture.NewProxy("roots/fallback-incompat").
+	protectedRequestHeadersPolicyRoute := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "protectedRHPRoute",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+					Set: []contour_api_v1.HeaderValue{{
+						Name:  "x-forwarded-for",
+						Value: "192.0.2.1",
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "requestHeadersPolicy, protected header invalid on Route", testcase{
+		objs: []interface{}{protectedRequestHeadersPolicyRoute, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: protectedRequestHeadersPolicyRoute.Name, Namespace: protectedRequestHeadersPolicyRoute.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeRouteError, "RequestHeadersPolicyInvalid", `rewriting "X-Forwarded-For" header is not allowed on request headers`),
+		},
+	})
+
+	protectedRequestHeadersPolicyRemoved := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "protectedRHPRemoved",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+					Remove: []string{"x-envoy-expected-rq-timeout-ms"},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "requestHeadersPolicy, protected header removed on Route", testcase{
+		objs: []interface{}{protectedRequestHeadersPolicyRemoved, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: protectedRequestHeadersPolicyRemoved.Name, Namespace: protectedRequestHeadersPolicyRemoved.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeRouteError, "RequestHeadersPolicyInvalid", `rewriting "X-Envoy-Expected-Rq-Timeout-Ms" header is not allowed on request headers`),
+		},
+	})
+
+	overriddenHeadersPolicyService := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "overriddenHPService",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+					Set: []contour_api_v1.HeaderValue{{
+						Name:  "X-App-Weight",
+						Value: "100",
+					}},
+				},
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+					RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+						Remove: []string{"x-app-weight"},
+					},
+				}},
+			}},
+		},
+	}
+
+	run(t, "requestHeadersPolicy, Service overrides Route headers", testcase{
+		objs: []interface{}{overriddenHeadersPolicyService, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: overriddenHeadersPolicyService.Name, Namespace: overriddenHeadersPolicyService.Namespace}: fixture.NewValidCondition().
+				ValidWithWarning(contour_api_v1.ConditionTypeServiceError, "HeadersPolicyOverridden", "Service [kuard:8080] request headers policy overrides route level headers X-App-Weight"),
+		},
+	})
+
+	drainedService := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "drained",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name:   fixture.ServiceRootsKuard.Name,
+					Port:   8080,
+					Weight: 0,
+				}, {
+					Name:   fixture.ServiceRootsHome.Name,
+					Port:   8080,
+					Weight: 100,
+				}},
+			}},
+		},
+	}
+
+	run(t, "service with zero weight is drained", testcase{
+		objs: []interface{}{drainedService, fixture.ServiceRootsKuard, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: drainedService.Name, Namespace: drainedService.Namespace}: fixture.NewValidCondition().
+				ValidWithWarning(contour_api_v1.ConditionTypeServiceError, "ServiceDrained", `service "kuard" has a weight of 0 and receives no traffic`),
+		},
+	})
+
+	unweightedServices := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unweighted",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}, {
+					Name: fixture.ServiceRootsHome.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "services without weights share traffic equally", testcase{
+		objs: []interface{}{unweightedServices, fixture.ServiceRootsKuard, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: unweightedServices.Name, Namespace: unweightedServices.Namespace}: fixture.NewValidCondition().Valid(),
+		},
+	})
+
+	blockedNamespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fixture.ServiceRootsKuard.Namespace,
+			Annotations: map[string]string{
+				"projectcontour.io/routes-blocked": "true",
+			},
+		},
+	}
+
+	run(t, "routes of a blocked namespace", testcase{
+		objs: []interface{}{unweightedServices, blockedNamespace, fixture.ServiceRootsKuard, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: unweightedServices.Name, Namespace: unweightedServices.Namespace}: fixture.NewValidCondition().
+				ValidWithWarning(contour_api_v1.ConditionTypeRouteError, "NamespaceBlocked", `routes from namespace "roots" are blocked by the cluster administrator and respond with a 503 status`),
+		},
+	})
+
+	blockedTCPProxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "blocked-tcpproxy",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			},
+		},
+	}
+
+	run(t, "TCP proxy of a blocked namespace", testcase{
+		objs: []interface{}{blockedTCPProxy, blockedNamespace, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: blockedTCPProxy.Name, Namespace: blockedTCPProxy.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeTCPProxyError, "NamespaceBlocked", `TCP proxies from namespace "roots" are blocked by the cluster administrator`),
+		},
+	})
+
+	invalidResponseHeadersPolicyService := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalidRHPService",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{
+					{
+						Name: fixture.ServiceRootsKuard.Name,
+						Port: 8080,
+						ResponseHeadersPolicy: &contour_api_v1.HeadersPolicy{
+							Set: []contour_api_v1.HeaderValue{{
+								Name:  "Host",
+								Value: "external.com",
+							}},
+						},
+					},
+				},
+			}},
+		},
+	}
+
+	run(t, "responseHeadersPolicy, Host header invalid on Service", testcase{
+		objs: []interface{}{invalidResponseHeadersPolicyService, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: invalidResponseHeadersPolicyService.Name, Namespace: invalidResponseHeadersPolicyService.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeServiceError, "ResponseHeadersPolicyInvalid", `rewriting "Host" header is not supported on response headers`),
+		},
+	})
+
+	invalidResponseHeadersPolicyRoute := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalidRHPRoute",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{
+					{
+						Name: fixture.ServiceRootsKuard.Name,
+						Port: 8080,
+					},
+				},
+				ResponseHeadersPolicy: &contour_api_v1.HeadersPolicy{
+					Set: []contour_api_v1.HeaderValue{{
+						Name:  "Host",
+						Value: "external.com",
+					}},
+				},
+			}},
+		},
+	}
+
+	run(t, "responseHeadersPolicy, Host header invalid on Route", testcase{
+		objs: []interface{}{invalidResponseHeadersPolicyRoute, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: invalidResponseHeadersPolicyRoute.Name, Namespace: invalidResponseHeadersPolicyRoute.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeRouteError, "ResponseHeaderPolicyInvalid", `rewriting "Host" header is not supported on response headers`),
+		},
+	})
+
+	proxyAuthFallback := fixture.NewProxy("roots/fallback-incompat").
 		WithSpec(contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
 				Fqdn: "invalid.com",
@@ -2328,85 +2874,850 @@ func TestDAGStatus(t *testing.T) {
 				},
 			},
 			Routes: []contour_api_v1.Route{{
-				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	run(t, "fallback and client auth is invalid", testcase{
+		objs: []interface{}{fixture.SecretRootsCert, proxyAuthFallback},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{Name: proxyAuthFallback.Name, Namespace: proxyAuthFallback.Namespace}: fixture.NewValidCondition().
+				WithError(contour_api_v1.ConditionTypeTLSError, "TLSIncompatibleFeatures", "Spec.Virtualhost.TLS fallback & client authorization are incompatible"),
+		},
+	})
+
+	invalidResponseTimeout := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "invalid-timeouts",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{
+				{
+					Services: []contour_api_v1.Service{
+						{
+							Name: fixture.ServiceRootsKuard.Name,
+						},
+					},
+					TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+						Response: "invalid-val",
+					},
+				},
+			},
+		},
+	}
+
+	run(t, "proxy with invalid response timeout value is invalid", testcase{
+		objs: []interface{}{invalidResponseTimeout, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidResponseTimeout.Name,
+				Namespace: invalidResponseTimeout.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "TimeoutPolicyNotValid",
+				`route.timeoutPolicy failed to parse: error parsing response timeout: invalid timeout "invalid-val": must be a duration such as "10s", or "infinity" to disable the timeout`),
+		},
+	})
+
+	invalidPerTryTimeout := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "invalid-per-try-timeout",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+				}},
+				RetryPolicy: &contour_api_v1.RetryPolicy{
+					PerTryTimeout: "invalid-val",
+				},
+			}},
+		},
+	}
+
+	run(t, "proxy with invalid per try timeout value is invalid", testcase{
+		objs: []interface{}{invalidPerTryTimeout, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidPerTryTimeout.Name,
+				Namespace: invalidPerTryTimeout.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "RetryPolicyNotValid",
+				`route.retryPolicy failed to parse: error parsing per try timeout: invalid timeout "invalid-val": must be a duration such as "10s", or "infinity" to disable the timeout`),
+		},
+	})
+
+	invalidIdleTimeout := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "invalid-timeouts",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{
+				{
+					Services: []contour_api_v1.Service{
+						{
+							Name: fixture.ServiceRootsKuard.Name,
+						},
+					},
+					TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+						Idle: "invalid-val",
+					},
+				},
+			},
+		},
+	}
+
+	run(t, "proxy with invalid idle timeout value is invalid", testcase{
+		objs: []interface{}{invalidIdleTimeout, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidIdleTimeout.Name,
+				Namespace: invalidIdleTimeout.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "TimeoutPolicyNotValid",
+				`route.timeoutPolicy failed to parse: error parsing idle timeout: invalid timeout "invalid-val": must be a duration such as "10s", or "infinity" to disable the timeout`),
+		},
+	})
+
+	websocketIdleTimeoutWithoutWebsockets := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "websocket-idle-timeout",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+				}},
+				WebsocketIdleTimeout: "1h",
+			}},
+		},
+	}
+
+	run(t, "proxy with websocket idle timeout but websockets disabled is invalid", testcase{
+		objs: []interface{}{websocketIdleTimeoutWithoutWebsockets, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      websocketIdleTimeoutWithoutWebsockets.Name,
+				Namespace: websocketIdleTimeoutWithoutWebsockets.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "WebsocketIdleTimeoutNotValid",
+				`route.websocketIdleTimeout requires route.enableWebsockets`),
+		},
+	})
+
+	invalidWebsocketIdleTimeout := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "invalid-websocket-idle-timeout",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+				}},
+				EnableWebsockets:     true,
+				WebsocketIdleTimeout: "invalid-val",
+			}},
+		},
+	}
+
+	run(t, "proxy with invalid websocket idle timeout value is invalid", testcase{
+		objs: []interface{}{invalidWebsocketIdleTimeout, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidWebsocketIdleTimeout.Name,
+				Namespace: invalidWebsocketIdleTimeout.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "WebsocketIdleTimeoutNotValid",
+				`route.websocketIdleTimeout failed to parse: invalid timeout "invalid-val": must be a duration such as "10s", or "infinity" to disable the timeout`),
+		},
+	})
+
+	invalidMaxConnectionDuration := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "invalid-max-connection-duration",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:                  "example.com",
+				MaxConnectionDuration: "invalid-val",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with invalid max connection duration is invalid", testcase{
+		objs: []interface{}{invalidMaxConnectionDuration, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidMaxConnectionDuration.Name,
+				Namespace: invalidMaxConnectionDuration.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "MaxConnectionDurationInvalid",
+				`Spec.VirtualHost.MaxConnectionDuration is invalid: invalid timeout "invalid-val": must be a duration such as "10s", or "infinity" to disable the timeout`),
+		},
+	})
+
+	insecureMaxConnectionDuration := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "insecure-max-connection-duration",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:                  "example.com",
+				MaxConnectionDuration: "10m",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with max connection duration and without TLS is ignored", testcase{
+		objs: []interface{}{insecureMaxConnectionDuration, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      insecureMaxConnectionDuration.Name,
+				Namespace: insecureMaxConnectionDuration.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				`ignoring field "Spec.VirtualHost.MaxConnectionDuration"; it requires TLS to be enabled`),
+		},
+	})
+
+	insecureRequestHeadersLimits := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "insecure-request-headers-limits",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:                 "example.com",
+				RequestHeadersLimits: &contour_api_v1.RequestHeadersLimits{MaxCount: 200},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with request headers limits and without TLS is ignored", testcase{
+		objs: []interface{}{insecureRequestHeadersLimits, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      insecureRequestHeadersLimits.Name,
+				Namespace: insecureRequestHeadersLimits.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				`ignoring field "Spec.VirtualHost.RequestHeadersLimits"; it requires TLS to be enabled`),
+		},
+	})
+
+	healthVirtualHostProxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "health-virtual-host",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "lb-health.example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with the name of the health virtual host", testcase{
+		objs:              []interface{}{healthVirtualHostProxy, fixture.ServiceRootsKuard},
+		healthVirtualHost: "lb-health.example.com",
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      healthVirtualHostProxy.Name,
+				Namespace: healthVirtualHostProxy.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "HealthVirtualHostConflict",
+				`virtual host "lb-health.example.com" is reserved for the health virtual host and is not served over HTTP`),
+		},
+	})
+
+	insecurePathNormalization := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "insecure-path-normalization",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:              "example.com",
+				PathNormalization: &contour_api_v1.PathNormalizationPolicy{DisableMergeSlashes: true},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with path normalization and without TLS is ignored", testcase{
+		objs: []interface{}{insecurePathNormalization, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      insecurePathNormalization.Name,
+				Namespace: insecurePathNormalization.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				`ignoring field "Spec.VirtualHost.PathNormalization"; it requires TLS to be enabled`),
+		},
+	})
+
+	insecureHTTP10Policy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "insecure-http10-policy",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:         "example.com",
+				HTTP10Policy: &contour_api_v1.HTTP10Policy{DefaultHost: true},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with http/1.0 policy and without TLS is ignored", testcase{
+		objs: []interface{}{insecureHTTP10Policy, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      insecureHTTP10Policy.Name,
+				Namespace: insecureHTTP10Policy.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				`ignoring field "Spec.VirtualHost.HTTP10Policy"; it requires TLS to be enabled`),
+		},
+	})
+
+	invalidHTTP10Policy := insecureHTTP10Policy.DeepCopy()
+	invalidHTTP10Policy.Name = "invalid-http10-policy"
+	invalidHTTP10Policy.Spec.VirtualHost.HTTP10Policy.Disabled = true
+
+	run(t, "proxy with http/1.0 disabled and a default host is invalid", testcase{
+		objs: []interface{}{invalidHTTP10Policy, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidHTTP10Policy.Name,
+				Namespace: invalidHTTP10Policy.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "HTTP10PolicyNotValid",
+				"Spec.VirtualHost.HTTP10Policy: defaultHost cannot be specified when disabled is true"),
+		},
+	})
+
+	insecureConcurrencyPolicy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "insecure-concurrency-policy",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:              "example.com",
+				ConcurrencyPolicy: &contour_api_v1.ConcurrencyPolicy{SuccessRateThreshold: 90},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with concurrency policy and without TLS is ignored", testcase{
+		objs: []interface{}{insecureConcurrencyPolicy, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      insecureConcurrencyPolicy.Name,
+				Namespace: insecureConcurrencyPolicy.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				`ignoring field "Spec.VirtualHost.ConcurrencyPolicy"; it requires TLS to be enabled, as admission control can't be set per route or per insecure virtual host`),
+		},
+	})
+
+	invalidConcurrencyPolicy := insecureConcurrencyPolicy.DeepCopy()
+	invalidConcurrencyPolicy.Name = "invalid-concurrency-policy"
+	invalidConcurrencyPolicy.Spec.VirtualHost.ConcurrencyPolicy.SamplingWindow = "100ms"
+
+	run(t, "proxy with a sub-second concurrency sampling window is invalid", testcase{
+		objs: []interface{}{invalidConcurrencyPolicy, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidConcurrencyPolicy.Name,
+				Namespace: invalidConcurrencyPolicy.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "ConcurrencyPolicyNotValid",
+				"Spec.VirtualHost.ConcurrencyPolicy is invalid: samplingWindow must be at least 1s"),
+		},
+	})
+
+	insecureAccessLogHeaders := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "insecure-access-log-headers",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:             "example.com",
+				AccessLogHeaders: &contour_api_v1.AccessLogHeaders{Request: []string{"x-tenant-id"}},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with access log headers and without TLS is ignored", testcase{
+		objs: []interface{}{insecureAccessLogHeaders, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      insecureAccessLogHeaders.Name,
+				Namespace: insecureAccessLogHeaders.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				`ignoring field "Spec.VirtualHost.AccessLogHeaders"; it requires TLS to be enabled`),
+		},
+	})
+
+	invalidAccessLogHeaders := insecureAccessLogHeaders.DeepCopy()
+	invalidAccessLogHeaders.Name = "invalid-access-log-headers"
+	invalidAccessLogHeaders.Spec.VirtualHost.AccessLogHeaders.Response = []string{"x cache"}
+
+	run(t, "proxy with an invalid access log header name is invalid", testcase{
+		objs: []interface{}{invalidAccessLogHeaders, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidAccessLogHeaders.Name,
+				Namespace: invalidAccessLogHeaders.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "AccessLogHeadersNotValid",
+				`Spec.VirtualHost.AccessLogHeaders is invalid: invalid access log header name "x cache"`),
+		},
+	})
+
+	insecureServerHeader := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "insecure-server-header",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:         "example.com",
+				ServerHeader: &contour_api_v1.ServerHeaderPolicy{Name: "example"},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with a server header and without TLS is ignored", testcase{
+		objs: []interface{}{insecureServerHeader, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      insecureServerHeader.Name,
+				Namespace: insecureServerHeader.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeVirtualHostError, "IgnoredField",
+				`ignoring field "Spec.VirtualHost.ServerHeader"; it requires TLS to be enabled`),
+		},
+	})
+
+	invalidServerHeader := insecureServerHeader.DeepCopy()
+	invalidServerHeader.Name = "invalid-server-header"
+	invalidServerHeader.Spec.VirtualHost.ServerHeader.Name = "example\n"
+
+	run(t, "proxy with an invalid server name is invalid", testcase{
+		objs: []interface{}{invalidServerHeader, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidServerHeader.Name,
+				Namespace: invalidServerHeader.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "ServerHeaderNotValid",
+				`Spec.VirtualHost.ServerHeader is invalid: invalid server name "example\n": must only contain printable ASCII characters`),
+		},
+	})
+
+	allDNSLookupFamily := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "all-dns-lookup-family",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name:            fixture.ServiceRootsKuard.Name,
+					Port:            8080,
+					DNSLookupFamily: "all",
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with the all DNS lookup family falls back to auto", testcase{
+		objs: []interface{}{allDNSLookupFamily, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      allDNSLookupFamily.Name,
+				Namespace: allDNSLookupFamily.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeServiceError, "DNSLookupFamilyNotSupported",
+				`Service [kuard:8080] DNS lookup family "all" is not supported by Envoy, "auto" is used`),
+		},
+	})
+
+	maintenanceBodyAndRedirect := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "maintenance-body-and-redirect",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn:        "example.com",
+				Maintenance: true,
+				MaintenancePolicy: &contour_api_v1.MaintenancePolicy{
+					Body:     "down for maintenance",
+					Redirect: &contour_api_v1.MaintenanceRedirect{Hostname: "status.example.com"},
+				},
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
 			}},
-		})
+		},
+	}
 
-	run(t, "fallback and client auth is invalid", testcase{
-		objs: []interface{}{fixture.SecretRootsCert, proxyAuthFallback},
+	run(t, "proxy in maintenance with both a body and a redirect is invalid", testcase{
+		objs: []interface{}{maintenanceBodyAndRedirect, fixture.ServiceRootsKuard},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
-			{Name: proxyAuthFallback.Name, Namespace: proxyAuthFallback.Namespace}: fixture.NewValidCondition().
-				WithError(contour_api_v1.ConditionTypeTLSError, "TLSIncompatibleFeatures", "Spec.Virtualhost.TLS fallback & client authorization are incompatible"),
+			{
+				Name:      maintenanceBodyAndRedirect.Name,
+				Namespace: maintenanceBodyAndRedirect.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "MaintenancePolicyNotValid",
+				"Spec.VirtualHost.MaintenancePolicy is invalid: body cannot be specified with redirect"),
 		},
 	})
 
-	invalidResponseTimeout := &contour_api_v1.HTTPProxy{
+	maintenanceRetryAfter := maintenanceBodyAndRedirect.DeepCopy()
+	maintenanceRetryAfter.Name = "maintenance-retry-after"
+	maintenanceRetryAfter.Spec.VirtualHost.MaintenancePolicy = &contour_api_v1.MaintenancePolicy{RetryAfter: "0s"}
+
+	run(t, "proxy in maintenance with a zero retryAfter is invalid", testcase{
+		objs: []interface{}{maintenanceRetryAfter, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      maintenanceRetryAfter.Name,
+				Namespace: maintenanceRetryAfter.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeVirtualHostError, "MaintenancePolicyNotValid",
+				"Spec.VirtualHost.MaintenancePolicy is invalid: retryAfter must be positive"),
+		},
+	})
+
+	// A maintenance policy has no effect, and is not validated,
+	// unless maintenance is enabled.
+	maintenanceDisabled := maintenanceBodyAndRedirect.DeepCopy()
+	maintenanceDisabled.Name = "maintenance-disabled"
+	maintenanceDisabled.Spec.VirtualHost.Maintenance = false
+
+	run(t, "proxy with a maintenance policy but not in maintenance is valid", testcase{
+		objs: []interface{}{maintenanceDisabled, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      maintenanceDisabled.Name,
+				Namespace: maintenanceDisabled.Namespace,
+			}: fixture.NewValidCondition().Valid(),
+		},
+	})
+
+	invalidActiveWindow := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: fixture.ServiceRootsKuard.Namespace,
-			Name:      "invalid-timeouts",
+			Name:      "invalid-active-window",
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
 				Fqdn: "example.com",
 			},
-			Routes: []contour_api_v1.Route{
-				{
-					Services: []contour_api_v1.Service{
-						{
-							Name: fixture.ServiceRootsKuard.Name,
-						},
-					},
-					TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
-						Response: "invalid-val",
-					},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: fixture.ServiceRootsKuard.Name,
+					Port: 8080,
+				}},
+				ActiveWindow: &contour_api_v1.ActiveWindow{
+					Schedule: "0 9 * * 1-5",
 				},
-			},
+			}},
 		},
 	}
 
-	run(t, "proxy with invalid response timeout value is invalid", testcase{
-		objs: []interface{}{invalidResponseTimeout, fixture.ServiceRootsKuard},
+	run(t, "proxy with a route active window schedule without a duration is invalid", testcase{
+		objs: []interface{}{invalidActiveWindow, fixture.ServiceRootsKuard},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
 			{
-				Name:      invalidResponseTimeout.Name,
-				Namespace: invalidResponseTimeout.Namespace,
-			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "TimeoutPolicyNotValid",
-				`route.timeoutPolicy failed to parse: error parsing response timeout: unable to parse timeout string "invalid-val": time: invalid duration "invalid-val"`),
+				Name:      invalidActiveWindow.Name,
+				Namespace: invalidActiveWindow.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "ActiveWindowNotValid",
+				"route.activeWindow is invalid: schedule requires a duration"),
 		},
 	})
 
-	invalidIdleTimeout := &contour_api_v1.HTTPProxy{
+	invalidUpstreamTLSVersions := &contour_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: fixture.ServiceRootsKuard.Namespace,
-			Name:      "invalid-timeouts",
+			Name:      "invalid-upstream-tls-versions",
 		},
 		Spec: contour_api_v1.HTTPProxySpec{
 			VirtualHost: &contour_api_v1.VirtualHost{
 				Fqdn: "example.com",
 			},
-			Routes: []contour_api_v1.Route{
-				{
-					Services: []contour_api_v1.Service{
-						{
-							Name: fixture.ServiceRootsKuard.Name,
-						},
-					},
-					TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
-						Idle: "invalid-val",
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name:     fixture.ServiceRootsKuard.Name,
+					Port:     8080,
+					Protocol: pointer.StringPtr("tls"),
+					UpstreamTLS: &contour_api_v1.UpstreamTLS{
+						MinimumProtocolVersion: "1.3",
+						MaximumProtocolVersion: "1.2",
 					},
-				},
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with upstream tls minimum version above maximum version", testcase{
+		objs: []interface{}{invalidUpstreamTLSVersions, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidUpstreamTLSVersions.Name,
+				Namespace: invalidUpstreamTLSVersions.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "UpstreamTLSInvalid",
+				`Service [kuard:8080] upstream TLS policy error: minimumProtocolVersion "1.3" is greater than maximumProtocolVersion "1.2"`),
+		},
+	})
+
+	invalidUpstreamTLSCiphers := invalidUpstreamTLSVersions.DeepCopy()
+	invalidUpstreamTLSCiphers.Name = "invalid-upstream-tls-ciphers"
+	invalidUpstreamTLSCiphers.Spec.Routes[0].Services[0].UpstreamTLS = &contour_api_v1.UpstreamTLS{
+		CipherSuites: []string{"ECDHE-RSA-AES256-GCM-SHA384", "NOT-A-CIPHER"},
+	}
+
+	run(t, "proxy with invalid upstream tls cipher suites", testcase{
+		objs: []interface{}{invalidUpstreamTLSCiphers, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidUpstreamTLSCiphers.Name,
+				Namespace: invalidUpstreamTLSCiphers.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "UpstreamTLSInvalid",
+				"Service [kuard:8080] upstream TLS policy error: invalid ciphers: NOT-A-CIPHER"),
+		},
+	})
+
+	invalidSNI := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "invalid-sni",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
 			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name:     fixture.ServiceRootsKuard.Name,
+					Port:     8080,
+					Protocol: pointer.StringPtr("tls"),
+					SNI:      "Backend_Example.com",
+				}},
+			}},
 		},
 	}
 
-	run(t, "proxy with invalid idle timeout value is invalid", testcase{
-		objs: []interface{}{invalidIdleTimeout, fixture.ServiceRootsKuard},
+	run(t, "proxy with invalid upstream sni", testcase{
+		objs: []interface{}{invalidSNI, fixture.ServiceRootsKuard},
 		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
 			{
-				Name:      invalidIdleTimeout.Name,
-				Namespace: invalidIdleTimeout.Namespace,
-			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeRouteError, "TimeoutPolicyNotValid",
-				`route.timeoutPolicy failed to parse: error parsing idle timeout: unable to parse timeout string "invalid-val": time: invalid duration "invalid-val"`),
+				Name:      invalidSNI.Name,
+				Namespace: invalidSNI.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "SNIInvalid",
+				`Service [kuard:8080] SNI "Backend_Example.com" is invalid: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`),
+		},
+	})
+
+	caSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca",
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+		},
+		Data: map[string][]byte{
+			CACertificateKey: []byte(fixture.CERTIFICATE),
+		},
+	}
+
+	mismatchedSNI := invalidSNI.DeepCopy()
+	mismatchedSNI.Name = "mismatched-sni"
+	mismatchedSNI.Spec.Routes[0].Services[0].SNI = "backend.example.com"
+	mismatchedSNI.Spec.Routes[0].Services[0].UpstreamValidation = &contour_api_v1.UpstreamValidation{
+		CACertificate: caSecret.Name,
+		SubjectName:   "example.com",
+	}
+
+	run(t, "proxy with upstream sni not matching the validation subject name", testcase{
+		objs: []interface{}{mismatchedSNI, caSecret, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      mismatchedSNI.Name,
+				Namespace: mismatchedSNI.Namespace,
+			}: fixture.NewValidCondition().ValidWithWarning(contour_api_v1.ConditionTypeServiceError, "SNISubjectNameMismatch",
+				`Service [kuard:8080] SNI "backend.example.com" does not match the validation subjectName "example.com"`),
+		},
+	})
+
+	spiffeSNI := mismatchedSNI.DeepCopy()
+	spiffeSNI.Name = "spiffe-sni"
+	spiffeSNI.Spec.Routes[0].Services[0].UpstreamValidation = &contour_api_v1.UpstreamValidation{
+		CACertificate: caSecret.Name,
+		SpiffeID:      "spiffe://example.org/ns/default/sa/backend",
+	}
+
+	run(t, "proxy with upstream sni and a validation spiffe id", testcase{
+		objs: []interface{}{spiffeSNI, caSecret, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      spiffeSNI.Name,
+				Namespace: spiffeSNI.Namespace,
+			}: fixture.NewValidCondition().Valid(),
+		},
+	})
+
+	missingAdditionalCA := mismatchedSNI.DeepCopy()
+	missingAdditionalCA.Name = "missing-additional-ca"
+	missingAdditionalCA.Spec.Routes[0].Services[0].SNI = ""
+	missingAdditionalCA.Spec.Routes[0].Services[0].UpstreamValidation.AdditionalCACertificates = []string{"ca", "missing"}
+
+	run(t, "proxy with a missing additional validation ca secret", testcase{
+		objs: []interface{}{missingAdditionalCA, caSecret, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      missingAdditionalCA.Name,
+				Namespace: missingAdditionalCA.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "TLSUpstreamValidation",
+				`Service [kuard:8080] TLS upstream validation policy error: invalid CA Secret "roots/missing": Secret not found`),
+		},
+	})
+
+	spiffeAndSubjectName := spiffeSNI.DeepCopy()
+	spiffeAndSubjectName.Name = "spiffe-and-subject-name"
+	spiffeAndSubjectName.Spec.Routes[0].Services[0].UpstreamValidation.SubjectName = "backend.example.com"
+
+	run(t, "proxy with both a validation subject name and spiffe id", testcase{
+		objs: []interface{}{spiffeAndSubjectName, caSecret, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      spiffeAndSubjectName.Name,
+				Namespace: spiffeAndSubjectName.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "TLSUpstreamValidation",
+				"Service [kuard:8080] TLS upstream validation policy error: subjectName and spiffeID cannot both be specified"),
+		},
+	})
+
+	invalidSpiffeID := spiffeSNI.DeepCopy()
+	invalidSpiffeID.Name = "invalid-spiffe-id"
+	invalidSpiffeID.Spec.Routes[0].Services[0].UpstreamValidation.SpiffeID = "spiffe:///ns/default/sa/backend"
+
+	run(t, "proxy with a validation spiffe id without a trust domain", testcase{
+		objs: []interface{}{invalidSpiffeID, caSecret, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      invalidSpiffeID.Name,
+				Namespace: invalidSpiffeID.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "TLSUpstreamValidation",
+				`Service [kuard:8080] TLS upstream validation policy error: invalid spiffeID "spiffe:///ns/default/sa/backend": missing trust domain`),
+		},
+	})
+
+	failoverWithoutPrimary := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fixture.ServiceRootsKuard.Namespace,
+			Name:      "failover-without-primary",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name:     fixture.ServiceRootsKuard.Name,
+					Port:     8080,
+					Priority: 1,
+				}},
+			}},
+		},
+	}
+
+	run(t, "proxy with failover priorities and no priority 0 service", testcase{
+		objs: []interface{}{failoverWithoutPrimary, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      failoverWithoutPrimary.Name,
+				Namespace: failoverWithoutPrimary.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "FailoverInvalid",
+				"failover priorities require exactly one service with priority 0 in the route, which cannot be an ExternalName service"),
+		},
+	})
+
+	failoverMirror := failoverWithoutPrimary.DeepCopy()
+	failoverMirror.Name = "failover-mirror"
+	failoverMirror.Spec.Routes[0].Services[0].Mirror = true
+
+	run(t, "proxy with failover priority on a mirror service", testcase{
+		objs: []interface{}{failoverMirror, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]contour_api_v1.DetailedCondition{
+			{
+				Name:      failoverMirror.Name,
+				Namespace: failoverMirror.Namespace,
+			}: fixture.NewValidCondition().WithError(contour_api_v1.ConditionTypeServiceError, "FailoverInvalid",
+				"Service [kuard:8080] failover priority cannot be set on mirror or ExternalName services"),
 		},
 	})
 
@@ -2631,6 +3942,54 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 		}},
 	})
 
+	run(t, "httproute in a blocked namespace", testcase{
+		objs: []interface{}{
+			kuardService,
+			&v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "default",
+					Annotations: map[string]string{
+						"projectcontour.io/routes-blocked": "true",
+					},
+				},
+			},
+			&gatewayapi_v1alpha1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "basic",
+					Namespace: "default",
+					Labels: map[string]string{
+						"app": "contour",
+					},
+				},
+				Spec: gatewayapi_v1alpha1.HTTPRouteSpec{
+					Gateways: &gatewayapi_v1alpha1.RouteGateways{
+						Allow: gatewayAllowTypePtr(gatewayapi_v1alpha1.GatewayAllowAll),
+					},
+					Hostnames: []gatewayapi_v1alpha1.Hostname{
+						"test.projectcontour.io",
+					},
+					Rules: []gatewayapi_v1alpha1.HTTPRouteRule{{
+						Matches: httpRouteMatch(gatewayapi_v1alpha1.PathMatchPrefix, "/"),
+						ForwardTo: []gatewayapi_v1alpha1.HTTPRouteForwardTo{{
+							ServiceName: pointer.StringPtr("kuard"),
+							Port:        gatewayPort(8080),
+						}},
+					}},
+				},
+			}},
+		want: []*status.RouteConditionsUpdate{{
+			FullName: types.NamespacedName{Namespace: "default", Name: "basic"},
+			Conditions: map[gatewayapi_v1alpha1.RouteConditionType]metav1.Condition{
+				gatewayapi_v1alpha1.ConditionRouteAdmitted: {
+					Type:    string(gatewayapi_v1alpha1.ConditionRouteAdmitted),
+					Status:  contour_api_v1.ConditionFalse,
+					Reason:  string(status.ReasonNamespaceBlocked),
+					Message: `Routes from namespace "default" are blocked by the cluster administrator.`,
+				},
+			},
+		}},
+	})
+
 	run(t, "invalid prefix match for httproute", testcase{
 		objs: []interface{}{
 			kuardService,
@@ -3900,3 +5259,126 @@ func TestGatewayAPITLSRouteDAGStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestDAGEffectiveRoutes(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	root := func(fqdn, prefix string) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fqdn,
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: fqdn,
+				},
+				Includes: []contour_api_v1.Include{{
+					Name: "child",
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: prefix,
+					}},
+				}},
+			},
+		}
+	}
+
+	child := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "child",
+			Namespace: "default",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: svc.Name,
+					Port: 8080,
+				}},
+			}, {
+				Conditions: []contour_api_v1.MatchCondition{{
+					Prefix: "/api",
+				}, {
+					Header: &contour_api_v1.HeaderMatchCondition{
+						Name:    "x-version",
+						Present: true,
+					},
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: svc.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&HTTPProxyProcessor{},
+			&ListenerProcessor{},
+		},
+	}
+	for _, o := range []interface{}{svc, root("b.example.com", "/b"), root("a.example.com", "/a"), child} {
+		builder.Source.Insert(o)
+	}
+	dag := builder.Build()
+
+	updates := make(map[types.NamespacedName]*status.ProxyUpdate)
+	for _, pu := range dag.StatusCache.GetProxyUpdates() {
+		updates[pu.Fullname] = pu
+	}
+
+	got := updates[types.NamespacedName{Namespace: "default", Name: "child"}].Mutate(child).(*contour_api_v1.HTTPProxy)
+	assert.Equal(t, []contour_api_v1.EffectiveRoute{{
+		Fqdn:       "a.example.com",
+		Index:      0,
+		Conditions: []contour_api_v1.MatchCondition{{Prefix: "/a"}},
+	}, {
+		Fqdn:  "a.example.com",
+		Index: 1,
+		Conditions: []contour_api_v1.MatchCondition{{
+			Prefix: "/a",
+		}, {
+			Prefix: "/api",
+		}, {
+			Header: &contour_api_v1.HeaderMatchCondition{
+				Name:    "x-version",
+				Present: true,
+			},
+		}},
+	}, {
+		Fqdn:       "b.example.com",
+		Index:      0,
+		Conditions: []contour_api_v1.MatchCondition{{Prefix: "/b"}},
+	}, {
+		Fqdn:  "b.example.com",
+		Index: 1,
+		Conditions: []contour_api_v1.MatchCondition{{
+			Prefix: "/b",
+		}, {
+			Prefix: "/api",
+		}, {
+			Header: &contour_api_v1.HeaderMatchCondition{
+				Name:    "x-version",
+				Present: true,
+			},
+		}},
+	}}, got.Status.EffectiveRoutes)
+
+	// Root HTTPProxies without routes have no effective routes.
+	got = updates[types.NamespacedName{Namespace: "default", Name: "a.example.com"}].Mutate(root("a.example.com", "/a")).(*contour_api_v1.HTTPProxy)
+	assert.Empty(t, got.Status.EffectiveRoutes)
+}
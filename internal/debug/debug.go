@@ -16,8 +16,11 @@
 package debug
 
 import (
+	"errors"
 	"net/http"
 	"net/http/pprof"
+	"strings"
+	"sync"
 
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/httpsvc"
@@ -27,14 +30,43 @@ import (
 type Service struct {
 	httpsvc.Service
 
-	Builder *dag.Builder
+	// Reviewer, if set, lets requests for the portion of the DAG
+	// in a namespace be authorized with a bearer token that it
+	// allows, instead of the bearer token of the Service.
+	Reviewer NamespaceReviewer
+
+	mu     sync.Mutex
+	latest *dag.DAG
+}
+
+// OnChange records d as the DAG served by the Service. The Service
+// never builds a DAG itself, so that it doesn't race with the
+// event handler.
+func (svc *Service) OnChange(d *dag.DAG) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.latest = d
+}
+
+// dag returns the most recently built DAG, or nil if
+// none has been built yet.
+func (svc *Service) dag() *dag.DAG {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	return svc.latest
 }
 
 // Start fulfills the g.Start contract.
 // When stop is closed the http server will shutdown.
 func (svc *Service) Start(stop <-chan struct{}) error {
 	registerProfile(&svc.ServeMux)
-	registerDotWriter(&svc.ServeMux, svc.Builder)
+	registerDotWriter(&svc.ServeMux, svc.dag, svc.Reviewer)
+	registerFQDNWriter(&svc.ServeMux, svc.dag)
+	if svc.Reviewer != nil {
+		svc.Service.TokenExempt = func(r *http.Request) bool {
+			return r.URL.Path == "/debug/dag" && r.URL.Query().Get("namespace") != ""
+		}
+	}
 	return svc.Service.Start(stop)
 }
 
@@ -50,10 +82,48 @@ func registerProfile(mux *http.ServeMux) {
 	mux.Handle("/debug/pprof/threadcreate", pprof.Handler("threadcreate"))
 }
 
-func registerDotWriter(mux *http.ServeMux, builder *dag.Builder) {
+// registerDotWriter registers /debug/dag, which writes the DAG
+// returned by latest in DOT format. If the namespace query parameter
+// is set, only the routes and TCP proxies built from objects in that
+// namespace, and the vertices that lead to them, are written. If
+// reviewer is set, such requests must carry a bearer token that it
+// allows.
+func registerDotWriter(mux *http.ServeMux, latest func() *dag.DAG, reviewer NamespaceReviewer) {
 	mux.HandleFunc("/debug/dag", func(w http.ResponseWriter, r *http.Request) {
+		namespace := r.URL.Query().Get("namespace")
+		if namespace != "" && reviewer != nil {
+			authorization := r.Header.Get("Authorization")
+			token := strings.TrimPrefix(authorization, "Bearer ")
+			if token == "" || token == authorization {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			allowed, err := reviewer.Allowed(r.Context(), token, namespace)
+			if errors.Is(err, ErrReviewRateLimited) {
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+		}
+
+		d := latest()
+		if d == nil {
+			http.Error(w, "the DAG has not been built yet", http.StatusServiceUnavailable)
+			return
+		}
+
 		dw := &dotWriter{
-			Builder: builder,
+			DAG:       d,
+			Namespace: namespace,
 		}
 		dw.writeDot(w)
 	})
@@ -0,0 +1,228 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// allowToken is a NamespaceReviewer that allows the token
+// "tenant" to access the namespace "marketing".
+type allowToken struct{}
+
+func (allowToken) Allowed(_ context.Context, token, namespace string) (bool, error) {
+	return token == "tenant" && namespace == "marketing", nil
+}
+
+func TestDotWriterNamespace(t *testing.T) {
+	builder := &dag.Builder{
+		Source: dag.KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []dag.Processor{
+			&dag.HTTPProxyProcessor{},
+			&dag.ListenerProcessor{},
+		},
+	}
+
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: fixture.ObjectMeta(name),
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol:   "TCP",
+					Port:       8080,
+					TargetPort: intstr.FromInt(8080),
+				}},
+			},
+		}
+	}
+
+	for _, o := range []interface{}{
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: fixture.ObjectMeta("default/root"),
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName: "secret",
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "app",
+						Port: 8080,
+					}},
+				}},
+				Includes: []contour_api_v1.Include{{
+					Name:      "blog",
+					Namespace: "marketing",
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: "/blog",
+					}},
+				}},
+			},
+		},
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: fixture.ObjectMeta("marketing/blog"),
+			Spec: contour_api_v1.HTTPProxySpec{
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "blog",
+						Port: 8080,
+					}},
+				}},
+			},
+		},
+		service("default/app"),
+		service("marketing/blog"),
+		&v1.Secret{
+			ObjectMeta: fixture.ObjectMeta("default/secret"),
+			Type:       v1.SecretTypeTLS,
+			Data: map[string][]byte{
+				v1.TLSCertKey:       []byte(fixture.CERTIFICATE),
+				v1.TLSPrivateKeyKey: []byte(fixture.RSA_PRIVATE_KEY),
+			},
+		},
+	} {
+		builder.Source.Insert(o)
+	}
+
+	mux := &http.ServeMux{}
+	registerDotWriter(mux, builder.Build, allowToken{})
+
+	get := func(url, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", url, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/debug/dag", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "service|default/app:8080")
+	assert.Contains(t, w.Body.String(), "service|marketing/blog:8080")
+	assert.Contains(t, w.Body.String(), "secret|default/secret")
+
+	assert.Equal(t, http.StatusUnauthorized, get("/debug/dag?namespace=marketing", "").Code)
+	assert.Equal(t, http.StatusForbidden, get("/debug/dag?namespace=marketing", "wrong").Code)
+	assert.Equal(t, http.StatusForbidden, get("/debug/dag?namespace=default", "tenant").Code)
+
+	w = get("/debug/dag?namespace=marketing", "tenant")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com")
+	assert.Contains(t, w.Body.String(), "service|marketing/blog:8080")
+	assert.NotContains(t, w.Body.String(), "service|default/app:8080")
+	assert.NotContains(t, w.Body.String(), "secret|default/secret")
+}
+
+func TestTokenReviewer(t *testing.T) {
+	reviews := 0
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "tenant" {
+			review.Status.Authenticated = true
+			review.Status.User.Username = "tenant"
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "tenant" &&
+			attrs.Namespace == "marketing" &&
+			attrs.Verb == "get" &&
+			attrs.Group == "projectcontour.io" &&
+			attrs.Resource == "httpproxies"
+		return true, review, nil
+	})
+
+	reviewer := &TokenReviewer{Client: client}
+
+	tests := map[string]struct {
+		token     string
+		namespace string
+		want      bool
+	}{
+		"allowed":         {token: "tenant", namespace: "marketing", want: true},
+		"other namespace": {token: "tenant", namespace: "default", want: false},
+		"unauthenticated": {token: "wrong", namespace: "marketing", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			allowed, err := reviewer.Allowed(context.Background(), tc.token, tc.namespace)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, allowed)
+		})
+	}
+
+	// Assert the results are cached.
+	reviews = 0
+	for name, tc := range tests {
+		allowed, err := reviewer.Allowed(context.Background(), tc.token, tc.namespace)
+		assert.NoError(t, err, name)
+		assert.Equal(t, tc.want, allowed, name)
+	}
+	assert.Equal(t, 0, reviews)
+
+	// Assert the results expire.
+	reviewer = &TokenReviewer{Client: client, TTL: time.Nanosecond}
+	for i := 0; i < 2; i++ {
+		allowed, err := reviewer.Allowed(context.Background(), "tenant", "marketing")
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Equal(t, 2, reviews)
+
+	// Assert the reviews that aren't cached are rate limited.
+	reviewer = &TokenReviewer{Client: client, Limiter: rate.NewLimiter(rate.Every(time.Hour), 1)}
+	_, err := reviewer.Allowed(context.Background(), "tenant", "marketing")
+	assert.NoError(t, err)
+	_, err = reviewer.Allowed(context.Background(), "tenant", "marketing")
+	assert.NoError(t, err)
+	_, err = reviewer.Allowed(context.Background(), "tenant", "default")
+	assert.Equal(t, ErrReviewRateLimited, err)
+}
+
+func TestDotWriterNotBuilt(t *testing.T) {
+	mux := &http.ServeMux{}
+	registerDotWriter(mux, func() *dag.DAG { return nil }, nil)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/debug/dag", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
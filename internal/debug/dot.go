@@ -24,7 +24,11 @@ import (
 // quick and dirty dot debugging package
 
 type dotWriter struct {
-	*dag.Builder
+	*dag.DAG
+
+	// Namespace, if set, limits the graph to the routes and TCP
+	// proxies built from objects in this namespace.
+	Namespace string
 }
 
 type pair struct {
@@ -80,16 +84,52 @@ func (dw *dotWriter) writeDot(w io.Writer) {
 		edges: make(map[pair]bool),
 	}
 
-	var visit func(dag.Vertex)
-	visit = func(parent dag.Vertex) {
-		ctx.writeVertex(parent)
-		parent.Visit(func(child dag.Vertex) {
-			visit(child)
-			ctx.writeEdge(parent, child)
-		})
+	dw.DAG.Visit(func(v dag.Vertex) {
+		dw.visit(ctx, v, dw.Namespace == "")
+	})
+
+	fmt.Fprintln(w, "}")
+}
+
+// visit writes v and the vertices reachable from it, and returns
+// true if v was written. If all is false, only the routes and TCP
+// proxies in dw.Namespace are written, along with their children,
+// the vertices that lead to them, and the secrets in dw.Namespace
+// of the virtual hosts that are written.
+func (dw *dotWriter) visit(ctx *ctx, v dag.Vertex, all bool) bool {
+	if !all {
+		switch v := v.(type) {
+		case *dag.Route:
+			all = v.Source.Namespace == dw.Namespace
+		case *dag.TCPProxy:
+			all = v.Source.Namespace == dw.Namespace
+		}
 	}
 
-	dw.Builder.Build().Visit(visit)
+	write := all
+	var children []dag.Vertex
+	v.Visit(func(child dag.Vertex) {
+		// A secret doesn't make its virtual host visible, so
+		// it is only written once its virtual host is.
+		if secret, ok := child.(*dag.Secret); ok && !all {
+			if secret.Namespace() == dw.Namespace {
+				children = append(children, child)
+			}
+			return
+		}
+		if dw.visit(ctx, child, all) {
+			children = append(children, child)
+			write = true
+		}
+	})
+	if !write {
+		return false
+	}
 
-	fmt.Fprintln(w, "}")
+	ctx.writeVertex(v)
+	for _, child := range children {
+		ctx.writeVertex(child)
+		ctx.writeEdge(v, child)
+	}
+	return true
 }
@@ -0,0 +1,273 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/projectcontour/contour/internal/dag"
+)
+
+// FQDN is the effective configuration of a fully qualified
+// domain name in the DAG, as served by /debug/fqdn.
+type FQDN struct {
+	Name         string        `json:"name"`
+	VirtualHosts []VirtualHost `json:"virtualHosts"`
+}
+
+// VirtualHost is the configuration of the FQDN on one listener.
+type VirtualHost struct {
+	Listener string `json:"listener"`
+
+	// TLS is set for secure virtual hosts.
+	TLS *TLS `json:"tls,omitempty"`
+
+	Routes []Route `json:"routes,omitempty"`
+
+	// TCPProxy lists the backends that TLS connections
+	// are forwarded to, if the virtual host proxies TCP.
+	TCPProxy []Backend `json:"tcpProxy,omitempty"`
+
+	// Sources are the Kubernetes objects that the routes
+	// and TCP proxy of the virtual host were built from,
+	// formatted as kind/namespace/name.
+	Sources []string `json:"sources,omitempty"`
+}
+
+// TLS is the TLS configuration of a secure virtual host.
+type TLS struct {
+	// Secret is the namespace/name of the certificate Secret.
+	// It is empty when TLS is passed through to the backend.
+	Secret                 string `json:"secret,omitempty"`
+	FallbackCertificate    string `json:"fallbackCertificate,omitempty"`
+	MinimumProtocolVersion string `json:"minimumProtocolVersion,omitempty"`
+	ClientValidation       bool   `json:"clientValidation,omitempty"`
+
+	// Authorization is the name of the authorization
+	// server cluster, if external authorization is enabled.
+	Authorization string `json:"authorization,omitempty"`
+}
+
+// Route is the configuration of a route of a virtual host.
+type Route struct {
+	Match    string    `json:"match"`
+	Headers  []string  `json:"headers,omitempty"`
+	Backends []Backend `json:"backends,omitempty"`
+
+	// Redirect and DirectResponse describe routes
+	// that are not forwarded to backends.
+	Redirect       string `json:"redirect,omitempty"`
+	DirectResponse uint32 `json:"directResponse,omitempty"`
+
+	HTTPSUpgrade bool   `json:"httpsUpgrade,omitempty"`
+	Websocket    bool   `json:"websocket,omitempty"`
+	Source       string `json:"source,omitempty"`
+}
+
+// Backend is a service that a route or TCP proxy forwards to.
+type Backend struct {
+	// Service is formatted as namespace/name:port.
+	Service  string `json:"service"`
+	Weight   uint32 `json:"weight"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// registerFQDNWriter registers /debug/fqdn, which writes the
+// configuration of the FQDN named by the name query parameter
+// in the DAG returned by latest as JSON.
+func registerFQDNWriter(mux *http.ServeMux, latest func() *dag.DAG) {
+	mux.HandleFunc("/debug/fqdn", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "the name query parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		d := latest()
+		if d == nil {
+			http.Error(w, "the DAG has not been built yet", http.StatusServiceUnavailable)
+			return
+		}
+
+		fqdn := FQDNOf(d, name)
+		if len(fqdn.VirtualHosts) == 0 {
+			http.Error(w, fmt.Sprintf("no virtual host found for %q", name), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(fqdn); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// FQDNOf returns the configuration of the virtual hosts of the
+// given DAG that are named name, ordered by listener name.
+func FQDNOf(d *dag.DAG, name string) FQDN {
+	fqdn := FQDN{
+		Name: name,
+	}
+
+	for ln, vh := range d.GetVirtualHosts() {
+		if ln.Name == name {
+			fqdn.VirtualHosts = append(fqdn.VirtualHosts, virtualHostOf(vh, nil))
+		}
+	}
+	for ln, svh := range d.GetSecureVirtualHosts() {
+		if ln.Name == name {
+			fqdn.VirtualHosts = append(fqdn.VirtualHosts, virtualHostOf(&svh.VirtualHost, svh))
+		}
+	}
+
+	sort.Slice(fqdn.VirtualHosts, func(i, j int) bool {
+		return fqdn.VirtualHosts[i].Listener < fqdn.VirtualHosts[j].Listener
+	})
+
+	return fqdn
+}
+
+// virtualHostOf returns the configuration of vh. If vh is
+// the virtual host of a secure virtual host, svh is set.
+func virtualHostOf(vh *dag.VirtualHost, svh *dag.SecureVirtualHost) VirtualHost {
+	v := VirtualHost{
+		Listener: vh.ListenerName,
+	}
+	sources := map[string]bool{}
+
+	vh.Visit(func(vertex dag.Vertex) {
+		if route, ok := vertex.(*dag.Route); ok {
+			r := routeOf(route)
+			// Routes are shared with the insecure virtual host,
+			// but are only upgraded to HTTPS on it.
+			r.HTTPSUpgrade = r.HTTPSUpgrade && svh == nil
+			v.Routes = append(v.Routes, r)
+			sources[r.Source] = true
+		}
+	})
+	sort.Slice(v.Routes, func(i, j int) bool {
+		if v.Routes[i].Match != v.Routes[j].Match {
+			return v.Routes[i].Match < v.Routes[j].Match
+		}
+		return strings.Join(v.Routes[i].Headers, ",") < strings.Join(v.Routes[j].Headers, ",")
+	})
+
+	if svh != nil {
+		tls := &TLS{
+			MinimumProtocolVersion: svh.MinTLSVersion,
+			ClientValidation:       svh.DownstreamValidation != nil,
+		}
+		if svh.Secret != nil {
+			tls.Secret = svh.Secret.Namespace() + "/" + svh.Secret.Name()
+		}
+		if svh.FallbackCertificate != nil {
+			tls.FallbackCertificate = svh.FallbackCertificate.Namespace() + "/" + svh.FallbackCertificate.Name()
+		}
+		if svh.AuthorizationService != nil {
+			tls.Authorization = svh.AuthorizationService.Name
+		}
+		v.TLS = tls
+
+		if svh.TCPProxy != nil {
+			v.TCPProxy = backendsOf(svh.TCPProxy.Clusters)
+			sources[sourceOf(svh.TCPProxy.Source)] = true
+		}
+	}
+
+	for source := range sources {
+		if source != "" {
+			v.Sources = append(v.Sources, source)
+		}
+	}
+	sort.Strings(v.Sources)
+
+	return v
+}
+
+func routeOf(route *dag.Route) Route {
+	r := Route{
+		Match:        route.PathMatchCondition.String(),
+		Backends:     backendsOf(route.Clusters),
+		HTTPSUpgrade: route.HTTPSUpgrade,
+		Websocket:    route.Websocket,
+		Source:       sourceOf(route.Source),
+	}
+
+	for _, hc := range route.HeaderMatchConditions {
+		r.Headers = append(r.Headers, headerOf(hc))
+	}
+	if route.Redirect != nil {
+		r.Redirect = fmt.Sprintf("%d %s", route.Redirect.StatusCode, redirectTarget(route.Redirect))
+	}
+	if route.DirectResponse != nil {
+		r.DirectResponse = route.DirectResponse.StatusCode
+	}
+
+	return r
+}
+
+// headerOf formats a header match condition as the header
+// name, the match type and, unless it is a presence match,
+// the value.
+func headerOf(hc dag.HeaderMatchCondition) string {
+	s := hc.Name
+	if hc.Invert {
+		s += " not"
+	}
+	s += " " + hc.MatchType
+	if hc.MatchType != dag.HeaderMatchTypePresent {
+		s += " " + hc.Value
+	}
+	return s
+}
+
+// redirectTarget formats the parts of the request URL
+// that the redirect replaces.
+func redirectTarget(r *dag.Redirect) string {
+	var parts []string
+	if r.Hostname != "" {
+		parts = append(parts, "hostname="+r.Hostname)
+	}
+	if r.Path != "" {
+		parts = append(parts, "path="+r.Path)
+	}
+	return strings.Join(parts, ",")
+}
+
+func backendsOf(clusters []*dag.Cluster) []Backend {
+	var backends []Backend
+	for _, c := range clusters {
+		if c.Upstream == nil {
+			continue
+		}
+		backends = append(backends, Backend{
+			Service: fmt.Sprintf("%s/%s:%d",
+				c.Upstream.Weighted.ServiceNamespace, c.Upstream.Weighted.ServiceName, c.Upstream.Weighted.ServicePort.Port),
+			Weight:   c.Weight,
+			Protocol: c.Protocol,
+		})
+	}
+	return backends
+}
+
+func sourceOf(ref dag.ObjectReference) string {
+	if ref.Kind == "" {
+		return ""
+	}
+	return ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+}
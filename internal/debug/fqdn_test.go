@@ -0,0 +1,148 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestFQDNOf(t *testing.T) {
+	builder := dag.Builder{
+		Source: dag.KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []dag.Processor{
+			&dag.HTTPProxyProcessor{},
+			&dag.ListenerProcessor{},
+		},
+	}
+
+	service := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: fixture.ObjectMeta(name),
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Protocol:   "TCP",
+					Port:       8080,
+					TargetPort: intstr.FromInt(8080),
+				}},
+			},
+		}
+	}
+
+	for _, o := range []interface{}{
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: fixture.ObjectMeta("default/root"),
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName: "secret",
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "app",
+						Port: 8080,
+					}},
+				}},
+				Includes: []contour_api_v1.Include{{
+					Name:      "blog",
+					Namespace: "marketing",
+					Conditions: []contour_api_v1.MatchCondition{{
+						Prefix: "/blog",
+					}},
+				}},
+			},
+		},
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: fixture.ObjectMeta("marketing/blog"),
+			Spec: contour_api_v1.HTTPProxySpec{
+				Routes: []contour_api_v1.Route{{
+					Conditions: []contour_api_v1.MatchCondition{{
+						Header: &contour_api_v1.HeaderMatchCondition{
+							Name:    "x-beta",
+							Present: true,
+						},
+					}},
+					Services: []contour_api_v1.Service{{
+						Name:   "blog",
+						Port:   8080,
+						Weight: 100,
+					}},
+				}},
+			},
+		},
+		service("default/app"),
+		service("marketing/blog"),
+		&v1.Secret{
+			ObjectMeta: fixture.ObjectMeta("default/secret"),
+			Type:       v1.SecretTypeTLS,
+			Data: map[string][]byte{
+				v1.TLSCertKey:       []byte(fixture.CERTIFICATE),
+				v1.TLSPrivateKeyKey: []byte(fixture.RSA_PRIVATE_KEY),
+			},
+		},
+	} {
+		builder.Source.Insert(o)
+	}
+
+	d := builder.Build()
+
+	assert.Equal(t, FQDN{
+		Name: "example.com",
+		VirtualHosts: []VirtualHost{{
+			Listener: "ingress_http",
+			Routes: []Route{{
+				Match:        "prefix: / type: string",
+				HTTPSUpgrade: true,
+				Backends:     []Backend{{Service: "default/app:8080"}},
+				Source:       "HTTPProxy/default/root",
+			}, {
+				Match:        "prefix: /blog type: string",
+				Headers:      []string{"x-beta present"},
+				HTTPSUpgrade: true,
+				Backends:     []Backend{{Service: "marketing/blog:8080", Weight: 100}},
+				Source:       "HTTPProxy/marketing/blog",
+			}},
+			Sources: []string{"HTTPProxy/default/root", "HTTPProxy/marketing/blog"},
+		}, {
+			Listener: "ingress_https",
+			TLS: &TLS{
+				Secret:                 "default/secret",
+				MinimumProtocolVersion: "1.2",
+			},
+			Routes: []Route{{
+				Match:    "prefix: / type: string",
+				Backends: []Backend{{Service: "default/app:8080"}},
+				Source:   "HTTPProxy/default/root",
+			}, {
+				Match:    "prefix: /blog type: string",
+				Headers:  []string{"x-beta present"},
+				Backends: []Backend{{Service: "marketing/blog:8080", Weight: 100}},
+				Source:   "HTTPProxy/marketing/blog",
+			}},
+			Sources: []string{"HTTPProxy/default/root", "HTTPProxy/marketing/blog"},
+		}},
+	}, FQDNOf(d, "example.com"))
+
+	assert.Empty(t, FQDNOf(d, "missing.example.com").VirtualHosts)
+}
@@ -0,0 +1,171 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"golang.org/x/time/rate"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultReviewTTL is the default time for which a TokenReviewer
+// caches the result of a review.
+const DefaultReviewTTL = 10 * time.Second
+
+// ErrReviewRateLimited is returned by a TokenReviewer when a review
+// would exceed the rate at which it may send reviews.
+var ErrReviewRateLimited = errors.New("too many token reviews")
+
+// Add RBAC policy to support authorizing namespaced debug requests.
+// +kubebuilder:rbac:groups="authentication.k8s.io",resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups="authorization.k8s.io",resources=subjectaccessreviews,verbs=create
+
+// NamespaceReviewer decides whether a bearer token grants access
+// to the portion of the DAG in a namespace.
+type NamespaceReviewer interface {
+	Allowed(ctx context.Context, token, namespace string) (bool, error)
+}
+
+// TokenReviewer is a NamespaceReviewer that authenticates bearer
+// tokens with the Kubernetes API server, and allows access to a
+// namespace to the users that may get HTTPProxies in it.
+type TokenReviewer struct {
+	Client kubernetes.Interface
+
+	// TTL is the time for which the result of a review is
+	// cached. If zero, DefaultReviewTTL is used.
+	TTL time.Duration
+
+	// Limiter, if not nil, limits the rate at which reviews
+	// are sent to the API server. Cached results are not
+	// subject to it.
+	Limiter *rate.Limiter
+
+	mu      sync.Mutex
+	reviews map[reviewKey]reviewResult
+}
+
+// reviewKey identifies the review of a token, by its hash,
+// for a namespace.
+type reviewKey struct {
+	token     [sha256.Size]byte
+	namespace string
+}
+
+type reviewResult struct {
+	allowed bool
+	expires time.Time
+}
+
+// Allowed returns true if token authenticates a user that may
+// get HTTPProxies in namespace. It returns ErrReviewRateLimited
+// if the result isn't cached and t.Limiter doesn't allow a review.
+func (t *TokenReviewer) Allowed(ctx context.Context, token, namespace string) (bool, error) {
+	key := reviewKey{
+		token:     sha256.Sum256([]byte(token)),
+		namespace: namespace,
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	result, ok := t.reviews[key]
+	t.mu.Unlock()
+	if ok && now.Before(result.expires) {
+		return result.allowed, nil
+	}
+
+	if t.Limiter != nil && !t.Limiter.Allow() {
+		return false, ErrReviewRateLimited
+	}
+
+	allowed, err := t.review(ctx, token, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	ttl := t.TTL
+	if ttl == 0 {
+		ttl = DefaultReviewTTL
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.reviews == nil {
+		t.reviews = map[reviewKey]reviewResult{}
+	}
+	// Forget the expired results, so that the cache
+	// doesn't grow with every token ever reviewed.
+	for k, r := range t.reviews {
+		if !now.Before(r.expires) {
+			delete(t.reviews, k)
+		}
+	}
+	t.reviews[key] = reviewResult{
+		allowed: allowed,
+		expires: now.Add(ttl),
+	}
+
+	return allowed, nil
+}
+
+// review asks the API server whether token authenticates a user
+// that may get HTTPProxies in namespace.
+func (t *TokenReviewer) review(ctx context.Context, token, namespace string) (bool, error) {
+	review, err := t.Client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: token,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !review.Status.Authenticated {
+		return false, nil
+	}
+
+	user := review.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	access, err := t.Client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Group:     contour_api_v1.GroupName,
+				Resource:  "httpproxies",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return access.Status.Allowed, nil
+}
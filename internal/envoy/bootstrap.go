@@ -17,6 +17,7 @@ package envoy
 
 import (
 	"os"
+	"time"
 
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
@@ -88,6 +89,30 @@ type BootstrapConfig struct {
 	// DNSLookupFamily specifies DNS Resolution Policy to use for Envoy -> Contour cluster name lookup.
 	// Either v4, v6 or auto.
 	DNSLookupFamily string
+
+	// NodeID is the Envoy node id. Envoy's --service-node
+	// command line option overrides it.
+	NodeID string
+
+	// NodeCluster is the Envoy node cluster id. Envoy's
+	// --service-cluster command line option overrides it.
+	NodeCluster string
+
+	// LocalityRegion, LocalityZone and LocalitySubZone are the
+	// Envoy node locality, used by locality aware load balancing.
+	// Envoy's --service-zone command line option overrides the zone.
+	LocalityRegion  string
+	LocalityZone    string
+	LocalitySubZone string
+
+	// XDSInitialFetchTimeout is how long Envoy waits for the first
+	// xDS response before it starts its listeners anyway. If zero,
+	// Envoy's default of 15s is used.
+	XDSInitialFetchTimeout time.Duration
+
+	// XDSRateLimitQPS, if set, limits the rate of the xDS requests
+	// that Envoy sends to Contour, in requests per second.
+	XDSRateLimitQPS float64
 }
 
 func (c *BootstrapConfig) GetXdsAddress() string { return stringOrDefault(c.XDSAddress, "127.0.0.1") }
@@ -24,7 +24,47 @@ import (
 )
 
 // Clustername returns the name of the CDS cluster for this service.
+// If the cluster has been named by a ClusterNamer, that name is used.
 func Clustername(cluster *dag.Cluster) string {
+	if cluster.Name != "" {
+		return cluster.Name
+	}
+
+	ns, name, port := clusterService(cluster)
+
+	// This isn't a crypto hash, we just want a unique name.
+	hash := sha1.Sum([]byte(clusterProperties(cluster))) // nolint:gosec
+
+	return Hashname(60, ns, name, port, fmt.Sprintf("%x", hash[:5]))
+}
+
+// FullClustername returns the untruncated name of the CDS cluster for
+// this service. A hash of the cluster's properties is only appended if
+// they differ from the defaults.
+func FullClustername(cluster *dag.Cluster) string {
+	ns, name, port := clusterService(cluster)
+
+	props := clusterProperties(cluster)
+	if props == "" {
+		return strings.Join([]string{ns, name, port}, "/")
+	}
+
+	// This isn't a crypto hash, we just want a unique name.
+	hash := sha1.Sum([]byte(props)) // nolint:gosec
+
+	return strings.Join([]string{ns, name, port, fmt.Sprintf("%x", hash[:5])}, "/")
+}
+
+// clusterService returns the namespace, name and port of the
+// service of the cluster.
+func clusterService(cluster *dag.Cluster) (string, string, string) {
+	service := cluster.Upstream
+	return service.Weighted.ServiceNamespace, service.Weighted.ServiceName, strconv.Itoa(int(service.Weighted.ServicePort.Port))
+}
+
+// clusterProperties returns the properties of the cluster that
+// distinguish it from other clusters of the same service.
+func clusterProperties(cluster *dag.Cluster) string {
 	service := cluster.Upstream
 	buf := cluster.LoadBalancerPolicy
 	if hc := cluster.HTTPHealthCheckPolicy; hc != nil {
@@ -44,15 +84,35 @@ func Clustername(cluster *dag.Cluster) string {
 	}
 	if uv := cluster.UpstreamValidation; uv != nil {
 		buf += uv.CACertificate.Object.ObjectMeta.Name
+		for _, ca := range uv.AdditionalCACertificates {
+			buf += ca.Object.ObjectMeta.Name
+		}
 		buf += uv.SubjectName
+		for _, san := range uv.SubjectAltNames {
+			buf += san.Value
+		}
 	}
-
-	// This isn't a crypto hash, we just want a unique name.
-	hash := sha1.Sum([]byte(buf)) // nolint:gosec
-
-	ns := service.Weighted.ServiceNamespace
-	name := service.Weighted.ServiceName
-	return Hashname(60, ns, name, strconv.Itoa(int(service.Weighted.ServicePort.Port)), fmt.Sprintf("%x", hash[:5]))
+	if ut := cluster.UpstreamTLS; ut != nil {
+		buf += ut.MinimumProtocolVersion
+		buf += ut.MaximumProtocolVersion
+		buf += strings.Join(ut.CipherSuites, ",")
+	}
+	if service.ExternalName != "" && cluster.DNSLookupFamilyOverridden {
+		buf += cluster.DNSLookupFamily
+	}
+	if cluster.EnableTrailers {
+		buf += "trailers"
+	}
+	if fc := cluster.FailoverCluster(); fc != nil {
+		buf += fc.ClusterName
+	}
+	if lb := cluster.LBConfig; lb.HealthyPanicThreshold > 0 {
+		buf += "panic" + strconv.Itoa(int(lb.HealthyPanicThreshold))
+	}
+	if cluster.MaxRequestsPerConnection > 0 {
+		buf += "mrpc" + strconv.FormatUint(uint64(cluster.MaxRequestsPerConnection), 10)
+	}
+	return buf
 }
 
 // AltStatName generates an alternative stat name for the service
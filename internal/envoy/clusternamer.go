@@ -0,0 +1,175 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/projectcontour/contour/pkg/config"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ClusterNamer is a DAG processor that names the clusters in the DAG
+// using the configured naming strategy. If two different clusters
+// would be given the same name, it gives the later cluster a distinct
+// name, rather than letting Envoy merge them, and sets an error
+// condition on the HTTPProxy or route that the cluster belongs to.
+//
+// ClusterNamer must run after all the processors that add clusters.
+type ClusterNamer struct {
+	logrus.FieldLogger
+
+	// Naming is the cluster naming strategy. If not set,
+	// config.HashedClusterNaming is used.
+	Naming config.ClusterNamingType
+}
+
+// Run names the clusters in the DAG.
+func (n *ClusterNamer) Run(d *dag.DAG, _ *dag.KubernetesCache) {
+	var clusters []*dag.Cluster
+
+	// sources holds the objects whose routes or TCP
+	// proxies each cluster was reached through.
+	sources := map[*dag.Cluster][]dag.ObjectReference{}
+
+	var visit func(dag.Vertex, *dag.ObjectReference)
+	visit = func(v dag.Vertex, source *dag.ObjectReference) {
+		switch v := v.(type) {
+		case *dag.Route:
+			source = &v.Source
+		case *dag.TCPProxy:
+			source = &v.Source
+		case *dag.Cluster:
+			if _, ok := sources[v]; !ok {
+				clusters = append(clusters, v)
+				sources[v] = nil
+			}
+			if source != nil {
+				sources[v] = append(sources[v], *source)
+			}
+		}
+		v.Visit(func(child dag.Vertex) {
+			visit(child, source)
+		})
+	}
+	d.Visit(func(v dag.Vertex) {
+		visit(v, nil)
+	})
+
+	names := make(map[*dag.Cluster]string, len(clusters))
+	keys := make(map[*dag.Cluster]string, len(clusters))
+	for _, c := range clusters {
+		names[c] = n.name(c)
+		keys[c] = clusterKey(c)
+	}
+
+	// Resolve collisions in a stable order, so that the same
+	// cluster keeps its name across DAG rebuilds.
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if names[clusters[i]] != names[clusters[j]] {
+			return names[clusters[i]] < names[clusters[j]]
+		}
+		return keys[clusters[i]] < keys[clusters[j]]
+	})
+
+	// owners maps each cluster name to the key of the
+	// cluster that it was given to.
+	owners := map[string]string{}
+	for _, c := range clusters {
+		name, key := names[c], keys[c]
+
+		resolved := name
+		for i := 1; ; i++ {
+			owner, ok := owners[resolved]
+			if !ok || owner == key {
+				break
+			}
+			resolved = fmt.Sprintf("%s-%d", name, i)
+		}
+
+		if resolved != name {
+			if _, ok := owners[resolved]; !ok && n.FieldLogger != nil {
+				n.WithField("cluster", name).
+					WithField("renamed", resolved).
+					Error("cluster name collision between clusters of the same service with different properties")
+			}
+			for _, source := range sources[c] {
+				reportCollision(d, source, name, resolved)
+			}
+		}
+
+		owners[resolved] = key
+		c.Name = resolved
+	}
+}
+
+// reportCollision sets an error condition on the source of a cluster
+// that was renamed because its name collided with another cluster.
+func reportCollision(d *dag.DAG, source dag.ObjectReference, name, renamed string) {
+	fullname := types.NamespacedName{Namespace: source.Namespace, Name: source.Name}
+	msg := fmt.Sprintf("cluster name %q collides with a different cluster of the same service, renamed to %q", name, renamed)
+
+	switch source.Kind {
+	case "HTTPProxy":
+		if pu := d.StatusCache.CommittedProxyUpdate(fullname); pu != nil {
+			pu.ConditionFor(status.ValidCondition).AddError(contour_api_v1.ConditionTypeServiceError, "ClusterNameCollision", msg)
+		}
+	case "HTTPRoute":
+		if ru := d.StatusCache.CommittedRouteUpdate(fullname, status.ResourceHTTPRoute); ru != nil {
+			ru.AddError(status.ConditionUniqueClusterNames, status.ReasonClusterNameCollision, msg)
+		}
+	case "TLSRoute":
+		if ru := d.StatusCache.CommittedRouteUpdate(fullname, status.ResourceTLSRoute); ru != nil {
+			ru.AddError(status.ConditionUniqueClusterNames, status.ReasonClusterNameCollision, msg)
+		}
+	}
+}
+
+// name returns the name of the cluster given by the naming strategy.
+func (n *ClusterNamer) name(cluster *dag.Cluster) string {
+	switch n.Naming {
+	case config.FullClusterNaming:
+		return FullClustername(cluster)
+	default:
+		cluster.Name = ""
+		return Clustername(cluster)
+	}
+}
+
+// clusterKey returns a hash of the cluster's fields, other than its
+// name and its weight on the route, so that clusters that would be
+// configured differently in Envoy have different keys.
+func clusterKey(cluster *dag.Cluster) string {
+	c := *cluster
+	c.Name = ""
+	c.Weight = 0
+
+	data, err := json.Marshal(&c)
+	if err != nil {
+		// Fall back to the identity of the cluster, which keeps
+		// it from sharing a name with any other cluster.
+		return fmt.Sprintf("%p", cluster)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
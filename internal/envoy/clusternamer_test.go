@@ -0,0 +1,184 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/status"
+	"github.com/projectcontour/contour/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayapi_v1alpha1 "sigs.k8s.io/gateway-api/apis/v1alpha1"
+)
+
+func cluster(namespace, name string, port int32) *dag.Cluster {
+	return &dag.Cluster{
+		Upstream: &dag.Service{
+			Weighted: dag.WeightedService{
+				Weight:           1,
+				ServiceName:      name,
+				ServiceNamespace: namespace,
+				ServicePort: v1.ServicePort{
+					Protocol: "TCP",
+					Port:     port,
+				},
+			},
+		},
+	}
+}
+
+func TestFullClustername(t *testing.T) {
+	c := cluster("it-is-a-truth-universally-acknowledged-that-a-single-man-in-possession-of-a-good-fortune", "must-be-in-want-of-a-wife", 9999)
+	assert.Equal(t, "it-is-a-truth-universally-acknowledged-that-a-single-man-in-possession-of-a-good-fortune/must-be-in-want-of-a-wife/9999", FullClustername(c))
+
+	c.LoadBalancerPolicy = "Random"
+	assert.Equal(t, "it-is-a-truth-universally-acknowledged-that-a-single-man-in-possession-of-a-good-fortune/must-be-in-want-of-a-wife/9999/58d888c08a", FullClustername(c))
+}
+
+func TestClusterNamer(t *testing.T) {
+	tests := map[string]struct {
+		naming   config.ClusterNamingType
+		clusters []*dag.Cluster
+		want     []string
+	}{
+		"hashed": {
+			clusters: []*dag.Cluster{
+				cluster("default", "kuard", 8080),
+				cluster("default", "kuard", 8080),
+			},
+			want: []string{
+				"default/kuard/8080/da39a3ee5e",
+				"default/kuard/8080/da39a3ee5e",
+			},
+		},
+		"full": {
+			naming: config.FullClusterNaming,
+			clusters: []*dag.Cluster{
+				cluster("default", "kuard", 8080),
+				cluster("default", "kuard", 8081),
+			},
+			want: []string{
+				"default/kuard/8080",
+				"default/kuard/8081",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := &dag.DAG{}
+			d.AddRoot(&dag.Route{
+				PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+				Clusters:           tc.clusters,
+			})
+
+			namer := ClusterNamer{
+				FieldLogger: fixture.NewTestLogger(t),
+				Naming:      tc.naming,
+			}
+			namer.Run(d, nil)
+
+			var got []string
+			for _, c := range tc.clusters {
+				got = append(got, c.Name)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestClusterNamerReportsCollisions(t *testing.T) {
+	// The plain and h2c clusters get the same hashed name, as the
+	// protocol isn't part of it. The cluster with the greater key
+	// is the one that's renamed.
+	kept := cluster("default", "kuard", 8080)
+	renamed := cluster("default", "kuard", 8080)
+	renamed.Protocol = "h2c"
+	if clusterKey(kept) > clusterKey(renamed) {
+		kept.Protocol, renamed.Protocol = "h2c", ""
+	}
+	same := cluster("default", "kuard", 8080)
+	same.Protocol = renamed.Protocol
+
+	d := &dag.DAG{StatusCache: status.NewCache(types.NamespacedName{})}
+
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "proxy"},
+	}
+	pa, commit := d.StatusCache.ProxyAccessor(proxy)
+	pa.ConditionFor(status.ValidCondition)
+	commit()
+
+	ra, commit := d.StatusCache.RouteConditionsAccessor(types.NamespacedName{Namespace: "default", Name: "route"}, 1, status.ResourceHTTPRoute, nil)
+	ra.AddCondition(gatewayapi_v1alpha1.ConditionRouteAdmitted, metav1.ConditionTrue, status.ReasonValid, "Valid HTTPRoute")
+	commit()
+
+	d.AddRoot(&dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+		Clusters:           []*dag.Cluster{kept},
+		Source:             dag.ObjectReference{Kind: "HTTPProxy", Namespace: "default", Name: "proxy"},
+	})
+	d.AddRoot(&dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+		Clusters:           []*dag.Cluster{renamed, same},
+		Source:             dag.ObjectReference{Kind: "HTTPRoute", Namespace: "default", Name: "route"},
+	})
+
+	namer := ClusterNamer{FieldLogger: fixture.NewTestLogger(t)}
+	namer.Run(d, nil)
+
+	// Clusters with the same key keep sharing a name.
+	assert.Equal(t, "default/kuard/8080/da39a3ee5e", kept.Name)
+	assert.Equal(t, "default/kuard/8080/da39a3ee5e-1", renamed.Name)
+	assert.Equal(t, "default/kuard/8080/da39a3ee5e-1", same.Name)
+
+	// Only the HTTPRoute, which has the renamed
+	// clusters, reports the collision.
+	pu := d.StatusCache.CommittedProxyUpdate(types.NamespacedName{Namespace: "default", Name: "proxy"})
+	require.NotNil(t, pu)
+	assert.Equal(t, contour_api_v1.ConditionTrue, pu.ConditionFor(status.ValidCondition).Status)
+
+	ru := d.StatusCache.CommittedRouteUpdate(types.NamespacedName{Namespace: "default", Name: "route"}, status.ResourceHTTPRoute)
+	require.NotNil(t, ru)
+	assert.Equal(t, metav1.ConditionFalse, ru.Conditions[status.ConditionUniqueClusterNames].Status)
+	assert.Equal(t, string(status.ReasonClusterNameCollision), ru.Conditions[status.ConditionUniqueClusterNames].Reason)
+	assert.Equal(t, metav1.ConditionFalse, ru.Conditions[gatewayapi_v1alpha1.ConditionRouteAdmitted].Status)
+	assert.Equal(t, string(status.ReasonErrorsExist), ru.Conditions[gatewayapi_v1alpha1.ConditionRouteAdmitted].Reason)
+
+	// When the HTTPProxy has the renamed cluster, it reports it.
+	d = &dag.DAG{StatusCache: status.NewCache(types.NamespacedName{})}
+	pa, commit = d.StatusCache.ProxyAccessor(proxy)
+	pa.ConditionFor(status.ValidCondition)
+	commit()
+	d.AddRoot(&dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+		Clusters:           []*dag.Cluster{renamed, kept},
+		Source:             dag.ObjectReference{Kind: "HTTPProxy", Namespace: "default", Name: "proxy"},
+	})
+	namer.Run(d, nil)
+
+	pu = d.StatusCache.CommittedProxyUpdate(types.NamespacedName{Namespace: "default", Name: "proxy"})
+	require.NotNil(t, pu)
+	valid := pu.ConditionFor(status.ValidCondition)
+	assert.Equal(t, contour_api_v1.ConditionFalse, valid.Status)
+	require.Len(t, valid.Errors, 1)
+	assert.Equal(t, "ClusterNameCollision", valid.Errors[0].Reason)
+}
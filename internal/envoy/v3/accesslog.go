@@ -14,13 +14,18 @@
 package v3
 
 import (
+	"fmt"
+
 	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	envoy_grpc_als_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	_struct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/pkg/config"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // FileAccessLogEnvoy returns a new file based access log filter
@@ -37,6 +42,55 @@ func FileAccessLogEnvoy(path string) []*envoy_accesslog_v3.AccessLog {
 	}}
 }
 
+// envoyAccessLogFormat is Envoy's default access log format,
+// without its trailing newline.
+const envoyAccessLogFormat = `[%START_TIME%] "%REQ(:METHOD)% %REQ(X-ENVOY-ORIGINAL-PATH?:PATH)% %PROTOCOL%" ` +
+	`%RESPONSE_CODE% %RESPONSE_FLAGS% %BYTES_RECEIVED% %BYTES_SENT% %DURATION% %RESP(X-ENVOY-UPSTREAM-SERVICE-TIME)% ` +
+	`"%REQ(X-FORWARDED-FOR)%" "%REQ(USER-AGENT)%" "%REQ(X-REQUEST-ID)%" "%REQ(:AUTHORITY)%" "%UPSTREAM_HOST%"`
+
+// FileAccessLogEnvoyWithHeaders returns a new file based access log
+// filter that will output Envoy's default access logs, followed by
+// the quoted values of the given request and response headers and,
+// if source is set, by the quoted source of each request's route.
+// See AccessLogSourceFields.
+func FileAccessLogEnvoyWithHeaders(path string, headers config.AccessLogHeaders, source bool) []*envoy_accesslog_v3.AccessLog {
+	if headers.Empty() && !source {
+		return FileAccessLogEnvoy(path)
+	}
+
+	format := envoyAccessLogFormat
+	for _, name := range headers.Request {
+		format += fmt.Sprintf(` "%%REQ(%s)%%"`, name)
+	}
+	for _, name := range headers.Response {
+		format += fmt.Sprintf(` "%%RESP(%s)%%"`, name)
+	}
+	if source {
+		format += fmt.Sprintf(` "%s/%s" "%s"`, routeMetadataOperator("namespace"),
+			routeMetadataOperator("name"), routeMetadataOperator("vhost"))
+	}
+
+	return fileAccessLogText(path, format+"\n")
+}
+
+// AccessLogSourceFields are the JSON access log fields that record the
+// kind, namespace and name of the object that each request was routed
+// by, and the name of its virtual host, so that a shared access log can
+// be split per tenant. They are read from the metadata recorded by
+// RouteSourceMetadata.
+var AccessLogSourceFields = config.AccessLogFields{
+	"source_kind=" + routeMetadataOperator("kind"),
+	"source_namespace=" + routeMetadataOperator("namespace"),
+	"source_name=" + routeMetadataOperator("name"),
+	"vhost=" + routeMetadataOperator("vhost"),
+}
+
+// routeMetadataOperator returns the access log operator that
+// logs the given key of the metadata recorded on routes.
+func routeMetadataOperator(key string) string {
+	return fmt.Sprintf("%%METADATA(ROUTE:%s:%s)%%", RouteMetadataNamespace, key)
+}
+
 // FileAccessLogJSON returns a new file based access log filter
 // that will log in JSON format
 func FileAccessLogJSON(path string, fields config.AccessLogFields) []*envoy_accesslog_v3.AccessLog {
@@ -66,6 +120,65 @@ func FileAccessLogJSON(path string, fields config.AccessLogFields) []*envoy_acce
 	}}
 }
 
+// tcpProxyAccessLogFormat is the text format used to log
+// TCP proxied connections.
+const tcpProxyAccessLogFormat = "[%START_TIME%] %DOWNSTREAM_REMOTE_ADDRESS% %REQUESTED_SERVER_NAME% " +
+	"%UPSTREAM_CLUSTER% %UPSTREAM_HOST% %BYTES_RECEIVED% %BYTES_SENT% %DURATION% %RESPONSE_FLAGS%\n"
+
+// FileAccessLogTCPProxy returns a new file based access log filter
+// that will log TCP proxied connections as text.
+func FileAccessLogTCPProxy(path string) []*envoy_accesslog_v3.AccessLog {
+	return fileAccessLogText(path, tcpProxyAccessLogFormat)
+}
+
+// fileAccessLogText returns a new file based access log filter
+// that will log in the given text format.
+func fileAccessLogText(path string, format string) []*envoy_accesslog_v3.AccessLog {
+	return []*envoy_accesslog_v3.AccessLog{{
+		Name: wellknown.FileAccessLog,
+		ConfigType: &envoy_accesslog_v3.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_file_v3.FileAccessLog{
+				Path: path,
+				AccessLogFormat: &envoy_file_v3.FileAccessLog_LogFormat{
+					LogFormat: &envoy_config_core_v3.SubstitutionFormatString{
+						Format: &envoy_config_core_v3.SubstitutionFormatString_TextFormatSource{
+							TextFormatSource: &envoy_config_core_v3.DataSource{
+								Specifier: &envoy_config_core_v3.DataSource_InlineString{
+									InlineString: format,
+								},
+							},
+						},
+					},
+				},
+			}),
+		},
+	}}
+}
+
+// GRPCAccessLogTCPProxy returns a new access log filter that will send
+// TCP proxied connection logs to the gRPC access log service of the
+// given extension service.
+func GRPCAccessLogTCPProxy(extensionService types.NamespacedName, logName string) []*envoy_accesslog_v3.AccessLog {
+	return []*envoy_accesslog_v3.AccessLog{{
+		Name: "envoy.access_loggers.tcp_grpc",
+		ConfigType: &envoy_accesslog_v3.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_grpc_als_v3.TcpGrpcAccessLogConfig{
+				CommonConfig: &envoy_grpc_als_v3.CommonGrpcAccessLogConfig{
+					LogName: logName,
+					GrpcService: &envoy_config_core_v3.GrpcService{
+						TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+								ClusterName: dag.ExtensionClusterName(extensionService),
+							},
+						},
+					},
+					TransportApiVersion: envoy_config_core_v3.ApiVersion_V3,
+				},
+			}),
+		},
+	}}
+}
+
 func sv(s string) *_struct.Value {
 	return &_struct.Value{
 		Kind: &_struct.Value_StringValue{
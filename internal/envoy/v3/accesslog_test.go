@@ -19,10 +19,13 @@ import (
 	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	envoy_grpc_als_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	_struct "github.com/golang/protobuf/ptypes/struct"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestFileAccessLog(t *testing.T) {
@@ -50,6 +53,53 @@ func TestFileAccessLog(t *testing.T) {
 	}
 }
 
+func TestFileAccessLogWithHeaders(t *testing.T) {
+	protobuf.ExpectEqual(t, FileAccessLogEnvoy("/dev/stdout"), FileAccessLogEnvoyWithHeaders("/dev/stdout", config.AccessLogHeaders{}, false))
+
+	got := FileAccessLogEnvoyWithHeaders("/dev/stdout", config.AccessLogHeaders{
+		Request:  []string{"x-tenant-id"},
+		Response: []string{"x-cache"},
+	}, false)
+	want := []*envoy_accesslog_v3.AccessLog{{
+		Name: wellknown.FileAccessLog,
+		ConfigType: &envoy_accesslog_v3.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_file_v3.FileAccessLog{
+				Path: "/dev/stdout",
+				AccessLogFormat: &envoy_file_v3.FileAccessLog_LogFormat{
+					LogFormat: &envoy_config_core_v3.SubstitutionFormatString{
+						Format: &envoy_config_core_v3.SubstitutionFormatString_TextFormatSource{
+							TextFormatSource: &envoy_config_core_v3.DataSource{
+								Specifier: &envoy_config_core_v3.DataSource_InlineString{
+									InlineString: `[%START_TIME%] "%REQ(:METHOD)% %REQ(X-ENVOY-ORIGINAL-PATH?:PATH)% %PROTOCOL%" ` +
+										`%RESPONSE_CODE% %RESPONSE_FLAGS% %BYTES_RECEIVED% %BYTES_SENT% %DURATION% %RESP(X-ENVOY-UPSTREAM-SERVICE-TIME)% ` +
+										`"%REQ(X-FORWARDED-FOR)%" "%REQ(USER-AGENT)%" "%REQ(X-REQUEST-ID)%" "%REQ(:AUTHORITY)%" "%UPSTREAM_HOST%" ` +
+										`"%REQ(x-tenant-id)%" "%RESP(x-cache)%"` + "\n",
+								},
+							},
+						},
+					},
+				},
+			}),
+		},
+	}}
+	protobuf.ExpectEqual(t, want, got)
+}
+
+func TestFileAccessLogWithSource(t *testing.T) {
+	got := FileAccessLogEnvoyWithHeaders("/dev/stdout", config.AccessLogHeaders{}, true)
+	want := fileAccessLogText("/dev/stdout", envoyAccessLogFormat+
+		` "%METADATA(ROUTE:io.projectcontour:namespace)%/%METADATA(ROUTE:io.projectcontour:name)%"`+
+		` "%METADATA(ROUTE:io.projectcontour:vhost)%"`+"\n")
+	protobuf.ExpectEqual(t, want, got)
+
+	assert.Equal(t, map[string]string{
+		"source_kind":      "%METADATA(ROUTE:io.projectcontour:kind)%",
+		"source_namespace": "%METADATA(ROUTE:io.projectcontour:namespace)%",
+		"source_name":      "%METADATA(ROUTE:io.projectcontour:name)%",
+		"vhost":            "%METADATA(ROUTE:io.projectcontour:vhost)%",
+	}, AccessLogSourceFields.AsFieldMap())
+}
+
 func TestJSONFileAccessLog(t *testing.T) {
 	tests := map[string]struct {
 		path    string
@@ -122,3 +172,51 @@ func TestJSONFileAccessLog(t *testing.T) {
 		})
 	}
 }
+
+func TestFileAccessLogTCPProxy(t *testing.T) {
+	got := FileAccessLogTCPProxy("/dev/stdout")
+	want := []*envoy_accesslog_v3.AccessLog{{
+		Name: wellknown.FileAccessLog,
+		ConfigType: &envoy_accesslog_v3.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_file_v3.FileAccessLog{
+				Path: "/dev/stdout",
+				AccessLogFormat: &envoy_file_v3.FileAccessLog_LogFormat{
+					LogFormat: &envoy_config_core_v3.SubstitutionFormatString{
+						Format: &envoy_config_core_v3.SubstitutionFormatString_TextFormatSource{
+							TextFormatSource: &envoy_config_core_v3.DataSource{
+								Specifier: &envoy_config_core_v3.DataSource_InlineString{
+									InlineString: "[%START_TIME%] %DOWNSTREAM_REMOTE_ADDRESS% %REQUESTED_SERVER_NAME% " +
+										"%UPSTREAM_CLUSTER% %UPSTREAM_HOST% %BYTES_RECEIVED% %BYTES_SENT% %DURATION% %RESPONSE_FLAGS%\n",
+								},
+							},
+						},
+					},
+				},
+			}),
+		},
+	}}
+	protobuf.ExpectEqual(t, want, got)
+}
+
+func TestGRPCAccessLogTCPProxy(t *testing.T) {
+	got := GRPCAccessLogTCPProxy(types.NamespacedName{Namespace: "projectcontour", Name: "als"}, "contour-tcpproxy")
+	want := []*envoy_accesslog_v3.AccessLog{{
+		Name: "envoy.access_loggers.tcp_grpc",
+		ConfigType: &envoy_accesslog_v3.AccessLog_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_grpc_als_v3.TcpGrpcAccessLogConfig{
+				CommonConfig: &envoy_grpc_als_v3.CommonGrpcAccessLogConfig{
+					LogName: "contour-tcpproxy",
+					GrpcService: &envoy_config_core_v3.GrpcService{
+						TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+								ClusterName: "extension/projectcontour/als",
+							},
+						},
+					},
+					TransportApiVersion: envoy_config_core_v3.ApiVersion_V3,
+				},
+			}),
+		},
+	}}
+	protobuf.ExpectEqual(t, want, got)
+}
@@ -19,6 +19,7 @@ import (
 	envoy_extensions_upstream_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
@@ -44,7 +45,7 @@ func UpstreamTLSContext(peerValidationContext *dag.PeerValidationContext, sni st
 		Sni: sni,
 	}
 
-	if peerValidationContext.GetCACertificate() != nil && len(peerValidationContext.GetSubjectName()) > 0 {
+	if peerValidationContext.GetCACertificate() != nil && (len(peerValidationContext.GetSubjectName()) > 0 || len(peerValidationContext.SubjectAltNames) > 0) {
 		// We have to explicitly assign the value from validationContext
 		// to context.CommonTlsContext.ValidationContextType because the
 		// latter is an interface. Returning nil from validationContext
@@ -53,6 +54,8 @@ func UpstreamTLSContext(peerValidationContext *dag.PeerValidationContext, sni st
 		// to explode later on.
 		vc := validationContext(peerValidationContext.GetCACertificate(), peerValidationContext.GetSubjectName(), false)
 		if vc != nil {
+			vc.ValidationContext.MatchSubjectAltNames = append(vc.ValidationContext.MatchSubjectAltNames,
+				subjectAltNameMatchers(peerValidationContext.SubjectAltNames)...)
 			context.CommonTlsContext.ValidationContextType = vc
 		}
 	}
@@ -110,6 +113,7 @@ func DownstreamTLSContext(serverSecret *dag.Secret, tlsMinProtoVersion envoy_v3_
 	if peerValidationContext != nil {
 		vc := validationContext(peerValidationContext.GetCACertificate(), "", peerValidationContext.SkipClientCertValidation)
 		if vc != nil {
+			vc.ValidationContext.MatchSubjectAltNames = subjectAltNameMatchers(peerValidationContext.SubjectAltNames)
 			context.CommonTlsContext.ValidationContextType = vc
 			context.RequireClientCertificate = protobuf.Bool(true)
 		}
@@ -118,15 +122,72 @@ func DownstreamTLSContext(serverSecret *dag.Secret, tlsMinProtoVersion envoy_v3_
 	return context
 }
 
-func http2ProtocolOptions() map[string]*any.Any {
-	return map[string]*any.Any{
-		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
-			&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
-				UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
-					ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
-						ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{},
+// subjectAltNameMatchers translates the allowed subject alternative names
+// of a peer validation context into Envoy string matchers.
+func subjectAltNameMatchers(sans []dag.SubjectAltNameMatch) []*matcher.StringMatcher {
+	var matchers []*matcher.StringMatcher
+	for _, san := range sans {
+		switch san.MatchType {
+		case dag.SubjectAltNameMatchTypeExact:
+			matchers = append(matchers, &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_Exact{
+					Exact: san.Value,
+				},
+			})
+		case dag.SubjectAltNameMatchTypeSuffix:
+			matchers = append(matchers, &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_Suffix{
+					Suffix: san.Value,
+				},
+			})
+		}
+	}
+	return matchers
+}
+
+// http1ProtocolOptionsWithTrailers returns HTTP/1 protocol options
+// that propagate trailers to and from upstream connections.
+func http1ProtocolOptionsWithTrailers() map[string]*any.Any {
+	options := &envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+		UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+					HttpProtocolOptions: &envoy_api_v3_core.Http1ProtocolOptions{
+						EnableTrailers: true,
 					},
 				},
-			}),
+			},
+		},
+	}
+
+	return map[string]*any.Any{
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(options),
+	}
+}
+
+func http2ProtocolOptions() map[string]*any.Any {
+	return http2ProtocolOptionsWithIdleTimeout(nil)
+}
+
+// http2ProtocolOptionsWithIdleTimeout returns HTTP/2 protocol options
+// that close upstream connections after they have been idle for the
+// given duration. A nil duration uses the Envoy default.
+func http2ProtocolOptionsWithIdleTimeout(idleTimeout *duration.Duration) map[string]*any.Any {
+	options := &envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+		UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{},
+			},
+		},
+	}
+
+	if idleTimeout != nil {
+		options.CommonHttpProtocolOptions = &envoy_api_v3_core.HttpProtocolOptions{
+			IdleTimeout: idleTimeout,
+		}
+	}
+
+	return map[string]*any.Any{
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(options),
 	}
 }
@@ -96,6 +96,33 @@ func TestUpstreamTLSContext(t *testing.T) {
 				},
 			},
 		},
+		"no alpn, ca and spiffe id": {
+			validation: &dag.PeerValidationContext{
+				CACertificate: secret,
+				SubjectAltNames: []dag.SubjectAltNameMatch{{
+					MatchType: dag.SubjectAltNameMatchTypeExact,
+					Value:     "spiffe://example.org/ns/default/sa/backend",
+				}},
+			},
+			want: &envoy_v3_tls.UpstreamTlsContext{
+				CommonTlsContext: &envoy_v3_tls.CommonTlsContext{
+					ValidationContextType: &envoy_v3_tls.CommonTlsContext_ValidationContext{
+						ValidationContext: &envoy_v3_tls.CertificateValidationContext{
+							TrustedCa: &envoy_api_v3_core.DataSource{
+								Specifier: &envoy_api_v3_core.DataSource_InlineBytes{
+									InlineBytes: []byte("ca"),
+								},
+							},
+							MatchSubjectAltNames: []*matcher.StringMatcher{{
+								MatchPattern: &matcher.StringMatcher_Exact{
+									Exact: "spiffe://example.org/ns/default/sa/backend",
+								}},
+							},
+						},
+					},
+				},
+			},
+		},
 		"external name sni": {
 			externalName: "projectcontour.local",
 			want: &envoy_v3_tls.UpstreamTlsContext{
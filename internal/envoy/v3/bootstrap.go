@@ -35,6 +35,7 @@ import (
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // WriteBootstrap writes bootstrap configuration to files.
@@ -153,9 +154,10 @@ func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
 
 func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_bootstrap_v3.Bootstrap {
 	return &envoy_bootstrap_v3.Bootstrap{
+		Node: node(c),
 		DynamicResources: &envoy_bootstrap_v3.Bootstrap_DynamicResources{
-			LdsConfig: ConfigSource("contour"),
-			CdsConfig: ConfigSource("contour"),
+			LdsConfig: xdsConfigSource(c),
+			CdsConfig: xdsConfigSource(c),
 		},
 		StaticResources: &envoy_bootstrap_v3.Bootstrap_StaticResources{
 			Clusters: []*envoy_cluster_v3.Cluster{{
@@ -215,6 +217,43 @@ func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_bootstrap_v3.Bootstrap {
 	}
 }
 
+// node returns the Envoy node of the bootstrap configuration,
+// or nil if none of its fields are set.
+func node(c *envoy.BootstrapConfig) *envoy_core_v3.Node {
+	if c.NodeID == "" && c.NodeCluster == "" &&
+		c.LocalityRegion == "" && c.LocalityZone == "" && c.LocalitySubZone == "" {
+		return nil
+	}
+
+	n := &envoy_core_v3.Node{
+		Id:      c.NodeID,
+		Cluster: c.NodeCluster,
+	}
+	if c.LocalityRegion != "" || c.LocalityZone != "" || c.LocalitySubZone != "" {
+		n.Locality = &envoy_core_v3.Locality{
+			Region:  c.LocalityRegion,
+			Zone:    c.LocalityZone,
+			SubZone: c.LocalitySubZone,
+		}
+	}
+	return n
+}
+
+// xdsConfigSource returns the config source of the dynamic
+// resources, tuned by the xDS options of the bootstrap configuration.
+func xdsConfigSource(c *envoy.BootstrapConfig) *envoy_core_v3.ConfigSource {
+	source := ConfigSource("contour")
+	if c.XDSInitialFetchTimeout > 0 {
+		source.InitialFetchTimeout = protobuf.Duration(c.XDSInitialFetchTimeout)
+	}
+	if c.XDSRateLimitQPS > 0 {
+		source.GetApiConfigSource().RateLimitSettings = &envoy_core_v3.RateLimitSettings{
+			FillRate: wrapperspb.Double(c.XDSRateLimitQPS),
+		}
+	}
+	return source
+}
+
 func upstreamFileTLSContext(c *envoy.BootstrapConfig) *envoy_tls_v3.UpstreamTlsContext {
 	context := &envoy_tls_v3.UpstreamTlsContext{
 		CommonTlsContext: &envoy_tls_v3.CommonTlsContext{
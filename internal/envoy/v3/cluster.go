@@ -21,6 +21,7 @@ import (
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_v3_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -45,14 +46,28 @@ func Cluster(c *dag.Cluster) *envoy_cluster_v3.Cluster {
 	cluster.Name = envoy.Clustername(c)
 	cluster.AltStatName = envoy.AltStatName(service)
 	cluster.LbPolicy = lbPolicy(c.LoadBalancerPolicy)
+	if cluster.LbPolicy == envoy_cluster_v3.Cluster_RING_HASH {
+		cluster.LbConfig = ringHashLBConfig(c.LBConfig)
+	}
 	cluster.HealthChecks = edshealthcheck(c)
 	cluster.DnsLookupFamily = parseDNSLookupFamily(c.DNSLookupFamily)
+	cluster.CommonLbConfig.HealthyPanicThreshold.Value = float64(c.LBConfig.HealthyPanicThreshold)
 
 	switch len(service.ExternalName) {
 	case 0:
 		// external name not set, cluster will be discovered via EDS
 		cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS)
 		cluster.EdsClusterConfig = edsconfig("contour", service)
+
+		// Failover services are priority levels of the
+		// cluster load assignment, and are balanced by the
+		// weights of their localities, rather than by zone.
+		if fc := c.FailoverCluster(); fc != nil {
+			cluster.EdsClusterConfig.ServiceName = fc.ClusterName
+			cluster.CommonLbConfig.LocalityConfigSpecifier = &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+				LocalityWeightedLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig{},
+			}
+		}
 	default:
 		// external name set, use hard coded DNS name
 		cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS)
@@ -64,43 +79,47 @@ func Cluster(c *dag.Cluster) *envoy_cluster_v3.Cluster {
 		cluster.IgnoreHealthOnHostRemoval = true
 	}
 
-	if envoy.AnyPositive(service.MaxConnections, service.MaxPendingRequests, service.MaxRequests, service.MaxRetries) {
-		cluster.CircuitBreakers = &envoy_cluster_v3.CircuitBreakers{
-			Thresholds: []*envoy_cluster_v3.CircuitBreakers_Thresholds{{
-				MaxConnections:     protobuf.UInt32OrNil(service.MaxConnections),
-				MaxPendingRequests: protobuf.UInt32OrNil(service.MaxPendingRequests),
-				MaxRequests:        protobuf.UInt32OrNil(service.MaxRequests),
-				MaxRetries:         protobuf.UInt32OrNil(service.MaxRetries),
-			}},
-		}
-	}
+	cluster.CircuitBreakers = circuitBreakers(service.MaxConnections, service.MaxPendingRequests, service.MaxRequests, service.MaxRetries)
+	cluster.MaxRequestsPerConnection = protobuf.UInt32OrNil(c.MaxRequestsPerConnection)
 
 	switch c.Protocol {
 	case "tls":
 		cluster.TransportSocket = UpstreamTLSTransportSocket(
-			UpstreamTLSContext(
-				c.UpstreamValidation,
-				c.SNI,
-				c.ClientCertificate,
-			),
+			clusterTLSContext(c),
 		)
+		if c.EnableTrailers {
+			cluster.TypedExtensionProtocolOptions = http1ProtocolOptionsWithTrailers()
+		}
 	case "h2":
 		cluster.TypedExtensionProtocolOptions = http2ProtocolOptions()
 		cluster.TransportSocket = UpstreamTLSTransportSocket(
-			UpstreamTLSContext(
-				c.UpstreamValidation,
-				c.SNI,
-				c.ClientCertificate,
-				"h2",
-			),
+			clusterTLSContext(c, "h2"),
 		)
 	case "h2c":
 		cluster.TypedExtensionProtocolOptions = http2ProtocolOptions()
+	default:
+		if c.EnableTrailers {
+			cluster.TypedExtensionProtocolOptions = http1ProtocolOptionsWithTrailers()
+		}
 	}
 
 	return cluster
 }
 
+// clusterTLSContext returns the UpstreamTlsContext used to connect
+// to the upstream of the given cluster.
+func clusterTLSContext(c *dag.Cluster, alpnProtocols ...string) *envoy_v3_tls.UpstreamTlsContext {
+	context := UpstreamTLSContext(c.UpstreamValidation, c.SNI, c.ClientCertificate, alpnProtocols...)
+	if ut := c.UpstreamTLS; ut != nil {
+		context.CommonTlsContext.TlsParams = &envoy_v3_tls.TlsParameters{
+			TlsMinimumProtocolVersion: ParseTLSVersion(ut.MinimumProtocolVersion),
+			TlsMaximumProtocolVersion: ParseTLSVersion(ut.MaximumProtocolVersion),
+			CipherSuites:              ut.CipherSuites,
+		}
+	}
+	return context
+}
+
 // ExtensionCluster builds a envoy_cluster_v3.Cluster struct for the given extension service.
 func ExtensionCluster(ext *dag.ExtensionCluster) *envoy_cluster_v3.Cluster {
 	cluster := clusterDefaults()
@@ -119,6 +138,13 @@ func ExtensionCluster(ext *dag.ExtensionCluster) *envoy_cluster_v3.Cluster {
 
 	cluster.LbPolicy = lbPolicy(ext.LoadBalancerPolicy)
 
+	// Extension services balance traffic across their Kubernetes
+	// Services using the weights of the localities in the cluster
+	// load assignment.
+	cluster.CommonLbConfig.LocalityConfigSpecifier = &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+		LocalityWeightedLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig{},
+	}
+
 	// Cluster will be discovered via EDS.
 	cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS)
 	cluster.EdsClusterConfig = &envoy_cluster_v3.Cluster_EdsClusterConfig{
@@ -128,6 +154,16 @@ func ExtensionCluster(ext *dag.ExtensionCluster) *envoy_cluster_v3.Cluster {
 
 	// TODO(jpeach): Externalname service support in https://github.com/projectcontour/contour/issues/2875
 
+	cluster.CircuitBreakers = circuitBreakers(ext.MaxConnections, ext.MaxPendingRequests, ext.MaxRequests, ext.MaxRetries)
+
+	if ext.GRPCHealthCheckPolicy != nil {
+		cluster.HealthChecks = []*envoy_core_v3.HealthCheck{
+			grpcHealthCheck(ext),
+		}
+		// Drain connections immediately if the endpoint is known to be removed.
+		cluster.IgnoreHealthOnHostRemoval = true
+	}
+
 	switch ext.Protocol {
 	case "h2":
 		cluster.TypedExtensionProtocolOptions = http2ProtocolOptions()
@@ -143,6 +179,48 @@ func ExtensionCluster(ext *dag.ExtensionCluster) *envoy_cluster_v3.Cluster {
 		cluster.TypedExtensionProtocolOptions = http2ProtocolOptions()
 	}
 
+	if idle := envoy.Timeout(ext.TimeoutPolicy.IdleTimeout); idle != nil {
+		cluster.TypedExtensionProtocolOptions = http2ProtocolOptionsWithIdleTimeout(idle)
+	}
+
+	return cluster
+}
+
+// circuitBreakers returns the *envoy_cluster_v3.CircuitBreakers for the
+// given thresholds, or nil if none of them are set.
+func circuitBreakers(maxConnections, maxPendingRequests, maxRequests, maxRetries uint32) *envoy_cluster_v3.CircuitBreakers {
+	if !envoy.AnyPositive(maxConnections, maxPendingRequests, maxRequests, maxRetries) {
+		return nil
+	}
+
+	return &envoy_cluster_v3.CircuitBreakers{
+		Thresholds: []*envoy_cluster_v3.CircuitBreakers_Thresholds{{
+			MaxConnections:     protobuf.UInt32OrNil(maxConnections),
+			MaxPendingRequests: protobuf.UInt32OrNil(maxPendingRequests),
+			MaxRequests:        protobuf.UInt32OrNil(maxRequests),
+			MaxRetries:         protobuf.UInt32OrNil(maxRetries),
+		}},
+	}
+}
+
+// DNSNameCluster builds a envoy_cluster_v3.Cluster for the given *dag.DNSNameCluster.
+func DNSNameCluster(c *dag.DNSNameCluster) *envoy_cluster_v3.Cluster {
+	cluster := clusterDefaults()
+
+	cluster.Name = c.Name()
+	cluster.ClusterDiscoveryType = ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS)
+	cluster.DnsLookupFamily = parseDNSLookupFamily(c.DNSLookupFamily)
+	cluster.LoadAssignment = &envoy_endpoint_v3.ClusterLoadAssignment{
+		ClusterName: c.Name(),
+		Endpoints:   Endpoints(SocketAddress(c.Address, c.Port)),
+	}
+
+	if c.Scheme == "https" {
+		cluster.TransportSocket = UpstreamTLSTransportSocket(
+			UpstreamTLSContext(c.UpstreamValidation, c.Address, nil),
+		)
+	}
+
 	return cluster
 }
 
@@ -181,6 +259,26 @@ func lbPolicy(strategy string) envoy_cluster_v3.Cluster_LbPolicy {
 	}
 }
 
+// ringHashLBConfig returns the ring hash configuration of a cluster,
+// or nil if Envoy's defaults should be used.
+func ringHashLBConfig(lb dag.ClusterLBConfig) *envoy_cluster_v3.Cluster_RingHashLbConfig_ {
+	if lb.MinimumRingSize == 0 && lb.MaximumRingSize == 0 && lb.RingHashFunction == "" {
+		return nil
+	}
+
+	config := &envoy_cluster_v3.Cluster_RingHashLbConfig{
+		MinimumRingSize: protobuf.UInt64OrNil(lb.MinimumRingSize),
+		MaximumRingSize: protobuf.UInt64OrNil(lb.MaximumRingSize),
+	}
+	if lb.RingHashFunction == "murmur_hash_2" {
+		config.HashFunction = envoy_cluster_v3.Cluster_RingHashLbConfig_MURMUR_HASH_2
+	}
+
+	return &envoy_cluster_v3.Cluster_RingHashLbConfig_{
+		RingHashLbConfig: config,
+	}
+}
+
 func edshealthcheck(c *dag.Cluster) []*envoy_core_v3.HealthCheck {
 	if c.HTTPHealthCheckPolicy == nil && c.TCPHealthCheckPolicy == nil {
 		return nil
@@ -251,5 +349,7 @@ func parseDNSLookupFamily(value string) envoy_cluster_v3.Cluster_DnsLookupFamily
 	case "v6":
 		return envoy_cluster_v3.Cluster_V6_ONLY
 	}
+	// The "all" family isn't supported by Envoy 1.18, and falls
+	// back to AUTO, which also resolves either family.
 	return envoy_cluster_v3.Cluster_AUTO
 }
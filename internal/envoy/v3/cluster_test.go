@@ -19,6 +19,7 @@ import (
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_v3_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_extensions_upstream_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/golang/protobuf/proto"
@@ -151,6 +152,35 @@ func TestCluster(t *testing.T) {
 				},
 			},
 		},
+		"http upstream with trailers": {
+			cluster: &dag.Cluster{
+				Upstream:       service(s1),
+				EnableTrailers: true,
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/fdd74bdda1",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				TypedExtensionProtocolOptions: map[string]*any.Any{
+					"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
+						&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+							UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+								ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+									ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{
+										HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+											EnableTrailers: true,
+										},
+									},
+								},
+							},
+						}),
+				},
+			},
+		},
 		"h2 upstream": {
 			cluster: &dag.Cluster{
 				Upstream: service(s1, "h2"),
@@ -216,6 +246,32 @@ func TestCluster(t *testing.T) {
 				DnsLookupFamily:      envoy_cluster_v3.Cluster_V6_ONLY,
 			},
 		},
+		"externalName service - overridden dns-lookup-family v6": {
+			cluster: &dag.Cluster{
+				Upstream:                  service(s2),
+				DNSLookupFamily:           "v6",
+				DNSLookupFamilyOverridden: true,
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/39bcc1930b",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
+				DnsLookupFamily:      envoy_cluster_v3.Cluster_V6_ONLY,
+			},
+		},
+		"externalName service - dns-lookup-family all": {
+			cluster: &dag.Cluster{
+				Upstream:        service(s2),
+				DNSLookupFamily: "all",
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_STRICT_DNS),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
+			},
+		},
 		"externalName service - dns-lookup-family auto": {
 			cluster: &dag.Cluster{
 				Upstream:        service(s2),
@@ -260,6 +316,103 @@ func TestCluster(t *testing.T) {
 				),
 			},
 		},
+		"tls upstream with tls parameters": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1, "tls"),
+				Protocol: "tls",
+				UpstreamTLS: &dag.UpstreamTLS{
+					MinimumProtocolVersion: "1.2",
+					MaximumProtocolVersion: "1.3",
+					CipherSuites:           []string{"ECDHE-RSA-AES256-GCM-SHA384"},
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/f70d1b5c9c",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				TransportSocket: UpstreamTLSTransportSocket(
+					&envoy_v3_tls.UpstreamTlsContext{
+						CommonTlsContext: &envoy_v3_tls.CommonTlsContext{
+							TlsParams: &envoy_v3_tls.TlsParameters{
+								TlsMinimumProtocolVersion: envoy_v3_tls.TlsParameters_TLSv1_2,
+								TlsMaximumProtocolVersion: envoy_v3_tls.TlsParameters_TLSv1_3,
+								CipherSuites:              []string{"ECDHE-RSA-AES256-GCM-SHA384"},
+							},
+						},
+					},
+				),
+			},
+		},
+		"failover": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				Failover: []dag.WeightedService{{
+					Priority:         1,
+					ServiceName:      "kuarder",
+					ServiceNamespace: "default",
+					ServicePort:      s1.Spec.Ports[0],
+				}},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/5bedce9c75",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http/failover-5d85e4a2c8",
+				},
+				CommonLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig{
+					HealthyPanicThreshold: &envoy_type.Percent{
+						Value: 0,
+					},
+					LocalityConfigSpecifier: &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+						LocalityWeightedLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig{},
+					},
+				},
+			},
+		},
+		"healthy panic threshold": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				LBConfig: dag.ClusterLBConfig{
+					HealthyPanicThreshold: 50,
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/1060b87aca",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				CommonLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig{
+					HealthyPanicThreshold: &envoy_type.Percent{
+						Value: 50,
+					},
+				},
+			},
+		},
+		"max requests per connection": {
+			cluster: &dag.Cluster{
+				Upstream:                 service(s1),
+				MaxRequestsPerConnection: 100,
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/8f7a23e642",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				MaxRequestsPerConnection: protobuf.UInt32(100),
+			},
+		},
 		"tls upstream - external name": {
 			cluster: &dag.Cluster{
 				Upstream: service(svcExternal, "tls"),
@@ -444,6 +597,51 @@ func TestCluster(t *testing.T) {
 				LbPolicy: envoy_cluster_v3.Cluster_RING_HASH,
 			},
 		},
+		"cluster with cookie policy and ring hash config": {
+			cluster: &dag.Cluster{
+				Upstream:           service(s1),
+				LoadBalancerPolicy: "Cookie",
+				LBConfig: dag.ClusterLBConfig{
+					MinimumRingSize:  4096,
+					MaximumRingSize:  4096,
+					RingHashFunction: "murmur_hash_2",
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/e4f81994fe",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				LbPolicy: envoy_cluster_v3.Cluster_RING_HASH,
+				LbConfig: &envoy_cluster_v3.Cluster_RingHashLbConfig_{
+					RingHashLbConfig: &envoy_cluster_v3.Cluster_RingHashLbConfig{
+						MinimumRingSize: protobuf.UInt64(4096),
+						MaximumRingSize: protobuf.UInt64(4096),
+						HashFunction:    envoy_cluster_v3.Cluster_RingHashLbConfig_MURMUR_HASH_2,
+					},
+				},
+			},
+		},
+		"ring hash config is ignored without a hashing policy": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				LBConfig: dag.ClusterLBConfig{
+					MinimumRingSize: 4096,
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+			},
+		},
 
 		"tcp service": {
 			cluster: &dag.Cluster{
@@ -628,6 +826,47 @@ func TestClustername(t *testing.T) {
 			},
 			want: "default/backend/80/6bf46b7b3a",
 		},
+		"externalname with dns lookup family": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "backend",
+						ServiceNamespace: "default",
+						ServicePort: v1.ServicePort{
+							Name:       "http",
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(6502),
+						},
+					},
+					ExternalName: "foo.io",
+				},
+				DNSLookupFamily:           "v6",
+				DNSLookupFamilyOverridden: true,
+			},
+			want: "default/backend/80/39bcc1930b",
+		},
+		"externalname with auto dns lookup family": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "backend",
+						ServiceNamespace: "default",
+						ServicePort: v1.ServicePort{
+							Name:       "http",
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(6502),
+						},
+					},
+					ExternalName: "foo.io",
+				},
+				DNSLookupFamily: "auto",
+			},
+			want: "default/backend/80/da39a3ee5e",
+		},
 	}
 
 	for name, tc := range tests {
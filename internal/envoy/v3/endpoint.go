@@ -30,6 +30,22 @@ func LBEndpoint(addr *envoy_core_v3.Address) *envoy_endpoint_v3.LbEndpoint {
 	}
 }
 
+// UnhealthyLBEndpoint creates a new LbEndpoint whose health status
+// is UNHEALTHY, so that Envoy does not route requests to it.
+func UnhealthyLBEndpoint(addr *envoy_core_v3.Address) *envoy_endpoint_v3.LbEndpoint {
+	lb := LBEndpoint(addr)
+	lb.HealthStatus = envoy_core_v3.HealthStatus_UNHEALTHY
+	return lb
+}
+
+// DrainingLBEndpoint creates a new LbEndpoint whose health status
+// is DRAINING, so that Envoy stops routing new requests to it.
+func DrainingLBEndpoint(addr *envoy_core_v3.Address) *envoy_endpoint_v3.LbEndpoint {
+	lb := LBEndpoint(addr)
+	lb.HealthStatus = envoy_core_v3.HealthStatus_DRAINING
+	return lb
+}
+
 // Endpoints returns a slice of LocalityLbEndpoints.
 // The slice contains one entry, with one LbEndpoint per
 // *envoy_core_v3.Address supplied.
@@ -0,0 +1,72 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"time"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_cluster_dfp_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/clusters/dynamic_forward_proxy/v3"
+	envoy_common_dfp_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/dynamic_forward_proxy/v3"
+	envoy_filter_dfp_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/dynamic_forward_proxy/v3"
+	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/projectcontour/contour/internal/protobuf"
+)
+
+// DynamicForwardProxyClusterName is the name of the cluster that
+// routes of forward proxy virtual hosts send their requests to.
+const DynamicForwardProxyClusterName = "dynamic_forward_proxy"
+
+// dynamicForwardProxyDNSCache returns the configuration of the DNS
+// cache that the dynamic forward proxy cluster and filter share.
+// Envoy requires both to have exactly the same configuration.
+func dynamicForwardProxyDNSCache() *envoy_common_dfp_v3.DnsCacheConfig {
+	return &envoy_common_dfp_v3.DnsCacheConfig{
+		Name:            DynamicForwardProxyClusterName,
+		DnsLookupFamily: envoy_cluster_v3.Cluster_V4_ONLY,
+	}
+}
+
+// DynamicForwardProxyCluster returns the cluster that forwards each
+// request to the host named by its Host header, which is resolved
+// using the DNS cache of the dynamic forward proxy filter.
+func DynamicForwardProxyCluster() *envoy_cluster_v3.Cluster {
+	return &envoy_cluster_v3.Cluster{
+		Name:           DynamicForwardProxyClusterName,
+		ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+		LbPolicy:       envoy_cluster_v3.Cluster_CLUSTER_PROVIDED,
+		ClusterDiscoveryType: &envoy_cluster_v3.Cluster_ClusterType{
+			ClusterType: &envoy_cluster_v3.Cluster_CustomClusterType{
+				Name: "envoy.clusters.dynamic_forward_proxy",
+				TypedConfig: protobuf.MustMarshalAny(&envoy_cluster_dfp_v3.ClusterConfig{
+					DnsCacheConfig: dynamicForwardProxyDNSCache(),
+				}),
+			},
+		},
+	}
+}
+
+// FilterDynamicForwardProxy returns an `envoy.filters.http.dynamic_forward_proxy`
+// filter, which resolves the Host of the requests that are routed to the
+// dynamic forward proxy cluster before they are forwarded.
+func FilterDynamicForwardProxy() *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: "envoy.filters.http.dynamic_forward_proxy",
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_filter_dfp_v3.FilterConfig{
+				DnsCacheConfig: dynamicForwardProxyDNSCache(),
+			}),
+		},
+	}
+}
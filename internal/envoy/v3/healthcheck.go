@@ -62,6 +62,23 @@ func tcpHealthCheck(cluster *dag.Cluster) *envoy_core_v3.HealthCheck {
 	}
 }
 
+// grpcHealthCheck returns a *envoy_core_v3.HealthCheck value for ExtensionClusters
+func grpcHealthCheck(cluster *dag.ExtensionCluster) *envoy_core_v3.HealthCheck {
+	hc := cluster.GRPCHealthCheckPolicy
+
+	return &envoy_core_v3.HealthCheck{
+		Timeout:            durationOrDefault(hc.Timeout, envoy.HCTimeout),
+		Interval:           durationOrDefault(hc.Interval, envoy.HCInterval),
+		UnhealthyThreshold: protobuf.UInt32OrDefault(hc.UnhealthyThreshold, envoy.HCUnhealthyThreshold),
+		HealthyThreshold:   protobuf.UInt32OrDefault(hc.HealthyThreshold, envoy.HCHealthyThreshold),
+		HealthChecker: &envoy_core_v3.HealthCheck_GrpcHealthCheck_{
+			GrpcHealthCheck: &envoy_core_v3.HealthCheck_GrpcHealthCheck{
+				ServiceName: hc.ServiceName,
+			},
+		},
+	}
+}
+
 func durationOrDefault(d, def time.Duration) *duration.Duration {
 	if d != 0 {
 		return protobuf.Duration(d)
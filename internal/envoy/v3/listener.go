@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"sort"
 	"strings"
 	"time"
@@ -24,22 +25,29 @@ import (
 	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_admission_control_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/admission_control/v3alpha"
 	envoy_compressor_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/compressor/v3"
+	envoy_decompressor_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/decompressor/v3"
 	envoy_config_filter_http_ext_authz_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	lua "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	envoy_oauth2_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/oauth2/v3alpha"
+	envoy_config_filter_http_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
 	envoy_extensions_filters_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/projectcontour/contour/pkg/config"
 )
 
 type HTTPVersionType = http.HttpConnectionManager_CodecType
@@ -54,6 +62,8 @@ const (
 	HTTPFilterCORS    = "type.googleapis.com/envoy.extensions.filters.http.cors.v3.Cors"
 	HTTPFilterGrpcWeb = "type.googleapis.com/envoy.extensions.filters.http.grpc_web.v3.GrpcWeb"
 	HTTPFilterGzip    = "type.googleapis.com/envoy.extensions.compression.gzip.compressor.v3.Gzip"
+
+	HTTPFilterGzipDecompressor = "type.googleapis.com/envoy.extensions.compression.gzip.decompressor.v3.Gzip"
 )
 
 // ProtoNamesForVersions returns the slice of ALPN protocol names for the give HTTP versions.
@@ -152,7 +162,17 @@ type httpConnectionManagerBuilder struct {
 	filters                       []*http.HttpFilter
 	codec                         HTTPVersionType // Note the zero value is AUTO, which is the default we want.
 	allowChunkedLength            bool
+	enableTrailers                bool
 	numTrustedHops                uint32
+	maxRequestHeadersKB           uint32
+	maxRequestHeadersCount        uint32
+	disableNormalizePath          bool
+	disableMergeSlashes           bool
+	escapedSlashesAction          config.EscapedSlashesActionType
+	headersWithUnderscoresAction  config.HeadersWithUnderscoresActionType
+	serverHeader                  config.ServerHeaderParameters
+	disableHTTP10                 bool
+	defaultHostForHTTP10          string
 }
 
 // RouteConfigName sets the name of the RDS element that contains
@@ -225,11 +245,83 @@ func (b *httpConnectionManagerBuilder) AllowChunkedLength(enabled bool) *httpCon
 	return b
 }
 
+// EnableTrailers enables the propagation of trailers on HTTP/1
+// downstream connections.
+func (b *httpConnectionManagerBuilder) EnableTrailers(enabled bool) *httpConnectionManagerBuilder {
+	b.enableTrailers = enabled
+	return b
+}
+
 func (b *httpConnectionManagerBuilder) NumTrustedHops(num uint32) *httpConnectionManagerBuilder {
 	b.numTrustedHops = num
 	return b
 }
 
+// MaxRequestHeadersKB sets the maximum size of the request headers in
+// kilobytes. If zero, Envoy's default of 60KiB is used.
+func (b *httpConnectionManagerBuilder) MaxRequestHeadersKB(kb uint32) *httpConnectionManagerBuilder {
+	b.maxRequestHeadersKB = kb
+	return b
+}
+
+// MaxRequestHeadersCount sets the maximum number of request headers.
+// If zero, Envoy's default of 100 headers is used.
+func (b *httpConnectionManagerBuilder) MaxRequestHeadersCount(count uint32) *httpConnectionManagerBuilder {
+	b.maxRequestHeadersCount = count
+	return b
+}
+
+// DisableNormalizePath disables RFC 3986 normalization of request paths,
+// which is otherwise always enabled.
+func (b *httpConnectionManagerBuilder) DisableNormalizePath(disabled bool) *httpConnectionManagerBuilder {
+	b.disableNormalizePath = disabled
+	return b
+}
+
+// DisableMergeSlashes disables merging adjacent slashes in request paths,
+// which is otherwise always enabled.
+func (b *httpConnectionManagerBuilder) DisableMergeSlashes(disabled bool) *httpConnectionManagerBuilder {
+	b.disableMergeSlashes = disabled
+	return b
+}
+
+// EscapedSlashesAction sets the action to take when a request path
+// contains escaped slashes. By default, Envoy keeps them unchanged.
+func (b *httpConnectionManagerBuilder) EscapedSlashesAction(action config.EscapedSlashesActionType) *httpConnectionManagerBuilder {
+	b.escapedSlashesAction = action
+	return b
+}
+
+// DisableHTTP10 rejects HTTP/1.0 requests, which are otherwise
+// accepted as long as they carry a Host header.
+func (b *httpConnectionManagerBuilder) DisableHTTP10(disabled bool) *httpConnectionManagerBuilder {
+	b.disableHTTP10 = disabled
+	return b
+}
+
+// DefaultHostForHTTP10 sets the host used for HTTP/1.0 requests
+// that do not carry a Host header. Such requests are rejected if
+// no default host is set.
+func (b *httpConnectionManagerBuilder) DefaultHostForHTTP10(host string) *httpConnectionManagerBuilder {
+	b.defaultHostForHTTP10 = host
+	return b
+}
+
+// HeadersWithUnderscoresAction sets the action to take when a request
+// header name contains an underscore. By default such headers are allowed.
+func (b *httpConnectionManagerBuilder) HeadersWithUnderscoresAction(action config.HeadersWithUnderscoresActionType) *httpConnectionManagerBuilder {
+	b.headersWithUnderscoresAction = action
+	return b
+}
+
+// ServerHeader sets the value of the Server header of responses
+// and how the Server header of upstream responses is handled. By
+// default, Envoy overwrites it with "envoy".
+func (b *httpConnectionManagerBuilder) ServerHeader(serverHeader config.ServerHeaderParameters) *httpConnectionManagerBuilder {
+	b.serverHeader = serverHeader
+	return b
+}
+
 func (b *httpConnectionManagerBuilder) DefaultFilters() *httpConnectionManagerBuilder {
 
 	// Add a default set of ordered http filters.
@@ -277,6 +369,16 @@ func (b *httpConnectionManagerBuilder) DefaultFilters() *httpConnectionManagerBu
 				),
 			},
 		},
+		&http.HttpFilter{
+			Name: "rbac",
+			ConfigType: &http.HttpFilter_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(
+					// since no rules are defined here, the filter allows
+					// all requests, but can be restricted on a per-route basis.
+					&envoy_config_filter_http_rbac_v3.RBAC{},
+				),
+			},
+		},
 		&http.HttpFilter{
 			Name: "router",
 			ConfigType: &http.HttpFilter_TypedConfig{
@@ -382,11 +484,13 @@ func (b *httpConnectionManagerBuilder) Get() *envoy_listener_v3.Filter {
 		HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
 			// Enable support for HTTP/1.0 requests that carry
 			// a Host: header. See #537.
-			AcceptHttp_10:      true,
-			AllowChunkedLength: b.allowChunkedLength,
+			AcceptHttp_10:         !b.disableHTTP10,
+			DefaultHostForHttp_10: b.defaultHostForHTTP10,
+			AllowChunkedLength:    b.allowChunkedLength,
+			EnableTrailers:        b.enableTrailers,
 		},
 		UseRemoteAddress: protobuf.Bool(true),
-		NormalizePath:    protobuf.Bool(true),
+		NormalizePath:    protobuf.Bool(!b.disableNormalizePath),
 
 		// We can ignore any port number supplied in the Host/:authority header
 		// before processing by filters or routing.
@@ -398,7 +502,7 @@ func (b *httpConnectionManagerBuilder) Get() *envoy_listener_v3.Filter {
 
 		// issue #1487 pass through X-Request-Id if provided.
 		PreserveExternalRequestId: true,
-		MergeSlashes:              true,
+		MergeSlashes:              !b.disableMergeSlashes,
 
 		RequestTimeout:      envoy.Timeout(b.requestTimeout),
 		StreamIdleTimeout:   envoy.Timeout(b.streamIdleTimeout),
@@ -415,6 +519,40 @@ func (b *httpConnectionManagerBuilder) Get() *envoy_listener_v3.Filter {
 		cm.CommonHttpProtocolOptions.MaxConnectionDuration = protobuf.Duration(b.maxConnectionDuration.Duration())
 	}
 
+	if b.maxRequestHeadersKB > 0 {
+		cm.MaxRequestHeadersKb = protobuf.UInt32(b.maxRequestHeadersKB)
+	}
+
+	if b.maxRequestHeadersCount > 0 {
+		cm.CommonHttpProtocolOptions.MaxHeadersCount = protobuf.UInt32(b.maxRequestHeadersCount)
+	}
+
+	switch b.escapedSlashesAction {
+	case config.KeepUnchangedEscapedSlashes:
+		cm.PathWithEscapedSlashesAction = http.HttpConnectionManager_KEEP_UNCHANGED
+	case config.RejectEscapedSlashes:
+		cm.PathWithEscapedSlashesAction = http.HttpConnectionManager_REJECT_REQUEST
+	case config.UnescapeAndRedirectEscapedSlashes:
+		cm.PathWithEscapedSlashesAction = http.HttpConnectionManager_UNESCAPE_AND_REDIRECT
+	case config.UnescapeAndForwardEscapedSlashes:
+		cm.PathWithEscapedSlashesAction = http.HttpConnectionManager_UNESCAPE_AND_FORWARD
+	}
+
+	switch b.headersWithUnderscoresAction {
+	case config.RejectHeadersWithUnderscores:
+		cm.CommonHttpProtocolOptions.HeadersWithUnderscoresAction = envoy_core_v3.HttpProtocolOptions_REJECT_REQUEST
+	case config.DropHeadersWithUnderscores:
+		cm.CommonHttpProtocolOptions.HeadersWithUnderscoresAction = envoy_core_v3.HttpProtocolOptions_DROP_HEADER
+	}
+
+	cm.ServerName = b.serverHeader.Name
+	switch b.serverHeader.Transformation {
+	case config.AppendIfAbsentServerHeader:
+		cm.ServerHeaderTransformation = http.HttpConnectionManager_APPEND_IF_ABSENT
+	case config.PassThroughServerHeader:
+		cm.ServerHeaderTransformation = http.HttpConnectionManager_PASS_THROUGH
+	}
+
 	if len(b.accessLoggers) > 0 {
 		cm.AccessLog = b.accessLoggers
 	}
@@ -461,6 +599,13 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accesslogger []*accesslog.
 	// Set to 9001 because now it's OVER NINE THOUSAND.
 	idleTimeout := protobuf.Duration(9001 * time.Second)
 
+	// Envoy requires a positive max downstream connection duration,
+	// so leave it unset unless an explicit duration is configured.
+	var maxConnectionDuration *duration.Duration
+	if d := proxy.MaxConnectionDuration; !d.UseDefault() && !d.IsDisabled() {
+		maxConnectionDuration = protobuf.Duration(d.Duration())
+	}
+
 	switch len(proxy.Clusters) {
 	case 1:
 		return &envoy_listener_v3.Filter{
@@ -471,16 +616,29 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accesslogger []*accesslog.
 					ClusterSpecifier: &tcp.TcpProxy_Cluster{
 						Cluster: envoy.Clustername(proxy.Clusters[0]),
 					},
-					AccessLog:   accesslogger,
-					IdleTimeout: idleTimeout,
+					AccessLog:                       accesslogger,
+					IdleTimeout:                     idleTimeout,
+					MaxDownstreamConnectionDuration: maxConnectionDuration,
 				}),
 			},
 		}
 	default:
+		// As with HTTP routes, the clusters receive an equal share
+		// of the connections unless some of them set a weight, in
+		// which case the clusters with a zero weight receive none.
+		// Envoy requires positive weights, so they are left out.
+		weighted := false
+		for _, c := range proxy.Clusters {
+			weighted = weighted || c.Weight > 0
+		}
+
 		var clusters []*tcp.TcpProxy_WeightedCluster_ClusterWeight
 		for _, c := range proxy.Clusters {
 			weight := c.Weight
 			if weight == 0 {
+				if weighted {
+					continue
+				}
 				weight = 1
 			}
 			clusters = append(clusters, &tcp.TcpProxy_WeightedCluster_ClusterWeight{
@@ -499,8 +657,9 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accesslogger []*accesslog.
 							Clusters: clusters,
 						},
 					},
-					AccessLog:   accesslogger,
-					IdleTimeout: idleTimeout,
+					AccessLog:                       accesslogger,
+					IdleTimeout:                     idleTimeout,
+					MaxDownstreamConnectionDuration: maxConnectionDuration,
 				}),
 			},
 		}
@@ -562,20 +721,28 @@ func FilterChains(filters ...*envoy_listener_v3.Filter) []*envoy_listener_v3.Fil
 }
 
 func FilterMisdirectedRequests(fqdn string) *http.HttpFilter {
-	var target string
-
 	if strings.HasPrefix(fqdn, "*.") {
 		// When we have a wildcard hostname, we will have already matched
 		// the filter chain on an SNI that falls under the wildcard so we
 		// retrieve that and make sure the :authority header matches.
-		// See: https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/lua_filter#requestedservername
-		target = "request_handle:streamInfo():requestedServerName()"
-	} else {
-		// For specific hostnames we know the SNI we need to match the
-		// :authority header against so we can simplify the code.
-		target = `"` + strings.ToLower(fqdn) + `"`
+		return FilterMisdirectedRequestsBySNI()
 	}
 
+	// For specific hostnames we know the SNI we need to match the
+	// :authority header against so we can simplify the code.
+	return filterMisdirectedRequests(`"` + strings.ToLower(fqdn) + `"`)
+}
+
+// FilterMisdirectedRequestsBySNI returns an HTTP filter that responds
+// with 421 to requests whose :authority header doesn't match the SNI
+// server name of their connection. It is used on filter chains that
+// match more than one server name.
+func FilterMisdirectedRequestsBySNI() *http.HttpFilter {
+	// See: https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/lua_filter#requestedservername
+	return filterMisdirectedRequests("request_handle:streamInfo():requestedServerName()")
+}
+
+func filterMisdirectedRequests(target string) *http.HttpFilter {
 	code := `
 function envoy_on_request(request_handle)
 	local headers = request_handle:headers()
@@ -642,6 +809,131 @@ func FilterExternalAuthz(authzClusterName string, failOpen bool, timeout timeout
 	}
 }
 
+// FilterOAuth2 returns an `envoy.filters.http.oauth2` filter that
+// redirects users of the given host to the OpenID Connect provider
+// described by the policy.
+func FilterOAuth2(host string, policy *dag.OIDCPolicy) *http.HttpFilter {
+	pathMatcher := func(path string) *matcher.PathMatcher {
+		return &matcher.PathMatcher{
+			Rule: &matcher.PathMatcher_Path{
+				Path: &matcher.StringMatcher{
+					MatchPattern: &matcher.StringMatcher_Exact{
+						Exact: path,
+					},
+				},
+			},
+		}
+	}
+
+	oauthConfig := envoy_oauth2_v3.OAuth2{
+		Config: &envoy_oauth2_v3.OAuth2Config{
+			TokenEndpoint: &envoy_core_v3.HttpUri{
+				Uri: policy.TokenEndpoint,
+				HttpUpstreamType: &envoy_core_v3.HttpUri_Cluster{
+					Cluster: policy.TokenCluster.Name(),
+				},
+				Timeout: protobuf.Duration(5 * time.Second),
+			},
+			AuthorizationEndpoint: policy.AuthorizationEndpoint,
+			Credentials: &envoy_oauth2_v3.OAuth2Credentials{
+				ClientId: policy.ClientID,
+				TokenSecret: &envoy_tls_v3.SdsSecretConfig{
+					Name:      OAuth2TokenSecretName(policy),
+					SdsConfig: ConfigSource("contour"),
+				},
+				TokenFormation: &envoy_oauth2_v3.OAuth2Credentials_HmacSecret{
+					HmacSecret: &envoy_tls_v3.SdsSecretConfig{
+						Name:      OAuth2HMACSecretName(policy),
+						SdsConfig: ConfigSource("contour"),
+					},
+				},
+			},
+			RedirectUri:         "https://" + host + policy.RedirectPath,
+			RedirectPathMatcher: pathMatcher(policy.RedirectPath),
+			SignoutPath:         pathMatcher(policy.SignoutPath),
+			ForwardBearerToken:  policy.ForwardBearerToken,
+			AuthScopes:          policy.Scopes,
+		},
+	}
+
+	return &http.HttpFilter{
+		Name: "envoy.filters.http.oauth2",
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&oauthConfig),
+		},
+	}
+}
+
+// FilterRequestDecompression returns a HTTP filter that decompresses
+// gzip encoded request bodies before they are forwarded upstream.
+// Response decompression is disabled, so responses are passed to the
+// client unmodified.
+func FilterRequestDecompression() *http.HttpFilter {
+	return &http.HttpFilter{
+		Name: "decompressor",
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_decompressor_v3.Decompressor{
+				DecompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+					Name: "gzip",
+					TypedConfig: &any.Any{
+						TypeUrl: HTTPFilterGzipDecompressor,
+					},
+				},
+				ResponseDirectionConfig: &envoy_decompressor_v3.Decompressor_ResponseDirectionConfig{
+					CommonConfig: &envoy_decompressor_v3.Decompressor_CommonDirectionConfig{
+						Enabled: &envoy_core_v3.RuntimeFeatureFlag{
+							DefaultValue: protobuf.Bool(false),
+							RuntimeKey:   "decompressor.response.enabled",
+						},
+					},
+				},
+			}),
+		},
+	}
+}
+
+// FilterAdmissionControl returns an `envoy.filters.http.admission_control`
+// filter that probabilistically rejects requests when the success rate
+// of requests in the policy's sampling window falls below its threshold.
+// HTTP responses other than 5xx are treated as successes, as are gRPC
+// statuses that do not indicate an overloaded backend. If policy is nil,
+// FilterAdmissionControl returns nil.
+func FilterAdmissionControl(policy *dag.ConcurrencyPolicy) *http.HttpFilter {
+	if policy == nil {
+		return nil
+	}
+
+	return &http.HttpFilter{
+		Name: "envoy.filters.http.admission_control",
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_admission_control_v3.AdmissionControl{
+				Enabled: &envoy_core_v3.RuntimeFeatureFlag{
+					DefaultValue: protobuf.Bool(true),
+					RuntimeKey:   "admission_control.enabled",
+				},
+				EvaluationCriteria: &envoy_admission_control_v3.AdmissionControl_SuccessCriteria_{
+					SuccessCriteria: &envoy_admission_control_v3.AdmissionControl_SuccessCriteria{
+						HttpCriteria: &envoy_admission_control_v3.AdmissionControl_SuccessCriteria_HttpCriteria{
+							HttpSuccessStatus: []*envoy_type.Int32Range{{
+								Start: 100,
+								End:   500,
+							}},
+						},
+						GrpcCriteria: &envoy_admission_control_v3.AdmissionControl_SuccessCriteria_GrpcCriteria{},
+					},
+				},
+				SamplingWindow: protobuf.Duration(policy.SamplingWindow),
+				SrThreshold: &envoy_core_v3.RuntimePercent{
+					DefaultValue: &envoy_type.Percent{
+						Value: float64(policy.SuccessRateThreshold),
+					},
+					RuntimeKey: "admission_control.sr_threshold",
+				},
+			}),
+		},
+	}
+}
+
 // FilterChainTLS returns a TLS enabled envoy_listener_v3.FilterChain.
 func FilterChainTLS(domain string, downstream *envoy_tls_v3.DownstreamTlsContext, filters []*envoy_listener_v3.Filter) *envoy_listener_v3.FilterChain {
 	fc := &envoy_listener_v3.FilterChain{
@@ -669,6 +961,20 @@ func FilterChainTLS(domain string, downstream *envoy_tls_v3.DownstreamTlsContext
 	return fc
 }
 
+// SourcePrefixRanges returns the supplied address ranges as a
+// []*envoy_core_v3.CidrRange for use in a FilterChainMatch.
+func SourcePrefixRanges(ranges []*net.IPNet) []*envoy_core_v3.CidrRange {
+	var cidrs []*envoy_core_v3.CidrRange
+	for _, r := range ranges {
+		ones, _ := r.Mask.Size()
+		cidrs = append(cidrs, &envoy_core_v3.CidrRange{
+			AddressPrefix: r.IP.String(),
+			PrefixLen:     protobuf.UInt32(uint32(ones)),
+		})
+	}
+	return cidrs
+}
+
 // FilterChainTLSFallback returns a TLS enabled envoy_listener_v3.FilterChain conifgured for FallbackCertificate.
 func FilterChainTLSFallback(downstream *envoy_tls_v3.DownstreamTlsContext, filters []*envoy_listener_v3.Filter) *envoy_listener_v3.FilterChain {
 	fc := &envoy_listener_v3.FilterChain{
@@ -685,6 +991,20 @@ func FilterChainTLSFallback(downstream *envoy_tls_v3.DownstreamTlsContext, filte
 	return fc
 }
 
+// FilterChainTLSPassthroughFallback returns a envoy_listener_v3.FilterChain that
+// matches TLS connections which did not match any other filter chain by server name.
+// Envoy cannot match the absence of a server name, so the chain starts with an
+// RBAC filter that closes the connections presenting an unknown server name.
+func FilterChainTLSPassthroughFallback(filters []*envoy_listener_v3.Filter) *envoy_listener_v3.FilterChain {
+	return &envoy_listener_v3.FilterChain{
+		Name:    "fallback-passthrough",
+		Filters: append([]*envoy_listener_v3.Filter{NoServerNameRBAC("fallback-passthrough")}, filters...),
+		FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+			TransportProtocol: "tls",
+		},
+	}
+}
+
 // ListenerFilters returns a []*envoy_listener_v3.ListenerFilter for the supplied listener filters.
 func ListenerFilters(filters ...*envoy_listener_v3.ListenerFilter) []*envoy_listener_v3.ListenerFilter {
 	return filters
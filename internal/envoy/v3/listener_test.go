@@ -22,15 +22,18 @@ import (
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoy_compressor_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/compressor/v3"
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
+	envoy_config_filter_http_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	envoy_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/timeout"
+	"github.com/projectcontour/contour/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
@@ -302,6 +305,45 @@ func TestDownstreamTLSContext(t *testing.T) {
 		},
 	}
 
+	peerValidationContextWithSubjectAltNames := &dag.PeerValidationContext{
+		CACertificate: &dag.Secret{
+			Object: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					dag.CACertificateKey: ca,
+				},
+			},
+		},
+		SubjectAltNames: []dag.SubjectAltNameMatch{{
+			MatchType: dag.SubjectAltNameMatchTypeExact,
+			Value:     "client.example.com",
+		}, {
+			MatchType: dag.SubjectAltNameMatchTypeSuffix,
+			Value:     ".clients.example.com",
+		}},
+	}
+	validationContextWithSubjectAltNames := &envoy_tls_v3.CommonTlsContext_ValidationContext{
+		ValidationContext: &envoy_tls_v3.CertificateValidationContext{
+			TrustedCa: &envoy_core_v3.DataSource{
+				Specifier: &envoy_core_v3.DataSource_InlineBytes{
+					InlineBytes: ca,
+				},
+			},
+			MatchSubjectAltNames: []*matcher.StringMatcher{{
+				MatchPattern: &matcher.StringMatcher_Exact{
+					Exact: "client.example.com",
+				},
+			}, {
+				MatchPattern: &matcher.StringMatcher_Suffix{
+					Suffix: ".clients.example.com",
+				},
+			}},
+		},
+	}
+
 	tests := map[string]struct {
 		got  *envoy_tls_v3.DownstreamTlsContext
 		want *envoy_tls_v3.DownstreamTlsContext
@@ -364,6 +406,18 @@ func TestDownstreamTLSContext(t *testing.T) {
 				RequireClientCertificate: protobuf.Bool(true),
 			},
 		},
+		"client authentication with allowed subject alt names": {
+			DownstreamTLSContext(serverSecret, envoy_tls_v3.TlsParameters_TLSv1_2, cipherSuites, peerValidationContextWithSubjectAltNames, "h2", "http/1.1"),
+			&envoy_tls_v3.DownstreamTlsContext{
+				CommonTlsContext: &envoy_tls_v3.CommonTlsContext{
+					TlsParams:                      tlsParams,
+					TlsCertificateSdsSecretConfigs: tlsCertificateSdsSecretConfigs,
+					AlpnProtocols:                  alpnProtocols,
+					ValidationContextType:          validationContextWithSubjectAltNames,
+				},
+				RequireClientCertificate: protobuf.Bool(true),
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -385,6 +439,15 @@ func TestHTTPConnectionManager(t *testing.T) {
 		connectionShutdownGracePeriod timeout.Setting
 		allowChunkedLength            bool
 		xffNumTrustedHops             uint32
+		maxRequestHeadersKB           uint32
+		maxRequestHeadersCount        uint32
+		disableNormalizePath          bool
+		disableMergeSlashes           bool
+		escapedSlashesAction          config.EscapedSlashesActionType
+		headersWithUnderscoresAction  config.HeadersWithUnderscoresActionType
+		disableHTTP10                 bool
+		defaultHostForHTTP10          string
+		serverHeader                  config.ServerHeaderParameters
 		want                          *envoy_listener_v3.Filter
 	}{
 		"default": {
@@ -451,6 +514,706 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+					}),
+				},
+			},
+		},
+		"request timeout of 10s": {
+			routename:      "default/kuard",
+			accesslogger:   FileAccessLogEnvoy("/dev/stdout"),
+			requestTimeout: timeout.DurationSetting(10 * time.Second),
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						RequestTimeout:            protobuf.Duration(10 * time.Second),
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+					}),
+				},
+			},
+		},
+		"connection idle timeout of 90s": {
+			routename:             "default/kuard",
+			accesslogger:          FileAccessLogEnvoy("/dev/stdout"),
+			connectionIdleTimeout: timeout.DurationSetting(90 * time.Second),
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+							IdleTimeout: protobuf.Duration(90 * time.Second),
+						},
+						AccessLog:        FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress: protobuf.Bool(true),
+						NormalizePath:    protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+					}),
+				},
+			},
+		},
+		"stream idle timeout of 90s": {
+			routename:         "default/kuard",
+			accesslogger:      FileAccessLogEnvoy("/dev/stdout"),
+			streamIdleTimeout: timeout.DurationSetting(90 * time.Second),
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+						StreamIdleTimeout:         protobuf.Duration(90 * time.Second),
+					}),
+				},
+			},
+		},
+		"max connection duration of 90s": {
+			routename:             "default/kuard",
+			accesslogger:          FileAccessLogEnvoy("/dev/stdout"),
+			maxConnectionDuration: timeout.DurationSetting(90 * time.Second),
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+							MaxConnectionDuration: protobuf.Duration(90 * time.Second),
+						},
+						AccessLog:        FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress: protobuf.Bool(true),
+						NormalizePath:    protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+					}),
+				},
+			},
+		},
+		"when max connection duration is disabled, it's omitted": {
+			routename:             "default/kuard",
+			accesslogger:          FileAccessLogEnvoy("/dev/stdout"),
+			maxConnectionDuration: timeout.DisabledSetting(),
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+					}),
+				},
+			},
+		},
+		"delayed close timeout of 90s": {
+			routename:           "default/kuard",
+			accesslogger:        FileAccessLogEnvoy("/dev/stdout"),
+			delayedCloseTimeout: timeout.DurationSetting(90 * time.Second),
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
+						}, {
+							Name: "router",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterRouter,
+								},
+							},
+						}},
+						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
+							// Enable support for HTTP/1.0 requests that carry
+							// a Host: header. See #537.
+							AcceptHttp_10: true,
+						},
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
+						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
+							StripAnyHostPort: true,
+						},
+						PreserveExternalRequestId: true,
+						MergeSlashes:              true,
+						DelayedCloseTimeout:       protobuf.Duration(90 * time.Second),
+					}),
+				},
+			},
+		},
+		"drain timeout of 90s": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "default/kuard",
+						RouteSpecifier: &http.HttpConnectionManager_Rds{
+							Rds: &http.Rds{
+								RouteConfigName: "default/kuard",
+								ConfigSource: &envoy_core_v3.ConfigSource{
+									ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+									ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_ApiConfigSource{
+										ApiConfigSource: &envoy_core_v3.ApiConfigSource{
+											ApiType:             envoy_core_v3.ApiConfigSource_GRPC,
+											TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+											GrpcServices: []*envoy_core_v3.GrpcService{{
+												TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+													EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+														ClusterName: "contour",
+													},
+												},
+											}},
+										},
+									},
+								},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: "compressor",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_compressor_v3.Compressor{
+									CompressorLibrary: &envoy_core_v3.TypedExtensionConfig{
+										Name: "gzip",
+										TypedConfig: &any.Any{
+											TypeUrl: HTTPFilterGzip,
+										},
+									},
+								}),
+							},
+						}, {
+							Name: "grpcweb",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterGrpcWeb,
+								},
+							},
+						}, {
+							Name: "cors",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: &any.Any{
+									TypeUrl: HTTPFilterCORS,
+								},
+							},
+						}, {
+							Name: "local_ratelimit",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+										StatPrefix: "http",
+									},
+								),
+							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -473,14 +1236,16 @@ func TestHTTPConnectionManager(t *testing.T) {
 						},
 						PreserveExternalRequestId: true,
 						MergeSlashes:              true,
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
 					}),
 				},
 			},
 		},
-		"request timeout of 10s": {
-			routename:      "default/kuard",
-			accesslogger:   FileAccessLogEnvoy("/dev/stdout"),
-			requestTimeout: timeout.DurationSetting(10 * time.Second),
+		"enable allow_chunked_length": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			allowChunkedLength:            true,
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -542,6 +1307,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -553,7 +1325,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
 							// Enable support for HTTP/1.0 requests that carry
 							// a Host: header. See #537.
-							AcceptHttp_10: true,
+							AcceptHttp_10:      true,
+							AllowChunkedLength: true,
 						},
 						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
 						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
@@ -562,17 +1335,18 @@ func TestHTTPConnectionManager(t *testing.T) {
 						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
 							StripAnyHostPort: true,
 						},
-						RequestTimeout:            protobuf.Duration(10 * time.Second),
 						PreserveExternalRequestId: true,
 						MergeSlashes:              true,
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
 					}),
 				},
 			},
 		},
-		"connection idle timeout of 90s": {
-			routename:             "default/kuard",
-			accesslogger:          FileAccessLogEnvoy("/dev/stdout"),
-			connectionIdleTimeout: timeout.DurationSetting(90 * time.Second),
+		"enable XffNumTrustedHops": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			xffNumTrustedHops:             1,
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -634,6 +1408,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -647,25 +1428,27 @@ func TestHTTPConnectionManager(t *testing.T) {
 							// a Host: header. See #537.
 							AcceptHttp_10: true,
 						},
-						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
-							IdleTimeout: protobuf.Duration(90 * time.Second),
-						},
-						AccessLog:        FileAccessLogEnvoy("/dev/stdout"),
-						UseRemoteAddress: protobuf.Bool(true),
-						NormalizePath:    protobuf.Bool(true),
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(true),
 						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
 							StripAnyHostPort: true,
 						},
 						PreserveExternalRequestId: true,
 						MergeSlashes:              true,
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
+						XffNumTrustedHops:         1,
 					}),
 				},
 			},
 		},
-		"stream idle timeout of 90s": {
-			routename:         "default/kuard",
-			accesslogger:      FileAccessLogEnvoy("/dev/stdout"),
-			streamIdleTimeout: timeout.DurationSetting(90 * time.Second),
+		"request headers limits": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			maxRequestHeadersKB:           80,
+			maxRequestHeadersCount:        200,
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -727,6 +1510,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -740,24 +1530,29 @@ func TestHTTPConnectionManager(t *testing.T) {
 							// a Host: header. See #537.
 							AcceptHttp_10: true,
 						},
-						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
-						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
-						UseRemoteAddress:          protobuf.Bool(true),
-						NormalizePath:             protobuf.Bool(true),
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+							MaxHeadersCount: protobuf.UInt32(200),
+						},
+						AccessLog:        FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress: protobuf.Bool(true),
+						NormalizePath:    protobuf.Bool(true),
 						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
 							StripAnyHostPort: true,
 						},
 						PreserveExternalRequestId: true,
 						MergeSlashes:              true,
-						StreamIdleTimeout:         protobuf.Duration(90 * time.Second),
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
+						MaxRequestHeadersKb:       protobuf.UInt32(80),
 					}),
 				},
 			},
 		},
-		"max connection duration of 90s": {
-			routename:             "default/kuard",
-			accesslogger:          FileAccessLogEnvoy("/dev/stdout"),
-			maxConnectionDuration: timeout.DurationSetting(90 * time.Second),
+		"path normalization disabled": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			disableNormalizePath:          true,
+			disableMergeSlashes:           true,
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -819,6 +1614,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -832,25 +1634,25 @@ func TestHTTPConnectionManager(t *testing.T) {
 							// a Host: header. See #537.
 							AcceptHttp_10: true,
 						},
-						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
-							MaxConnectionDuration: protobuf.Duration(90 * time.Second),
-						},
-						AccessLog:        FileAccessLogEnvoy("/dev/stdout"),
-						UseRemoteAddress: protobuf.Bool(true),
-						NormalizePath:    protobuf.Bool(true),
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
+						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress:          protobuf.Bool(true),
+						NormalizePath:             protobuf.Bool(false),
 						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
 							StripAnyHostPort: true,
 						},
 						PreserveExternalRequestId: true,
-						MergeSlashes:              true,
+						MergeSlashes:              false,
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
 					}),
 				},
 			},
 		},
-		"when max connection duration is disabled, it's omitted": {
-			routename:             "default/kuard",
-			accesslogger:          FileAccessLogEnvoy("/dev/stdout"),
-			maxConnectionDuration: timeout.DisabledSetting(),
+		"unescape and redirect escaped slashes": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			escapedSlashesAction:          config.UnescapeAndRedirectEscapedSlashes,
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -912,6 +1714,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -932,16 +1741,19 @@ func TestHTTPConnectionManager(t *testing.T) {
 						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
 							StripAnyHostPort: true,
 						},
-						PreserveExternalRequestId: true,
-						MergeSlashes:              true,
+						PreserveExternalRequestId:    true,
+						MergeSlashes:                 true,
+						PathWithEscapedSlashesAction: http.HttpConnectionManager_UNESCAPE_AND_REDIRECT,
+						DrainTimeout:                 protobuf.Duration(90 * time.Second),
 					}),
 				},
 			},
 		},
-		"delayed close timeout of 90s": {
-			routename:           "default/kuard",
-			accesslogger:        FileAccessLogEnvoy("/dev/stdout"),
-			delayedCloseTimeout: timeout.DurationSetting(90 * time.Second),
+		"reject headers with underscores": {
+			routename:                     "default/kuard",
+			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
+			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			headersWithUnderscoresAction:  config.RejectHeadersWithUnderscores,
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -1003,6 +1815,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -1016,24 +1835,30 @@ func TestHTTPConnectionManager(t *testing.T) {
 							// a Host: header. See #537.
 							AcceptHttp_10: true,
 						},
-						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
-						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
-						UseRemoteAddress:          protobuf.Bool(true),
-						NormalizePath:             protobuf.Bool(true),
+						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+							HeadersWithUnderscoresAction: envoy_core_v3.HttpProtocolOptions_REJECT_REQUEST,
+						},
+						AccessLog:        FileAccessLogEnvoy("/dev/stdout"),
+						UseRemoteAddress: protobuf.Bool(true),
+						NormalizePath:    protobuf.Bool(true),
 						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
 							StripAnyHostPort: true,
 						},
 						PreserveExternalRequestId: true,
 						MergeSlashes:              true,
-						DelayedCloseTimeout:       protobuf.Duration(90 * time.Second),
+						DrainTimeout:              protobuf.Duration(90 * time.Second),
 					}),
 				},
 			},
 		},
-		"drain timeout of 90s": {
+		"server header": {
 			routename:                     "default/kuard",
 			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
 			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
+			serverHeader: config.ServerHeaderParameters{
+				Name:           "example",
+				Transformation: config.AppendIfAbsentServerHeader,
+			},
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -1095,6 +1920,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -1115,18 +1947,20 @@ func TestHTTPConnectionManager(t *testing.T) {
 						StripPortMode: &http.HttpConnectionManager_StripAnyHostPort{
 							StripAnyHostPort: true,
 						},
-						PreserveExternalRequestId: true,
-						MergeSlashes:              true,
-						DrainTimeout:              protobuf.Duration(90 * time.Second),
+						PreserveExternalRequestId:  true,
+						MergeSlashes:               true,
+						DrainTimeout:               protobuf.Duration(90 * time.Second),
+						ServerName:                 "example",
+						ServerHeaderTransformation: http.HttpConnectionManager_APPEND_IF_ABSENT,
 					}),
 				},
 			},
 		},
-		"enable allow_chunked_length": {
+		"default host for http/1.0": {
 			routename:                     "default/kuard",
 			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
 			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
-			allowChunkedLength:            true,
+			defaultHostForHTTP10:          "www.example.com",
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -1188,6 +2022,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -1199,8 +2040,8 @@ func TestHTTPConnectionManager(t *testing.T) {
 						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
 							// Enable support for HTTP/1.0 requests that carry
 							// a Host: header. See #537.
-							AcceptHttp_10:      true,
-							AllowChunkedLength: true,
+							AcceptHttp_10:         true,
+							DefaultHostForHttp_10: "www.example.com",
 						},
 						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
 						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
@@ -1216,11 +2057,11 @@ func TestHTTPConnectionManager(t *testing.T) {
 				},
 			},
 		},
-		"enable XffNumTrustedHops": {
+		"http/1.0 disabled": {
 			routename:                     "default/kuard",
 			accesslogger:                  FileAccessLogEnvoy("/dev/stdout"),
 			connectionShutdownGracePeriod: timeout.DurationSetting(90 * time.Second),
-			xffNumTrustedHops:             1,
+			disableHTTP10:                 true,
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
@@ -1282,6 +2123,13 @@ func TestHTTPConnectionManager(t *testing.T) {
 									},
 								),
 							},
+						}, {
+							Name: "rbac",
+							ConfigType: &http.HttpFilter_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(
+									&envoy_config_filter_http_rbac_v3.RBAC{},
+								),
+							},
 						}, {
 							Name: "router",
 							ConfigType: &http.HttpFilter_TypedConfig{
@@ -1291,9 +2139,7 @@ func TestHTTPConnectionManager(t *testing.T) {
 							},
 						}},
 						HttpProtocolOptions: &envoy_core_v3.Http1ProtocolOptions{
-							// Enable support for HTTP/1.0 requests that carry
-							// a Host: header. See #537.
-							AcceptHttp_10: true,
+							AcceptHttp_10: false,
 						},
 						CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{},
 						AccessLog:                 FileAccessLogEnvoy("/dev/stdout"),
@@ -1305,7 +2151,6 @@ func TestHTTPConnectionManager(t *testing.T) {
 						PreserveExternalRequestId: true,
 						MergeSlashes:              true,
 						DrainTimeout:              protobuf.Duration(90 * time.Second),
-						XffNumTrustedHops:         1,
 					}),
 				},
 			},
@@ -1325,6 +2170,15 @@ func TestHTTPConnectionManager(t *testing.T) {
 				ConnectionShutdownGracePeriod(tc.connectionShutdownGracePeriod).
 				AllowChunkedLength(tc.allowChunkedLength).
 				NumTrustedHops(tc.xffNumTrustedHops).
+				MaxRequestHeadersKB(tc.maxRequestHeadersKB).
+				MaxRequestHeadersCount(tc.maxRequestHeadersCount).
+				DisableNormalizePath(tc.disableNormalizePath).
+				DisableMergeSlashes(tc.disableMergeSlashes).
+				EscapedSlashesAction(tc.escapedSlashesAction).
+				HeadersWithUnderscoresAction(tc.headersWithUnderscoresAction).
+				DisableHTTP10(tc.disableHTTP10).
+				DefaultHostForHTTP10(tc.defaultHostForHTTP10).
+				ServerHeader(tc.serverHeader).
 				DefaultFilters().
 				Get()
 
@@ -1367,6 +2221,19 @@ func TestTCPProxy(t *testing.T) {
 		},
 		Weight: 20,
 	}
+	c3 := &dag.Cluster{
+		Upstream: &dag.Service{
+			Weighted: dag.WeightedService{
+				ServiceName:      "example3",
+				ServiceNamespace: "default",
+				ServicePort: v1.ServicePort{
+					Protocol:   "TCP",
+					Port:       443,
+					TargetPort: intstr.FromInt(8443),
+				},
+			},
+		},
+	}
 
 	tests := map[string]struct {
 		proxy *dag.TCPProxy
@@ -1390,9 +2257,48 @@ func TestTCPProxy(t *testing.T) {
 				},
 			},
 		},
+		"single cluster with max connection duration": {
+			proxy: &dag.TCPProxy{
+				Clusters:              []*dag.Cluster{c1},
+				MaxConnectionDuration: timeout.DurationSetting(time.Hour),
+			},
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.TCPProxy,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_tcp_proxy_v3.TcpProxy{
+						StatPrefix: statPrefix,
+						ClusterSpecifier: &envoy_tcp_proxy_v3.TcpProxy_Cluster{
+							Cluster: envoy.Clustername(c1),
+						},
+						AccessLog:                       FileAccessLogEnvoy(accessLogPath),
+						IdleTimeout:                     protobuf.Duration(9001 * time.Second),
+						MaxDownstreamConnectionDuration: protobuf.Duration(time.Hour),
+					}),
+				},
+			},
+		},
+		"single cluster with max connection duration disabled": {
+			proxy: &dag.TCPProxy{
+				Clusters:              []*dag.Cluster{c1},
+				MaxConnectionDuration: timeout.DisabledSetting(),
+			},
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.TCPProxy,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_tcp_proxy_v3.TcpProxy{
+						StatPrefix: statPrefix,
+						ClusterSpecifier: &envoy_tcp_proxy_v3.TcpProxy_Cluster{
+							Cluster: envoy.Clustername(c1),
+						},
+						AccessLog:   FileAccessLogEnvoy(accessLogPath),
+						IdleTimeout: protobuf.Duration(9001 * time.Second),
+					}),
+				},
+			},
+		},
 		"multiple cluster": {
 			proxy: &dag.TCPProxy{
-				Clusters: []*dag.Cluster{c2, c1},
+				Clusters: []*dag.Cluster{c3, c1},
 			},
 			want: &envoy_listener_v3.Filter{
 				Name: wellknown.TCPProxy,
@@ -1405,6 +2311,29 @@ func TestTCPProxy(t *testing.T) {
 									Name:   envoy.Clustername(c1),
 									Weight: 1,
 								}, {
+									Name:   envoy.Clustername(c3),
+									Weight: 1,
+								}},
+							},
+						},
+						AccessLog:   FileAccessLogEnvoy(accessLogPath),
+						IdleTimeout: protobuf.Duration(9001 * time.Second),
+					}),
+				},
+			},
+		},
+		"multiple cluster with zero weight": {
+			proxy: &dag.TCPProxy{
+				Clusters: []*dag.Cluster{c2, c1},
+			},
+			want: &envoy_listener_v3.Filter{
+				Name: wellknown.TCPProxy,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_tcp_proxy_v3.TcpProxy{
+						StatPrefix: statPrefix,
+						ClusterSpecifier: &envoy_tcp_proxy_v3.TcpProxy_WeightedClusters{
+							WeightedClusters: &envoy_tcp_proxy_v3.TcpProxy_WeightedCluster{
+								Clusters: []*envoy_tcp_proxy_v3.TcpProxy_WeightedCluster_ClusterWeight{{
 									Name:   envoy.Clustername(c2),
 									Weight: 20,
 								}},
@@ -1625,6 +2554,14 @@ func TestAddFilter(t *testing.T) {
 						),
 					},
 				},
+				{
+					Name: "rbac",
+					ConfigType: &http.HttpFilter_TypedConfig{
+						TypedConfig: protobuf.MustMarshalAny(
+							&envoy_config_filter_http_rbac_v3.RBAC{},
+						),
+					},
+				},
 				FilterExternalAuthz("test", false, timeout.Setting{}),
 				{
 					Name: "router",
@@ -0,0 +1,117 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_config_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	envoy_filter_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	envoy_network_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/protobuf"
+)
+
+// RouteRBAC returns a per-route config for the HTTP RBAC filter
+// that only allows the requests that have one of the methods,
+// and all of the header values, of the given policy.
+func RouteRBAC(policy *dag.RBACPolicy) *any.Any {
+	if policy == nil {
+		return nil
+	}
+
+	var permissions []*envoy_config_rbac_v3.Permission
+
+	if len(policy.Methods) > 0 {
+		var methods []*envoy_config_rbac_v3.Permission
+		for _, m := range policy.Methods {
+			methods = append(methods, headerPermission(dag.HeaderMatchCondition{
+				Name:      ":method",
+				Value:     m,
+				MatchType: dag.HeaderMatchTypeExact,
+			}))
+		}
+		permissions = append(permissions, &envoy_config_rbac_v3.Permission{
+			Rule: &envoy_config_rbac_v3.Permission_OrRules{
+				OrRules: &envoy_config_rbac_v3.Permission_Set{Rules: methods},
+			},
+		})
+	}
+
+	for _, h := range policy.Headers {
+		permissions = append(permissions, headerPermission(h))
+	}
+
+	return protobuf.MustMarshalAny(&envoy_filter_rbac_v3.RBACPerRoute{
+		Rbac: &envoy_filter_rbac_v3.RBAC{
+			Rules: &envoy_config_rbac_v3.RBAC{
+				Action: envoy_config_rbac_v3.RBAC_ALLOW,
+				Policies: map[string]*envoy_config_rbac_v3.Policy{
+					"route": {
+						Permissions: []*envoy_config_rbac_v3.Permission{{
+							Rule: &envoy_config_rbac_v3.Permission_AndRules{
+								AndRules: &envoy_config_rbac_v3.Permission_Set{Rules: permissions},
+							},
+						}},
+						Principals: []*envoy_config_rbac_v3.Principal{{
+							Identifier: &envoy_config_rbac_v3.Principal_Any{Any: true},
+						}},
+					},
+				},
+			},
+		},
+	})
+}
+
+// headerPermission returns a RBAC permission that matches
+// the requests that satisfy the header condition h.
+func headerPermission(h dag.HeaderMatchCondition) *envoy_config_rbac_v3.Permission {
+	return &envoy_config_rbac_v3.Permission{
+		Rule: &envoy_config_rbac_v3.Permission_Header{
+			Header: headerMatcher([]dag.HeaderMatchCondition{h})[0],
+		},
+	}
+}
+
+// NoServerNameRBAC returns a network RBAC filter that only allows
+// the connections that did not present an SNI server name.
+func NoServerNameRBAC(statPrefix string) *envoy_listener_v3.Filter {
+	return &envoy_listener_v3.Filter{
+		Name: wellknown.RoleBasedAccessControl,
+		ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_network_rbac_v3.RBAC{
+				StatPrefix: statPrefix,
+				Rules: &envoy_config_rbac_v3.RBAC{
+					Action: envoy_config_rbac_v3.RBAC_ALLOW,
+					Policies: map[string]*envoy_config_rbac_v3.Policy{
+						"no-server-name": {
+							Permissions: []*envoy_config_rbac_v3.Permission{{
+								Rule: &envoy_config_rbac_v3.Permission_RequestedServerName{
+									RequestedServerName: &envoy_matcher_v3.StringMatcher{
+										MatchPattern: &envoy_matcher_v3.StringMatcher_Exact{Exact: ""},
+									},
+								},
+							}},
+							Principals: []*envoy_config_rbac_v3.Principal{{
+								Identifier: &envoy_config_rbac_v3.Principal_Any{Any: true},
+							}},
+						},
+					},
+				},
+			}),
+		},
+	}
+}
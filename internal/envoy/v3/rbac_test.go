@@ -0,0 +1,120 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_config_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_filter_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	envoy_network_rbac_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestRouteRBAC(t *testing.T) {
+	header := func(name, value string) *envoy_config_rbac_v3.Permission {
+		return &envoy_config_rbac_v3.Permission{
+			Rule: &envoy_config_rbac_v3.Permission_Header{
+				Header: &envoy_route_v3.HeaderMatcher{
+					Name:                 name,
+					HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_ExactMatch{ExactMatch: value},
+				},
+			},
+		}
+	}
+
+	allow := func(permissions ...*envoy_config_rbac_v3.Permission) *anypb.Any {
+		return protobuf.MustMarshalAny(&envoy_filter_rbac_v3.RBACPerRoute{
+			Rbac: &envoy_filter_rbac_v3.RBAC{
+				Rules: &envoy_config_rbac_v3.RBAC{
+					Action: envoy_config_rbac_v3.RBAC_ALLOW,
+					Policies: map[string]*envoy_config_rbac_v3.Policy{
+						"route": {
+							Permissions: []*envoy_config_rbac_v3.Permission{{
+								Rule: &envoy_config_rbac_v3.Permission_AndRules{
+									AndRules: &envoy_config_rbac_v3.Permission_Set{Rules: permissions},
+								},
+							}},
+							Principals: []*envoy_config_rbac_v3.Principal{{
+								Identifier: &envoy_config_rbac_v3.Principal_Any{Any: true},
+							}},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	tests := map[string]struct {
+		policy *dag.RBACPolicy
+		want   *anypb.Any
+	}{
+		"nil policy": {
+			policy: nil,
+			want:   nil,
+		},
+		"methods": {
+			policy: &dag.RBACPolicy{Methods: []string{"GET", "HEAD"}},
+			want: allow(&envoy_config_rbac_v3.Permission{
+				Rule: &envoy_config_rbac_v3.Permission_OrRules{
+					OrRules: &envoy_config_rbac_v3.Permission_Set{
+						Rules: []*envoy_config_rbac_v3.Permission{
+							header(":method", "GET"),
+							header(":method", "HEAD"),
+						},
+					},
+				},
+			}),
+		},
+		"headers": {
+			policy: &dag.RBACPolicy{
+				Headers: []dag.HeaderMatchCondition{
+					{Name: "X-Internal", Value: "true", MatchType: dag.HeaderMatchTypeExact},
+					{Name: "X-Team", Value: "a", MatchType: dag.HeaderMatchTypeExact},
+				},
+			},
+			want: allow(header("X-Internal", "true"), header("X-Team", "a")),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, RouteRBAC(tc.policy))
+		})
+	}
+}
+
+func TestNoServerNameRBAC(t *testing.T) {
+	filter := NoServerNameRBAC("fallback-passthrough")
+	assert.Equal(t, "envoy.filters.network.rbac", filter.Name)
+
+	var got envoy_network_rbac_v3.RBAC
+	require.NoError(t, filter.GetTypedConfig().UnmarshalTo(&got))
+	require.NoError(t, got.Validate())
+
+	assert.Equal(t, "fallback-passthrough", got.StatPrefix)
+	assert.Equal(t, envoy_config_rbac_v3.RBAC_ALLOW, got.Rules.Action)
+
+	permissions := got.Rules.Policies["no-server-name"].Permissions
+	require.Len(t, permissions, 1)
+	assert.Equal(t, &envoy_matcher_v3.StringMatcher{
+		MatchPattern: &envoy_matcher_v3.StringMatcher_Exact{Exact: ""},
+	}, permissions[0].GetRequestedServerName())
+}
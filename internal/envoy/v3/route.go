@@ -15,6 +15,7 @@ package v3
 
 import (
 	"fmt"
+	"net/http"
 	"regexp"
 	"sort"
 	"strings"
@@ -24,6 +25,7 @@ import (
 	envoy_config_filter_http_ext_authz_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/golang/protobuf/ptypes/any"
+	_struct "github.com/golang/protobuf/ptypes/struct"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -60,8 +62,25 @@ const prefixPathMatchSegmentRegex = `((\/).*)?`
 
 var _ = regexp.MustCompile(prefixPathMatchSegmentRegex)
 
+// ignoreCaseRegexFlag makes an RE2 regular expression case-insensitive.
+const ignoreCaseRegexFlag = "(?i)"
+
 // RouteMatch creates a *envoy_route_v3.RouteMatch for the supplied *dag.Route.
 func RouteMatch(route *dag.Route) *envoy_route_v3.RouteMatch {
+	match := routeMatch(route)
+	if route.IgnorePathCase {
+		// case_sensitive only applies to prefix and path matches,
+		// so regex matches are made case-insensitive with a flag.
+		if r, ok := match.PathSpecifier.(*envoy_route_v3.RouteMatch_SafeRegex); ok {
+			r.SafeRegex.Regex = ignoreCaseRegexFlag + r.SafeRegex.Regex
+		} else {
+			match.CaseSensitive = protobuf.Bool(false)
+		}
+	}
+	return match
+}
+
+func routeMatch(route *dag.Route) *envoy_route_v3.RouteMatch {
 	switch c := route.PathMatchCondition.(type) {
 	case *dag.RegexMatchCondition:
 		return &envoy_route_v3.RouteMatch{
@@ -107,11 +126,40 @@ func RouteMatch(route *dag.Route) *envoy_route_v3.RouteMatch {
 // http status code supplied. This allows a direct response to a route request
 // with an HTTP status code without needing to route to a specific cluster.
 func RouteDirectResponse(response *dag.DirectResponse) *envoy_route_v3.Route_DirectResponse {
-	return &envoy_route_v3.Route_DirectResponse{
+	r := &envoy_route_v3.Route_DirectResponse{
 		DirectResponse: &envoy_route_v3.DirectResponseAction{
 			Status: response.StatusCode,
 		},
 	}
+	if response.Body != "" {
+		r.DirectResponse.Body = &envoy_core_v3.DataSource{
+			Specifier: &envoy_core_v3.DataSource_InlineString{
+				InlineString: response.Body,
+			},
+		}
+	}
+	return r
+}
+
+// RouteRedirect creates a *envoy_route_v3.Route_Redirect that redirects
+// the request to the hostname and path of redirect.
+func RouteRedirect(redirect *dag.Redirect) *envoy_route_v3.Route_Redirect {
+	r := &envoy_route_v3.Route_Redirect{
+		Redirect: &envoy_route_v3.RedirectAction{
+			HostRedirect: redirect.Hostname,
+		},
+	}
+	if redirect.Path != "" {
+		r.Redirect.PathRewriteSpecifier = &envoy_route_v3.RedirectAction_PathRedirect{
+			PathRedirect: redirect.Path,
+		}
+	}
+	if redirect.StatusCode == http.StatusMovedPermanently {
+		r.Redirect.ResponseCode = envoy_route_v3.RedirectAction_MOVED_PERMANENTLY
+	} else {
+		r.Redirect.ResponseCode = envoy_route_v3.RedirectAction_FOUND
+	}
+	return r
 }
 
 // RouteRoute creates a *envoy_route_v3.Route_Route for the services supplied.
@@ -127,6 +175,10 @@ func RouteRoute(r *dag.Route) *envoy_route_v3.Route_Route {
 		RequestMirrorPolicies: mirrorPolicy(r),
 	}
 
+	if r.StripPrefix {
+		ra.RegexRewrite = stripPrefixRewrite(r)
+	}
+
 	if r.RateLimitPolicy != nil && r.RateLimitPolicy.Global != nil {
 		ra.RateLimits = GlobalRateLimits(r.RateLimitPolicy.Global.Descriptors)
 	}
@@ -146,11 +198,16 @@ func RouteRoute(r *dag.Route) *envoy_route_v3.Route_Route {
 		)
 	}
 
-	if envoy.SingleSimpleCluster(r.Clusters) {
+	switch {
+	case r.DynamicForwardProxy:
+		ra.ClusterSpecifier = &envoy_route_v3.RouteAction_Cluster{
+			Cluster: DynamicForwardProxyClusterName,
+		}
+	case envoy.SingleSimpleCluster(r.Clusters):
 		ra.ClusterSpecifier = &envoy_route_v3.RouteAction_Cluster{
 			Cluster: envoy.Clustername(r.Clusters[0]),
 		}
-	} else {
+	default:
 		ra.ClusterSpecifier = &envoy_route_v3.RouteAction_WeightedClusters{
 			WeightedClusters: weightedClusters(r.Clusters),
 		}
@@ -219,10 +276,91 @@ func retryPolicy(r *dag.Route) *envoy_route_v3.RetryPolicy {
 		rp.NumRetries = protobuf.UInt32(r.RetryPolicy.NumRetries)
 	}
 	rp.PerTryTimeout = envoy.Timeout(r.RetryPolicy.PerTryTimeout)
+	if r.RetryPolicy.RetryOtherHosts {
+		rp.RetryHostPredicate = []*envoy_route_v3.RetryPolicy_RetryHostPredicate{{
+			Name: "envoy.retry_host_predicates.previous_hosts",
+		}}
+		// Give Envoy a few tries to find an endpoint that wasn't
+		// tried before, since endpoints are picked at random.
+		rp.HostSelectionRetryMaxAttempts = 3
+	}
 
 	return rp
 }
 
+// stripPrefixRewrite returns the regex rewrite that replaces the route's
+// prefix, and the slashes that follow it, with a single slash. Paths in
+// which the prefix is followed by anything but a slash don't match, and
+// are left unmodified. The prefix is matched case-insensitively if the
+// route ignores the case of the path. It returns nil if the route's
+// prefix is "/".
+func stripPrefixRewrite(r *dag.Route) *matcher.RegexMatchAndSubstitute {
+	cond, ok := r.PathMatchCondition.(*dag.PrefixMatchCondition)
+	if !ok {
+		return nil
+	}
+	prefix := strings.TrimRight(cond.Prefix, "/")
+	if prefix == "" {
+		return nil
+	}
+
+	regex := "^" + regexp.QuoteMeta(prefix) + "(/+|$)"
+	if r.IgnorePathCase {
+		regex = ignoreCaseRegexFlag + regex
+	}
+
+	return &matcher.RegexMatchAndSubstitute{
+		Pattern:      SafeRegexMatch(regex),
+		Substitution: "/",
+	}
+}
+
+// RouteMetadataNamespace is the filter metadata namespace
+// under which Contour records the metadata of routes.
+const RouteMetadataNamespace = "io.projectcontour"
+
+// RouteMetadata returns the metadata of a route, which records the
+// object it was built from and its labels, or nil if it has no labels.
+func RouteMetadata(r *dag.Route) *envoy_core_v3.Metadata {
+	if len(r.Labels) == 0 {
+		return nil
+	}
+	return routeMetadata(r, "")
+}
+
+// RouteSourceMetadata returns the metadata of a route, which records
+// the object it was built from, its labels if it has any, and the name
+// of its virtual host, so that they can be logged in access logs.
+func RouteSourceMetadata(r *dag.Route, vhost string) *envoy_core_v3.Metadata {
+	return routeMetadata(r, vhost)
+}
+
+func routeMetadata(r *dag.Route, vhost string) *envoy_core_v3.Metadata {
+	fields := map[string]*_struct.Value{
+		"kind":      sv(r.Source.Kind),
+		"namespace": sv(r.Source.Namespace),
+		"name":      sv(r.Source.Name),
+	}
+	if vhost != "" {
+		fields["vhost"] = sv(vhost)
+	}
+	if len(r.Labels) > 0 {
+		labels := &_struct.Struct{Fields: map[string]*_struct.Value{}}
+		for k, v := range r.Labels {
+			labels.Fields[k] = sv(v)
+		}
+		fields["labels"] = &_struct.Value{
+			Kind: &_struct.Value_StructValue{StructValue: labels},
+		}
+	}
+
+	return &envoy_core_v3.Metadata{
+		FilterMetadata: map[string]*_struct.Struct{
+			RouteMetadataNamespace: {Fields: fields},
+		},
+	}
+}
+
 // UpgradeHTTPS returns a route Action that redirects the request to HTTPS.
 func UpgradeHTTPS() *envoy_route_v3.Route_Redirect {
 	return &envoy_route_v3.Route_Redirect{
@@ -234,6 +372,22 @@ func UpgradeHTTPS() *envoy_route_v3.Route_Redirect {
 	}
 }
 
+// RedirectHTTPS returns a route Action that redirects the request to
+// the given externally visible scheme and port, for when a load balancer
+// in front of Envoy terminates TLS. An empty scheme redirects to HTTPS,
+// and a zero port leaves the port of the request's Host header as
+// UpgradeHTTPS does.
+func RedirectHTTPS(scheme string, port uint32) *envoy_route_v3.Route_Redirect {
+	redirect := UpgradeHTTPS()
+	if scheme != "" && scheme != "https" {
+		redirect.Redirect.SchemeRewriteSpecifier = &envoy_route_v3.RedirectAction_SchemeRedirect{
+			SchemeRedirect: scheme,
+		}
+	}
+	redirect.Redirect.PortRedirect = port
+	return redirect
+}
+
 // HeaderValueList creates a list of Envoy HeaderValueOptions from the provided map.
 func HeaderValueList(hvm map[string]string, app bool) []*envoy_core_v3.HeaderValueOption {
 	var hvs []*envoy_core_v3.HeaderValueOption
@@ -300,6 +454,21 @@ func VirtualHost(hostname string, routes ...*envoy_route_v3.Route) *envoy_route_
 	}
 }
 
+// VirtualHostStatsCluster returns a virtual cluster that matches every
+// request to a virtual host, so that Envoy emits request statistics
+// for the virtual host under vhost.<name>.vcluster.all.
+func VirtualHostStatsCluster() *envoy_route_v3.VirtualCluster {
+	return &envoy_route_v3.VirtualCluster{
+		Name: "all",
+		Headers: []*envoy_route_v3.HeaderMatcher{{
+			Name: ":path",
+			HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_PresentMatch{
+				PresentMatch: true,
+			},
+		}},
+	}
+}
+
 // CORSVirtualHost creates a new route.VirtualHost with a CORS policy.
 func CORSVirtualHost(hostname string, corspolicy *envoy_route_v3.CorsPolicy, routes ...*envoy_route_v3.Route) *envoy_route_v3.VirtualHost {
 	vh := VirtualHost(hostname, routes...)
@@ -315,6 +484,10 @@ func RouteConfiguration(name string, virtualhosts ...*envoy_route_v3.VirtualHost
 		RequestHeadersToAdd: Headers(
 			AppendHeader("x-request-start", "t=%START_TIME(%s.%3f)%"),
 		),
+		// Apply the headers of weighted clusters after those
+		// of their route, so that service level header policies
+		// take precedence over route level ones.
+		MostSpecificHeaderMutationsWins: true,
 	}
 }
 
@@ -375,9 +548,15 @@ func headerMatcher(headers []dag.HeaderMatchCondition) []*envoy_route_v3.HeaderM
 
 		switch h.MatchType {
 		case dag.HeaderMatchTypeExact:
-			header.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_ExactMatch{ExactMatch: h.Value}
+			if h.IgnoreCase {
+				header.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+					SafeRegexMatch: SafeRegexMatch(ignoreCaseRegexFlag + regexp.QuoteMeta(h.Value)),
+				}
+			} else {
+				header.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_ExactMatch{ExactMatch: h.Value}
+			}
 		case dag.HeaderMatchTypeContains:
-			header.HeaderMatchSpecifier = containsMatch(h.Value)
+			header.HeaderMatchSpecifier = containsMatch(h.Value, h.IgnoreCase)
 		case dag.HeaderMatchTypePresent:
 			header.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_PresentMatch{PresentMatch: true}
 		case dag.HeaderMatchTypeRegex:
@@ -391,12 +570,15 @@ func headerMatcher(headers []dag.HeaderMatchCondition) []*envoy_route_v3.HeaderM
 }
 
 // containsMatch returns a HeaderMatchSpecifier which will match the
-// supplied substring
-func containsMatch(s string) *envoy_route_v3.HeaderMatcher_SafeRegexMatch {
+// supplied substring, optionally without regard to case.
+func containsMatch(s string, ignoreCase bool) *envoy_route_v3.HeaderMatcher_SafeRegexMatch {
 	// convert the substring s into a regular expression that matches s.
 	// note that Envoy expects the expression to match the entire string, not just the substring
 	// formed from s. see [projectcontour/contour/#1751 & envoyproxy/envoy#8283]
 	regex := fmt.Sprintf(".*%s.*", regexp.QuoteMeta(s))
+	if ignoreCase {
+		regex = ignoreCaseRegexFlag + regex
+	}
 
 	return &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
 		SafeRegexMatch: SafeRegexMatch(regex),
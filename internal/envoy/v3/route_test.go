@@ -14,12 +14,14 @@
 package v3
 
 import (
+	"regexp"
 	"testing"
 	"time"
 
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	_struct "github.com/golang/protobuf/ptypes/struct"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/fixture"
@@ -296,6 +298,31 @@ func TestRouteRoute(t *testing.T) {
 				},
 			},
 		},
+		"retry other hosts": {
+			route: &dag.Route{
+				RetryPolicy: &dag.RetryPolicy{
+					RetryOn:         "reset,connect-failure",
+					NumRetries:      1,
+					RetryOtherHosts: true,
+				},
+				Clusters: []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RetryPolicy: &envoy_route_v3.RetryPolicy{
+						RetryOn:    "reset,connect-failure",
+						NumRetries: protobuf.UInt32(1),
+						RetryHostPredicate: []*envoy_route_v3.RetryPolicy_RetryHostPredicate{{
+							Name: "envoy.retry_host_predicates.previous_hosts",
+						}},
+						HostSelectionRetryMaxAttempts: 3,
+					},
+				},
+			},
+		},
 		"retriable status codes: 502, 503, 504": {
 			route: &dag.Route{
 				RetryPolicy: &dag.RetryPolicy{
@@ -575,6 +602,19 @@ func TestRouteDirectResponse(t *testing.T) {
 				},
 			},
 		},
+		"503 with body": {
+			directResponse: &dag.DirectResponse{StatusCode: 503, Body: "down for maintenance"},
+			want: &envoy_route_v3.Route_DirectResponse{
+				DirectResponse: &envoy_route_v3.DirectResponseAction{
+					Status: 503,
+					Body: &envoy_core_v3.DataSource{
+						Specifier: &envoy_core_v3.DataSource_InlineString{
+							InlineString: "down for maintenance",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -585,6 +625,42 @@ func TestRouteDirectResponse(t *testing.T) {
 	}
 }
 
+func TestRouteRedirect(t *testing.T) {
+	tests := map[string]struct {
+		redirect *dag.Redirect
+		want     *envoy_route_v3.Route_Redirect
+	}{
+		"hostname": {
+			redirect: &dag.Redirect{Hostname: "status.example.com", StatusCode: 302},
+			want: &envoy_route_v3.Route_Redirect{
+				Redirect: &envoy_route_v3.RedirectAction{
+					HostRedirect: "status.example.com",
+					ResponseCode: envoy_route_v3.RedirectAction_FOUND,
+				},
+			},
+		},
+		"hostname and path, permanent": {
+			redirect: &dag.Redirect{Hostname: "status.example.com", Path: "/maintenance", StatusCode: 301},
+			want: &envoy_route_v3.Route_Redirect{
+				Redirect: &envoy_route_v3.RedirectAction{
+					HostRedirect: "status.example.com",
+					PathRewriteSpecifier: &envoy_route_v3.RedirectAction_PathRedirect{
+						PathRedirect: "/maintenance",
+					},
+					ResponseCode: envoy_route_v3.RedirectAction_MOVED_PERMANENTLY,
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := RouteRedirect(tc.redirect)
+			protobuf.ExpectEqual(t, tc.want, got)
+		})
+	}
+}
+
 func TestWeightedClusters(t *testing.T) {
 	tests := map[string]struct {
 		clusters []*dag.Cluster
@@ -741,6 +817,7 @@ func TestRouteConfiguration(t *testing.T) {
 					},
 					Append: protobuf.Bool(true),
 				}},
+				MostSpecificHeaderMutationsWins: true,
 			},
 		},
 		"one virtualhost": {
@@ -760,6 +837,7 @@ func TestRouteConfiguration(t *testing.T) {
 					},
 					Append: protobuf.Bool(true),
 				}},
+				MostSpecificHeaderMutationsWins: true,
 			},
 		},
 	}
@@ -1017,6 +1095,137 @@ func TestUpgradeHTTPS(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestRedirectHTTPS(t *testing.T) {
+	protobuf.ExpectEqual(t, UpgradeHTTPS(), RedirectHTTPS("", 0))
+	protobuf.ExpectEqual(t, UpgradeHTTPS(), RedirectHTTPS("https", 0))
+
+	protobuf.ExpectEqual(t, &envoy_route_v3.Route_Redirect{
+		Redirect: &envoy_route_v3.RedirectAction{
+			SchemeRewriteSpecifier: &envoy_route_v3.RedirectAction_HttpsRedirect{
+				HttpsRedirect: true,
+			},
+			PortRedirect: 8443,
+		},
+	}, RedirectHTTPS("https", 8443))
+
+	protobuf.ExpectEqual(t, &envoy_route_v3.Route_Redirect{
+		Redirect: &envoy_route_v3.RedirectAction{
+			SchemeRewriteSpecifier: &envoy_route_v3.RedirectAction_SchemeRedirect{
+				SchemeRedirect: "http",
+			},
+			PortRedirect: 80,
+		},
+	}, RedirectHTTPS("http", 80))
+}
+
+func TestRouteMetadata(t *testing.T) {
+	source := dag.ObjectReference{Kind: "HTTPProxy", Namespace: "default", Name: "cart"}
+
+	assert.Nil(t, RouteMetadata(&dag.Route{Source: source}))
+
+	got := RouteMetadata(&dag.Route{
+		Source: source,
+		Labels: map[string]string{"team": "checkout"},
+	})
+	want := &envoy_core_v3.Metadata{
+		FilterMetadata: map[string]*_struct.Struct{
+			"io.projectcontour": {
+				Fields: map[string]*_struct.Value{
+					"kind":      sv("HTTPProxy"),
+					"namespace": sv("default"),
+					"name":      sv("cart"),
+					"labels": {
+						Kind: &_struct.Value_StructValue{
+							StructValue: &_struct.Struct{
+								Fields: map[string]*_struct.Value{"team": sv("checkout")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	protobuf.ExpectEqual(t, want, got)
+}
+
+func TestRouteSourceMetadata(t *testing.T) {
+	got := RouteSourceMetadata(&dag.Route{
+		Source: dag.ObjectReference{Kind: "Ingress", Namespace: "default", Name: "cart"},
+	}, "www.example.com")
+	want := &envoy_core_v3.Metadata{
+		FilterMetadata: map[string]*_struct.Struct{
+			"io.projectcontour": {
+				Fields: map[string]*_struct.Value{
+					"kind":      sv("Ingress"),
+					"namespace": sv("default"),
+					"name":      sv("cart"),
+					"vhost":     sv("www.example.com"),
+				},
+			},
+		},
+	}
+	protobuf.ExpectEqual(t, want, got)
+}
+
+func TestStripPrefixRewrite(t *testing.T) {
+	route := func(prefix string) *dag.Route {
+		return &dag.Route{
+			PathMatchCondition: &dag.PrefixMatchCondition{Prefix: prefix},
+			StripPrefix:        true,
+		}
+	}
+
+	assert.Nil(t, stripPrefixRewrite(route("/")))
+	assert.Nil(t, stripPrefixRewrite(&dag.Route{
+		PathMatchCondition: &dag.ExactMatchCondition{Path: "/foo"},
+		StripPrefix:        true,
+	}))
+
+	// The rewritten path of each request path, for routes with
+	// the prefix "/foo" and "/foo/". Both are equivalent.
+	tests := map[string]string{
+		"/foo":         "/",
+		"/foo/":        "/",
+		"/foo/v1":      "/v1",
+		"/foo//v1":     "/v1",
+		"/foo/v1/":     "/v1/",
+		"/foo/foo/v1":  "/foo/v1",
+		"/foosball":    "/foosball",
+		"/foo.bar/baz": "/foo.bar/baz",
+	}
+
+	for _, prefix := range []string{"/foo", "/foo/"} {
+		rewrite := stripPrefixRewrite(route(prefix))
+		assert.Equal(t, "^/foo(/+|$)", rewrite.Pattern.Regex)
+		assert.Equal(t, "/", rewrite.Substitution)
+
+		re := regexp.MustCompile(rewrite.Pattern.Regex)
+		for path, want := range tests {
+			assert.Equal(t, want, re.ReplaceAllString(path, rewrite.Substitution), path)
+		}
+	}
+
+	// Regex metacharacters in the prefix are matched literally.
+	assert.Equal(t, `^/v1\.0(/+|$)`, stripPrefixRewrite(route("/v1.0")).Pattern.Regex)
+
+	// The prefix of a route that ignores the path case is
+	// stripped whatever the case of the request path.
+	ignoreCase := route("/foo")
+	ignoreCase.IgnorePathCase = true
+	rewrite := stripPrefixRewrite(ignoreCase)
+	assert.Equal(t, "(?i)^/foo(/+|$)", rewrite.Pattern.Regex)
+
+	re := regexp.MustCompile(rewrite.Pattern.Regex)
+	for path, want := range map[string]string{
+		"/foo/v1":   "/v1",
+		"/FOO/v1":   "/v1",
+		"/Foo":      "/",
+		"/FOOsball": "/FOOsball",
+	} {
+		assert.Equal(t, want, re.ReplaceAllString(path, rewrite.Substitution), path)
+	}
+}
+
 func TestRouteMatch(t *testing.T) {
 	tests := map[string]struct {
 		route *dag.Route
@@ -1105,6 +1314,35 @@ func TestRouteMatch(t *testing.T) {
 				},
 			},
 		},
+		"path prefix ignore case": {
+			route: &dag.Route{
+				PathMatchCondition: &dag.PrefixMatchCondition{
+					Prefix:          "/foo",
+					PrefixMatchType: dag.PrefixMatchString,
+				},
+				IgnorePathCase: true,
+			},
+			want: &envoy_route_v3.RouteMatch{
+				PathSpecifier: &envoy_route_v3.RouteMatch_Prefix{
+					Prefix: "/foo",
+				},
+				CaseSensitive: protobuf.Bool(false),
+			},
+		},
+		"path prefix match segment ignore case": {
+			route: &dag.Route{
+				PathMatchCondition: &dag.PrefixMatchCondition{
+					Prefix:          "/foo",
+					PrefixMatchType: dag.PrefixMatchSegment,
+				},
+				IgnorePathCase: true,
+			},
+			want: &envoy_route_v3.RouteMatch{
+				PathSpecifier: &envoy_route_v3.RouteMatch_SafeRegex{
+					SafeRegex: SafeRegexMatch(`(?i)/foo((\/).*)?`),
+				},
+			},
+		},
 		"path prefix match segment with regex meta char": {
 			route: &dag.Route{
 				PathMatchCondition: &dag.PrefixMatchCondition{
@@ -1145,6 +1383,44 @@ func TestRouteMatch(t *testing.T) {
 				},
 			},
 		},
+		"header exact match ignore case": {
+			route: &dag.Route{
+				HeaderMatchConditions: []dag.HeaderMatchCondition{{
+					Name:       "x-header",
+					Value:      "a.b",
+					MatchType:  dag.HeaderMatchTypeExact,
+					IgnoreCase: true,
+				}},
+			},
+			want: &envoy_route_v3.RouteMatch{
+				Headers: []*envoy_route_v3.HeaderMatcher{{
+					Name: "x-header",
+					HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: SafeRegexMatch(`(?i)a\.b`),
+					},
+				}},
+			},
+		},
+		"header contains match ignore case": {
+			route: &dag.Route{
+				HeaderMatchConditions: []dag.HeaderMatchCondition{{
+					Name:       "x-header",
+					Value:      "abc",
+					MatchType:  dag.HeaderMatchTypeContains,
+					Invert:     true,
+					IgnoreCase: true,
+				}},
+			},
+			want: &envoy_route_v3.RouteMatch{
+				Headers: []*envoy_route_v3.HeaderMatcher{{
+					Name:        "x-header",
+					InvertMatch: true,
+					HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: SafeRegexMatch("(?i).*abc.*"),
+					},
+				}},
+			},
+		},
 		"header regex match": {
 			route: &dag.Route{
 				HeaderMatchConditions: []dag.HeaderMatchCondition{{
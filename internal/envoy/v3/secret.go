@@ -40,3 +40,39 @@ func Secret(s *dag.Secret) *envoy_tls_v3.Secret {
 		},
 	}
 }
+
+// OAuth2TokenSecretName returns the name of the SDS secret holding the
+// OAuth2 client secret of the given policy.
+func OAuth2TokenSecretName(p *dag.OIDCPolicy) string {
+	return envoy.Secretname(p.Credentials) + "/token"
+}
+
+// OAuth2HMACSecretName returns the name of the SDS secret holding the
+// HMAC secret of the given policy.
+func OAuth2HMACSecretName(p *dag.OIDCPolicy) string {
+	return envoy.Secretname(p.Credentials) + "/hmac"
+}
+
+// OAuth2Secrets creates the envoy_tls_v3.Secrets referenced by the
+// OAuth2 filter for the given policy.
+func OAuth2Secrets(p *dag.OIDCPolicy) []*envoy_tls_v3.Secret {
+	return []*envoy_tls_v3.Secret{
+		genericSecret(OAuth2TokenSecretName(p), p.ClientSecret()),
+		genericSecret(OAuth2HMACSecretName(p), p.HMACSecret()),
+	}
+}
+
+func genericSecret(name string, data []byte) *envoy_tls_v3.Secret {
+	return &envoy_tls_v3.Secret{
+		Name: name,
+		Type: &envoy_tls_v3.Secret_GenericSecret{
+			GenericSecret: &envoy_tls_v3.GenericSecret{
+				Secret: &envoy_core_v3.DataSource{
+					Specifier: &envoy_core_v3.DataSource_InlineBytes{
+						InlineBytes: data,
+					},
+				},
+			},
+		},
+	}
+}
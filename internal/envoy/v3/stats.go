@@ -80,3 +80,52 @@ func StatsListener(address string, port int) *envoy_listener_v3.Listener {
 		SocketOptions: TCPKeepaliveSocketOptions(),
 	}
 }
+
+// HealthListener returns a *envoy_listener_v3.Listener that answers requests
+// for the supplied path with Envoy's readiness status. It is intended to be
+// probed by external load balancers that cannot be pointed at /ready on the
+// stats listener.
+func HealthListener(address string, port int, path string) *envoy_listener_v3.Listener {
+	return &envoy_listener_v3.Listener{
+		Name:    "envoy-health",
+		Address: SocketAddress(address, port),
+		FilterChains: FilterChains(
+			&envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "envoy-health",
+						RouteSpecifier: &http.HttpConnectionManager_RouteConfig{
+							RouteConfig: &envoy_route_v3.RouteConfiguration{
+								VirtualHosts: []*envoy_route_v3.VirtualHost{{
+									Name:    "backend",
+									Domains: []string{"*"},
+									Routes: []*envoy_route_v3.Route{{
+										Match: &envoy_route_v3.RouteMatch{
+											PathSpecifier: &envoy_route_v3.RouteMatch_Path{
+												Path: path,
+											},
+										},
+										Action: &envoy_route_v3.Route_Route{
+											Route: &envoy_route_v3.RouteAction{
+												ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+													Cluster: "service-stats",
+												},
+												PrefixRewrite: "/ready",
+											},
+										},
+									}},
+								}},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: wellknown.Router,
+						}},
+						NormalizePath: protobuf.Bool(true),
+					}),
+				},
+			},
+		),
+		SocketOptions: TCPKeepaliveSocketOptions(),
+	}
+}
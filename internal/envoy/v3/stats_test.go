@@ -96,3 +96,50 @@ func TestStatsListener(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthListener(t *testing.T) {
+	got := HealthListener("0.0.0.0", 8090, "/healthz")
+	want := &envoy_listener_v3.Listener{
+		Name:    "envoy-health",
+		Address: SocketAddress("0.0.0.0", 8090),
+		FilterChains: FilterChains(
+			&envoy_listener_v3.Filter{
+				Name: wellknown.HTTPConnectionManager,
+				ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&http.HttpConnectionManager{
+						StatPrefix: "envoy-health",
+						RouteSpecifier: &http.HttpConnectionManager_RouteConfig{
+							RouteConfig: &envoy_route_v3.RouteConfiguration{
+								VirtualHosts: []*envoy_route_v3.VirtualHost{{
+									Name:    "backend",
+									Domains: []string{"*"},
+									Routes: []*envoy_route_v3.Route{{
+										Match: &envoy_route_v3.RouteMatch{
+											PathSpecifier: &envoy_route_v3.RouteMatch_Path{
+												Path: "/healthz",
+											},
+										},
+										Action: &envoy_route_v3.Route_Route{
+											Route: &envoy_route_v3.RouteAction{
+												ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+													Cluster: "service-stats",
+												},
+												PrefixRewrite: "/ready",
+											},
+										},
+									}},
+								}},
+							},
+						},
+						HttpFilters: []*http.HttpFilter{{
+							Name: wellknown.Router,
+						}},
+						NormalizePath: protobuf.Bool(true),
+					}),
+				},
+			},
+		),
+		SocketOptions: TCPKeepaliveSocketOptions(),
+	}
+	protobuf.ExpectEqual(t, want, got)
+}
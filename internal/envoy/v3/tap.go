@@ -0,0 +1,122 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"regexp"
+	"strings"
+
+	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/config/common/matcher/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_config_tap_v3 "github.com/envoyproxy/go-control-plane/envoy/config/tap/v3"
+	envoy_common_tap_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/tap/v3"
+	envoy_tap_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/tap/v3"
+	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/protobuf"
+)
+
+// FilterTap returns an `envoy.filters.http.tap` filter that captures
+// the requests and responses of the virtual hosts with a tap policy
+// to files named <pathPrefix>_<id>.json. Requests are matched on
+// their :authority header, and on the path prefix of the policy if
+// it has one. FilterTap returns nil if pathPrefix is empty or none
+// of the virtual hosts has a tap policy.
+func FilterTap(pathPrefix string, vhosts ...*dag.VirtualHost) *http.HttpFilter {
+	if pathPrefix == "" {
+		return nil
+	}
+
+	var rules []*envoy_matcher_v3.MatchPredicate
+	for _, vh := range vhosts {
+		if vh.TapPolicy != nil {
+			rules = append(rules, tapMatch(vh.Name, vh.TapPolicy))
+		}
+	}
+
+	var match *envoy_matcher_v3.MatchPredicate
+	switch len(rules) {
+	case 0:
+		return nil
+	case 1:
+		match = rules[0]
+	default:
+		// A match set needs at least two rules.
+		match = &envoy_matcher_v3.MatchPredicate{
+			Rule: &envoy_matcher_v3.MatchPredicate_OrMatch{
+				OrMatch: &envoy_matcher_v3.MatchPredicate_MatchSet{
+					Rules: rules,
+				},
+			},
+		}
+	}
+
+	return &http.HttpFilter{
+		Name: "envoy.filters.http.tap",
+		ConfigType: &http.HttpFilter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_tap_v3.Tap{
+				CommonConfig: &envoy_common_tap_v3.CommonExtensionConfig{
+					ConfigType: &envoy_common_tap_v3.CommonExtensionConfig_StaticConfig{
+						StaticConfig: &envoy_config_tap_v3.TapConfig{
+							Match: match,
+							OutputConfig: &envoy_config_tap_v3.OutputConfig{
+								Sinks: []*envoy_config_tap_v3.OutputSink{{
+									Format: envoy_config_tap_v3.OutputSink_JSON_BODY_AS_STRING,
+									OutputSinkType: &envoy_config_tap_v3.OutputSink_FilePerTap{
+										FilePerTap: &envoy_config_tap_v3.FilePerTapSink{
+											PathPrefix: pathPrefix,
+										},
+									},
+								}},
+							},
+						},
+					},
+				},
+			}),
+		},
+	}
+}
+
+// tapMatch returns a predicate matching the requests to hostname
+// that are selected by policy. The :authority header may include
+// a port, and a wildcard hostname matches a single DNS label.
+func tapMatch(hostname string, policy *dag.TapPolicy) *envoy_matcher_v3.MatchPredicate {
+	authority := regexp.QuoteMeta(hostname)
+	if strings.HasPrefix(hostname, "*.") {
+		authority = "[^.]+" + regexp.QuoteMeta(hostname[1:])
+	}
+
+	headers := []*envoy_route_v3.HeaderMatcher{{
+		Name: ":authority",
+		HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+			SafeRegexMatch: SafeRegexMatch(ignoreCaseRegexFlag + authority + "(:[0-9]+)?"),
+		},
+	}}
+	if policy.PathPrefix != "" {
+		headers = append(headers, &envoy_route_v3.HeaderMatcher{
+			Name: ":path",
+			HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_PrefixMatch{
+				PrefixMatch: policy.PathPrefix,
+			},
+		})
+	}
+
+	return &envoy_matcher_v3.MatchPredicate{
+		Rule: &envoy_matcher_v3.MatchPredicate_HttpRequestHeadersMatch{
+			HttpRequestHeadersMatch: &envoy_matcher_v3.HttpHeadersMatch{
+				Headers: headers,
+			},
+		},
+	}
+}
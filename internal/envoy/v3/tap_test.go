@@ -0,0 +1,139 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/config/common/matcher/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_config_tap_v3 "github.com/envoyproxy/go-control-plane/envoy/config/tap/v3"
+	envoy_common_tap_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/tap/v3"
+	envoy_tap_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/tap/v3"
+	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterTap(t *testing.T) {
+	tapped := &dag.VirtualHost{
+		Name:      "www.example.com",
+		TapPolicy: &dag.TapPolicy{PathPrefix: "/api"},
+	}
+	wildcard := &dag.VirtualHost{
+		Name:      "*.example.org",
+		TapPolicy: &dag.TapPolicy{},
+	}
+	untapped := &dag.VirtualHost{
+		Name: "untapped.example.com",
+	}
+
+	authority := func(regex string) *envoy_route_v3.HeaderMatcher {
+		return &envoy_route_v3.HeaderMatcher{
+			Name: ":authority",
+			HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+				SafeRegexMatch: SafeRegexMatch(regex),
+			},
+		}
+	}
+	headersMatch := func(headers ...*envoy_route_v3.HeaderMatcher) *envoy_matcher_v3.MatchPredicate {
+		return &envoy_matcher_v3.MatchPredicate{
+			Rule: &envoy_matcher_v3.MatchPredicate_HttpRequestHeadersMatch{
+				HttpRequestHeadersMatch: &envoy_matcher_v3.HttpHeadersMatch{
+					Headers: headers,
+				},
+			},
+		}
+	}
+	tappedMatch := headersMatch(
+		authority(`(?i)www\.example\.com(:[0-9]+)?`),
+		&envoy_route_v3.HeaderMatcher{
+			Name: ":path",
+			HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_PrefixMatch{
+				PrefixMatch: "/api",
+			},
+		},
+	)
+	wildcardMatch := headersMatch(authority(`(?i)[^.]+\.example\.org(:[0-9]+)?`))
+
+	tests := map[string]struct {
+		pathPrefix string
+		vhosts     []*dag.VirtualHost
+		want       *envoy_matcher_v3.MatchPredicate
+	}{
+		"no path prefix": {
+			vhosts: []*dag.VirtualHost{tapped},
+		},
+		"no tapped vhosts": {
+			pathPrefix: "/tmp/tap/capture",
+			vhosts:     []*dag.VirtualHost{untapped},
+		},
+		"one tapped vhost": {
+			pathPrefix: "/tmp/tap/capture",
+			vhosts:     []*dag.VirtualHost{untapped, tapped},
+			want:       tappedMatch,
+		},
+		"several tapped vhosts": {
+			pathPrefix: "/tmp/tap/capture",
+			vhosts:     []*dag.VirtualHost{tapped, untapped, wildcard},
+			want: &envoy_matcher_v3.MatchPredicate{
+				Rule: &envoy_matcher_v3.MatchPredicate_OrMatch{
+					OrMatch: &envoy_matcher_v3.MatchPredicate_MatchSet{
+						Rules: []*envoy_matcher_v3.MatchPredicate{tappedMatch, wildcardMatch},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := FilterTap(tc.pathPrefix, tc.vhosts...)
+			if tc.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+
+			want := &envoy_tap_v3.Tap{
+				CommonConfig: &envoy_common_tap_v3.CommonExtensionConfig{
+					ConfigType: &envoy_common_tap_v3.CommonExtensionConfig_StaticConfig{
+						StaticConfig: &envoy_config_tap_v3.TapConfig{
+							Match: tc.want,
+							OutputConfig: &envoy_config_tap_v3.OutputConfig{
+								Sinks: []*envoy_config_tap_v3.OutputSink{{
+									Format: envoy_config_tap_v3.OutputSink_JSON_BODY_AS_STRING,
+									OutputSinkType: &envoy_config_tap_v3.OutputSink_FilePerTap{
+										FilePerTap: &envoy_config_tap_v3.FilePerTapSink{
+											PathPrefix: tc.pathPrefix,
+										},
+									},
+								}},
+							},
+						},
+					},
+				},
+			}
+			require.NoError(t, want.Validate())
+
+			protobuf.ExpectEqual(t, &http.HttpFilter{
+				Name: "envoy.filters.http.tap",
+				ConfigType: &http.HttpFilter_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(want),
+				},
+			}, got)
+		})
+	}
+}
@@ -31,6 +31,8 @@ import (
 	"github.com/projectcontour/contour/internal/fixture"
 	"github.com/projectcontour/contour/internal/protobuf"
 	corev1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -136,7 +138,7 @@ func authzInvalidResponseTimeout(t *testing.T, rh cache.ResourceEventHandler, c
 	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
 		TypeUrl:   listenerType,
 		Resources: resources(t, staticListener()),
-	}).Status(p).HasError(contour_api_v1.ConditionTypeAuthError, "AuthResponseTimeoutInvalid", `Spec.Virtualhost.Authorization.ResponseTimeout is invalid: unable to parse timeout string "invalid-timeout": time: invalid duration "invalid-timeout"`)
+	}).Status(p).HasError(contour_api_v1.ConditionTypeAuthError, "AuthResponseTimeoutInvalid", `Spec.Virtualhost.Authorization.ResponseTimeout is invalid: invalid timeout "invalid-timeout": must be a duration such as "10s", or "infinity" to disable the timeout`)
 }
 
 func authzFailOpen(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
@@ -513,6 +515,140 @@ func authzInvalidReference(t *testing.T, rh cache.ResourceEventHandler, c *Conto
 	}).Status(invalid).IsValid()
 }
 
+func authzIngress(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	const fqdn = "ingress.projectcontour.io"
+
+	ingress := func(name, path string, annotations map[string]string) *networking_v1.Ingress {
+		annotations["ingress.kubernetes.io/force-ssl-redirect"] = "true"
+		return &networking_v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: networking_v1.IngressSpec{
+				TLS: []networking_v1.IngressTLS{{
+					Hosts:      []string{fqdn},
+					SecretName: "certificate",
+				}},
+				Rules: []networking_v1.IngressRule{{
+					Host: fqdn,
+					IngressRuleValue: networking_v1.IngressRuleValue{
+						HTTP: &networking_v1.HTTPIngressRuleValue{
+							Paths: []networking_v1.HTTPIngressPath{{
+								Path: path,
+								Backend: networking_v1.IngressBackend{
+									Service: &networking_v1.IngressServiceBackend{
+										Name: "app-server",
+										Port: networking_v1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	// The Ingress that attaches the ExtensionService sets the
+	// authorization of the virtual host, and each Ingress sets
+	// the authorization of its own routes.
+	rh.OnAdd(ingress("authorized", "/pay", map[string]string{
+		"projectcontour.io/auth-service":   "auth/extension",
+		"projectcontour.io/auth-fail-open": "true",
+		"projectcontour.io/auth-context":   "team=payments",
+	}))
+	rh.OnAdd(ingress("public", "/public", map[string]string{
+		"projectcontour.io/auth-disabled": "true",
+	}))
+
+	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			defaultHTTPListener(),
+			&envoy_listener_v3.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				FilterChains: []*envoy_listener_v3.FilterChain{
+					filterchaintls(fqdn,
+						&corev1.Secret{
+							ObjectMeta: fixture.ObjectMeta("certificate"),
+							Type:       "kubernetes.io/tls",
+							Data:       featuretests.Secretdata(featuretests.CERTIFICATE, featuretests.RSA_PRIVATE_KEY),
+						},
+						authzFilterFor(
+							fqdn,
+							&envoy_config_filter_http_ext_authz_v3.ExtAuthz{
+								Services:               grpcCluster("extension/auth/extension"),
+								ClearRouteCache:        true,
+								FailureModeAllow:       true,
+								IncludePeerCertificate: true,
+								StatusOnError: &envoy_type.HttpStatus{
+									Code: envoy_type.StatusCode_Forbidden,
+								},
+								TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+							},
+						),
+						nil, "h2", "http/1.1"),
+				},
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			},
+			staticListener()),
+	})
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: routeType,
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration(
+				path.Join("https", fqdn),
+				envoy_v3.VirtualHost(fqdn,
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/public"),
+						Action: routeCluster("default/app-server/80/da39a3ee5e"),
+						TypedPerFilterConfig: withFilterConfig("envoy.filters.http.ext_authz",
+							&envoy_config_filter_http_ext_authz_v3.ExtAuthzPerRoute{
+								Override: &envoy_config_filter_http_ext_authz_v3.ExtAuthzPerRoute_Disabled{
+									Disabled: true,
+								},
+							}),
+					},
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/pay"),
+						Action: routeCluster("default/app-server/80/da39a3ee5e"),
+						TypedPerFilterConfig: withFilterConfig("envoy.filters.http.ext_authz",
+							&envoy_config_filter_http_ext_authz_v3.ExtAuthzPerRoute{
+								Override: &envoy_config_filter_http_ext_authz_v3.ExtAuthzPerRoute_CheckSettings{
+									CheckSettings: &envoy_config_filter_http_ext_authz_v3.CheckSettings{
+										ContextExtensions: map[string]string{
+											"team": "payments",
+										},
+									},
+								},
+							}),
+					},
+				),
+			),
+			envoy_v3.RouteConfiguration(
+				"ingress_http",
+				envoy_v3.VirtualHost(fqdn,
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/public"),
+						Action: withRedirect(),
+					},
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/pay"),
+						Action: withRedirect(),
+					},
+				),
+			),
+		),
+	})
+}
+
 func TestAuthorization(t *testing.T) {
 	subtests := map[string]func(*testing.T, cache.ResourceEventHandler, *Contour){
 		"MissingExtension":       authzInvalidReference,
@@ -522,6 +658,7 @@ func TestAuthorization(t *testing.T) {
 		"FailOpen":               authzFailOpen,
 		"ResponseTimeout":        authzResponseTimeout,
 		"InvalidResponseTimeout": authzInvalidResponseTimeout,
+		"Ingress":                authzIngress,
 	}
 
 	for n, f := range subtests {
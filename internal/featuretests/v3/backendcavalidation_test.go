@@ -14,6 +14,7 @@
 package v3
 
 import (
+	"strings"
 	"testing"
 
 	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -183,4 +184,32 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 		TypeUrl:   secretType,
 	})
 
+	// During a CA rotation, the certificates of both CAs are trusted.
+	newSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bar",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			dag.CACertificateKey: []byte(featuretests.CERTIFICATE),
+		},
+	}
+	rh.OnAdd(newSecret)
+
+	hp2 := hp1.DeepCopy()
+	hp2.Spec.Routes[0].Services[0].UpstreamValidation.AdditionalCACertificates = []string{newSecret.Name}
+	rh.OnUpdate(hp1, hp2)
+
+	bundle := featuretests.CERTIFICATE
+	if !strings.HasSuffix(bundle, "\n") {
+		bundle += "\n"
+	}
+	bundle += featuretests.CERTIFICATE
+
+	c.Request(clusterType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			tlsCluster(cluster("default/kuard/443/3ec7a3496e", "default/kuard/securebackend", "default_kuard_443"), []byte(bundle), "subjname", "", nil),
+		),
+		TypeUrl: clusterType,
+	})
 }
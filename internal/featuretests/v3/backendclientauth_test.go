@@ -217,7 +217,7 @@ func TestBackendClientAuthenticationWithExtensionService(t *testing.T) {
 		TypeUrl: clusterType,
 		Resources: resources(t,
 			DefaultCluster(
-				h2cCluster(cluster("extension/default/ext", "extension/default/ext", "extension_default_ext")),
+				h2cCluster(extensionCluster("extension/default/ext", "extension_default_ext")),
 				&envoy_cluster_v3.Cluster{TransportSocket: tlsSocket},
 			),
 		),
@@ -489,7 +489,7 @@ func TestClusterPerServiceParameters(t *testing.T) {
 				}},
 			}, {
 				Conditions: []contour_api_v1.MatchCondition{{
-					Prefix: "/a",
+					Prefix: "/b",
 				}},
 				Services: []contour_api_v1.Service{{
 					Name:   "kuard",
@@ -29,6 +29,7 @@ import (
 	envoy_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_extensions_upstream_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
@@ -143,6 +144,16 @@ func cluster(name, servicename, statName string) *envoy_cluster_v3.Cluster {
 	})
 }
 
+// extensionCluster returns a cluster for the ExtensionService with
+// the given name, which uses locality weighted load balancing.
+func extensionCluster(name, statName string) *envoy_cluster_v3.Cluster {
+	c := cluster(name, name, statName)
+	c.CommonLbConfig.LocalityConfigSpecifier = &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+		LocalityWeightedLbConfig: &envoy_cluster_v3.Cluster_CommonLbConfig_LocalityWeightedLbConfig{},
+	}
+	return c
+}
+
 func tlsCluster(c *envoy_cluster_v3.Cluster, ca []byte, subjectName string, sni string, clientSecret *v1.Secret, alpnProtocols ...string) *envoy_cluster_v3.Cluster {
 	var secret *dag.Secret
 	if clientSecret != nil {
@@ -222,6 +233,14 @@ func withPrefixRewrite(route *envoy_route_v3.Route_Route, replacement string) *e
 	return route
 }
 
+func withStripPrefix(route *envoy_route_v3.Route_Route, prefix string) *envoy_route_v3.Route_Route {
+	route.Route.RegexRewrite = &matcher.RegexMatchAndSubstitute{
+		Pattern:      envoy_v3.SafeRegexMatch("^" + prefix + "(/+|$)"),
+		Substitution: "/",
+	}
+	return route
+}
+
 func withRetryPolicy(route *envoy_route_v3.Route_Route, retryOn string, numRetries uint32, perTryTimeout time.Duration) *envoy_route_v3.Route_Route {
 	route.Route.RetryPolicy = &envoy_route_v3.RetryPolicy{
 		RetryOn: retryOn,
@@ -15,19 +15,23 @@ package v3
 
 import (
 	"testing"
+	"time"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	envoy_v3_tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_extensions_upstream_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/golang/protobuf/ptypes/any"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	"github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/projectcontour/contour/internal/dag"
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/featuretests"
 	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/utils/pointer"
@@ -48,7 +52,7 @@ func extBasic(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
 		TypeUrl: clusterType,
 		Resources: resources(t,
 			DefaultCluster(
-				h2cCluster(cluster("extension/ns/ext", "extension/ns/ext", "extension_ns_ext")),
+				h2cCluster(extensionCluster("extension/ns/ext", "extension_ns_ext")),
 				&envoy_cluster_v3.Cluster{
 					TransportSocket: envoy_v3.UpstreamTLSTransportSocket(
 						&envoy_v3_tls.UpstreamTlsContext{
@@ -91,7 +95,7 @@ func extCleartext(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
 		TypeUrl: clusterType,
 		Resources: resources(t,
 			DefaultCluster(
-				h2cCluster(cluster("extension/ns/ext", "extension/ns/ext", "extension_ns_ext")),
+				h2cCluster(extensionCluster("extension/ns/ext", "extension_ns_ext")),
 			),
 		),
 	})
@@ -141,7 +145,7 @@ func extUpstreamValidation(t *testing.T, rh cache.ResourceEventHandler, c *Conto
 		TypeUrl: clusterType,
 		Resources: resources(t,
 			DefaultCluster(
-				h2cCluster(cluster("extension/ns/ext", "extension/ns/ext", "extension_ns_ext")),
+				h2cCluster(extensionCluster("extension/ns/ext", "extension_ns_ext")),
 				&envoy_cluster_v3.Cluster{TransportSocket: tlsSocket},
 			),
 		),
@@ -272,7 +276,7 @@ func extInvalidLoadBalancerPolicy(t *testing.T, rh cache.ResourceEventHandler, c
 			DefaultCluster(
 				// Default load balancer policy should be set as we were passed
 				// an invalid value, we can assert we get a basic cluster.
-				h2cCluster(cluster("extension/ns/ext", "extension/ns/ext", "extension_ns_ext")),
+				h2cCluster(extensionCluster("extension/ns/ext", "extension_ns_ext")),
 				&envoy_cluster_v3.Cluster{
 					TransportSocket: envoy_v3.UpstreamTLSTransportSocket(
 						&envoy_v3_tls.UpstreamTlsContext{
@@ -305,7 +309,7 @@ func extInvalidLoadBalancerPolicy(t *testing.T, rh cache.ResourceEventHandler, c
 			DefaultCluster(
 				// Default load balancer policy should be set as we were passed
 				// an invalid value, we can assert we get a basic cluster.
-				h2cCluster(cluster("extension/ns/ext", "extension/ns/ext", "extension_ns_ext")),
+				h2cCluster(extensionCluster("extension/ns/ext", "extension_ns_ext")),
 				&envoy_cluster_v3.Cluster{
 					TransportSocket: envoy_v3.UpstreamTLSTransportSocket(
 						&envoy_v3_tls.UpstreamTlsContext{
@@ -320,6 +324,117 @@ func extInvalidLoadBalancerPolicy(t *testing.T, rh cache.ResourceEventHandler, c
 	})
 }
 
+func extClusterPolicies(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	ext := &v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("ns/ext"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Protocol: pointer.StringPtr("h2c"),
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "svc1", Port: 8081},
+			},
+			TimeoutPolicy: &contour_api_v1.TimeoutPolicy{
+				Idle: "30s",
+			},
+			CircuitBreakerPolicy: &v1alpha1.CircuitBreakerPolicy{
+				MaxConnections: 100,
+				MaxRequests:    200,
+			},
+			HealthCheckPolicy: &v1alpha1.GRPCHealthCheckPolicy{
+				ServiceName:     "auth",
+				IntervalSeconds: 5,
+			},
+		},
+	}
+
+	rh.OnAdd(ext)
+
+	c.Request(clusterType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: clusterType,
+		Resources: resources(t,
+			DefaultCluster(
+				extensionCluster("extension/ns/ext", "extension_ns_ext"),
+				&envoy_cluster_v3.Cluster{
+					TypedExtensionProtocolOptions: map[string]*any.Any{
+						"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
+							&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+								CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+									IdleTimeout: protobuf.Duration(30 * time.Second),
+								},
+								UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+									ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+										ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{},
+									},
+								},
+							}),
+					},
+					CircuitBreakers: &envoy_cluster_v3.CircuitBreakers{
+						Thresholds: []*envoy_cluster_v3.CircuitBreakers_Thresholds{{
+							MaxConnections: protobuf.UInt32(100),
+							MaxRequests:    protobuf.UInt32(200),
+						}},
+					},
+					HealthChecks: []*envoy_core_v3.HealthCheck{{
+						Timeout:            protobuf.Duration(2 * time.Second),
+						Interval:           protobuf.Duration(5 * time.Second),
+						UnhealthyThreshold: protobuf.UInt32(3),
+						HealthyThreshold:   protobuf.UInt32(2),
+						HealthChecker: &envoy_core_v3.HealthCheck_GrpcHealthCheck_{
+							GrpcHealthCheck: &envoy_core_v3.HealthCheck_GrpcHealthCheck{
+								ServiceName: "auth",
+							},
+						},
+					}},
+					IgnoreHealthOnHostRemoval: true,
+				},
+			),
+		),
+	})
+
+	rh.OnUpdate(ext, &v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("ns/ext"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "svc1", Port: 8081},
+			},
+			HealthCheckPolicy: &v1alpha1.GRPCHealthCheckPolicy{
+				IntervalSeconds: -1,
+			},
+		},
+	})
+
+	// An invalid health check policy invalidates the extension service.
+	c.Request(clusterType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: clusterType,
+	})
+}
+
+func extPriorityFailover(t *testing.T, rh cache.ResourceEventHandler, c *Contour) {
+	rh.OnAdd(&v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("ns/ext"),
+		Spec: v1alpha1.ExtensionServiceSpec{
+			Services: []v1alpha1.ExtensionServiceTarget{
+				{Name: "svc1", Port: 8081, Priority: 10},
+				{Name: "svc2", Port: 8082, Priority: 20, Weight: 5},
+			},
+		},
+	})
+
+	primary := envoy_v3.WeightedEndpoints(1, envoy_v3.SocketAddress("192.168.183.20", 8081))[0]
+	secondary := envoy_v3.WeightedEndpoints(5, envoy_v3.SocketAddress("192.168.183.21", 8082))[0]
+	secondary.Priority = 1
+
+	c.Request(endpointType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: endpointType,
+		Resources: resources(t, &envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "extension/ns/ext",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{
+				primary,
+				secondary,
+			},
+		}),
+	})
+}
+
 func TestExtensionService(t *testing.T) {
 	subtests := map[string]func(*testing.T, cache.ResourceEventHandler, *Contour){
 		"Basic":                     extBasic,
@@ -330,6 +445,8 @@ func TestExtensionService(t *testing.T) {
 		"InconsistentProto":         extInconsistentProto,
 		"InvalidTimeout":            extInvalidTimeout,
 		"InvalidLoadBalancerPolicy": extInvalidLoadBalancerPolicy,
+		"ClusterPolicies":           extClusterPolicies,
+		"PriorityFailover":          extPriorityFailover,
 	}
 
 	for n, f := range subtests {
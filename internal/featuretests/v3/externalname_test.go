@@ -317,3 +317,56 @@ func TestExternalNameService(t *testing.T) {
 		),
 	})
 }
+
+// Assert that a HTTPProxy service can override the DNS lookup
+// family used to resolve an ExternalName service.
+func TestExternalNameServiceDNSLookupFamily(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	s1 := fixture.NewService("kuard").
+		WithSpec(v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}},
+			ExternalName: "foo.io",
+			Type:         v1.ServiceTypeExternalName,
+		})
+	rh.OnAdd(s1)
+
+	rh.OnAdd(fixture.NewProxy("kuard").
+		WithFQDN("kuard.projectcontour.io").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name:            s1.Name,
+					Port:            80,
+					DNSLookupFamily: "v6",
+				}},
+			}},
+		}),
+	)
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http",
+				envoy_v3.VirtualHost("kuard.projectcontour.io",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/kuard/80/39bcc1930b"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	})
+
+	cluster := externalNameCluster("default/kuard/80/39bcc1930b", "default/kuard", "default_kuard_80", "foo.io", 80)
+	cluster.DnsLookupFamily = envoy_cluster_v3.Cluster_V6_ONLY
+
+	c.Request(clusterType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t, cluster),
+		TypeUrl:   clusterType,
+	})
+}
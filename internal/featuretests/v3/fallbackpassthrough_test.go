@@ -0,0 +1,149 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	"github.com/projectcontour/contour/internal/fixture"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestFallbackPassthroughService(t *testing.T) {
+	rh, c, done := setup(t, func(eh *contour.EventHandler) {
+		eh.Builder.Source.ConfiguredServiceRefs = []*types.NamespacedName{{
+			Name:      "legacy",
+			Namespace: "admin",
+		}}
+		eh.Builder.Processors = []dag.Processor{
+			&dag.IngressProcessor{},
+			&dag.HTTPProxyProcessor{},
+			&dag.ListenerProcessor{
+				FieldLogger: fixture.NewTestLogger(t),
+				FallbackPassthroughService: &types.NamespacedName{
+					Name:      "legacy",
+					Namespace: "admin",
+				},
+				FallbackPassthroughPort: 443,
+			},
+		}
+	})
+	defer done()
+
+	rh.OnAdd(fixture.NewService("backend").
+		WithPorts(v1.ServicePort{Port: 443, TargetPort: intstr.FromInt(8443)}))
+
+	p1 := fixture.NewProxy("simple").
+		WithFQDN("kuard-tcp.example.com").
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "kuard-tcp.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				Services: []contour_api_v1.Service{{
+					Name: "backend",
+					Port: 443,
+				}},
+			},
+		})
+	rh.OnAdd(p1)
+
+	// The fallback service doesn't exist yet, so only the SNI filter chain is present.
+	c.Request(listenerType, "ingress_https").Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			&envoy_listener_v3.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					Filters: envoy_v3.Filters(
+						tcpproxy("ingress_https", "default/backend/443/da39a3ee5e"),
+					),
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"kuard-tcp.example.com"},
+					},
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			},
+		),
+		TypeUrl: listenerType,
+	})
+
+	legacy := fixture.NewService("admin/legacy").
+		WithPorts(v1.ServicePort{Port: 443, TargetPort: intstr.FromInt(9443)})
+	rh.OnAdd(legacy)
+
+	// Connections without a server name are passed through to the fallback service.
+	c.Request(listenerType, "ingress_https").Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			&envoy_listener_v3.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					Filters: envoy_v3.Filters(
+						tcpproxy("ingress_https", "default/backend/443/da39a3ee5e"),
+					),
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"kuard-tcp.example.com"},
+					},
+				}, {
+					Name: "fallback-passthrough",
+					Filters: envoy_v3.Filters(
+						envoy_v3.NoServerNameRBAC("fallback-passthrough"),
+						tcpproxy("ingress_https", "admin/legacy/443/da39a3ee5e"),
+					),
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						TransportProtocol: "tls",
+					},
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			},
+		),
+		TypeUrl: listenerType,
+	})
+
+	c.Request(clusterType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			cluster("admin/legacy/443/da39a3ee5e", "admin/legacy", "admin_legacy_443"),
+			cluster("default/backend/443/da39a3ee5e", "default/backend", "default_backend_443"),
+		),
+		TypeUrl: clusterType,
+	})
+
+	// Without any secure virtual hosts there is no secure listener to fall back on.
+	rh.OnDelete(p1)
+
+	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			staticListener(),
+		),
+		TypeUrl: listenerType,
+	})
+}
@@ -121,12 +121,12 @@ func setup(t *testing.T, opts ...interface{}) (cache.ResourceEventHandler, *Cont
 	}
 
 	eh.Builder.Processors = []dag.Processor{
-		&dag.IngressProcessor{
-			FieldLogger: log.WithField("context", "IngressProcessor"),
-		},
 		&dag.ExtensionServiceProcessor{
 			FieldLogger: log.WithField("context", "ExtensionServiceProcessor"),
 		},
+		&dag.IngressProcessor{
+			FieldLogger: log.WithField("context", "IngressProcessor"),
+		},
 		&dag.HTTPProxyProcessor{},
 		&dag.GatewayAPIProcessor{
 			FieldLogger: log.WithField("context", "GatewayAPIProcessor"),
@@ -146,8 +146,8 @@ func setup(t *testing.T, opts ...interface{}) (cache.ResourceEventHandler, *Cont
 	l, err := net.Listen("tcp", "127.0.0.1:0")
 	require.NoError(t, err)
 
-	srv := xds.NewServer(registry)
-	contour_xds_v3.RegisterServer(contour_xds_v3.NewContourServer(log, xdscache.ResourcesOf(resources)...), srv)
+	srv := xds.NewServer(registry, nil, nil)
+	contour_xds_v3.RegisterServer(contour_xds_v3.NewContourServer(log, nil, xdscache.ResourcesOf(resources)...), srv)
 
 	var g workgroup.Group
 
@@ -0,0 +1,190 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	"github.com/projectcontour/contour/internal/fixture"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestConditions_NotPrefix_HTTPProxy(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("svc1").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	rh.OnAdd(fixture.NewService("svc2").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+	)
+
+	notFound := envoy_v3.RouteDirectResponse(&dag.DirectResponse{StatusCode: 404})
+
+	// Requests under /admin are excluded from the route, so
+	// they are answered by a more specific route.
+	proxy := fixture.NewProxy("simple").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "hello.world"},
+			Routes: []contour_api_v1.Route{{
+				Conditions: []contour_api_v1.MatchCondition{{
+					NotPrefix: "/admin",
+				}},
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+			}},
+		})
+	rh.OnAdd(proxy)
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http",
+				envoy_v3.VirtualHost("hello.world",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/admin"),
+						Action: notFound,
+					},
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/svc1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy).IsValid()
+
+	// A route that matches the excluded requests takes precedence.
+	proxy = update(rh, proxy, func(proxy *contour_api_v1.HTTPProxy) {
+		proxy.Spec.Routes = append(proxy.Spec.Routes, contour_api_v1.Route{
+			Conditions: matchconditions(prefixMatchCondition("/admin")),
+			Services: []contour_api_v1.Service{{
+				Name: "svc2",
+				Port: 80,
+			}},
+		})
+	})
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http",
+				envoy_v3.VirtualHost("hello.world",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/admin"),
+						Action: routeCluster("default/svc2/80/da39a3ee5e"),
+					},
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/svc1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy).IsValid()
+
+	// Exclusions are relative to the prefix of the route,
+	// and keep its header conditions.
+	proxy = update(rh, proxy, func(proxy *contour_api_v1.HTTPProxy) {
+		proxy.Spec.Routes = []contour_api_v1.Route{{
+			Conditions: append(
+				matchconditions(
+					prefixMatchCondition("/api/"),
+					headerExactMatchCondition("x-tenant", "a"),
+				),
+				contour_api_v1.MatchCondition{NotPrefix: "/internal"},
+			),
+			Services: []contour_api_v1.Service{{
+				Name: "svc1",
+				Port: 80,
+			}},
+		}}
+	})
+
+	tenant := dag.HeaderMatchCondition{
+		Name:      "x-tenant",
+		Value:     "a",
+		MatchType: "exact",
+	}
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http",
+				envoy_v3.VirtualHost("hello.world",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/api/internal", tenant),
+						Action: notFound,
+					},
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/api/", tenant),
+						Action: routeCluster("default/svc1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy).IsValid()
+
+	// Excluding all requests is invalid.
+	proxy = update(rh, proxy, func(proxy *contour_api_v1.HTTPProxy) {
+		proxy.Spec.Routes[0].Conditions = []contour_api_v1.MatchCondition{{
+			NotPrefix: "/",
+		}}
+	})
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy).HasError(contour_api_v1.ConditionTypeRouteError, "PathMatchConditionsNotValid", "route: notPrefix condition / excludes all requests")
+
+	// Exclusions aren't supported on includes.
+	rh.OnAdd(fixture.NewProxy("child").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "svc1",
+					Port: 80,
+				}},
+			}},
+		}))
+
+	proxy = update(rh, proxy, func(proxy *contour_api_v1.HTTPProxy) {
+		proxy.Spec.Routes = nil
+		proxy.Spec.Includes = []contour_api_v1.Include{{
+			Name: "child",
+			Conditions: []contour_api_v1.MatchCondition{{
+				NotPrefix: "/admin",
+			}},
+		}}
+	})
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(proxy).HasError(contour_api_v1.ConditionTypeIncludeError, "PathMatchConditionsNotValid", "include: notPrefix conditions are only supported on routes")
+}
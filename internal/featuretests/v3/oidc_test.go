@@ -0,0 +1,152 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"path"
+	"testing"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	"github.com/projectcontour/contour/internal/featuretests"
+	"github.com/projectcontour/contour/internal/fixture"
+	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestOIDCPolicy(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	const fqdn = "oidc.projectcontour.io"
+
+	cert := &corev1.Secret{
+		ObjectMeta: fixture.ObjectMeta("certificate"),
+		Type:       "kubernetes.io/tls",
+		Data:       featuretests.Secretdata(featuretests.CERTIFICATE, featuretests.RSA_PRIVATE_KEY),
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: fixture.ObjectMeta("oidc-credentials"),
+		Type:       corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			dag.OIDCClientIDKey:     []byte("contour"),
+			dag.OIDCClientSecretKey: []byte("s3cr3t"),
+		},
+	}
+
+	rh.OnAdd(cert)
+	rh.OnAdd(credentials)
+	rh.OnAdd(fixture.NewService("app-server").
+		WithPorts(corev1.ServicePort{Port: 80}))
+
+	p := fixture.NewProxy("proxy").
+		WithFQDN(fqdn).
+		WithCertificate("certificate").
+		WithOIDCPolicy(contour_api_v1.OIDCPolicy{
+			AuthorizationEndpoint:   "https://login.example.com/authorize",
+			TokenEndpoint:           "https://login.example.com/token",
+			Scopes:                  []string{"email", "openid"},
+			ClientCredentialsSecret: "oidc-credentials",
+		}).
+		WithSpec(contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{Name: "app-server", Port: 80}},
+			}},
+		})
+
+	rh.OnAdd(p)
+
+	policy := &dag.OIDCPolicy{
+		AuthorizationEndpoint: "https://login.example.com/authorize",
+		TokenEndpoint:         "https://login.example.com/token",
+		TokenCluster: &dag.DNSNameCluster{
+			Address: "login.example.com",
+			Scheme:  "https",
+			Port:    443,
+		},
+		Scopes:       []string{"openid", "email"},
+		ClientID:     "contour",
+		Credentials:  &dag.Secret{Object: credentials},
+		RedirectPath: "/oauth2/callback",
+		SignoutPath:  "/oauth2/signout",
+	}
+
+	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: listenerType,
+		Resources: resources(t,
+			defaultHTTPListener(),
+			&envoy_listener_v3.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				FilterChains: []*envoy_listener_v3.FilterChain{
+					filterchaintls(fqdn, cert,
+						envoy_v3.HTTPConnectionManagerBuilder().
+							AddFilter(envoy_v3.FilterMisdirectedRequests(fqdn)).
+							DefaultFilters().
+							AddFilter(envoy_v3.FilterOAuth2(fqdn, policy)).
+							RouteConfigName(path.Join("https", fqdn)).
+							MetricsPrefix(xdscache_v3.ENVOY_HTTPS_LISTENER).
+							AccessLoggers(envoy_v3.FileAccessLogEnvoy("/dev/stdout")).
+							Get(),
+						nil, "h2", "http/1.1"),
+				},
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			},
+			staticListener()),
+	}).Status(p).IsValid()
+
+	c.Request(clusterType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: clusterType,
+		Resources: resources(t,
+			cluster("default/app-server/80/da39a3ee5e", "default/app-server", "default_app-server_80"),
+			envoy_v3.DNSNameCluster(policy.TokenCluster),
+		),
+	})
+
+	c.Request(secretType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: secretType,
+		Resources: resources(t,
+			envoy_v3.Secret(&dag.Secret{Object: cert}),
+			envoy_v3.OAuth2Secrets(policy)[1],
+			envoy_v3.OAuth2Secrets(policy)[0],
+		),
+	})
+
+	// Removing the credentials invalidates the proxy.
+	rh.OnDelete(credentials)
+
+	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl:   listenerType,
+		Resources: resources(t, staticListener()),
+	}).Status(p).HasError(contour_api_v1.ConditionTypeAuthError, "OIDCPolicyNotValid",
+		`Spec.VirtualHost.OIDCPolicy is invalid: client credentials Secret "default/oidc-credentials" is invalid: Secret not found`)
+
+	// The token endpoint isn't guessed from the authorization endpoint.
+	rh.OnAdd(credentials)
+	p2 := p.DeepCopy()
+	p2.Spec.VirtualHost.OIDCPolicy.TokenEndpoint = ""
+	rh.OnUpdate(p, p2)
+
+	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl:   listenerType,
+		Resources: resources(t, staticListener()),
+	}).Status(p2).HasError(contour_api_v1.ConditionTypeAuthError, "OIDCPolicyNotValid",
+		`Spec.VirtualHost.OIDCPolicy is invalid: token endpoint "": must be an absolute https URL`)
+}
@@ -0,0 +1,106 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"testing"
+
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/golang/protobuf/ptypes/any"
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/dag"
+	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
+	"github.com/projectcontour/contour/internal/fixture"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRBACPolicy(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("s1").WithPorts(corev1.ServicePort{Port: 80}))
+
+	p := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "proxy1",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "foo.com",
+			},
+			Routes: []contour_api_v1.Route{{
+				Conditions: matchconditions(prefixMatchCondition("/admin")),
+				Services: []contour_api_v1.Service{{
+					Name: "s1",
+					Port: 80,
+				}},
+				RBACPolicy: &contour_api_v1.RBACPolicy{
+					Methods: []string{"GET"},
+					Headers: []contour_api_v1.HeaderValue{{Name: "x-internal", Value: "true"}},
+				},
+			}, {
+				Services: []contour_api_v1.Service{{
+					Name: "s1",
+					Port: 80,
+				}},
+			}},
+		},
+	}
+	rh.OnAdd(p)
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: routeType,
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http",
+				envoy_v3.VirtualHost("foo.com",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/admin"),
+						Action: routeCluster("default/s1/80/da39a3ee5e"),
+						TypedPerFilterConfig: map[string]*any.Any{
+							"envoy.filters.http.rbac": envoy_v3.RouteRBAC(&dag.RBACPolicy{
+								Methods: []string{"GET"},
+								Headers: []dag.HeaderMatchCondition{{
+									Name:      "X-Internal",
+									Value:     "true",
+									MatchType: dag.HeaderMatchTypeExact,
+								}},
+							}),
+						},
+					},
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/"),
+						Action: routeCluster("default/s1/80/da39a3ee5e"),
+					},
+				),
+			),
+		),
+	}).Status(p).IsValid()
+
+	// An empty policy is rejected, rather than allowing
+	// or denying every request.
+	p2 := p.DeepCopy()
+	p2.Spec.Routes[0].RBACPolicy = &contour_api_v1.RBACPolicy{}
+	rh.OnUpdate(p, p2)
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		TypeUrl: routeType,
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http"),
+		),
+	}).Status(p2).HasError(contour_api_v1.ConditionTypeRouteError, "RBACPolicyNotValid",
+		"route.rbacPolicy is invalid: at least one method or header must be specified")
+}
@@ -526,6 +526,75 @@ func artifactoryDocker(t *testing.T) {
 	})
 }
 
+func stripPrefix(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(fixture.NewService("kuard").
+		WithPorts(v1.ServicePort{Port: 8080, TargetPort: intstr.FromInt(8080)}))
+
+	app := fixture.NewProxy("app").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			Routes: []contour_api_v1.Route{{
+				Services: []contour_api_v1.Service{{
+					Name: "kuard",
+					Port: 8080,
+				}},
+				PathRewritePolicy: &contour_api_v1.PathRewritePolicy{
+					StripPrefix: true,
+				},
+			}},
+		})
+
+	root := fixture.NewProxy("root").WithSpec(
+		contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "kuard.projectcontour.io",
+			},
+			Includes: []contour_api_v1.Include{{
+				Name:       app.Name,
+				Namespace:  app.Namespace,
+				Conditions: matchconditions(prefixMatchCondition("/api/")),
+			}},
+		})
+
+	rh.OnAdd(app)
+	rh.OnAdd(root)
+
+	// Stripping the prefix doesn't need a separate route for each
+	// form of the prefix, since the rewrite removes any slashes
+	// that follow it.
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http",
+				envoy_v3.VirtualHost("kuard.projectcontour.io",
+					&envoy_route_v3.Route{
+						Match:  routePrefix("/api/"),
+						Action: withStripPrefix(routeCluster("default/kuard/8080/da39a3ee5e"), "/api"),
+					},
+				),
+			),
+		),
+		TypeUrl: routeType,
+	}).Status(app).IsValid()
+
+	// Prefix replacements can't be combined with stripping the prefix.
+	app = update(rh, app,
+		func(app *contour_api_v1.HTTPProxy) {
+			app.Spec.Routes[0].PathRewritePolicy.ReplacePrefix =
+				[]contour_api_v1.ReplacePrefix{
+					{Replacement: "/v1"},
+				}
+		})
+
+	c.Request(routeType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			envoy_v3.RouteConfiguration("ingress_http"),
+		),
+		TypeUrl: routeType,
+	}).Status(app).HasError(contour_api_v1.ConditionTypePrefixReplaceError, "AmbiguousReplacement", "cannot specify both prefix replacements and strip prefix")
+}
+
 func TestHTTPProxyPathPrefix(t *testing.T) {
 	subtests := []struct {
 		Name string
@@ -535,6 +604,7 @@ func TestHTTPProxyPathPrefix(t *testing.T) {
 		{Name: "MultiInclude", Func: multiInclude},
 		{Name: "ReplaceWithSlash", Func: replaceWithSlash},
 		{Name: "ArtifactoryDocker", Func: artifactoryDocker},
+		{Name: "StripPrefix", Func: stripPrefix},
 	}
 
 	for _, s := range subtests {
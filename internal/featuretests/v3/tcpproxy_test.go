@@ -15,16 +15,24 @@ package v3
 
 import (
 	"testing"
+	"time"
 
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoy_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/featuretests"
 	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/projectcontour/contour/internal/protobuf"
+	xdscache_v3 "github.com/projectcontour/contour/internal/xdscache/v3"
+	"github.com/projectcontour/contour/pkg/config"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -910,3 +918,146 @@ func TestTCPProxyInvalidLoadBalancerPolicy(t *testing.T) {
 		TypeUrl: clusterType,
 	})
 }
+
+func TestTCPProxyAllowedSourceRanges(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	svc := fixture.NewService("backend").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)})
+
+	rh.OnAdd(svc)
+
+	hp1 := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: svc.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "kuard-tcp.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				Services: []contour_api_v1.Service{{
+					Name: svc.Name,
+					Port: 80,
+				}},
+				AllowedSourceRanges: []string{"10.0.0.0/8", "2001:db8::/32"},
+			},
+		},
+	}
+	rh.OnAdd(hp1)
+
+	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			&envoy_listener_v3.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					Filters: envoy_v3.Filters(
+						tcpproxy("ingress_https", "default/backend/80/da39a3ee5e"),
+					),
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"kuard-tcp.example.com"},
+						SourcePrefixRanges: []*envoy_core_v3.CidrRange{{
+							AddressPrefix: "10.0.0.0",
+							PrefixLen:     protobuf.UInt32(8),
+						}, {
+							AddressPrefix: "2001:db8::",
+							PrefixLen:     protobuf.UInt32(32),
+						}},
+					},
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			},
+			staticListener(),
+		),
+		TypeUrl: listenerType,
+	}).Status(hp1).IsValid()
+
+	hp2 := hp1.DeepCopy()
+	hp2.Spec.TCPProxy.AllowedSourceRanges = []string{"10.0.0.0/33"}
+	rh.OnUpdate(hp1, hp2)
+
+	c.Request(listenerType).Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			staticListener(),
+		),
+		TypeUrl: listenerType,
+	}).Status(hp2).HasError(contour_api_v1.ConditionTypeTCPProxyError, "InvalidSourceRange",
+		`Spec.TCPProxy.AllowedSourceRanges: invalid CIDR "10.0.0.0/33"`)
+}
+
+func TestTCPProxyAccessLog(t *testing.T) {
+	rh, c, done := setup(t, func(conf *xdscache_v3.ListenerConfig) {
+		conf.TCPProxyAccessLog = &xdscache_v3.TCPProxyAccessLogConfig{
+			Type:             config.GRPCTCPProxyAccessLog,
+			ExtensionService: types.NamespacedName{Namespace: "projectcontour", Name: "als"},
+		}
+	})
+	defer done()
+
+	svc := fixture.NewService("backend").
+		WithPorts(v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)})
+
+	rh.OnAdd(svc)
+
+	rh.OnAdd(&contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "simple",
+			Namespace: svc.Namespace,
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{
+				Fqdn: "kuard-tcp.example.com",
+				TLS: &contour_api_v1.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &contour_api_v1.TCPProxy{
+				Services: []contour_api_v1.Service{{
+					Name: svc.Name,
+					Port: 80,
+				}},
+			},
+		},
+	})
+
+	c.Request(listenerType, "ingress_https").Equals(&envoy_discovery_v3.DiscoveryResponse{
+		Resources: resources(t,
+			&envoy_listener_v3.Listener{
+				Name:    "ingress_https",
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					Filters: envoy_v3.Filters(&envoy_listener_v3.Filter{
+						Name: wellknown.TCPProxy,
+						ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+							TypedConfig: protobuf.MustMarshalAny(&envoy_tcp_proxy_v3.TcpProxy{
+								StatPrefix: "ingress_https",
+								ClusterSpecifier: &envoy_tcp_proxy_v3.TcpProxy_Cluster{
+									Cluster: "default/backend/80/da39a3ee5e",
+								},
+								AccessLog:   envoy_v3.GRPCAccessLogTCPProxy(types.NamespacedName{Namespace: "projectcontour", Name: "als"}, "contour-tcpproxy"),
+								IdleTimeout: protobuf.Duration(9001 * time.Second),
+							}),
+						},
+					}),
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"kuard-tcp.example.com"},
+					},
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			},
+		),
+		TypeUrl: listenerType,
+	})
+}
@@ -81,6 +81,16 @@ func (dcb *DetailedConditionBuilder) WithWarning(errorType, reason, message stri
 
 }
 
+// ValidWithWarning returns a valid condition that carries
+// the given warning, as warnings don't invalidate the object.
+func (dcb *DetailedConditionBuilder) ValidWithWarning(warnType, reason, message string) v1.DetailedCondition {
+
+	dcb.Valid()
+
+	return dcb.WithWarning(warnType, reason, message)
+
+}
+
 func (dcb *DetailedConditionBuilder) WithWarningf(warnType, reason, formatmsg string, args ...interface{}) v1.DetailedCondition {
 
 	dc := (*v1.DetailedCondition)(dcb)
@@ -89,3 +89,9 @@ func (b *ProxyBuilder) WithAuthServer(auth contour_api_v1.AuthorizationServer) *
 	b.Spec.VirtualHost.Authorization = &auth
 	return b
 }
+
+func (b *ProxyBuilder) WithOIDCPolicy(policy contour_api_v1.OIDCPolicy) *ProxyBuilder {
+	b.ensureTLS()
+	b.Spec.VirtualHost.OIDCPolicy = &policy
+	return b
+}
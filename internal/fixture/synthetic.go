@@ -0,0 +1,103 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fixture
+
+import (
+	"fmt"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// SyntheticNamespace returns the objects of a synthetic namespace
+// holding the given number of root HTTPProxies. Each HTTPProxy has
+// its own Service and TLS Secret, and routes to the Service with a
+// prefix condition and a request header policy, so that building
+// the DAG for these objects exercises the common HTTPProxy paths.
+func SyntheticNamespace(namespace string, proxies int) []interface{} {
+	objs := []interface{}{
+		&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace},
+		},
+	}
+
+	for i := 0; i < proxies; i++ {
+		name := fmt.Sprintf("proxy-%d", i)
+
+		objs = append(objs,
+			&v1.Service{
+				ObjectMeta: ObjectMeta(namespace + "/" + name),
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{
+						Name:       "http",
+						Protocol:   v1.ProtocolTCP,
+						Port:       80,
+						TargetPort: intstr.FromInt(8080),
+					}},
+				},
+			},
+			&v1.Secret{
+				ObjectMeta: ObjectMeta(namespace + "/" + name),
+				Type:       v1.SecretTypeTLS,
+				Data: map[string][]byte{
+					v1.TLSCertKey:       []byte(CERTIFICATE),
+					v1.TLSPrivateKeyKey: []byte(RSA_PRIVATE_KEY),
+				},
+			},
+			&contour_api_v1.HTTPProxy{
+				ObjectMeta: ObjectMeta(namespace + "/" + name),
+				Spec: contour_api_v1.HTTPProxySpec{
+					VirtualHost: &contour_api_v1.VirtualHost{
+						Fqdn: fmt.Sprintf("%s.%s.example.com", name, namespace),
+						TLS: &contour_api_v1.TLS{
+							SecretName: name,
+						},
+					},
+					Routes: []contour_api_v1.Route{{
+						Conditions: []contour_api_v1.MatchCondition{{
+							Prefix: "/",
+						}},
+						RequestHeadersPolicy: &contour_api_v1.HeadersPolicy{
+							Set: []contour_api_v1.HeaderValue{{
+								Name:  "X-Synthetic",
+								Value: name,
+							}},
+						},
+						Services: []contour_api_v1.Service{{
+							Name: name,
+							Port: 80,
+						}},
+					}},
+				},
+			},
+		)
+	}
+
+	return objs
+}
+
+// SyntheticObjects returns the objects of the given number of
+// synthetic namespaces, each holding the given number of root
+// HTTPProxies. See SyntheticNamespace.
+func SyntheticObjects(namespaces, proxies int) []interface{} {
+	var objs []interface{}
+
+	for i := 0; i < namespaces; i++ {
+		objs = append(objs, SyntheticNamespace(fmt.Sprintf("synthetic-%d", i), proxies)...)
+	}
+
+	return objs
+}
@@ -15,23 +15,159 @@
 package health
 
 import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
 )
 
-// Handler returns a http Handler for a health endpoint.
-func Handler(client *kubernetes.Clientset) http.Handler {
+// Check checks a dependency of Contour.
+type Check struct {
+	// Name identifies the dependency in the health report.
+	Name string
+
+	// Critical checks fail the health endpoint when they fail.
+	// Other checks are only reported.
+	Critical bool
+
+	// Run checks the dependency, and returns a summary of its
+	// status, or an error if it is unhealthy.
+	Run func() (string, error)
+}
+
+// Handler returns a http Handler for a health endpoint, which fails
+// if any critical check fails. If the verbose query parameter is
+// set, the result of each check is written, one per line.
+func Handler(checks ...Check) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Try and lookup Kubernetes server version as a quick and dirty check
-		_, err := client.ServerVersion()
-		if err != nil {
-			msg := fmt.Sprintf("Failed Kubernetes Check: %v", err)
+		var failed error
+		var report strings.Builder
+		for _, check := range checks {
+			summary, err := check.Run()
+			if err != nil {
+				fmt.Fprintf(&report, "[-]%s failed: %v\n", check.Name, err)
+				if check.Critical && failed == nil {
+					failed = fmt.Errorf("Failed %s check: %v", check.Name, err)
+				}
+				continue
+			}
+			fmt.Fprintf(&report, "[+]%s ok: %s\n", check.Name, summary)
+		}
+
+		_, verbose := r.URL.Query()["verbose"]
+		if failed != nil {
+			msg := failed.Error()
+			if verbose {
+				msg = report.String() + msg
+			}
 			http.Error(w, msg, http.StatusServiceUnavailable)
 			return
 		}
+
 		w.WriteHeader(http.StatusOK)
+		if verbose {
+			fmt.Fprint(w, report.String())
+		}
 		fmt.Fprintln(w, "OK")
 	})
 }
+
+// Poll returns a function that runs checks every interval until
+// stop is closed, so that the metrics they record stay current
+// whether or not the health endpoint is requested.
+func Poll(interval time.Duration, checks ...Check) func(<-chan struct{}) error {
+	return func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			for _, check := range checks {
+				_, _ = check.Run()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return nil
+			}
+		}
+	}
+}
+
+// APIServer returns a critical check that the Kubernetes API
+// server can be reached. The latency of each request is passed
+// to observe, if set.
+func APIServer(client kubernetes.Interface, observe func(time.Duration)) Check {
+	return Check{
+		Name:     "apiserver",
+		Critical: true,
+		Run: func() (string, error) {
+			// Try and lookup Kubernetes server version as a quick and dirty check
+			start := time.Now()
+			_, err := client.Discovery().ServerVersion()
+			latency := time.Since(start)
+			if err != nil {
+				return "", err
+			}
+			if observe != nil {
+				observe(latency)
+			}
+			return fmt.Sprintf("latency %s", latency.Round(time.Millisecond)), nil
+		},
+	}
+}
+
+// Certificate returns a check of the expiry of the first certificate
+// in the PEM file filename. Its expiry time is passed to observe, if
+// set. The check fails once the certificate has expired.
+func Certificate(name, filename string, observe func(time.Time)) Check {
+	return Check{
+		Name: name,
+		Run: func() (string, error) {
+			buf, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return "", err
+			}
+			block, _ := pem.Decode(buf)
+			if block == nil || block.Type != "CERTIFICATE" {
+				return "", fmt.Errorf("no certificate found in %s", filename)
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return "", err
+			}
+			if observe != nil {
+				observe(cert.NotAfter)
+			}
+
+			remaining := time.Until(cert.NotAfter)
+			if remaining <= 0 {
+				return "", fmt.Errorf("certificate expired at %s", cert.NotAfter.UTC().Format(time.RFC3339))
+			}
+			return fmt.Sprintf("%d days remaining", int(remaining.Hours()/24)), nil
+		},
+	}
+}
+
+// DAG returns a check that reports the time of the last DAG rebuild,
+// and how long the oldest change it included waited to be applied,
+// as returned by status. It fails until the DAG is first built.
+func DAG(status func() (time.Time, time.Duration)) Check {
+	return Check{
+		Name: "dag",
+		Run: func() (string, error) {
+			rebuilt, lag := status()
+			if rebuilt.IsZero() {
+				return "", errors.New("not built yet")
+			}
+			return fmt.Sprintf("rebuilt %s ago, informer lag %s",
+				time.Since(rebuilt).Round(time.Second), lag.Round(time.Millisecond)), nil
+		},
+	}
+}
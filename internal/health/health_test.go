@@ -0,0 +1,158 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run: func() (string, error) {
+			return "fine", err
+		},
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := map[string]struct {
+		checks []Check
+		url    string
+		want   int
+		body   string
+	}{
+		"healthy": {
+			checks: []Check{check("a", true, nil), check("b", false, nil)},
+			url:    "/healthz",
+			want:   http.StatusOK,
+			body:   "OK\n",
+		},
+		"healthy verbose": {
+			checks: []Check{check("a", true, nil), check("b", false, nil)},
+			url:    "/healthz?verbose",
+			want:   http.StatusOK,
+			body:   "[+]a ok: fine\n[+]b ok: fine\nOK\n",
+		},
+		"non critical failure": {
+			checks: []Check{check("a", true, nil), check("b", false, errors.New("broken"))},
+			url:    "/healthz?verbose",
+			want:   http.StatusOK,
+			body:   "[+]a ok: fine\n[-]b failed: broken\nOK\n",
+		},
+		"critical failure": {
+			checks: []Check{check("a", true, errors.New("broken")), check("b", false, nil)},
+			url:    "/healthz",
+			want:   http.StatusServiceUnavailable,
+			body:   "Failed a check: broken\n",
+		},
+		"critical failure verbose": {
+			checks: []Check{check("a", true, errors.New("broken")), check("b", false, nil)},
+			url:    "/healthz?verbose",
+			want:   http.StatusServiceUnavailable,
+			body:   "[-]a failed: broken\n[+]b ok: fine\nFailed a check: broken\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Handler(tc.checks...).ServeHTTP(w, httptest.NewRequest("GET", tc.url, nil))
+			assert.Equal(t, tc.want, w.Code)
+			assert.Equal(t, tc.body, w.Body.String())
+		})
+	}
+}
+
+func TestAPIServer(t *testing.T) {
+	var latency time.Duration
+	observed := false
+	c := APIServer(fake.NewSimpleClientset(), func(d time.Duration) {
+		latency, observed = d, true
+	})
+
+	_, err := c.Run()
+	assert.NoError(t, err)
+	assert.True(t, c.Critical)
+	assert.True(t, observed)
+	assert.GreaterOrEqual(t, int64(latency), int64(0))
+}
+
+func TestCertificate(t *testing.T) {
+	writeCert := func(notAfter time.Time) string {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "contour"},
+			NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+
+		filename := filepath.Join(t.TempDir(), "tls.crt")
+		require.NoError(t, ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+		return filename
+	}
+
+	notAfter := time.Now().Add(30*24*time.Hour + time.Hour).Truncate(time.Second)
+	var expiry time.Time
+	summary, err := Certificate("xds-certificate", writeCert(notAfter), func(ts time.Time) {
+		expiry = ts
+	}).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "30 days remaining", summary)
+	assert.True(t, notAfter.Equal(expiry))
+
+	_, err = Certificate("xds-certificate", writeCert(time.Now().Add(-time.Hour)), nil).Run()
+	assert.Error(t, err)
+
+	_, err = Certificate("xds-certificate", filepath.Join(t.TempDir(), "missing"), nil).Run()
+	assert.Error(t, err)
+}
+
+func TestDAG(t *testing.T) {
+	var rebuilt time.Time
+	c := DAG(func() (time.Time, time.Duration) {
+		return rebuilt, 250 * time.Millisecond
+	})
+
+	_, err := c.Run()
+	assert.Error(t, err)
+
+	rebuilt = time.Now().Add(-5 * time.Second)
+	summary, err := c.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, "rebuilt 5s ago, informer lag 250ms", summary)
+}
@@ -17,9 +17,16 @@ package httpsvc
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -30,6 +37,30 @@ type Service struct {
 	Addr string
 	Port int
 
+	// TLSConfig optionally serves the endpoint with TLS.
+	TLSConfig *tls.Config
+
+	// CertFile and KeyFile, if TLSConfig is not set, optionally
+	// serve the endpoint with TLS using the certificate and key in
+	// these files. They are read at each handshake, so that rotated
+	// certificates are picked up without a restart.
+	CertFile string
+	KeyFile  string
+
+	// CAFile, if set, requires clients to present a certificate
+	// signed by one of the CAs in this file. It requires CertFile
+	// and KeyFile.
+	CAFile string
+
+	// TokenFile, if set, requires requests to carry the bearer
+	// token in this file in their Authorization header.
+	TokenFile string
+
+	// TokenExempt, if set, reports the requests that are served
+	// without the bearer token in TokenFile, because their handler
+	// authorizes them itself.
+	TokenExempt func(*http.Request) bool
+
 	logrus.FieldLogger
 	http.ServeMux
 }
@@ -45,9 +76,19 @@ func (svc *Service) Start(stop <-chan struct{}) (err error) {
 		}
 	}()
 
+	handler, err := svc.handler()
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := svc.tlsConfig()
+	if err != nil {
+		return err
+	}
+
 	s := http.Server{
 		Addr:           net.JoinHostPort(svc.Addr, strconv.Itoa(svc.Port)),
-		Handler:        &svc.ServeMux,
+		Handler:        handler,
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   5 * time.Minute, // allow for long trace requests
 		MaxHeaderBytes: 1 << 11,         // 8kb should be enough for anyone
@@ -64,6 +105,104 @@ func (svc *Service) Start(stop <-chan struct{}) (err error) {
 		_ = s.Shutdown(ctx) // ignored, will always be a cancellation error
 	}()
 
-	svc.WithField("address", s.Addr).Info("started HTTP server")
-	return s.ListenAndServe()
+	if tlsConfig == nil {
+		svc.WithField("address", s.Addr).Info("started HTTP server")
+		return s.ListenAndServe()
+	}
+
+	l, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+
+	svc.WithField("address", s.Addr).WithField("tls", true).Info("started HTTP server")
+	return s.Serve(tls.NewListener(l, tlsConfig))
+}
+
+// handler returns the handler of the endpoint, which requires the
+// bearer token in TokenFile if it is set.
+func (svc *Service) handler() (http.Handler, error) {
+	if svc.TokenFile == "" {
+		return &svc.ServeMux, nil
+	}
+
+	buf, err := ioutil.ReadFile(svc.TokenFile)
+	if err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(string(buf))
+	if token == "" {
+		return nil, fmt.Errorf("bearer token file %s is empty", svc.TokenFile)
+	}
+	want := []byte("Bearer " + token)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if svc.TokenExempt != nil && svc.TokenExempt(r) {
+			svc.ServeMux.ServeHTTP(w, r)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		svc.ServeMux.ServeHTTP(w, r)
+	}), nil
+}
+
+// tlsConfig returns the TLS configuration of the endpoint, or nil
+// if it is served without TLS.
+func (svc *Service) tlsConfig() (*tls.Config, error) {
+	if svc.TLSConfig != nil {
+		return svc.TLSConfig, nil
+	}
+
+	if svc.CertFile == "" && svc.KeyFile == "" {
+		if svc.CAFile != "" {
+			return nil, errors.New("a CA file requires a certificate and key file")
+		}
+		return nil, nil
+	}
+
+	// Load the files now to catch configuration errors early.
+	if _, err := svc.loadTLSConfig(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return svc.loadTLSConfig()
+		},
+	}, nil
+}
+
+// loadTLSConfig returns the TLS configuration built from the
+// current contents of the certificate, key and CA files.
+func (svc *Service) loadTLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(svc.CertFile, svc.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if svc.CAFile != "" {
+		buf, err := ioutil.ReadFile(svc.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		certPool := x509.NewCertPool()
+		if ok := certPool.AppendCertsFromPEM(buf); !ok {
+			return nil, fmt.Errorf("unable to append certificate in %s to CA pool", svc.CAFile)
+		}
+
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = certPool
+	}
+
+	return config, nil
 }
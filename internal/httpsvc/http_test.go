@@ -0,0 +1,103 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpsvc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerBearerToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("s3cr3t\n"), 0600))
+
+	svc := Service{TokenFile: tokenFile}
+	svc.ServeMux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler, err := svc.handler()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		authorization string
+		want          int
+	}{
+		"no token":     {want: http.StatusUnauthorized},
+		"wrong token":  {authorization: "Bearer wrong", want: http.StatusUnauthorized},
+		"basic auth":   {authorization: "Basic czNjcjN0", want: http.StatusUnauthorized},
+		"bearer token": {authorization: "Bearer s3cr3t", want: http.StatusOK},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/metrics", nil)
+			if tc.authorization != "" {
+				r.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tc.want, w.Code)
+		})
+	}
+}
+
+func TestHandlerTokenExempt(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("s3cr3t\n"), 0600))
+
+	svc := Service{
+		TokenFile: tokenFile,
+		TokenExempt: func(r *http.Request) bool {
+			return r.URL.Path == "/public"
+		},
+	}
+	svc.ServeMux.HandleFunc("/public", func(w http.ResponseWriter, r *http.Request) {})
+	svc.ServeMux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler, err := svc.handler()
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/public", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/private", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestHandlerEmptyBearerToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("\n"), 0600))
+
+	svc := Service{TokenFile: tokenFile}
+	_, err := svc.handler()
+	assert.Error(t, err)
+}
+
+func TestTLSConfigRequiresCertificate(t *testing.T) {
+	svc := Service{CAFile: "ca.pem"}
+	_, err := svc.tlsConfig()
+	assert.Error(t, err)
+
+	svc = Service{}
+	config, err := svc.tlsConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+}
@@ -17,7 +17,10 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapi_v1alpha1 "sigs.k8s.io/gateway-api/apis/v1alpha1"
 )
 
 // isStatusEqual checks that two objects of supported Kubernetes types
@@ -26,6 +29,8 @@ import (
 // Currently supports:
 // networking.k8s.io/ingress/v1
 // projectcontour.io/v1
+// projectcontour.io/v1alpha1 extensionservice
+// networking.x-k8s.io/v1alpha1 gateway, httproute and tlsroute
 func isStatusEqual(objA, objB interface{}) bool {
 
 	switch a := objA.(type) {
@@ -48,6 +53,38 @@ func isStatusEqual(objA, objB interface{}) bool {
 				return true
 			}
 		}
+	case *contour_api_v1alpha1.ExtensionService:
+		switch b := objB.(type) {
+		case *contour_api_v1alpha1.ExtensionService:
+			if cmp.Equal(a.Status, b.Status,
+				cmpopts.IgnoreFields(contour_api_v1.Condition{}, "LastTransitionTime")) {
+				return true
+			}
+		}
+	case *gatewayapi_v1alpha1.Gateway:
+		switch b := objB.(type) {
+		case *gatewayapi_v1alpha1.Gateway:
+			if cmp.Equal(a.Status, b.Status,
+				cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")) {
+				return true
+			}
+		}
+	case *gatewayapi_v1alpha1.HTTPRoute:
+		switch b := objB.(type) {
+		case *gatewayapi_v1alpha1.HTTPRoute:
+			if cmp.Equal(a.Status, b.Status,
+				cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")) {
+				return true
+			}
+		}
+	case *gatewayapi_v1alpha1.TLSRoute:
+		switch b := objB.(type) {
+		case *gatewayapi_v1alpha1.TLSRoute:
+			if cmp.Equal(a.Status, b.Status,
+				cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")) {
+				return true
+			}
+		}
 	}
 
 	return false
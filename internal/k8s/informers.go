@@ -26,10 +26,13 @@ import (
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingressclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses/status,verbs=create;get;update
 
-// +kubebuilder:rbac:groups="projectcontour.io",resources=httpproxies;tlscertificatedelegations,verbs=get;list;watch
-// +kubebuilder:rbac:groups="projectcontour.io",resources=httpproxies/status,verbs=create;get;update
-// +kubebuilder:rbac:groups="projectcontour.io",resources=extensionservices,verbs=get;list;watch
-// +kubebuilder:rbac:groups="projectcontour.io",resources=extensionservices/status,verbs=create;get;update
+// +kubebuilder:rbac:groups="projectcontour.io",resources=tlscertificatedelegations,verbs=get;list;watch
+// +kubebuilder:rbac:groups="projectcontour.io",resources=httpproxies,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="projectcontour.io",resources=httpproxies/status,verbs=create;get;update;patch
+// +kubebuilder:rbac:groups="projectcontour.io",resources=extensionservices,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="projectcontour.io",resources=extensionservices/status,verbs=create;get;update;patch
+// +kubebuilder:rbac:groups="projectcontour.io",resources=trafficsplits,verbs=get;list;watch
+// +kubebuilder:rbac:groups="projectcontour.io",resources=ingresspolicies,verbs=get;list;watch
 
 // DefaultResources ...
 func DefaultResources() []schema.GroupVersionResource {
@@ -37,6 +40,8 @@ func DefaultResources() []schema.GroupVersionResource {
 		contour_api_v1.HTTPProxyGVR,
 		contour_api_v1.TLSCertificateDelegationGVR,
 		contour_api_v1alpha1.ExtensionServiceGVR,
+		contour_api_v1alpha1.TrafficSplitGVR,
+		contour_api_v1alpha1.IngressPolicyGVR,
 		corev1.SchemeGroupVersion.WithResource("services"),
 	}
 }
@@ -102,6 +107,15 @@ func EndpointsResources() []schema.GroupVersionResource {
 	}
 }
 
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+
+// PodsResources ...
+func PodsResources() []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{
+		corev1.SchemeGroupVersion.WithResource("pods"),
+	}
+}
+
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
 
 // ServicesResources ...
@@ -15,12 +15,18 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	contour_api_v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -44,6 +50,19 @@ func NewStatusUpdate(name, namespace string, gvr schema.GroupVersionResource, mu
 	}
 }
 
+// key returns the identity of the object that the update applies to.
+func (upd StatusUpdate) key() statusUpdateKey {
+	return statusUpdateKey{
+		NamespacedName: upd.NamespacedName,
+		Resource:       upd.Resource,
+	}
+}
+
+type statusUpdateKey struct {
+	types.NamespacedName
+	Resource schema.GroupVersionResource
+}
+
 // StatusMutator is an interface to hold mutator functions for status updates.
 type StatusMutator interface {
 	Mutate(obj interface{}) interface{}
@@ -61,6 +80,31 @@ func (m StatusMutatorFunc) Mutate(old interface{}) interface{} {
 	return m(old)
 }
 
+// chainMutators returns a StatusMutator that applies first and then next.
+func chainMutators(first, next StatusMutator) StatusMutator {
+	return StatusMutatorFunc(func(obj interface{}) interface{} {
+		return next.Mutate(first.Mutate(obj))
+	})
+}
+
+// StatusFieldManager is the field manager that owns the status
+// fields written by Contour with server-side apply.
+const StatusFieldManager = "contour"
+
+// DefaultStatusUpdateBackoff is the backoff used to retry status
+// updates that conflict with other writes to the object. The jitter
+// spreads out retries from writers that conflicted at the same time.
+var DefaultStatusUpdateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 10 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.5,
+}
+
+// maxStatusUpdateBatch is the maximum number of updates that are
+// collected from the UpdateChannel before they are written.
+const maxStatusUpdateBatch = 1000
+
 // StatusUpdateHandler holds the details required to actually write an Update back to the referenced object.
 type StatusUpdateHandler struct {
 	Log           logrus.FieldLogger
@@ -69,41 +113,57 @@ type StatusUpdateHandler struct {
 	LeaderElected chan struct{}
 	IsLeader      bool
 	Converter     *UnstructuredConverter
+
+	// Backoff is used to retry updates that conflict with
+	// other writes. If not set, DefaultStatusUpdateBackoff
+	// is used.
+	Backoff *wait.Backoff
 }
 
-func (suh *StatusUpdateHandler) apply(upd StatusUpdate) {
-	gvk, err := suh.Clients.KindFor(upd.Resource)
+// backoff returns the configured backoff, or DefaultStatusUpdateBackoff.
+func (suh *StatusUpdateHandler) backoff() wait.Backoff {
+	if suh.Backoff != nil {
+		return *suh.Backoff
+	}
+	return DefaultStatusUpdateBackoff
+}
 
-	if err != nil {
-		suh.Log.WithError(err).
-			WithField("name", upd.NamespacedName.Name).
-			WithField("namespace", upd.NamespacedName.Namespace).
-			WithField("resource", upd.Resource).
-			Error("failed to map Resource to Kind ")
-		return
+// batch returns the given update together with the updates that are
+// already queued on the UpdateChannel. Updates for the same object are
+// merged so that each object is written at most once.
+func (suh *StatusUpdateHandler) batch(first StatusUpdate) []StatusUpdate {
+	updates := []StatusUpdate{first}
+	index := map[statusUpdateKey]int{first.key(): 0}
+
+	for n := 1; n < maxStatusUpdateBatch; n++ {
+		select {
+		case upd := <-suh.UpdateChannel:
+			if i, ok := index[upd.key()]; ok {
+				updates[i].Mutator = chainMutators(updates[i].Mutator, upd.Mutator)
+				continue
+			}
+			index[upd.key()] = len(updates)
+			updates = append(updates, upd)
+		default:
+			return updates
+		}
 	}
 
-	tmpl, err := suh.Converter.scheme.New(gvk)
+	return updates
+}
+
+func (suh *StatusUpdateHandler) apply(upd StatusUpdate) {
+	obj, err := newStatusObject(suh.Clients, suh.Converter, upd.Resource)
 	if err != nil {
 		suh.Log.WithError(err).
 			WithField("name", upd.NamespacedName.Name).
 			WithField("namespace", upd.NamespacedName.Namespace).
-			WithField("kind", gvk).
-			Error("failed to allocate template object")
-		return
-	}
-
-	obj, ok := tmpl.(client.Object)
-	if !ok {
-		suh.Log.
-			WithField("name", upd.NamespacedName.Name).
-			WithField("namespace", upd.NamespacedName.Namespace).
-			WithField("kind", gvk).
-			Error("failed to type-assert to client.Object")
+			WithField("resource", upd.Resource).
+			Error("failed to allocate object for status update")
 		return
 	}
 
-	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+	if err := retry.RetryOnConflict(suh.backoff(), func() error {
 		// Fetch the lister cache for the informer associated with this resource.
 		if err := suh.Clients.Cache().Get(context.Background(), upd.NamespacedName, obj); err != nil {
 			return err
@@ -118,6 +178,13 @@ func (suh *StatusUpdateHandler) apply(upd StatusUpdate) {
 			return nil
 		}
 
+		if applyObj := statusApplyConfiguration(newObj); applyObj != nil {
+			if err := suh.migrateManagedFields(upd, obj); err != nil {
+				return err
+			}
+			return suh.applyStatus(upd, applyObj)
+		}
+
 		usNewObj, err := suh.Converter.ToUnstructured(newObj)
 		if err != nil {
 			return fmt.Errorf("unable to convert object: %w", err)
@@ -136,6 +203,196 @@ func (suh *StatusUpdateHandler) apply(upd StatusUpdate) {
 	}
 }
 
+// applyStatus writes the status fields of applyObj with server-side apply.
+// Contour forces ownership of these fields, so writes never conflict
+// with other field managers and never touch fields it doesn't own.
+func (suh *StatusUpdateHandler) applyStatus(upd StatusUpdate, applyObj client.Object) error {
+	data, err := applyPatchData(suh.Converter, applyObj)
+	if err != nil {
+		return err
+	}
+
+	force := true
+	_, err = suh.Clients.DynamicClient().
+		Resource(upd.Resource).
+		Namespace(upd.NamespacedName.Namespace).
+		Patch(context.Background(), upd.NamespacedName.Name, types.ApplyPatchType, data, metav1.PatchOptions{
+			FieldManager: StatusFieldManager,
+			Force:        &force,
+		}, "status")
+	return err
+}
+
+// newStatusObject allocates an empty object of the kind of resource,
+// which the object that a status update applies to is read into.
+func newStatusObject(clients *Clients, converter *UnstructuredConverter, resource schema.GroupVersionResource) (client.Object, error) {
+	gvk, err := clients.KindFor(resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map resource to kind: %w", err)
+	}
+
+	tmpl, err := converter.scheme.New(gvk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate template object for %s: %w", gvk, err)
+	}
+
+	obj, ok := tmpl.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("failed to type-assert %s to client.Object", gvk)
+	}
+	return obj, nil
+}
+
+// applyPatchData returns the server-side apply patch of the
+// status fields of applyObj.
+func applyPatchData(converter *UnstructuredConverter, applyObj client.Object) ([]byte, error) {
+	usApplyObj, err := converter.ToUnstructured(applyObj)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert object: %w", err)
+	}
+	unstructured.RemoveNestedField(usApplyObj.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(usApplyObj.Object, "spec")
+
+	data, err := json.Marshal(usApplyObj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal object: %w", err)
+	}
+	return data, nil
+}
+
+// statusPatch returns a patch that sets the status subresource of an
+// object to the status of newObj. Objects whose status Contour writes
+// with server-side apply get an apply patch, and others a merge patch
+// that replaces their status.
+func statusPatch(converter *UnstructuredConverter, newObj interface{}) (types.PatchType, []byte, error) {
+	if applyObj := statusApplyConfiguration(newObj); applyObj != nil {
+		data, err := applyPatchData(converter, applyObj)
+		return types.ApplyPatchType, data, err
+	}
+
+	usNewObj, err := converter.ToUnstructured(newObj)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to convert object: %w", err)
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"status": usNewObj.Object["status"],
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to marshal status: %w", err)
+	}
+	return types.MergePatchType, data, nil
+}
+
+// migrateManagedFields removes the managed fields entry left by
+// earlier Contour versions, which wrote status with update semantics.
+// Otherwise, fields that Contour no longer applies would stay owned
+// by that entry and never be removed.
+func (suh *StatusUpdateHandler) migrateManagedFields(upd StatusUpdate, obj client.Object) error {
+	managedFields, migrated := withoutLegacyStatusManager(obj.GetManagedFields())
+	if !migrated {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": obj.GetResourceVersion(),
+			"managedFields":   managedFields,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to marshal managed fields: %w", err)
+	}
+
+	suh.Log.WithField("name", upd.NamespacedName.Name).
+		WithField("namespace", upd.NamespacedName.Namespace).
+		Info("migrating status to server-side apply")
+
+	_, err = suh.Clients.DynamicClient().
+		Resource(upd.Resource).
+		Namespace(upd.NamespacedName.Namespace).
+		Patch(context.Background(), upd.NamespacedName.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// withoutLegacyStatusManager returns the managed fields without the
+// update entries of the Contour field manager, and whether any were
+// removed.
+func withoutLegacyStatusManager(entries []metav1.ManagedFieldsEntry) ([]metav1.ManagedFieldsEntry, bool) {
+	var remaining []metav1.ManagedFieldsEntry
+	removed := false
+
+	for _, e := range entries {
+		if e.Manager == StatusFieldManager && e.Operation == metav1.ManagedFieldsOperationUpdate {
+			removed = true
+			continue
+		}
+		remaining = append(remaining, e)
+	}
+
+	// An empty list leaves the managed fields unchanged, while
+	// a single empty entry clears them.
+	if removed && len(remaining) == 0 {
+		remaining = []metav1.ManagedFieldsEntry{{}}
+	}
+
+	return remaining, removed
+}
+
+// statusApplyConfiguration returns an object holding only the status
+// fields that Contour owns on obj, or nil if the status of obj is not
+// written with server-side apply.
+func statusApplyConfiguration(obj interface{}) client.Object {
+	switch o := obj.(type) {
+	case *contour_api_v1.HTTPProxy:
+		return &contour_api_v1.HTTPProxy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: contour_api_v1.GroupVersion.String(),
+				Kind:       "HTTPProxy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      o.Name,
+				Namespace: o.Namespace,
+			},
+			Status: contour_api_v1.HTTPProxyStatus{
+				CurrentStatus:   o.Status.CurrentStatus,
+				Description:     o.Status.Description,
+				LoadBalancer:    o.Status.LoadBalancer,
+				Conditions:      ownedConditions(o.Status.Conditions),
+				EffectiveRoutes: o.Status.EffectiveRoutes,
+			},
+		}
+	case *contour_api_v1alpha1.ExtensionService:
+		return &contour_api_v1alpha1.ExtensionService{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: contour_api_v1alpha1.GroupVersion.String(),
+				Kind:       "ExtensionService",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      o.Name,
+				Namespace: o.Namespace,
+			},
+			Status: contour_api_v1alpha1.ExtensionServiceStatus{
+				Conditions: ownedConditions(o.Status.Conditions),
+			},
+		}
+	default:
+		return nil
+	}
+}
+
+// ownedConditions returns the conditions that Contour writes. Conditions
+// added by other controllers are left to their own field managers.
+func ownedConditions(conditions []contour_api_v1.DetailedCondition) []contour_api_v1.DetailedCondition {
+	var owned []contour_api_v1.DetailedCondition
+	for _, c := range conditions {
+		if c.Type == contour_api_v1.ValidConditionType {
+			owned = append(owned, c)
+		}
+	}
+	return owned
+}
+
 // Start runs the goroutine to perform status writes.
 // Until the Contour is elected leader, will drop updates on the floor.
 func (suh *StatusUpdateHandler) Start(stop <-chan struct{}) error {
@@ -156,11 +413,16 @@ func (suh *StatusUpdateHandler) Start(stop <-chan struct{}) error {
 				continue
 			}
 
+			updates := suh.batch(upd)
+
 			suh.Log.WithField("name", upd.NamespacedName.Name).
 				WithField("namespace", upd.NamespacedName.Namespace).
+				WithField("batch_size", len(updates)).
 				Debug("received a status update")
 
-			suh.apply(upd)
+			for _, u := range updates {
+				suh.apply(u)
+			}
 		}
 
 	}
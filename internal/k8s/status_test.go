@@ -0,0 +1,230 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapi_v1alpha1 "sigs.k8s.io/gateway-api/apis/v1alpha1"
+)
+
+func TestStatusUpdateHandlerBatch(t *testing.T) {
+	setDescription := func(desc string) StatusMutator {
+		return StatusMutatorFunc(func(obj interface{}) interface{} {
+			proxy := obj.(*contour_api_v1.HTTPProxy).DeepCopy()
+			proxy.Status.Description = desc
+			return proxy
+		})
+	}
+	setLoadBalancer := StatusMutatorFunc(func(obj interface{}) interface{} {
+		proxy := obj.(*contour_api_v1.HTTPProxy).DeepCopy()
+		proxy.Status.LoadBalancer = v1.LoadBalancerStatus{
+			Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.1"}},
+		}
+		return proxy
+	})
+
+	suh := StatusUpdateHandler{
+		UpdateChannel: make(chan StatusUpdate, 10),
+	}
+
+	suh.UpdateChannel <- NewStatusUpdate("other", "default", contour_api_v1.HTTPProxyGVR, setDescription("other"))
+	suh.UpdateChannel <- NewStatusUpdate("proxy", "default", contour_api_v1.HTTPProxyGVR, setLoadBalancer)
+	suh.UpdateChannel <- NewStatusUpdate("proxy", "default", contour_api_v1.HTTPProxyGVR, setDescription("second"))
+
+	updates := suh.batch(NewStatusUpdate("proxy", "default", contour_api_v1.HTTPProxyGVR, setDescription("first")))
+	require.Len(t, updates, 2)
+	assert.Empty(t, suh.UpdateChannel)
+
+	// Updates for the same object are merged in the order they were sent.
+	assert.Equal(t, "proxy", updates[0].NamespacedName.Name)
+	got := updates[0].Mutator.Mutate(&contour_api_v1.HTTPProxy{}).(*contour_api_v1.HTTPProxy)
+	assert.Equal(t, "second", got.Status.Description)
+	assert.Equal(t, "192.0.2.1", got.Status.LoadBalancer.Ingress[0].IP)
+
+	assert.Equal(t, "other", updates[1].NamespacedName.Name)
+}
+
+func TestStatusUpdateHandlerBackoff(t *testing.T) {
+	suh := StatusUpdateHandler{}
+	assert.Equal(t, DefaultStatusUpdateBackoff, suh.backoff())
+
+	backoff := DefaultStatusUpdateBackoff
+	backoff.Steps = 1
+	suh.Backoff = &backoff
+	assert.Equal(t, 1, suh.backoff().Steps)
+}
+
+func TestIsStatusEqualGatewayRoute(t *testing.T) {
+	route := func(status metav1.ConditionStatus, transition time.Time) *gatewayapi_v1alpha1.HTTPRoute {
+		return &gatewayapi_v1alpha1.HTTPRoute{
+			Status: gatewayapi_v1alpha1.HTTPRouteStatus{
+				RouteStatus: gatewayapi_v1alpha1.RouteStatus{
+					Gateways: []gatewayapi_v1alpha1.RouteGatewayStatus{{
+						Conditions: []metav1.Condition{{
+							Type:               "Admitted",
+							Status:             status,
+							ObservedGeneration: 1,
+							LastTransitionTime: metav1.NewTime(transition),
+						}},
+					}},
+				},
+			},
+		}
+	}
+
+	now := time.Now()
+	assert.True(t, isStatusEqual(route(metav1.ConditionTrue, now), route(metav1.ConditionTrue, now.Add(time.Minute))))
+	assert.False(t, isStatusEqual(route(metav1.ConditionTrue, now), route(metav1.ConditionFalse, now)))
+}
+
+func TestWithoutLegacyStatusManager(t *testing.T) {
+	legacy := metav1.ManagedFieldsEntry{Manager: StatusFieldManager, Operation: metav1.ManagedFieldsOperationUpdate}
+	applied := metav1.ManagedFieldsEntry{Manager: StatusFieldManager, Operation: metav1.ManagedFieldsOperationApply}
+	kubectl := metav1.ManagedFieldsEntry{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate}
+
+	got, removed := withoutLegacyStatusManager([]metav1.ManagedFieldsEntry{kubectl, legacy})
+	assert.True(t, removed)
+	assert.Equal(t, []metav1.ManagedFieldsEntry{kubectl}, got)
+
+	got, removed = withoutLegacyStatusManager([]metav1.ManagedFieldsEntry{legacy})
+	assert.True(t, removed)
+	assert.Equal(t, []metav1.ManagedFieldsEntry{{}}, got)
+
+	got, removed = withoutLegacyStatusManager([]metav1.ManagedFieldsEntry{kubectl, applied})
+	assert.False(t, removed)
+	assert.Equal(t, []metav1.ManagedFieldsEntry{kubectl, applied}, got)
+}
+
+func TestStatusApplyConfiguration(t *testing.T) {
+	proxy := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "proxy",
+			Namespace:       "default",
+			ResourceVersion: "12",
+		},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "example.com"},
+		},
+		Status: contour_api_v1.HTTPProxyStatus{
+			CurrentStatus: "valid",
+			Description:   "Valid HTTPProxy",
+			Conditions: []contour_api_v1.DetailedCondition{{
+				Condition: metav1.Condition{Type: contour_api_v1.ValidConditionType, Status: metav1.ConditionTrue},
+			}, {
+				Condition: metav1.Condition{Type: "Other", Status: metav1.ConditionTrue},
+			}},
+		},
+	}
+
+	got, ok := statusApplyConfiguration(proxy).(*contour_api_v1.HTTPProxy)
+	require.True(t, ok)
+	assert.Equal(t, "HTTPProxy", got.Kind)
+	assert.Equal(t, "proxy", got.Name)
+	assert.Empty(t, got.ResourceVersion)
+	assert.Nil(t, got.Spec.VirtualHost)
+	assert.Equal(t, "valid", got.Status.CurrentStatus)
+	require.Len(t, got.Status.Conditions, 1)
+	assert.Equal(t, contour_api_v1.ValidConditionType, got.Status.Conditions[0].Type)
+
+	assert.Nil(t, statusApplyConfiguration(&gatewayapi_v1alpha1.HTTPRoute{}))
+}
+
+// objectCache is a cache that holds a single object.
+type objectCache struct {
+	informertest.FakeInformers
+	obj client.Object
+}
+
+func (c *objectCache) Get(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+	c.obj.(*contour_api_v1.HTTPProxy).DeepCopyInto(obj.(*contour_api_v1.HTTPProxy))
+	return nil
+}
+
+func TestStatusUpdateHandlerApplyEffectiveRoutes(t *testing.T) {
+	converter, err := NewUnstructuredConverter()
+	require.NoError(t, err)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(contour_api_v1.GroupVersion.WithKind("HTTPProxy"), meta.RESTScopeNamespace)
+
+	// Record the status patches instead of applying them, since the
+	// fake client does not support server-side apply.
+	var patches [][]byte
+	dynamic := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	dynamic.PrependReactor("patch", "httpproxies", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patches = append(patches, action.(k8stesting.PatchAction).GetPatch())
+		return true, nil, nil
+	})
+
+	stored := &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "proxy",
+			Namespace: "default",
+		},
+	}
+	cache := &objectCache{obj: stored}
+
+	suh := StatusUpdateHandler{
+		Log:       fixture.NewTestLogger(t),
+		Clients:   &Clients{RESTMapper: mapper, dynamic: dynamic, cache: cache},
+		Converter: converter,
+	}
+
+	effectiveRoutes := []contour_api_v1.EffectiveRoute{{
+		Fqdn:  "example.com",
+		Index: 0,
+		Conditions: []contour_api_v1.MatchCondition{{
+			Prefix: "/api",
+		}},
+	}}
+	setEffectiveRoutes := StatusMutatorFunc(func(obj interface{}) interface{} {
+		proxy := obj.(*contour_api_v1.HTTPProxy).DeepCopy()
+		proxy.Status.CurrentStatus = "valid"
+		proxy.Status.EffectiveRoutes = effectiveRoutes
+		return proxy
+	})
+	update := NewStatusUpdate("proxy", "default", contour_api_v1.HTTPProxyGVR, setEffectiveRoutes)
+
+	// The effective routes are part of the applied status.
+	suh.apply(update)
+	require.Len(t, patches, 1)
+
+	var applied contour_api_v1.HTTPProxy
+	require.NoError(t, json.Unmarshal(patches[0], &applied))
+	assert.Equal(t, effectiveRoutes, applied.Status.EffectiveRoutes)
+
+	// Once the applied status is stored, the update is a no-op.
+	cache.obj = &contour_api_v1.HTTPProxy{
+		ObjectMeta: stored.ObjectMeta,
+		Status:     applied.Status,
+	}
+	suh.apply(update)
+	assert.Len(t, patches, 1)
+}
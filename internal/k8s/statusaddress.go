@@ -34,11 +34,12 @@ import (
 // Note that this is intended to handle updating the status.loadBalancer struct only,
 // not more general status updates. That's a job for the StatusUpdater.
 type StatusAddressUpdater struct {
-	Logger           logrus.FieldLogger
-	LBStatus         v1.LoadBalancerStatus
-	IngressClassName string
-	StatusUpdater    StatusUpdater
-	Converter        Converter
+	Logger               logrus.FieldLogger
+	LBStatus             v1.LoadBalancerStatus
+	IngressClassName     string
+	IngressClassMatching annotation.ClassMatching
+	StatusUpdater        StatusUpdater
+	Converter            Converter
 
 	// mu guards the LBStatus field, which can be updated dynamically.
 	mu sync.Mutex
@@ -87,7 +88,7 @@ func (s *StatusAddressUpdater) OnAdd(obj interface{}) {
 
 	switch o := obj.(type) {
 	case *networking_v1.Ingress:
-		if !ingress_validation.MatchesIngressClassName(o, s.IngressClassName) {
+		if !ingress_validation.MatchesIngressClassNameWith(o, s.IngressClassName, s.IngressClassMatching) {
 			logNoMatch(s.Logger.WithField("ingress-class-name", pointer.StringPtrDerefOr(o.Spec.IngressClassName, "")), o)
 			return
 		}
@@ -95,7 +96,7 @@ func (s *StatusAddressUpdater) OnAdd(obj interface{}) {
 		typed = o.DeepCopy()
 		gvr = networking_v1.SchemeGroupVersion.WithResource("ingresses")
 	case *contour_api_v1.HTTPProxy:
-		if !annotation.MatchesIngressClass(o, s.IngressClassName) {
+		if !annotation.MatchesIngressClassWith(o, s.IngressClassName, s.IngressClassMatching) {
 			logNoMatch(s.Logger, o)
 			return
 		}
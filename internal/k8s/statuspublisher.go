@@ -0,0 +1,218 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PublishedStatus is the status of a single object that a
+// StatusPublisher serves to status writers. Patch is written
+// to the status subresource of the object with PatchType.
+type PublishedStatus struct {
+	Resource  schema.GroupVersionResource `json:"resource"`
+	Namespace string                      `json:"namespace,omitempty"`
+	Name      string                      `json:"name"`
+	PatchType types.PatchType             `json:"patchType"`
+	Patch     json.RawMessage             `json:"patch"`
+
+	// sequence is the value of the StatusPublisher's
+	// sequence number when the status last changed.
+	sequence uint64
+}
+
+// PublishedStatuses is the response of a StatusPublisher.
+type PublishedStatuses struct {
+	// Epoch identifies the StatusPublisher. Sequence numbers
+	// of different epochs are not comparable.
+	Epoch string `json:"epoch"`
+
+	// Sequence is the sequence number of the most recently
+	// changed status. It is passed back to the StatusPublisher
+	// to only request the statuses that changed since.
+	Sequence uint64 `json:"sequence"`
+
+	// Statuses are the statuses that changed after the
+	// requested sequence number, in the order they changed.
+	Statuses []PublishedStatus `json:"statuses"`
+}
+
+// StatusPublisher takes the place of a StatusUpdateHandler when
+// status is written by a separate status writer process. Rather than
+// writing status updates itself, it applies them to the cached objects,
+// and serves the resulting status patches over HTTP.
+type StatusPublisher struct {
+	Log           logrus.FieldLogger
+	Clients       *Clients
+	UpdateChannel chan StatusUpdate
+	Converter     *UnstructuredConverter
+
+	mu       sync.Mutex
+	epoch    string
+	sequence uint64
+	statuses map[statusUpdateKey]PublishedStatus
+}
+
+// NewStatusPublisher returns a StatusPublisher with a new epoch.
+func NewStatusPublisher(log logrus.FieldLogger, clients *Clients, converter *UnstructuredConverter) *StatusPublisher {
+	return &StatusPublisher{
+		Log:       log,
+		Clients:   clients,
+		Converter: converter,
+		epoch:     uuid.New().String(),
+		statuses:  map[statusUpdateKey]PublishedStatus{},
+	}
+}
+
+// Start runs the goroutine that publishes status updates. Unlike a
+// StatusUpdateHandler, it doesn't wait to be elected leader, since any
+// Contour may be asked for statuses by the status writer.
+func (sp *StatusPublisher) Start(stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case upd := <-sp.UpdateChannel:
+			sp.publish(upd)
+		}
+	}
+}
+
+// Writer retrieves the interface that should be used to write to the StatusPublisher.
+func (sp *StatusPublisher) Writer() StatusUpdater {
+	if sp.UpdateChannel == nil {
+		sp.UpdateChannel = make(chan StatusUpdate, 100)
+	}
+
+	return &StatusUpdateWriter{
+		UpdateChannel: sp.UpdateChannel,
+	}
+}
+
+func (sp *StatusPublisher) publish(upd StatusUpdate) {
+	log := sp.Log.WithField("name", upd.NamespacedName.Name).
+		WithField("namespace", upd.NamespacedName.Namespace).
+		WithField("resource", upd.Resource)
+
+	obj, err := newStatusObject(sp.Clients, sp.Converter, upd.Resource)
+	if err != nil {
+		log.WithError(err).Error("failed to allocate object for status update")
+		return
+	}
+
+	if err := sp.Clients.Cache().Get(context.Background(), upd.NamespacedName, obj); err != nil {
+		if errors.IsNotFound(err) {
+			sp.store(upd.key(), nil)
+			return
+		}
+		log.WithError(err).Error("failed to get object for status update")
+		return
+	}
+
+	newObj := upd.Mutator.Mutate(obj)
+
+	// If the object already has the new status, there is
+	// nothing to write, and any earlier status is stale.
+	if isStatusEqual(obj, newObj) {
+		sp.store(upd.key(), nil)
+		return
+	}
+
+	patchType, patch, err := statusPatch(sp.Converter, newObj)
+	if err != nil {
+		log.WithError(err).Error("failed to publish status")
+		return
+	}
+
+	sp.store(upd.key(), &PublishedStatus{
+		Resource:  upd.Resource,
+		Namespace: upd.NamespacedName.Namespace,
+		Name:      upd.NamespacedName.Name,
+		PatchType: patchType,
+		Patch:     patch,
+	})
+}
+
+// store records st as the status of the object with the given key,
+// or forgets the status of the object if st is nil.
+func (sp *StatusPublisher) store(key statusUpdateKey, st *PublishedStatus) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if st == nil {
+		delete(sp.statuses, key)
+		return
+	}
+
+	if old, ok := sp.statuses[key]; ok && old.PatchType == st.PatchType && string(old.Patch) == string(st.Patch) {
+		return
+	}
+
+	sp.sequence++
+	st.sequence = sp.sequence
+	sp.statuses[key] = *st
+}
+
+// Since returns the statuses that changed after the given sequence number.
+func (sp *StatusPublisher) Since(sequence uint64) PublishedStatuses {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	resp := PublishedStatuses{
+		Epoch:    sp.epoch,
+		Sequence: sp.sequence,
+		Statuses: []PublishedStatus{},
+	}
+	for _, st := range sp.statuses {
+		if st.sequence > sequence {
+			resp.Statuses = append(resp.Statuses, st)
+		}
+	}
+
+	sort.Slice(resp.Statuses, func(i, j int) bool {
+		return resp.Statuses[i].sequence < resp.Statuses[j].sequence
+	})
+
+	return resp
+}
+
+// ServeHTTP serves the statuses that changed after the sequence
+// number given by the "since" query parameter, or all statuses
+// if it is not set.
+func (sp *StatusPublisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var since uint64
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		if since, err = strconv.ParseUint(s, 10, 64); err != nil {
+			http.Error(w, "invalid since parameter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sp.Since(since)); err != nil {
+		sp.Log.WithError(err).Error("failed to write statuses")
+	}
+}
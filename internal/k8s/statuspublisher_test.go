@@ -0,0 +1,148 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStatusPublisherSince(t *testing.T) {
+	sp := NewStatusPublisher(logrus.New(), nil, nil)
+
+	key := func(name string) statusUpdateKey {
+		return statusUpdateKey{
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: name},
+			Resource:       contour_api_v1.HTTPProxyGVR,
+		}
+	}
+	status := func(name, patch string) *PublishedStatus {
+		return &PublishedStatus{
+			Resource:  contour_api_v1.HTTPProxyGVR,
+			Namespace: "default",
+			Name:      name,
+			PatchType: types.MergePatchType,
+			Patch:     json.RawMessage(patch),
+		}
+	}
+	names := func(resp PublishedStatuses) []string {
+		var names []string
+		for _, st := range resp.Statuses {
+			names = append(names, st.Name)
+		}
+		return names
+	}
+
+	sp.store(key("a"), status("a", `{"status":{"currentStatus":"valid"}}`))
+	sp.store(key("b"), status("b", `{"status":{"currentStatus":"invalid"}}`))
+
+	resp := sp.Since(0)
+	assert.Equal(t, uint64(2), resp.Sequence)
+	assert.Equal(t, []string{"a", "b"}, names(resp))
+
+	// An unchanged status is not published again.
+	sp.store(key("a"), status("a", `{"status":{"currentStatus":"valid"}}`))
+	assert.Empty(t, sp.Since(2).Statuses)
+
+	// A changed status is published after the others.
+	sp.store(key("a"), status("a", `{"status":{"currentStatus":"invalid"}}`))
+	resp = sp.Since(0)
+	assert.Equal(t, uint64(3), resp.Sequence)
+	assert.Equal(t, []string{"b", "a"}, names(resp))
+	assert.Equal(t, []string{"a"}, names(sp.Since(2)))
+
+	// A forgotten status is no longer published.
+	sp.store(key("b"), nil)
+	assert.Equal(t, []string{"a"}, names(sp.Since(0)))
+}
+
+func TestStatusPublisherServeHTTP(t *testing.T) {
+	sp := NewStatusPublisher(logrus.New(), nil, nil)
+	sp.store(statusUpdateKey{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "proxy"},
+		Resource:       contour_api_v1.HTTPProxyGVR,
+	}, &PublishedStatus{
+		Resource:  contour_api_v1.HTTPProxyGVR,
+		Namespace: "default",
+		Name:      "proxy",
+		PatchType: types.ApplyPatchType,
+		Patch:     json.RawMessage(`{"status":{"currentStatus":"valid"}}`),
+	})
+
+	rec := httptest.NewRecorder()
+	sp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp PublishedStatuses
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, sp.epoch, resp.Epoch)
+	assert.Equal(t, uint64(1), resp.Sequence)
+	require.Len(t, resp.Statuses, 1)
+	assert.Equal(t, contour_api_v1.HTTPProxyGVR, resp.Statuses[0].Resource)
+	assert.Equal(t, types.ApplyPatchType, resp.Statuses[0].PatchType)
+	assert.JSONEq(t, `{"status":{"currentStatus":"valid"}}`, string(resp.Statuses[0].Patch))
+
+	rec = httptest.NewRecorder()
+	sp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status?since=1", nil))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Statuses)
+
+	rec = httptest.NewRecorder()
+	sp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status?since=latest", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestStatusPatch(t *testing.T) {
+	converter, err := NewUnstructuredConverter()
+	require.NoError(t, err)
+
+	patchType, patch, err := statusPatch(converter, &contour_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{Name: "proxy", Namespace: "default"},
+		Spec: contour_api_v1.HTTPProxySpec{
+			VirtualHost: &contour_api_v1.VirtualHost{Fqdn: "example.com"},
+		},
+		Status: contour_api_v1.HTTPProxyStatus{CurrentStatus: "valid"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.ApplyPatchType, patchType)
+	assert.JSONEq(t, `{
+		"apiVersion": "projectcontour.io/v1",
+		"kind": "HTTPProxy",
+		"metadata": {"name": "proxy", "namespace": "default"},
+		"status": {"currentStatus": "valid", "loadBalancer": {}}
+	}`, string(patch))
+
+	patchType, patch, err = statusPatch(converter, &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "default"},
+		Status: networking_v1.IngressStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{{IP: "10.0.0.1"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.MergePatchType, patchType)
+	assert.JSONEq(t, `{"status": {"loadBalancer": {"ingress": [{"ip": "10.0.0.1"}]}}}`, string(patch))
+}
@@ -16,6 +16,7 @@ package metrics
 
 import (
 	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/projectcontour/contour/internal/build"
@@ -26,20 +27,37 @@ import (
 // Metrics provide Prometheus metrics for the app
 type Metrics struct {
 	buildInfoGauge *prometheus.GaugeVec
+	fipsModeGauge  prometheus.Gauge
 
 	proxyTotalGauge     *prometheus.GaugeVec
 	proxyRootTotalGauge *prometheus.GaugeVec
 	proxyInvalidGauge   *prometheus.GaugeVec
 	proxyValidGauge     *prometheus.GaugeVec
 	proxyOrphanedGauge  *prometheus.GaugeVec
+	proxyRoutesGauge    *prometheus.GaugeVec
+
+	websocketRoutesGauge *prometheus.GaugeVec
 
 	dagRebuildGauge             *prometheus.GaugeVec
 	dagRebuildTotal             prometheus.Counter
 	CacheHandlerOnUpdateSummary prometheus.Summary
 	EventHandlerOperations      *prometheus.CounterVec
+	DAGProcessorDuration        *prometheus.HistogramVec
+	DAGObjectDuration           *prometheus.HistogramVec
+	QuarantinedSecrets          prometheus.Gauge
+	InformerLag                 prometheus.Gauge
+
+	xdsCertificateExpiryGauge prometheus.Gauge
+	apiServerLatencyGauge     prometheus.Gauge
 
 	// Keep a local cache of metrics for comparison on updates
-	proxyMetricCache *RouteMetric
+	proxyMetricCache          *RouteMetric
+	websocketRouteMetricCache map[Meta]int
+	proxyRoutesMetricCache    map[ProxyMeta]ProxyRoutes
+
+	// proxyLabels are the keys of the HTTPProxy labels
+	// that are added to the proxyRoutesGauge.
+	proxyLabels []string
 }
 
 // RouteMetric stores various metrics for HTTPProxy objects
@@ -56,29 +74,56 @@ type Meta struct {
 	VHost, Namespace string
 }
 
+// ProxyMeta holds the namespace and name of a HTTPProxy,
+// and a vhost that it defines routes of.
+type ProxyMeta struct {
+	Namespace, Name, VHost string
+}
+
+// ProxyRoutes holds the number of routes a HTTPProxy
+// defines in a vhost, and the labels of the HTTPProxy.
+type ProxyRoutes struct {
+	Routes int
+	Labels map[string]string
+}
+
 const (
 	BuildInfoGauge = "contour_build_info"
+	FIPSModeGauge  = "contour_fips_mode"
 
 	HTTPProxyTotalGauge     = "contour_httpproxy"
 	HTTPProxyRootTotalGauge = "contour_httpproxy_root"
 	HTTPProxyInvalidGauge   = "contour_httpproxy_invalid"
 	HTTPProxyValidGauge     = "contour_httpproxy_valid"
 	HTTPProxyOrphanedGauge  = "contour_httpproxy_orphaned"
+	HTTPProxyRoutesGauge    = "contour_httpproxy_routes"
+
+	WebsocketRoutesGauge = "contour_websocket_routes"
 
 	DAGRebuildGauge             = "contour_dagrebuild_timestamp"
 	DAGRebuildTotal             = "contour_dagrebuild_total"
 	cacheHandlerOnUpdateSummary = "contour_cachehandler_onupdate_duration_seconds"
 	eventHandlerOperations      = "contour_eventhandler_operation_total"
+	dagProcessorDuration        = "contour_dag_processor_duration_seconds"
+	dagObjectDuration           = "contour_dag_object_duration_seconds"
+	quarantinedSecrets          = "contour_quarantined_secrets"
+	informerLag                 = "contour_informer_lag_seconds"
+
+	XDSCertificateExpiryGauge = "contour_xds_certificate_expiry_timestamp"
+	APIServerLatencyGauge     = "contour_apiserver_latency_seconds"
 )
 
 // NewMetrics creates a new set of metrics and registers them with
-// the supplied registry.
+// the supplied registry. The values of the HTTPProxy labels whose
+// keys are listed in proxyLabels are added to the labels of the
+// contour_httpproxy_routes metric, as label_<key>.
 //
 // NOTE: when adding new metrics, update Zero() and run
 // `./hack/generate-metrics-doc.go` using `make generate-metrics-docs`
 // to regenerate the metrics documentation.
-func NewMetrics(registry *prometheus.Registry) *Metrics {
+func NewMetrics(registry *prometheus.Registry, proxyLabels ...string) *Metrics {
 	m := Metrics{
+		proxyLabels: proxyLabels,
 		buildInfoGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: BuildInfoGauge,
@@ -86,6 +131,12 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{"branch", "revision", "version"},
 		),
+		fipsModeGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: FIPSModeGauge,
+				Help: "Whether Contour was built with BoringCrypto and is running in FIPS mode. Set to 1 if FIPS mode is enabled, 0 otherwise.",
+			},
+		),
 		proxyMetricCache: &RouteMetric{},
 		proxyTotalGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -122,6 +173,20 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{"namespace"},
 		),
+		proxyRoutesGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: HTTPProxyRoutesGauge,
+				Help: "Total number of routes of each HTTPProxy, by virtual host. Labels also include the values of the configured HTTPProxy labels, as label_<key>.",
+			},
+			append([]string{"namespace", "name", "vhost"}, proxyLabelNames(proxyLabels)...),
+		),
+		websocketRoutesGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: WebsocketRoutesGauge,
+				Help: "Total number of routes with websockets enabled, by virtual host and the namespace of the object that defines the route.",
+			},
+			[]string{"namespace", "vhost"},
+		),
 		dagRebuildGauge: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: DAGRebuildGauge,
@@ -147,8 +212,50 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{"op", "kind"},
 		),
+		DAGProcessorDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: dagProcessorDuration,
+				Help: "Histogram for the runtime of each DAG processor during a DAG rebuild, by processor.",
+			},
+			[]string{"processor"},
+		),
+		DAGObjectDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    dagObjectDuration,
+				Help:    "Histogram for the time taken to process each object during a DAG rebuild, by object kind. Secret durations include certificate validation.",
+				Buckets: prometheus.ExponentialBuckets(0.00001, 10, 6),
+			},
+			[]string{"kind"},
+		),
+		QuarantinedSecrets: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: quarantinedSecrets,
+				Help: "Number of Secrets whose latest version is invalid, and which are served at their last valid version.",
+			},
+		),
+		InformerLag: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: informerLag,
+				Help: "Time the oldest Kubernetes object change included in the last DAG rebuild waited to be applied.",
+			},
+		),
+		xdsCertificateExpiryGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: XDSCertificateExpiryGauge,
+				Help: "Timestamp at which the certificate Contour serves xDS with expires.",
+			},
+		),
+		apiServerLatencyGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: APIServerLatencyGauge,
+				Help: "Latency of the last health check request to the Kubernetes API server.",
+			},
+		),
 	}
 	m.buildInfoGauge.WithLabelValues(build.Branch, build.Sha, build.Version).Set(1)
+	if build.FIPSEnabled() {
+		m.fipsModeGauge.Set(1)
+	}
 	m.register(registry)
 	return &m
 }
@@ -157,15 +264,24 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 func (m *Metrics) register(registry *prometheus.Registry) {
 	registry.MustRegister(
 		m.buildInfoGauge,
+		m.fipsModeGauge,
 		m.proxyTotalGauge,
 		m.proxyRootTotalGauge,
 		m.proxyInvalidGauge,
 		m.proxyValidGauge,
 		m.proxyOrphanedGauge,
+		m.proxyRoutesGauge,
+		m.websocketRoutesGauge,
 		m.dagRebuildGauge,
 		m.dagRebuildTotal,
 		m.CacheHandlerOnUpdateSummary,
 		m.EventHandlerOperations,
+		m.DAGProcessorDuration,
+		m.DAGObjectDuration,
+		m.QuarantinedSecrets,
+		m.InformerLag,
+		m.xdsCertificateExpiryGauge,
+		m.apiServerLatencyGauge,
 	)
 }
 
@@ -188,7 +304,15 @@ func (m *Metrics) Zero() {
 
 	m.SetDAGLastRebuilt(time.Now())
 	m.SetHTTPProxyMetric(zeroes)
+	m.SetHTTPProxyRoutesMetric(map[ProxyMeta]ProxyRoutes{{}: {}})
+	m.SetWebsocketRouteMetric(map[Meta]int{meta: 0})
 	m.EventHandlerOperations.WithLabelValues("add", "Secret").Inc()
+	m.DAGProcessorDuration.WithLabelValues("HTTPProxyProcessor").Observe(0)
+	m.DAGObjectDuration.WithLabelValues("Secret").Observe(0)
+	m.QuarantinedSecrets.Set(0)
+	m.InformerLag.Set(0)
+	m.SetXDSCertificateExpiry(time.Now())
+	m.SetAPIServerLatency(0)
 
 	prometheus.NewTimer(m.CacheHandlerOnUpdateSummary).ObserveDuration()
 }
@@ -198,6 +322,18 @@ func (m *Metrics) SetDAGLastRebuilt(ts time.Time) {
 	m.dagRebuildGauge.WithLabelValues().Set(float64(ts.Unix()))
 }
 
+// SetXDSCertificateExpiry records the time at which the
+// certificate Contour serves xDS with expires.
+func (m *Metrics) SetXDSCertificateExpiry(ts time.Time) {
+	m.xdsCertificateExpiryGauge.Set(float64(ts.Unix()))
+}
+
+// SetAPIServerLatency records the latency of the last health
+// check request to the Kubernetes API server.
+func (m *Metrics) SetAPIServerLatency(d time.Duration) {
+	m.apiServerLatencyGauge.Set(d.Seconds())
+}
+
 // SetDAGRebuiltTotal records the total number of times DAG was rebuilt
 func (m *Metrics) SetDAGRebuiltTotal() {
 	m.dagRebuildTotal.Inc()
@@ -253,6 +389,77 @@ func (m *Metrics) SetHTTPProxyMetric(metrics RouteMetric) {
 	}
 }
 
+// SetHTTPProxyRoutesMetric sets the number of routes that each
+// HTTPProxy defines in each virtual host.
+func (m *Metrics) SetHTTPProxyRoutesMetric(metrics map[ProxyMeta]ProxyRoutes) {
+	// Remove the HTTPProxies that no longer define routes of a
+	// virtual host, or whose labels have changed.
+	for meta, last := range m.proxyRoutesMetricCache {
+		lastValues := m.proxyRoutesLabelValues(meta, last.Labels)
+		if cur, ok := metrics[meta]; ok && equalValues(lastValues, m.proxyRoutesLabelValues(meta, cur.Labels)) {
+			continue
+		}
+		m.proxyRoutesGauge.DeleteLabelValues(lastValues...)
+	}
+
+	for meta, value := range metrics {
+		m.proxyRoutesGauge.WithLabelValues(m.proxyRoutesLabelValues(meta, value.Labels)...).Set(float64(value.Routes))
+	}
+
+	m.proxyRoutesMetricCache = metrics
+}
+
+// proxyRoutesLabelValues returns the values of the labels of the
+// proxyRoutesGauge. HTTPProxy labels that are not set are empty.
+func (m *Metrics) proxyRoutesLabelValues(meta ProxyMeta, labels map[string]string) []string {
+	values := []string{meta.Namespace, meta.Name, meta.VHost}
+	for _, key := range m.proxyLabels {
+		values = append(values, labels[key])
+	}
+	return values
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var invalidLabelNameChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// proxyLabelNames returns the Prometheus label names of the keys
+// of HTTPProxy labels. Each is prefixed with "label_", and has the
+// characters that are not valid in label names replaced with "_".
+func proxyLabelNames(keys []string) []string {
+	var names []string
+	for _, key := range keys {
+		names = append(names, "label_"+invalidLabelNameChars.ReplaceAllString(key, "_"))
+	}
+	return names
+}
+
+// SetWebsocketRouteMetric sets the number of routes with websockets
+// enabled of each virtual host.
+func (m *Metrics) SetWebsocketRouteMetric(metrics map[Meta]int) {
+	for meta, value := range metrics {
+		m.websocketRoutesGauge.WithLabelValues(meta.Namespace, meta.VHost).Set(float64(value))
+		delete(m.websocketRouteMetricCache, meta)
+	}
+
+	// Remove the virtual hosts that no longer have websocket routes.
+	for meta := range m.websocketRouteMetricCache {
+		m.websocketRoutesGauge.DeleteLabelValues(meta.Namespace, meta.VHost)
+	}
+
+	m.websocketRouteMetricCache = metrics
+}
+
 // Handler returns a http Handler for a metrics endpoint.
 func Handler(registry *prometheus.Registry) http.Handler {
 	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
@@ -689,3 +689,91 @@ func TestRemoveProxyMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveWebsocketRouteMetric(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	m.SetWebsocketRouteMetric(map[Meta]int{
+		{Namespace: "testns", VHost: "foo.com"}: 2,
+		{Namespace: "testns", VHost: "bar.com"}: 1,
+	})
+	m.SetWebsocketRouteMetric(map[Meta]int{
+		{Namespace: "testns", VHost: "foo.com"}: 3,
+	})
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []*io_prometheus_client.Metric{}
+	for _, mf := range gathering {
+		if mf.GetName() == WebsocketRoutesGauge {
+			got = mf.Metric
+		}
+	}
+
+	want := []*io_prometheus_client.Metric{
+		{
+			Label: []*io_prometheus_client.LabelPair{{
+				Name:  func() *string { i := "namespace"; return &i }(),
+				Value: func() *string { i := "testns"; return &i }(),
+			}, {
+				Name:  func() *string { i := "vhost"; return &i }(),
+				Value: func() *string { i := "foo.com"; return &i }(),
+			}},
+			Gauge: &io_prometheus_client.Gauge{
+				Value: func() *float64 { i := float64(3); return &i }(),
+			},
+		},
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestRemoveHTTPProxyRoutesMetric(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r, "team", "cost-center")
+
+	m.SetHTTPProxyRoutesMetric(map[ProxyMeta]ProxyRoutes{
+		{Namespace: "testns", Name: "foo", VHost: "foo.com"}: {Routes: 2, Labels: map[string]string{"team": "a"}},
+		{Namespace: "testns", Name: "bar", VHost: "bar.com"}: {Routes: 1},
+	})
+	m.SetHTTPProxyRoutesMetric(map[ProxyMeta]ProxyRoutes{
+		{Namespace: "testns", Name: "foo", VHost: "foo.com"}: {Routes: 3, Labels: map[string]string{"team": "b", "cost-center": "42"}},
+	})
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []*io_prometheus_client.Metric{}
+	for _, mf := range gathering {
+		if mf.GetName() == HTTPProxyRoutesGauge {
+			got = mf.Metric
+		}
+	}
+
+	label := func(name, value string) *io_prometheus_client.LabelPair {
+		return &io_prometheus_client.LabelPair{Name: &name, Value: &value}
+	}
+
+	want := []*io_prometheus_client.Metric{
+		{
+			Label: []*io_prometheus_client.LabelPair{
+				label("label_cost_center", "42"),
+				label("label_team", "b"),
+				label("name", "foo"),
+				label("namespace", "testns"),
+				label("vhost", "foo.com"),
+			},
+			Gauge: &io_prometheus_client.Gauge{
+				Value: func() *float64 { i := float64(3); return &i }(),
+			},
+		},
+	}
+
+	assert.Equal(t, want, got)
+}
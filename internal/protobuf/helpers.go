@@ -42,6 +42,13 @@ func UInt32(val uint32) *wrappers.UInt32Value {
 	}
 }
 
+// UInt64 converts a uint64 to a pointer to a wrappers.UInt64Value.
+func UInt64(val uint64) *wrappers.UInt64Value {
+	return &wrappers.UInt64Value{
+		Value: val,
+	}
+}
+
 // UInt32OrDefault returns a wrapped UInt32Value. If val is 0, def is wrapped and returned.
 func UInt32OrDefault(val uint32, def uint32) *wrappers.UInt32Value {
 	switch val {
@@ -62,6 +69,16 @@ func UInt32OrNil(val uint32) *wrappers.UInt32Value {
 	}
 }
 
+// UInt64OrNil returns a wrapped UInt64Value. If val is 0, nil is returned
+func UInt64OrNil(val uint64) *wrappers.UInt64Value {
+	switch val {
+	case 0:
+		return nil
+	default:
+		return UInt64(val)
+	}
+}
+
 // Bool converts a bool to a pointer to a wrappers.BoolValue.
 func Bool(val bool) *wrappers.BoolValue {
 	return &wrappers.BoolValue{
@@ -25,6 +25,11 @@ func TestU32Nil(t *testing.T) {
 	assert.Equal(t, UInt32(1), UInt32OrNil(1))
 }
 
+func TestU64Nil(t *testing.T) {
+	assert.Equal(t, (*wrappers.UInt64Value)(nil), UInt64OrNil(0))
+	assert.Equal(t, UInt64(1), UInt64OrNil(1))
+}
+
 func TestU32Default(t *testing.T) {
 	assert.Equal(t, UInt32(99), UInt32OrDefault(0, 99))
 	assert.Equal(t, UInt32(1), UInt32OrDefault(1, 99))
@@ -0,0 +1,178 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schedule parses cron schedules.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron schedule of the form
+// "minute hour day-of-month month day-of-week",
+// evaluated in UTC.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar record whether the day of month and
+	// day of week fields are "*". If neither is, a day matches
+	// if either field matches, as in cron.
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	name     string
+	min, max int
+}
+
+var (
+	minutes     = bounds{"minute", 0, 59}
+	hours       = bounds{"hour", 0, 23}
+	daysOfMonth = bounds{"day of month", 1, 31}
+	months      = bounds{"month", 1, 12}
+	// Both 0 and 7 are Sunday.
+	daysOfWeek = bounds{"day of week", 0, 7}
+)
+
+// Parse parses a cron schedule of five space separated fields:
+// minute, hour, day of month, month and day of week. Each field
+// is "*" or a comma separated list of values or ranges, such as
+// "1-5", optionally with a step, such as "*/15" or "0-30/10".
+func Parse(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields, found %d", expr, len(fields))
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], daysOfMonth); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], daysOfWeek); err != nil {
+		return nil, err
+	}
+
+	// Fold Sunday as 7 into Sunday as 0.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return &s, nil
+}
+
+// parseField returns the set of values of field as a bitmask.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", b.name, part)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := b.min, b.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.Index(rng, "-")
+			var err error
+			if lo, err = parseValue(rng[:i], b); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(rng[i+1:], b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", b.name, rng)
+			}
+		default:
+			var err error
+			if lo, err = parseValue(rng, b); err != nil {
+				return 0, err
+			}
+			// A single value with a step, such as "5/15",
+			// runs to the end of the field's range.
+			if step == 1 {
+				hi = lo
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("invalid %s %q, must be in the range %d-%d", b.name, s, b.min, b.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that matches the schedule,
+// or the zero time if the schedule does not match within the next
+// five years, as with "0 0 30 2 *".
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	if s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,122 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		expr    string
+		wantErr bool
+	}{
+		"every minute":        {expr: "* * * * *"},
+		"lists and ranges":    {expr: "0,30 9-17 * * 1-5"},
+		"steps":               {expr: "*/15 0-12/2 1/7 * *"},
+		"sunday as 7":         {expr: "0 0 * * 7"},
+		"too few fields":      {expr: "* * * *", wantErr: true},
+		"too many fields":     {expr: "* * * * * *", wantErr: true},
+		"minute out of range": {expr: "60 * * * *", wantErr: true},
+		"day out of range":    {expr: "* * 0 * *", wantErr: true},
+		"reversed range":      {expr: "* 17-9 * * *", wantErr: true},
+		"zero step":           {expr: "*/0 * * * *", wantErr: true},
+		"names":               {expr: "* * * JAN MON", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			assert.Equal(t, tc.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return d
+	}
+
+	tests := map[string]struct {
+		expr string
+		from string
+		want string
+	}{
+		"next minute": {
+			expr: "* * * * *",
+			from: "2021-03-01T10:00:30Z",
+			want: "2021-03-01T10:01:00Z",
+		},
+		"strictly after": {
+			expr: "0 10 * * *",
+			from: "2021-03-01T10:00:00Z",
+			want: "2021-03-02T10:00:00Z",
+		},
+		"later today": {
+			expr: "30 17 * * *",
+			from: "2021-03-01T10:00:00Z",
+			want: "2021-03-01T17:30:00Z",
+		},
+		"next weekday": {
+			// 2021-03-05 is a Friday.
+			expr: "0 9 * * 1-5",
+			from: "2021-03-05T12:00:00Z",
+			want: "2021-03-08T09:00:00Z",
+		},
+		"sunday as 7": {
+			expr: "0 0 * * 7",
+			from: "2021-03-01T00:00:00Z",
+			want: "2021-03-07T00:00:00Z",
+		},
+		"day of month or day of week": {
+			// The 15th, or any Monday.
+			expr: "0 0 15 * 1",
+			from: "2021-03-09T00:00:00Z",
+			want: "2021-03-15T00:00:00Z",
+		},
+		"next year": {
+			expr: "0 0 1 1 *",
+			from: "2021-03-01T00:00:00Z",
+			want: "2022-01-01T00:00:00Z",
+		},
+		"leap day": {
+			expr: "0 0 29 2 *",
+			from: "2021-03-01T00:00:00Z",
+			want: "2024-02-29T00:00:00Z",
+		},
+		"converted to UTC": {
+			expr: "0 12 * * *",
+			from: "2021-03-01T10:00:00+05:00",
+			want: "2021-03-01T12:00:00Z",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, date(tc.want), s.Next(date(tc.from)))
+		})
+	}
+
+	s, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(date("2021-03-01T00:00:00Z")).IsZero())
+}
@@ -0,0 +1,117 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sorter
+
+import (
+	"sort"
+
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/pkg/config"
+)
+
+// ForRoutes returns a sort.Interface that orders
+// routes with the given ordering.
+func ForRoutes(routes []*dag.Route, ordering config.RouteOrderingType) sort.Interface {
+	if ordering == config.SpecificityRouteOrdering {
+		return specificityRouteSorter(routes)
+	}
+	return routeSorter(routes)
+}
+
+// Sorts the given Route slice in place with SpecificityRouteOrdering.
+// The HeaderMatch slice of each route must already be sorted.
+type specificityRouteSorter []*dag.Route
+
+func (s specificityRouteSorter) Len() int      { return len(s) }
+func (s specificityRouteSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s specificityRouteSorter) Less(i, j int) bool {
+	a, b := s[i], s[j]
+
+	rankA, valueA := pathMatchOf(a)
+	rankB, valueB := pathMatchOf(b)
+
+	switch {
+	case rankA != rankB:
+		return rankA < rankB
+	case rankA != regexRank && len(valueA) != len(valueB):
+		// Sort longest path match first. The length of a regex
+		// says nothing about what it matches, so regex matches
+		// are only ordered textually.
+		return len(valueA) > len(valueB)
+	case valueA != valueB:
+		return valueA > valueB
+	}
+
+	// Segment prefixes sort first as they are more specific.
+	if segmentA, segmentB := isSegmentPrefix(a), isSegmentPrefix(b); segmentA != segmentB {
+		return segmentA
+	}
+
+	// Sort routes with the most header match conditions first.
+	if len(a.HeaderMatchConditions) != len(b.HeaderMatchConditions) {
+		return len(a.HeaderMatchConditions) > len(b.HeaderMatchConditions)
+	}
+
+	if cmp := compareHeaderMatchConditions(a.HeaderMatchConditions, b.HeaderMatchConditions); cmp != 0 {
+		return cmp < 0
+	}
+
+	// Sort the oldest route first, so that a newer object
+	// can't take over requests that an older one matches.
+	return a.CreationTimestamp.Before(b.CreationTimestamp)
+}
+
+const regexRank = 1
+
+// pathMatchOf returns the rank of the path match type of the route,
+// lowest first, and the path match value.
+func pathMatchOf(r *dag.Route) (int, string) {
+	switch c := r.PathMatchCondition.(type) {
+	case *dag.ExactMatchCondition:
+		return 0, c.Path
+	case *dag.RegexMatchCondition:
+		return regexRank, c.Regex
+	case *dag.PrefixMatchCondition:
+		return 2, c.Prefix
+	default:
+		return 3, ""
+	}
+}
+
+func isSegmentPrefix(r *dag.Route) bool {
+	c, ok := r.PathMatchCondition.(*dag.PrefixMatchCondition)
+	return ok && c.PrefixMatchType == dag.PrefixMatchSegment
+}
+
+// compareHeaderMatchConditions compares two sorted slices of header
+// match conditions of the same length by their first differing
+// condition. It returns -1 if lhs sorts first, 1 if rhs sorts first
+// and 0 if neither does.
+func compareHeaderMatchConditions(lhs, rhs []dag.HeaderMatchCondition) int {
+	pair := make([]dag.HeaderMatchCondition, 2)
+
+	for i := range lhs {
+		pair[0] = lhs[i]
+		pair[1] = rhs[i]
+
+		switch {
+		case headerMatchConditionSorter(pair).Less(0, 1):
+			return -1
+		case headerMatchConditionSorter(pair).Less(1, 0):
+			return 1
+		}
+	}
+
+	return 0
+}
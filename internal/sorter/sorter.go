@@ -259,9 +259,9 @@ func (s filterChainSorter) Less(i, j int) bool {
 		return true
 	}
 
-	// The ServerNames field will only ever have a single entry
-	// in our FilterChain config, so it's okay to only sort
-	// on the first slice entry.
+	// A server name is only ever matched by a single FilterChain,
+	// and chains with several names list them in order, so it's
+	// okay to only sort on the first slice entry.
 	return s[i].FilterChainMatch.ServerNames[0] < s[j].FilterChainMatch.ServerNames[0]
 }
 
@@ -17,6 +17,7 @@ import (
 	"math/rand"
 	"sort"
 	"testing"
+	"time"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
@@ -26,6 +27,7 @@ import (
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -201,6 +203,92 @@ func TestSortRoutesPathMatch(t *testing.T) {
 	assert.Equal(t, want, have)
 }
 
+func TestSortRoutesPathMatchSpecificity(t *testing.T) {
+	want := []*dag.Route{
+		// Note that exact matches sort before regex matches.
+		{
+			PathMatchCondition: matchExact("/aab/a"),
+		},
+		{
+			PathMatchCondition: matchExact("/aab"),
+		},
+		{
+			PathMatchCondition: matchExact("/aaa"),
+		},
+		// Note that regex matches sort before prefix matches.
+		// Regex matches are ordered textually, not by length.
+		{
+			PathMatchCondition: matchRegex("/v2"),
+		},
+		{
+			PathMatchCondition: matchRegex("/this/is/the/longest"),
+		},
+		{
+			PathMatchCondition: matchRegex(`/foo((\/).*)*`),
+		},
+		{
+			PathMatchCondition: matchRegex("/"),
+		},
+		{
+			PathMatchCondition: matchRegex("."),
+		},
+		// Longer prefixes sort first, regardless of their text.
+		{
+			PathMatchCondition: matchPrefixSegment("/path/prefix/a"),
+		},
+		{
+			PathMatchCondition: matchPrefixString("/path/prefix/a"),
+		},
+		// Prefix segment matches sort before string matches.
+		{
+			PathMatchCondition: matchPrefixSegment("/path/prefix2"),
+		},
+		{
+			PathMatchCondition: matchPrefixString("/path/prefix2"),
+		},
+		{
+			PathMatchCondition: matchPrefixString("/path/prefix"),
+		},
+		{
+			PathMatchCondition: matchPrefixSegment("/path/p"),
+		},
+	}
+
+	have := shuffleRoutes(want)
+
+	sort.Stable(ForRoutes(have, config.SpecificityRouteOrdering))
+	assert.Equal(t, want, have)
+}
+
+func TestSortRoutesCreationTimestamp(t *testing.T) {
+	older := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	want := []*dag.Route{
+		{
+			PathMatchCondition: matchPrefixString("/path"),
+			HeaderMatchConditions: []dag.HeaderMatchCondition{
+				presentHeader("header-name"),
+			},
+			CreationTimestamp: newer,
+		},
+		{
+			PathMatchCondition: matchPrefixString("/path"),
+			CreationTimestamp:  older,
+		},
+		{
+			PathMatchCondition: matchPrefixString("/path"),
+			CreationTimestamp:  newer,
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		have := shuffleRoutes(want)
+		sort.Stable(ForRoutes(have, config.SpecificityRouteOrdering))
+		assert.Equal(t, want, have)
+	}
+}
+
 func TestSortRoutesLongestHeaders(t *testing.T) {
 	want := []*dag.Route{
 		{
@@ -15,6 +15,7 @@ package status
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
@@ -44,6 +45,10 @@ type ProxyUpdate struct {
 	// keyed by the Type (since that's what the apiserver will end up
 	// doing.)
 	Conditions map[ConditionType]*projectcontour.DetailedCondition
+
+	// EffectiveRoutes holds the merged match conditions of the
+	// routes of the object, for each root that includes it.
+	EffectiveRoutes []projectcontour.EffectiveRoute
 }
 
 // ProxyAccessor returns a ProxyUpdate that allows a client to build up a list of
@@ -64,13 +69,24 @@ func (c *Cache) ProxyAccessor(proxy *contour_api_v1.HTTPProxy) (*ProxyUpdate, fu
 	}
 }
 
+// CommittedProxyUpdate returns the ProxyUpdate committed for the named
+// HTTPProxy, or nil if none was committed. It lets processors that run
+// after the HTTPProxy processor add conditions to the HTTPProxy.
+func (c *Cache) CommittedProxyUpdate(name types.NamespacedName) *ProxyUpdate {
+	return c.proxyUpdates[name]
+}
+
 func (c *Cache) commitProxy(pu *ProxyUpdate) {
 	if len(pu.Conditions) == 0 {
 		return
 	}
 
-	_, ok := c.proxyUpdates[pu.Fullname]
+	existing, ok := c.proxyUpdates[pu.Fullname]
 	if ok {
+		// Keep the effective routes recorded when the object was
+		// reached through other includes.
+		pu.EffectiveRoutes = append(existing.EffectiveRoutes, pu.EffectiveRoutes...)
+
 		// When we're committing, if we already have a Valid Condition with an error, and we're trying to
 		// set the object back to Valid, skip the commit, as we've visited too far down.
 		// If this is removed, the status reporting for when a parent delegates to a child that delegates to itself
@@ -78,6 +94,7 @@ func (c *Cache) commitProxy(pu *ProxyUpdate) {
 		// TODO(youngnick)#2968: This issue has more details.
 		if c.proxyUpdates[pu.Fullname].Conditions[ValidCondition].Status == contour_api_v1.ConditionFalse {
 			if pu.Conditions[ValidCondition].Status == contour_api_v1.ConditionTrue {
+				existing.EffectiveRoutes = pu.EffectiveRoutes
 				return
 			}
 		}
@@ -136,6 +153,13 @@ func (pu *ProxyUpdate) Mutate(obj interface{}) interface{} {
 
 	}
 
+	// Order the effective routes by root, so that the status
+	// doesn't change with the order the roots are processed in.
+	proxy.Status.EffectiveRoutes = append([]projectcontour.EffectiveRoute(nil), pu.EffectiveRoutes...)
+	sort.SliceStable(proxy.Status.EffectiveRoutes, func(i, j int) bool {
+		return proxy.Status.EffectiveRoutes[i].Fqdn < proxy.Status.EffectiveRoutes[j].Fqdn
+	})
+
 	// Set the old status fields using the Valid DetailedCondition's details.
 	// Other conditions are not relevant for these two fields.
 	validCond := proxy.Status.GetConditionFor(projectcontour.ValidConditionType)
@@ -28,6 +28,9 @@ const ResourceTLSRoute = "tlsroutes"
 
 const ConditionNotImplemented gatewayapi_v1alpha1.RouteConditionType = "NotImplemented"
 const ConditionResolvedRefs gatewayapi_v1alpha1.RouteConditionType = "ResolvedRefs"
+const ConditionIngressPolicyPermitted gatewayapi_v1alpha1.RouteConditionType = "IngressPolicyPermitted"
+const ConditionUniqueClusterNames gatewayapi_v1alpha1.RouteConditionType = "UniqueClusterNames"
+const ConditionAcceptedHostnames gatewayapi_v1alpha1.RouteConditionType = "AcceptedHostnames"
 
 type RouteReasonType string
 
@@ -39,6 +42,10 @@ const ReasonDegraded RouteReasonType = "Degraded"
 const ReasonValid RouteReasonType = "Valid"
 const ReasonErrorsExist RouteReasonType = "ErrorsExist"
 const ReasonGatewayAllowMismatch RouteReasonType = "GatewayAllowMismatch"
+const ReasonNamespaceBlocked RouteReasonType = "NamespaceBlocked"
+const ReasonIngressPolicyNotPermitted RouteReasonType = "IngressPolicyNotPermitted"
+const ReasonClusterNameCollision RouteReasonType = "ClusterNameCollision"
+const ReasonHealthVirtualHostConflict RouteReasonType = "HealthVirtualHostConflict"
 
 // clock is used to set lastTransitionTime on status conditions.
 var clock utilclock.Clock = utilclock.RealClock{}
@@ -92,6 +99,26 @@ func (c *Cache) RouteConditionsAccessor(nsName types.NamespacedName, generation
 	}
 }
 
+// CommittedRouteUpdate returns the RouteConditionsUpdate committed for
+// the named route of the given resource, or nil if none was committed.
+// It lets processors that run after the Gateway API processor add
+// conditions to the route.
+func (c *Cache) CommittedRouteUpdate(name types.NamespacedName, resource string) *RouteConditionsUpdate {
+	if pu, ok := c.routeUpdates[name]; ok && pu.Resource == resource {
+		return pu
+	}
+	return nil
+}
+
+// AddError adds a False condition of the given type to the route,
+// and replaces its Admitted condition to report that errors exist.
+func (routeUpdate *RouteConditionsUpdate) AddError(cond gatewayapi_v1alpha1.RouteConditionType, reason RouteReasonType, message string) {
+	routeUpdate.AddCondition(cond, metav1.ConditionFalse, reason, message)
+
+	delete(routeUpdate.Conditions, gatewayapi_v1alpha1.ConditionRouteAdmitted)
+	routeUpdate.AddCondition(gatewayapi_v1alpha1.ConditionRouteAdmitted, metav1.ConditionFalse, ReasonErrorsExist, "Errors found, check other Conditions for details.")
+}
+
 func (c *Cache) commitRoute(pu *RouteConditionsUpdate) {
 	if len(pu.Conditions) == 0 {
 		return
@@ -66,8 +66,12 @@ func DurationSetting(duration time.Duration) Setting {
 //	- an empty string means "use the default".
 //	- any valid representation of "0" means "use the default".
 //	- a valid Go duration string is used as the specific timeout value.
-//	- "infinity" or "infinite" means "disable the timeout".
+//	- "infinity", "infinite" or "disabled" means "disable the timeout".
 //	- any other value results in an error.
+//
+// Parse is used for all timeouts in HTTPProxy, ExtensionService, Ingress
+// annotations and the configuration file, so that they accept the same
+// values and report the same errors.
 func Parse(timeout string) (Setting, error) {
 	// An empty string is interpreted as no explicit timeout specified, so
 	// use the Envoy default.
@@ -75,15 +79,18 @@ func Parse(timeout string) (Setting, error) {
 		return DefaultSetting(), nil
 	}
 
-	// Interpret "infinity" or "infinite" as a disabled/infinite timeout, which envoy config
-	// usually expects as an explicit value of 0.
-	if timeout == "infinity" || timeout == "infinite" {
+	// Interpret "infinity", "infinite" or "disabled" as a disabled/infinite
+	// timeout, which envoy config usually expects as an explicit value of 0.
+	if isDisabled(timeout) {
 		return DisabledSetting(), nil
 	}
 
 	d, err := time.ParseDuration(timeout)
 	if err != nil {
-		return Setting{}, fmt.Errorf("unable to parse timeout string %q: %w", timeout, err)
+		return Setting{}, parseError(timeout)
+	}
+	if d < 0 {
+		return Setting{}, parseError(timeout)
 	}
 
 	if d == 0 {
@@ -93,6 +100,22 @@ func Parse(timeout string) (Setting, error) {
 	return DurationSetting(d), nil
 }
 
+// isDisabled returns true if timeout is one of the strings
+// that disable a timeout.
+func isDisabled(timeout string) bool {
+	switch timeout {
+	case "infinity", "infinite", "disabled":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseError returns the error for a timeout string that can't be parsed.
+func parseError(timeout string) error {
+	return fmt.Errorf("invalid timeout %q: must be a duration such as \"10s\", or \"infinity\" to disable the timeout", timeout)
+}
+
 // ParseMaxAge parses string representations of "max age" values used mostly
 // in cache related settings. An example of this is the MaxAge field of the
 // CORS policy:
@@ -106,8 +129,8 @@ func ParseMaxAge(timeout string) (Setting, error) {
 	}
 
 	d, err := time.ParseDuration(timeout)
-	if err != nil {
-		return Setting{}, fmt.Errorf("unable to parse timeout string %q: %w", timeout, err)
+	if err != nil || d < 0 {
+		return Setting{}, fmt.Errorf("invalid max age %q: must be a duration such as \"10s\"", timeout)
 	}
 	if d == 0 {
 		return DisabledSetting(), nil
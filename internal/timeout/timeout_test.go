@@ -45,6 +45,10 @@ func TestParse(t *testing.T) {
 			duration: "infinite",
 			want:     DisabledSetting(),
 		},
+		"disabled": {
+			duration: "disabled",
+			want:     DisabledSetting(),
+		},
 		"10 seconds": {
 			duration: "10s",
 			want:     DurationSetting(10 * time.Second),
@@ -54,6 +58,11 @@ func TestParse(t *testing.T) {
 			want:     DefaultSetting(),
 			wantErr:  true,
 		},
+		"negative": {
+			duration: "-10s",
+			want:     DefaultSetting(),
+			wantErr:  true,
+		},
 	}
 
 	for name, tc := range tests {
@@ -69,6 +78,11 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseError(t *testing.T) {
+	_, err := Parse("10")
+	assert.EqualError(t, err, `invalid timeout "10": must be a duration such as "10s", or "infinity" to disable the timeout`)
+}
+
 func TestParseMaxAge(t *testing.T) {
 	tests := map[string]struct {
 		duration string
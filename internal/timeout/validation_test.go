@@ -24,7 +24,7 @@ import (
 // for timeout strings matches the behavior of Parse().
 func TestKubebuilderValidation(t *testing.T) {
 	// keep in sync with kubebuilder annotations in apis/projectcontour/v1/httpproxy.go
-	regex := regexp.MustCompile(`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`)
+	regex := regexp.MustCompile(`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite|disabled)$`)
 
 	for tc, valid := range map[string]bool{
 		// valid duration strings across all allowed units
@@ -61,14 +61,16 @@ func TestKubebuilderValidation(t *testing.T) {
 		"1s2.34h1m7.23s0.21us1.ns": true,
 
 		// invalid duration strings
-		"abc":      false,
-		"1":        false,
-		"9,25s":    false,
-		"disabled": false,
+		"abc":     false,
+		"1":       false,
+		"9,25s":   false,
+		"-1s":     false,
+		"infinit": false,
 
 		// magic strings
 		"infinity": true,
 		"infinite": true,
+		"disabled": true,
 	} {
 		regexMatches := regex.MatchString(tc)
 		_, parseErr := Parse(tc)
@@ -27,6 +27,14 @@ const DefaultClassName = "contour"
 // or spec ingress class name matche the passed in ingress class name.
 // Annotations take precedence over spec field if both are set.
 func MatchesIngressClassName(obj *networking_v1.Ingress, ingressClassName string) bool {
+	return MatchesIngressClassNameWith(obj, ingressClassName, "")
+}
+
+// MatchesIngressClassNameWith returns true if the passed in Ingress
+// matches the passed in ingress class name as MatchesIngressClassName
+// does, except that an Ingress with no class set is matched as
+// classMatching selects.
+func MatchesIngressClassNameWith(obj *networking_v1.Ingress, ingressClassName string, classMatching annotation.ClassMatching) bool {
 	annotationClass := annotation.IngressClass(obj)
 	specClass := pointer.StringPtrDerefOr(obj.Spec.IngressClassName, "")
 
@@ -44,6 +52,6 @@ func MatchesIngressClassName(obj *networking_v1.Ingress, ingressClassName string
 		return specClass == classToMatch
 	}
 
-	// Matches if class is not set.
-	return ingressClassName == ""
+	// Matches if class is not set, as selected.
+	return classMatching.MatchesClassless(ingressClassName)
 }
@@ -16,6 +16,7 @@ package ingress
 import (
 	"testing"
 
+	"github.com/projectcontour/contour/internal/annotation"
 	"github.com/stretchr/testify/assert"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -106,3 +107,21 @@ func TestMatchesIngressClassNameIngressV1(t *testing.T) {
 		},
 	}, "something"))
 }
+
+func TestMatchesIngressClassNameWithClassMatching(t *testing.T) {
+	classless := &networking_v1.Ingress{}
+	classed := &networking_v1.Ingress{
+		Spec: networking_v1.IngressSpec{
+			IngressClassName: pointer.StringPtr("contour"),
+		},
+	}
+
+	// Strict matching never matches an Ingress with no class set.
+	assert.False(t, MatchesIngressClassNameWith(classless, "", annotation.ClassMatchingStrict))
+	assert.False(t, MatchesIngressClassNameWith(classless, "something", annotation.ClassMatchingStrict))
+	assert.True(t, MatchesIngressClassNameWith(classed, "", annotation.ClassMatchingStrict))
+	// Permissive matching always matches an Ingress with no class set.
+	assert.True(t, MatchesIngressClassNameWith(classless, "", annotation.ClassMatchingPermissive))
+	assert.True(t, MatchesIngressClassNameWith(classless, "something", annotation.ClassMatchingPermissive))
+	assert.False(t, MatchesIngressClassNameWith(classed, "something", annotation.ClassMatchingPermissive))
+}
@@ -14,25 +14,76 @@
 package xds
 
 import (
+	"context"
+	"strings"
+
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// StreamsRateLimitedTotal is the name of the counter of xDS
+	// streams rejected by the stream rate limiter.
+	StreamsRateLimitedTotal = "contour_grpc_streams_ratelimited_total"
 )
 
+// Interceptor intercepts the streams and unary calls made to a gRPC server.
+type Interceptor interface {
+	StreamServerInterceptor() grpc.StreamServerInterceptor
+	UnaryServerInterceptor() grpc.UnaryServerInterceptor
+}
+
 // NewServer If registry is non-nil gRPC server metrics will be automatically
-// configured and enabled.
-func NewServer(registry *prometheus.Registry, opts ...grpc.ServerOption) *grpc.Server {
+// configured and enabled. If limiter is non-nil, new streams are rejected
+// with codes.ResourceExhausted when they exceed its rate. If authorizer is
+// non-nil, it intercepts every stream and unary call after the metrics.
+func NewServer(registry *prometheus.Registry, limiter *rate.Limiter, authorizer Interceptor, opts ...grpc.ServerOption) *grpc.Server {
 	var metrics *grpc_prometheus.ServerMetrics
+	var rateLimited *prometheus.CounterVec
+	var streamInterceptors []grpc.StreamServerInterceptor
+	var unaryInterceptors []grpc.UnaryServerInterceptor
 
 	// TODO: Decouple registry from this.
 	if registry != nil {
 		metrics = grpc_prometheus.NewServerMetrics()
 		registry.MustRegister(metrics)
 
-		opts = append(opts,
-			grpc.StreamInterceptor(metrics.StreamServerInterceptor()),
-			grpc.UnaryInterceptor(metrics.UnaryServerInterceptor()),
-		)
+		if limiter != nil {
+			rateLimited = prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: StreamsRateLimitedTotal,
+					Help: "Total number of xDS streams rejected because they exceeded the stream rate limit.",
+				},
+				[]string{"grpc_service", "grpc_method"},
+			)
+			registry.MustRegister(rateLimited)
+		}
+	}
+
+	if limiter != nil {
+		streamInterceptors = append(streamInterceptors, rateLimitStreams(limiter, rateLimited))
+	}
+
+	if metrics != nil {
+		streamInterceptors = append(streamInterceptors, metrics.StreamServerInterceptor())
+		unaryInterceptors = append(unaryInterceptors, metrics.UnaryServerInterceptor())
+	}
+
+	if authorizer != nil {
+		streamInterceptors = append(streamInterceptors, authorizer.StreamServerInterceptor())
+		unaryInterceptors = append(unaryInterceptors, authorizer.UnaryServerInterceptor())
+	}
+
+	if len(streamInterceptors) > 0 {
+		opts = append(opts, grpc.StreamInterceptor(chainStreamInterceptors(streamInterceptors)))
+	}
+
+	if len(unaryInterceptors) > 0 {
+		opts = append(opts, grpc.UnaryInterceptor(chainUnaryInterceptors(unaryInterceptors)))
 	}
 
 	g := grpc.NewServer(opts...)
@@ -43,3 +94,58 @@ func NewServer(registry *prometheus.Registry, opts ...grpc.ServerOption) *grpc.S
 
 	return g
 }
+
+// chainStreamInterceptors returns a grpc.StreamServerInterceptor that
+// runs interceptors in order, each one wrapping the next.
+func chainStreamInterceptors(interceptors []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// chainUnaryInterceptors returns a grpc.UnaryServerInterceptor that
+// runs interceptors in order, each one wrapping the next.
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitStreams returns a grpc.StreamServerInterceptor that rejects
+// streams that limiter does not allow, counting them in rejected if it
+// is non-nil.
+func rateLimitStreams(limiter *rate.Limiter, rejected *prometheus.CounterVec) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !limiter.Allow() {
+			if rejected != nil {
+				service, method := splitMethodName(info.FullMethod)
+				rejected.WithLabelValues(service, method).Inc()
+			}
+			return status.Errorf(codes.ResourceExhausted, "too many new streams, retry later")
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// splitMethodName splits a gRPC method name of the form
+// "/package.service/method" into its service and method.
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", "unknown"
+}
@@ -0,0 +1,78 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimitStreams(t *testing.T) {
+	rejected := prometheus.NewCounterVec(prometheus.CounterOpts{Name: StreamsRateLimitedTotal}, []string{"grpc_service", "grpc_method"})
+	interceptor := rateLimitStreams(rate.NewLimiter(rate.Limit(1), 2), rejected)
+
+	info := &grpc.StreamServerInfo{
+		FullMethod: "/envoy.service.cluster.v3.ClusterDiscoveryService/StreamClusters",
+	}
+	handled := 0
+	handler := func(interface{}, grpc.ServerStream) error {
+		handled++
+		return nil
+	}
+
+	// The burst of two streams is allowed, the third is rejected.
+	assert.NoError(t, interceptor(nil, nil, info, handler))
+	assert.NoError(t, interceptor(nil, nil, info, handler))
+	err := interceptor(nil, nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	assert.Equal(t, 2, handled)
+	assert.Equal(t, float64(1), testutil.ToFloat64(rejected.WithLabelValues("envoy.service.cluster.v3.ClusterDiscoveryService", "StreamClusters")))
+}
+
+func TestSplitMethodName(t *testing.T) {
+	service, method := splitMethodName("/envoy.service.discovery.v3.AggregatedDiscoveryService/StreamAggregatedResources")
+	assert.Equal(t, "envoy.service.discovery.v3.AggregatedDiscoveryService", service)
+	assert.Equal(t, "StreamAggregatedResources", method)
+
+	service, method = splitMethodName("bogus")
+	assert.Equal(t, "unknown", service)
+	assert.Equal(t, "unknown", method)
+}
+
+func TestChainStreamInterceptors(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.StreamServerInterceptor {
+		return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			calls = append(calls, name)
+			return handler(srv, ss)
+		}
+	}
+
+	chain := chainStreamInterceptors([]grpc.StreamServerInterceptor{interceptor("first"), interceptor("second")})
+	err := chain(nil, nil, &grpc.StreamServerInfo{}, func(interface{}, grpc.ServerStream) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
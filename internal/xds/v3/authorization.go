@@ -0,0 +1,191 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NodeMatch matches an Envoy node ID or cluster name, either
+// exactly or by prefix.
+type NodeMatch struct {
+	Exact  string
+	Prefix string
+}
+
+// Matches returns true if s matches m.
+func (m NodeMatch) Matches(s string) bool {
+	if m.Exact != "" {
+		return s == m.Exact
+	}
+	return m.Prefix != "" && strings.HasPrefix(s, m.Prefix)
+}
+
+// NodeAuthorizer authorizes xDS requests by the Envoy node that
+// makes them. Requests without a node are rejected.
+type NodeAuthorizer struct {
+	logrus.FieldLogger
+
+	// AllowedNodes are the node IDs permitted to make requests.
+	// If empty, any node ID is permitted.
+	AllowedNodes []NodeMatch
+
+	// AllowedClusters are the node cluster names permitted to make
+	// requests. If empty, any cluster name is permitted.
+	AllowedClusters []NodeMatch
+
+	// BindSAN requires the node ID or cluster name to match a DNS
+	// or URI subject alternative name of the client certificate.
+	BindSAN bool
+}
+
+// nodeRequest is implemented by the xDS request messages.
+type nodeRequest interface {
+	GetNode() *envoy_core_v3.Node
+}
+
+// Authorize returns a codes.PermissionDenied error if node may not
+// make requests, given the client certificates it presented.
+func (a *NodeAuthorizer) Authorize(node *envoy_core_v3.Node, certs []*x509.Certificate) error {
+	if node == nil {
+		return status.Error(codes.PermissionDenied, "request has no node")
+	}
+
+	if len(a.AllowedNodes) > 0 && !matchesAny(a.AllowedNodes, node.Id) {
+		return status.Errorf(codes.PermissionDenied, "node ID %q is not permitted", node.Id)
+	}
+
+	if len(a.AllowedClusters) > 0 && !matchesAny(a.AllowedClusters, node.Cluster) {
+		return status.Errorf(codes.PermissionDenied, "node cluster %q is not permitted", node.Cluster)
+	}
+
+	if a.BindSAN {
+		if len(certs) == 0 {
+			return status.Errorf(codes.PermissionDenied, "node ID %q did not present a client certificate", node.Id)
+		}
+		if !hasSAN(certs[0], node.Id) && !hasSAN(certs[0], node.Cluster) {
+			return status.Errorf(codes.PermissionDenied, "client certificate is not valid for node ID %q or cluster %q", node.Id, node.Cluster)
+		}
+	}
+
+	return nil
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor that
+// authorizes each request received on a stream that carries a node.
+// The first request of a stream must carry a node.
+func (a *NodeAuthorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &authorizedStream{ServerStream: ss, authorizer: a})
+	}
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that
+// authorizes each request.
+func (a *NodeAuthorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if r, ok := req.(nodeRequest); ok {
+			if err := a.authorize(ctx, r.GetNode()); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authorize authorizes node with the client certificates of the
+// gRPC peer in ctx, logging rejected nodes.
+func (a *NodeAuthorizer) authorize(ctx context.Context, node *envoy_core_v3.Node) error {
+	var certs []*x509.Certificate
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			certs = info.State.PeerCertificates
+		}
+	}
+
+	err := a.Authorize(node, certs)
+	if err != nil && a.FieldLogger != nil {
+		a.WithError(err).Error("rejected unauthorized xDS request")
+	}
+	return err
+}
+
+// authorizedStream is a grpc.ServerStream that authorizes the
+// requests it receives.
+type authorizedStream struct {
+	grpc.ServerStream
+	authorizer *NodeAuthorizer
+	authorized bool
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	r, ok := m.(nodeRequest)
+	if !ok {
+		return nil
+	}
+
+	// Envoy can be configured to send its node on the first
+	// request of a stream only.
+	if r.GetNode() == nil && s.authorized {
+		return nil
+	}
+
+	if err := s.authorizer.authorize(s.Context(), r.GetNode()); err != nil {
+		return err
+	}
+	s.authorized = true
+	return nil
+}
+
+func matchesAny(matches []NodeMatch, s string) bool {
+	for _, m := range matches {
+		if m.Matches(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSAN returns true if name is one of the DNS or URI subject
+// alternative names of cert.
+func hasSAN(cert *x509.Certificate, name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, dns := range cert.DNSNames {
+		if dns == name {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == name {
+			return true
+		}
+	}
+	return false
+}
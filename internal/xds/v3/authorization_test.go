@@ -0,0 +1,175 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNodeAuthorizerAuthorize(t *testing.T) {
+	cert := &x509.Certificate{
+		DNSNames: []string{"envoy"},
+		URIs:     []*url.URL{{Scheme: "spiffe", Host: "cluster.local", Path: "/ns/projectcontour"}},
+	}
+
+	tests := map[string]struct {
+		authorizer NodeAuthorizer
+		node       *envoy_core_v3.Node
+		certs      []*x509.Certificate
+		want       bool
+	}{
+		"no restrictions": {
+			node: &envoy_core_v3.Node{Id: "envoy-1", Cluster: "projectcontour"},
+			want: true,
+		},
+		"no node": {
+			want: false,
+		},
+		"exact node ID": {
+			authorizer: NodeAuthorizer{AllowedNodes: []NodeMatch{{Exact: "envoy-1"}}},
+			node:       &envoy_core_v3.Node{Id: "envoy-1"},
+			want:       true,
+		},
+		"exact node ID mismatch": {
+			authorizer: NodeAuthorizer{AllowedNodes: []NodeMatch{{Exact: "envoy-1"}}},
+			node:       &envoy_core_v3.Node{Id: "envoy-10"},
+			want:       false,
+		},
+		"prefix node ID": {
+			authorizer: NodeAuthorizer{AllowedNodes: []NodeMatch{{Exact: "debug"}, {Prefix: "envoy-"}}},
+			node:       &envoy_core_v3.Node{Id: "envoy-7d9c"},
+			want:       true,
+		},
+		"cluster allowed": {
+			authorizer: NodeAuthorizer{AllowedClusters: []NodeMatch{{Exact: "projectcontour"}}},
+			node:       &envoy_core_v3.Node{Id: "envoy-1", Cluster: "projectcontour"},
+			want:       true,
+		},
+		"cluster not allowed": {
+			authorizer: NodeAuthorizer{AllowedClusters: []NodeMatch{{Exact: "projectcontour"}}},
+			node:       &envoy_core_v3.Node{Id: "envoy-1", Cluster: "other-fleet"},
+			want:       false,
+		},
+		"node ID allowed but cluster not allowed": {
+			authorizer: NodeAuthorizer{
+				AllowedNodes:    []NodeMatch{{Prefix: "envoy-"}},
+				AllowedClusters: []NodeMatch{{Exact: "projectcontour"}},
+			},
+			node: &envoy_core_v3.Node{Id: "envoy-1", Cluster: "other-fleet"},
+			want: false,
+		},
+		"SAN matches node ID": {
+			authorizer: NodeAuthorizer{BindSAN: true},
+			node:       &envoy_core_v3.Node{Id: "envoy", Cluster: "projectcontour"},
+			certs:      []*x509.Certificate{cert},
+			want:       true,
+		},
+		"SAN matches node cluster": {
+			authorizer: NodeAuthorizer{BindSAN: true},
+			node:       &envoy_core_v3.Node{Id: "envoy-1", Cluster: "spiffe://cluster.local/ns/projectcontour"},
+			certs:      []*x509.Certificate{cert},
+			want:       true,
+		},
+		"SAN does not match": {
+			authorizer: NodeAuthorizer{BindSAN: true},
+			node:       &envoy_core_v3.Node{Id: "envoy-1", Cluster: "other-fleet"},
+			certs:      []*x509.Certificate{cert},
+			want:       false,
+		},
+		"no client certificate": {
+			authorizer: NodeAuthorizer{BindSAN: true},
+			node:       &envoy_core_v3.Node{Id: "envoy"},
+			want:       false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.authorizer.Authorize(tc.node, tc.certs)
+			if tc.want {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, codes.PermissionDenied, status.Code(err))
+			}
+		})
+	}
+}
+
+func TestNodeAuthorizerStreamServerInterceptor(t *testing.T) {
+	authorizer := &NodeAuthorizer{AllowedNodes: []NodeMatch{{Exact: "envoy"}}}
+
+	tests := map[string]struct {
+		requests []*envoy_service_discovery_v3.DiscoveryRequest
+		want     []codes.Code
+	}{
+		"node on first request only": {
+			requests: []*envoy_service_discovery_v3.DiscoveryRequest{
+				{Node: &envoy_core_v3.Node{Id: "envoy"}},
+				{VersionInfo: "1"},
+			},
+			want: []codes.Code{codes.OK, codes.OK},
+		},
+		"no node on first request": {
+			requests: []*envoy_service_discovery_v3.DiscoveryRequest{
+				{VersionInfo: "1"},
+			},
+			want: []codes.Code{codes.PermissionDenied},
+		},
+		"node changed on later request": {
+			requests: []*envoy_service_discovery_v3.DiscoveryRequest{
+				{Node: &envoy_core_v3.Node{Id: "envoy"}},
+				{Node: &envoy_core_v3.Node{Id: "intruder"}},
+			},
+			want: []codes.Code{codes.OK, codes.PermissionDenied},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ss := &mockServerStream{requests: tc.requests}
+			err := authorizer.StreamServerInterceptor()(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+				for _, want := range tc.want {
+					err := ss.RecvMsg(&envoy_service_discovery_v3.DiscoveryRequest{})
+					assert.Equal(t, want, status.Code(err))
+				}
+				return nil
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	requests []*envoy_service_discovery_v3.DiscoveryRequest
+}
+
+func (m *mockServerStream) Context() context.Context { return context.Background() }
+
+func (m *mockServerStream) RecvMsg(msg interface{}) error {
+	proto.Merge(msg.(proto.Message), m.requests[0])
+	m.requests = m.requests[1:]
+	return nil
+}
@@ -14,6 +14,7 @@
 package v3
 
 import (
+	"context"
 	"fmt"
 
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -21,19 +22,38 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// NackHandler is called with the type URL and error message of each
+// request in which Envoy rejects the previous configuration update.
+type NackHandler func(typeURL, message string)
+
 // NewRequestLoggingCallbacks returns an implementation of the Envoy xDS server
 // callbacks for use when Contour is run in Envoy xDS server mode to provide
-// request detail logging. Currently only the xDS State of the World callback
-// OnStreamRequest is implemented.
-func NewRequestLoggingCallbacks(log logrus.FieldLogger) envoy_server_v3.Callbacks {
+// request detail logging. Rejected updates are also passed to onNack, if it
+// is not nil. Currently only the xDS State of the World callbacks
+// OnStreamRequest and OnFetchRequest are implemented.
+func NewRequestLoggingCallbacks(log logrus.FieldLogger, onNack NackHandler) envoy_server_v3.Callbacks {
 	return &envoy_server_v3.CallbackFuncs{
 		StreamRequestFunc: func(streamID int64, req *envoy_service_discovery_v3.DiscoveryRequest) error {
 			logDiscoveryRequestDetails(log, req)
+			notifyNack(onNack, req)
+			return nil
+		},
+		FetchRequestFunc: func(ctx context.Context, req *envoy_service_discovery_v3.DiscoveryRequest) error {
+			logDiscoveryRequestDetails(log, req)
+			notifyNack(onNack, req)
 			return nil
 		},
 	}
 }
 
+// notifyNack calls onNack if req rejects the previous update.
+func notifyNack(onNack NackHandler, req *envoy_service_discovery_v3.DiscoveryRequest) {
+	if onNack == nil || req.ErrorDetail == nil {
+		return
+	}
+	onNack(req.GetTypeUrl(), req.ErrorDetail.Message)
+}
+
 // Helper function for use in the Envoy xDS server callbacks and the Contour
 // xDS server to log request details. Returns logger with fields added for any
 // subsequent error handling and logging.
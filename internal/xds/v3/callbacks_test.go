@@ -139,7 +139,7 @@ func TestOnStreamRequestCallbackLogs(t *testing.T) {
 	log, logHook := test.NewNullLogger()
 	log.SetLevel(logrus.DebugLevel)
 
-	callbacks := NewRequestLoggingCallbacks(log)
+	callbacks := NewRequestLoggingCallbacks(log, nil)
 	err := callbacks.OnStreamRequest(999, &envoy_service_discovery_v3.DiscoveryRequest{
 		VersionInfo:   "req-version",
 		ResponseNonce: "resp-nonce",
@@ -149,3 +149,29 @@ func TestOnStreamRequestCallbackLogs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, logHook.AllEntries())
 }
+
+func TestOnStreamRequestCallbackNacks(t *testing.T) {
+	log, _ := test.NewNullLogger()
+
+	var nacks []string
+	callbacks := NewRequestLoggingCallbacks(log, func(typeURL, message string) {
+		nacks = append(nacks, typeURL+": "+message)
+	})
+
+	// An ACK is not passed to the handler.
+	assert.NoError(t, callbacks.OnStreamRequest(999, &envoy_service_discovery_v3.DiscoveryRequest{
+		VersionInfo: "1",
+		TypeUrl:     "some-type-url",
+	}))
+	assert.Empty(t, nacks)
+
+	assert.NoError(t, callbacks.OnStreamRequest(999, &envoy_service_discovery_v3.DiscoveryRequest{
+		VersionInfo: "1",
+		TypeUrl:     "some-type-url",
+		ErrorDetail: &status.Status{
+			Code:    int32(code.Code_INVALID_ARGUMENT),
+			Message: "Invalid regex",
+		},
+	}))
+	assert.Equal(t, []string{"some-type-url: Invalid regex"}, nacks)
+}
@@ -24,6 +24,8 @@ import (
 	envoy_service_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
 	envoy_service_route_v3 "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
 	envoy_service_secret_v3 "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	envoy_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectcontour/contour/internal/xds"
@@ -39,10 +41,12 @@ type grpcStream interface {
 
 // NewContourServer creates an internally implemented Server that streams the
 // provided set of Resource objects. The returned Server implements the xDS
-// State of the World (SotW) variant.
-func NewContourServer(log logrus.FieldLogger, resources ...xds.Resource) Server {
+// State of the World (SotW) variant. Rejected updates are passed to onNack,
+// if it is not nil.
+func NewContourServer(log logrus.FieldLogger, onNack NackHandler, resources ...xds.Resource) Server {
 	c := contourServer{
 		FieldLogger: log,
+		onNack:      onNack,
 		resources:   map[string]xds.Resource{},
 	}
 
@@ -54,9 +58,9 @@ func NewContourServer(log logrus.FieldLogger, resources ...xds.Resource) Server
 }
 
 type contourServer struct {
-	// Since we only implement the streaming state of the world
-	// protocol, embed the default null implementations to handle
-	// the unimplemented gRPC endpoints.
+	// Since we only implement the state of the world protocol,
+	// embed the default null implementations to handle the
+	// unimplemented gRPC endpoints.
 	envoy_service_discovery_v3.UnimplementedAggregatedDiscoveryServiceServer
 	envoy_service_secret_v3.UnimplementedSecretDiscoveryServiceServer
 	envoy_service_route_v3.UnimplementedRouteDiscoveryServiceServer
@@ -65,6 +69,7 @@ type contourServer struct {
 	envoy_service_listener_v3.UnimplementedListenerDiscoveryServiceServer
 
 	logrus.FieldLogger
+	onNack      NackHandler
 	resources   map[string]xds.Resource
 	connections xds.Counter
 }
@@ -104,6 +109,7 @@ func (s *contourServer) stream(st grpcStream) error {
 
 		// Note: redeclare log in this scope so the next time around the loop all is forgotten.
 		log := logDiscoveryRequestDetails(log, req)
+		notifyNack(s.onNack, req)
 
 		// From the request we derive the resource to stream which have
 		// been registered according to the typeURL.
@@ -121,31 +127,9 @@ func (s *contourServer) stream(st grpcStream) error {
 			// TODO(dfc) the thing that has changed may not be in the scope of the filter
 			// so we're going to be sending an update that is a no-op. See #426
 
-			var resources []proto.Message
-			switch len(req.ResourceNames) {
-			case 0:
-				// no resource hints supplied, return the full
-				// contents of the resource
-				resources = r.Contents()
-			default:
-				// resource hints supplied, return exactly those
-				resources = r.Query(req.ResourceNames)
-			}
-
-			any := make([]*any.Any, 0, len(resources))
-			for _, r := range resources {
-				a, err := anypb.New(proto.MessageV2(r))
-				if err != nil {
-					return done(log, err)
-				}
-				any = append(any, a)
-			}
-
-			resp := &envoy_service_discovery_v3.DiscoveryResponse{
-				VersionInfo: strconv.Itoa(last),
-				Resources:   any,
-				TypeUrl:     req.GetTypeUrl(),
-				Nonce:       strconv.Itoa(last),
+			resp, err := response(r, req, last)
+			if err != nil {
+				return done(log, err)
 			}
 
 			if err := st.Send(resp); err != nil {
@@ -158,6 +142,62 @@ func (s *contourServer) stream(st grpcStream) error {
 	}
 }
 
+// fetch processes a single DiscoveryRequest of the xDS REST (fetch)
+// protocol. It returns a SkipFetchError if the version of the request
+// is already the current version of the resource.
+func (s *contourServer) fetch(req *envoy_service_discovery_v3.DiscoveryRequest) (*envoy_service_discovery_v3.DiscoveryResponse, error) {
+	logDiscoveryRequestDetails(s.FieldLogger, req)
+	notifyNack(s.onNack, req)
+
+	r, ok := s.resources[req.GetTypeUrl()]
+	if !ok {
+		return nil, fmt.Errorf("no resource registered for typeURL %q", req.GetTypeUrl())
+	}
+
+	// Registering with a last value less than zero returns the
+	// current version of the resource immediately.
+	ch := make(chan int, 1)
+	r.Register(ch, -1)
+	last := <-ch
+
+	if req.GetVersionInfo() == strconv.Itoa(last) {
+		return nil, &envoy_types.SkipFetchError{}
+	}
+
+	return response(r, req, last)
+}
+
+// response returns the DiscoveryResponse to req for version last
+// of the resource r.
+func response(r xds.Resource, req *envoy_service_discovery_v3.DiscoveryRequest, last int) (*envoy_service_discovery_v3.DiscoveryResponse, error) {
+	var resources []proto.Message
+	switch len(req.ResourceNames) {
+	case 0:
+		// no resource hints supplied, return the full
+		// contents of the resource
+		resources = r.Contents()
+	default:
+		// resource hints supplied, return exactly those
+		resources = r.Query(req.ResourceNames)
+	}
+
+	any := make([]*any.Any, 0, len(resources))
+	for _, r := range resources {
+		a, err := anypb.New(proto.MessageV2(r))
+		if err != nil {
+			return nil, err
+		}
+		any = append(any, a)
+	}
+
+	return &envoy_service_discovery_v3.DiscoveryResponse{
+		VersionInfo: strconv.Itoa(last),
+		Resources:   any,
+		TypeUrl:     req.GetTypeUrl(),
+		Nonce:       strconv.Itoa(last),
+	}, nil
+}
+
 func (s *contourServer) StreamClusters(srv envoy_service_cluster_v3.ClusterDiscoveryService_StreamClustersServer) error {
 	return s.stream(srv)
 }
@@ -177,3 +217,28 @@ func (s *contourServer) StreamRoutes(srv envoy_service_route_v3.RouteDiscoverySe
 func (s *contourServer) StreamSecrets(srv envoy_service_secret_v3.SecretDiscoveryService_StreamSecretsServer) error {
 	return s.stream(srv)
 }
+
+func (s *contourServer) FetchClusters(_ context.Context, req *envoy_service_discovery_v3.DiscoveryRequest) (*envoy_service_discovery_v3.DiscoveryResponse, error) {
+	req.TypeUrl = resource.ClusterType
+	return s.fetch(req)
+}
+
+func (s *contourServer) FetchEndpoints(_ context.Context, req *envoy_service_discovery_v3.DiscoveryRequest) (*envoy_service_discovery_v3.DiscoveryResponse, error) {
+	req.TypeUrl = resource.EndpointType
+	return s.fetch(req)
+}
+
+func (s *contourServer) FetchListeners(_ context.Context, req *envoy_service_discovery_v3.DiscoveryRequest) (*envoy_service_discovery_v3.DiscoveryResponse, error) {
+	req.TypeUrl = resource.ListenerType
+	return s.fetch(req)
+}
+
+func (s *contourServer) FetchRoutes(_ context.Context, req *envoy_service_discovery_v3.DiscoveryRequest) (*envoy_service_discovery_v3.DiscoveryResponse, error) {
+	req.TypeUrl = resource.RouteType
+	return s.fetch(req)
+}
+
+func (s *contourServer) FetchSecrets(_ context.Context, req *envoy_service_discovery_v3.DiscoveryRequest) (*envoy_service_discovery_v3.DiscoveryResponse, error) {
+	req.TypeUrl = resource.SecretType
+	return s.fetch(req)
+}
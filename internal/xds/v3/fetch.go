@@ -0,0 +1,114 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"path"
+
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	envoy_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+type fetchFunc func(context.Context, *envoy_service_discovery_v3.DiscoveryRequest) (*envoy_service_discovery_v3.DiscoveryResponse, error)
+
+// NewFetchHandler returns a http.Handler that serves the xDS REST
+// (fetch) protocol of srv. Clients POST a JSON DiscoveryRequest to
+// the fetch path of a resource type, e.g. /v3/discovery:clusters,
+// and receive a JSON DiscoveryResponse, or 304 Not Modified if the
+// request's version_info is already the current version. If authorizer
+// is non-nil, requests it does not authorize are rejected with 403
+// Forbidden.
+func NewFetchHandler(log logrus.FieldLogger, srv Server, authorizer *NodeAuthorizer) http.Handler {
+	return &fetchHandler{
+		FieldLogger: log,
+		authorizer:  authorizer,
+		fetchers: map[string]fetchFunc{
+			resource.FetchClusters:  srv.FetchClusters,
+			resource.FetchEndpoints: srv.FetchEndpoints,
+			resource.FetchListeners: srv.FetchListeners,
+			resource.FetchRoutes:    srv.FetchRoutes,
+			resource.FetchSecrets:   srv.FetchSecrets,
+		},
+	}
+}
+
+type fetchHandler struct {
+	logrus.FieldLogger
+	authorizer *NodeAuthorizer
+	fetchers   map[string]fetchFunc
+}
+
+func (h *fetchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fetch, ok := h.fetchers[path.Clean(r.URL.Path)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &envoy_service_discovery_v3.DiscoveryRequest{}
+	if err := (&jsonpb.Unmarshaler{AllowUnknownFields: true}).Unmarshal(r.Body, req); err != nil {
+		http.Error(w, "cannot parse DiscoveryRequest: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.authorizer != nil {
+		var certs []*x509.Certificate
+		if r.TLS != nil {
+			certs = r.TLS.PeerCertificates
+		}
+		if err := h.authorizer.Authorize(req.Node, certs); err != nil {
+			h.WithError(err).WithField("path", r.URL.Path).Error("rejected unauthorized xDS request")
+			http.Error(w, status.Convert(err).Message(), http.StatusForbidden)
+			return
+		}
+	}
+
+	resp, err := fetch(r.Context(), req)
+	if err != nil {
+		if isSkipFetch(err) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h.WithError(err).WithField("path", r.URL.Path).Error("failed to fetch xDS resources")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := (&jsonpb.Marshaler{OrigName: true}).Marshal(w, resp); err != nil {
+		h.WithError(err).WithField("path", r.URL.Path).Error("failed to write xDS response")
+	}
+}
+
+// isSkipFetch returns true if err reports that the resources
+// requested are already up to date.
+func isSkipFetch(err error) bool {
+	var ptr *envoy_types.SkipFetchError
+	var val envoy_types.SkipFetchError
+	return errors.As(err, &ptr) || errors.As(err, &val)
+}
@@ -0,0 +1,138 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchHandler(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	srv := NewContourServer(log, nil, &mockResource{
+		register: func(ch chan int, last int) {
+			ch <- 7
+		},
+		contents: func() []proto.Message {
+			return []proto.Message{&envoy_cluster_v3.Cluster{Name: "default/kuard/80/da39a3ee5e"}}
+		},
+		query: func(names []string) []proto.Message {
+			var values []proto.Message
+			for _, n := range names {
+				values = append(values, &envoy_cluster_v3.Cluster{Name: n})
+			}
+			return values
+		},
+		typeurl: func() string { return resource.ClusterType },
+	})
+
+	authorizer := &NodeAuthorizer{
+		AllowedNodes: []NodeMatch{{Exact: "envoy"}},
+	}
+
+	tests := map[string]struct {
+		authorizer *NodeAuthorizer
+		method     string
+		path       string
+		body       string
+		want       int
+		match      []string
+	}{
+		"all clusters": {
+			method: http.MethodPost,
+			path:   resource.FetchClusters,
+			body:   `{"node": {"id": "envoy"}}`,
+			want:   http.StatusOK,
+			match: []string{
+				`"version_info":"7"`,
+				`"name":"default/kuard/80/da39a3ee5e"`,
+				`"type_url":"` + resource.ClusterType + `"`,
+			},
+		},
+		"named clusters": {
+			method: http.MethodPost,
+			path:   resource.FetchClusters,
+			body:   `{"resource_names": ["default/httpbin/80/da39a3ee5e"]}`,
+			want:   http.StatusOK,
+			match:  []string{`"name":"default/httpbin/80/da39a3ee5e"`},
+		},
+		"current version": {
+			method: http.MethodPost,
+			path:   resource.FetchClusters,
+			body:   `{"version_info": "7"}`,
+			want:   http.StatusNotModified,
+		},
+		"unregistered type": {
+			method: http.MethodPost,
+			path:   resource.FetchListeners,
+			body:   `{}`,
+			want:   http.StatusInternalServerError,
+		},
+		"invalid request": {
+			method: http.MethodPost,
+			path:   resource.FetchClusters,
+			body:   `{"node": 7}`,
+			want:   http.StatusBadRequest,
+		},
+		"unknown path": {
+			method: http.MethodPost,
+			path:   "/v3/discovery:potatoes",
+			body:   `{}`,
+			want:   http.StatusNotFound,
+		},
+		"authorized node": {
+			authorizer: authorizer,
+			method:     http.MethodPost,
+			path:       resource.FetchClusters,
+			body:       `{"node": {"id": "envoy"}}`,
+			want:       http.StatusOK,
+		},
+		"unauthorized node": {
+			authorizer: authorizer,
+			method:     http.MethodPost,
+			path:       resource.FetchClusters,
+			body:       `{"node": {"id": "intruder"}}`,
+			want:       http.StatusForbidden,
+			match:      []string{`node ID "intruder" is not permitted`},
+		},
+		"not a POST": {
+			method: http.MethodGet,
+			path:   resource.FetchClusters,
+			want:   http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewFetchHandler(log, srv, tc.authorizer).ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.want, rec.Code)
+			for _, m := range tc.match {
+				assert.Contains(t, rec.Body.String(), m)
+			}
+		})
+	}
+}
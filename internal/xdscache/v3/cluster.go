@@ -16,6 +16,8 @@ package v3
 import (
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
@@ -29,27 +31,79 @@ import (
 )
 
 // ClusterCache manages the contents of the gRPC CDS cache.
+// Its contents are replaced, never modified, so that reads
+// do not wait for updates.
 type ClusterCache struct {
-	mu     sync.Mutex
-	values map[string]*envoy_cluster_v3.Cluster
+	// Linger is how long clusters that are removed from the
+	// cache are still served. If not set, clusters are removed
+	// immediately.
+	Linger time.Duration
+
+	// Observer notifies when lingering clusters are removed.
+	Observer contour.Observer
+
+	mu        sync.Mutex   // Serializes updates.
+	values    atomic.Value // map[string]*envoy_cluster_v3.Cluster
+	lingering lingerer
 	contour.Cond
 }
 
+// load returns the current contents of the cache, which
+// must not be modified.
+func (c *ClusterCache) load() map[string]*envoy_cluster_v3.Cluster {
+	values, _ := c.values.Load().(map[string]*envoy_cluster_v3.Cluster)
+	return values
+}
+
 // Update replaces the contents of the cache with the supplied map.
+// Clusters that are no longer in the supplied map are kept until
+// their linger period expires.
 func (c *ClusterCache) Update(v map[string]*envoy_cluster_v3.Cluster) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.values = v
+	for name := range v {
+		c.lingering.present(name)
+	}
+	for name, cluster := range c.load() {
+		if _, ok := v[name]; !ok && c.lingering.linger(name, c.Linger, c.expire) {
+			if v == nil {
+				v = map[string]*envoy_cluster_v3.Cluster{}
+			}
+			v[name] = cluster
+		}
+	}
+
+	c.values.Store(v)
 	c.Cond.Notify()
 }
 
+// expire removes the clusters whose linger period has expired.
+func (c *ClusterCache) expire() {
+	c.mu.Lock()
+	names := c.lingering.expired()
+	if len(names) > 0 {
+		values := map[string]*envoy_cluster_v3.Cluster{}
+		for name, cluster := range c.load() {
+			values[name] = cluster
+		}
+		for _, name := range names {
+			delete(values, name)
+		}
+		c.values.Store(values)
+		c.Cond.Notify()
+	}
+	c.mu.Unlock()
+
+	if len(names) > 0 && c.Observer != nil {
+		c.Observer.Refresh()
+	}
+}
+
 // Contents returns a copy of the cache's contents.
 func (c *ClusterCache) Contents() []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
 	var values []*envoy_cluster_v3.Cluster
-	for _, v := range c.values {
+	for _, v := range c.load() {
 		values = append(values, v)
 	}
 	sort.Stable(sorter.For(values))
@@ -57,8 +111,7 @@ func (c *ClusterCache) Contents() []proto.Message {
 }
 
 func (c *ClusterCache) Query(names []string) []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	current := c.load()
 	var values []*envoy_cluster_v3.Cluster
 	for _, n := range names {
 		// if the cluster is not registered we cannot return
@@ -66,7 +119,7 @@ func (c *ClusterCache) Query(names []string) []proto.Message {
 		// discovery type; DNS, EDS, etc. We cannot determine the
 		// correct value for this property from the cluster's name
 		// provided by the query so we must not return a blank cluster.
-		if v, ok := c.values[n]; ok {
+		if v, ok := current[n]; ok {
 			values = append(values, v)
 		}
 	}
@@ -106,6 +159,15 @@ func (v *clusterVisitor) visit(vertex dag.Vertex) {
 		if _, ok := v.clusters[name]; !ok {
 			v.clusters[name] = envoy_v3.ExtensionCluster(cluster)
 		}
+	case *dag.DNSNameCluster:
+		name := cluster.Name()
+		if _, ok := v.clusters[name]; !ok {
+			v.clusters[name] = envoy_v3.DNSNameCluster(cluster)
+		}
+	case *dag.VirtualHost:
+		if cluster.ForwardProxy != nil {
+			v.clusters[envoy_v3.DynamicForwardProxyClusterName] = envoy_v3.DynamicForwardProxyCluster()
+		}
 	}
 
 	// recurse into children of v
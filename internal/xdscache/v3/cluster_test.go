@@ -24,8 +24,10 @@ import (
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/golang/protobuf/ptypes/duration"
 	contour_api_v1 "github.com/projectcontour/contour/apis/projectcontour/v1"
+	"github.com/projectcontour/contour/internal/contour"
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -156,6 +158,45 @@ func TestClusterCacheQuery(t *testing.T) {
 	}
 }
 
+func TestClusterCacheLinger(t *testing.T) {
+	kuard := &envoy_cluster_v3.Cluster{
+		Name:                 "default/kuard/443/da39a3ee5e",
+		AltStatName:          "default_kuard_443",
+		ClusterDiscoveryType: envoy_v3.ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+		EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+			EdsConfig:   envoy_v3.ConfigSource("contour"),
+			ServiceName: "default/kuard",
+		},
+	}
+
+	refreshed := make(chan struct{}, 1)
+	cc := ClusterCache{
+		Linger: 100 * time.Millisecond,
+		Observer: contour.ObserverFunc(func() {
+			refreshed <- struct{}{}
+		}),
+	}
+
+	want := []proto.Message{cluster(kuard)}
+
+	cc.Update(clustermap(kuard))
+	protobuf.ExpectEqual(t, want, cc.Contents())
+
+	// The removed cluster is still served.
+	cc.Update(nil)
+	protobuf.ExpectEqual(t, want, cc.Contents())
+	protobuf.ExpectEqual(t, want, cc.Query([]string{kuard.Name}))
+
+	// Once the linger period expires, the cluster is removed,
+	// and the observer is notified.
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("lingering cluster was not removed")
+	}
+	assert.Empty(t, cc.Contents())
+}
+
 func TestClusterVisit(t *testing.T) {
 	tests := map[string]struct {
 		objs []interface{}
@@ -165,6 +206,27 @@ func TestClusterVisit(t *testing.T) {
 			objs: nil,
 			want: map[string]*envoy_cluster_v3.Cluster{},
 		},
+		"forward proxy": {
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "egress",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "egress.example.com",
+							ForwardProxy: &contour_api_v1.ForwardProxyPolicy{
+								AllowedDomains: []string{"api.example.com"},
+							},
+						},
+					},
+				},
+			},
+			want: map[string]*envoy_cluster_v3.Cluster{
+				"dynamic_forward_proxy": envoy_v3.DynamicForwardProxyCluster(),
+			},
+		},
 		"single unnamed service": {
 			objs: []interface{}{
 				&networking_v1.Ingress{
@@ -17,6 +17,8 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
@@ -38,16 +40,19 @@ type LoadBalancingEndpoint = envoy_endpoint_v3.LbEndpoint
 
 // RecalculateEndpoints generates a slice of LoadBalancingEndpoint
 // resources by matching the given service port to the given v1.Endpoints.
+// If includeNotReady is true, addresses that are not ready are included
+// as UNHEALTHY endpoints, otherwise they are omitted. Ready addresses
+// that belong to a Pod in terminating are included as DRAINING endpoints.
 // ep may be nil, in which case, the result is also nil.
-func RecalculateEndpoints(port v1.ServicePort, ep *v1.Endpoints) []*LoadBalancingEndpoint {
+func RecalculateEndpoints(port v1.ServicePort, ep *v1.Endpoints, includeNotReady bool, terminating map[types.NamespacedName]bool) []*LoadBalancingEndpoint {
 	if ep == nil {
 		return nil
 	}
 
 	var lb []*LoadBalancingEndpoint
 	for _, s := range ep.Subsets {
-		// Skip subsets without ready addresses.
-		if len(s.Addresses) < 1 {
+		// Skip subsets without usable addresses.
+		if len(s.Addresses) < 1 && (!includeNotReady || len(s.NotReadyAddresses) < 1) {
 			continue
 		}
 
@@ -71,14 +76,44 @@ func RecalculateEndpoints(port v1.ServicePort, ep *v1.Endpoints) []*LoadBalancin
 
 			for _, a := range addresses {
 				addr := envoy_v3.SocketAddress(a.IP, int(p.Port))
+				if pod, ok := podOf(a); ok && terminating[pod] {
+					// The Pod is shutting down, but the Endpoints
+					// have not caught up yet, so stop sending it
+					// new requests now.
+					lb = append(lb, envoy_v3.DrainingLBEndpoint(addr))
+					continue
+				}
 				lb = append(lb, envoy_v3.LBEndpoint(addr))
 			}
+
+			if !includeNotReady {
+				continue
+			}
+
+			// Collect UNHEALTHY Envoy endpoints for the addresses that are not ready,
+			// so that Envoy keeps them and can quickly use them again once they are.
+			notReady := append([]v1.EndpointAddress{}, s.NotReadyAddresses...) // Shallow copy.
+			sort.Slice(notReady, func(i, j int) bool { return notReady[i].IP < notReady[j].IP })
+
+			for _, a := range notReady {
+				addr := envoy_v3.SocketAddress(a.IP, int(p.Port))
+				lb = append(lb, envoy_v3.UnhealthyLBEndpoint(addr))
+			}
 		}
 	}
 
 	return lb
 }
 
+// podOf returns the name of the Pod that a is an address of,
+// and false if a does not refer to a Pod.
+func podOf(a v1.EndpointAddress) (types.NamespacedName, bool) {
+	if a.TargetRef == nil || a.TargetRef.Kind != "Pod" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: a.TargetRef.Namespace, Name: a.TargetRef.Name}, true
+}
+
 // EndpointsCache is a cache of Endpoint and ServiceCluster objects.
 type EndpointsCache struct {
 	mu sync.Mutex // Protects all fields.
@@ -95,14 +130,23 @@ type EndpointsCache struct {
 
 	// Cache of endpoints, indexed by name.
 	endpoints map[types.NamespacedName]*v1.Endpoints
+
+	// Set of the Pods that are terminating, indexed by name.
+	terminating map[types.NamespacedName]bool
+
+	// Set of the deleted Pods that still have an address in
+	// the cached Endpoints, indexed by name. These remain
+	// terminating until the Endpoints no longer include them.
+	deleted map[types.NamespacedName]bool
 }
 
 // Recalculate regenerates all the ClusterLoadAssignments from the
 // cached Endpoints and stale ServiceClusters. A ClusterLoadAssignment
 // will be generated for every stale ServerCluster, however, if there
 // are no endpoints for the Services in the ServiceCluster, the
-// ClusterLoadAssignment will be empty.
-func (c *EndpointsCache) Recalculate() map[string]*envoy_endpoint_v3.ClusterLoadAssignment {
+// ClusterLoadAssignment will be empty. If includeNotReady is true,
+// addresses that are not ready are included as UNHEALTHY endpoints.
+func (c *EndpointsCache) Recalculate(includeNotReady bool) map[string]*envoy_endpoint_v3.ClusterLoadAssignment {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -124,7 +168,7 @@ func (c *EndpointsCache) Recalculate() map[string]*envoy_endpoint_v3.ClusterLoad
 		// attach them as a new LocalityEndpoints resource2.
 		for _, w := range cluster.Services {
 			n := types.NamespacedName{Namespace: w.ServiceNamespace, Name: w.ServiceName}
-			if lb := RecalculateEndpoints(w.ServicePort, c.endpoints[n]); lb != nil {
+			if lb := RecalculateEndpoints(w.ServicePort, c.endpoints[n], includeNotReady, c.terminating); lb != nil {
 				// Append the new set of endpoints. Users are allowed to set the load
 				// balancing weight to 0, which we reflect to Envoy as nil in order to
 				// assign no load to that locality.
@@ -133,6 +177,7 @@ func (c *EndpointsCache) Recalculate() map[string]*envoy_endpoint_v3.ClusterLoad
 					&LocalityEndpoints{
 						LbEndpoints:         lb,
 						LoadBalancingWeight: protobuf.UInt32OrNil(w.Weight),
+						Priority:            w.Priority,
 					},
 				)
 			}
@@ -196,6 +241,7 @@ func (c *EndpointsCache) UpdateEndpoint(ep *v1.Endpoints) bool {
 
 	name := k8s.NamespacedNameOf(ep)
 	c.endpoints[name] = ep.DeepCopy()
+	c.forgetDeletedPods()
 
 	// If any service clusters include this endpoint, mark them
 	// all as stale.
@@ -216,6 +262,7 @@ func (c *EndpointsCache) DeleteEndpoint(ep *v1.Endpoints) bool {
 
 	name := k8s.NamespacedNameOf(ep)
 	delete(c.endpoints, name)
+	c.forgetDeletedPods()
 
 	// If any service clusters include this endpoint, mark them
 	// all as stale.
@@ -227,18 +274,118 @@ func (c *EndpointsCache) DeleteEndpoint(ep *v1.Endpoints) bool {
 	return false
 }
 
+// UpdatePod records whether pod is terminating. Any ServiceClusters
+// that are backed by Endpoints with an address of pod become stale
+// if that changes. Returns a boolean indicating whether any
+// ServiceClusters became stale or not.
+func (c *EndpointsCache) UpdatePod(pod *v1.Pod) bool {
+	return c.setTerminating(k8s.NamespacedNameOf(pod), pod.DeletionTimestamp != nil)
+}
+
+// DeletePod forgets pod. If the cached Endpoints still have an
+// address of pod, pod remains terminating until they no longer
+// do, and any ServiceClusters that are backed by those Endpoints
+// become stale if pod was not terminating yet. Returns a boolean
+// indicating whether any ServiceClusters became stale or not.
+func (c *EndpointsCache) DeletePod(pod *v1.Pod) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := k8s.NamespacedNameOf(pod)
+	for _, ep := range c.endpoints {
+		if hasPodAddress(ep, name) {
+			c.deleted[name] = true
+			return c.setTerminatingLocked(name, true)
+		}
+	}
+
+	delete(c.deleted, name)
+	return c.setTerminatingLocked(name, false)
+}
+
+// forgetDeletedPods forgets the deleted Pods that no longer
+// have an address in the cached Endpoints.
+func (c *EndpointsCache) forgetDeletedPods() {
+	for pod := range c.deleted {
+		found := false
+		for _, ep := range c.endpoints {
+			if hasPodAddress(ep, pod) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			delete(c.deleted, pod)
+			delete(c.terminating, pod)
+		}
+	}
+}
+
+func (c *EndpointsCache) setTerminating(pod types.NamespacedName, terminating bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A Pod that is updated after it was deleted has been
+	// recreated with the same name.
+	delete(c.deleted, pod)
+
+	return c.setTerminatingLocked(pod, terminating)
+}
+
+func (c *EndpointsCache) setTerminatingLocked(pod types.NamespacedName, terminating bool) bool {
+	if c.terminating[pod] == terminating {
+		return false
+	}
+
+	if terminating {
+		c.terminating[pod] = true
+	} else {
+		delete(c.terminating, pod)
+	}
+
+	// Mark the service clusters of any endpoints that
+	// have an address of this Pod as stale.
+	stale := false
+	for name, ep := range c.endpoints {
+		if !hasPodAddress(ep, pod) {
+			continue
+		}
+		if affected := c.services[name]; len(affected) > 0 {
+			c.stale = append(c.stale, affected...)
+			stale = true
+		}
+	}
+
+	return stale
+}
+
+// hasPodAddress returns true if ep has a ready address of pod.
+func hasPodAddress(ep *v1.Endpoints, pod types.NamespacedName) bool {
+	for _, s := range ep.Subsets {
+		for _, a := range s.Addresses {
+			if name, ok := podOf(a); ok && name == pod {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // NewEndpointsTranslator allocates a new endpoints translator.
 func NewEndpointsTranslator(log logrus.FieldLogger) *EndpointsTranslator {
-	return &EndpointsTranslator{
+	e := &EndpointsTranslator{
 		Cond:        contour.Cond{},
 		FieldLogger: log,
-		entries:     map[string]*envoy_endpoint_v3.ClusterLoadAssignment{},
 		cache: EndpointsCache{
-			stale:     nil,
-			services:  map[types.NamespacedName][]*dag.ServiceCluster{},
-			endpoints: map[types.NamespacedName]*v1.Endpoints{},
+			stale:       nil,
+			services:    map[types.NamespacedName][]*dag.ServiceCluster{},
+			endpoints:   map[types.NamespacedName]*v1.Endpoints{},
+			terminating: map[types.NamespacedName]bool{},
+			deleted:     map[types.NamespacedName]bool{},
 		},
 	}
+	e.entries.Store(map[string]*envoy_endpoint_v3.ClusterLoadAssignment{})
+	return e
 }
 
 // A EndpointsTranslator translates Kubernetes Endpoints objects into Envoy
@@ -250,10 +397,29 @@ type EndpointsTranslator struct {
 	contour.Cond
 	logrus.FieldLogger
 
+	// IncludeNotReadyEndpoints includes the addresses of Endpoints
+	// that are not ready as UNHEALTHY endpoints, instead of omitting them.
+	IncludeNotReadyEndpoints bool
+
+	// Linger is how long the load assignments of clusters that
+	// are removed from the DAG are still served, with their
+	// endpoints frozen. If not set, they are removed immediately.
+	Linger time.Duration
+
 	cache EndpointsCache
 
-	mu      sync.Mutex // Protects entries.
-	entries map[string]*envoy_endpoint_v3.ClusterLoadAssignment
+	mu sync.Mutex // Serializes updates of entries, and protects lingering.
+
+	// entries holds the load assignments, which are replaced, never
+	// modified, so that reads do not wait for updates.
+	entries   atomic.Value // map[string]*envoy_endpoint_v3.ClusterLoadAssignment
+	lingering lingerer
+}
+
+// load returns the current load assignments, which must not be modified.
+func (e *EndpointsTranslator) load() map[string]*envoy_endpoint_v3.ClusterLoadAssignment {
+	entries, _ := e.entries.Load().(map[string]*envoy_endpoint_v3.ClusterLoadAssignment)
+	return entries
 }
 
 // Merge combines the given entries with the existing entries in the
@@ -263,9 +429,14 @@ func (e *EndpointsTranslator) Merge(entries map[string]*envoy_endpoint_v3.Cluste
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	merged := map[string]*envoy_endpoint_v3.ClusterLoadAssignment{}
+	for k, v := range e.load() {
+		merged[k] = v
+	}
 	for k, v := range entries {
-		e.entries[k] = v
+		merged[k] = v
 	}
+	e.entries.Store(merged)
 }
 
 // OnChange observes DAG rebuild events.
@@ -303,14 +474,24 @@ func (e *EndpointsTranslator) OnChange(d *dag.DAG) {
 	// be removed. Since we reset the cluster cache above, all
 	// the load assignments will be recalculated and we can just
 	// set the entries rather than merging them.
-	entries := e.cache.Recalculate()
+	entries := e.cache.Recalculate(e.IncludeNotReadyEndpoints)
 
 	// Only update and notify if entries has changed.
 	changed := false
 
 	e.mu.Lock()
-	if !equal(e.entries, entries) {
-		e.entries = entries
+	for name := range entries {
+		e.lingering.present(name)
+	}
+	for name, cla := range e.load() {
+		// The endpoints of a lingering cluster are no longer
+		// recalculated, so it keeps its last load assignment.
+		if _, ok := entries[name]; !ok && e.lingering.linger(name, e.Linger, e.expire) {
+			entries[name] = cla
+		}
+	}
+	if !equal(e.load(), entries) {
+		e.entries.Store(entries)
 		changed = true
 	}
 	e.mu.Unlock()
@@ -323,6 +504,31 @@ func (e *EndpointsTranslator) OnChange(d *dag.DAG) {
 	}
 }
 
+// expire removes the load assignments whose linger period has expired.
+func (e *EndpointsTranslator) expire() {
+	e.mu.Lock()
+	names := e.lingering.expired()
+	if len(names) > 0 {
+		entries := map[string]*envoy_endpoint_v3.ClusterLoadAssignment{}
+		for name, cla := range e.load() {
+			entries[name] = cla
+		}
+		for _, name := range names {
+			delete(entries, name)
+		}
+		e.entries.Store(entries)
+	}
+	e.mu.Unlock()
+
+	if len(names) > 0 {
+		e.Debug("lingering cluster load assignments expired, notifying waiters")
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	}
+}
+
 // equal returns true if a and b are the same length, have the same set
 // of keys, and have proto-equivalent values for each key, or false otherwise.
 func equal(a, b map[string]*envoy_endpoint_v3.ClusterLoadAssignment) bool {
@@ -351,7 +557,18 @@ func (e *EndpointsTranslator) OnAdd(obj interface{}) {
 		}
 
 		e.WithField("endpoint", k8s.NamespacedNameOf(obj)).Debug("Endpoint is in use by a ServiceCluster, recalculating ClusterLoadAssignments")
-		e.Merge(e.cache.Recalculate())
+		e.Merge(e.cache.Recalculate(e.IncludeNotReadyEndpoints))
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	case *v1.Pod:
+		if !e.cache.UpdatePod(obj) {
+			return
+		}
+
+		e.WithField("pod", k8s.NamespacedNameOf(obj)).Debug("terminating Pod is in use by a ServiceCluster, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate(e.IncludeNotReadyEndpoints))
 		e.Notify()
 		if e.Observer != nil {
 			e.Observer.Refresh()
@@ -388,7 +605,20 @@ func (e *EndpointsTranslator) OnUpdate(oldObj, newObj interface{}) {
 		}
 
 		e.WithField("endpoint", k8s.NamespacedNameOf(newObj)).Debug("Endpoint is in use by a ServiceCluster, recalculating ClusterLoadAssignments")
-		e.Merge(e.cache.Recalculate())
+		e.Merge(e.cache.Recalculate(e.IncludeNotReadyEndpoints))
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	case *v1.Pod:
+		// Only the transition of the Pod to terminating matters,
+		// and the cache ignores updates that do not change that.
+		if !e.cache.UpdatePod(newObj) {
+			return
+		}
+
+		e.WithField("pod", k8s.NamespacedNameOf(newObj)).Debug("terminating Pod is in use by a ServiceCluster, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate(e.IncludeNotReadyEndpoints))
 		e.Notify()
 		if e.Observer != nil {
 			e.Observer.Refresh()
@@ -406,7 +636,18 @@ func (e *EndpointsTranslator) OnDelete(obj interface{}) {
 		}
 
 		e.WithField("endpoint", k8s.NamespacedNameOf(obj)).Debug("Endpoint was in use by a ServiceCluster, recalculating ClusterLoadAssignments")
-		e.Merge(e.cache.Recalculate())
+		e.Merge(e.cache.Recalculate(e.IncludeNotReadyEndpoints))
+		e.Notify()
+		if e.Observer != nil {
+			e.Observer.Refresh()
+		}
+	case *v1.Pod:
+		if !e.cache.DeletePod(obj) {
+			return
+		}
+
+		e.WithField("pod", k8s.NamespacedNameOf(obj)).Debug("terminating Pod was in use by a ServiceCluster, recalculating ClusterLoadAssignments")
+		e.Merge(e.cache.Recalculate(e.IncludeNotReadyEndpoints))
 		e.Notify()
 		if e.Observer != nil {
 			e.Observer.Refresh()
@@ -420,11 +661,10 @@ func (e *EndpointsTranslator) OnDelete(obj interface{}) {
 
 // Contents returns a copy of the contents of the cache.
 func (e *EndpointsTranslator) Contents() []proto.Message {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	entries := e.load()
 
-	values := make([]*envoy_endpoint_v3.ClusterLoadAssignment, 0, len(e.entries))
-	for _, v := range e.entries {
+	values := make([]*envoy_endpoint_v3.ClusterLoadAssignment, 0, len(entries))
+	for _, v := range entries {
 		values = append(values, v)
 	}
 
@@ -433,12 +673,11 @@ func (e *EndpointsTranslator) Contents() []proto.Message {
 }
 
 func (e *EndpointsTranslator) Query(names []string) []proto.Message {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	entries := e.load()
 
 	values := make([]*envoy_endpoint_v3.ClusterLoadAssignment, 0, len(names))
 	for _, n := range names {
-		v, ok := e.entries[n]
+		v, ok := entries[n]
 		if !ok {
 			e.Debugf("no cache entry for %q", n)
 			v = &envoy_endpoint_v3.ClusterLoadAssignment{
@@ -14,7 +14,9 @@
 package v3
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	"github.com/golang/protobuf/proto"
@@ -25,6 +27,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestEndpointsTranslatorContents(t *testing.T) {
@@ -53,7 +56,7 @@ func TestEndpointsTranslatorContents(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			et := NewEndpointsTranslator(fixture.NewTestLogger(t))
-			et.entries = tc.contents
+			et.entries.Store(tc.contents)
 			got := et.Contents()
 			protobuf.ExpectEqual(t, tc.want, got)
 		})
@@ -109,7 +112,7 @@ func TestEndpointCacheQuery(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			et := NewEndpointsTranslator(fixture.NewTestLogger(t))
-			et.entries = tc.contents
+			et.entries.Store(tc.contents)
 			got := et.Query(tc.query)
 			protobuf.ExpectEqual(t, tc.want, got)
 		})
@@ -154,9 +157,10 @@ func TestEndpointsTranslatorAddEndpoints(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		ep         *v1.Endpoints
-		want       []proto.Message
-		wantUpdate bool
+		ep              *v1.Endpoints
+		includeNotReady bool
+		want            []proto.Message
+		wantUpdate      bool
 	}{
 		"simple": {
 			ep: endpoints("default", "simple", v1.EndpointSubset{
@@ -304,11 +308,58 @@ func TestEndpointsTranslatorAddEndpoints(t *testing.T) {
 			},
 			wantUpdate: true,
 		},
+		"not ready included as unhealthy": {
+			ep: endpoints("default", "httpbin-org", v1.EndpointSubset{
+				Addresses: addresses(
+					"10.10.1.1",
+				),
+				NotReadyAddresses: addresses(
+					"10.10.3.3",
+					"10.10.2.2",
+				),
+				Ports: ports(
+					port("a", 8675),
+				),
+			}, v1.EndpointSubset{
+				NotReadyAddresses: addresses(
+					"10.10.1.1",
+				),
+				Ports: ports(
+					port("b", 309),
+				),
+			}),
+			includeNotReady: true,
+			want: []proto.Message{
+				&envoy_endpoint_v3.ClusterLoadAssignment{
+					ClusterName: "default/httpbin-org/a",
+					Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+						LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+							envoy_v3.LBEndpoint(envoy_v3.SocketAddress("10.10.1.1", 8675)),
+							envoy_v3.UnhealthyLBEndpoint(envoy_v3.SocketAddress("10.10.2.2", 8675)),
+							envoy_v3.UnhealthyLBEndpoint(envoy_v3.SocketAddress("10.10.3.3", 8675)),
+						},
+						LoadBalancingWeight: protobuf.UInt32(1),
+					}},
+				},
+				&envoy_endpoint_v3.ClusterLoadAssignment{
+					ClusterName: "default/httpbin-org/b",
+					Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+						LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+							envoy_v3.UnhealthyLBEndpoint(envoy_v3.SocketAddress("10.10.1.1", 309)),
+						},
+						LoadBalancingWeight: protobuf.UInt32(1),
+					}},
+				},
+				&envoy_endpoint_v3.ClusterLoadAssignment{ClusterName: "default/simple"},
+			},
+			wantUpdate: true,
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+			et.IncludeNotReadyEndpoints = tc.includeNotReady
 			observer := &simpleObserver{}
 			et.Observer = observer
 
@@ -745,6 +796,232 @@ func TestEndpointsTranslatorScaleToZeroEndpoints(t *testing.T) {
 	protobuf.RequireEqual(t, want, et.Contents())
 }
 
+// Test that the load assignments can be read while they are updated.
+func TestEndpointsTranslatorConcurrentReads(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+
+	require.NoError(t, et.cache.SetClusters([]*dag.ServiceCluster{
+		{
+			ClusterName: "default/simple",
+			Services: []dag.WeightedService{{
+				Weight:           1,
+				ServiceName:      "simple",
+				ServiceNamespace: "default",
+				ServicePort:      v1.ServicePort{},
+			}},
+		},
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			et.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+				Addresses: addresses(fmt.Sprintf("192.168.183.%d", i)),
+				Ports: ports(
+					port("", 8080),
+				),
+			}))
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			want := []proto.Message{
+				&envoy_endpoint_v3.ClusterLoadAssignment{
+					ClusterName: "default/simple",
+					Endpoints:   envoy_v3.WeightedEndpoints(1, envoy_v3.SocketAddress("192.168.183.99", 8080)),
+				},
+			}
+			protobuf.RequireEqual(t, want, et.Contents())
+			return
+		default:
+			for _, cla := range et.Query([]string{"default/simple"}) {
+				assert.Equal(t, "default/simple", cla.(*envoy_endpoint_v3.ClusterLoadAssignment).ClusterName)
+			}
+		}
+	}
+}
+
+// Test that the load assignment of a cluster that is removed from
+// the DAG lingers, with its endpoints frozen, before it is removed.
+func TestEndpointsTranslatorLinger(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+	et.Linger = 100 * time.Millisecond
+
+	withCluster := &dag.DAG{}
+	withCluster.AddRoot(&dag.ServiceCluster{
+		ClusterName: "default/simple",
+		Services: []dag.WeightedService{{
+			Weight:           1,
+			ServiceName:      "simple",
+			ServiceNamespace: "default",
+			ServicePort:      v1.ServicePort{},
+		}},
+	})
+	et.OnChange(withCluster)
+
+	e1 := endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("192.168.183.24"),
+		Ports: ports(
+			port("", 8080),
+		),
+	})
+	et.OnAdd(e1)
+
+	want := []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints:   envoy_v3.WeightedEndpoints(1, envoy_v3.SocketAddress("192.168.183.24", 8080)),
+		},
+	}
+	protobuf.RequireEqual(t, want, et.Contents())
+
+	// The cluster is removed, and so are its endpoints, but the
+	// load assignment is kept as it was.
+	et.OnChange(&dag.DAG{})
+	et.OnDelete(e1)
+	protobuf.RequireEqual(t, want, et.Contents())
+
+	// Once the linger period expires, the load assignment is removed.
+	require.Eventually(t, func() bool {
+		return len(et.Contents()) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// A cluster that comes back before its linger period
+	// expires is served as normal.
+	et.OnChange(withCluster)
+	et.OnChange(&dag.DAG{})
+	et.OnChange(withCluster)
+	et.OnAdd(e1)
+	time.Sleep(2 * et.Linger)
+	protobuf.RequireEqual(t, want, et.Contents())
+}
+
+// Test that the endpoints of a terminating Pod are drained before
+// the Pod is removed from the Endpoints.
+func TestEndpointsTranslatorTerminatingPod(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+
+	require.NoError(t, et.cache.SetClusters([]*dag.ServiceCluster{
+		{
+			ClusterName: "default/simple",
+			Services: []dag.WeightedService{{
+				Weight:           1,
+				ServiceName:      "simple",
+				ServiceNamespace: "default",
+				ServicePort:      v1.ServicePort{},
+			}},
+		},
+	}))
+
+	addrs := addresses("192.168.183.24", "192.168.183.25")
+	addrs[0].TargetRef = &v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "simple-a"}
+	addrs[1].TargetRef = &v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "simple-b"}
+
+	et.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addrs,
+		Ports: ports(
+			port("", 8080),
+		),
+	}))
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "simple-a",
+		},
+	}
+	et.OnAdd(pod)
+
+	// Assert a Pod that is not terminating is not drained.
+	want := []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.24", 8080),
+				envoy_v3.SocketAddress("192.168.183.25", 8080),
+			),
+		},
+	}
+
+	protobuf.RequireEqual(t, want, et.Contents())
+
+	terminating := pod.DeepCopy()
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	et.OnUpdate(pod, terminating)
+
+	// Assert the endpoint of the terminating Pod is drained.
+	want = []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+					envoy_v3.DrainingLBEndpoint(envoy_v3.SocketAddress("192.168.183.24", 8080)),
+					envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.168.183.25", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}},
+		},
+	}
+
+	protobuf.RequireEqual(t, want, et.Contents())
+
+	et.OnDelete(terminating)
+
+	// Assert the endpoint remains drained when the Pod is
+	// deleted before it is removed from the Endpoints.
+	protobuf.RequireEqual(t, want, et.Contents())
+
+	et.OnUpdate(
+		endpoints("default", "simple", v1.EndpointSubset{
+			Addresses: addrs,
+			Ports: ports(
+				port("", 8080),
+			),
+		}),
+		endpoints("default", "simple", v1.EndpointSubset{
+			Addresses: addrs[1:],
+			Ports: ports(
+				port("", 8080),
+			),
+		}),
+	)
+
+	// Assert the endpoint is removed with the Pod's address.
+	want = []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: envoy_v3.WeightedEndpoints(1,
+				envoy_v3.SocketAddress("192.168.183.25", 8080),
+			),
+		},
+	}
+
+	protobuf.RequireEqual(t, want, et.Contents())
+
+	// Assert a Pod that is deleted without first being seen
+	// as terminating is drained until it leaves the Endpoints.
+	other := pod.DeepCopy()
+	other.Name = "simple-b"
+	et.OnDelete(other)
+
+	want = []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+					envoy_v3.DrainingLBEndpoint(envoy_v3.SocketAddress("192.168.183.25", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}},
+		},
+	}
+
+	protobuf.RequireEqual(t, want, et.Contents())
+}
+
 // Test that a cluster with weighted services propagates the weights.
 func TestEndpointsTranslatorWeightedService(t *testing.T) {
 	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
@@ -0,0 +1,82 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"time"
+)
+
+// lingerer tracks the resources that have been removed from a
+// cache, so that the cache can keep serving them for a while. This
+// gives Envoy time to finish the requests that are using a cluster,
+// and to apply the route configuration that stops using it, before
+// the cluster is removed.
+//
+// lingerer is not safe for concurrent use; callers must hold the
+// lock of the cache they track.
+type lingerer struct {
+	// expiries holds the time at which each lingering
+	// resource is removed, indexed by name.
+	expiries map[string]time.Time
+}
+
+// linger returns true if the resource name, which is no longer in
+// the cache, should still be served. The first time a resource is
+// removed, it lingers for period, and expire is called once it may
+// be removed. If period is not positive, resources don't linger.
+func (l *lingerer) linger(name string, period time.Duration, expire func()) bool {
+	if period <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	expiry, ok := l.expiries[name]
+	if !ok {
+		if l.expiries == nil {
+			l.expiries = map[string]time.Time{}
+		}
+		expiry = now.Add(period)
+		l.expiries[name] = expiry
+		time.AfterFunc(period, expire)
+	}
+
+	if now.Before(expiry) {
+		return true
+	}
+
+	delete(l.expiries, name)
+	return false
+}
+
+// present records that the resource name is in the cache, so
+// that it no longer lingers.
+func (l *lingerer) present(name string) {
+	delete(l.expiries, name)
+}
+
+// expired returns the names of the lingering resources whose
+// linger period has expired, and forgets them.
+func (l *lingerer) expired() []string {
+	now := time.Now()
+
+	var names []string
+	for name, expiry := range l.expiries {
+		if !now.Before(expiry) {
+			names = append(names, name)
+			delete(l.expiries, name)
+		}
+	}
+
+	return names
+}
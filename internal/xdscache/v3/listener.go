@@ -14,9 +14,10 @@
 package v3
 
 import (
+	"fmt"
 	"path"
 	"sort"
-	"sync"
+	"sync/atomic"
 
 	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
@@ -36,21 +37,38 @@ import (
 
 // nolint:golint
 const (
-	ENVOY_HTTP_LISTENER            = "ingress_http"
-	ENVOY_FALLBACK_ROUTECONFIG     = "ingress_fallbackcert"
-	ENVOY_HTTPS_LISTENER           = "ingress_https"
-	DEFAULT_HTTP_ACCESS_LOG        = "/dev/stdout"
-	DEFAULT_HTTP_LISTENER_ADDRESS  = "0.0.0.0"
-	DEFAULT_HTTP_LISTENER_PORT     = 8080
-	DEFAULT_HTTPS_ACCESS_LOG       = "/dev/stdout"
-	DEFAULT_HTTPS_LISTENER_ADDRESS = DEFAULT_HTTP_LISTENER_ADDRESS
-	DEFAULT_HTTPS_LISTENER_PORT    = 8443
+	ENVOY_HTTP_LISTENER              = "ingress_http"
+	ENVOY_FALLBACK_ROUTECONFIG       = "ingress_fallbackcert"
+	ENVOY_HTTPS_LISTENER             = "ingress_https"
+	DEFAULT_HTTP_ACCESS_LOG          = "/dev/stdout"
+	DEFAULT_HTTP_LISTENER_ADDRESS    = "0.0.0.0"
+	DEFAULT_HTTP_LISTENER_PORT       = 8080
+	DEFAULT_HTTPS_ACCESS_LOG         = "/dev/stdout"
+	DEFAULT_HTTPS_LISTENER_ADDRESS   = DEFAULT_HTTP_LISTENER_ADDRESS
+	DEFAULT_HTTPS_LISTENER_PORT      = 8443
+	DEFAULT_TCPPROXY_ACCESS_LOG      = "/dev/stdout"
+	DEFAULT_TCPPROXY_ACCESS_LOG_NAME = "contour-tcpproxy"
 )
 
 type Listener struct {
 	Name    string
 	Address string
 	Port    int
+
+	// AdditionalAddresses are bound by copies of the
+	// listener, named after the listener and the index
+	// of the address, e.g. ingress_http_1.
+	AdditionalAddresses []string
+
+	// ConnectionBalancer, if set, overrides the ListenerConfig's
+	// ConnectionBalancer for this listener. Valid values are
+	// 'exact' and 'none', which disables connection balancing.
+	ConnectionBalancer string
+
+	// DrainType, if set, overrides the ListenerConfig's
+	// DrainType for this listener. Valid values are
+	// 'default' and 'modify-only'.
+	DrainType string
 }
 
 // ListenerConfig holds configuration parameters for building Envoy Listeners.
@@ -103,6 +121,16 @@ type ListenerConfig struct {
 	// Defaults to a particular set of fields.
 	AccessLogFields config.AccessLogFields
 
+	// AccessLogHeaders lists the request and response headers
+	// whose values are captured in the HTTP access logs, unless
+	// overridden by a vhost.
+	AccessLogHeaders config.AccessLogHeaders
+
+	// AccessLogSource adds the object and virtual host that each
+	// request was routed by to the HTTP access logs. It must match
+	// the route cache's setting.
+	AccessLogSource bool
+
 	// RequestTimeout configures the request_timeout for all Connection Managers.
 	RequestTimeout timeout.Setting
 
@@ -127,10 +155,54 @@ type ListenerConfig struct {
 	// listeners.
 	AllowChunkedLength bool
 
+	// EnableTrailers enables the propagation of trailers on the HTTP1 options
+	// for all listeners.
+	EnableTrailers bool
+
+	// EnableRequestDecompression adds a decompressor filter for gzip encoded
+	// request bodies to all Connection Managers.
+	EnableRequestDecompression bool
+
 	// XffNumTrustedHops sets the number of additional ingress proxy hops from the
 	// right side of the x-forwarded-for HTTP header to trust.
 	XffNumTrustedHops uint32
 
+	// MaxRequestHeadersKB sets the max_request_headers_kb of all
+	// Connection Managers. If zero, Envoy's default is used.
+	MaxRequestHeadersKB uint32
+
+	// MaxRequestHeadersCount sets the common_http_protocol_options.max_headers_count
+	// of all Connection Managers. If zero, Envoy's default is used.
+	MaxRequestHeadersCount uint32
+
+	// DisableNormalizePath disables normalize_path on all Connection
+	// Managers, unless overridden by a vhost.
+	DisableNormalizePath bool
+
+	// DisableMergeSlashes disables merge_slashes on all Connection
+	// Managers, unless overridden by a vhost.
+	DisableMergeSlashes bool
+
+	// EscapedSlashesAction sets the path_with_escaped_slashes_action
+	// of all Connection Managers, unless overridden by a vhost. If not
+	// set, Envoy's default is used.
+	EscapedSlashesAction config.EscapedSlashesActionType
+
+	// HeadersWithUnderscoresAction sets the headers_with_underscores_action
+	// of all Connection Managers. If not set, such headers are allowed.
+	HeadersWithUnderscoresAction config.HeadersWithUnderscoresActionType
+
+	// ServerHeader configures the Server header of all Connection
+	// Managers, unless overridden by a vhost.
+	ServerHeader config.ServerHeaderParameters
+
+	// DrainType configures the drain_type of all listeners.
+	// Valid values are 'default' and 'modify-only'.
+	// The validated value is 'modify-only'.
+	// If no configuration is specified, Envoy drains listeners on
+	// modification, removal, hot restart and health check failure.
+	DrainType string
+
 	// ConnectionBalancer
 	// The validated value is 'exact'.
 	// If no configuration is specified, Envoy will not attempt to balance active connections between worker threads
@@ -139,6 +211,46 @@ type ListenerConfig struct {
 	// RateLimitConfig optionally configures the global Rate Limit Service to be
 	// used.
 	RateLimitConfig *RateLimitConfig
+
+	// TCPProxyAccessLog optionally configures a dedicated access log for
+	// TCP proxied connections. If not set, TCP proxied connections are
+	// logged to the HTTPS access log.
+	TCPProxyAccessLog *TCPProxyAccessLogConfig
+
+	// HealthListener optionally configures an additional static
+	// plaintext listener that serves Envoy's readiness status on
+	// a configurable path.
+	HealthListener *HealthListenerConfig
+
+	// TapPathPrefix is the prefix of the files that the tap filter
+	// writes the captured traffic of virtual hosts with a tap policy
+	// to. If not set, traffic is not captured.
+	TapPathPrefix string
+
+	// HealthVirtualHost is the name of the virtual host that the
+	// route cache adds to the HTTP listener's routes for load
+	// balancer health checks. If set, the HTTP listener is created
+	// even if no other virtual host is bound to it.
+	HealthVirtualHost string
+
+	// ConsolidateFilterChains, if set, serves the secure virtual
+	// hosts that can share a filter chain from one filter chain per
+	// group. See consolidatedRouteConfigName. The route cache must
+	// be configured with the same value.
+	ConsolidateFilterChains bool
+}
+
+type HealthListenerConfig struct {
+	Address string
+	Port    int
+	Path    string
+}
+
+type TCPProxyAccessLogConfig struct {
+	Type             config.TCPProxyAccessLogType
+	Path             string
+	ExtensionService types.NamespacedName
+	LogName          string
 }
 
 type RateLimitConfig struct {
@@ -243,20 +355,54 @@ func (lvc *ListenerConfig) accesslogFields() config.AccessLogFields {
 }
 
 func (lvc *ListenerConfig) newInsecureAccessLog() []*envoy_accesslog_v3.AccessLog {
+	return lvc.newAccessLog(lvc.httpAccessLog(), lvc.AccessLogHeaders)
+}
+
+func (lvc *ListenerConfig) newSecureAccessLog(headers config.AccessLogHeaders) []*envoy_accesslog_v3.AccessLog {
+	return lvc.newAccessLog(lvc.httpsAccessLog(), headers)
+}
+
+// newAccessLog returns the access log written to path, which
+// captures the given request and response headers.
+func (lvc *ListenerConfig) newAccessLog(path string, headers config.AccessLogHeaders) []*envoy_accesslog_v3.AccessLog {
 	switch lvc.accesslogType() {
 	case string(config.JSONAccessLog):
-		return envoy_v3.FileAccessLogJSON(lvc.httpAccessLog(), lvc.accesslogFields())
+		fields := append(append(config.AccessLogFields{}, lvc.accesslogFields()...), headers.AsFields()...)
+		if lvc.AccessLogSource {
+			fields = append(fields, envoy_v3.AccessLogSourceFields...)
+		}
+		return envoy_v3.FileAccessLogJSON(path, fields)
 	default:
-		return envoy_v3.FileAccessLogEnvoy(lvc.httpAccessLog())
+		return envoy_v3.FileAccessLogEnvoyWithHeaders(path, headers, lvc.AccessLogSource)
 	}
 }
 
-func (lvc *ListenerConfig) newSecureAccessLog() []*envoy_accesslog_v3.AccessLog {
-	switch lvc.accesslogType() {
-	case "json":
-		return envoy_v3.FileAccessLogJSON(lvc.httpsAccessLog(), lvc.accesslogFields())
+// newTCPProxyAccessLog returns the access log for TCP proxied
+// connections, or the HTTPS access log if none is configured.
+func (lvc *ListenerConfig) newTCPProxyAccessLog() []*envoy_accesslog_v3.AccessLog {
+	if lvc.TCPProxyAccessLog == nil {
+		// TCP proxied connections have no headers to capture.
+		return lvc.newSecureAccessLog(config.AccessLogHeaders{})
+	}
+
+	switch lvc.TCPProxyAccessLog.Type {
+	case config.GRPCTCPProxyAccessLog:
+		logName := lvc.TCPProxyAccessLog.LogName
+		if logName == "" {
+			logName = DEFAULT_TCPPROXY_ACCESS_LOG_NAME
+		}
+		return envoy_v3.GRPCAccessLogTCPProxy(lvc.TCPProxyAccessLog.ExtensionService, logName)
+	case config.FileTCPProxyAccessLog:
+		path := lvc.TCPProxyAccessLog.Path
+		if path == "" {
+			path = DEFAULT_TCPPROXY_ACCESS_LOG
+		}
+		if lvc.accesslogType() == string(config.JSONAccessLog) {
+			return envoy_v3.FileAccessLogJSON(path, config.DefaultTCPProxyFields)
+		}
+		return envoy_v3.FileAccessLogTCPProxy(path)
 	default:
-		return envoy_v3.FileAccessLogEnvoy(lvc.httpsAccessLog())
+		return lvc.newSecureAccessLog(config.AccessLogHeaders{})
 	}
 }
 
@@ -270,10 +416,20 @@ func (lvc *ListenerConfig) minTLSVersion() envoy_tls_v3.TlsParameters_TlsProtoco
 	return envoy_tls_v3.TlsParameters_TLSv1_2
 }
 
+// newRequestDecompressionFilter returns the request decompression
+// filter if it is enabled, or nil otherwise.
+func (lvc *ListenerConfig) newRequestDecompressionFilter() *http.HttpFilter {
+	if !lvc.EnableRequestDecompression {
+		return nil
+	}
+	return envoy_v3.FilterRequestDecompression()
+}
+
 // ListenerCache manages the contents of the gRPC LDS cache.
+// Its contents are replaced, never modified, so that reads
+// do not wait for updates.
 type ListenerCache struct {
-	mu           sync.Mutex
-	values       map[string]*envoy_listener_v3.Listener
+	values       atomic.Value // map[string]*envoy_listener_v3.Listener
 	staticValues map[string]*envoy_listener_v3.Listener
 
 	Config ListenerConfig
@@ -283,29 +439,39 @@ type ListenerCache struct {
 // NewListenerCache returns an instance of a ListenerCache
 func NewListenerCache(config ListenerConfig, address string, port int) *ListenerCache {
 	stats := envoy_v3.StatsListener(address, port)
+	staticValues := map[string]*envoy_listener_v3.Listener{
+		stats.Name: stats,
+	}
+
+	if hl := config.HealthListener; hl != nil {
+		health := envoy_v3.HealthListener(hl.Address, hl.Port, hl.Path)
+		staticValues[health.Name] = health
+	}
+
 	return &ListenerCache{
-		Config: config,
-		staticValues: map[string]*envoy_listener_v3.Listener{
-			stats.Name: stats,
-		},
+		Config:       config,
+		staticValues: staticValues,
 	}
 }
 
-// Update replaces the contents of the cache with the supplied map.
-func (c *ListenerCache) Update(v map[string]*envoy_listener_v3.Listener) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// load returns the current contents of the cache, which
+// must not be modified.
+func (c *ListenerCache) load() map[string]*envoy_listener_v3.Listener {
+	values, _ := c.values.Load().(map[string]*envoy_listener_v3.Listener)
+	return values
+}
 
-	c.values = v
+// Update replaces the contents of the cache with the supplied map,
+// which must not be modified afterwards.
+func (c *ListenerCache) Update(v map[string]*envoy_listener_v3.Listener) {
+	c.values.Store(v)
 	c.Cond.Notify()
 }
 
 // Contents returns a copy of the cache's contents.
 func (c *ListenerCache) Contents() []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
 	var values []*envoy_listener_v3.Listener
-	for _, v := range c.values {
+	for _, v := range c.load() {
 		values = append(values, v)
 	}
 	for _, v := range c.staticValues {
@@ -318,11 +484,10 @@ func (c *ListenerCache) Contents() []proto.Message {
 // Query returns the proto.Messages in the ListenerCache that match
 // a slice of strings
 func (c *ListenerCache) Query(names []string) []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	current := c.load()
 	var values []*envoy_listener_v3.Listener
 	for _, n := range names {
-		v, ok := c.values[n]
+		v, ok := current[n]
 		if !ok {
 			v, ok = c.staticValues[n]
 			if !ok {
@@ -351,17 +516,50 @@ type listenerVisitor struct {
 	*ListenerConfig
 
 	listeners        map[string]*envoy_listener_v3.Listener
-	httpListenerName string // Name of dag.VirtualHost encountered.
+	httpListenerName string             // Name of dag.VirtualHost encountered.
+	tappedHosts      []*dag.VirtualHost // dag.VirtualHosts with a tap policy.
+	forwardProxy     bool               // Whether a dag.VirtualHost is a forward proxy.
+
+	// filterChainGroups are the dag.SecureVirtualHosts that share
+	// a filter chain, by the name of their route configuration.
+	filterChainGroups map[string][]*dag.SecureVirtualHost
 }
 
 func visitListeners(root dag.Vertex, lvc *ListenerConfig) map[string]*envoy_listener_v3.Listener {
 	lv := listenerVisitor{
-		ListenerConfig: lvc.DefaultListeners(),
-		listeners:      lvc.SecureListeners(),
+		ListenerConfig:    lvc.DefaultListeners(),
+		listeners:         lvc.SecureListeners(),
+		filterChainGroups: map[string][]*dag.SecureVirtualHost{},
 	}
 
 	lv.visit(root)
 
+	groups := make([]string, 0, len(lv.filterChainGroups))
+	for name := range lv.filterChainGroups {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	for _, name := range groups {
+		lv.addConsolidatedFilterChain(name, lv.filterChainGroups[name])
+	}
+
+	if lv.httpListenerName == "" && lvc.HealthVirtualHost != "" {
+		lv.httpListenerName = ENVOY_HTTP_LISTENER
+	}
+
+	// The tap filter's match is built from the tapped hosts, which
+	// are visited in no particular order.
+	sort.Slice(lv.tappedHosts, func(i, j int) bool {
+		return lv.tappedHosts[i].Name < lv.tappedHosts[j].Name
+	})
+
+	// Routes to the dynamic forward proxy cluster
+	// need the filter to resolve their upstream host.
+	var forwardProxyFilter *http.HttpFilter
+	if lv.forwardProxy {
+		forwardProxyFilter = envoy_v3.FilterDynamicForwardProxy()
+	}
+
 	if httpListener, ok := lvc.HTTPListeners[lv.httpListenerName]; ok {
 
 		// Add a listener if there are vhosts bound to http.
@@ -378,8 +576,19 @@ func visitListeners(root dag.Vertex, lvc *ListenerConfig) map[string]*envoy_list
 			MaxConnectionDuration(lvc.MaxConnectionDuration).
 			ConnectionShutdownGracePeriod(lvc.ConnectionShutdownGracePeriod).
 			AllowChunkedLength(lvc.AllowChunkedLength).
+			EnableTrailers(lvc.EnableTrailers).
+			AddFilter(lvc.newRequestDecompressionFilter()).
 			NumTrustedHops(lvc.XffNumTrustedHops).
+			MaxRequestHeadersKB(lvc.MaxRequestHeadersKB).
+			MaxRequestHeadersCount(lvc.MaxRequestHeadersCount).
+			DisableNormalizePath(lvc.DisableNormalizePath).
+			DisableMergeSlashes(lvc.DisableMergeSlashes).
+			EscapedSlashesAction(lvc.EscapedSlashesAction).
+			HeadersWithUnderscoresAction(lvc.HeadersWithUnderscoresAction).
+			ServerHeader(lvc.ServerHeader).
 			AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(lv.RateLimitConfig))).
+			AddFilter(envoy_v3.FilterTap(lvc.TapPathPrefix, lv.tappedHosts...)).
+			AddFilter(forwardProxyFilter).
 			Get()
 
 		lv.listeners[httpListener.Name] = envoy_v3.Listener(
@@ -400,23 +609,138 @@ func visitListeners(root dag.Vertex, lvc *ListenerConfig) map[string]*envoy_list
 		sort.Stable(sorter.For(lv.listeners[ENVOY_HTTPS_LISTENER].FilterChains))
 	}
 
+	// Copy listeners to each of their additional addresses.
+	for _, l := range lvc.HTTPListeners {
+		lv.addAdditionalAddresses(l)
+	}
+	for _, l := range lvc.HTTPSListeners {
+		lv.addAdditionalAddresses(l)
+	}
+
 	// support more params of envoy listener
 
 	// 1. connection balancer
-	switch lvc.ConnectionBalancer {
-	case "exact":
-		for _, listener := range lv.listeners {
-			listener.ConnectionBalanceConfig = &envoy_listener_v3.Listener_ConnectionBalanceConfig{
-				BalanceType: &envoy_listener_v3.Listener_ConnectionBalanceConfig_ExactBalance_{
-					ExactBalance: &envoy_listener_v3.Listener_ConnectionBalanceConfig_ExactBalance{},
-				},
-			}
-		}
+	for _, listener := range lv.listeners {
+		listener.ConnectionBalanceConfig = connectionBalanceConfig(lvc.ConnectionBalancer)
+	}
+	for _, l := range lvc.HTTPListeners {
+		lv.setConnectionBalancer(l)
+	}
+	for _, l := range lvc.HTTPSListeners {
+		lv.setConnectionBalancer(l)
+	}
+
+	// 2. drain type
+	for _, listener := range lv.listeners {
+		listener.DrainType = drainType(lvc.DrainType)
+	}
+	for _, l := range lvc.HTTPListeners {
+		lv.setDrainType(l)
+	}
+	for _, l := range lvc.HTTPSListeners {
+		lv.setDrainType(l)
 	}
 
 	return lv.listeners
 }
 
+// addAdditionalAddresses adds a copy of the built listener l
+// for each of its additional addresses.
+func (v *listenerVisitor) addAdditionalAddresses(l Listener) {
+	listener, ok := v.listeners[l.Name]
+	if !ok {
+		return
+	}
+
+	for i, address := range l.AdditionalAddresses {
+		name := additionalListenerName(l.Name, i)
+		additional := proto.Clone(listener).(*envoy_listener_v3.Listener)
+		additional.Name = name
+		additional.Address = envoy_v3.SocketAddress(address, l.Port)
+
+		// An additional IPv6 address only accepts IPv6
+		// connections, since IPv4 connections are served
+		// by the listener's other addresses.
+		additional.Address.GetSocketAddress().Ipv4Compat = false
+
+		v.listeners[name] = additional
+	}
+}
+
+// additionalListenerName returns the name of the copy of the
+// named listener for its i'th additional address.
+func additionalListenerName(name string, i int) string {
+	return fmt.Sprintf("%s_%d", name, i+1)
+}
+
+// setConnectionBalancer sets the connection balancer of the built
+// listener l, and of its copies, if l overrides it.
+func (v *listenerVisitor) setConnectionBalancer(l Listener) {
+	if l.ConnectionBalancer == "" {
+		return
+	}
+
+	for _, listener := range v.copies(l) {
+		listener.ConnectionBalanceConfig = connectionBalanceConfig(l.ConnectionBalancer)
+	}
+}
+
+// setDrainType sets the drain type of the built listener l,
+// and of its copies, if l overrides it.
+func (v *listenerVisitor) setDrainType(l Listener) {
+	if l.DrainType == "" {
+		return
+	}
+
+	for _, listener := range v.copies(l) {
+		listener.DrainType = drainType(l.DrainType)
+	}
+}
+
+// copies returns the built listener l and its copies for
+// each of its additional addresses.
+func (v *listenerVisitor) copies(l Listener) []*envoy_listener_v3.Listener {
+	names := []string{l.Name}
+	for i := range l.AdditionalAddresses {
+		names = append(names, additionalListenerName(l.Name, i))
+	}
+
+	var listeners []*envoy_listener_v3.Listener
+	for _, name := range names {
+		if listener, ok := v.listeners[name]; ok {
+			listeners = append(listeners, listener)
+		}
+	}
+	return listeners
+}
+
+// drainType returns the listener drain type of the named drain
+// type, which is DEFAULT unless the drain type is 'modify-only'.
+func drainType(drain string) envoy_listener_v3.Listener_DrainType {
+	switch drain {
+	case "modify-only":
+		return envoy_listener_v3.Listener_MODIFY_ONLY
+	default:
+		return envoy_listener_v3.Listener_DEFAULT
+	}
+}
+
+// connectionBalanceConfig returns the listener connection balance
+// config of the named connection balancer, or nil if the connection
+// balancer is not 'exact'.
+func connectionBalanceConfig(balancer string) *envoy_listener_v3.Listener_ConnectionBalanceConfig {
+	switch balancer {
+	case "exact":
+		return &envoy_listener_v3.Listener_ConnectionBalanceConfig{
+			BalanceType: &envoy_listener_v3.Listener_ConnectionBalanceConfig_ExactBalance_{
+				ExactBalance: &envoy_listener_v3.Listener_ConnectionBalanceConfig_ExactBalance{},
+			},
+		}
+	default:
+		return nil
+	}
+}
+
 func envoyGlobalRateLimitConfig(config *RateLimitConfig) *envoy_v3.GlobalRateLimitConfig {
 	if config == nil {
 		return nil
@@ -458,21 +782,25 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 		// that we need to then double back at the end and add
 		// the listener properly
 		v.httpListenerName = vh.ListenerName
+		if vh.TapPolicy != nil {
+			v.tappedHosts = append(v.tappedHosts, vh)
+		}
+		if vh.ForwardProxy != nil {
+			v.forwardProxy = true
+		}
 	case *dag.SecureVirtualHost:
+		if v.ConsolidateFilterChains {
+			if name := consolidatedRouteConfigName(vh); name != "" {
+				v.filterChainGroups[name] = append(v.filterChainGroups[name], vh)
+				v.addFallbackFilterChain(vh, envoy_v3.ProtoNamesForVersions(v.DefaultHTTPVersions...))
+				return
+			}
+		}
+
 		var alpnProtos []string
 		var filters []*envoy_listener_v3.Filter
 
 		if vh.TCPProxy == nil {
-			var authFilter *http.HttpFilter
-
-			if vh.AuthorizationService != nil {
-				authFilter = envoy_v3.FilterExternalAuthz(
-					vh.AuthorizationService.Name,
-					vh.AuthorizationFailOpen,
-					vh.AuthorizationResponseTimeout,
-				)
-			}
-
 			// Create a uniquely named HTTP connection manager for
 			// this vhost, so that the SNI name the client requests
 			// only grants access to that host. See RFC 6066 for
@@ -480,33 +808,19 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 			// metrics prefix to keep compatibility with previous
 			// Contour versions since the metrics prefix will be
 			// coded into monitoring dashboards.
-			cm := envoy_v3.HTTPConnectionManagerBuilder().
-				Codec(envoy_v3.CodecForVersions(v.DefaultHTTPVersions...)).
-				AddFilter(envoy_v3.FilterMisdirectedRequests(vh.VirtualHost.Name)).
-				DefaultFilters().
-				AddFilter(authFilter).
-				RouteConfigName(path.Join("https", vh.VirtualHost.Name)).
-				MetricsPrefix(vh.ListenerName).
-				AccessLoggers(v.ListenerConfig.newSecureAccessLog()).
-				RequestTimeout(v.ListenerConfig.RequestTimeout).
-				ConnectionIdleTimeout(v.ListenerConfig.ConnectionIdleTimeout).
-				StreamIdleTimeout(v.ListenerConfig.StreamIdleTimeout).
-				DelayedCloseTimeout(v.ListenerConfig.DelayedCloseTimeout).
-				MaxConnectionDuration(v.ListenerConfig.MaxConnectionDuration).
-				ConnectionShutdownGracePeriod(v.ListenerConfig.ConnectionShutdownGracePeriod).
-				AllowChunkedLength(v.ListenerConfig.AllowChunkedLength).
-				NumTrustedHops(v.ListenerConfig.XffNumTrustedHops).
-				AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(v.RateLimitConfig))).
-				Get()
+			cm := v.secureHTTPConnectionManager(vh,
+				path.Join("https", vh.VirtualHost.Name),
+				envoy_v3.FilterMisdirectedRequests(vh.VirtualHost.Name),
+				envoy_v3.FilterTap(v.ListenerConfig.TapPathPrefix, &vh.VirtualHost))
 
 			filters = envoy_v3.Filters(cm)
 
-			alpnProtos = envoy_v3.ProtoNamesForVersions(v.DefaultHTTPVersions...)
+			alpnProtos = envoy_v3.ProtoNamesForVersions(v.httpVersions(vh)...)
 		} else {
 			filters = envoy_v3.Filters(
 				envoy_v3.TCPProxy(vh.ListenerName,
 					vh.TCPProxy,
-					v.ListenerConfig.newSecureAccessLog()),
+					v.ListenerConfig.newTCPProxyAccessLog()),
 			)
 
 			// Do not offer ALPN for TCP proxying, since
@@ -529,49 +843,266 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 				alpnProtos...)
 		}
 
-		v.listeners[vh.ListenerName].FilterChains = append(v.listeners[vh.ListenerName].FilterChains,
-			envoy_v3.FilterChainTLS(vh.VirtualHost.Name, downstreamTLS, filters))
-
-		// If this VirtualHost has enabled the fallback certificate then set a default
-		// FilterChain which will allow routes with this vhost to accept non-SNI TLS requests.
-		// Note that we don't add the misdirected requests filter on this chain because at this
-		// point we don't actually know the full set of server names that will be bound to the
-		// filter chain through the ENVOY_FALLBACK_ROUTECONFIG route configuration.
-		if vh.FallbackCertificate != nil && !envoy_v3.ContainsFallbackFilterChain(v.listeners[vh.ListenerName].FilterChains) {
-			// Construct the downstreamTLSContext passing the configured fallbackCertificate. The TLS minProtocolVersion will use
-			// the value defined in the Contour Configuration file if defined.
-			downstreamTLS = envoy_v3.DownstreamTLSContext(
-				vh.FallbackCertificate,
-				v.ListenerConfig.minTLSVersion(),
-				v.ListenerConfig.CipherSuites,
-				vh.DownstreamValidation,
-				alpnProtos...)
-
-			cm := envoy_v3.HTTPConnectionManagerBuilder().
-				DefaultFilters().
-				RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
-				MetricsPrefix(vh.ListenerName).
-				AccessLoggers(v.ListenerConfig.newSecureAccessLog()).
-				RequestTimeout(v.ListenerConfig.RequestTimeout).
-				ConnectionIdleTimeout(v.ListenerConfig.ConnectionIdleTimeout).
-				StreamIdleTimeout(v.ListenerConfig.StreamIdleTimeout).
-				DelayedCloseTimeout(v.ListenerConfig.DelayedCloseTimeout).
-				MaxConnectionDuration(v.ListenerConfig.MaxConnectionDuration).
-				ConnectionShutdownGracePeriod(v.ListenerConfig.ConnectionShutdownGracePeriod).
-				AllowChunkedLength(v.ListenerConfig.AllowChunkedLength).
-				NumTrustedHops(v.ListenerConfig.XffNumTrustedHops).
-				AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(v.RateLimitConfig))).
-				Get()
-
-			// Default filter chain
-			filters = envoy_v3.Filters(cm)
+		fc := envoy_v3.FilterChainTLS(vh.VirtualHost.Name, downstreamTLS, filters)
 
-			v.listeners[vh.ListenerName].FilterChains = append(v.listeners[vh.ListenerName].FilterChains,
-				envoy_v3.FilterChainTLSFallback(downstreamTLS, filters))
+		// Restrict the filter chain to the permitted client addresses so
+		// that connections from any other source do not match and are closed.
+		if vh.TCPProxy != nil && len(vh.TCPProxy.AllowedSourceRanges) > 0 {
+			fc.FilterChainMatch.SourcePrefixRanges = envoy_v3.SourcePrefixRanges(vh.TCPProxy.AllowedSourceRanges)
 		}
 
+		v.listeners[vh.ListenerName].FilterChains = append(v.listeners[vh.ListenerName].FilterChains, fc)
+
+		v.addFallbackFilterChain(vh, envoy_v3.ProtoNamesForVersions(v.DefaultHTTPVersions...))
+
+	case *dag.Listener:
+		// Add the catch-all filter chain for TLS connections that
+		// don't present an SNI server name, then recurse into the
+		// listener's virtual hosts.
+		if vh.FallbackTCPProxy != nil {
+			v.listeners[ENVOY_HTTPS_LISTENER].FilterChains = append(v.listeners[ENVOY_HTTPS_LISTENER].FilterChains,
+				envoy_v3.FilterChainTLSPassthroughFallback(envoy_v3.Filters(
+					envoy_v3.TCPProxy(ENVOY_HTTPS_LISTENER,
+						vh.FallbackTCPProxy,
+						v.ListenerConfig.newTCPProxyAccessLog()),
+				)))
+		}
+		vertex.Visit(v.visit)
 	default:
 		// recurse
 		vertex.Visit(v.visit)
 	}
 }
+
+// secureHTTPConnectionManager returns the HTTP connection manager of the
+// filter chain of vh, which routes requests with the named route
+// configuration.
+func (v *listenerVisitor) secureHTTPConnectionManager(vh *dag.SecureVirtualHost, routeConfigName string, misdirectedFilter, tapFilter *http.HttpFilter) *envoy_listener_v3.Filter {
+	var authFilter *http.HttpFilter
+	var oauth2Filter *http.HttpFilter
+
+	if vh.AuthorizationService != nil {
+		authFilter = envoy_v3.FilterExternalAuthz(
+			vh.AuthorizationService.Name,
+			vh.AuthorizationFailOpen,
+			vh.AuthorizationResponseTimeout,
+		)
+	}
+
+	if vh.OIDCPolicy != nil {
+		oauth2Filter = envoy_v3.FilterOAuth2(vh.VirtualHost.Name, vh.OIDCPolicy)
+	}
+
+	maxConnectionDuration := v.ListenerConfig.MaxConnectionDuration
+	if !vh.MaxConnectionDuration.UseDefault() {
+		maxConnectionDuration = vh.MaxConnectionDuration
+	}
+
+	maxRequestHeadersKB := v.ListenerConfig.MaxRequestHeadersKB
+	if vh.MaxRequestHeadersKB > 0 {
+		maxRequestHeadersKB = vh.MaxRequestHeadersKB
+	}
+	maxRequestHeadersCount := v.ListenerConfig.MaxRequestHeadersCount
+	if vh.MaxRequestHeadersCount > 0 {
+		maxRequestHeadersCount = vh.MaxRequestHeadersCount
+	}
+
+	pathNormalization := dag.PathNormalization{
+		DisableNormalizePath: v.ListenerConfig.DisableNormalizePath,
+		DisableMergeSlashes:  v.ListenerConfig.DisableMergeSlashes,
+		EscapedSlashesAction: v.ListenerConfig.EscapedSlashesAction,
+	}
+	if vh.PathNormalization != nil {
+		pathNormalization = *vh.PathNormalization
+		if pathNormalization.EscapedSlashesAction == "" {
+			pathNormalization.EscapedSlashesAction = v.ListenerConfig.EscapedSlashesAction
+		}
+	}
+
+	var http10Policy dag.HTTP10Policy
+	if vh.HTTP10Policy != nil {
+		http10Policy = *vh.HTTP10Policy
+	}
+
+	accessLogHeaders := v.ListenerConfig.AccessLogHeaders
+	if vh.AccessLogHeaders != nil {
+		accessLogHeaders = *vh.AccessLogHeaders
+	}
+
+	serverHeader := v.ListenerConfig.ServerHeader
+	if vh.ServerHeader != nil {
+		serverHeader = serverHeader.Merge(*vh.ServerHeader)
+	}
+
+	return envoy_v3.HTTPConnectionManagerBuilder().
+		Codec(envoy_v3.CodecForVersions(v.httpVersions(vh)...)).
+		AddFilter(misdirectedFilter).
+		DefaultFilters().
+		AddFilter(oauth2Filter).
+		AddFilter(authFilter).
+		AddFilter(envoy_v3.FilterAdmissionControl(vh.ConcurrencyPolicy)).
+		AddFilter(tapFilter).
+		RouteConfigName(routeConfigName).
+		MetricsPrefix(vh.ListenerName).
+		AccessLoggers(v.ListenerConfig.newSecureAccessLog(accessLogHeaders)).
+		RequestTimeout(v.ListenerConfig.RequestTimeout).
+		ConnectionIdleTimeout(v.ListenerConfig.ConnectionIdleTimeout).
+		StreamIdleTimeout(v.ListenerConfig.StreamIdleTimeout).
+		DelayedCloseTimeout(v.ListenerConfig.DelayedCloseTimeout).
+		MaxConnectionDuration(maxConnectionDuration).
+		ConnectionShutdownGracePeriod(v.ListenerConfig.ConnectionShutdownGracePeriod).
+		AllowChunkedLength(v.ListenerConfig.AllowChunkedLength).
+		EnableTrailers(v.ListenerConfig.EnableTrailers).
+		AddFilter(v.ListenerConfig.newRequestDecompressionFilter()).
+		NumTrustedHops(v.ListenerConfig.XffNumTrustedHops).
+		MaxRequestHeadersKB(maxRequestHeadersKB).
+		MaxRequestHeadersCount(maxRequestHeadersCount).
+		DisableNormalizePath(pathNormalization.DisableNormalizePath).
+		DisableMergeSlashes(pathNormalization.DisableMergeSlashes).
+		EscapedSlashesAction(pathNormalization.EscapedSlashesAction).
+		DisableHTTP10(http10Policy.Disabled).
+		DefaultHostForHTTP10(http10Policy.DefaultHost).
+		HeadersWithUnderscoresAction(v.ListenerConfig.HeadersWithUnderscoresAction).
+		ServerHeader(serverHeader).
+		AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(v.RateLimitConfig))).
+		Get()
+}
+
+// httpVersions returns the HTTP versions that vh offers, which
+// are the listener's unless vh overrides them.
+func (v *listenerVisitor) httpVersions(vh *dag.SecureVirtualHost) []envoy_v3.HTTPVersionType {
+	if len(vh.HTTPVersions) == 0 {
+		return v.DefaultHTTPVersions
+	}
+
+	var versions []envoy_v3.HTTPVersionType
+	for _, version := range vh.HTTPVersions {
+		switch version {
+		case config.HTTPVersion1:
+			versions = append(versions, envoy_v3.HTTPVersion1)
+		case config.HTTPVersion2:
+			versions = append(versions, envoy_v3.HTTPVersion2)
+		}
+	}
+	return versions
+}
+
+// addFallbackFilterChain adds the filter chain for the fallback
+// certificate to the listener of vh.
+func (v *listenerVisitor) addFallbackFilterChain(vh *dag.SecureVirtualHost, alpnProtos []string) {
+	// If this VirtualHost has enabled the fallback certificate then set a default
+	// FilterChain which will allow routes with this vhost to accept non-SNI TLS requests.
+	// Note that we don't add the misdirected requests filter on this chain because at this
+	// point we don't actually know the full set of server names that will be bound to the
+	// filter chain through the ENVOY_FALLBACK_ROUTECONFIG route configuration.
+	if vh.FallbackCertificate != nil && !envoy_v3.ContainsFallbackFilterChain(v.listeners[vh.ListenerName].FilterChains) {
+		// Construct the downstreamTLSContext passing the configured fallbackCertificate. The TLS minProtocolVersion will use
+		// the value defined in the Contour Configuration file if defined.
+		downstreamTLS := envoy_v3.DownstreamTLSContext(
+			vh.FallbackCertificate,
+			v.ListenerConfig.minTLSVersion(),
+			v.ListenerConfig.CipherSuites,
+			vh.DownstreamValidation,
+			alpnProtos...)
+
+		cm := envoy_v3.HTTPConnectionManagerBuilder().
+			DefaultFilters().
+			RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
+			MetricsPrefix(vh.ListenerName).
+			AccessLoggers(v.ListenerConfig.newSecureAccessLog(v.ListenerConfig.AccessLogHeaders)).
+			RequestTimeout(v.ListenerConfig.RequestTimeout).
+			ConnectionIdleTimeout(v.ListenerConfig.ConnectionIdleTimeout).
+			StreamIdleTimeout(v.ListenerConfig.StreamIdleTimeout).
+			DelayedCloseTimeout(v.ListenerConfig.DelayedCloseTimeout).
+			MaxConnectionDuration(v.ListenerConfig.MaxConnectionDuration).
+			ConnectionShutdownGracePeriod(v.ListenerConfig.ConnectionShutdownGracePeriod).
+			AllowChunkedLength(v.ListenerConfig.AllowChunkedLength).
+			EnableTrailers(v.ListenerConfig.EnableTrailers).
+			AddFilter(v.ListenerConfig.newRequestDecompressionFilter()).
+			NumTrustedHops(v.ListenerConfig.XffNumTrustedHops).
+			MaxRequestHeadersKB(v.ListenerConfig.MaxRequestHeadersKB).
+			MaxRequestHeadersCount(v.ListenerConfig.MaxRequestHeadersCount).
+			DisableNormalizePath(v.ListenerConfig.DisableNormalizePath).
+			DisableMergeSlashes(v.ListenerConfig.DisableMergeSlashes).
+			EscapedSlashesAction(v.ListenerConfig.EscapedSlashesAction).
+			HeadersWithUnderscoresAction(v.ListenerConfig.HeadersWithUnderscoresAction).
+			ServerHeader(v.ListenerConfig.ServerHeader).
+			AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(v.RateLimitConfig))).
+			Get()
+
+		// Default filter chain
+		filters := envoy_v3.Filters(cm)
+
+		v.listeners[vh.ListenerName].FilterChains = append(v.listeners[vh.ListenerName].FilterChains,
+			envoy_v3.FilterChainTLSFallback(downstreamTLS, filters))
+	}
+}
+
+// addConsolidatedFilterChain adds a single filter chain that matches
+// the names of all the given virtual hosts, and routes their requests
+// with the named route configuration.
+func (v *listenerVisitor) addConsolidatedFilterChain(routeConfigName string, vhosts []*dag.SecureVirtualHost) {
+	sort.Slice(vhosts, func(i, j int) bool {
+		return vhosts[i].VirtualHost.Name < vhosts[j].VirtualHost.Name
+	})
+
+	serverNames := make([]string, 0, len(vhosts))
+	tappedHosts := make([]*dag.VirtualHost, 0, len(vhosts))
+	for _, vh := range vhosts {
+		serverNames = append(serverNames, vh.VirtualHost.Name)
+		tappedHosts = append(tappedHosts, &vh.VirtualHost)
+	}
+
+	// The virtual hosts of a group only differ in their names and
+	// routes, so the first one stands in for the others. As a client
+	// could request any of the names on a connection, the :authority
+	// of each request is checked against the SNI name of its connection.
+	vh := vhosts[0]
+	alpnProtos := envoy_v3.ProtoNamesForVersions(v.DefaultHTTPVersions...)
+
+	cm := v.secureHTTPConnectionManager(vh,
+		routeConfigName,
+		envoy_v3.FilterMisdirectedRequestsBySNI(),
+		envoy_v3.FilterTap(v.ListenerConfig.TapPathPrefix, tappedHosts...))
+
+	// Choose the higher of the configured or requested TLS version.
+	vers := v.ListenerConfig.minTLSVersion()
+	if requested := envoy_v3.ParseTLSVersion(vh.MinTLSVersion); requested > vers {
+		vers = requested
+	}
+
+	downstreamTLS := envoy_v3.DownstreamTLSContext(
+		vh.Secret,
+		vers,
+		v.ListenerConfig.CipherSuites,
+		nil,
+		alpnProtos...)
+
+	fc := envoy_v3.FilterChainTLS(serverNames[0], downstreamTLS, envoy_v3.Filters(cm))
+	fc.FilterChainMatch.ServerNames = serverNames
+
+	v.listeners[vh.ListenerName].FilterChains = append(v.listeners[vh.ListenerName].FilterChains, fc)
+}
+
+// consolidatedRouteConfigName returns the name of the route configuration
+// of the group of secure virtual hosts that vh can share a filter chain
+// with, or "" if vh needs a filter chain of its own. Virtual hosts share
+// a filter chain when they are bound to the same listener, terminate TLS
+// with the same secret and minimum TLS version, and have none of the
+// settings that are configured on the filter chain of each virtual host.
+func consolidatedRouteConfigName(vh *dag.SecureVirtualHost) string {
+	switch {
+	case vh.Secret == nil, vh.TCPProxy != nil, vh.VirtualHost.Name == "*":
+		return ""
+	case vh.DownstreamValidation != nil, vh.AuthorizationService != nil, vh.OIDCPolicy != nil:
+		return ""
+	case !vh.MaxConnectionDuration.UseDefault(), vh.MaxRequestHeadersKB > 0, vh.MaxRequestHeadersCount > 0:
+		return ""
+	case vh.PathNormalization != nil, vh.HTTP10Policy != nil, vh.ConcurrencyPolicy != nil:
+		return ""
+	case vh.AccessLogHeaders != nil, vh.ServerHeader != nil, len(vh.HTTPVersions) > 0:
+		return ""
+	}
+
+	return path.Join("https", vh.ListenerName, vh.Secret.Namespace(), vh.Secret.Name(), vh.MinTLSVersion)
+}
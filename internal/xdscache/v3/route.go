@@ -14,10 +14,12 @@
 package v3
 
 import (
+	"net/http"
 	"path"
 	"sort"
-	"sync"
+	"sync/atomic"
 
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/golang/protobuf/proto"
@@ -27,31 +29,86 @@ import (
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"github.com/projectcontour/contour/internal/sorter"
+	"github.com/projectcontour/contour/pkg/config"
 )
 
 // RouteCache manages the contents of the gRPC RDS cache.
 type RouteCache struct {
-	mu     sync.Mutex
-	values map[string]*envoy_route_v3.RouteConfiguration
+	// RouteOrdering selects the order of the routes in each
+	// virtual host. If not set, config.DefaultRouteOrdering is used.
+	RouteOrdering config.RouteOrderingType
+
+	// RequestHeadersPolicy and ResponseHeadersPolicy are applied
+	// to all routes, beneath the policies of each route and service.
+	// See dag.GlobalHeadersPolicy.
+	RequestHeadersPolicy  *dag.HeadersPolicy
+	ResponseHeadersPolicy *dag.HeadersPolicy
+
+	// CorrelationHeader, if set, names a request header that is set
+	// to the request ID of each request on all routes. As Envoy
+	// preserves the X-Request-Id sent by clients, this is the same
+	// ID that is sent to upstream services in X-Request-Id.
+	CorrelationHeader string
+
+	// VirtualHostStats enables per virtual host request statistics.
+	// It is off by default, as it adds a set of statistics for every
+	// virtual host.
+	VirtualHostStats bool
+
+	// HealthVirtualHost, if set, names a virtual host that is added
+	// to the HTTP listener's routes, on which Envoy answers every
+	// request with 200 OK itself. It takes the place of any virtual
+	// host with the same name, as Envoy rejects duplicate domains.
+	HealthVirtualHost string
+
+	// ConsolidateFilterChains, if set, moves the virtual hosts that
+	// share a filter chain into the route configuration of their
+	// group. It must match the listener cache's setting.
+	ConsolidateFilterChains bool
+
+	// HTTPSRedirectScheme and HTTPSRedirectPort, if set, are the
+	// externally visible scheme and port that requests to routes
+	// that require TLS are redirected to, for when a load balancer
+	// in front of Envoy terminates TLS.
+	HTTPSRedirectScheme string
+	HTTPSRedirectPort   uint32
+
+	// TrustForwardedProto, if set, routes the requests to routes
+	// that require TLS that have X-Forwarded-Proto: https set by
+	// a load balancer that terminated TLS, rather than redirecting
+	// them.
+	TrustForwardedProto bool
+
+	// AccessLogSource, if set, records the object that each route was
+	// built from and its virtual host in the route's metadata, where
+	// the access logs read them. It must match the listener cache's
+	// setting.
+	AccessLogSource bool
+
+	// values holds the contents of the cache, which are replaced,
+	// never modified, so that reads do not wait for updates.
+	values atomic.Value // map[string]*envoy_route_v3.RouteConfiguration
 	contour.Cond
 }
 
-// Update replaces the contents of the cache with the supplied map.
-func (c *RouteCache) Update(v map[string]*envoy_route_v3.RouteConfiguration) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// load returns the current contents of the cache, which
+// must not be modified.
+func (c *RouteCache) load() map[string]*envoy_route_v3.RouteConfiguration {
+	values, _ := c.values.Load().(map[string]*envoy_route_v3.RouteConfiguration)
+	return values
+}
 
-	c.values = v
+// Update replaces the contents of the cache with the supplied map,
+// which must not be modified afterwards.
+func (c *RouteCache) Update(v map[string]*envoy_route_v3.RouteConfiguration) {
+	c.values.Store(v)
 	c.Cond.Notify()
 }
 
 // Contents returns a copy of the cache's contents.
 func (c *RouteCache) Contents() []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	var values []*envoy_route_v3.RouteConfiguration
-	for _, v := range c.values {
+	for _, v := range c.load() {
 		values = append(values, v)
 	}
 
@@ -61,12 +118,11 @@ func (c *RouteCache) Contents() []proto.Message {
 
 // Query searches the RouteCache for the named RouteConfiguration entries.
 func (c *RouteCache) Query(names []string) []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	current := c.load()
 
 	var values []*envoy_route_v3.RouteConfiguration
 	for _, n := range names {
-		v, ok := c.values[n]
+		v, ok := current[n]
 		if !ok {
 			// if there is no route registered with the cache
 			// we return a blank route configuration. This is
@@ -89,15 +145,140 @@ func (c *RouteCache) Query(names []string) []proto.Message {
 func (*RouteCache) TypeURL() string { return resource.RouteType }
 
 func (c *RouteCache) OnChange(root *dag.DAG) {
-	routes := visitRoutes(root)
+	routes := visitRoutes(root, c.RouteOrdering, httpsRedirect{
+		scheme:              c.HTTPSRedirectScheme,
+		port:                c.HTTPSRedirectPort,
+		trustForwardedProto: c.TrustForwardedProto,
+	}, c.AccessLogSource)
+	if c.ConsolidateFilterChains {
+		consolidateSecureRoutes(root, routes)
+	}
+	if c.HealthVirtualHost != "" {
+		addHealthVirtualHost(routes[ENVOY_HTTP_LISTENER], c.HealthVirtualHost)
+	}
+	for _, rc := range routes {
+		addGlobalHeaders(rc, c.RequestHeadersPolicy, c.ResponseHeadersPolicy)
+		if c.CorrelationHeader != "" {
+			addCorrelationHeader(rc, c.CorrelationHeader)
+		}
+		if c.VirtualHostStats {
+			addVirtualHostStats(rc)
+		}
+	}
 	c.Update(routes)
 }
 
+// addGlobalHeaders adds the request and response headers policies
+// to the route configuration. Envoy applies route configuration
+// headers before those of virtual hosts, routes and clusters.
+func addGlobalHeaders(rc *envoy_route_v3.RouteConfiguration, request, response *dag.HeadersPolicy) {
+	if request != nil {
+		rc.RequestHeadersToAdd = append(rc.RequestHeadersToAdd, envoy_v3.HeaderValueList(request.Set, false)...)
+		rc.RequestHeadersToRemove = append(rc.RequestHeadersToRemove, request.Remove...)
+	}
+	if response != nil {
+		rc.ResponseHeadersToAdd = append(rc.ResponseHeadersToAdd, envoy_v3.HeaderValueList(response.Set, false)...)
+		rc.ResponseHeadersToRemove = append(rc.ResponseHeadersToRemove, response.Remove...)
+	}
+}
+
+// addCorrelationHeader sets the named request header to the
+// request ID in the route configuration.
+func addCorrelationHeader(rc *envoy_route_v3.RouteConfiguration, name string) {
+	rc.RequestHeadersToAdd = append(rc.RequestHeadersToAdd, envoy_v3.HeaderValueList(map[string]string{
+		name: "%REQ(X-REQUEST-ID)%",
+	}, false)...)
+}
+
+// addVirtualHostStats adds a virtual cluster matching all requests
+// to each virtual host of the route configuration.
+func addVirtualHostStats(rc *envoy_route_v3.RouteConfiguration) {
+	for _, vh := range rc.VirtualHosts {
+		vh.VirtualClusters = append(vh.VirtualClusters, envoy_v3.VirtualHostStatsCluster())
+	}
+}
+
+// addHealthVirtualHost adds a virtual host for hostname to the
+// route configuration that answers all requests with 200 OK,
+// removing hostname from the domains of the other virtual hosts.
+func addHealthVirtualHost(rc *envoy_route_v3.RouteConfiguration, hostname string) {
+	var vhosts []*envoy_route_v3.VirtualHost
+	for _, vh := range rc.VirtualHosts {
+		var domains []string
+		for _, d := range vh.Domains {
+			if d != hostname {
+				domains = append(domains, d)
+			}
+		}
+		if len(domains) == 0 {
+			continue
+		}
+		vh.Domains = domains
+		vhosts = append(vhosts, vh)
+	}
+
+	rc.VirtualHosts = append(vhosts, envoy_v3.VirtualHost(hostname, &envoy_route_v3.Route{
+		Match: envoy_v3.RouteMatch(&dag.Route{
+			PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+		}),
+		Action: envoy_v3.RouteDirectResponse(&dag.DirectResponse{StatusCode: http.StatusOK}),
+	}))
+	sort.Stable(sorter.For(rc.VirtualHosts))
+}
+
+// addForwardProxies adds the virtual hosts of forward proxies to the
+// route configuration. As Envoy rejects duplicate domains, domains
+// that are already matched by another virtual host are left out of
+// the forward proxy, and a forward proxy left without domains is not
+// added at all.
+func addForwardProxies(rc *envoy_route_v3.RouteConfiguration, vhosts []*envoy_route_v3.VirtualHost) {
+	claimed := map[string]bool{}
+	for _, vh := range rc.VirtualHosts {
+		for _, d := range vh.Domains {
+			claimed[d] = true
+		}
+	}
+
+	sort.Stable(sorter.For(vhosts))
+	for _, vh := range vhosts {
+		var domains []string
+		for _, d := range vh.Domains {
+			if !claimed[d] {
+				claimed[d] = true
+				domains = append(domains, d)
+			}
+		}
+		if len(domains) == 0 {
+			continue
+		}
+		vh.Domains = domains
+		rc.VirtualHosts = append(rc.VirtualHosts, vh)
+	}
+}
+
+// httpsRedirect configures how requests to routes that
+// require TLS are redirected to HTTPS. See RouteCache.
+type httpsRedirect struct {
+	scheme              string
+	port                uint32
+	trustForwardedProto bool
+}
+
 type routeVisitor struct {
-	routes map[string]*envoy_route_v3.RouteConfiguration
+	routes   map[string]*envoy_route_v3.RouteConfiguration
+	ordering config.RouteOrderingType
+	redirect httpsRedirect
+
+	// sourceMetadata records the source of each
+	// route in its metadata. See RouteSourceMetadata.
+	sourceMetadata bool
+
+	// forwardProxies are the virtual hosts of forward proxies,
+	// which are added to the HTTP listener's routes last.
+	forwardProxies []*envoy_route_v3.VirtualHost
 }
 
-func visitRoutes(root dag.Vertex) map[string]*envoy_route_v3.RouteConfiguration {
+func visitRoutes(root dag.Vertex, ordering config.RouteOrderingType, redirect httpsRedirect, sourceMetadata bool) map[string]*envoy_route_v3.RouteConfiguration {
 	// Collect the route configurations for all the routes we can
 	// find. For HTTP hosts, the routes will all be collected on the
 	// well-known ENVOY_HTTP_LISTENER, but for HTTPS hosts, we will
@@ -107,9 +288,13 @@ func visitRoutes(root dag.Vertex) map[string]*envoy_route_v3.RouteConfiguration
 		routes: map[string]*envoy_route_v3.RouteConfiguration{
 			ENVOY_HTTP_LISTENER: envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER),
 		},
+		ordering:       ordering,
+		redirect:       redirect,
+		sourceMetadata: sourceMetadata,
 	}
 
 	rv.visit(root)
+	addForwardProxies(rv.routes[ENVOY_HTTP_LISTENER], rv.forwardProxies)
 
 	for _, v := range rv.routes {
 		sort.Stable(sorter.For(v.VirtualHosts))
@@ -118,6 +303,72 @@ func visitRoutes(root dag.Vertex) map[string]*envoy_route_v3.RouteConfiguration
 	return rv.routes
 }
 
+// consolidateSecureRoutes moves the virtual host of each secure virtual
+// host that shares a filter chain from its own route configuration into
+// the route configuration of its group. See consolidatedRouteConfigName.
+func consolidateSecureRoutes(root dag.Vertex, routes map[string]*envoy_route_v3.RouteConfiguration) {
+	groups := map[string]bool{}
+
+	var visit func(dag.Vertex)
+	visit = func(vertex dag.Vertex) {
+		switch vh := vertex.(type) {
+		case *dag.VirtualHost:
+			// Insecure virtual hosts are not consolidated.
+		case *dag.SecureVirtualHost:
+			name := consolidatedRouteConfigName(vh)
+			if name == "" {
+				return
+			}
+
+			own := path.Join("https", vh.VirtualHost.Name)
+			rc, ok := routes[own]
+			if !ok {
+				return
+			}
+			delete(routes, own)
+
+			if _, ok := routes[name]; !ok {
+				routes[name] = envoy_v3.RouteConfiguration(name)
+			}
+			routes[name].VirtualHosts = append(routes[name].VirtualHosts, rc.VirtualHosts...)
+			groups[name] = true
+		default:
+			vertex.Visit(visit)
+		}
+	}
+	visit(root)
+
+	for name := range groups {
+		sort.Stable(sorter.For(routes[name].VirtualHosts))
+	}
+}
+
+// localReplyRoute returns a route that Envoy answers itself,
+// with either the route's direct response or its redirect.
+func localReplyRoute(route *dag.Route) *envoy_route_v3.Route {
+	rt := &envoy_route_v3.Route{
+		Match: envoy_v3.RouteMatch(route),
+	}
+	if route.DirectResponse != nil {
+		rt.Action = envoy_v3.RouteDirectResponse(route.DirectResponse)
+	} else {
+		rt.Action = envoy_v3.RouteRedirect(route.Redirect)
+	}
+	if route.ResponseHeadersPolicy != nil {
+		rt.ResponseHeadersToAdd = envoy_v3.HeaderValueList(route.ResponseHeadersPolicy.Set, false)
+		rt.ResponseHeadersToRemove = route.ResponseHeadersPolicy.Remove
+	}
+	return rt
+}
+
+// routeMetadata returns the metadata of a route of the named virtual host.
+func (v *routeVisitor) routeMetadata(route *dag.Route, vhost string) *envoy_core_v3.Metadata {
+	if v.sourceMetadata {
+		return envoy_v3.RouteSourceMetadata(route, vhost)
+	}
+	return envoy_v3.RouteMetadata(route)
+}
+
 func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost) {
 	var routes []*dag.Route
 
@@ -140,20 +391,18 @@ func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost) {
 			// envoy.RouteRoute.
 			return &envoy_route_v3.Route{
 				Match:  envoy_v3.RouteMatch(route),
-				Action: envoy_v3.UpgradeHTTPS(),
+				Action: envoy_v3.RedirectHTTPS(v.redirect.scheme, v.redirect.port),
 			}
 		}
 
-		if route.DirectResponse != nil {
-			return &envoy_route_v3.Route{
-				Match:  envoy_v3.RouteMatch(route),
-				Action: envoy_v3.RouteDirectResponse(route.DirectResponse),
-			}
+		if route.DirectResponse != nil || route.Redirect != nil {
+			return localReplyRoute(route)
 		}
 
 		rt := &envoy_route_v3.Route{
-			Match:  envoy_v3.RouteMatch(route),
-			Action: envoy_v3.RouteRoute(route),
+			Match:    envoy_v3.RouteMatch(route),
+			Action:   envoy_v3.RouteRoute(route),
+			Metadata: v.routeMetadata(route, vh.Name),
 		}
 		if route.RequestHeadersPolicy != nil {
 			rt.RequestHeadersToAdd = append(envoy_v3.HeaderValueList(route.RequestHeadersPolicy.Set, false), envoy_v3.HeaderValueList(route.RequestHeadersPolicy.Add, true)...)
@@ -169,12 +418,51 @@ func (v *routeVisitor) onVirtualHost(vh *dag.VirtualHost) {
 			}
 			rt.TypedPerFilterConfig["envoy.filters.http.local_ratelimit"] = envoy_v3.LocalRateLimitConfig(route.RateLimitPolicy.Local, "vhost."+vh.Name)
 		}
+		if route.RBACPolicy != nil {
+			if rt.TypedPerFilterConfig == nil {
+				rt.TypedPerFilterConfig = map[string]*any.Any{}
+			}
+			rt.TypedPerFilterConfig["envoy.filters.http.rbac"] = envoy_v3.RouteRBAC(route.RBACPolicy)
+		}
 		return rt
 
 	}
 
-	sortRoutes(routes)
-	v.routes[ENVOY_HTTP_LISTENER].VirtualHosts = append(v.routes[ENVOY_HTTP_LISTENER].VirtualHosts, toEnvoyVirtualHost(vh, routes, toEnvoyRoute))
+	if v.redirect.trustForwardedProto {
+		routes = forwardedProtoRoutes(routes)
+	}
+
+	sortRoutes(routes, v.ordering)
+	evh := toEnvoyVirtualHost(vh, routes, toEnvoyRoute)
+	if vh.ForwardProxy != nil {
+		evh.Domains = vh.ForwardProxy.AllowedDomains
+		v.forwardProxies = append(v.forwardProxies, evh)
+		return
+	}
+	v.routes[ENVOY_HTTP_LISTENER].VirtualHosts = append(v.routes[ENVOY_HTTP_LISTENER].VirtualHosts, evh)
+}
+
+// forwardedProtoRoutes adds a copy of each route that requires TLS
+// which is routed rather than redirected, for the requests that have
+// X-Forwarded-Proto: https set by a load balancer that terminated TLS.
+// The copy has one more header match condition than the route, so it
+// sorts before it.
+func forwardedProtoRoutes(routes []*dag.Route) []*dag.Route {
+	var result []*dag.Route
+	for _, route := range routes {
+		if route.HTTPSUpgrade {
+			forwarded := *route
+			forwarded.HTTPSUpgrade = false
+			forwarded.HeaderMatchConditions = append([]dag.HeaderMatchCondition{{
+				Name:      "x-forwarded-proto",
+				Value:     "https",
+				MatchType: dag.HeaderMatchTypeExact,
+			}}, route.HeaderMatchConditions...)
+			result = append(result, &forwarded)
+		}
+		result = append(result, route)
+	}
+	return result
 }
 
 func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
@@ -193,16 +481,14 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 	}
 
 	toEnvoyRoute := func(route *dag.Route) *envoy_route_v3.Route {
-		if route.DirectResponse != nil {
-			return &envoy_route_v3.Route{
-				Match:  envoy_v3.RouteMatch(route),
-				Action: envoy_v3.RouteDirectResponse(route.DirectResponse),
-			}
+		if route.DirectResponse != nil || route.Redirect != nil {
+			return localReplyRoute(route)
 		}
 
 		rt := &envoy_route_v3.Route{
-			Match:  envoy_v3.RouteMatch(route),
-			Action: envoy_v3.RouteRoute(route),
+			Match:    envoy_v3.RouteMatch(route),
+			Action:   envoy_v3.RouteRoute(route),
+			Metadata: v.routeMetadata(route, svh.VirtualHost.Name),
 		}
 
 		if route.RequestHeadersPolicy != nil {
@@ -219,6 +505,12 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 			}
 			rt.TypedPerFilterConfig["envoy.filters.http.local_ratelimit"] = envoy_v3.LocalRateLimitConfig(route.RateLimitPolicy.Local, "vhost."+svh.Name)
 		}
+		if route.RBACPolicy != nil {
+			if rt.TypedPerFilterConfig == nil {
+				rt.TypedPerFilterConfig = map[string]*any.Any{}
+			}
+			rt.TypedPerFilterConfig["envoy.filters.http.rbac"] = envoy_v3.RouteRBAC(route.RBACPolicy)
+		}
 
 		// If authorization is enabled on this host, we may need to set per-route filter overrides.
 		if svh.AuthorizationService != nil {
@@ -247,7 +539,7 @@ func (v *routeVisitor) onSecureVirtualHost(svh *dag.SecureVirtualHost) {
 		v.routes[name] = envoy_v3.RouteConfiguration(name)
 	}
 
-	sortRoutes(routes)
+	sortRoutes(routes, v.ordering)
 	v.routes[name].VirtualHosts = append(v.routes[name].VirtualHosts, toEnvoyVirtualHost(&svh.VirtualHost, routes, toEnvoyRoute))
 
 	// A fallback route configuration contains routes for all the vhosts that have the fallback certificate enabled.
@@ -283,10 +575,9 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 	}
 }
 
-// sortRoutes sorts the given Route slice in place. Routes are ordered
-// first by path match type, path match value via string comparison and
-// then by the length of the HeaderMatch slice (if any). The HeaderMatch
-// slice is also ordered by the matching header name.
+// sortRoutes sorts the given Route slice in place with the given
+// ordering. The HeaderMatch slice of each route is also ordered by
+// the matching header name. See config.RouteOrderingType.
 // We sort dag.Route objects before converting to Envoy types to ensure
 // more accurate ordering of route matches. Contour route match types may
 // be implemented by Envoy route match types that change over time, or by
@@ -295,12 +586,12 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 // Contour types instead ensures we can sort from most to least specific
 // route match regardless of the underlying Envoy type that is used to
 // implement the match.
-func sortRoutes(routes []*dag.Route) {
+func sortRoutes(routes []*dag.Route, ordering config.RouteOrderingType) {
 	for _, r := range routes {
 		sort.Stable(sorter.For(r.HeaderMatchConditions))
 	}
 
-	sort.Stable(sorter.For(routes))
+	sort.Stable(sorter.ForRoutes(routes, ordering))
 }
 
 // toEnvoyVirtualHost converts a DAG virtual host and routes to an Envoy virtual host.
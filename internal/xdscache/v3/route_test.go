@@ -27,6 +27,7 @@ import (
 	"github.com/projectcontour/contour/internal/dag"
 	envoy_v3 "github.com/projectcontour/contour/internal/envoy/v3"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"github.com/projectcontour/contour/pkg/config"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
@@ -135,6 +136,162 @@ func TestRouteCacheQuery(t *testing.T) {
 	}
 }
 
+func TestRouteCacheGlobalHeaders(t *testing.T) {
+	rc := RouteCache{
+		RequestHeadersPolicy: &dag.HeadersPolicy{
+			Set:    map[string]string{"X-Envoy-Hostname": "%HOSTNAME%"},
+			Remove: []string{"X-Internal"},
+		},
+		ResponseHeadersPolicy: &dag.HeadersPolicy{
+			Set:    map[string]string{"X-Frame-Options": "DENY"},
+			Remove: []string{"Server"},
+		},
+	}
+	rc.OnChange(&dag.DAG{})
+
+	want := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER)
+	want.RequestHeadersToAdd = append(want.RequestHeadersToAdd, &envoy_core_v3.HeaderValueOption{
+		Header: &envoy_core_v3.HeaderValue{
+			Key:   "X-Envoy-Hostname",
+			Value: "%HOSTNAME%",
+		},
+		Append: protobuf.Bool(false),
+	})
+	want.RequestHeadersToRemove = []string{"X-Internal"}
+	want.ResponseHeadersToAdd = []*envoy_core_v3.HeaderValueOption{{
+		Header: &envoy_core_v3.HeaderValue{
+			Key:   "X-Frame-Options",
+			Value: "DENY",
+		},
+		Append: protobuf.Bool(false),
+	}}
+	want.ResponseHeadersToRemove = []string{"Server"}
+
+	protobuf.ExpectEqual(t, []proto.Message{want}, rc.Contents())
+}
+
+func TestRouteCacheCorrelationHeader(t *testing.T) {
+	rc := RouteCache{
+		CorrelationHeader: "X-Correlation-Id",
+	}
+	rc.OnChange(&dag.DAG{})
+
+	want := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER)
+	want.RequestHeadersToAdd = append(want.RequestHeadersToAdd, &envoy_core_v3.HeaderValueOption{
+		Header: &envoy_core_v3.HeaderValue{
+			Key:   "X-Correlation-Id",
+			Value: "%REQ(X-REQUEST-ID)%",
+		},
+		Append: protobuf.Bool(false),
+	})
+
+	protobuf.ExpectEqual(t, []proto.Message{want}, rc.Contents())
+}
+
+func TestAddVirtualHostStats(t *testing.T) {
+	rc := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+		envoy_v3.VirtualHost("www.example.com"),
+		envoy_v3.VirtualHost("api.example.com"),
+	)
+	addVirtualHostStats(rc)
+
+	want := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+		&envoy_route_v3.VirtualHost{
+			Name:    "www.example.com",
+			Domains: []string{"www.example.com"},
+			VirtualClusters: []*envoy_route_v3.VirtualCluster{{
+				Name: "all",
+				Headers: []*envoy_route_v3.HeaderMatcher{{
+					Name: ":path",
+					HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_PresentMatch{
+						PresentMatch: true,
+					},
+				}},
+			}},
+		},
+		&envoy_route_v3.VirtualHost{
+			Name:            "api.example.com",
+			Domains:         []string{"api.example.com"},
+			VirtualClusters: []*envoy_route_v3.VirtualCluster{envoy_v3.VirtualHostStatsCluster()},
+		},
+	)
+
+	protobuf.ExpectEqual(t, want, rc)
+}
+
+func TestAddHealthVirtualHost(t *testing.T) {
+	rc := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+		envoy_v3.VirtualHost("www.example.com"),
+		envoy_v3.VirtualHost("lb-health.example.internal", &envoy_route_v3.Route{
+			Match:  envoy_v3.RouteMatch(&dag.Route{PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"}}),
+			Action: envoy_v3.UpgradeHTTPS(),
+		}),
+		envoy_v3.VirtualHost("api.example.com"),
+		&envoy_route_v3.VirtualHost{
+			Name:    "egress.example.com",
+			Domains: []string{"*.github.com", "lb-health.example.internal"},
+		},
+	)
+	addHealthVirtualHost(rc, "lb-health.example.internal")
+
+	want := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+		envoy_v3.VirtualHost("api.example.com"),
+		&envoy_route_v3.VirtualHost{
+			Name:    "egress.example.com",
+			Domains: []string{"*.github.com"},
+		},
+		&envoy_route_v3.VirtualHost{
+			Name:    "lb-health.example.internal",
+			Domains: []string{"lb-health.example.internal"},
+			Routes: []*envoy_route_v3.Route{{
+				Match: &envoy_route_v3.RouteMatch{
+					PathSpecifier: &envoy_route_v3.RouteMatch_Prefix{
+						Prefix: "/",
+					},
+				},
+				Action: &envoy_route_v3.Route_DirectResponse{
+					DirectResponse: &envoy_route_v3.DirectResponseAction{
+						Status: 200,
+					},
+				},
+			}},
+		},
+		envoy_v3.VirtualHost("www.example.com"),
+	)
+
+	protobuf.ExpectEqual(t, want, rc)
+}
+
+func TestAddForwardProxies(t *testing.T) {
+	rc := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+		envoy_v3.VirtualHost("api.example.com"),
+	)
+	addForwardProxies(rc, []*envoy_route_v3.VirtualHost{{
+		Name:    "egress-b.example.com",
+		Domains: []string{"*.github.com", "api.example.com", "www.example.com"},
+	}, {
+		Name:    "egress-a.example.com",
+		Domains: []string{"api.example.com", "www.example.com"},
+	}, {
+		Name:    "egress-c.example.com",
+		Domains: []string{"api.example.com"},
+	}})
+
+	want := envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+		envoy_v3.VirtualHost("api.example.com"),
+		&envoy_route_v3.VirtualHost{
+			Name:    "egress-a.example.com",
+			Domains: []string{"www.example.com"},
+		},
+		&envoy_route_v3.VirtualHost{
+			Name:    "egress-b.example.com",
+			Domains: []string{"*.github.com"},
+		},
+	)
+
+	protobuf.ExpectEqual(t, want, rc)
+}
+
 func TestRouteVisit(t *testing.T) {
 	tests := map[string]struct {
 		objs                []interface{}
@@ -272,6 +429,95 @@ func TestRouteVisit(t *testing.T) {
 				),
 			),
 		},
+		"httpproxy forward proxy": {
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "egress",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn: "egress.example.com",
+							ForwardProxy: &contour_api_v1.ForwardProxyPolicy{
+								AllowedDomains: []string{"api.example.com", "*.github.com"},
+							},
+						},
+					},
+				},
+			},
+			want: routeConfigurations(
+				envoy_v3.RouteConfiguration("ingress_http",
+					&envoy_route_v3.VirtualHost{
+						Name:    "egress.example.com",
+						Domains: []string{"*.github.com", "api.example.com"},
+						Routes: []*envoy_route_v3.Route{{
+							Match:  routePrefix("/"),
+							Action: routecluster("dynamic_forward_proxy"),
+						}},
+					},
+				),
+			),
+		},
+		"httpproxy in maintenance": {
+			objs: []interface{}{
+				&contour_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: contour_api_v1.HTTPProxySpec{
+						VirtualHost: &contour_api_v1.VirtualHost{
+							Fqdn:        "www.example.com",
+							Maintenance: true,
+							MaintenancePolicy: &contour_api_v1.MaintenancePolicy{
+								RetryAfter: "1h",
+								Body:       "down for maintenance",
+							},
+						},
+						Routes: []contour_api_v1.Route{{
+							Services: []contour_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: routeConfigurations(
+				envoy_v3.RouteConfiguration("ingress_http",
+					envoy_v3.VirtualHost("www.example.com",
+						&envoy_route_v3.Route{
+							Match: routePrefix("/"),
+							Action: envoy_v3.RouteDirectResponse(&dag.DirectResponse{
+								StatusCode: 503,
+								Body:       "down for maintenance",
+							}),
+							ResponseHeadersToAdd: []*envoy_core_v3.HeaderValueOption{{
+								Header: &envoy_core_v3.HeaderValue{
+									Key:   "Retry-After",
+									Value: "3600",
+								},
+								Append: protobuf.Bool(false),
+							}},
+						},
+					),
+				),
+			),
+		},
 		"default backend ingress with secret": {
 			objs: []interface{}{
 				&networking_v1.Ingress{
@@ -2929,12 +3175,174 @@ func TestRouteVisit(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			root := buildDAGFallback(t, tc.fallbackCertificate, tc.objs...)
-			got := visitRoutes(root)
+			got := visitRoutes(root, config.DefaultRouteOrdering, httpsRedirect{}, false)
 			protobuf.ExpectEqual(t, tc.want, got)
 		})
 	}
 }
 
+func TestConsolidateSecureRoutes(t *testing.T) {
+	proxy := func(name, secret string) *contour_api_v1.HTTPProxy {
+		return &contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: name + ".example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName: secret,
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "backend",
+						Port: 80,
+					}},
+				}},
+			},
+		}
+	}
+	secret := func(name string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Type: "kubernetes.io/tls",
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		}
+	}
+
+	own := proxy("c", "secret")
+	own.Spec.VirtualHost.ServerHeader = &contour_api_v1.ServerHeaderPolicy{Name: "c"}
+
+	root := buildDAGFallback(t, nil,
+		proxy("b", "secret"),
+		proxy("a", "secret"),
+		own,
+		proxy("d", "other"),
+		secret("secret"),
+		secret("other"),
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backend",
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     80,
+				}},
+			},
+		},
+	)
+
+	routes := visitRoutes(root, config.DefaultRouteOrdering, httpsRedirect{}, false)
+	consolidateSecureRoutes(root, routes)
+
+	got := map[string][]string{}
+	for name, rc := range routes {
+		for _, vh := range rc.VirtualHosts {
+			got[name] = append(got[name], vh.Name)
+		}
+	}
+
+	assert.Equal(t, map[string][]string{
+		ENVOY_HTTP_LISTENER:                      {"a.example.com", "b.example.com", "c.example.com", "d.example.com"},
+		"https/ingress_https/default/secret/1.2": {"a.example.com", "b.example.com"},
+		"https/c.example.com":                    {"c.example.com"},
+		"https/ingress_https/default/other/1.2":  {"d.example.com"},
+	}, got)
+}
+
+func TestRouteVisitHTTPSRedirect(t *testing.T) {
+	root := buildDAGFallback(t, nil,
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "simple",
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "www.example.com",
+					TLS: &contour_api_v1.TLS{
+						SecretName: "secret",
+					},
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "backend",
+						Port: 80,
+					}},
+				}},
+			},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Type: "kubernetes.io/tls",
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backend",
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     80,
+				}},
+			},
+		},
+	)
+
+	redirect := &envoy_route_v3.Route_Redirect{
+		Redirect: &envoy_route_v3.RedirectAction{
+			SchemeRewriteSpecifier: &envoy_route_v3.RedirectAction_HttpsRedirect{
+				HttpsRedirect: true,
+			},
+			PortRedirect: 8443,
+		},
+	}
+
+	routes := visitRoutes(root, config.DefaultRouteOrdering, httpsRedirect{port: 8443}, false)
+	protobuf.ExpectEqual(t,
+		envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+			envoy_v3.VirtualHost("www.example.com",
+				&envoy_route_v3.Route{
+					Match:  routePrefix("/"),
+					Action: redirect,
+				},
+			),
+		), routes[ENVOY_HTTP_LISTENER])
+
+	routes = visitRoutes(root, config.DefaultRouteOrdering, httpsRedirect{port: 8443, trustForwardedProto: true}, false)
+	protobuf.ExpectEqual(t,
+		envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+			envoy_v3.VirtualHost("www.example.com",
+				&envoy_route_v3.Route{
+					Match: routePrefix("/", dag.HeaderMatchCondition{
+						Name:      "x-forwarded-proto",
+						Value:     "https",
+						MatchType: dag.HeaderMatchTypeExact,
+					}),
+					Action: routecluster("default/backend/80/da39a3ee5e"),
+				},
+				&envoy_route_v3.Route{
+					Match:  routePrefix("/"),
+					Action: redirect,
+				},
+			),
+		), routes[ENVOY_HTTP_LISTENER])
+}
+
 func TestSortLongestRouteFirst(t *testing.T) {
 	tests := map[string]struct {
 		routes []*dag.Route
@@ -3123,7 +3531,7 @@ func TestSortLongestRouteFirst(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			got := append([]*dag.Route{}, tc.routes...) // shallow copy
-			sortRoutes(got)
+			sortRoutes(got, config.DefaultRouteOrdering)
 			assert.Equal(t, tc.want, got)
 		})
 	}
@@ -3231,3 +3639,52 @@ func withMirrorPolicy(route *envoy_route_v3.Route_Route, mirror string) *envoy_r
 	}}
 	return route
 }
+
+func TestRouteVisitSourceMetadata(t *testing.T) {
+	root := buildDAGFallback(t, nil,
+		&contour_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "simple",
+				Namespace: "default",
+			},
+			Spec: contour_api_v1.HTTPProxySpec{
+				VirtualHost: &contour_api_v1.VirtualHost{
+					Fqdn: "www.example.com",
+				},
+				Routes: []contour_api_v1.Route{{
+					Services: []contour_api_v1.Service{{
+						Name: "backend",
+						Port: 80,
+					}},
+				}},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backend",
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     80,
+				}},
+			},
+		},
+	)
+
+	routes := visitRoutes(root, config.DefaultRouteOrdering, httpsRedirect{}, true)
+	protobuf.ExpectEqual(t,
+		envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER,
+			envoy_v3.VirtualHost("www.example.com",
+				&envoy_route_v3.Route{
+					Match:  routePrefix("/"),
+					Action: routecluster("default/backend/80/da39a3ee5e"),
+					Metadata: envoy_v3.RouteSourceMetadata(&dag.Route{
+						Source: dag.ObjectReference{Kind: "HTTPProxy", Namespace: "default", Name: "simple"},
+					}, "www.example.com"),
+				},
+			),
+		), routes[ENVOY_HTTP_LISTENER])
+}
@@ -15,7 +15,7 @@ package v3
 
 import (
 	"sort"
-	"sync"
+	"sync/atomic"
 
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
@@ -29,27 +29,31 @@ import (
 )
 
 // SecretCache manages the contents of the gRPC SDS cache.
+// Its contents are replaced, never modified, so that reads
+// do not wait for updates.
 type SecretCache struct {
-	mu     sync.Mutex
-	values map[string]*envoy_tls_v3.Secret
+	values atomic.Value // map[string]*envoy_tls_v3.Secret
 	contour.Cond
 }
 
-// Update replaces the contents of the cache with the supplied map.
-func (c *SecretCache) Update(v map[string]*envoy_tls_v3.Secret) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// load returns the current contents of the cache, which
+// must not be modified.
+func (c *SecretCache) load() map[string]*envoy_tls_v3.Secret {
+	values, _ := c.values.Load().(map[string]*envoy_tls_v3.Secret)
+	return values
+}
 
-	c.values = v
+// Update replaces the contents of the cache with the supplied map,
+// which must not be modified afterwards.
+func (c *SecretCache) Update(v map[string]*envoy_tls_v3.Secret) {
+	c.values.Store(v)
 	c.Cond.Notify()
 }
 
 // Contents returns a copy of the cache's contents.
 func (c *SecretCache) Contents() []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
 	var values []*envoy_tls_v3.Secret
-	for _, v := range c.values {
+	for _, v := range c.load() {
 		values = append(values, v)
 	}
 	sort.Stable(sorter.For(values))
@@ -57,14 +61,13 @@ func (c *SecretCache) Contents() []proto.Message {
 }
 
 func (c *SecretCache) Query(names []string) []proto.Message {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	current := c.load()
 	var values []*envoy_tls_v3.Secret
 	for _, n := range names {
 		// we can only return secrets where their value is
 		// known. if the secret is not registered in the cache
 		// we return nothing.
-		if v, ok := c.values[n]; ok {
+		if v, ok := current[n]; ok {
 			values = append(values, v)
 		}
 	}
@@ -109,6 +112,11 @@ func (v *secretVisitor) visit(vertex dag.Vertex) {
 		if obj.FallbackCertificate != nil {
 			v.addSecret(obj.FallbackCertificate)
 		}
+		if obj.OIDCPolicy != nil {
+			for _, s := range envoy_v3.OAuth2Secrets(obj.OIDCPolicy) {
+				v.secrets[s.Name] = s
+			}
+		}
 	case *dag.Cluster:
 		if obj.ClientCertificate != nil {
 			v.addSecret(obj.ClientCertificate)
@@ -205,8 +205,8 @@ func TestGRPC(t *testing.T) {
 				FieldLogger: log,
 			}
 
-			srv := xds.NewServer(nil)
-			contour_xds_v3.RegisterServer(contour_xds_v3.NewContourServer(log, xdscache.ResourcesOf(resources)...), srv)
+			srv := xds.NewServer(nil, nil, nil)
+			contour_xds_v3.RegisterServer(contour_xds_v3.NewContourServer(log, nil, xdscache.ResourcesOf(resources)...), srv)
 			l, err := net.Listen("tcp", "127.0.0.1:0")
 			require.NoError(t, err)
 			done := make(chan error, 1)
@@ -38,6 +38,22 @@ var DefaultFields = AccessLogFields([]string{
 	"x_forwarded_for",
 })
 
+// DefaultTCPProxyFields are fields that will be included when JSON logging
+// is enabled for TCP proxied connections.
+var DefaultTCPProxyFields = AccessLogFields([]string{
+	"@timestamp",
+	"bytes_received",
+	"bytes_sent",
+	"downstream_local_address",
+	"downstream_remote_address",
+	"duration",
+	"requested_server_name",
+	"response_flags",
+	"upstream_cluster",
+	"upstream_host",
+	"upstream_local_address",
+})
+
 // DEFAULT_ACCESS_LOG_TYPE is the default access log format.
 const DEFAULT_ACCESS_LOG_TYPE = EnvoyAccessLog
 
@@ -108,6 +124,7 @@ var envoySimpleOperators = map[string]struct{}{
 // envoyComplexOperators is the list of known Envoy log template keywords that require
 // arguments.
 var envoyComplexOperators = map[string]struct{}{
+	"METADATA":   {},
 	"REQ":        {},
 	"RESP":       {},
 	"START_TIME": {},
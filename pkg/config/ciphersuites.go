@@ -16,6 +16,8 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/projectcontour/contour/internal/build"
 )
 
 // TLSCiphers holds a list of TLS ciphers
@@ -45,6 +47,16 @@ var DefaultTLSCiphers = TLSCiphers([]string{
 	//"AES256-SHA",
 })
 
+// DefaultFIPSTLSCiphers contains the list of default ciphers used by
+// Contour when it is running in FIPS mode. It omits the CHACHA20-POLY1305
+// ciphers from DefaultTLSCiphers, as they are not FIPS approved.
+var DefaultFIPSTLSCiphers = TLSCiphers([]string{
+	"ECDHE-ECDSA-AES128-GCM-SHA256",
+	"ECDHE-RSA-AES128-GCM-SHA256",
+	"ECDHE-ECDSA-AES256-GCM-SHA384",
+	"ECDHE-RSA-AES256-GCM-SHA384",
+})
+
 // validTLSCiphers contains the list of TLS ciphers that Envoy supports
 // See: https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/transport_sockets/tls/v3/common.proto#extensions-transport-sockets-tls-v3-tlsparameters
 // Note: This list is a superset of what is valid for stock Envoy builds and those using BoringSSL FIPS.
@@ -65,11 +77,37 @@ var validTLSCiphers = map[string]struct{}{
 	"AES256-SHA":                                                    {},
 }
 
+// fipsTLSCiphers contains the subset of validTLSCiphers that are FIPS
+// approved and supported by Envoy builds using BoringSSL FIPS.
+// See: https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/transport_sockets/tls/v3/common.proto#envoy-v3-api-field-extensions-transport-sockets-tls-v3-tlsparameters-cipher-suites
+var fipsTLSCiphers = map[string]struct{}{
+	"ECDHE-ECDSA-AES128-GCM-SHA256": {},
+	"ECDHE-RSA-AES128-GCM-SHA256":   {},
+	"ECDHE-ECDSA-AES128-SHA":        {},
+	"ECDHE-RSA-AES128-SHA":          {},
+	"AES128-GCM-SHA256":             {},
+	"AES128-SHA":                    {},
+	"ECDHE-ECDSA-AES256-GCM-SHA384": {},
+	"ECDHE-RSA-AES256-GCM-SHA384":   {},
+	"ECDHE-ECDSA-AES256-SHA":        {},
+	"ECDHE-RSA-AES256-SHA":          {},
+	"AES256-GCM-SHA384":             {},
+	"AES256-SHA":                    {},
+}
+
+// fipsEnabled reports whether Contour is running in FIPS mode, in which
+// case only FIPS approved ciphers are accepted.
+var fipsEnabled = build.FIPSEnabled
+
 // SanitizeCipherSuites trims a list of ciphers to remove whitespace and
-// duplicates, returning the passed in default if the corrected list is empty.
-// The ciphers argument should be a list of valid ciphers.
+// duplicates, returning the default ciphers if the corrected list is empty.
+// The default is DefaultFIPSTLSCiphers in FIPS mode and DefaultTLSCiphers
+// otherwise. The ciphers argument should be a list of valid ciphers.
 func SanitizeCipherSuites(ciphers []string) []string {
 	if len(ciphers) == 0 {
+		if fipsEnabled() {
+			return DefaultFIPSTLSCiphers
+		}
 		return DefaultTLSCiphers
 	}
 
@@ -85,17 +123,27 @@ func SanitizeCipherSuites(ciphers []string) []string {
 	return validatedCiphers
 }
 
-// Validate ciphers. Returns error on unsupported cipher.
+// Validate ciphers. Returns error on unsupported cipher, or on a cipher
+// that is not FIPS approved when running in FIPS mode.
 func (tlsCiphers TLSCiphers) Validate() error {
 	invalidCiphers := []string{}
+	unapprovedCiphers := []string{}
+	fips := fipsEnabled()
 	for _, cipher := range tlsCiphers {
 		trimmed := strings.TrimSpace(cipher)
 		if _, ok := validTLSCiphers[trimmed]; !ok {
 			invalidCiphers = append(invalidCiphers, trimmed)
+			continue
+		}
+		if _, ok := fipsTLSCiphers[trimmed]; fips && !ok {
+			unapprovedCiphers = append(unapprovedCiphers, trimmed)
 		}
 	}
 	if len(invalidCiphers) > 0 {
 		return fmt.Errorf("invalid ciphers: %s", strings.Join(invalidCiphers, ","))
 	}
+	if len(unapprovedCiphers) > 0 {
+		return fmt.Errorf("ciphers not FIPS approved: %s", strings.Join(unapprovedCiphers, ","))
+	}
 	return nil
 }
@@ -17,6 +17,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -98,17 +100,116 @@ type ClusterDNSFamilyType string
 
 func (c ClusterDNSFamilyType) Validate() error {
 	switch c {
-	case AutoClusterDNSFamily, IPv4ClusterDNSFamily, IPv6ClusterDNSFamily:
+	case AutoClusterDNSFamily, IPv4ClusterDNSFamily, IPv6ClusterDNSFamily, AllClusterDNSFamily:
 		return nil
 	default:
 		return fmt.Errorf("invalid cluster DNS lookup family %q", c)
 	}
 }
 
+// ClusterNamingType is the naming strategy of Envoy clusters.
+type ClusterNamingType string
+
+func (c ClusterNamingType) Validate() error {
+	switch c {
+	case "", HashedClusterNaming, FullClusterNaming:
+		return nil
+	default:
+		return fmt.Errorf("invalid cluster naming strategy %q", c)
+	}
+}
+
+// HashedClusterNaming truncates cluster names to 60 characters and
+// always appends a hash of the cluster's properties.
+const HashedClusterNaming ClusterNamingType = "hashed"
+
+// FullClusterNaming uses the full namespace, name and port of the
+// service, only appending a hash of the cluster's properties if
+// they differ from the defaults.
+const FullClusterNaming ClusterNamingType = "full"
+
+// LoadBalancerStrategyType is the default load balancing strategy
+// of Envoy clusters.
+type LoadBalancerStrategyType string
+
+func (l LoadBalancerStrategyType) Validate() error {
+	switch l {
+	case "", RoundRobinLoadBalancerStrategy, WeightedLeastRequestLoadBalancerStrategy, RandomLoadBalancerStrategy:
+		return nil
+	default:
+		return fmt.Errorf("invalid load balancer strategy %q", l)
+	}
+}
+
+const RoundRobinLoadBalancerStrategy LoadBalancerStrategyType = "RoundRobin"
+const WeightedLeastRequestLoadBalancerStrategy LoadBalancerStrategyType = "WeightedLeastRequest"
+const RandomLoadBalancerStrategy LoadBalancerStrategyType = "Random"
+
+// RingHashFunctionType is the hash function Envoy uses to build
+// the hash ring of a cluster.
+type RingHashFunctionType string
+
+func (r RingHashFunctionType) Validate() error {
+	switch r {
+	case "", XXHashRingHashFunction, MurmurHash2RingHashFunction:
+		return nil
+	default:
+		return fmt.Errorf("invalid ring hash function %q", r)
+	}
+}
+
+const XXHashRingHashFunction RingHashFunctionType = "xx_hash"
+const MurmurHash2RingHashFunction RingHashFunctionType = "murmur_hash_2"
+
 const AutoClusterDNSFamily ClusterDNSFamilyType = "auto"
 const IPv4ClusterDNSFamily ClusterDNSFamilyType = "v4"
 const IPv6ClusterDNSFamily ClusterDNSFamilyType = "v6"
 
+// AllClusterDNSFamily resolves both IPv4 and IPv6 addresses. The
+// Envoy version that Contour supports has no such lookup family, so
+// AutoClusterDNSFamily is used in its place until it does.
+const AllClusterDNSFamily ClusterDNSFamilyType = "all"
+
+// RouteOrderingType is the order of the routes in a virtual host.
+type RouteOrderingType string
+
+func (r RouteOrderingType) Validate() error {
+	switch r {
+	case "", DefaultRouteOrdering, SpecificityRouteOrdering:
+		return nil
+	default:
+		return fmt.Errorf("invalid route ordering %q", r)
+	}
+}
+
+// DefaultRouteOrdering orders routes by path match type (exact, then
+// regex, then prefix), then by path match value in reverse textual
+// order, then by the number of header match conditions.
+const DefaultRouteOrdering RouteOrderingType = "default"
+
+// SpecificityRouteOrdering orders exact and prefix routes by the length
+// of the path match value, longest first, rather than textually. Routes
+// that otherwise match the same requests are ordered by the number of
+// header match conditions, then by the creation time of the object the
+// route came from, oldest first.
+const SpecificityRouteOrdering RouteOrderingType = "specificity"
+
+// IngressClassMatchingType selects whether Ingresses and HTTPProxies
+// that have no ingress class set are served.
+type IngressClassMatchingType string
+
+func (i IngressClassMatchingType) Validate() error {
+	switch i {
+	case "", StrictIngressClassMatching, PermissiveIngressClassMatching:
+		return nil
+	default:
+		return fmt.Errorf("invalid ingress class matching %q", i)
+	}
+}
+
+const StrictIngressClassMatching IngressClassMatchingType = "strict"
+const PermissiveIngressClassMatching IngressClassMatchingType = "permissive"
+
 // AccessLogType is the name of a supported access logging mechanism.
 type AccessLogType string
 
@@ -165,7 +266,7 @@ func (a AccessLogFields) Validate() error {
 				return fmt.Errorf("invalid JSON field: %s, invalid Envoy format: %s, invalid Envoy operator: %s", val, f, op)
 			}
 
-			if (op == "REQ" || op == "RESP" || op == "TRAILER") && f[3] == "" {
+			if (op == "REQ" || op == "RESP" || op == "TRAILER" || op == "METADATA") && f[3] == "" {
 				return fmt.Errorf("invalid JSON field: %s, invalid Envoy format: %s, arguments required for operator: %s", val, f, op)
 			}
 
@@ -208,6 +309,105 @@ func (a AccessLogFields) AsFieldMap() map[string]string {
 	return fieldMap
 }
 
+// AccessLogHeaders lists the request and response headers whose
+// values are captured in access logs.
+type AccessLogHeaders struct {
+	// Request lists the request headers to capture.
+	Request []string `yaml:"request,omitempty"`
+
+	// Response lists the response headers to capture.
+	Response []string `yaml:"response,omitempty"`
+}
+
+// headerNameRegexp matches valid HTTP header names, including
+// pseudo-headers such as ":authority".
+var headerNameRegexp = regexp.MustCompile("^:?[0-9A-Za-z!#$%&'*+.^_|~-]+$")
+
+// Validate ensures that the header names are valid.
+func (h AccessLogHeaders) Validate() error {
+	for _, name := range append(append([]string{}, h.Request...), h.Response...) {
+		if !headerNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid access log header name %q", name)
+		}
+	}
+	return nil
+}
+
+// Empty returns true if no headers are captured.
+func (h AccessLogHeaders) Empty() bool {
+	return len(h.Request) == 0 && len(h.Response) == 0
+}
+
+// AsFields returns the JSON access log fields that capture the
+// headers. Request headers are logged as "req_<name>" and response
+// headers as "resp_<name>", where name is the lower case header
+// name with dashes replaced by underscores.
+func (h AccessLogHeaders) AsFields() AccessLogFields {
+	var fields AccessLogFields
+	for _, name := range h.Request {
+		fields = append(fields, fmt.Sprintf("req_%s=%%REQ(%s)%%", headerFieldName(name), name))
+	}
+	for _, name := range h.Response {
+		fields = append(fields, fmt.Sprintf("resp_%s=%%RESP(%s)%%", headerFieldName(name), name))
+	}
+	return fields
+}
+
+func headerFieldName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, ":")), "-", "_")
+}
+
+// TCPProxyAccessLogType is the name of a supported TCP proxy access log sink.
+type TCPProxyAccessLogType string
+
+func (t TCPProxyAccessLogType) Validate() error {
+	switch t {
+	case "", FileTCPProxyAccessLog, GRPCTCPProxyAccessLog:
+		return nil
+	default:
+		return fmt.Errorf("invalid TCP proxy access log type %q", t)
+	}
+}
+
+const FileTCPProxyAccessLog TCPProxyAccessLogType = "file"
+const GRPCTCPProxyAccessLog TCPProxyAccessLogType = "grpc"
+
+// TCPProxyAccessLogParameters holds the access log configuration
+// for connections proxied by a HTTPProxy's TCPProxy.
+type TCPProxyAccessLogParameters struct {
+	// Type selects where TCP proxied connections are logged.
+	// Valid options are 'file' and 'grpc'. If unset, TCP proxied
+	// connections are logged to the HTTPS listener's access log.
+	Type TCPProxyAccessLogType `yaml:"type,omitempty"`
+
+	// Path is the file that TCP proxied connections are logged to
+	// when Type is 'file'. Defaults to /dev/stdout.
+	Path string `yaml:"path,omitempty"`
+
+	// ExtensionService identifies the extension service implementing
+	// the gRPC access log service when Type is 'grpc', formatted as
+	// <namespace>/<name>.
+	ExtensionService string `yaml:"extensionService,omitempty"`
+
+	// LogName identifies the log to the gRPC access log service.
+	// Defaults to 'contour-tcpproxy'.
+	LogName string `yaml:"logName,omitempty"`
+}
+
+// Validate the TCP proxy access log type, and that an extension
+// service is given for gRPC access logging.
+func (t TCPProxyAccessLogParameters) Validate() error {
+	if err := t.Type.Validate(); err != nil {
+		return err
+	}
+
+	if t.Type == GRPCTCPProxyAccessLog && len(strings.TrimSpace(t.ExtensionService)) == 0 {
+		return errors.New("invalid TCP proxy access log: extensionService must be defined for grpc access logging")
+	}
+
+	return nil
+}
+
 // HTTPVersionType is the name of a supported HTTP version.
 type HTTPVersionType string
 
@@ -223,6 +423,128 @@ func (h HTTPVersionType) Validate() error {
 const HTTPVersion1 HTTPVersionType = "http/1.1"
 const HTTPVersion2 HTTPVersionType = "http/2"
 
+// HeadersWithUnderscoresActionType is the action to take when a
+// request header name contains an underscore.
+type HeadersWithUnderscoresActionType string
+
+func (h HeadersWithUnderscoresActionType) Validate() error {
+	switch h {
+	case "", AllowHeadersWithUnderscores, RejectHeadersWithUnderscores, DropHeadersWithUnderscores:
+		return nil
+	default:
+		return fmt.Errorf("invalid headers with underscores action %q", h)
+	}
+}
+
+const AllowHeadersWithUnderscores HeadersWithUnderscoresActionType = "allow"
+const RejectHeadersWithUnderscores HeadersWithUnderscoresActionType = "reject"
+const DropHeadersWithUnderscores HeadersWithUnderscoresActionType = "drop"
+
+// EscapedSlashesActionType is the action to take when a request path
+// contains escaped slashes, "%2F" or "%5C".
+type EscapedSlashesActionType string
+
+func (e EscapedSlashesActionType) Validate() error {
+	switch e {
+	case "", KeepUnchangedEscapedSlashes, RejectEscapedSlashes, UnescapeAndRedirectEscapedSlashes, UnescapeAndForwardEscapedSlashes:
+		return nil
+	default:
+		return fmt.Errorf("invalid escaped slashes action %q", e)
+	}
+}
+
+const KeepUnchangedEscapedSlashes EscapedSlashesActionType = "keep-unchanged"
+const RejectEscapedSlashes EscapedSlashesActionType = "reject"
+const UnescapeAndRedirectEscapedSlashes EscapedSlashesActionType = "unescape-and-redirect"
+const UnescapeAndForwardEscapedSlashes EscapedSlashesActionType = "unescape-and-forward"
+
+// ConnectionBalancerType is how a listener balances the connections
+// it accepts between Envoy's worker threads.
+type ConnectionBalancerType string
+
+func (c ConnectionBalancerType) Validate() error {
+	switch c {
+	case "", ExactConnectionBalancer, NoConnectionBalancer:
+		return nil
+	default:
+		return fmt.Errorf("invalid connection balancer %q", c)
+	}
+}
+
+const ExactConnectionBalancer ConnectionBalancerType = "exact"
+const NoConnectionBalancer ConnectionBalancerType = "none"
+
+// ListenerDrainType is when a listener drains its connections.
+type ListenerDrainType string
+
+func (d ListenerDrainType) Validate() error {
+	switch d {
+	case "", DefaultListenerDrain, ModifyOnlyListenerDrain:
+		return nil
+	default:
+		return fmt.Errorf("invalid listener drain type %q", d)
+	}
+}
+
+const DefaultListenerDrain ListenerDrainType = "default"
+const ModifyOnlyListenerDrain ListenerDrainType = "modify-only"
+
+// ServerHeaderTransformationType is how Envoy handles the Server
+// header of the responses it forwards.
+type ServerHeaderTransformationType string
+
+func (s ServerHeaderTransformationType) Validate() error {
+	switch s {
+	case "", OverwriteServerHeader, AppendIfAbsentServerHeader, PassThroughServerHeader:
+		return nil
+	default:
+		return fmt.Errorf("invalid server header transformation %q", s)
+	}
+}
+
+const OverwriteServerHeader ServerHeaderTransformationType = "overwrite"
+const AppendIfAbsentServerHeader ServerHeaderTransformationType = "append-if-absent"
+const PassThroughServerHeader ServerHeaderTransformationType = "pass-through"
+
+// ServerHeaderParameters configures the Server header of the
+// responses that Envoy sends.
+type ServerHeaderParameters struct {
+	// Name is the value of the Server header that Envoy sets.
+	// If not set, Envoy's default of "envoy" is used.
+	Name string `yaml:"name,omitempty"`
+
+	// Transformation is how the Server header of upstream responses
+	// is handled. Valid options are 'overwrite', which replaces it
+	// with Name, 'append-if-absent', which only sets it to Name if
+	// the upstream did not set one, and 'pass-through', which never
+	// sets it. If not set, the header is overwritten.
+	Transformation ServerHeaderTransformationType `yaml:"transformation,omitempty"`
+}
+
+// Validate ensures that the server name can be sent as a header
+// value and that the transformation is valid.
+func (s ServerHeaderParameters) Validate() error {
+	for _, r := range s.Name {
+		if r < ' ' || r > '~' {
+			return fmt.Errorf("invalid server name %q: must only contain printable ASCII characters", s.Name)
+		}
+	}
+
+	return s.Transformation.Validate()
+}
+
+// Merge returns the parameters with the fields of override
+// that are set replacing those of s.
+func (s ServerHeaderParameters) Merge(override ServerHeaderParameters) ServerHeaderParameters {
+	if override.Name != "" {
+		s.Name = override.Name
+	}
+	if override.Transformation != "" {
+		s.Transformation = override.Transformation
+	}
+	return s
+}
+
 // NamespacedName defines the namespace/name of the Kubernetes resource referred from the configuration file.
 // Used for Contour configuration YAML file parsing, otherwise we could use K8s types.NamespacedName.
 type NamespacedName struct {
@@ -247,6 +569,35 @@ func (n NamespacedName) Validate() error {
 	return nil
 }
 
+// NamespacedServicePort defines the namespace/name and port of a
+// Kubernetes service.
+type NamespacedServicePort struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+	Port      int    `yaml:"port"`
+}
+
+// Validate that both name fields and a valid port are present, or none are.
+func (n NamespacedServicePort) Validate() error {
+	if len(strings.TrimSpace(n.Name)) == 0 && len(strings.TrimSpace(n.Namespace)) == 0 && n.Port == 0 {
+		return nil
+	}
+
+	if err := (NamespacedName{Name: n.Name, Namespace: n.Namespace}).Validate(); err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(n.Name)) == 0 {
+		return errors.New("name must be defined")
+	}
+
+	if n.Port < 1 || n.Port > 65535 {
+		return fmt.Errorf("invalid port %d", n.Port)
+	}
+
+	return nil
+}
+
 // TLSParameters holds configuration file TLS configuration details.
 type TLSParameters struct {
 	MinimumProtocolVersion string `yaml:"minimum-protocol-version"`
@@ -255,6 +606,12 @@ type TLSParameters struct {
 	// use as fallback when a non-SNI request is received.
 	FallbackCertificate NamespacedName `yaml:"fallback-certificate,omitempty"`
 
+	// FallbackPassthroughService defines the namespace/name and port of
+	// the Kubernetes service to which TLS connections that do not present
+	// an SNI server name are passed through, without being decrypted.
+	// It cannot be used together with FallbackCertificate.
+	FallbackPassthroughService NamespacedServicePort `yaml:"fallback-passthrough-service,omitempty"`
+
 	// ClientCertificate defines the namespace/name of the Kubernetes
 	// secret containing the client certificate and private key
 	// to be used when establishing TLS connection to upstream
@@ -269,13 +626,21 @@ type TLSParameters struct {
 	CipherSuites TLSCiphers `yaml:"cipher-suites,omitempty"`
 }
 
-// Validate TLS fallback certificate, client certificate, and cipher suites
+// Validate TLS fallback certificate, fallback passthrough service, client certificate, and cipher suites
 func (t TLSParameters) Validate() error {
 	// Check TLS secret names.
 	if err := t.FallbackCertificate.Validate(); err != nil {
 		return fmt.Errorf("invalid TLS fallback certificate: %w", err)
 	}
 
+	if err := t.FallbackPassthroughService.Validate(); err != nil {
+		return fmt.Errorf("invalid TLS fallback passthrough service: %w", err)
+	}
+
+	if len(strings.TrimSpace(t.FallbackCertificate.Name)) > 0 && len(strings.TrimSpace(t.FallbackPassthroughService.Name)) > 0 {
+		return errors.New("invalid TLS configuration: fallback certificate and fallback passthrough service cannot both be specified")
+	}
+
 	if err := t.ClientCertificate.Validate(); err != nil {
 		return fmt.Errorf("invalid TLS client certificate: %w", err)
 	}
@@ -292,6 +657,115 @@ type ServerParameters struct {
 	// Defines the XDSServer to use for `contour serve`.
 	// Defaults to "contour"
 	XDSServerType ServerType `yaml:"xds-server-type,omitempty"`
+
+	// MaxConcurrentStreams is the maximum number of concurrent
+	// gRPC streams an Envoy can open on a single connection.
+	// If not set, defaults to 1048576.
+	MaxConcurrentStreams uint32 `yaml:"max-concurrent-streams,omitempty"`
+
+	// MaxRecvMsgSize is the maximum size in bytes of a message
+	// the xDS server accepts from an Envoy. If not set, the
+	// gRPC default of 4MiB is used.
+	MaxRecvMsgSize int `yaml:"max-recv-msg-size,omitempty"`
+
+	// KeepaliveMinTime is the minimum interval an Envoy must wait
+	// between keepalive pings. Envoys that ping more often are
+	// disconnected. If not set, the gRPC default of 5m is used.
+	KeepaliveMinTime time.Duration `yaml:"keepalive-min-time,omitempty"`
+
+	// MaxStreamsPerSecond limits the rate at which Envoys can open
+	// new xDS streams across all connections. Streams over the limit
+	// are rejected and Envoy retries them with backoff. If not set,
+	// new streams are not rate limited.
+	MaxStreamsPerSecond int `yaml:"max-streams-per-second,omitempty"`
+
+	// MaxStreamsBurst is the number of streams that can be opened at
+	// once before MaxStreamsPerSecond applies. If not set, defaults
+	// to MaxStreamsPerSecond.
+	MaxStreamsBurst int `yaml:"max-streams-burst,omitempty"`
+
+	// NodeAuthorization restricts the Envoy nodes that can make
+	// requests to the xDS server.
+	NodeAuthorization NodeAuthorizationParameters `yaml:"node-authorization,omitempty"`
+}
+
+// NodeMatch matches an Envoy node ID or cluster name. Exactly one
+// of Exact or Prefix must be set.
+type NodeMatch struct {
+	// Exact matches the whole node ID or cluster name.
+	Exact string `yaml:"exact,omitempty"`
+
+	// Prefix matches the start of the node ID or cluster name.
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+// Validate ensures that exactly one of Exact or Prefix is set.
+func (n NodeMatch) Validate() error {
+	if (n.Exact == "") == (n.Prefix == "") {
+		return errors.New("exactly one of exact or prefix must be specified")
+	}
+	return nil
+}
+
+// NodeAuthorizationParameters holds the configuration for
+// authorizing the Envoy nodes that make xDS requests.
+type NodeAuthorizationParameters struct {
+	// AllowedNodes are the Envoy node IDs permitted to make xDS
+	// requests. If not set, any node ID is permitted.
+	AllowedNodes []NodeMatch `yaml:"allowed-nodes,omitempty"`
+
+	// AllowedClusters are the Envoy node cluster names permitted
+	// to make xDS requests. If not set, any cluster name is permitted.
+	AllowedClusters []NodeMatch `yaml:"allowed-clusters,omitempty"`
+
+	// BindSAN requires the node ID or cluster name of each request to
+	// match a DNS or URI subject alternative name of the client
+	// certificate presented by the Envoy.
+	BindSAN bool `yaml:"bind-san,omitempty"`
+}
+
+// Validate ensures that the allowed node IDs and cluster names are valid.
+func (n NodeAuthorizationParameters) Validate() error {
+	for _, m := range n.AllowedNodes {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("invalid allowed node: %w", err)
+		}
+	}
+
+	for _, m := range n.AllowedClusters {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("invalid allowed cluster: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate ensures that the xDS server type is valid, that the gRPC
+// server limits are not negative and that the node authorization
+// is valid.
+func (s ServerParameters) Validate() error {
+	if err := s.XDSServerType.Validate(); err != nil {
+		return err
+	}
+
+	if s.MaxRecvMsgSize < 0 {
+		return fmt.Errorf("invalid xDS server max receive message size %d", s.MaxRecvMsgSize)
+	}
+
+	if s.KeepaliveMinTime < 0 {
+		return fmt.Errorf("invalid xDS server keepalive minimum time %s", s.KeepaliveMinTime)
+	}
+
+	if s.MaxStreamsPerSecond < 0 {
+		return fmt.Errorf("invalid xDS server max streams per second %d", s.MaxStreamsPerSecond)
+	}
+
+	if s.MaxStreamsBurst < 0 {
+		return fmt.Errorf("invalid xDS server max streams burst %d", s.MaxStreamsBurst)
+	}
+
+	return s.NodeAuthorization.Validate()
 }
 
 // GatewayParameters holds the configuration for Gateway API controllers.
@@ -382,11 +856,14 @@ func (t TimeoutParameters) Validate() error {
 	// package.
 	v := func(str string) error {
 		switch str {
-		case "", "infinity", "infinite":
+		case "", "infinity", "infinite", "disabled":
 			return nil
 		default:
-			_, err := time.ParseDuration(str)
-			return err
+			d, err := time.ParseDuration(str)
+			if err != nil || d < 0 {
+				return errors.New(`must be a duration such as "10s", or "infinity" to disable the timeout`)
+			}
+			return nil
 		}
 	}
 
@@ -417,21 +894,42 @@ func (t TimeoutParameters) Validate() error {
 	return nil
 }
 
+// ValidateHeaderValue returns an error if value is not a valid RFC 7230
+// field-value. Field values can't contain control characters other
+// than horizontal tab, and Envoy rejects any configuration that sets
+// a header to such a value.
+func ValidateHeaderValue(value string) error {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return fmt.Errorf("control character %q at offset %d", c, i)
+		}
+	}
+	return nil
+}
+
 type HeadersPolicy struct {
 	Set    map[string]string `yaml:"set,omitempty"`
 	Remove []string          `yaml:"remove,omitempty"`
 }
 
 func (h HeadersPolicy) Validate() error {
-	for key := range h.Set {
+	for key, val := range h.Set {
 		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
 			return fmt.Errorf("invalid header name %q: %v", key, msgs)
 		}
+		if err := ValidateHeaderValue(val); err != nil {
+			return fmt.Errorf("invalid header %q value: %v", key, err)
+		}
 	}
 	for _, val := range h.Remove {
 		if msgs := validation.IsHTTPHeaderName(val); len(msgs) != 0 {
 			return fmt.Errorf("invalid header name %q: %v", val, msgs)
 		}
+		for key := range h.Set {
+			if strings.EqualFold(key, val) {
+				return fmt.Errorf("header %q is both set and removed", val)
+			}
+		}
 	}
 	return nil
 }
@@ -443,6 +941,16 @@ type PolicyParameters struct {
 
 	// ResponseHeadersPolicy defines the response headers set/removed on all routes
 	ResponseHeadersPolicy HeadersPolicy `yaml:"response-headers,omitempty"`
+
+	// AllowedProtectedHeaders lists the protected request headers, such
+	// as X-Forwarded-For or X-Envoy-*, that HTTPProxy and HTTPRoute
+	// header policies are allowed to set or remove. A trailing "*"
+	// matches any header with that prefix.
+	AllowedProtectedHeaders []string `yaml:"allowed-protected-headers,omitempty"`
+
+	// RetryPolicy, if set, is the retry policy of the HTTPProxy and
+	// Ingress routes that don't configure retries.
+	RetryPolicy *RetryPolicyParameters `yaml:"retry-policy,omitempty"`
 }
 
 // Validate the header parameters.
@@ -453,6 +961,180 @@ func (h PolicyParameters) Validate() error {
 	if err := h.ResponseHeadersPolicy.Validate(); err != nil {
 		return err
 	}
+	if err := h.RetryPolicy.Validate(); err != nil {
+		return err
+	}
+	for _, name := range h.AllowedProtectedHeaders {
+		if msgs := validation.IsHTTPHeaderName(strings.TrimSuffix(name, "*")); len(msgs) != 0 {
+			return fmt.Errorf("invalid allowed protected header %q: %v", name, msgs)
+		}
+	}
+	return nil
+}
+
+// RetryPolicyParameters hold the default retry policy of routes,
+// which retries the requests that fail because an upstream endpoint
+// went away, for example while a Deployment is rolled out. Retries
+// are sent to a different endpoint than the failed attempts.
+type RetryPolicyParameters struct {
+	// RetryOn lists the conditions under which requests are
+	// retried. If not set, requests are retried when the upstream
+	// connection fails, is reset, or refuses the request's stream.
+	RetryOn []string `yaml:"retry-on,omitempty"`
+
+	// RetriableStatusCodes lists the response status codes that
+	// are retried when RetryOn includes retriable-status-codes.
+	RetriableStatusCodes []uint32 `yaml:"retriable-status-codes,omitempty"`
+
+	// NumRetries is the maximum number of retries of a request.
+	// If not set, defaults to 1.
+	NumRetries uint32 `yaml:"num-retries,omitempty"`
+
+	// PerTryTimeout is the timeout of each attempt. If not set,
+	// each attempt may take up to the route's request timeout.
+	PerTryTimeout time.Duration `yaml:"per-try-timeout,omitempty"`
+}
+
+// DefaultRetryOn are the conditions under which requests
+// are retried by the default retry policy.
+var DefaultRetryOn = []string{"reset", "connect-failure", "refused-stream"}
+
+// Validate ensures that the retry conditions and status codes are
+// valid, and that the per try timeout is not negative.
+func (r *RetryPolicyParameters) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	for _, on := range r.RetryOn {
+		switch on {
+		case "5xx", "gateway-error", "reset", "connect-failure", "retriable-4xx",
+			"refused-stream", "retriable-status-codes", "retriable-headers", "cancelled",
+			"deadline-exceeded", "internal", "resource-exhausted", "unavailable":
+		default:
+			return fmt.Errorf("invalid retry policy retry-on value %q", on)
+		}
+	}
+
+	for _, code := range r.RetriableStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid retry policy retriable status code %d", code)
+		}
+	}
+
+	if r.PerTryTimeout < 0 {
+		return fmt.Errorf("invalid retry policy per try timeout %q", r.PerTryTimeout)
+	}
+
+	return nil
+}
+
+// HTTPProxyLabels lists the keys of HTTPProxy labels.
+type HTTPProxyLabels []string
+
+// invalidMetricLabelChars matches the characters of label keys
+// that are replaced with "_" in metric label names.
+var invalidMetricLabelChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// Validate ensures that the keys are valid label keys, and that no
+// two of them have the same metric label name.
+func (l HTTPProxyLabels) Validate() error {
+	seen := map[string]string{}
+	for _, key := range l {
+		if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+			return fmt.Errorf("invalid HTTPProxy label key %q: %v", key, msgs)
+		}
+		name := invalidMetricLabelChars.ReplaceAllString(key, "_")
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("HTTPProxy label keys %q and %q have the same metric label name", other, key)
+		}
+		seen[name] = key
+	}
+	return nil
+}
+
+// RequestIDParameters configures the request IDs that Envoy sends
+// to upstream services.
+type RequestIDParameters struct {
+	// CorrelationHeader names a request header that is set to the
+	// request ID on every request sent to an upstream service, for
+	// services that expect it in a header other than X-Request-Id.
+	// The request ID is the one sent by the client in X-Request-Id,
+	// or else the one generated by Envoy.
+	CorrelationHeader string `yaml:"correlation-header,omitempty"`
+}
+
+// Validate ensures that the correlation header is a valid header
+// name and not X-Request-Id itself.
+func (r RequestIDParameters) Validate() error {
+	if r.CorrelationHeader == "" {
+		return nil
+	}
+	if msgs := validation.IsHTTPHeaderName(r.CorrelationHeader); len(msgs) != 0 {
+		return fmt.Errorf("invalid correlation header %q: %v", r.CorrelationHeader, msgs)
+	}
+	if strings.EqualFold(r.CorrelationHeader, "X-Request-Id") {
+		return fmt.Errorf("invalid correlation header %q: the request ID is always sent in X-Request-Id", r.CorrelationHeader)
+	}
+	return nil
+}
+
+// ExposedServiceParameters configures the virtual hosts that are
+// generated for Services annotated with projectcontour.io/expose.
+type ExposedServiceParameters struct {
+	// DomainSuffix is the domain under which annotated Services are
+	// exposed, each on the virtual host <service>.<namespace>.<suffix>.
+	// If empty, the annotation is ignored.
+	DomainSuffix string `yaml:"domain-suffix,omitempty"`
+}
+
+// Validate ensures that the domain suffix is a valid DNS subdomain.
+func (e ExposedServiceParameters) Validate() error {
+	if e.DomainSuffix == "" {
+		return nil
+	}
+	if msgs := validation.IsDNS1123Subdomain(e.DomainSuffix); len(msgs) != 0 {
+		return fmt.Errorf("invalid exposed services domain suffix %q: %v", e.DomainSuffix, msgs)
+	}
+	return nil
+}
+
+// TapParameters enable the Envoy tap filter for the virtual hosts of
+// HTTPProxies annotated with projectcontour.io/tap-until, which
+// captures their requests and responses to files on the Envoy host
+// until the annotated time.
+type TapParameters struct {
+	// PathPrefix is the prefix of the paths of the files that Envoy
+	// writes the captures to. Each capture is written to the file
+	// <path-prefix>_<id>.json.
+	PathPrefix string `yaml:"path-prefix"`
+
+	// MaxDuration is the longest time ahead of now that the
+	// tap-until annotation may be set to. If not set, defaults
+	// to 1h.
+	MaxDuration time.Duration `yaml:"max-duration,omitempty"`
+
+	// Namespaces restricts taps to the HTTPProxies in the listed
+	// namespaces. If empty, HTTPProxies in any namespace may be
+	// tapped.
+	Namespaces []string `yaml:"namespaces,omitempty"`
+}
+
+// Validate ensures that the tap path prefix is an absolute path
+// and that the maximum duration is not negative.
+func (t *TapParameters) Validate() error {
+	if t == nil {
+		return nil
+	}
+
+	if !strings.HasPrefix(t.PathPrefix, "/") {
+		return fmt.Errorf("invalid tap path prefix %q: must be an absolute path", t.PathPrefix)
+	}
+
+	if t.MaxDuration < 0 {
+		return fmt.Errorf("invalid tap max duration %q", t.MaxDuration)
+	}
+
 	return nil
 }
 
@@ -470,6 +1152,184 @@ type ClusterParameters struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/cluster/v3/cluster.proto.html#envoy-v3-api-enum-config-cluster-v3-cluster-dnslookupfamily
 	// for more information.
 	DNSLookupFamily ClusterDNSFamilyType `yaml:"dns-lookup-family"`
+
+	// Naming selects how Envoy clusters are named. Valid options are
+	// 'hashed' and 'full'. If not set, 'hashed' is used.
+	Naming ClusterNamingType `yaml:"naming,omitempty"`
+
+	// IncludeNotReadyEndpoints sends the addresses of Endpoints that
+	// are not ready to Envoy as UNHEALTHY endpoints, rather than omitting
+	// them. Envoy does not route requests to them, but can use them again
+	// as soon as they become ready.
+	IncludeNotReadyEndpoints bool `yaml:"include-not-ready-endpoints,omitempty"`
+
+	// DrainTerminatingEndpoints watches Pods, and sends the addresses
+	// of Pods that are terminating to Envoy as DRAINING endpoints,
+	// without waiting for them to be removed from their Endpoints.
+	DrainTerminatingEndpoints bool `yaml:"drain-terminating-endpoints,omitempty"`
+
+	// LoadBalancerStrategy is the load balancing strategy of clusters
+	// whose HTTPProxy route or TCPProxy does not set a load balancer
+	// policy, and of Ingress clusters. Valid options are 'RoundRobin',
+	// 'WeightedLeastRequest' and 'Random'. If not set, 'RoundRobin'
+	// is used.
+	LoadBalancerStrategy LoadBalancerStrategyType `yaml:"load-balancer-strategy,omitempty"`
+
+	// HealthCheck enables active health checking of clusters whose
+	// HTTPProxy route or TCPProxy does not set a health check policy,
+	// and of Ingress clusters. If not set, these clusters are not
+	// actively health checked.
+	HealthCheck *HealthCheckParameters `yaml:"health-check,omitempty"`
+
+	// HealthyPanicThreshold is the percentage of healthy endpoints
+	// of a cluster below which Envoy ignores the health of its
+	// endpoints and balances requests across all of them. If not
+	// set, 0 is used, which disables panic mode.
+	HealthyPanicThreshold uint32 `yaml:"healthy-panic-threshold,omitempty"`
+
+	// MaxRequestsPerConnection is the maximum number of requests
+	// Envoy sends over a single upstream connection of clusters
+	// whose service does not set one, either in its HTTPProxy or
+	// with the projectcontour.io/max-requests-per-connection
+	// annotation. If not set, the number is not limited.
+	MaxRequestsPerConnection uint32 `yaml:"max-requests-per-connection,omitempty"`
+
+	// RingHash configures the hash ring of clusters that use the
+	// Cookie or RequestHash load balancer policies. If not set,
+	// Envoy's defaults are used.
+	RingHash *RingHashParameters `yaml:"ring-hash,omitempty"`
+
+	// RemovedClusterLinger is how long Envoy keeps a cluster, with
+	// its endpoints frozen, after it is no longer used, for example
+	// because its Service was deleted. This lets requests that are
+	// still routed to the cluster complete. If not set, clusters are
+	// removed immediately.
+	RemovedClusterLinger time.Duration `yaml:"removed-cluster-linger,omitempty"`
+}
+
+// Validate ensures that the cluster load balancer strategy and
+// health check parameters are valid.
+func (c ClusterParameters) Validate() error {
+	if err := c.DNSLookupFamily.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Naming.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.LoadBalancerStrategy.Validate(); err != nil {
+		return err
+	}
+
+	if c.HealthyPanicThreshold > 100 {
+		return fmt.Errorf("invalid healthy panic threshold %d: must be at most 100", c.HealthyPanicThreshold)
+	}
+
+	if c.RemovedClusterLinger < 0 {
+		return fmt.Errorf("invalid removed cluster linger %s: must not be negative", c.RemovedClusterLinger)
+	}
+
+	if err := c.RingHash.Validate(); err != nil {
+		return err
+	}
+
+	return c.HealthCheck.Validate()
+}
+
+// MaxRingHashSize is the largest hash ring Envoy accepts.
+const MaxRingHashSize = 8388608
+
+// RingHashParameters hold the hash ring settings of Envoy clusters.
+// Envoy instances with the same settings build the same hash ring
+// for the same endpoints, so requests with the same hash reach the
+// same endpoint whichever Envoy they are sent to.
+type RingHashParameters struct {
+	// MinimumRingSize is the minimum number of entries of the
+	// hash ring. If not set, Envoy's default of 1024 is used.
+	MinimumRingSize uint64 `yaml:"minimum-ring-size,omitempty"`
+
+	// MaximumRingSize is the maximum number of entries of the
+	// hash ring. If not set, Envoy's default of 8M is used.
+	MaximumRingSize uint64 `yaml:"maximum-ring-size,omitempty"`
+
+	// HashFunction is the hash function used to place endpoints
+	// on the ring. Valid options are 'xx_hash' and 'murmur_hash_2'.
+	// If not set, 'xx_hash' is used.
+	HashFunction RingHashFunctionType `yaml:"hash-function,omitempty"`
+}
+
+// Validate ensures that the ring sizes and hash function are valid.
+func (r *RingHashParameters) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if r.MaximumRingSize > MaxRingHashSize {
+		return fmt.Errorf("invalid maximum ring size %d: must be at most %d", r.MaximumRingSize, MaxRingHashSize)
+	}
+
+	if r.MaximumRingSize > 0 && r.MinimumRingSize > r.MaximumRingSize {
+		return fmt.Errorf("invalid minimum ring size %d: must be at most the maximum ring size %d", r.MinimumRingSize, r.MaximumRingSize)
+	}
+
+	if r.MaximumRingSize == 0 && r.MinimumRingSize > MaxRingHashSize {
+		return fmt.Errorf("invalid minimum ring size %d: must be at most %d", r.MinimumRingSize, MaxRingHashSize)
+	}
+
+	return r.HashFunction.Validate()
+}
+
+// HealthCheckParameters hold the default active health check policy
+// of Envoy clusters.
+type HealthCheckParameters struct {
+	// Path is the request path of HTTP health checks. Clusters
+	// of TCPProxies are health checked by opening a connection.
+	Path string `yaml:"path"`
+
+	// Host is the Host header of HTTP health checks. If not set,
+	// "contour-envoy-healthcheck" is used.
+	Host string `yaml:"host,omitempty"`
+
+	// Interval is the time between health checks. If not set,
+	// defaults to 10s.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Timeout is the time to wait for a health check response.
+	// If not set, defaults to 2s.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// UnhealthyThreshold is the number of failed health checks
+	// required before a host is marked unhealthy. If not set,
+	// defaults to 3.
+	UnhealthyThreshold uint32 `yaml:"unhealthy-threshold,omitempty"`
+
+	// HealthyThreshold is the number of successful health checks
+	// required before a host is marked healthy. If not set,
+	// defaults to 2.
+	HealthyThreshold uint32 `yaml:"healthy-threshold,omitempty"`
+}
+
+// Validate ensures that the health check path is set and that the
+// health check durations are not negative.
+func (h *HealthCheckParameters) Validate() error {
+	if h == nil {
+		return nil
+	}
+
+	if !strings.HasPrefix(h.Path, "/") {
+		return fmt.Errorf("invalid health check path %q: must start with \"/\"", h.Path)
+	}
+
+	if h.Interval < 0 {
+		return fmt.Errorf("invalid health check interval %q", h.Interval)
+	}
+
+	if h.Timeout < 0 {
+		return fmt.Errorf("invalid health check timeout %q", h.Timeout)
+	}
+
+	return nil
 }
 
 // NetworkParameters hold various configurable network values.
@@ -489,6 +1349,197 @@ type ListenerParameters struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v2/api/v2/listener.proto#envoy-api-msg-listener-connectionbalanceconfig
 	// for more information.
 	ConnectionBalancer string `yaml:"connection-balancer"`
+
+	// HTTPConnectionBalancer and HTTPSConnectionBalancer, if set,
+	// override ConnectionBalancer for the HTTP and HTTPS listeners,
+	// including their additional addresses. Valid options are 'exact'
+	// and 'none', which disables connection balancing.
+	HTTPConnectionBalancer  ConnectionBalancerType `yaml:"http-connection-balancer,omitempty"`
+	HTTPSConnectionBalancer ConnectionBalancerType `yaml:"https-connection-balancer,omitempty"`
+
+	// DrainType. If the value is modify-only, listeners only drain connections
+	// when they are modified or removed by a configuration update, and not
+	// on hot restart or health check failure.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/listener/v3/listener.proto#envoy-v3-api-enum-config-listener-v3-listener-draintype
+	// for more information.
+	DrainType ListenerDrainType `yaml:"drain-type,omitempty"`
+
+	// HTTPDrainType and HTTPSDrainType, if set, override DrainType
+	// for the HTTP and HTTPS listeners, including their additional
+	// addresses. Valid options are 'default' and 'modify-only'.
+	HTTPDrainType  ListenerDrainType `yaml:"http-drain-type,omitempty"`
+	HTTPSDrainType ListenerDrainType `yaml:"https-drain-type,omitempty"`
+
+	// AdditionalAddresses are IP addresses that the HTTP and HTTPS listeners
+	// bind to in addition to their configured address. This allows serving
+	// IPv4 and IPv6 clients from separate addresses on dual-stack clusters.
+	AdditionalAddresses []string `yaml:"additional-addresses,omitempty"`
+
+	// MaxRequestHeadersKB is the maximum size of the request headers in
+	// KiB. Requests with larger headers are rejected. If not set, Envoy's
+	// default of 60 is used.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/filters/network/http_connection_manager/v3/http_connection_manager.proto#envoy-v3-api-field-extensions-filters-network-http-connection-manager-v3-httpconnectionmanager-max-request-headers-kb
+	// for more information.
+	MaxRequestHeadersKB uint32 `yaml:"max-request-headers-kb,omitempty"`
+
+	// MaxRequestHeadersCount is the maximum number of request headers.
+	// Requests with more headers are rejected. If not set, Envoy's default
+	// of 100 is used.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/core/v3/protocol.proto#envoy-v3-api-field-config-core-v3-httpprotocoloptions-max-headers-count
+	// for more information.
+	MaxRequestHeadersCount uint32 `yaml:"max-request-headers-count,omitempty"`
+
+	// DisableNormalizePath disables the RFC 3986 normalization of request
+	// paths, which resolves "." and ".." segments before routing.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/filters/network/http_connection_manager/v3/http_connection_manager.proto#envoy-v3-api-field-extensions-filters-network-http-connection-manager-v3-httpconnectionmanager-normalize-path
+	// for more information.
+	DisableNormalizePath bool `yaml:"disable-normalize-path,omitempty"`
+
+	// DisableMergeSlashes disables merging adjacent slashes in request
+	// paths before routing.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/filters/network/http_connection_manager/v3/http_connection_manager.proto#envoy-v3-api-field-extensions-filters-network-http-connection-manager-v3-httpconnectionmanager-merge-slashes
+	// for more information.
+	DisableMergeSlashes bool `yaml:"disable-merge-slashes,omitempty"`
+
+	// EscapedSlashesAction is the action to take when a request path
+	// contains escaped slashes. Valid options are 'keep-unchanged',
+	// 'reject', 'unescape-and-redirect' and 'unescape-and-forward'.
+	// If not set, Envoy's default, which keeps them unchanged, is used.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/filters/network/http_connection_manager/v3/http_connection_manager.proto#envoy-v3-api-field-extensions-filters-network-http-connection-manager-v3-httpconnectionmanager-path-with-escaped-slashes-action
+	// for more information.
+	EscapedSlashesAction EscapedSlashesActionType `yaml:"escaped-slashes-action,omitempty"`
+
+	// HeadersWithUnderscoresAction is the action to take when a request
+	// header name contains an underscore. Valid options are 'allow',
+	// 'reject' and 'drop'. If not set, headers are allowed.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/core/v3/protocol.proto#envoy-v3-api-field-config-core-v3-httpprotocoloptions-headers-with-underscores-action
+	// for more information.
+	HeadersWithUnderscoresAction HeadersWithUnderscoresActionType `yaml:"headers-with-underscores-action,omitempty"`
+
+	// ServerHeader configures the Server header of the responses
+	// that Envoy sends, unless overridden by a virtual host.
+	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/filters/network/http_connection_manager/v3/http_connection_manager.proto#envoy-v3-api-field-extensions-filters-network-http-connection-manager-v3-httpconnectionmanager-server-header-transformation
+	// for more information.
+	ServerHeader ServerHeaderParameters `yaml:"server-header,omitempty"`
+
+	// Health configures an optional plaintext listener that serves
+	// Envoy's readiness status, for load balancers that probe a
+	// path other than /ready.
+	Health HealthListenerParameters `yaml:"health,omitempty"`
+
+	// HealthVirtualHost is the name of a virtual host on the HTTP
+	// listener that Envoy answers itself with 200 OK on every path,
+	// so that load balancers can health check Envoy on the port that
+	// serves traffic without reaching a tenant application.
+	HealthVirtualHost string `yaml:"health-virtual-host,omitempty"`
+
+	// ConsolidateFilterChains serves the TLS virtual hosts that share
+	// a secret and have no per-virtual host settings from a single
+	// filter chain and route configuration, rather than one of each
+	// per virtual host. This keeps the HTTPS listener small when
+	// there are very many virtual hosts.
+	ConsolidateFilterChains bool `yaml:"consolidate-filter-chains,omitempty"`
+}
+
+// HealthListenerParameters hold the configuration of the optional
+// Envoy health listener.
+type HealthListenerParameters struct {
+	// Address is the IP address the health listener binds to.
+	// If not set, defaults to 0.0.0.0.
+	Address string `yaml:"address,omitempty"`
+
+	// Port is the port the health listener binds to. If not set,
+	// the health listener is disabled.
+	Port int `yaml:"port,omitempty"`
+
+	// Path is the request path that returns Envoy's readiness
+	// status. If not set, defaults to /healthz.
+	Path string `yaml:"path,omitempty"`
+}
+
+// Validate ensures that the health listener address, port and path
+// are valid.
+func (h HealthListenerParameters) Validate() error {
+	if h.Port < 0 || h.Port > 65535 {
+		return fmt.Errorf("invalid health listener port %d", h.Port)
+	}
+
+	if h.Address != "" && net.ParseIP(h.Address) == nil {
+		return fmt.Errorf("invalid health listener address %q", h.Address)
+	}
+
+	if h.Path != "" && !strings.HasPrefix(h.Path, "/") {
+		return fmt.Errorf("invalid health listener path %q: must start with \"/\"", h.Path)
+	}
+
+	return nil
+}
+
+// Validate ensures that the additional listener addresses are
+// unique IP addresses, that the request headers limits are
+// within Envoy's range, that the connection balancer, drain type,
+// header validation, server header and health listener options are
+// valid and that the health virtual host is a valid DNS name.
+func (l ListenerParameters) Validate() error {
+	if err := l.HTTPConnectionBalancer.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.HTTPSConnectionBalancer.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.DrainType.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.HTTPDrainType.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.HTTPSDrainType.Validate(); err != nil {
+		return err
+	}
+
+	if l.MaxRequestHeadersKB > 96 {
+		return fmt.Errorf("invalid max request headers size %d: must be at most 96KiB", l.MaxRequestHeadersKB)
+	}
+
+	if err := l.HeadersWithUnderscoresAction.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.EscapedSlashesAction.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.ServerHeader.Validate(); err != nil {
+		return err
+	}
+
+	if err := l.Health.Validate(); err != nil {
+		return err
+	}
+
+	if l.HealthVirtualHost != "" {
+		if msgs := validation.IsDNS1123Subdomain(l.HealthVirtualHost); len(msgs) != 0 {
+			return fmt.Errorf("invalid health virtual host %q: %v", l.HealthVirtualHost, msgs)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, a := range l.AdditionalAddresses {
+		ip := net.ParseIP(a)
+		if ip == nil {
+			return fmt.Errorf("invalid listener address %q", a)
+		}
+		if seen[ip.String()] {
+			return fmt.Errorf("duplicate listener address %q", a)
+		}
+		seen[ip.String()] = true
+	}
+
+	return nil
 }
 
 // Parameters contains the configuration file parameters for the
@@ -521,6 +1572,21 @@ type Parameters struct {
 	// output when AccessLogFormat is json.
 	AccessLogFields AccessLogFields `yaml:"json-fields,omitempty"`
 
+	// AccessLogHeaders lists the request and response headers whose
+	// values are captured in the access logs of HTTP requests. With
+	// the envoy format, their values are appended to each line, and
+	// with the json format, they are added as fields.
+	AccessLogHeaders AccessLogHeaders `yaml:"accesslog-headers,omitempty"`
+
+	// AccessLogSource adds the kind, namespace and name of the object
+	// that each HTTP request was routed by, and its virtual host, to the
+	// access logs, so that a shared access log can be split per tenant.
+	AccessLogSource bool `yaml:"accesslog-source,omitempty"`
+
+	// TCPProxyAccessLog configures access logging for connections
+	// proxied by a HTTPProxy's TCPProxy.
+	TCPProxyAccessLog TCPProxyAccessLogParameters `yaml:"tcpproxy-accesslog,omitempty"`
+
 	// TLS contains TLS policy parameters.
 	TLS TLSParameters `yaml:"tls,omitempty"`
 
@@ -528,6 +1594,10 @@ type Parameters struct {
 	// permitInsecure field in HTTPProxy.
 	DisablePermitInsecure bool `yaml:"disablePermitInsecure,omitempty"`
 
+	// RequireIngressPolicy denies the virtual hosts and routes of a
+	// namespace unless an IngressPolicy in that namespace allows them.
+	RequireIngressPolicy bool `yaml:"require-ingress-policy,omitempty"`
+
 	// DisableAllowChunkedLength disables the RFC-compliant Envoy behavior to
 	// strip the "Content-Length" header if "Transfer-Encoding: chunked" is
 	// also set. This is an emergency off-switch to revert back to Envoy's
@@ -536,6 +1606,34 @@ type Parameters struct {
 	// See: https://github.com/projectcontour/contour/issues/3221
 	DisableAllowChunkedLength bool `yaml:"disableAllowChunkedLength,omitempty"`
 
+	// EnableTrailers enables the propagation of trailers on HTTP/1
+	// downstream connections, for example for gRPC clients that
+	// reach Envoy through an HTTP/1 intermediary.
+	EnableTrailers bool `yaml:"enableTrailers,omitempty"`
+
+	// EnableRequestDecompression enables decompression of gzip encoded
+	// request bodies before they are forwarded upstream. This allows
+	// gRPC services to be reached through intermediaries that compress
+	// requests.
+	EnableRequestDecompression bool `yaml:"enableRequestDecompression,omitempty"`
+
+	// RouteOrdering selects the order of the routes in a virtual host.
+	// "default" orders path matches textually, and "specificity" orders
+	// them by length. If not set, "default" is used.
+	RouteOrdering RouteOrderingType `yaml:"route-ordering,omitempty"`
+
+	// VirtualHostStats enables per virtual host request statistics.
+	// Envoy emits them under vhost.<name>.vcluster.all, where name is
+	// the virtual host's fully qualified domain name. They are disabled
+	// by default, as they add a set of statistics for every virtual host.
+	VirtualHostStats bool `yaml:"virtual-host-stats,omitempty"`
+
+	// HTTPProxyLabels lists the keys of the HTTPProxy labels, such as
+	// team or cost-center, that are copied into the Envoy metadata of
+	// the routes of each HTTPProxy, and added to the labels of the
+	// contour_httpproxy_routes metric as label_<key>.
+	HTTPProxyLabels HTTPProxyLabels `yaml:"httpproxy-labels,omitempty"`
+
 	// LeaderElection contains leader election parameters.
 	LeaderElection LeaderElectionParameters `yaml:"leaderelection,omitempty"`
 
@@ -546,6 +1644,17 @@ type Parameters struct {
 	// Policy specifies default policy applied if not overridden by the user
 	Policy PolicyParameters `yaml:"policy,omitempty"`
 
+	// RequestID configures the request IDs sent to upstream services.
+	RequestID RequestIDParameters `yaml:"request-id,omitempty"`
+
+	// ExposedServices configures the virtual hosts generated for
+	// Services annotated with projectcontour.io/expose: "true".
+	ExposedServices ExposedServiceParameters `yaml:"exposed-services,omitempty"`
+
+	// Tap enables capturing the traffic of annotated HTTPProxies
+	// with the Envoy tap filter. If nil, taps are disabled.
+	Tap *TapParameters `yaml:"tap,omitempty"`
+
 	// Namespace of the envoy service to inspect for Ingress status details.
 	EnvoyServiceNamespace string `yaml:"envoy-service-namespace,omitempty"`
 
@@ -572,6 +1681,123 @@ type Parameters struct {
 	// RateLimitService optionally holds properties of the Rate Limit Service
 	// to be used for global rate limiting.
 	RateLimitService RateLimitService `yaml:"rateLimitService,omitempty"`
+
+	// Audit configures the audit log of routing changes.
+	Audit AuditParameters `yaml:"audit,omitempty"`
+
+	// Ingress configures how Ingresses and HTTPProxies are selected.
+	Ingress IngressParameters `yaml:"ingress,omitempty"`
+
+	// HTTPSRedirect configures the redirects of HTTP requests to
+	// routes that require TLS.
+	HTTPSRedirect HTTPSRedirectParameters `yaml:"https-redirect,omitempty"`
+
+	// Includes limits the HTTPProxy includes that are followed
+	// from each root HTTPProxy.
+	Includes IncludeParameters `yaml:"includes,omitempty"`
+}
+
+// IncludeParameters holds the limits on the HTTPProxy includes
+// that are followed from each root HTTPProxy, which protect
+// Contour from pathological delegation graphs.
+type IncludeParameters struct {
+	// MaxDepth is the maximum length of a chain of includes
+	// from a root HTTPProxy. If zero, the depth is not limited.
+	MaxDepth int `yaml:"max-depth,omitempty"`
+
+	// MaxIncludes is the maximum number of includes followed
+	// from a root HTTPProxy, counting an HTTPProxy once for
+	// each path that includes it. If zero, the number of
+	// includes is not limited.
+	MaxIncludes int `yaml:"max-includes,omitempty"`
+}
+
+// Validate verifies that the include limits are not negative.
+func (i IncludeParameters) Validate() error {
+	if i.MaxDepth < 0 {
+		return fmt.Errorf("invalid include max depth %d", i.MaxDepth)
+	}
+
+	if i.MaxIncludes < 0 {
+		return fmt.Errorf("invalid include max includes %d", i.MaxIncludes)
+	}
+
+	return nil
+}
+
+// HTTPSRedirectParameters holds the configuration for redirecting
+// HTTP requests to HTTPS when a load balancer in front of Envoy
+// terminates TLS and forwards plaintext requests.
+type HTTPSRedirectParameters struct {
+	// Scheme is the externally visible scheme that requests are
+	// redirected to. Valid values are "https" and "http". If not
+	// set, requests are redirected to https.
+	Scheme string `yaml:"scheme,omitempty"`
+
+	// Port is the externally visible port that requests are
+	// redirected to. If not set, the port of the request's Host
+	// header is kept, unless it is the default port.
+	Port uint32 `yaml:"port,omitempty"`
+
+	// TrustForwardedProto routes the requests that have an
+	// X-Forwarded-Proto: https header, rather than redirecting
+	// them. As Envoy overwrites the header of requests from
+	// untrusted clients, network.num-trusted-hops must be set.
+	TrustForwardedProto bool `yaml:"trust-forwarded-proto,omitempty"`
+}
+
+// Validate verifies that the HTTPS redirect parameters are valid.
+func (h HTTPSRedirectParameters) Validate() error {
+	switch h.Scheme {
+	case "", "https", "http":
+	default:
+		return fmt.Errorf("invalid HTTPS redirect scheme %q", h.Scheme)
+	}
+
+	if h.Port > 65535 {
+		return fmt.Errorf("invalid HTTPS redirect port %d", h.Port)
+	}
+
+	return nil
+}
+
+// IngressParameters holds the configuration for selecting the
+// Ingresses and HTTPProxies that Contour serves.
+type IngressParameters struct {
+	// ClassMatching selects whether Ingresses and HTTPProxies that
+	// have no ingress class set are served. "strict" never serves
+	// them, and "permissive" always serves them. If not set, they
+	// are served only when no ingress class name is configured.
+	ClassMatching IngressClassMatchingType `yaml:"classMatching,omitempty"`
+}
+
+// AuditParameters holds the configuration for the audit log of
+// effective routing changes. The audit log is disabled unless
+// at least one destination is set.
+type AuditParameters struct {
+	// File is the path of a file that audit records are appended
+	// to, one JSON object per line.
+	File string `yaml:"file,omitempty"`
+
+	// Webhook is a http or https URL that batches of audit records
+	// are POSTed to as a JSON array.
+	Webhook string `yaml:"webhook,omitempty"`
+}
+
+// Validate verifies that the audit parameters are valid.
+func (a AuditParameters) Validate() error {
+	if a.Webhook == "" {
+		return nil
+	}
+
+	u, err := url.Parse(a.Webhook)
+	if err != nil {
+		return fmt.Errorf("invalid audit webhook %q: %w", a.Webhook, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid audit webhook %q: must be a http or https URL", a.Webhook)
+	}
+	return nil
 }
 
 // RateLimitService defines properties of a global Rate Limit Service.
@@ -599,11 +1825,11 @@ type RateLimitService struct {
 
 // Validate verifies that the parameter values do not have any syntax errors.
 func (p *Parameters) Validate() error {
-	if err := p.Cluster.DNSLookupFamily.Validate(); err != nil {
+	if err := p.Cluster.Validate(); err != nil {
 		return err
 	}
 
-	if err := p.Server.XDSServerType.Validate(); err != nil {
+	if err := p.Server.Validate(); err != nil {
 		return err
 	}
 
@@ -619,10 +1845,26 @@ func (p *Parameters) Validate() error {
 		return err
 	}
 
+	if err := p.AccessLogHeaders.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.TCPProxyAccessLog.Validate(); err != nil {
+		return err
+	}
+
 	if err := p.TLS.Validate(); err != nil {
 		return err
 	}
 
+	if err := p.Listener.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.RouteOrdering.Validate(); err != nil {
+		return err
+	}
+
 	if err := p.Timeouts.Validate(); err != nil {
 		return err
 	}
@@ -631,12 +1873,44 @@ func (p *Parameters) Validate() error {
 		return err
 	}
 
+	if err := p.RequestID.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.HTTPProxyLabels.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.ExposedServices.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Tap.Validate(); err != nil {
+		return err
+	}
+
 	for _, v := range p.DefaultHTTPVersions {
 		if err := v.Validate(); err != nil {
 			return err
 		}
 	}
 
+	if err := p.Audit.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Ingress.ClassMatching.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.HTTPSRedirect.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Includes.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
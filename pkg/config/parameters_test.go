@@ -112,6 +112,117 @@ func TestValidateClusterDNSFamilyType(t *testing.T) {
 	assert.NoError(t, AutoClusterDNSFamily.Validate())
 	assert.NoError(t, IPv4ClusterDNSFamily.Validate())
 	assert.NoError(t, IPv6ClusterDNSFamily.Validate())
+	assert.NoError(t, AllClusterDNSFamily.Validate())
+}
+
+func TestValidateClusterNamingType(t *testing.T) {
+	assert.Error(t, ClusterNamingType("foo").Validate())
+
+	assert.NoError(t, ClusterNamingType("").Validate())
+	assert.NoError(t, HashedClusterNaming.Validate())
+	assert.NoError(t, FullClusterNaming.Validate())
+}
+
+func TestValidateLoadBalancerStrategyType(t *testing.T) {
+	assert.Error(t, LoadBalancerStrategyType("Cookie").Validate())
+
+	assert.NoError(t, LoadBalancerStrategyType("").Validate())
+	assert.NoError(t, RoundRobinLoadBalancerStrategy.Validate())
+	assert.NoError(t, WeightedLeastRequestLoadBalancerStrategy.Validate())
+	assert.NoError(t, RandomLoadBalancerStrategy.Validate())
+}
+
+func TestValidateHealthCheckParameters(t *testing.T) {
+	assert.NoError(t, (*HealthCheckParameters)(nil).Validate())
+	assert.NoError(t, (&HealthCheckParameters{Path: "/healthz"}).Validate())
+
+	assert.Error(t, (&HealthCheckParameters{}).Validate())
+	assert.Error(t, (&HealthCheckParameters{Path: "healthz"}).Validate())
+	assert.Error(t, (&HealthCheckParameters{Path: "/healthz", Interval: -time.Second}).Validate())
+	assert.Error(t, (&HealthCheckParameters{Path: "/healthz", Timeout: -time.Second}).Validate())
+}
+
+func TestValidateClusterParameters(t *testing.T) {
+	c := Defaults().Cluster
+	c.HealthyPanicThreshold = 100
+	assert.NoError(t, c.Validate())
+
+	c.HealthyPanicThreshold = 101
+	assert.Error(t, c.Validate())
+
+	c = Defaults().Cluster
+	c.RemovedClusterLinger = 30 * time.Second
+	assert.NoError(t, c.Validate())
+
+	c.RemovedClusterLinger = -time.Second
+	assert.Error(t, c.Validate())
+}
+
+func TestValidateRingHashParameters(t *testing.T) {
+	assert.NoError(t, (*RingHashParameters)(nil).Validate())
+	assert.NoError(t, (&RingHashParameters{}).Validate())
+	assert.NoError(t, (&RingHashParameters{MinimumRingSize: 4096, MaximumRingSize: 4096}).Validate())
+	assert.NoError(t, (&RingHashParameters{MinimumRingSize: 4096, HashFunction: MurmurHash2RingHashFunction}).Validate())
+	assert.NoError(t, (&RingHashParameters{HashFunction: XXHashRingHashFunction}).Validate())
+
+	assert.Error(t, (&RingHashParameters{MinimumRingSize: 4096, MaximumRingSize: 1024}).Validate())
+	assert.Error(t, (&RingHashParameters{MaximumRingSize: MaxRingHashSize + 1}).Validate())
+	assert.Error(t, (&RingHashParameters{MinimumRingSize: MaxRingHashSize + 1}).Validate())
+	assert.Error(t, (&RingHashParameters{HashFunction: "md5"}).Validate())
+}
+
+func TestValidateRouteOrderingType(t *testing.T) {
+	assert.Error(t, RouteOrderingType("foo").Validate())
+
+	assert.NoError(t, RouteOrderingType("").Validate())
+	assert.NoError(t, DefaultRouteOrdering.Validate())
+	assert.NoError(t, SpecificityRouteOrdering.Validate())
+}
+
+func TestValidateIngressClassMatchingType(t *testing.T) {
+	assert.Error(t, IngressClassMatchingType("foo").Validate())
+	assert.Error(t, IngressClassMatchingType("Strict").Validate())
+	assert.NoError(t, IngressClassMatchingType("").Validate())
+	assert.NoError(t, StrictIngressClassMatching.Validate())
+	assert.NoError(t, PermissiveIngressClassMatching.Validate())
+}
+
+func TestValidateHTTPSRedirectParameters(t *testing.T) {
+	assert.NoError(t, HTTPSRedirectParameters{}.Validate())
+	assert.NoError(t, HTTPSRedirectParameters{Scheme: "https", Port: 443, TrustForwardedProto: true}.Validate())
+	assert.NoError(t, HTTPSRedirectParameters{Scheme: "http", Port: 8443}.Validate())
+	assert.Error(t, HTTPSRedirectParameters{Scheme: "ftp"}.Validate())
+	assert.Error(t, HTTPSRedirectParameters{Port: 65536}.Validate())
+}
+
+func TestValidateIncludeParameters(t *testing.T) {
+	assert.NoError(t, IncludeParameters{}.Validate())
+	assert.NoError(t, IncludeParameters{MaxDepth: 5, MaxIncludes: 100}.Validate())
+	assert.Error(t, IncludeParameters{MaxDepth: -1}.Validate())
+	assert.Error(t, IncludeParameters{MaxIncludes: -1}.Validate())
+}
+
+func TestValidateRetryPolicyParameters(t *testing.T) {
+	var r *RetryPolicyParameters
+	assert.NoError(t, r.Validate())
+	assert.NoError(t, (&RetryPolicyParameters{}).Validate())
+	assert.NoError(t, (&RetryPolicyParameters{
+		RetryOn:              []string{"reset", "retriable-status-codes"},
+		RetriableStatusCodes: []uint32{503},
+		NumRetries:           2,
+		PerTryTimeout:        time.Second,
+	}).Validate())
+	assert.Error(t, (&RetryPolicyParameters{RetryOn: []string{"sometimes"}}).Validate())
+	assert.Error(t, (&RetryPolicyParameters{RetriableStatusCodes: []uint32{99}}).Validate())
+	assert.Error(t, (&RetryPolicyParameters{RetriableStatusCodes: []uint32{600}}).Validate())
+	assert.Error(t, (&RetryPolicyParameters{PerTryTimeout: -time.Second}).Validate())
+}
+
+func TestValidateHeaderValue(t *testing.T) {
+	assert.NoError(t, ValidateHeaderValue(""))
+	assert.NoError(t, ValidateHeaderValue("text/html;\tcharset=utf-8"))
+	assert.EqualError(t, ValidateHeaderValue("envoy\r\nX-Injected: true"), `control character '\r' at offset 5`)
+	assert.Error(t, ValidateHeaderValue("\x7f"))
 }
 
 func TestValidateHeadersPolicy(t *testing.T) {
@@ -123,6 +234,15 @@ func TestValidateHeadersPolicy(t *testing.T) {
 	assert.Error(t, HeadersPolicy{
 		Remove: []string{"inv@lid-header"},
 	}.Validate())
+	assert.Error(t, HeadersPolicy{
+		Set: map[string]string{
+			"X-Envoy-Host": "envoy\r\nX-Injected: true",
+		},
+	}.Validate())
+	assert.Error(t, HeadersPolicy{
+		Set:    map[string]string{"X-Custom": "value"},
+		Remove: []string{"x-custom"},
+	}.Validate())
 	assert.NoError(t, HeadersPolicy{
 		Set:    map[string]string{},
 		Remove: []string{},
@@ -145,6 +265,18 @@ func TestValidateHeadersPolicy(t *testing.T) {
 	}.Validate())
 }
 
+func TestValidatePolicyParameters(t *testing.T) {
+	assert.NoError(t, PolicyParameters{
+		AllowedProtectedHeaders: []string{"X-Forwarded-For", "X-Envoy-*"},
+	}.Validate())
+	assert.Error(t, PolicyParameters{
+		AllowedProtectedHeaders: []string{"inv@lid-header"},
+	}.Validate())
+	assert.Error(t, PolicyParameters{
+		AllowedProtectedHeaders: []string{"*"},
+	}.Validate())
+}
+
 func TestValidateNamespacedName(t *testing.T) {
 	assert.NoErrorf(t, NamespacedName{}.Validate(), "empty name should be OK")
 	assert.NoError(t, NamespacedName{Name: "name", Namespace: "ns"}.Validate())
@@ -161,6 +293,37 @@ func TestValidateServerType(t *testing.T) {
 	assert.NoError(t, ContourServerType.Validate())
 }
 
+func TestValidateServerParameters(t *testing.T) {
+	assert.NoError(t, ServerParameters{XDSServerType: ContourServerType}.Validate())
+	assert.NoError(t, ServerParameters{
+		XDSServerType:        EnvoyServerType,
+		MaxConcurrentStreams: 1000,
+		MaxRecvMsgSize:       16 << 20,
+		KeepaliveMinTime:     30 * time.Second,
+		MaxStreamsPerSecond:  100,
+		MaxStreamsBurst:      500,
+	}.Validate())
+
+	assert.Error(t, ServerParameters{}.Validate())
+	assert.Error(t, ServerParameters{XDSServerType: ContourServerType, MaxRecvMsgSize: -1}.Validate())
+	assert.Error(t, ServerParameters{XDSServerType: ContourServerType, KeepaliveMinTime: -time.Second}.Validate())
+	assert.Error(t, ServerParameters{XDSServerType: ContourServerType, MaxStreamsPerSecond: -1}.Validate())
+	assert.Error(t, ServerParameters{XDSServerType: ContourServerType, MaxStreamsBurst: -1}.Validate())
+}
+
+func TestValidateNodeAuthorizationParameters(t *testing.T) {
+	assert.NoError(t, NodeAuthorizationParameters{}.Validate())
+	assert.NoError(t, NodeAuthorizationParameters{
+		AllowedNodes:    []NodeMatch{{Exact: "envoy"}, {Prefix: "envoy-"}},
+		AllowedClusters: []NodeMatch{{Exact: "projectcontour"}},
+		BindSAN:         true,
+	}.Validate())
+
+	assert.Error(t, NodeAuthorizationParameters{AllowedNodes: []NodeMatch{{}}}.Validate())
+	assert.Error(t, NodeAuthorizationParameters{AllowedNodes: []NodeMatch{{Exact: "envoy", Prefix: "envoy-"}}}.Validate())
+	assert.Error(t, NodeAuthorizationParameters{AllowedClusters: []NodeMatch{{}}}.Validate())
+}
+
 func TestValidateGatewayParameters(t *testing.T) {
 	// Namespace and controllerName are required if name is passed.
 	gw := &GatewayParameters{Name: "gwname", Namespace: "", ControllerName: ""}
@@ -199,6 +362,161 @@ func TestValidateAccessLogType(t *testing.T) {
 	assert.NoError(t, JSONAccessLog.Validate())
 }
 
+func TestValidateAccessLogHeaders(t *testing.T) {
+	assert.NoError(t, AccessLogHeaders{}.Validate())
+	assert.NoError(t, AccessLogHeaders{Request: []string{"x-tenant-id", ":authority"}, Response: []string{"X-Cache"}}.Validate())
+
+	assert.Error(t, AccessLogHeaders{Request: []string{""}}.Validate())
+	assert.Error(t, AccessLogHeaders{Request: []string{"x tenant"}}.Validate())
+	assert.Error(t, AccessLogHeaders{Response: []string{"x-cache)%"}}.Validate())
+}
+
+func TestValidateHTTPProxyLabels(t *testing.T) {
+	assert.NoError(t, HTTPProxyLabels(nil).Validate())
+	assert.NoError(t, HTTPProxyLabels{"team", "example.com/cost-center"}.Validate())
+
+	assert.Error(t, HTTPProxyLabels{""}.Validate())
+	assert.Error(t, HTTPProxyLabels{"-team"}.Validate())
+	assert.Error(t, HTTPProxyLabels{"cost-center", "cost.center"}.Validate())
+}
+
+func TestValidateRequestIDParameters(t *testing.T) {
+	assert.NoError(t, RequestIDParameters{}.Validate())
+	assert.NoError(t, RequestIDParameters{CorrelationHeader: "X-Correlation-Id"}.Validate())
+
+	assert.Error(t, RequestIDParameters{CorrelationHeader: "x correlation"}.Validate())
+	assert.Error(t, RequestIDParameters{CorrelationHeader: ":authority"}.Validate())
+	assert.Error(t, RequestIDParameters{CorrelationHeader: "x-request-id"}.Validate())
+}
+
+func TestValidateExposedServiceParameters(t *testing.T) {
+	assert.NoError(t, ExposedServiceParameters{}.Validate())
+	assert.NoError(t, ExposedServiceParameters{DomainSuffix: "dev.example.com"}.Validate())
+
+	assert.Error(t, ExposedServiceParameters{DomainSuffix: "*.example.com"}.Validate())
+	assert.Error(t, ExposedServiceParameters{DomainSuffix: "Dev.Example.com"}.Validate())
+	assert.Error(t, ExposedServiceParameters{DomainSuffix: ".example.com"}.Validate())
+}
+
+func TestValidateTapParameters(t *testing.T) {
+	var tap *TapParameters
+	assert.NoError(t, tap.Validate())
+	assert.NoError(t, (&TapParameters{PathPrefix: "/tmp/tap/capture"}).Validate())
+	assert.NoError(t, (&TapParameters{PathPrefix: "/tmp/tap/capture", MaxDuration: 15 * time.Minute}).Validate())
+
+	assert.Error(t, (&TapParameters{}).Validate())
+	assert.Error(t, (&TapParameters{PathPrefix: "tap/capture"}).Validate())
+	assert.Error(t, (&TapParameters{PathPrefix: "/tmp/tap/capture", MaxDuration: -time.Minute}).Validate())
+}
+
+func TestAccessLogHeadersAsFields(t *testing.T) {
+	headers := AccessLogHeaders{
+		Request:  []string{"X-Tenant-ID", ":authority"},
+		Response: []string{"x-cache"},
+	}
+
+	assert.Equal(t, AccessLogFields{
+		"req_x_tenant_id=%REQ(X-Tenant-ID)%",
+		"req_authority=%REQ(:authority)%",
+		"resp_x_cache=%RESP(x-cache)%",
+	}, headers.AsFields())
+	assert.NoError(t, headers.AsFields().Validate())
+}
+
+func TestValidateTCPProxyAccessLog(t *testing.T) {
+	assert.Error(t, TCPProxyAccessLogType("foo").Validate())
+
+	assert.NoError(t, TCPProxyAccessLogType("").Validate())
+	assert.NoError(t, FileTCPProxyAccessLog.Validate())
+	assert.NoError(t, GRPCTCPProxyAccessLog.Validate())
+
+	assert.NoError(t, TCPProxyAccessLogParameters{Type: FileTCPProxyAccessLog}.Validate())
+	assert.NoError(t, TCPProxyAccessLogParameters{Type: GRPCTCPProxyAccessLog, ExtensionService: "projectcontour/als"}.Validate())
+	assert.Error(t, TCPProxyAccessLogParameters{Type: GRPCTCPProxyAccessLog}.Validate())
+}
+
+func TestValidateListenerParameters(t *testing.T) {
+	assert.NoError(t, ListenerParameters{}.Validate())
+	assert.NoError(t, ListenerParameters{AdditionalAddresses: []string{"::"}}.Validate())
+	assert.NoError(t, ListenerParameters{AdditionalAddresses: []string{"10.0.0.1", "fd00::1"}}.Validate())
+
+	assert.Error(t, ListenerParameters{AdditionalAddresses: []string{"localhost"}}.Validate())
+	assert.Error(t, ListenerParameters{AdditionalAddresses: []string{"fd00::1", "fd00:0::1"}}.Validate())
+
+	assert.NoError(t, ListenerParameters{MaxRequestHeadersKB: 96, MaxRequestHeadersCount: 500}.Validate())
+	assert.Error(t, ListenerParameters{MaxRequestHeadersKB: 97}.Validate())
+
+	assert.NoError(t, ListenerParameters{HTTPConnectionBalancer: NoConnectionBalancer, HTTPSConnectionBalancer: ExactConnectionBalancer}.Validate())
+	assert.Error(t, ListenerParameters{HTTPConnectionBalancer: "round-robin"}.Validate())
+	assert.Error(t, ListenerParameters{HTTPSConnectionBalancer: "Exact"}.Validate())
+
+	assert.NoError(t, ListenerParameters{HTTPDrainType: DefaultListenerDrain, HTTPSDrainType: ModifyOnlyListenerDrain}.Validate())
+	assert.Error(t, ListenerParameters{HTTPDrainType: "graceful"}.Validate())
+	assert.NoError(t, ListenerParameters{DrainType: "modify-only"}.Validate())
+	assert.Error(t, ListenerParameters{DrainType: "graceful"}.Validate())
+
+	assert.NoError(t, ListenerParameters{HeadersWithUnderscoresAction: RejectHeadersWithUnderscores}.Validate())
+	assert.Error(t, ListenerParameters{HeadersWithUnderscoresAction: "block"}.Validate())
+	assert.Error(t, ListenerParameters{EscapedSlashesAction: "drop"}.Validate())
+
+	assert.NoError(t, ListenerParameters{Health: HealthListenerParameters{Port: 8090}}.Validate())
+	assert.NoError(t, ListenerParameters{Health: HealthListenerParameters{Address: "::", Port: 8090, Path: "/healthz"}}.Validate())
+	assert.Error(t, ListenerParameters{Health: HealthListenerParameters{Port: 70000}}.Validate())
+	assert.Error(t, ListenerParameters{Health: HealthListenerParameters{Address: "localhost", Port: 8090}}.Validate())
+	assert.Error(t, ListenerParameters{Health: HealthListenerParameters{Port: 8090, Path: "healthz"}}.Validate())
+
+	assert.NoError(t, ListenerParameters{HealthVirtualHost: "lb-health.example.internal"}.Validate())
+	assert.Error(t, ListenerParameters{HealthVirtualHost: "*.example.internal"}.Validate())
+	assert.Error(t, ListenerParameters{HealthVirtualHost: "lb-health.example.internal:80"}.Validate())
+
+	assert.NoError(t, ListenerParameters{ServerHeader: ServerHeaderParameters{Name: "example", Transformation: AppendIfAbsentServerHeader}}.Validate())
+	assert.Error(t, ListenerParameters{ServerHeader: ServerHeaderParameters{Name: "example\n"}}.Validate())
+	assert.Error(t, ListenerParameters{ServerHeader: ServerHeaderParameters{Transformation: "keep"}}.Validate())
+}
+
+func TestValidateServerHeaderTransformationType(t *testing.T) {
+	assert.Error(t, ServerHeaderTransformationType("foo").Validate())
+	assert.Error(t, ServerHeaderTransformationType("OVERWRITE").Validate())
+
+	assert.NoError(t, ServerHeaderTransformationType("").Validate())
+	assert.NoError(t, OverwriteServerHeader.Validate())
+	assert.NoError(t, AppendIfAbsentServerHeader.Validate())
+	assert.NoError(t, PassThroughServerHeader.Validate())
+}
+
+func TestServerHeaderParametersMerge(t *testing.T) {
+	global := ServerHeaderParameters{Name: "global", Transformation: AppendIfAbsentServerHeader}
+
+	assert.Equal(t, global, global.Merge(ServerHeaderParameters{}))
+	assert.Equal(t,
+		ServerHeaderParameters{Name: "override", Transformation: AppendIfAbsentServerHeader},
+		global.Merge(ServerHeaderParameters{Name: "override"}))
+	assert.Equal(t,
+		ServerHeaderParameters{Name: "global", Transformation: PassThroughServerHeader},
+		global.Merge(ServerHeaderParameters{Transformation: PassThroughServerHeader}))
+}
+
+func TestValidateHeadersWithUnderscoresActionType(t *testing.T) {
+	assert.Error(t, HeadersWithUnderscoresActionType("foo").Validate())
+	assert.Error(t, HeadersWithUnderscoresActionType("REJECT").Validate())
+
+	assert.NoError(t, HeadersWithUnderscoresActionType("").Validate())
+	assert.NoError(t, AllowHeadersWithUnderscores.Validate())
+	assert.NoError(t, RejectHeadersWithUnderscores.Validate())
+	assert.NoError(t, DropHeadersWithUnderscores.Validate())
+}
+
+func TestValidateEscapedSlashesActionType(t *testing.T) {
+	assert.Error(t, EscapedSlashesActionType("foo").Validate())
+	assert.Error(t, EscapedSlashesActionType("REJECT_REQUEST").Validate())
+
+	assert.NoError(t, EscapedSlashesActionType("").Validate())
+	assert.NoError(t, KeepUnchangedEscapedSlashes.Validate())
+	assert.NoError(t, RejectEscapedSlashes.Validate())
+	assert.NoError(t, UnescapeAndRedirectEscapedSlashes.Validate())
+	assert.NoError(t, UnescapeAndForwardEscapedSlashes.Validate())
+}
+
 func TestValidateAccessLogFields(t *testing.T) {
 	errorCases := [][]string{
 		{"dog", "cat"},
@@ -214,6 +532,7 @@ func TestValidateAccessLogFields(t *testing.T) {
 		{"invalid=%REQ%"},
 		{"invalid=%TRAILER%"},
 		{"invalid=%RESP%"},
+		{"invalid=%METADATA%"},
 		{"@timestamp", "invalid=%START_TIME(%s.%6f):10%"},
 	}
 
@@ -225,6 +544,7 @@ func TestValidateAccessLogFields(t *testing.T) {
 		{"@timestamp", "method"},
 		{"start_time"},
 		{"@timestamp", "response_duration"},
+		{"requested_server_name", "downstream_tls_version"},
 		{"@timestamp", "duration=%DURATION%.0"},
 		{"@timestamp", "duration=My duration=%DURATION%.0"},
 		{"@timestamp", "duratin=%START_TIME(%s.%6f)%"},
@@ -232,6 +552,7 @@ func TestValidateAccessLogFields(t *testing.T) {
 		{"@timestamp", "content-id=%REQ(X-CONTENT-ID):10%"},
 		{"@timestamp", "length=%RESP(CONTENT-LENGTH):10%"},
 		{"@timestamp", "trailer=%TRAILER(CONTENT-LENGTH):10%"},
+		{"@timestamp", "tenant=%METADATA(ROUTE:io.projectcontour:namespace)%"},
 		{"@timestamp", "duration=my durations are %DURATION%.0 and method is %REQ(:METHOD)%"},
 		{"dog=pug", "cat=black"},
 	}
@@ -276,7 +597,21 @@ func TestValidateTimeoutParams(t *testing.T) {
 	assert.Error(t, TimeoutParameters{MaxConnectionDuration: "boop"}.Validate())
 	assert.Error(t, TimeoutParameters{DelayedCloseTimeout: "bebop"}.Validate())
 	assert.Error(t, TimeoutParameters{ConnectionShutdownGracePeriod: "bong"}.Validate())
+	assert.Error(t, TimeoutParameters{RequestTimeout: "-1s"}.Validate())
+	assert.NoError(t, TimeoutParameters{StreamIdleTimeout: "disabled"}.Validate())
+
+}
+
+func TestValidateAuditParameters(t *testing.T) {
+	assert.NoError(t, AuditParameters{}.Validate())
+	assert.NoError(t, AuditParameters{File: "/var/log/contour/audit.log"}.Validate())
+	assert.NoError(t, AuditParameters{Webhook: "https://audit.example.com/contour"}.Validate())
+	assert.NoError(t, AuditParameters{Webhook: "http://audit.example.com:8080"}.Validate())
 
+	assert.Error(t, AuditParameters{Webhook: "audit.example.com"}.Validate())
+	assert.Error(t, AuditParameters{Webhook: "ftp://audit.example.com"}.Validate())
+	assert.Error(t, AuditParameters{Webhook: "https://"}.Validate())
+	assert.Error(t, AuditParameters{Webhook: "http://audit.example.com/%zz"}.Validate())
 }
 
 func TestTLSParametersValidation(t *testing.T) {
@@ -294,6 +629,37 @@ func TestTLSParametersValidation(t *testing.T) {
 		},
 	}.Validate())
 
+	// Fallback passthrough service validation
+	assert.NoError(t, TLSParameters{
+		FallbackPassthroughService: NamespacedServicePort{
+			Name:      "legacy",
+			Namespace: "default",
+			Port:      8443,
+		},
+	}.Validate())
+	assert.Error(t, TLSParameters{
+		FallbackPassthroughService: NamespacedServicePort{
+			Name:      "legacy",
+			Namespace: "default",
+		},
+	}.Validate())
+	assert.Error(t, TLSParameters{
+		FallbackPassthroughService: NamespacedServicePort{
+			Port: 8443,
+		},
+	}.Validate())
+	assert.Error(t, TLSParameters{
+		FallbackCertificate: NamespacedName{
+			Name:      "fallbacksecret",
+			Namespace: "default",
+		},
+		FallbackPassthroughService: NamespacedServicePort{
+			Name:      "legacy",
+			Namespace: "default",
+			Port:      8443,
+		},
+	}.Validate())
+
 	// Client certificate validation
 	assert.NoError(t, TLSParameters{
 		ClientCertificate: NamespacedName{
@@ -378,6 +744,26 @@ func TestSanitizeCipherSuites(t *testing.T) {
 	}
 }
 
+func TestFIPSCipherSuites(t *testing.T) {
+	defer func(f func() bool) { fipsEnabled = f }(fipsEnabled)
+	fipsEnabled = func() bool { return true }
+
+	assert.Equal(t, []string(DefaultFIPSTLSCiphers), SanitizeCipherSuites(nil))
+	assert.NoError(t, DefaultFIPSTLSCiphers.Validate())
+	assert.NoError(t, TLSCiphers{
+		"ECDHE-ECDSA-AES128-GCM-SHA256",
+		" AES256-SHA ",
+	}.Validate())
+	assert.EqualError(t, TLSCiphers{
+		"ECDHE-ECDSA-AES128-GCM-SHA256",
+		"[ECDHE-RSA-AES128-GCM-SHA256|ECDHE-RSA-CHACHA20-POLY1305]",
+	}.Validate(), "ciphers not FIPS approved: [ECDHE-RSA-AES128-GCM-SHA256|ECDHE-RSA-CHACHA20-POLY1305]")
+	assert.EqualError(t, DefaultTLSCiphers.Validate(), "ciphers not FIPS approved: "+
+		"[ECDHE-ECDSA-AES128-GCM-SHA256|ECDHE-ECDSA-CHACHA20-POLY1305],"+
+		"[ECDHE-RSA-AES128-GCM-SHA256|ECDHE-RSA-CHACHA20-POLY1305]")
+	assert.EqualError(t, TLSCiphers{"NOTAVALIDCIPHER"}.Validate(), "invalid ciphers: NOTAVALIDCIPHER")
+}
+
 func TestConfigFileValidation(t *testing.T) {
 	check := func(yamlIn string) {
 		t.Helper()
@@ -434,6 +820,17 @@ default-http-versions:
 - http/0.9
 `)
 
+	check(`
+cluster:
+  load-balancer-strategy: Cookie
+`)
+
+	check(`
+cluster:
+  health-check:
+    interval: 5s
+`)
+
 }
 
 func TestConfigFileDefaultOverrideImport(t *testing.T) {
@@ -529,4 +926,22 @@ default-http-versions:
 network:
   num-trusted-hops: 1
 `)
+
+	check(func(t *testing.T, conf *Parameters) {
+		assert.Equal(t, WeightedLeastRequestLoadBalancerStrategy, conf.Cluster.LoadBalancerStrategy)
+		assert.Equal(t, &HealthCheckParameters{
+			Path:               "/healthz",
+			Interval:           5 * time.Second,
+			Timeout:            time.Second,
+			UnhealthyThreshold: 5,
+		}, conf.Cluster.HealthCheck)
+	}, `
+cluster:
+  load-balancer-strategy: WeightedLeastRequest
+  health-check:
+    path: /healthz
+    interval: 5s
+    timeout: 1s
+    unhealthy-threshold: 5
+`)
 }